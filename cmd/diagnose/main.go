@@ -2,21 +2,38 @@
 package main
 
 import (
+	"encoding/hex"
+	"flag"
 	"fmt"
 	"os"
+	"path"
+	"strconv"
+	"strings"
 
 	"github.com/robert-malhotra/go-hdf5/hdf5"
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: go run cmd/diagnose/main.go <file.h5>")
+	rawHeader := flag.String("raw-header", "", "dump the raw message framing of the object header at this address (decimal or 0x hex)")
+	scavenge := flag.Bool("scavenge", false, "scan the whole file for object headers instead of walking the group hierarchy")
+	space := flag.Bool("space", false, "report the space taken by metadata, raw data and unaccounted bytes instead of walking the group hierarchy")
+	objPath := flag.String("path", "", "describe only the group, dataset or named datatype at this path instead of walking the group hierarchy")
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		fmt.Println("Usage: go run cmd/diagnose/main.go [-raw-header <addr>] [-scavenge] [-space] [-path <path>] <file.h5>")
 		os.Exit(1)
 	}
 
-	filename := os.Args[1]
+	filename := flag.Arg(0)
 	fmt.Printf("=== Analyzing %s ===\n\n", filename)
 
+	// Scavenging works on files too damaged to open
+	if *scavenge {
+		scavengeFile(filename)
+		return
+	}
+
 	f, err := hdf5.Open(filename)
 	if err != nil {
 		fmt.Printf("ERROR: Failed to open file: %v\n", err)
@@ -25,18 +42,74 @@ func main() {
 	defer f.Close()
 
 	fmt.Printf("Superblock version: %d\n", f.Version())
+	if trailing, err := f.TrailingBytes(); err != nil {
+		fmt.Printf("Trailing bytes: ERROR: %v\n", err)
+	} else if trailing > 0 {
+		fmt.Printf("Trailing bytes: %d after logical EOF (ignored)\n", trailing)
+	}
 	fmt.Println()
 
+	if *rawHeader != "" {
+		addr, err := strconv.ParseUint(*rawHeader, 0, 64)
+		if err != nil {
+			fmt.Printf("ERROR: invalid address %q: %v\n", *rawHeader, err)
+			os.Exit(1)
+		}
+		dumpRawHeader(f, addr)
+		return
+	}
+	if *space {
+		printSpace(f)
+		return
+	}
+	if *objPath != "" {
+		obj, err := f.Open(*objPath)
+		if err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		printObject(obj, "")
+		return
+	}
+
 	// Walk the entire file
-	walkGroup(f.Root(), "", 0)
+	walkFile(f)
 }
 
-func walkGroup(g *hdf5.Group, indent string, depth int) {
-	if depth > 20 {
-		fmt.Printf("%s[MAX DEPTH REACHED]\n", indent)
-		return
+func walkFile(f *hdf5.File) {
+	err := f.Walk(func(p string, obj hdf5.Object) error {
+		printObject(obj, depthIndent(p))
+		return nil
+	}, hdf5.WalkOptions{OnError: func(p string, err error) error {
+		fmt.Printf("%s%q: ERROR opening: %v\n", depthIndent(p), path.Base(p), err)
+		return nil
+	}})
+	if err != nil {
+		fmt.Printf("ERROR walking file: %v\n", err)
 	}
+}
+
+// printObject prints a group, dataset or named datatype.
+func printObject(obj hdf5.Object, indent string) {
+	switch obj.Kind() {
+	case hdf5.ObjectTypeGroup:
+		printGroup(obj.(*hdf5.Group), indent)
+	case hdf5.ObjectTypeDataset:
+		printDataset(obj.(*hdf5.Dataset), indent)
+	case hdf5.ObjectTypeNamedDatatype:
+		printDatatype(obj.(*hdf5.NamedDatatype), indent)
+	}
+}
 
+// depthIndent returns the indentation for an object at path p.
+func depthIndent(p string) string {
+	if p == "/" {
+		return ""
+	}
+	return strings.Repeat("  ", strings.Count(p, "/"))
+}
+
+func printGroup(g *hdf5.Group, indent string) {
 	members, err := g.Members()
 	if err != nil {
 		fmt.Printf("%sERROR getting members: %v\n", indent, err)
@@ -48,28 +121,128 @@ func walkGroup(g *hdf5.Group, indent string, depth int) {
 	fmt.Printf("%s  Members: %d\n", indent, len(members))
 	fmt.Printf("%s  Attrs: %v\n", indent, attrs)
 
-	if len(members) == 0 && len(attrs) == 0 && depth > 0 {
+	if len(members) == 0 && len(attrs) == 0 && g.Path() != "/" {
 		fmt.Printf("%s  [EMPTY - no members or attrs]\n", indent)
 	}
+}
 
-	for _, name := range members {
-		// Try as group first
-		subg, err := g.OpenGroup(name)
-		if err == nil {
-			walkGroup(subg, indent+"  ", depth+1)
-			continue
+func printDataset(ds *hdf5.Dataset, indent string) {
+	fmt.Printf("%sDataset %q:\n", indent, ds.Name())
+	fmt.Printf("%s  Shape: %v\n", indent, ds.Shape())
+	fmt.Printf("%s  Attrs: %v\n", indent, ds.Attrs())
+	fmt.Printf("%s  Layout: %s\n", indent, ds.Layout())
+	if chunks := ds.ChunkShape(); chunks != nil {
+		fmt.Printf("%s  Chunk shape: %v\n", indent, chunks)
+	}
+	if info, err := ds.StorageInfo(); err != nil {
+		fmt.Printf("%s  Storage: ERROR: %v\n", indent, err)
+	} else if info.Layout == hdf5.LayoutChunked {
+		fmt.Printf("%s  Storage: %d bytes in %d chunks, compression ratio %.2f\n", indent, info.StoredBytes, info.Chunks, info.CompressionRatio)
+	} else {
+		fmt.Printf("%s  Storage: %d bytes\n", indent, info.StoredBytes)
+	}
+	if segments, err := ds.ExternalSegments(); err != nil {
+		fmt.Printf("%s  External storage: ERROR: %v\n", indent, err)
+	} else if len(segments) > 0 {
+		fmt.Printf("%s  External storage (not readable):\n", indent)
+		for _, seg := range segments {
+			size := fmt.Sprint(seg.Size)
+			if seg.Unlimited {
+				size = "unlimited"
+			}
+			fmt.Printf("%s    %s offset %d size %s\n", indent, seg.Name, seg.Offset, size)
 		}
+	}
+}
 
-		// Try as dataset
-		ds, err := g.OpenDataset(name)
-		if err == nil {
-			fmt.Printf("%s  Dataset %q:\n", indent, name)
-			fmt.Printf("%s    Shape: %v\n", indent, ds.Shape())
-			fmt.Printf("%s    Attrs: %v\n", indent, ds.Attrs())
-			continue
+func printDatatype(t *hdf5.NamedDatatype, indent string) {
+	fmt.Printf("%sDatatype %q:\n", indent, t.Name())
+	fmt.Printf("%s  Type: %s\n", indent, t.DtypeInfo())
+	fmt.Printf("%s  Attrs: %v\n", indent, t.Attrs())
+}
+
+func dumpRawHeader(f *hdf5.File, addr uint64) {
+	fh, err := f.ForensicHeader(addr)
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Object header at 0x%x: version %d, flags 0x%02x, %d messages\n",
+		fh.Address, fh.Version, fh.Flags, len(fh.Messages))
+	for i, m := range fh.Messages {
+		fmt.Printf("\n[%d] %s (type 0x%04x) at 0x%x, chunk %d\n", i, m.TypeName, m.Type, m.Offset, m.Chunk)
+		fmt.Printf("    size %d, flags 0x%02x, body at 0x%x\n", m.Size, m.Flags, m.DataOffset)
+		fmt.Print(hex.Dump(m.Data))
+	}
+	if fh.FramingError != nil {
+		fmt.Printf("\nFraming unreadable at 0x%x: %v\n", fh.FramingErrorOffset, fh.FramingError)
+	}
+}
+
+func printSpace(f *hdf5.File) {
+	r, err := f.SpaceReport()
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	percent := func(n uint64) float64 {
+		if r.EOF == 0 {
+			return 0
 		}
+		return 100 * float64(n) / float64(r.EOF)
+	}
+	fmt.Printf("File space (EOF %d bytes):\n", r.EOF)
+	for _, row := range []struct {
+		name  string
+		bytes uint64
+	}{
+		{"Superblock", r.Superblock},
+		{"Object headers", r.ObjectHeaders},
+		{"B-trees and chunk indexes", r.BTrees},
+		{"Heaps", r.Heaps},
+		{"Total metadata", r.Metadata()},
+		{"Raw data", r.RawData},
+		{"Unaccounted", r.Unaccounted},
+	} {
+		fmt.Printf("  %-26s %12d  %5.1f%%\n", row.name+":", row.bytes, percent(row.bytes))
+	}
+	if r.Overlap > 0 {
+		fmt.Printf("  WARNING: %d bytes are claimed by more than one structure\n", r.Overlap)
+	}
+}
+
+func scavengeFile(filename string) {
+	file, err := os.Open(filename)
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	defer file.Close()
 
-		fmt.Printf("%s  %q: ERROR opening as group or dataset\n", indent, name)
-		fmt.Printf("%s    Group error: %v\n", indent, err)
+	info, err := file.Stat()
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	objects, err := hdf5.Scavenge(file, info.Size(), hdf5.WithScavengeProgress(func(scanned, total int64) {
+		fmt.Fprintf(os.Stderr, "\rScanned %d of %d bytes (%d%%)", scanned, total, scanned*100/max(total, 1))
+	}))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		fmt.Printf("ERROR: scavenge failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Found %d object headers:\n", len(objects))
+	for _, obj := range objects {
+		if obj.Kind == hdf5.ObjectTypeDataset {
+			fmt.Printf("  0x%x dataset shape %v, class %d, %d-byte elements\n",
+				obj.Address, obj.Shape, obj.DtypeClass, obj.DtypeSize)
+			continue
+		}
+		fmt.Printf("  0x%x %s\n", obj.Address, obj.Kind)
 	}
 }
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/robert-malhotra/go-hdf5/hdf5"
+)
+
+// writeText lists the objects one per line, h5ls style, followed by their
+// attributes and values.
+func writeText(w io.Writer, objects []object) error {
+	width := 0
+	for _, obj := range objects {
+		width = max(width, len(obj.Path))
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, obj := range objects {
+		fmt.Fprintf(bw, "%-*s  %s\n", width, obj.Path, summary(obj))
+		for _, a := range obj.Attributes {
+			value := "ERROR: " + a.Error
+			if a.Error == "" {
+				value = formatAttr(a)
+			}
+			fmt.Fprintf(bw, "    Attribute: %s %s %s = %s\n", a.Name, formatShape(a.Shape, nil), a.Dtype, value)
+		}
+		if obj.Data != nil {
+			data := formatElems(obj.Data, obj.info)
+			if obj.Truncated {
+				data += fmt.Sprintf(", ... (%d more)", obj.elements-uint64(len(obj.Data)))
+			}
+			fmt.Fprintf(bw, "    Data: %s\n", data)
+		}
+		if obj.Error != "" {
+			fmt.Fprintf(bw, "    ERROR: %s\n", obj.Error)
+		}
+	}
+	return bw.Flush()
+}
+
+// summary describes an object after its path: its kind and, for datasets
+// and named datatypes, its shape and datatype.
+func summary(obj object) string {
+	switch obj.Kind {
+	case "group":
+		return "Group"
+	case "dataset":
+		return fmt.Sprintf("Dataset %s %s", formatShape(obj.Shape, obj.maxShape), obj.Dtype)
+	case "datatype":
+		return "Type " + obj.Dtype
+	}
+	return "Unreadable"
+}
+
+// formatShape renders a shape as h5ls does, such as {3, 4} or {SCALAR},
+// adding the maximum dimensions, such as {3/Inf, 4/4}, if any differ.
+func formatShape(shape, maxShape []uint64) string {
+	if shape == nil {
+		return "{SCALAR}"
+	}
+	resizable := !reflect.DeepEqual(shape, maxShape) && len(maxShape) == len(shape)
+	dims := make([]string, len(shape))
+	for i, dim := range shape {
+		dims[i] = strconv.FormatUint(dim, 10)
+		switch {
+		case !resizable:
+		case maxShape[i] == hdf5.Unlimited:
+			dims[i] += "/Inf"
+		default:
+			dims[i] += "/" + strconv.FormatUint(maxShape[i], 10)
+		}
+	}
+	return "{" + strings.Join(dims, ", ") + "}"
+}
+
+// formatAttr renders an attribute's value: scalars as a single value and
+// others as their elements.
+func formatAttr(a attribute) string {
+	v := reflect.ValueOf(a.Value)
+	if a.scalar || v.Kind() != reflect.Slice || v.Type() == reflect.TypeOf([]byte(nil)) {
+		return formatValue(a.Value, a.info)
+	}
+	return "[" + formatElems(elements(a.Value, 0), a.info) + "]"
+}
+
+// formatElems renders elements of type info separated by commas.
+func formatElems(elems []interface{}, info hdf5.DtypeInfo) string {
+	parts := make([]string, len(elems))
+	for i, elem := range elems {
+		parts[i] = formatValue(elem, info)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatValue renders one value of type info: strings quoted, enums by
+// member name, opaque bytes in hex, compounds as {name=value, ...} in
+// member order, and arrays and sequences as [value, ...].
+func formatValue(v interface{}, info hdf5.DtypeInfo) string {
+	switch v := v.(type) {
+	case string:
+		if info.Class == hdf5.ClassEnum {
+			return v
+		}
+		return strconv.Quote(v)
+	case []byte:
+		return "0x" + hex.EncodeToString(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case int64:
+		for _, m := range info.Enum {
+			if m.Value == v {
+				return m.Name
+			}
+		}
+		return strconv.FormatInt(v, 10)
+	case map[string]interface{}:
+		return formatCompound(v, info)
+	case fmt.Stringer:
+		return v.String()
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return fmt.Sprint(v)
+	}
+	elem := info
+	if info.Base != nil && (info.Class == hdf5.ClassArray || info.Class == hdf5.ClassVarLen) {
+		elem = *info.Base
+	}
+	parts := make([]string, rv.Len())
+	for i := range parts {
+		parts[i] = formatValue(rv.Index(i).Interface(), elem)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// formatCompound renders a compound value in the member order of info, or
+// by name if info lists no members.
+func formatCompound(v map[string]interface{}, info hdf5.DtypeInfo) string {
+	members := info.Members
+	if len(members) == 0 {
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			members = append(members, hdf5.DtypeMember{Name: name})
+		}
+	}
+	parts := make([]string, 0, len(members))
+	for _, m := range members {
+		if val, ok := v[m.Name]; ok {
+			parts = append(parts, m.Name+"="+formatValue(val, m.Type))
+		}
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// writeJSON writes the objects as an indented JSON array.
+func writeJSON(w io.Writer, objects []object) error {
+	for i := range objects {
+		obj := &objects[i]
+		for j := range obj.Data {
+			obj.Data[j] = jsonValue(obj.Data[j])
+		}
+		for j := range obj.Attributes {
+			obj.Attributes[j].Value = jsonValue(obj.Attributes[j].Value)
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(objects)
+}
+
+// jsonValue converts a value read from the file into one encoding/json
+// can write: non-finite floats become the strings "NaN", "+Inf" and
+// "-Inf", and opaque bytes a hex string as in the text output.
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return strconv.FormatFloat(v, 'g', -1, 64)
+		}
+		return v
+	case float32:
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return strconv.FormatFloat(float64(v), 'g', -1, 32)
+		}
+		return v
+	case []byte:
+		return "0x" + hex.EncodeToString(v)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for name, val := range v {
+			m[name] = jsonValue(val)
+		}
+		return m
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return v
+	}
+	elems := make([]interface{}, rv.Len())
+	for i := range elems {
+		elems[i] = jsonValue(rv.Index(i).Interface())
+	}
+	return elems
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"reflect"
+
+	"github.com/robert-malhotra/go-hdf5/hdf5"
+)
+
+// object describes one object of the file, as listed and as written to
+// JSON.
+type object struct {
+	Path       string        `json:"path"`
+	Kind       string        `json:"kind,omitempty"` // "group", "dataset" or "datatype"
+	Shape      []uint64      `json:"shape,omitempty"`
+	Dtype      string        `json:"dtype,omitempty"`
+	Attributes []attribute   `json:"attributes,omitempty"`
+	Data       []interface{} `json:"data,omitempty"`
+	Truncated  bool          `json:"truncated,omitempty"` // Data holds only the first values
+	Error      string        `json:"error,omitempty"`
+
+	maxShape []uint64
+	elements uint64         // Number of elements of a dataset
+	info     hdf5.DtypeInfo // For formatting Data
+}
+
+// attribute describes one attribute of an object.
+type attribute struct {
+	Name  string      `json:"name"`
+	Shape []uint64    `json:"shape,omitempty"`
+	Dtype string      `json:"dtype"`
+	Value interface{} `json:"value,omitempty"`
+	Error string      `json:"error,omitempty"`
+
+	scalar bool
+	info   hdf5.DtypeInfo
+}
+
+// attrMapper is implemented by the objects that read all their attributes
+// in one pass.
+type attrMapper interface {
+	AttrNames() ([]string, error)
+	AttrMap() (map[string]interface{}, error)
+}
+
+// inspect opens the file and describes the objects the configuration
+// selects: the root group and its members, the whole hierarchy, or the one
+// dataset to print values of. Objects that cannot be opened or read are
+// described with their error rather than failing the listing.
+func inspect(cfg config) ([]object, error) {
+	f, err := hdf5.Open(cfg.filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if cfg.dump != "" {
+		ds, err := f.OpenDataset(cfg.dump)
+		if err != nil {
+			return nil, err
+		}
+		obj := describe(ds.Path(), ds, cfg.attrs)
+		obj.Data, err = readValues(ds, cfg.limit)
+		if err != nil {
+			obj.Error = err.Error()
+		}
+		obj.Truncated = uint64(len(obj.Data)) < obj.elements && err == nil
+		return []object{obj}, nil
+	}
+
+	objects := []object{}
+	err = f.Walk(func(p string, o hdf5.Object) error {
+		objects = append(objects, describe(p, o, cfg.attrs))
+		if _, ok := o.(*hdf5.Group); ok && p != "/" && !cfg.recursive {
+			return hdf5.SkipGroup
+		}
+		return nil
+	}, hdf5.WalkOptions{OnError: func(p string, err error) error {
+		objects = append(objects, object{Path: p, Error: err.Error()})
+		return nil
+	}})
+	return objects, err
+}
+
+// describe describes the object o found at path p, with its attributes if
+// withAttrs is set.
+func describe(p string, o hdf5.Object, withAttrs bool) object {
+	obj := object{Path: p}
+	switch o := o.(type) {
+	case *hdf5.Group:
+		obj.Kind = "group"
+	case *hdf5.Dataset:
+		obj.Kind = "dataset"
+		obj.Shape, obj.maxShape = o.Shape(), o.MaxShape()
+		obj.elements = o.NumElements()
+		obj.info = o.DtypeInfo()
+		obj.Dtype = obj.info.String()
+	case *hdf5.NamedDatatype:
+		obj.Kind = "datatype"
+		obj.info = o.DtypeInfo()
+		obj.Dtype = obj.info.String()
+	}
+	if withAttrs {
+		attrs, err := attributes(o)
+		if err != nil {
+			obj.Error = err.Error()
+		}
+		obj.Attributes = attrs
+	}
+	return obj
+}
+
+// attributes describes the attributes of o in order, decoding their values
+// with AttrMap where o has it.
+func attributes(o hdf5.Object) ([]attribute, error) {
+	names := o.Attrs()
+	var values map[string]interface{}
+	if m, ok := o.(attrMapper); ok {
+		var err error
+		if names, err = m.AttrNames(); err != nil {
+			return nil, err
+		}
+		if values, err = m.AttrMap(); err != nil {
+			return nil, err
+		}
+	}
+
+	attrs := make([]attribute, 0, len(names))
+	for _, name := range names {
+		a := o.Attr(name)
+		if a == nil {
+			continue
+		}
+		attr := attribute{Name: name, Shape: a.Shape(), scalar: a.IsScalar(), info: a.DtypeInfo()}
+		attr.Dtype = attr.info.String()
+
+		v, ok := values[name]
+		if !ok {
+			var err error
+			if v, err = a.Value(); err != nil {
+				v = hdf5.UnreadableAttr{Err: err}
+			}
+		}
+		if u, ok := v.(hdf5.UnreadableAttr); ok {
+			attr.Error = u.Err.Error()
+		} else {
+			attr.Value = v
+		}
+		attrs = append(attrs, attr)
+	}
+	return attrs, nil
+}
+
+// readValues reads the values of ds in row-major order, one per element,
+// or only the first limit of them if limit is not 0. Integer, float, enum
+// and fixed-length string datasets then have only the rows holding those
+// values read; others are read whole.
+func readValues(ds *hdf5.Dataset, limit int) ([]interface{}, error) {
+	info := ds.DtypeInfo()
+	var dest interface{}
+	partial := true
+	switch info.Class {
+	case hdf5.ClassInteger, hdf5.ClassTime, hdf5.ClassBitfield:
+		if info.Signed || info.Class == hdf5.ClassTime {
+			dest = &[]int64{}
+		} else {
+			dest = &[]uint64{}
+		}
+	case hdf5.ClassFloat:
+		dest = &[]float64{}
+	case hdf5.ClassString, hdf5.ClassEnum:
+		dest = &[]string{}
+	case hdf5.ClassVarLen:
+		partial = false
+		if info.VarLenString {
+			dest = &[]string{}
+		} else {
+			dest = &[]interface{}{}
+		}
+	case hdf5.ClassCompound:
+		partial = false
+		dest = &[]map[string]interface{}{}
+	case hdf5.ClassOpaque:
+		elems, _, err := ds.ReadOpaque()
+		if err != nil {
+			return nil, err
+		}
+		return elements(elems, limit), nil
+	case hdf5.ClassReference:
+		refs, err := ds.ReadReferences()
+		if err != nil {
+			return nil, err
+		}
+		return elements(refs, limit), nil
+	default:
+		partial = false
+		t, err := ds.GoType()
+		if err != nil {
+			return nil, err
+		}
+		dest = reflect.New(reflect.SliceOf(t)).Interface()
+	}
+
+	shape := ds.Shape()
+	if partial && limit > 0 && uint64(limit) < ds.NumElements() && len(shape) > 0 {
+		// Read whole rows, enough of them to hold limit values
+		rowSize := ds.NumElements() / shape[0]
+		start := make([]uint64, len(shape))
+		count := append([]uint64{(uint64(limit) + rowSize - 1) / rowSize}, shape[1:]...)
+		if err := ds.ReadSlice(start, count, dest); err != nil {
+			return nil, err
+		}
+	} else if err := ds.Read(dest); err != nil {
+		return nil, err
+	}
+	return elements(reflect.ValueOf(dest).Elem().Interface(), limit), nil
+}
+
+// elements returns the elements of the slice s, or only the first limit
+// of them if limit is not 0.
+func elements(s interface{}, limit int) []interface{} {
+	v := reflect.ValueOf(s)
+	n := v.Len()
+	if limit > 0 && n > limit {
+		n = limit
+	}
+	elems := make([]interface{}, n)
+	for i := range elems {
+		elems[i] = v.Index(i).Interface()
+	}
+	return elems
+}
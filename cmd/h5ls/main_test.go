@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/hdf5"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// Row is the element type of the fixture's compound dataset.
+type Row struct {
+	ID    int32   `hdf5:"id"`
+	Count uint16  `hdf5:"count"`
+	Value float64 `hdf5:"value"`
+}
+
+// createFixture writes a file with nested groups, a compound dataset and a
+// resizable one.
+func createFixture(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "fixture.h5")
+	f, err := hdf5.Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := f.Root().SetAttr("title", "fixture"); err != nil {
+		t.Fatalf("SetAttr failed: %v", err)
+	}
+	sensors, err := f.Root().CreateGroup("sensors")
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	if _, err := sensors.CreateDataset("temperature", []float64{20.5, 21, 21.5, 22},
+		hdf5.WithAttribute("units", "degC"),
+		hdf5.WithAttribute("range", []float64{-40, 85})); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if _, err := sensors.CreateDataset("log", []int32{1, 2, 3},
+		hdf5.WithChunks(4), hdf5.WithMaxDims(hdf5.Unlimited)); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	rows := []Row{{1, 10, 0.25}, {2, 20, 0.5}, {3, 30, 0.75}}
+	if _, err := f.Root().CreateDataset("table", rows); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return path
+}
+
+func TestGolden(t *testing.T) {
+	fixture := createFixture(t)
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"fixture", []string{fixture}},
+		{"fixture_recursive", []string{"-r", "-a", fixture}},
+		{"fixture_json", []string{"-r", "-a", "-json", fixture}},
+		{"fixture_table", []string{"-d", "/table", fixture}},
+		{"groups", []string{"-r", "../../testdata/groups.h5"}},
+		{"attributes", []string{"-r", "-a", "../../testdata/attributes.h5"}},
+		{"compound_attrs", []string{"-a", "-json", "../../testdata/compound_attrs.h5"}},
+		{"strings", []string{"-d", "/variable", "../../testdata/strings.h5"}},
+		{"chunked", []string{"-d", "/chunked", "-limit", "12", "../../testdata/chunked.h5"}},
+		{"chunked_json", []string{"-d", "/chunked", "-limit", "3", "-json", "../../testdata/chunked.h5"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := tt.args[len(tt.args)-1]
+			if _, err := os.Stat(path); err != nil {
+				t.Skipf("fixture not available: %v", err)
+			}
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, &stdout, &stderr); code != 0 {
+				t.Fatalf("exit status %d: %s", code, stderr.String())
+			}
+			got := stdout.Bytes()
+
+			golden := filepath.Join("testdata", tt.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("reading golden file (run with -update to create): %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("output differs from %s; run with -update and review the diff:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	fixture := createFixture(t)
+	tests := []struct {
+		args []string
+		code int
+		msg  string
+	}{
+		{nil, 2, "Usage"},
+		{[]string{"-limit", "-1", fixture}, 2, "Usage"},
+		{[]string{filepath.Join(t.TempDir(), "missing.h5")}, 1, "h5ls:"},
+		{[]string{"-d", "/sensors/missing", fixture}, 1, "missing"},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := run(tt.args, &stdout, &stderr); code != tt.code || !strings.Contains(stderr.String(), tt.msg) {
+			t.Errorf("run(%q) = %d, %q; want %d mentioning %q", tt.args, code, stderr.String(), tt.code, tt.msg)
+		}
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	enum := hdf5.DtypeInfo{Class: hdf5.ClassEnum, Enum: []hdf5.EnumMember{{Name: "RED", Value: 0}, {Name: "GREEN", Value: 1}}}
+	array := hdf5.DtypeInfo{Class: hdf5.ClassArray, ArrayDims: []int{2}, Base: &hdf5.DtypeInfo{Class: hdf5.ClassString}}
+	point := hdf5.DtypeInfo{Class: hdf5.ClassCompound, Members: []hdf5.DtypeMember{
+		{Name: "y", Type: hdf5.DtypeInfo{Class: hdf5.ClassFloat}},
+		{Name: "color", Type: enum},
+	}}
+	tests := []struct {
+		v    interface{}
+		info hdf5.DtypeInfo
+		want string
+	}{
+		{"a \"b\"", hdf5.DtypeInfo{Class: hdf5.ClassString}, `"a \"b\""`},
+		{int64(1), enum, "GREEN"},
+		{int64(7), enum, "7"},
+		{"RED", enum, "RED"},
+		{[]byte{0xCA, 0xFE}, hdf5.DtypeInfo{Class: hdf5.ClassOpaque}, "0xcafe"},
+		{float32(0.1), hdf5.DtypeInfo{Class: hdf5.ClassFloat}, "0.1"},
+		{math.Inf(-1), hdf5.DtypeInfo{Class: hdf5.ClassFloat}, "-Inf"},
+		{[]string{"x", "y"}, array, `["x", "y"]`},
+		{map[string]interface{}{"color": int64(0), "y": 2.5}, point, "{y=2.5, color=RED}"},
+		{map[string]interface{}{"b": int64(2), "a": "s"}, hdf5.DtypeInfo{}, `{a="s", b=2}`},
+		{hdf5.Reference(0x60), hdf5.DtypeInfo{Class: hdf5.ClassReference}, hdf5.Reference(0x60).String()},
+	}
+	for _, tt := range tests {
+		if got := formatValue(tt.v, tt.info); got != tt.want {
+			t.Errorf("formatValue(%#v) = %s, want %s", tt.v, got, tt.want)
+		}
+	}
+
+	if got := jsonValue([]float64{1, math.NaN()}); !reflect.DeepEqual(got, []interface{}{1.0, "NaN"}) {
+		t.Errorf("jsonValue = %#v, want [1 NaN]", got)
+	}
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"go/format"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/robert-malhotra/go-hdf5/hdf5"
+)
+
+// hdf5Import is the import path of the package the bindings are built on.
+const hdf5Import = "github.com/robert-malhotra/go-hdf5/hdf5"
+
+// generator accumulates the generated source for one file.
+type generator struct {
+	body    bytes.Buffer
+	imports map[string]bool // Standard library imports
+	idents  map[string]bool // Top-level identifiers already used
+	skipped []string        // Objects that could not be bound, with reasons
+	helpers map[string]bool // Shared helpers the generated code needs
+}
+
+// generate returns gofmt'ed Go source binding the datasets and attributes of
+// f. source is the name recorded in the generated header.
+func generate(f *hdf5.File, source, pkg string) ([]byte, error) {
+	g := &generator{
+		imports: make(map[string]bool),
+		idents:  make(map[string]bool),
+		helpers: make(map[string]bool),
+	}
+
+	err := hdf5.Walk(f.Root(), func(path string, obj interface{}, err error) error {
+		if err != nil {
+			g.skip(path, err.Error())
+			return nil
+		}
+		switch o := obj.(type) {
+		case *hdf5.Group:
+			g.attributes(path, o.Attrs(), o.Attr)
+		case *hdf5.Dataset:
+			g.dataset(path, o)
+			g.attributes(path, o.Attrs(), o.Attr)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking %s: %w", source, err)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by hdf5gen from %s; DO NOT EDIT.\n\n", source)
+	fmt.Fprintf(&out, "package %s\n\n", pkg)
+	if len(g.skipped) > 0 {
+		fmt.Fprintf(&out, "// Objects without bindings:\n")
+		for _, s := range g.skipped {
+			fmt.Fprintf(&out, "//   - %s\n", s)
+		}
+		fmt.Fprintln(&out)
+	}
+
+	// A file with nothing bound has no imports at all
+	if g.body.Len() == 0 {
+		return format.Source(out.Bytes())
+	}
+
+	std := make([]string, 0, len(g.imports))
+	for imp := range g.imports {
+		std = append(std, imp)
+	}
+	sort.Strings(std)
+	fmt.Fprintf(&out, "import (\n")
+	for _, imp := range std {
+		fmt.Fprintf(&out, "\t%q\n", imp)
+	}
+	fmt.Fprintf(&out, "\n\t%q\n)\n", hdf5Import)
+
+	g.writeHelpers(&out)
+	out.Write(g.body.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return src, nil
+}
+
+func (g *generator) skip(path, reason string) {
+	g.skipped = append(g.skipped, fmt.Sprintf("%s: %s", path, reason))
+}
+
+// dataset emits Read and ReadSlice functions for a dataset, plus an element
+// struct for compound datasets.
+func (g *generator) dataset(path string, ds *hdf5.Dataset) {
+	info := ds.DtypeInfo()
+	name := g.ident("Read" + exportedName(path))
+	base := strings.TrimPrefix(name, "Read")
+
+	var elemType string
+	var decode func(b string) string
+	switch info.Class {
+	case hdf5.ClassInteger, hdf5.ClassFloat:
+		t, expr, err := g.numeric(info)
+		if err != nil {
+			g.skip(path, err.Error())
+			return
+		}
+		elemType, decode = t, expr
+
+	case hdf5.ClassCompound:
+		fields, err := g.compoundFields(info)
+		if err != nil {
+			g.skip(path, err.Error())
+			return
+		}
+		elemType = g.ident(base)
+		fmt.Fprintf(&g.body, "\n// %s is an element of %s.\n", elemType, path)
+		fmt.Fprintf(&g.body, "type %s struct {\n", elemType)
+		for _, fld := range fields {
+			fmt.Fprintf(&g.body, "\t%s %s // %s\n", fld.name, fld.goType, fld.member)
+		}
+		fmt.Fprintf(&g.body, "}\n")
+		decode = func(b string) string {
+			var parts []string
+			for _, fld := range fields {
+				parts = append(parts, fmt.Sprintf("%s: %s", fld.name, fld.decode(b)))
+			}
+			return fmt.Sprintf("%s{\n%s,\n}", elemType, strings.Join(parts, ",\n"))
+		}
+
+	default:
+		g.skip(path, fmt.Sprintf("datatype class %d is not supported", info.Class))
+		return
+	}
+
+	g.imports["fmt"] = true
+	g.helpers["readRaw"] = true
+	decoder := "decode" + base
+
+	fmt.Fprintf(&g.body, "\n// %s reads all of %s (shape %v).\n", name, path, ds.Shape())
+	fmt.Fprintf(&g.body, "func %s(f *hdf5.File) ([]%s, error) {\n", name, elemType)
+	fmt.Fprintf(&g.body, "\traw, err := readRaw(f, %q, %d, nil, nil)\n", path, info.Size)
+	fmt.Fprintf(&g.body, "\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	fmt.Fprintf(&g.body, "\treturn %s(raw), nil\n}\n", decoder)
+
+	fmt.Fprintf(&g.body, "\n// %sSlice reads the hyperslab of %s at start with extent count.\n", name, path)
+	fmt.Fprintf(&g.body, "func %sSlice(f *hdf5.File, start, count []uint64) ([]%s, error) {\n", name, elemType)
+	fmt.Fprintf(&g.body, "\traw, err := readRaw(f, %q, %d, start, count)\n", path, info.Size)
+	fmt.Fprintf(&g.body, "\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	fmt.Fprintf(&g.body, "\treturn %s(raw), nil\n}\n", decoder)
+
+	fmt.Fprintf(&g.body, "\nfunc %s(raw []byte) []%s {\n", decoder, elemType)
+	fmt.Fprintf(&g.body, "\tout := make([]%s, len(raw)/%d)\n", elemType, info.Size)
+	fmt.Fprintf(&g.body, "\tfor i := range out {\n")
+	fmt.Fprintf(&g.body, "\t\tb := raw[i*%d:]\n", info.Size)
+	fmt.Fprintf(&g.body, "\t\tout[i] = %s\n", decode("b"))
+	fmt.Fprintf(&g.body, "\t}\n\treturn out\n}\n")
+}
+
+// structField is one field of a generated compound element struct.
+type structField struct {
+	name   string // Go field name
+	member string // Compound member name
+	goType string
+	decode func(b string) string
+}
+
+// compoundFields maps the members of a compound type to struct fields.
+// Only numeric members are supported.
+func (g *generator) compoundFields(info hdf5.DtypeInfo) ([]structField, error) {
+	used := make(map[string]bool)
+	var fields []structField
+	for _, m := range info.Members {
+		goType, decode, err := g.numeric(m.Type)
+		if err != nil {
+			return nil, fmt.Errorf("member %q: %w", m.Name, err)
+		}
+		name := exportedName(m.Name)
+		for i := 2; used[name]; i++ {
+			name = exportedName(m.Name) + strconv.Itoa(i)
+		}
+		used[name] = true
+
+		offset := m.Offset
+		fields = append(fields, structField{
+			name:   name,
+			member: m.Name,
+			goType: goType,
+			decode: func(b string) string { return decode(fmt.Sprintf("%s[%d:]", b, offset)) },
+		})
+	}
+	return fields, nil
+}
+
+// numeric returns the Go type of an integer or float type and a function
+// producing the expression that decodes it from the byte slice expression b.
+func (g *generator) numeric(info hdf5.DtypeInfo) (string, func(b string) string, error) {
+	var order string
+	switch info.ByteOrder {
+	case binary.LittleEndian:
+		order = "binary.LittleEndian"
+	case binary.BigEndian:
+		order = "binary.BigEndian"
+	default:
+		return "", nil, fmt.Errorf("unsupported byte order")
+	}
+
+	bits := info.Size * 8
+	switch {
+	case info.Class == hdf5.ClassInteger && info.Size == 1:
+		if info.Signed {
+			return "int8", func(b string) string { return fmt.Sprintf("int8(%s[0])", b) }, nil
+		}
+		return "uint8", func(b string) string { return fmt.Sprintf("%s[0]", b) }, nil
+
+	case info.Class == hdf5.ClassInteger && (info.Size == 2 || info.Size == 4 || info.Size == 8):
+		g.imports["encoding/binary"] = true
+		read := fmt.Sprintf("%s.Uint%d", order, bits)
+		if info.Signed {
+			t := fmt.Sprintf("int%d", bits)
+			return t, func(b string) string { return fmt.Sprintf("%s(%s(%s))", t, read, b) }, nil
+		}
+		return fmt.Sprintf("uint%d", bits), func(b string) string { return fmt.Sprintf("%s(%s)", read, b) }, nil
+
+	case info.Class == hdf5.ClassFloat && (info.Size == 4 || info.Size == 8):
+		g.imports["encoding/binary"] = true
+		g.imports["math"] = true
+		read := fmt.Sprintf("%s.Uint%d", order, bits)
+		return fmt.Sprintf("float%d", bits), func(b string) string {
+			return fmt.Sprintf("math.Float%dfrombits(%s(%s))", bits, read, b)
+		}, nil
+	}
+	return "", nil, fmt.Errorf("%d-byte class %d values are not supported", info.Size, info.Class)
+}
+
+// attrTypePattern matches the attribute value types accessors are generated for.
+var attrTypePattern = regexp.MustCompile(`^(\[\])?(string|bool|u?int(8|16|32|64)?|float(32|64))$`)
+
+// attributes emits one accessor per attribute of the object at path.
+func (g *generator) attributes(path string, names []string, attr func(string) *hdf5.Attribute) {
+	owner := exportedName(path)
+	if path == "/" {
+		owner = "Root"
+	}
+
+	for _, attrName := range names {
+		attrPath := path + "@" + attrName
+		if strings.Contains(attrName, "@") {
+			g.skip(attrPath, "attribute name contains '@'")
+			continue
+		}
+		a := attr(attrName)
+		if a == nil {
+			continue
+		}
+		value, err := a.Value()
+		if err != nil {
+			g.skip(attrPath, err.Error())
+			continue
+		}
+		goType := fmt.Sprintf("%T", value)
+		if !attrTypePattern.MatchString(goType) {
+			g.skip(attrPath, fmt.Sprintf("values of type %s are not supported", goType))
+			continue
+		}
+
+		zero := "nil"
+		switch {
+		case strings.HasPrefix(goType, "[]"):
+		case goType == "string":
+			zero = `""`
+		case goType == "bool":
+			zero = "false"
+		default:
+			zero = "0"
+		}
+
+		g.imports["fmt"] = true
+		name := g.ident("Read" + owner + "Attr" + exportedName(attrName))
+		fmt.Fprintf(&g.body, "\n// %s reads the %q attribute of %s.\n", name, attrName, path)
+		fmt.Fprintf(&g.body, "func %s(f *hdf5.File) (%s, error) {\n", name, goType)
+		fmt.Fprintf(&g.body, "\tv, err := f.ReadAttr(%q)\n", attrPath)
+		fmt.Fprintf(&g.body, "\tif err != nil {\n\t\treturn %s, err\n\t}\n", zero)
+		fmt.Fprintf(&g.body, "\tx, ok := v.(%s)\n", goType)
+		msg := strings.ReplaceAll(attrPath, "%", "%%") + ": got %T, want " + goType
+		fmt.Fprintf(&g.body, "\tif !ok {\n\t\treturn %s, fmt.Errorf(%q, v)\n\t}\n", zero, msg)
+		fmt.Fprintf(&g.body, "\treturn x, nil\n}\n")
+	}
+}
+
+// writeHelpers emits the shared helpers used by the generated functions.
+func (g *generator) writeHelpers(out *bytes.Buffer) {
+	if !g.helpers["readRaw"] {
+		return
+	}
+	out.WriteString(`
+// readRaw reads the raw elements of the dataset at path, all of them if count
+// is nil, after checking that the element size matches the generated code.
+func readRaw(f *hdf5.File, path string, size int, start, count []uint64) ([]byte, error) {
+	ds, err := f.OpenDataset(path)
+	if err != nil {
+		return nil, err
+	}
+	if got := ds.DtypeSize(); got != size {
+		return nil, fmt.Errorf("%s: element size is %d bytes, bindings expect %d", path, got, size)
+	}
+	if count == nil {
+		return ds.ReadRaw()
+	}
+	return ds.ReadSliceRaw(start, count)
+}
+`)
+}
+
+// ident reserves and returns a unique top-level identifier based on name.
+func (g *generator) ident(name string) string {
+	unique := name
+	for i := 2; g.idents[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	g.idents[unique] = true
+	return unique
+}
+
+// initialisms are words written in upper case in Go identifiers.
+var initialisms = map[string]bool{
+	"api": true, "id": true, "json": true, "http": true, "uid": true,
+	"url": true, "utc": true, "uuid": true, "xml": true,
+}
+
+// exportedName turns an HDF5 path or name into an exported Go identifier:
+// "/sensors/temperature_raw" becomes "SensorsTemperatureRaw".
+func exportedName(s string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var b strings.Builder
+	for _, w := range words {
+		if initialisms[strings.ToLower(w)] {
+			b.WriteString(strings.ToUpper(w))
+			continue
+		}
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+
+	name := b.String()
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
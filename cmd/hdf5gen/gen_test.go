@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/hdf5"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// Row is the element type of the fixture's compound dataset.
+type Row struct {
+	ID        int32   `hdf5:"id"`
+	Timestamp int64   `hdf5:"timestamp"`
+	Value     float64 `hdf5:"value"`
+}
+
+// createSchemaFile writes the fixture the bindings are generated from.
+func createSchemaFile(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "schema.h5")
+	f, err := hdf5.Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	sensors, err := f.Root().CreateGroup("sensors")
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	if _, err := sensors.CreateDataset("temperature", []float64{20.5, 21, 21.5, 22},
+		hdf5.WithAttribute("units", "degC"),
+		hdf5.WithAttribute("scale", 0.5)); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if _, err := sensors.CreateDataset("counts", []uint16{1, 2, 3, 4, 5, 6}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	rows := []Row{{1, 1000, 0.25}, {2, 2000, 0.5}, {3, 3000, 0.75}}
+	if _, err := f.Root().CreateDataset("table", rows,
+		hdf5.WithAttribute("ids", []int64{7, 8})); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return path
+}
+
+func generateFile(t *testing.T, path, pkg string) []byte {
+	t.Helper()
+	f, err := hdf5.Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	src, err := generate(f, filepath.Base(path), pkg)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	return src
+}
+
+func TestGenerateGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"schema", createSchemaFile(t)},
+		{"attributes", "../../testdata/attributes.h5"},
+		{"strings", "../../testdata/strings.h5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := os.Stat(tt.path); err != nil {
+				t.Skipf("fixture not available: %v", err)
+			}
+			got := generateFile(t, tt.path, "bindings")
+
+			golden := filepath.Join("testdata", tt.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("reading golden file (run with -update to create): %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("generated code differs from %s; run with -update and review the diff", golden)
+			}
+		})
+	}
+}
+
+func TestExportedName(t *testing.T) {
+	tests := map[string]string{
+		"/sensors/temperature": "SensorsTemperature",
+		"temperature_raw":      "TemperatureRaw",
+		"sensor_id":            "SensorID",
+		"/2d data":             "X2dData",
+		"--":                   "X",
+	}
+	for in, want := range tests {
+		if got := exportedName(in); got != want {
+			t.Errorf("exportedName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+const runMain = `package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/robert-malhotra/go-hdf5/hdf5"
+)
+
+func main() {
+	f, err := hdf5.Open(os.Args[1])
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+
+	temps, err := ReadSensorsTemperature(f)
+	fmt.Println(temps, err)
+	counts, err := ReadSensorsCountsSlice(f, []uint64{2}, []uint64{3})
+	fmt.Println(counts, err)
+	rows, err := ReadTable(f)
+	fmt.Printf("%+v %v\n", rows, err)
+	units, err := ReadSensorsTemperatureAttrUnits(f)
+	fmt.Println(units, err)
+	ids, err := ReadTableAttrIds(f)
+	fmt.Println(ids, err)
+}
+`
+
+// TestGeneratedBindingsRun compiles the generated bindings into a program
+// in a scratch module and reads the fixture through them.
+func TestGeneratedBindingsRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a program")
+	}
+	root, err := filepath.Abs("../..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixture := createSchemaFile(t)
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/bindings\n\ngo 1.25\n\n" +
+			"require github.com/robert-malhotra/go-hdf5 v0.0.0\n\n" +
+			"replace github.com/robert-malhotra/go-hdf5 => " + root + "\n",
+		"main.go":     runMain,
+		"bindings.go": string(generateFile(t, fixture, "main")),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command("go", "run", ".", fixture)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOWORK=off")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("go run failed: %v\n%s", err, out)
+	}
+
+	want := strings.Join([]string{
+		"[20.5 21 21.5 22] <nil>",
+		"[3 4 5] <nil>",
+		"[{ID:1 Timestamp:1000 Value:0.25} {ID:2 Timestamp:2000 Value:0.5} {ID:3 Timestamp:3000 Value:0.75}] <nil>",
+		"degC <nil>",
+		"[7 8] <nil>",
+	}, "\n") + "\n"
+	if string(out) != want {
+		t.Errorf("program output:\n%s\nwant:\n%s", out, want)
+	}
+}
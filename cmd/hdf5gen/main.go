@@ -0,0 +1,56 @@
+// Command hdf5gen generates typed Go accessors for the datasets and
+// attributes of a reference HDF5 file.
+//
+// For every integer, float or compound dataset it emits ReadX and ReadXSlice
+// functions that decode the raw bytes directly, with member offsets and byte
+// order fixed at generation time, plus a struct type per compound dataset.
+// Attributes get ReadXAttrY accessors. Objects whose types cannot be bound
+// are listed in a comment at the top of the output.
+//
+// Usage:
+//
+//	hdf5gen [-pkg name] [-o bindings.go] reference.h5
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/robert-malhotra/go-hdf5/hdf5"
+)
+
+func main() {
+	pkg := flag.String("pkg", "main", "package name of the generated file")
+	output := flag.String("o", "", "output file (default standard output)")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: hdf5gen [-pkg name] [-o bindings.go] reference.h5")
+		os.Exit(2)
+	}
+
+	filename := flag.Arg(0)
+	f, err := hdf5.Open(filename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "hdf5gen: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	src, err := generate(f, filepath.Base(filename), *pkg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "hdf5gen: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *output == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err := os.WriteFile(*output, src, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "hdf5gen: %v\n", err)
+		os.Exit(1)
+	}
+}
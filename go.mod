@@ -1,3 +1,5 @@
 module github.com/robert-malhotra/go-hdf5
 
 go 1.25
+
+require github.com/klauspost/compress v1.20.1
@@ -12,6 +12,7 @@ import (
 type Attribute struct {
 	msg    *message.Attribute
 	reader *binary.Reader // For resolving global heap references
+	path   string         // Attribute path, as JoinAttrPath builds it
 }
 
 // Name returns the attribute name.
@@ -19,7 +20,10 @@ func (a *Attribute) Name() string {
 	return a.msg.Name
 }
 
-// Shape returns the dimensions of the attribute value.
+// Shape returns the dimensions of the attribute value, nil for a scalar.
+// The Read methods return the values of multi-dimensional attributes
+// flattened in row-major order; Shape tells a 2x2 matrix from a vector of
+// four.
 func (a *Attribute) Shape() []uint64 {
 	if a.msg.Dataspace == nil || a.msg.Dataspace.IsScalar() {
 		return nil
@@ -27,6 +31,12 @@ func (a *Attribute) Shape() []uint64 {
 	return a.msg.Dataspace.Dimensions
 }
 
+// Rank returns the number of dimensions of the attribute value, 0 for a
+// scalar.
+func (a *Attribute) Rank() int {
+	return len(a.Shape())
+}
+
 // NumElements returns the total number of elements.
 func (a *Attribute) NumElements() uint64 {
 	if a.msg.Dataspace == nil {
@@ -51,18 +61,37 @@ func (a *Attribute) DtypeClass() message.DatatypeClass {
 	return a.msg.Datatype.Class
 }
 
+// DtypeInfo describes the attribute's datatype.
+func (a *Attribute) DtypeInfo() DtypeInfo {
+	if a.msg.Datatype == nil {
+		return DtypeInfo{}
+	}
+	return newDtypeInfo(a.msg.Datatype)
+}
+
 // Read reads the attribute value into dest.
-// dest should be a pointer to the appropriate type.
+// dest should be a pointer to the appropriate type. Compound values can be
+// read into a struct, or a slice of structs, with fields matched to members
+// as described for Dataset.Read:
+//
+//	var origin struct{ X, Y, Z float64 }
+//	err := attr.Read(&origin)
+//
+// Numeric values are only converted without loss, as by Dataset.Read:
+// reading an int64 attribute into []int32 fails with ErrTypeMismatch.
 func (a *Attribute) Read(dest interface{}) error {
 	if a.msg.Datatype == nil {
-		return fmt.Errorf("attribute has no datatype")
+		return withPath(a.path, fmt.Errorf("attribute has no datatype"))
+	}
+	if a.msg.Data == nil && a.NumElements() != 0 {
+		return withPath(a.path, fmt.Errorf("attribute has no data"))
 	}
-	if a.msg.Data == nil {
-		return fmt.Errorf("attribute has no data")
+	if err := checkDest(a.msg.Datatype, dest); err != nil {
+		return withPath(a.path, err)
 	}
 
 	numElements := a.NumElements()
-	return dtype.ConvertWithReader(a.msg.Datatype, a.msg.Data, numElements, dest, a.reader)
+	return withPath(a.path, dtype.ConvertWithReader(a.msg.Datatype, a.msg.Data, numElements, dest, a.reader))
 }
 
 // ReadFloat64 reads the attribute as float64 values.
@@ -93,6 +122,48 @@ func (a *Attribute) ReadInt32() ([]int32, error) {
 	return result, err
 }
 
+// ReadInt16 reads the attribute as int16 values.
+func (a *Attribute) ReadInt16() ([]int16, error) {
+	var result []int16
+	err := a.Read(&result)
+	return result, err
+}
+
+// ReadInt8 reads the attribute as int8 values.
+func (a *Attribute) ReadInt8() ([]int8, error) {
+	var result []int8
+	err := a.Read(&result)
+	return result, err
+}
+
+// ReadUint64 reads the attribute as uint64 values.
+func (a *Attribute) ReadUint64() ([]uint64, error) {
+	var result []uint64
+	err := a.Read(&result)
+	return result, err
+}
+
+// ReadUint32 reads the attribute as uint32 values.
+func (a *Attribute) ReadUint32() ([]uint32, error) {
+	var result []uint32
+	err := a.Read(&result)
+	return result, err
+}
+
+// ReadUint16 reads the attribute as uint16 values.
+func (a *Attribute) ReadUint16() ([]uint16, error) {
+	var result []uint16
+	err := a.Read(&result)
+	return result, err
+}
+
+// ReadUint8 reads the attribute as uint8 values.
+func (a *Attribute) ReadUint8() ([]uint8, error) {
+	var result []uint8
+	err := a.Read(&result)
+	return result, err
+}
+
 // ReadString reads the attribute as string values.
 func (a *Attribute) ReadString() ([]string, error) {
 	var result []string
@@ -100,6 +171,32 @@ func (a *Attribute) ReadString() ([]string, error) {
 	return result, err
 }
 
+// ReadEnumStrings reads an enum attribute as the names of its members.
+// Stored values that match no member are rendered as decimal numbers.
+func (a *Attribute) ReadEnumStrings() ([]string, error) {
+	if a.DtypeClass() != message.ClassEnum {
+		return nil, withPath(a.path, fmt.Errorf("%w: attribute is not an enum", ErrUnsupported))
+	}
+	var result []string
+	if err := a.Read(&result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ReadOpaque reads an opaque attribute as one byte slice per element,
+// along with the tag describing the contents.
+func (a *Attribute) ReadOpaque() ([][]byte, string, error) {
+	if a.DtypeClass() != message.ClassOpaque {
+		return nil, "", withPath(a.path, fmt.Errorf("%w: attribute is not opaque", ErrUnsupported))
+	}
+	var result [][]byte
+	if err := a.Read(&result); err != nil {
+		return nil, "", err
+	}
+	return result, a.msg.Datatype.OpaqueTag, nil
+}
+
 // ReadScalarInt64 reads a scalar int64 attribute.
 func (a *Attribute) ReadScalarInt64() (int64, error) {
 	vals, err := a.ReadInt64()
@@ -107,7 +204,7 @@ func (a *Attribute) ReadScalarInt64() (int64, error) {
 		return 0, err
 	}
 	if len(vals) == 0 {
-		return 0, fmt.Errorf("no values in attribute")
+		return 0, withPath(a.path, fmt.Errorf("no values in attribute"))
 	}
 	return vals[0], nil
 }
@@ -119,7 +216,7 @@ func (a *Attribute) ReadScalarFloat64() (float64, error) {
 		return 0, err
 	}
 	if len(vals) == 0 {
-		return 0, fmt.Errorf("no values in attribute")
+		return 0, withPath(a.path, fmt.Errorf("no values in attribute"))
 	}
 	return vals[0], nil
 }
@@ -131,7 +228,7 @@ func (a *Attribute) ReadScalarString() (string, error) {
 		return "", err
 	}
 	if len(vals) == 0 {
-		return "", fmt.Errorf("no values in attribute")
+		return "", withPath(a.path, fmt.Errorf("no values in attribute"))
 	}
 	return vals[0], nil
 }
@@ -151,7 +248,7 @@ func (a *Attribute) ReadCompound() ([]map[string]interface{}, error) {
 		if m, ok := v.(map[string]interface{}); ok {
 			maps[i] = m
 		} else {
-			return nil, fmt.Errorf("element %d is not a map: %T", i, v)
+			return nil, withPath(a.path, fmt.Errorf("element %d is not a map: %T", i, v))
 		}
 	}
 	return maps, nil
@@ -165,7 +262,7 @@ func (a *Attribute) ReadScalarCompound() (map[string]interface{}, error) {
 		return nil, err
 	}
 	if len(vals) == 0 {
-		return nil, fmt.Errorf("no values in attribute")
+		return nil, withPath(a.path, fmt.Errorf("no values in attribute"))
 	}
 	return vals[0], nil
 }
@@ -191,6 +288,9 @@ func (a *Attribute) IsArray() bool {
 // Value reads the attribute and returns an auto-typed Go value.
 // Returns appropriate types based on HDF5 datatype:
 //   - Fixed-point (integers): int64 or []int64
+//   - Time: int64 or []int64 seconds since the Unix epoch
+//   - Bitfield: uint64 or []uint64, masked to the bitfield's precision
+//   - Opaque: []byte or [][]byte
 //   - Floating-point: float64 or []float64
 //   - String: string or []string
 //   - Compound: map[string]interface{} or []map[string]interface{}
@@ -200,15 +300,15 @@ func (a *Attribute) IsArray() bool {
 // returns a slice.
 func (a *Attribute) Value() (interface{}, error) {
 	if a.msg.Datatype == nil {
-		return nil, fmt.Errorf("attribute has no datatype")
+		return nil, withPath(a.path, fmt.Errorf("attribute has no datatype"))
 	}
 
 	isScalar := a.IsScalar()
 	class := a.msg.Datatype.Class
 
 	switch class {
-	case message.ClassFixedPoint:
-		if a.msg.Datatype.Signed {
+	case message.ClassFixedPoint, message.ClassTime, message.ClassBitfield:
+		if a.msg.Datatype.Signed || class == message.ClassTime {
 			vals, err := a.ReadInt64()
 			if err != nil {
 				return nil, err
@@ -238,6 +338,16 @@ func (a *Attribute) Value() (interface{}, error) {
 		}
 		return vals, nil
 
+	case message.ClassOpaque:
+		vals, _, err := a.ReadOpaque()
+		if err != nil {
+			return nil, err
+		}
+		if isScalar && len(vals) == 1 {
+			return vals[0], nil
+		}
+		return vals, nil
+
 	case message.ClassString:
 		vals, err := a.ReadString()
 		if err != nil {
@@ -0,0 +1,125 @@
+package hdf5
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/robert-malhotra/go-hdf5/internal/btree"
+	"github.com/robert-malhotra/go-hdf5/internal/fheap"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+const (
+	// Size of the fractal heap IDs in dense attribute index records
+	denseAttrHeapIDSize = 8
+
+	// Message flag marking an attribute stored as a shared message
+	messageFlagShared = 0x02
+)
+
+// attributes returns the attributes of an object: those in its header,
+// followed by any in dense storage. Dense attributes that cannot be read
+// are left out and recorded as a WarnUnreadableAttributes warning, since
+// the callers have no error result.
+func (f *File) attributes(header *object.Header, objPath string) []*message.Attribute {
+	attrs, err := f.readAttributes(header)
+	if err != nil {
+		f.warnings.add(Warning{
+			Kind:      WarnUnreadableAttributes,
+			Path:      objPath,
+			Addresses: []uint64{header.Address},
+			Err:       err,
+		})
+	}
+	return attrs
+}
+
+// readAttributes returns the attributes of an object like attributes, and
+// the error reading dense storage failed with, if any, alongside the
+// attributes in the header.
+func (f *File) readAttributes(header *object.Header) ([]*message.Attribute, error) {
+	var attrs []*message.Attribute
+	seen := make(map[string]bool)
+	for _, msg := range header.GetMessages(message.TypeAttribute) {
+		attr := msg.(*message.Attribute)
+		attrs = append(attrs, attr)
+		seen[attr.Name] = true
+	}
+
+	info := header.AttributeInfo()
+	if info == nil || f.reader.IsUndefinedOffset(info.FractalHeapAddr) {
+		return attrs, nil
+	}
+	dense, err := f.readDenseAttributes(info)
+	if err != nil {
+		return attrs, fmt.Errorf("reading dense attributes: %w", err)
+	}
+	for _, attr := range dense {
+		if !seen[attr.Name] {
+			attrs = append(attrs, attr)
+			seen[attr.Name] = true
+		}
+	}
+	return attrs, nil
+}
+
+// readDenseAttributes reads the attributes an attribute info message points
+// to. They are returned in creation order when the object indexes it, and
+// sorted by name otherwise; the name index itself is ordered by hash.
+func (f *File) readDenseAttributes(info *message.AttributeInfo) ([]*message.Attribute, error) {
+	heap, err := fheap.ReadFractalHeap(f.reader, info.FractalHeapAddr)
+	if err != nil {
+		return nil, structureError("fractal heap", info.FractalHeapAddr, err)
+	}
+
+	indexAddr, wantType := info.NameIndexBTreeAddr, btree.BTreeV2TypeAttrName
+	byCreation := info.IndexesCreationOrder() && !f.reader.IsUndefinedOffset(info.CreationOrderBTreeAddr)
+	if byCreation {
+		indexAddr, wantType = info.CreationOrderBTreeAddr, btree.BTreeV2TypeAttrCreationOrder
+	}
+	typ, records, err := btree.ReadRecordsV2(f.reader, indexAddr)
+	if err != nil {
+		return nil, structureError("attribute index", indexAddr, err)
+	}
+	if typ != wantType {
+		return nil, fmt.Errorf("attribute index has B-tree type %d, expected %d", typ, wantType)
+	}
+
+	// Both record types start with the heap ID, the message flags and the
+	// creation order
+	attrs := make([]*message.Attribute, 0, len(records))
+	for _, rec := range records {
+		if len(rec) < denseAttrHeapIDSize+5 {
+			return nil, fmt.Errorf("attribute index record too short: %d bytes", len(rec))
+		}
+		// A shared attribute is kept in the shared message heap, under the
+		// heap ID in place of its own
+		id := rec[:denseAttrHeapIDSize]
+		var data []byte
+		if flags := rec[denseAttrHeapIDSize]; flags&messageFlagShared != 0 {
+			data, err = f.reader.SharedMessage(uint16(message.TypeAttribute), id)
+		} else {
+			data, err = heap.Object(id)
+		}
+		if err != nil {
+			return nil, err
+		}
+		msg, err := message.Parse(message.TypeAttribute, data, 0, f.reader)
+		if err != nil {
+			return nil, fmt.Errorf("parsing dense attribute: %w", err)
+		}
+		if msg, err = object.ResolveShared(f.reader, msg); err != nil {
+			return nil, fmt.Errorf("parsing dense attribute: %w", err)
+		}
+		attr := msg.(*message.Attribute)
+		attr.CreationOrder = binary.LittleEndian.Uint32(rec[denseAttrHeapIDSize+1:])
+		attrs = append(attrs, attr)
+	}
+
+	if !byCreation {
+		sort.SliceStable(attrs, func(i, j int) bool { return attrs[i].Name < attrs[j].Name })
+	}
+	return attrs, nil
+}
@@ -0,0 +1,473 @@
+package hdf5
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	binpkg "github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// testHeap builds a fractal heap in a file the way the HDF5 library lays
+// one out: objects are packed into direct blocks in heap offset order, and
+// only blocks holding objects are allocated.
+type testHeap struct {
+	t    *testing.T
+	f    *File
+	addr uint64
+
+	idLen         int
+	width         int
+	startSize     uint64
+	maxDirect     uint64
+	maxManaged    int
+	heapBits      int
+	maxDirectRows int
+
+	huge    [][]byte // Records of the huge object B-tree
+	objects []*testHeapObject
+}
+
+// testHeapObject is a managed object waiting to be placed.
+type testHeapObject struct {
+	msg    message.Message
+	id     []byte // Filled in with the heap ID when the heap is written
+	offset uint64
+}
+
+// testHeapBlock is a direct or indirect block of the heap.
+type testHeapBlock struct {
+	offset, size uint64
+	rows         int // Indirect blocks only
+	children     []*testHeapBlock
+	objects      []*testHeapObject
+}
+
+// newTestHeap reserves the header of a heap with the parameters libhdf5
+// uses for dense attributes (idLen 8) or links (idLen 7), except that
+// direct blocks are limited to 1 KiB so small tests reach nested indirect
+// blocks. The heap's address space is sized so managed IDs, which hold a
+// 2-byte length, fit in idLen.
+func newTestHeap(t *testing.T, f *File, idLen int) *testHeap {
+	h := &testHeap{
+		t: t, f: f, idLen: idLen,
+		width: 4, startSize: 512, maxDirect: 1024, maxManaged: 1000, heapBits: 8 * (idLen - 3),
+	}
+	h.maxDirectRows = bitLen(h.maxDirect) - bitLen(h.startSize) + 2
+	h.addr = f.allocate(int64(h.headerSize()))
+	return h
+}
+
+func bitLen(n uint64) int {
+	b := 0
+	for ; n > 1; n >>= 1 {
+		b++
+	}
+	return b
+}
+
+func (h *testHeap) headerSize() int {
+	return 4 + 1 + 2 + 2 + 1 + 4 + 12*8 + 3*8 + 2 + 2 + 2 + 2 + 4
+}
+
+func (h *testHeap) offsetSize() int { return (h.heapBits + 7) / 8 }
+
+// add stores msg in the heap, as a huge object if it is too large to be
+// managed, and returns a pointer to its heap ID, valid once write is done.
+func (h *testHeap) add(msg message.Message) *[]byte {
+	size := msg.(message.Serializable).SerializedSize(h.f.writer)
+	if size > h.maxManaged {
+		addr := h.f.allocate(int64(size))
+		if err := msg.(message.Serializable).Serialize(h.f.writer.At(int64(addr))); err != nil {
+			h.t.Fatalf("writing huge object: %v", err)
+		}
+		key := uint64(len(h.huge) + 1)
+		rec := binary.LittleEndian.AppendUint64(nil, addr)
+		rec = binary.LittleEndian.AppendUint64(rec, uint64(size))
+		h.huge = append(h.huge, binary.LittleEndian.AppendUint64(rec, key))
+		id := append([]byte{0x10}, binary.LittleEndian.AppendUint64(nil, key)[:h.idLen-1]...)
+		return &id
+	}
+	obj := &testHeapObject{msg: msg}
+	h.objects = append(h.objects, obj)
+	return &obj.id
+}
+
+// table lays out the doubling table of an indirect block.
+func (h *testHeap) table(offset uint64, rows int) *testHeapBlock {
+	blk := &testHeapBlock{offset: offset, rows: rows}
+	for row := 0; row < rows; row++ {
+		size := h.startSize
+		if row > 0 {
+			size <<= row - 1
+		}
+		for col := 0; col < h.width; col++ {
+			if row < h.maxDirectRows {
+				blk.children = append(blk.children, &testHeapBlock{offset: offset, size: size})
+			} else {
+				childRows := bitLen(size) - bitLen(h.startSize*uint64(h.width)) + 1
+				blk.children = append(blk.children, h.table(offset, childRows))
+			}
+			offset += size
+		}
+	}
+	blk.size = offset - blk.offset
+	return blk
+}
+
+// directBlocks lists the direct blocks under blk in heap offset order.
+func directBlocks(blk *testHeapBlock) []*testHeapBlock {
+	if blk.rows == 0 {
+		return []*testHeapBlock{blk}
+	}
+	var blocks []*testHeapBlock
+	for _, child := range blk.children {
+		blocks = append(blocks, directBlocks(child)...)
+	}
+	return blocks
+}
+
+// write places the managed objects, writes the blocks holding them and
+// the header, and fills in every heap ID.
+func (h *testHeap) write() {
+	prefix := uint64(4 + 1 + 8 + h.offsetSize())
+	root := h.table(0, 8)
+	blocks := directBlocks(root)
+	b, pos := 0, prefix
+	for _, obj := range h.objects {
+		size := uint64(obj.msg.(message.Serializable).SerializedSize(h.f.writer))
+		for pos+size > blocks[b].size {
+			b, pos = b+1, prefix
+		}
+		obj.offset = blocks[b].offset + pos
+		blocks[b].objects = append(blocks[b].objects, obj)
+		pos += size
+
+		obj.id = append([]byte{0}, binary.LittleEndian.AppendUint64(nil, obj.offset)[:h.offsetSize()]...)
+		obj.id = binary.LittleEndian.AppendUint16(obj.id, uint16(size))
+	}
+
+	rootRows := 0
+	var rootAddr uint64 = math.MaxUint64
+	if len(h.objects) > 0 {
+		last := blocks[b].offset
+		for row, off := 0, uint64(0); ; row++ {
+			size := h.startSize
+			if row > 0 {
+				size <<= row - 1
+			}
+			off += size * uint64(h.width)
+			if last < off {
+				rootRows = row + 1
+				break
+			}
+		}
+		root.rows = rootRows
+		root.children = root.children[:rootRows*h.width]
+		rootAddr = h.writeBlock(root)
+	}
+
+	hdr := []byte("FRHP\x00")
+	hdr = binary.LittleEndian.AppendUint16(hdr, uint16(h.idLen))
+	hdr = binary.LittleEndian.AppendUint16(hdr, 0) // No filters
+	hdr = append(hdr, 0)                           // Flags
+	hdr = binary.LittleEndian.AppendUint32(hdr, uint32(h.maxManaged))
+	hdr = binary.LittleEndian.AppendUint64(hdr, uint64(len(h.huge)+1))
+	hugeAddr := uint64(math.MaxUint64)
+	if len(h.huge) > 0 {
+		hugeAddr = writeTestBTreeV2(h.t, h.f, 1, 24, h.huge)
+	}
+	hdr = binary.LittleEndian.AppendUint64(hdr, hugeAddr)
+	hdr = binary.LittleEndian.AppendUint64(hdr, 0)              // Free space
+	hdr = binary.LittleEndian.AppendUint64(hdr, math.MaxUint64) // Free space manager
+	for i := 0; i < 8; i++ {
+		hdr = binary.LittleEndian.AppendUint64(hdr, 0) // Statistics
+	}
+	hdr = binary.LittleEndian.AppendUint16(hdr, uint16(h.width))
+	hdr = binary.LittleEndian.AppendUint64(hdr, h.startSize)
+	hdr = binary.LittleEndian.AppendUint64(hdr, h.maxDirect)
+	hdr = binary.LittleEndian.AppendUint16(hdr, uint16(h.heapBits))
+	hdr = binary.LittleEndian.AppendUint16(hdr, 1)
+	hdr = binary.LittleEndian.AppendUint64(hdr, rootAddr)
+	hdr = binary.LittleEndian.AppendUint16(hdr, uint16(rootRows))
+	hdr = binary.LittleEndian.AppendUint32(hdr, binpkg.ChecksumLookup3(hdr, 0))
+	if err := h.f.writer.At(int64(h.addr)).WriteBytes(hdr); err != nil {
+		h.t.Fatalf("writing fractal heap header: %v", err)
+	}
+}
+
+// writeBlock writes blk and the blocks under it holding objects, returning
+// its address, or the undefined address if it holds none.
+func (h *testHeap) writeBlock(blk *testHeapBlock) uint64 {
+	header := append([]byte("FHDB\x00"), binary.LittleEndian.AppendUint64(nil, h.addr)...)
+	header = append(header, binary.LittleEndian.AppendUint64(nil, blk.offset)[:h.offsetSize()]...)
+
+	if blk.rows == 0 {
+		if len(blk.objects) == 0 {
+			return math.MaxUint64
+		}
+		addr := h.f.allocate(int64(blk.size))
+		if err := h.f.writer.At(int64(addr)).WriteBytes(header); err != nil {
+			h.t.Fatalf("writing direct block: %v", err)
+		}
+		for _, obj := range blk.objects {
+			w := h.f.writer.At(int64(addr + obj.offset - blk.offset))
+			if err := obj.msg.(message.Serializable).Serialize(w); err != nil {
+				h.t.Fatalf("writing heap object: %v", err)
+			}
+		}
+		return addr
+	}
+
+	copy(header, "FHIB")
+	used := false
+	for _, child := range blk.children {
+		addr := h.writeBlock(child)
+		used = used || addr != math.MaxUint64
+		header = binary.LittleEndian.AppendUint64(header, addr)
+	}
+	if !used {
+		return math.MaxUint64
+	}
+	header = binary.LittleEndian.AppendUint32(header, binpkg.ChecksumLookup3(header, 0))
+	addr := h.f.allocate(int64(len(header)))
+	if err := h.f.writer.At(int64(addr)).WriteBytes(header); err != nil {
+		h.t.Fatalf("writing indirect block: %v", err)
+	}
+	return addr
+}
+
+// writeTestBTreeV2 writes a v2 B-tree of 512-byte nodes holding records,
+// which must be in key order, with one level of internal nodes if they do
+// not fit in one leaf.
+func writeTestBTreeV2(t *testing.T, f *File, typ uint8, recSize int, records [][]byte) uint64 {
+	t.Helper()
+	const nodeSize = 512
+	leafMax := (nodeSize - 10) / recSize
+
+	writeNode := func(sig string, recs [][]byte, pointers []byte) uint64 {
+		node := append([]byte(sig), 0, typ)
+		for _, rec := range recs {
+			node = append(node, rec...)
+		}
+		node = append(node, pointers...)
+		node = binary.LittleEndian.AppendUint32(node, binpkg.ChecksumLookup3(node, 0))
+		addr := f.allocate(nodeSize)
+		if err := f.writer.At(int64(addr)).WriteBytes(node); err != nil {
+			t.Fatalf("writing B-tree node: %v", err)
+		}
+		return addr
+	}
+
+	var rootAddr uint64
+	var rootRecords, depth int
+	if len(records) <= leafMax {
+		rootAddr, rootRecords = writeNode("BTLF", records, nil), len(records)
+	} else {
+		// Fill leaves, taking the record after each as a separator
+		nrecSize := 1
+		if leafMax > 255 {
+			nrecSize = 2
+		}
+		var separators [][]byte
+		var pointers []byte
+		for i := 0; i < len(records); {
+			n := min(leafMax, len(records)-i)
+			addr := writeNode("BTLF", records[i:i+n], nil)
+			pointers = binary.LittleEndian.AppendUint64(pointers, addr)
+			pointers = append(pointers, binary.LittleEndian.AppendUint16(nil, uint16(n))[:nrecSize]...)
+			i += n
+			if i < len(records) {
+				separators = append(separators, records[i])
+				i++
+			}
+		}
+		rootAddr, rootRecords, depth = writeNode("BTIN", separators, pointers), len(separators), 1
+	}
+
+	hdr := []byte("BTHD\x00")
+	hdr = append(hdr, typ)
+	hdr = binary.LittleEndian.AppendUint32(hdr, nodeSize)
+	hdr = binary.LittleEndian.AppendUint16(hdr, uint16(recSize))
+	hdr = binary.LittleEndian.AppendUint16(hdr, uint16(depth))
+	hdr = append(hdr, 100, 40)
+	hdr = binary.LittleEndian.AppendUint64(hdr, rootAddr)
+	hdr = binary.LittleEndian.AppendUint16(hdr, uint16(rootRecords))
+	hdr = binary.LittleEndian.AppendUint64(hdr, uint64(len(records)))
+	hdr = binary.LittleEndian.AppendUint32(hdr, binpkg.ChecksumLookup3(hdr, 0))
+	addr := f.allocate(int64(len(hdr)))
+	if err := f.writer.At(int64(addr)).WriteBytes(hdr); err != nil {
+		t.Fatalf("writing B-tree header: %v", err)
+	}
+	return addr
+}
+
+// writeDenseAttributes stores attrs in dense storage, created in the order
+// given, and returns the attribute info message pointing to it with the
+// given flags: bit 0 tracks creation order, and bit 1 writes a creation
+// order index too.
+func writeDenseAttributes(t *testing.T, f *File, attrs []*message.Attribute, flags uint8) *rawMessage {
+	t.Helper()
+	heap := newTestHeap(t, f, 8)
+	ids := make([]*[]byte, len(attrs))
+	for i, attr := range attrs {
+		ids[i] = heap.add(attr)
+	}
+	heap.write()
+
+	// The name index is ordered by the hash of the name
+	var byName, byOrder [][]byte
+	for i, attr := range attrs {
+		rec := append(append([]byte(nil), *ids[i]...), 0)
+		rec = binary.LittleEndian.AppendUint32(rec, uint32(i))
+		byOrder = append(byOrder, rec)
+		byName = append(byName, binary.LittleEndian.AppendUint32(append([]byte(nil), rec...),
+			binpkg.ChecksumLookup3([]byte(attr.Name), 0)))
+	}
+	sort.Slice(byName, func(i, j int) bool {
+		return binary.LittleEndian.Uint32(byName[i][13:]) < binary.LittleEndian.Uint32(byName[j][13:])
+	})
+
+	body := []byte{0, flags}
+	if flags&0x01 != 0 {
+		body = binary.LittleEndian.AppendUint16(body, uint16(len(attrs)))
+	}
+	body = binary.LittleEndian.AppendUint64(body, heap.addr)
+	body = binary.LittleEndian.AppendUint64(body, writeTestBTreeV2(t, f, 8, 17, byName))
+	if flags&0x02 != 0 {
+		body = binary.LittleEndian.AppendUint64(body, writeTestBTreeV2(t, f, 9, 13, byOrder))
+	}
+	return &rawMessage{message.TypeAttributeInfo, body}
+}
+
+// writeObject writes an object header holding messages, links it from the
+// root group and returns its address.
+func writeObject(t *testing.T, f *File, name string, messages []message.Message) uint64 {
+	t.Helper()
+	addr := f.allocate(int64(object.HeaderSize(f.writer, messages)))
+	if _, err := object.WriteHeader(f.writer.At(int64(addr)), messages); err != nil {
+		t.Fatalf("writing %s header: %v", name, err)
+	}
+	if err := f.Root().addLink(message.NewHardLink(name, addr)); err != nil {
+		t.Fatalf("linking %s: %v", name, err)
+	}
+	return addr
+}
+
+func TestDenseAttributes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dense_attrs.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// 50 attributes of 16 float64 values each fill the root direct blocks
+	// and spill into nested indirect blocks; one more is too large to be
+	// managed and is stored as a huge object
+	f64 := message.NewFloatDatatype(8, message.OrderLE)
+	var attrs []*message.Attribute
+	for i := 0; i < 50; i++ {
+		vals := make([]float64, 16)
+		for j := range vals {
+			vals[j] = float64(i*100 + j)
+		}
+		attrs = append(attrs, message.NewAttribute(fmt.Sprintf("attr_%02d", i), f64,
+			message.NewDataspace([]uint64{16}, nil), float64Bytes(vals...)))
+	}
+	big := make([]float64, 200)
+	for j := range big {
+		big[j] = float64(j)
+	}
+	attrs = append(attrs, message.NewAttribute("big", f64, message.NewDataspace([]uint64{200}, nil), float64Bytes(big...)))
+
+	data := f.allocate(24)
+	if err := f.writer.At(int64(data)).WriteBytes(float64Bytes(1, 2, 3)); err != nil {
+		t.Fatalf("writing data: %v", err)
+	}
+	compact := message.NewScalarAttribute("units", message.NewStringDatatype(2, message.PadNullTerm, message.CharsetASCII), []byte("m\x00"))
+	writeObject(t, f, "dense", append(object.NewDatasetHeader(message.NewDataspace([]uint64{3}, nil), f64,
+		message.NewContiguousLayout(data, 24)), compact, writeDenseAttributes(t, f, attrs, 0)))
+
+	// Creation order is used when it is indexed
+	ordered := []*message.Attribute{attrs[3], attrs[1], attrs[2]}
+	writeObject(t, f, "ordered", append(object.NewEmptyGroupHeader(), writeDenseAttributes(t, f, ordered, 0x03)))
+
+	// Attribute info pointing at something other than a heap
+	broken := binary.LittleEndian.AppendUint64([]byte{0, 0}, data)
+	broken = binary.LittleEndian.AppendUint64(broken, data)
+	writeObject(t, f, "broken", append(object.NewEmptyGroupHeader(), compact,
+		&rawMessage{message.TypeAttributeInfo, broken}))
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	ds, err := f.OpenDataset("dense")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	names := ds.Attrs()
+	if len(names) != 52 || names[0] != "units" || names[1] != "attr_00" || names[50] != "attr_49" || names[51] != "big" {
+		t.Fatalf("Attrs() = %v, want units, attr_00..attr_49, big", names)
+	}
+	for i := 0; i < 50; i++ {
+		attr := ds.Attr(fmt.Sprintf("attr_%02d", i))
+		if attr == nil {
+			t.Fatalf("attr_%02d not found", i)
+		}
+		vals, err := attr.ReadFloat64()
+		if err != nil || len(vals) != 16 || vals[0] != float64(i*100) || vals[15] != float64(i*100+15) {
+			t.Errorf("attr_%02d = %v, %v", i, vals, err)
+		}
+	}
+	if vals, err := ds.Attr("big").ReadFloat64(); err != nil || !reflect.DeepEqual(vals, big) {
+		t.Errorf("big = %v, %v", vals, err)
+	}
+	if units, err := ds.Attr("units").ReadScalarString(); err != nil || units != "m" {
+		t.Errorf("units = %q, %v", units, err)
+	}
+	if len(f.Warnings()) != 0 {
+		t.Errorf("unexpected warnings: %v", f.Warnings())
+	}
+
+	grp, err := f.OpenGroup("ordered")
+	if err != nil {
+		t.Fatalf("OpenGroup failed: %v", err)
+	}
+	if got, want := grp.Attrs(), []string{"attr_03", "attr_01", "attr_02"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Attrs() = %v, want %v", got, want)
+	}
+
+	grp, err = f.OpenGroup("broken")
+	if err != nil {
+		t.Fatalf("OpenGroup failed: %v", err)
+	}
+	if got := grp.Attrs(); !reflect.DeepEqual(got, []string{"units"}) {
+		t.Errorf("Attrs() = %v, want the compact attribute only", got)
+	}
+	if w := f.Warnings(); len(w) != 1 || w[0].Kind != WarnUnreadableAttributes || w[0].Path != "/broken" {
+		t.Errorf("Warnings() = %v, want UnreadableAttributes for /broken", w)
+	}
+	if _, err := f.CollectAttrs([]string{"units"}); err == nil {
+		t.Error("CollectAttrs succeeded with unreadable dense attributes")
+	}
+
+	values, err := f.CollectAttrs([]string{"attr_49"}, CollectDatasetsOnly(), CollectSkipMissing())
+	if err != nil {
+		t.Fatalf("CollectAttrs failed: %v", err)
+	}
+	if vals, ok := values["/dense"]["attr_49"].([]float64); !ok || vals[1] != 4901 {
+		t.Errorf("CollectAttrs /dense = %v", values["/dense"])
+	}
+}
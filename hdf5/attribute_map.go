@@ -0,0 +1,102 @@
+package hdf5
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// UnreadableAttr is the value AttrMap gives an attribute that could not be
+// decoded, such as one of an unsupported datatype. Err is the error
+// Attribute.Value failed with.
+type UnreadableAttr struct {
+	Err error
+}
+
+func (u UnreadableAttr) Error() string {
+	return fmt.Sprintf("unreadable attribute: %v", u.Err)
+}
+
+func (u UnreadableAttr) Unwrap() error {
+	return u.Err
+}
+
+// AttrMap reads every attribute of the group in one pass and returns the
+// values keyed by name, decoded as Attribute.Value decodes them: scalar
+// integers as int64 (uint64 if unsigned) and floats as float64, arrays of
+// them as []int64, []uint64 and []float64, strings as string or []string
+// and compounds as map[string]interface{}. An attribute that cannot be
+// decoded maps to an UnreadableAttr instead of failing the call; an error
+// is only returned if the attributes cannot be listed. See AttrNames for
+// their order.
+func (g *Group) AttrMap() (map[string]interface{}, error) {
+	header, err := g.objectHeader()
+	if err != nil {
+		return nil, err
+	}
+	return g.file.attrMap(header, g.path)
+}
+
+// AttrNames returns the names of all attributes on this group in creation
+// order if the group tracks it, and in storage order otherwise. Unlike
+// Attrs, it fails if attributes in dense storage cannot be read.
+func (g *Group) AttrNames() ([]string, error) {
+	header, err := g.objectHeader()
+	if err != nil {
+		return nil, err
+	}
+	return g.file.attrNames(header, g.path)
+}
+
+// AttrMap reads every attribute of the dataset in one pass and returns the
+// values keyed by name; see Group.AttrMap.
+func (d *Dataset) AttrMap() (map[string]interface{}, error) {
+	return d.file.attrMap(d.header, d.path)
+}
+
+// AttrNames returns the names of all attributes on this dataset in
+// creation order if the dataset tracks it, and in storage order otherwise.
+// Unlike Attrs, it fails if attributes in dense storage cannot be read.
+func (d *Dataset) AttrNames() ([]string, error) {
+	return d.file.attrNames(d.header, d.path)
+}
+
+// attrMap decodes the attributes of an object, reading its header and
+// dense storage once.
+func (f *File) attrMap(header *object.Header, objPath string) (map[string]interface{}, error) {
+	attrs, err := f.readAttributes(header)
+	if err != nil {
+		return nil, withPath(objPath, err)
+	}
+	values := make(map[string]interface{}, len(attrs))
+	for _, msg := range attrs {
+		if _, seen := values[msg.Name]; seen {
+			continue
+		}
+		attr := &Attribute{msg: msg, reader: f.reader, path: JoinAttrPath(objPath, msg.Name)}
+		val, err := attr.Value()
+		if err != nil {
+			val = UnreadableAttr{Err: err}
+		}
+		values[msg.Name] = val
+	}
+	return values, nil
+}
+
+// attrNames lists the attribute names of an object in creation order if
+// it tracks it, and in storage order otherwise.
+func (f *File) attrNames(header *object.Header, objPath string) ([]string, error) {
+	attrs, err := f.readAttributes(header)
+	if err != nil {
+		return nil, withPath(objPath, err)
+	}
+	if tracksAttrCreationOrder(header) {
+		sort.SliceStable(attrs, func(i, j int) bool { return attrs[i].CreationOrder < attrs[j].CreationOrder })
+	}
+	names := make([]string, len(attrs))
+	for i, attr := range attrs {
+		names[i] = attr.Name
+	}
+	return names, nil
+}
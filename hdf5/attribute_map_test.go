@@ -0,0 +1,94 @@
+package hdf5
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+func TestAttrMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "attr_map.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	_, err = f.Root().CreateDataset("data", []int32{1, 2, 3},
+		WithAttribute("count", int64(42)),
+		WithAttribute("scale", 0.5),
+		WithAttribute("units", "m"),
+		WithAttribute("range", []float64{-1, 1}),
+		WithAttribute("ids", []int32{4, 5, 6}),
+		WithAttribute("origin", map[string]interface{}{"x": 1.5, "y": int32(2)}),
+		WithAttribute("broken", int64(0)),
+	)
+	if err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if err := f.Root().SetAttr("version", int64(3)); err != nil {
+		t.Fatalf("SetAttr failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	ds, err := f.OpenDataset("data")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	// Drop the value of one attribute, so that it cannot be decoded
+	for _, msg := range ds.header.GetMessages(message.TypeAttribute) {
+		if attr := msg.(*message.Attribute); attr.Name == "broken" {
+			attr.Data = nil
+		}
+	}
+
+	values, err := ds.AttrMap()
+	if err != nil {
+		t.Fatalf("AttrMap failed: %v", err)
+	}
+	want := map[string]interface{}{
+		"count":  int64(42),
+		"scale":  0.5,
+		"units":  "m",
+		"range":  []float64{-1, 1},
+		"ids":    []int64{4, 5, 6},
+		"origin": map[string]interface{}{"x": 1.5, "y": int32(2)},
+	}
+	for name, w := range want {
+		if got := values[name]; !reflect.DeepEqual(got, w) {
+			t.Errorf("%s = %#v, want %#v", name, got, w)
+		}
+	}
+	unreadable, ok := values["broken"].(UnreadableAttr)
+	if !ok {
+		t.Fatalf("broken = %#v, want an UnreadableAttr", values["broken"])
+	}
+	var perr *ParseError
+	if !errors.As(unreadable, &perr) || perr.Path != "/data@broken" {
+		t.Errorf("broken: got %v, want an error for /data@broken", unreadable.Err)
+	}
+	if len(values) != len(want)+1 {
+		t.Errorf("AttrMap has %d values, want %d", len(values), len(want)+1)
+	}
+
+	names, err := ds.AttrNames()
+	if err != nil {
+		t.Fatalf("AttrNames failed: %v", err)
+	}
+	if !reflect.DeepEqual(names, ds.Attrs()) || len(names) != len(values) {
+		t.Errorf("AttrNames = %v, want %v", names, ds.Attrs())
+	}
+
+	rootValues, err := f.Root().AttrMap()
+	if err != nil || !reflect.DeepEqual(rootValues, map[string]interface{}{"version": int64(3)}) {
+		t.Errorf("root AttrMap = %v, %v", rootValues, err)
+	}
+}
@@ -0,0 +1,98 @@
+package hdf5
+
+import (
+	"fmt"
+
+	binpkg "github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// SetAttr sets an attribute of the dataset, replacing any attribute of the
+// same name. value is converted as by WithAttribute: numbers, strings,
+// slices of them, and structs or maps as compound values.
+//
+// The dataset's object header is updated where it is, so other links to the
+// dataset stay valid. A replacement no larger than the old value overwrites
+// it; anything else is added in free space in the header, or in a new
+// header continuation block. Datasets whose attributes are in dense storage
+// return an error wrapping ErrUnsupported.
+func (d *Dataset) SetAttr(name string, value interface{}) error {
+	if !d.file.writable {
+		return fmt.Errorf("file is not writable")
+	}
+	if name == "" {
+		return fmt.Errorf("attribute name cannot be empty")
+	}
+	attr, err := createAttributeMessage(name, value)
+	if err != nil {
+		return fmt.Errorf("creating attribute %q: %w", name, err)
+	}
+
+	addr := d.headerAddr()
+	r := d.file.headerReader()
+	header, err := object.Read(r, addr)
+	if err != nil {
+		return fmt.Errorf("reading dataset header: %w", err)
+	}
+	if info := header.AttributeInfo(); info != nil && !r.IsUndefinedOffset(info.FractalHeapAddr) {
+		return fmt.Errorf("%w: setting attributes of %s, whose attributes are in dense storage", ErrUnsupported, d.path)
+	}
+	err = object.SetAttribute(r, d.file.writer, addr, attr, d.file.allocate)
+	d.file.headers.invalidate(addr)
+	if err != nil {
+		return fmt.Errorf("writing attribute %q: %w", name, err)
+	}
+
+	// Reread the header so that the new attribute can be read back
+	if header, err = object.Read(r, addr); err != nil {
+		return fmt.Errorf("reading dataset header: %w", err)
+	}
+	d.header = header
+	return nil
+}
+
+// SetAttr sets an attribute of the group, replacing any attribute of the
+// same name. value is converted as by WithAttribute. The group's object
+// header is rewritten with its links and attributes, as when adding links.
+func (g *Group) SetAttr(name string, value interface{}) error {
+	if !g.file.writable {
+		return fmt.Errorf("file is not writable")
+	}
+	if name == "" {
+		return fmt.Errorf("attribute name cannot be empty")
+	}
+	attr, err := createAttributeMessage(name, value)
+	if err != nil {
+		return fmt.Errorf("creating attribute %q: %w", name, err)
+	}
+
+	if g.pendingLinks == nil {
+		if err := g.loadExistingLinks(); err != nil {
+			return fmt.Errorf("loading existing links: %w", err)
+		}
+	}
+	replaced := false
+	for i, old := range g.pendingAttrs {
+		if old.Name == name {
+			g.pendingAttrs[i], replaced = attr, true
+			break
+		}
+	}
+	if !replaced {
+		g.pendingAttrs = append(g.pendingAttrs, attr)
+	}
+	return g.rewriteHeader()
+}
+
+// headerReader returns a reader of the file's object headers. Files made
+// with Create have no reader of their own.
+func (f *File) headerReader() *binpkg.Reader {
+	if f.reader != nil {
+		return f.reader
+	}
+	return binpkg.NewReader(f.file, binpkg.Config{
+		ByteOrder:  f.writer.ByteOrder(),
+		OffsetSize: f.writer.OffsetSize(),
+		LengthSize: f.writer.LengthSize(),
+	})
+}
@@ -1,9 +1,14 @@
 package hdf5
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
 )
 
 func TestCreateDatasetWithScalarAttribute(t *testing.T) {
@@ -339,3 +344,332 @@ func TestCreateDatasetWithStringArrayAttribute(t *testing.T) {
 		}
 	}
 }
+
+// acquisition is a compound attribute value with a nested struct and an array member.
+type acquisition struct {
+	SensorID  uint16     `hdf5:"sensor_id"`
+	Gain      float64    `hdf5:"gain"`
+	Offset    [3]float64 `hdf5:"offset"`
+	Timestamp int64      `hdf5:"timestamp"`
+	Window    struct {
+		Start int32 `hdf5:"start"`
+		Stop  int32 `hdf5:"stop"`
+	} `hdf5:"window"`
+	internal int
+	Ignored  string `hdf5:"-"`
+}
+
+func TestCreateDatasetWithCompoundAttribute(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "compound_attr.h5")
+
+	f, err := Create(testFile)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	acq := acquisition{SensorID: 7, Gain: 2.5, Offset: [3]float64{0.1, 0.2, 0.3}, Timestamp: 1700000000}
+	acq.Window.Start, acq.Window.Stop = 10, 20
+	runs := []acquisition{{SensorID: 1, Gain: 1}, {SensorID: 2, Gain: 2}}
+	_, err = f.Root().CreateDataset("data", []int32{1, 2, 3},
+		WithAttribute("acquisition", acq),
+		WithAttribute("runs", runs),
+		WithAttribute("calibration", map[string]interface{}{"scale": 0.5, "bias": int32(-3)}),
+	)
+	if err != nil {
+		t.Fatalf("CreateDataset with compound attributes failed: %v", err)
+	}
+	f.Close()
+
+	f2, err := Open(testFile)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f2.Close()
+
+	ds, err := f2.OpenDataset("data")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+
+	got, err := ds.Attr("acquisition").ReadScalarCompound()
+	if err != nil {
+		t.Fatalf("ReadScalarCompound failed: %v", err)
+	}
+	if got["sensor_id"] != uint16(7) || got["gain"] != 2.5 || got["timestamp"] != int64(1700000000) {
+		t.Errorf("scalar members: got %v", got)
+	}
+	offset, ok := got["offset"].([]float64)
+	if !ok || len(offset) != 3 || offset[0] != 0.1 || offset[2] != 0.3 {
+		t.Errorf("offset: got %v (%T), want [0.1 0.2 0.3]", got["offset"], got["offset"])
+	}
+	window, ok := got["window"].(map[string]interface{})
+	if !ok || window["start"] != int32(10) || window["stop"] != int32(20) {
+		t.Errorf("window: got %v", got["window"])
+	}
+	if _, ok := got["Ignored"]; ok {
+		t.Error("field tagged hdf5:\"-\" was written")
+	}
+
+	all, err := ds.Attr("runs").ReadCompound()
+	if err != nil {
+		t.Fatalf("ReadCompound failed: %v", err)
+	}
+	if len(all) != 2 || all[0]["sensor_id"] != uint16(1) || all[1]["gain"] != 2.0 {
+		t.Errorf("runs: got %v", all)
+	}
+
+	cal, err := ds.Attr("calibration").ReadScalarCompound()
+	if err != nil {
+		t.Fatalf("ReadScalarCompound failed: %v", err)
+	}
+	if cal["scale"] != 0.5 || cal["bias"] != int32(-3) {
+		t.Errorf("calibration: got %v", cal)
+	}
+}
+
+func TestCompoundAttributeUnsupportedMembers(t *testing.T) {
+	type withString struct {
+		Name string
+	}
+	type deep struct {
+		Outer struct {
+			Inner struct{ X int32 }
+		}
+	}
+
+	for name, value := range map[string]interface{}{
+		"varlen string": withString{Name: "x"},
+		"deep nesting":  deep{},
+	} {
+		if _, err := createAttributeMessage("a", value); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+// TestSetAttr sets attributes on new and existing datasets and groups, and
+// replaces them with values of the same and of larger sizes.
+func TestSetAttr(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "set_attr.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	ds, err := f.Root().CreateDataset("data", []float64{1, 2, 3}, WithAttribute("kept", int32(7)))
+	if err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	grp, err := f.Root().CreateGroup("grp")
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+
+	set := func(name string, obj interface {
+		SetAttr(string, interface{}) error
+	}, attr string, value interface{}) {
+		t.Helper()
+		if err := obj.SetAttr(attr, value); err != nil {
+			t.Fatalf("SetAttr %s %s failed: %v", name, attr, err)
+		}
+	}
+	set("data", ds, "units", "m")
+	set("data", ds, "scale", 2.5)
+	set("data", ds, "range", []float64{-1, 1})
+	set("data", ds, "scale", 3.5)  // Same size, in place
+	set("data", ds, "units", "km") // Larger, moves
+	set("data", ds, "labels", []string{"x", "y", "z"})
+	set("grp", grp, "title", "run 1")
+	set("grp", grp, "count", int64(3))
+	set("grp", grp, "title", "first run")
+	set("root", f.Root(), "version", int32(2))
+	if _, err := grp.CreateDataset("inner", []int32{1}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+
+	// The handle sees its new attributes
+	if v, err := ds.Attr("scale").ReadScalarFloat64(); err != nil || v != 3.5 {
+		t.Errorf("scale before Close = %v, %v, want 3.5", v, err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	check := func(f *File) {
+		t.Helper()
+		ds, err := f.OpenDataset("data")
+		if err != nil {
+			t.Fatalf("OpenDataset failed: %v", err)
+		}
+		if got, err := ds.ReadFloat64(); err != nil || !reflect.DeepEqual(got, []float64{1, 2, 3}) {
+			t.Errorf("data = %v, %v", got, err)
+		}
+		if v, err := ds.Attr("kept").ReadScalarInt64(); err != nil || v != 7 {
+			t.Errorf("kept = %v, %v, want 7", v, err)
+		}
+		if v, err := ds.Attr("scale").ReadScalarFloat64(); err != nil || v != 3.5 {
+			t.Errorf("scale = %v, %v, want 3.5", v, err)
+		}
+		if v, err := ds.Attr("units").ReadString(); err != nil || !reflect.DeepEqual(v, []string{"km"}) {
+			t.Errorf("units = %v, %v, want [km]", v, err)
+		}
+		if v, err := ds.Attr("range").ReadFloat64(); err != nil || !reflect.DeepEqual(v, []float64{-1, 1}) {
+			t.Errorf("range = %v, %v, want [-1 1]", v, err)
+		}
+		if v, err := ds.Attr("labels").ReadString(); err != nil || !reflect.DeepEqual(v, []string{"x", "y", "z"}) {
+			t.Errorf("labels = %v, %v, want [x y z]", v, err)
+		}
+		if names := ds.Attrs(); len(names) != 5 {
+			t.Errorf("dataset attributes = %v, want 5", names)
+		}
+
+		grp, err := f.OpenGroup("grp")
+		if err != nil {
+			t.Fatalf("OpenGroup failed: %v", err)
+		}
+		if v, err := grp.Attr("title").ReadString(); err != nil || !reflect.DeepEqual(v, []string{"first run"}) {
+			t.Errorf("title = %v, %v, want [first run]", v, err)
+		}
+		if v, err := grp.Attr("count").ReadScalarInt64(); err != nil || v != 3 {
+			t.Errorf("count = %v, %v, want 3", v, err)
+		}
+		if _, err := f.OpenDataset("grp/inner"); err != nil {
+			t.Errorf("OpenDataset grp/inner failed: %v", err)
+		}
+		if v, err := f.Root().Attr("version").ReadScalarInt64(); err != nil || v != 2 {
+			t.Errorf("version = %v, %v, want 2", v, err)
+		}
+	}
+	f, err = Open(path, WithStrict())
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	check(f)
+	f.Close()
+
+	// Replace values in the reopened file, and add a group link, which
+	// rewrites the group header with its attributes
+	f, err = OpenReadWrite(path)
+	if err != nil {
+		t.Fatalf("OpenReadWrite failed: %v", err)
+	}
+	ds, err = f.OpenDataset("data")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	set("data", ds, "kept", int32(7))
+	set("data", ds, "labels", []string{"x", "y", "z"})
+	if _, err := f.Root().CreateGroup("grp/sub"); err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	f, err = Open(path, WithStrict())
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	check(f)
+}
+
+// TestSetAttrV1Header adds attributes to datasets with version 1 object
+// headers, as written by h5py by default.
+func TestSetAttrV1Header(t *testing.T) {
+	path := copyTestdata(t, "v0_integers.h5")
+	f, err := OpenReadWrite(path)
+	if err != nil {
+		t.Fatalf("OpenReadWrite failed: %v", err)
+	}
+	ds, err := f.OpenDataset("int32")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if ds.header.Version != 1 {
+		t.Skipf("int32 has a version %d object header", ds.header.Version)
+	}
+	for i, value := range []interface{}{"a description long enough to need a continuation block", []float64{1, 2, 3, 4}, int64(5)} {
+		if err := ds.SetAttr(fmt.Sprintf("attr%d", i), value); err != nil {
+			t.Fatalf("SetAttr %d failed: %v", i, err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path, WithStrict())
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	ds, err = f.OpenDataset("int32")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if got, err := ds.ReadInt32(); err != nil || !reflect.DeepEqual(got, []int32{1, 2, 3, 4, 5}) {
+		t.Errorf("int32 = %v, %v", got, err)
+	}
+	if v, err := ds.Attr("attr0").ReadString(); err != nil || len(v) != 1 || !strings.HasPrefix(v[0], "a description") {
+		t.Errorf("attr0 = %v, %v", v, err)
+	}
+	if v, err := ds.Attr("attr1").ReadFloat64(); err != nil || !reflect.DeepEqual(v, []float64{1, 2, 3, 4}) {
+		t.Errorf("attr1 = %v, %v", v, err)
+	}
+	if v, err := ds.Attr("attr2").ReadScalarInt64(); err != nil || v != 5 {
+		t.Errorf("attr2 = %v, %v", v, err)
+	}
+	if other, err := f.OpenDataset("int64"); err != nil {
+		t.Errorf("OpenDataset int64 failed: %v", err)
+	} else if got, err := other.ReadInt64(); err != nil || !reflect.DeepEqual(got, []int64{10, 20, 30}) {
+		t.Errorf("int64 = %v, %v", got, err)
+	}
+}
+
+// TestUTF8AttributeNames writes attributes with non-ASCII names, which are
+// marked UTF-8 in their attribute messages, and reads them back.
+func TestUTF8AttributeNames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "utf8_attrs.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	ds, err := f.Root().CreateDataset("data", []int32{1, 2}, WithAttribute("température", 21.5))
+	if err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if err := ds.SetAttr("привет", "мир"); err != nil {
+		t.Fatalf("SetAttr failed: %v", err)
+	}
+	if err := f.Root().SetAttr("温度", int64(3)); err != nil {
+		t.Fatalf("SetAttr failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path, WithStrict())
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	ds, err = f.OpenDataset("data")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if names := ds.Attrs(); !reflect.DeepEqual(names, []string{"température", "привет"}) {
+		t.Errorf("Attrs = %q", names)
+	}
+	if v, err := ds.Attr("température").ReadScalarFloat64(); err != nil || v != 21.5 {
+		t.Errorf("température = %v, %v, want 21.5", v, err)
+	}
+	if v, err := ds.Attr("привет").ReadString(); err != nil || !reflect.DeepEqual(v, []string{"мир"}) {
+		t.Errorf("привет = %v, %v, want [мир]", v, err)
+	}
+	if v, err := f.Root().Attr("温度").ReadScalarInt64(); err != nil || v != 3 {
+		t.Errorf("温度 = %v, %v, want 3", v, err)
+	}
+	for _, msg := range ds.header.GetMessages(message.TypeAttribute) {
+		if attr := msg.(*message.Attribute); attr.NameCharset != message.CharsetUTF8 {
+			t.Errorf("attribute %q has charset %d, want UTF-8", attr.Name, attr.NameCharset)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package hdf5
+
+import (
+	"encoding/binary"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// TestBigEndianData reads big-endian datasets and attributes, which take the
+// byte-swapping conversion path on little-endian hosts.
+func TestBigEndianData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big_endian.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	be := binary.BigEndian
+	write := func(name string, dt *message.Datatype, n uint64, data []byte, attrs ...message.Message) {
+		t.Helper()
+		addr := f.allocate(int64(len(data)))
+		if err := f.writer.At(int64(addr)).WriteBytes(data); err != nil {
+			t.Fatalf("writing %s data: %v", name, err)
+		}
+		msgs := object.NewDatasetHeader(message.NewDataspace([]uint64{n}, nil), dt,
+			message.NewContiguousLayout(addr, uint64(len(data))))
+		writeObject(t, f, name, append(msgs, attrs...))
+	}
+
+	var i16, i32, i64, f32, f64 []byte
+	for i := 0; i < 100; i++ {
+		i16 = be.AppendUint16(i16, uint16(int16(i*300-15000)))
+		i32 = be.AppendUint32(i32, uint32(int32(i*-70000)))
+		i64 = be.AppendUint64(i64, uint64(int64(i)<<40|int64(i)))
+		f32 = be.AppendUint32(f32, math.Float32bits(float32(i)/8))
+		f64 = be.AppendUint64(f64, math.Float64bits(float64(i)-0.5))
+	}
+	write("int16", message.NewFixedPointDatatype(2, true, message.OrderBE), 100, i16)
+	write("int32", message.NewFixedPointDatatype(4, true, message.OrderBE), 100, i32)
+	write("int64", message.NewFixedPointDatatype(8, true, message.OrderBE), 100, i64,
+		message.NewScalarAttribute("scale", message.NewFloatDatatype(8, message.OrderBE), be.AppendUint64(nil, math.Float64bits(0.125))),
+		message.NewScalarAttribute("offset", message.NewFixedPointDatatype(4, true, message.OrderBE), be.AppendUint32(nil, uint32(0xFFFFFF85))),
+		message.NewAttribute("range", message.NewFloatDatatype(4, message.OrderBE), message.NewDataspace([]uint64{2}, nil),
+			be.AppendUint32(be.AppendUint32(nil, math.Float32bits(-1.5)), math.Float32bits(2.25))))
+	write("float32", message.NewFloatDatatype(4, message.OrderBE), 100, f32)
+	write("float64", message.NewFloatDatatype(8, message.OrderBE), 100, f64)
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	open := func(name string) *Dataset {
+		t.Helper()
+		ds, err := f.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset %s failed: %v", name, err)
+		}
+		return ds
+	}
+	want16, want32, want64 := make([]int16, 100), make([]int32, 100), make([]int64, 100)
+	wantF32, wantF64 := make([]float32, 100), make([]float64, 100)
+	for i := range want16 {
+		want16[i] = int16(i*300 - 15000)
+		want32[i] = int32(i * -70000)
+		want64[i] = int64(i)<<40 | int64(i)
+		wantF32[i] = float32(i) / 8
+		wantF64[i] = float64(i) - 0.5
+	}
+
+	var got16 []int16
+	if err := open("int16").Read(&got16); err != nil || !reflect.DeepEqual(got16, want16) {
+		t.Errorf("int16 = %v, %v, want %v", got16, err, want16)
+	}
+	var got32 []int32
+	if err := open("int32").Read(&got32); err != nil || !reflect.DeepEqual(got32, want32) {
+		t.Errorf("int32 = %v, %v, want %v", got32, err, want32)
+	}
+	ds := open("int64")
+	if got, err := ds.ReadInt64(); err != nil || !reflect.DeepEqual(got, want64) {
+		t.Errorf("int64 = %v, %v, want %v", got, err, want64)
+	}
+	var gotF32 []float32
+	if err := open("float32").Read(&gotF32); err != nil || !reflect.DeepEqual(gotF32, wantF32) {
+		t.Errorf("float32 = %v, %v, want %v", gotF32, err, wantF32)
+	}
+	if got, err := open("float64").ReadFloat64(); err != nil || !reflect.DeepEqual(got, wantF64) {
+		t.Errorf("float64 = %v, %v, want %v", got, err, wantF64)
+	}
+
+	// Attributes, including scalars converted to a wider type
+	if v, err := ds.Attr("scale").ReadScalarFloat64(); err != nil || v != 0.125 {
+		t.Errorf("scale = %v, %v, want 0.125", v, err)
+	}
+	if v, err := ds.Attr("offset").ReadScalarInt64(); err != nil || v != -123 {
+		t.Errorf("offset = %v, %v, want -123", v, err)
+	}
+	if v, err := ds.Attr("offset").ReadInt32(); err != nil || !reflect.DeepEqual(v, []int32{-123}) {
+		t.Errorf("offset as int32 = %v, %v, want [-123]", v, err)
+	}
+	if v, err := ds.Attr("range").ReadFloat32(); err != nil || !reflect.DeepEqual(v, []float32{-1.5, 2.25}) {
+		t.Errorf("range = %v, %v, want [-1.5 2.25]", v, err)
+	}
+}
@@ -0,0 +1,270 @@
+package hdf5
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	binpkg "github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/btree"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+func TestForEachChunkEdgeChunks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chunks.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	// 23 elements in chunks of 5: the last chunk holds only 3
+	data := make([]int32, 23)
+	for i := range data {
+		data[i] = int32(i * 10)
+	}
+	if _, err := f.Root().CreateDataset("series", data, WithChunks(5)); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("flat", []int32{1, 2, 3}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	f.Close()
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	ds, err := f.OpenDataset("series")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+
+	got := make([]int32, len(data))
+	chunks := 0
+	err = ds.ForEachChunk(func(offset []uint64, raw []byte) error {
+		chunks++
+		if want := min(5, 23-offset[0]) * 4; uint64(len(raw)) != want {
+			t.Errorf("chunk at %v has %d bytes, want %d", offset, len(raw), want)
+			return nil
+		}
+		for k := 0; k < len(raw)/4; k++ {
+			got[offset[0]+uint64(k)] = int32(binary.LittleEndian.Uint32(raw[4*k:]))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEachChunk failed: %v", err)
+	}
+	if chunks != 5 {
+		t.Errorf("visited %d chunks, want 5", chunks)
+	}
+	if !reflect.DeepEqual(got, data) {
+		t.Errorf("reassembled chunks = %v, want %v", got, data)
+	}
+
+	flat, err := f.OpenDataset("flat")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	err = flat.ForEachChunk(func([]uint64, []byte) error { return nil })
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("contiguous dataset: err = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestForEachChunkStopsOnError(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "btree_v2_compressed.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	ds, err := f.OpenDataset("compressed")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	all, err := ds.ReadFloat64()
+	if err != nil {
+		t.Fatalf("ReadFloat64 failed: %v", err)
+	}
+
+	// Each gzip-compressed 10x10 chunk decodes to 100 float64 values
+	stop := errors.New("stop")
+	calls := 0
+	err = ds.ForEachChunk(func(offset []uint64, raw []byte) error {
+		calls++
+		if len(raw) != 800 {
+			t.Fatalf("chunk at %v has %d bytes, want 800", offset, len(raw))
+		}
+		first := binary.LittleEndian.Uint64(raw)
+		if want := all[offset[0]*100+offset[1]]; first != math.Float64bits(want) {
+			t.Errorf("chunk at %v starts with %#x, want %v", offset, first, want)
+		}
+		if calls == 3 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Errorf("err = %v, want the callback's error", err)
+	}
+	if calls != 3 {
+		t.Errorf("callback ran %d times after returning an error on the third", calls)
+	}
+}
+
+// v1ChunkLayout is a version 3 chunked layout message pointing at a v1
+// B-tree chunk index, which files this package writes never use.
+type v1ChunkLayout struct {
+	btreeAddr uint64
+	chunkDims []uint32 // With the element size last
+}
+
+func (m *v1ChunkLayout) Type() message.Type { return message.TypeDataLayout }
+
+func (m *v1ChunkLayout) SerializedSize(w *binpkg.Writer) int {
+	return 3 + w.OffsetSize() + 4*len(m.chunkDims)
+}
+
+func (m *v1ChunkLayout) Serialize(w *binpkg.Writer) error {
+	if err := w.WriteBytes([]byte{3, byte(message.LayoutChunked), byte(len(m.chunkDims))}); err != nil {
+		return err
+	}
+	if err := w.WriteOffset(m.btreeAddr); err != nil {
+		return err
+	}
+	for _, d := range m.chunkDims {
+		if err := w.WriteUint32(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeChunkBTreeV1 writes a v1 B-tree chunk index over entries, which are
+// in order, with at most fanout children per node, and returns its root.
+// end is the key bounding the last chunk, the dataset's dimensions.
+func writeChunkBTreeV1(t *testing.T, f *File, entries []btree.ChunkEntry, fanout int, end []uint64) uint64 {
+	t.Helper()
+	keySize := 8 + 8*(len(end)+1)
+	key := func(b []byte, e btree.ChunkEntry) []byte {
+		b = binary.LittleEndian.AppendUint32(b, uint32(e.Size))
+		b = binary.LittleEndian.AppendUint32(b, e.FilterMask)
+		for _, o := range e.Offset {
+			b = binary.LittleEndian.AppendUint64(b, o)
+		}
+		return binary.LittleEndian.AppendUint64(b, 0)
+	}
+
+	// Each level lists its nodes by their first key and address
+	level := entries
+	for depth := byte(0); ; depth++ {
+		var next []btree.ChunkEntry
+		for start := 0; start < len(level); start += fanout {
+			children := level[start:min(start+fanout, len(level))]
+			last := btree.ChunkEntry{Offset: end}
+			if start+fanout < len(level) {
+				last = level[start+fanout]
+			}
+			node := []byte{'T', 'R', 'E', 'E', 1, depth}
+			node = binary.LittleEndian.AppendUint16(node, uint16(len(children)))
+			node = binary.LittleEndian.AppendUint64(node, ^uint64(0))
+			node = binary.LittleEndian.AppendUint64(node, ^uint64(0))
+			for _, child := range children {
+				node = key(node, child)
+				node = binary.LittleEndian.AppendUint64(node, child.Address)
+			}
+			node = key(node, last)
+			// Room for a full node, as the C library allocates
+			node = append(node, make([]byte, (fanout-len(children))*(keySize+8))...)
+
+			addr := f.allocate(int64(len(node)))
+			if err := f.writer.At(int64(addr)).WriteBytes(node); err != nil {
+				t.Fatalf("writing B-tree node: %v", err)
+			}
+			next = append(next, btree.ChunkEntry{Offset: children[0].Offset, Address: addr})
+		}
+		if len(next) == 1 {
+			return next[0].Address
+		}
+		level = next
+	}
+}
+
+// TestReadChunkBTreeV1Levels reads a dataset whose 720 chunks take a v1
+// B-tree of two levels at the default K of 32, and checks every element.
+func TestReadChunkBTreeV1Levels(t *testing.T) {
+	const rows, cols, chunk = 120, 150, 5
+	path := filepath.Join(t.TempDir(), "btree_v1.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	// Chunks in row-major order hold their elements' indexes
+	var entries []btree.ChunkEntry
+	for r0 := 0; r0 < rows; r0 += chunk {
+		for c0 := 0; c0 < cols; c0 += chunk {
+			var vals []float64
+			for r := r0; r < r0+chunk; r++ {
+				for c := c0; c < c0+chunk; c++ {
+					vals = append(vals, float64(r*cols+c))
+				}
+			}
+			raw := float64Bytes(vals...)
+			addr := f.allocate(int64(len(raw)))
+			if err := f.writer.At(int64(addr)).WriteBytes(raw); err != nil {
+				t.Fatalf("writing chunk: %v", err)
+			}
+			entries = append(entries, btree.ChunkEntry{
+				Offset:  []uint64{uint64(r0), uint64(c0)},
+				Size:    uint64(len(raw)),
+				Address: addr,
+			})
+		}
+	}
+	root := writeChunkBTreeV1(t, f, entries, 64, []uint64{rows, cols})
+	writeObject(t, f, "grid", []message.Message{
+		message.NewDataspace([]uint64{rows, cols}, nil),
+		message.NewFloatDatatype(8, message.OrderLE),
+		&v1ChunkLayout{btreeAddr: root, chunkDims: []uint32{chunk, chunk, 8}},
+	})
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	ds, err := f.OpenDataset("grid")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if v := ds.header.DataLayout().Version; v != 3 {
+		t.Fatalf("layout version %d, want 3", v)
+	}
+	if level, err := f.reader.At(int64(root) + 5).ReadUint8(); err != nil || level != 1 {
+		t.Fatalf("root level = %d, %v, want 1", level, err)
+	}
+
+	got, err := ds.ReadFloat64()
+	if err != nil {
+		t.Fatalf("ReadFloat64 failed: %v", err)
+	}
+	if len(got) != rows*cols {
+		t.Fatalf("got %d elements, want %d", len(got), rows*cols)
+	}
+	for i, v := range got {
+		if v != float64(i) {
+			t.Fatalf("element %d = %v, want %d", i, v, i)
+		}
+	}
+	info, err := ds.StorageInfo()
+	if err != nil || info.Chunks != len(entries) {
+		t.Errorf("StorageInfo = %+v, %v, want %d chunks", info, err, len(entries))
+	}
+}
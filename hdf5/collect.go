@@ -0,0 +1,230 @@
+package hdf5
+
+import (
+	"fmt"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// CollectOption configures CollectAttrs.
+type CollectOption func(*collectOptions)
+
+type collectOptions struct {
+	root         string
+	datasetsOnly bool
+	skipMissing  bool
+}
+
+func defaultCollectOptions() *collectOptions {
+	return &collectOptions{
+		root: "/",
+	}
+}
+
+// CollectDatasetsOnly restricts CollectAttrs to datasets; groups are
+// traversed but never reported.
+func CollectDatasetsOnly() CollectOption {
+	return func(o *collectOptions) {
+		o.datasetsOnly = true
+	}
+}
+
+// CollectUnder restricts CollectAttrs to the subtree rooted at the given group path.
+func CollectUnder(groupPath string) CollectOption {
+	return func(o *collectOptions) {
+		o.root = groupPath
+	}
+}
+
+// CollectSkipMissing omits objects that do not carry every requested attribute.
+// By default, every visited object is reported with whichever of the requested
+// attributes it has (possibly none).
+func CollectSkipMissing() CollectOption {
+	return func(o *collectOptions) {
+		o.skipMissing = true
+	}
+}
+
+// CollectAttrs reads the named attributes from every group and dataset in the
+// file in a single pass and returns them keyed by object path, then attribute
+// name. Committed datatypes are not reported.
+//
+// Each object header is read exactly once, and only the requested attributes
+// are decoded. Only hard links are followed, so every object is reported at
+// most once (under the first path it is reached by); soft and external links
+// are not traversed.
+//
+// Example:
+//
+//	attrs, err := f.CollectAttrs([]string{"units", "scale"}, hdf5.CollectDatasetsOnly())
+//	for path, values := range attrs {
+//	    fmt.Println(path, values["units"], values["scale"])
+//	}
+func (f *File) CollectAttrs(attrNames []string, opts ...CollectOption) (map[string]map[string]interface{}, error) {
+	if f.closed {
+		return nil, ErrClosed
+	}
+
+	options := defaultCollectOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	start, err := f.OpenGroup(options.root)
+	if err != nil {
+		return nil, fmt.Errorf("opening %q: %w", options.root, err)
+	}
+
+	c := &attrCollector{
+		file:    f,
+		names:   attrNames,
+		options: options,
+		result:  make(map[string]map[string]interface{}),
+		visited: map[uint64]bool{start.addr: true},
+	}
+	if err := c.collectGroup(start.path, start.header); err != nil {
+		return nil, err
+	}
+	return c.result, nil
+}
+
+// attrCollector holds the traversal state for CollectAttrs.
+type attrCollector struct {
+	file    *File
+	names   []string
+	options *collectOptions
+	result  map[string]map[string]interface{}
+	visited map[uint64]bool
+}
+
+// collectGroup records the group's attributes and recurses into its hard-linked
+// child groups. Committed datatypes and unrecognised objects are skipped.
+func (c *attrCollector) collectGroup(groupPath string, header *object.Header) error {
+	if !c.options.datasetsOnly {
+		if err := c.collectObject(groupPath, header); err != nil {
+			return err
+		}
+	}
+
+	g := &Group{file: c.file, path: groupPath, header: header}
+	children, err := g.hardLinkedChildren()
+	if err != nil {
+		return fmt.Errorf("listing %q: %w", groupPath, err)
+	}
+
+	for _, child := range children {
+		if c.visited[child.address] {
+			continue
+		}
+		c.visited[child.address] = true
+
+		childPath := joinPath(groupPath, child.name)
+		childHeader, err := c.file.readHeader(child.address)
+		if err != nil {
+			return withPath(childPath, structureError("object header", child.address, err))
+		}
+
+		switch headerKind(childHeader) {
+		case ObjectTypeDataset:
+			if err := c.collectObject(childPath, childHeader); err != nil {
+				return err
+			}
+		case ObjectTypeGroup:
+			if err := c.collectGroup(childPath, childHeader); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// collectObject decodes the requested attributes found in header.
+func (c *attrCollector) collectObject(objPath string, header *object.Header) error {
+	values := make(map[string]interface{}, len(c.names))
+
+	attrs, err := c.file.readAttributes(header)
+	if err != nil {
+		return fmt.Errorf("reading attributes of %s: %w", objPath, err)
+	}
+	for _, attrMsg := range attrs {
+		if !containsString(c.names, attrMsg.Name) {
+			continue
+		}
+		if _, seen := values[attrMsg.Name]; seen {
+			continue
+		}
+		attr := &Attribute{msg: attrMsg, reader: c.file.reader, path: JoinAttrPath(objPath, attrMsg.Name)}
+		val, err := attr.Value()
+		if err != nil {
+			return err
+		}
+		values[attrMsg.Name] = val
+	}
+
+	if c.options.skipMissing && len(values) < len(c.names) {
+		return nil
+	}
+	c.result[objPath] = values
+	return nil
+}
+
+// childLink is a named hard link to an object in the same file.
+type childLink struct {
+	name    string
+	address uint64
+}
+
+// hardLinkedChildren lists the group's hard-linked members without reading
+// their object headers.
+func (g *Group) hardLinkedChildren() ([]childLink, error) {
+	var children []childLink
+
+	links, err := g.links()
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		if link.IsHard() {
+			children = append(children, childLink{name: link.Name, address: link.ObjectAddress})
+		}
+	}
+	if len(links) > 0 {
+		return children, nil
+	}
+
+	var symTable *message.SymbolTable
+	if symMsg := g.header.GetMessage(message.TypeSymbolTable); symMsg != nil {
+		symTable = symMsg.(*message.SymbolTable)
+	} else if g.path == "/" && g.file.superblock.RootGroupBTreeAddress != 0 {
+		symTable = &message.SymbolTable{
+			BTreeAddress:     g.file.superblock.RootGroupBTreeAddress,
+			LocalHeapAddress: g.file.superblock.RootGroupLocalHeapAddress,
+		}
+	}
+	if symTable == nil {
+		return children, nil
+	}
+
+	entries, err := g.getMembersV1(symTable)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.LinkType != 1 {
+			children = append(children, childLink{name: entry.Name, address: entry.ObjectAddress})
+		}
+	}
+	return children, nil
+}
+
+// containsString reports whether s is in list.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,257 @@
+package hdf5
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+func TestReadCompoundColumns(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "compound_columns.h5")
+
+	f, err := Create(testFile)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	i32 := message.NewFixedPointDatatype(4, true, message.OrderLE)
+	f64 := message.NewFloatDatatype(8, message.OrderLE)
+	dt := message.NewCompoundDatatype(16, []message.CompoundMember{
+		{Name: "id", ByteOffset: 0, Type: i32},
+		{Name: "flags", ByteOffset: 4, Type: i32},
+		{Name: "value", ByteOffset: 8, Type: f64},
+	})
+	ds, err := f.Root().CreateDatasetWithType("records", []uint64{3}, dt)
+	if err != nil {
+		t.Fatalf("CreateDatasetWithType failed: %v", err)
+	}
+
+	// Encode does not handle compound values, so write the rows directly
+	raw := make([]byte, 3*16)
+	for i := 0; i < 3; i++ {
+		row := raw[i*16:]
+		binary.LittleEndian.PutUint32(row[0:], uint32(i+1))
+		binary.LittleEndian.PutUint32(row[4:], 0xFF)
+		binary.LittleEndian.PutUint64(row[8:], math.Float64bits(float64(i)+0.5))
+	}
+	if err := f.writer.At(int64(ds.dataAddr)).WriteBytes(raw); err != nil {
+		t.Fatalf("writing rows: %v", err)
+	}
+	f.Close()
+
+	f2, err := Open(testFile)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f2.Close()
+
+	records, err := f2.OpenDataset("/records")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+
+	cols, err := records.ReadCompoundColumns([]string{"value", "id"})
+	if err != nil {
+		t.Fatalf("ReadCompoundColumns failed: %v", err)
+	}
+	if _, ok := cols["flags"]; ok {
+		t.Error("unrequested member flags was returned")
+	}
+	for i := 0; i < 3; i++ {
+		if v := cols["id"][i]; v != int32(i+1) {
+			t.Errorf("id[%d] = %v, want %d", i, v, i+1)
+		}
+		if v := cols["value"][i]; v != float64(i)+0.5 {
+			t.Errorf("value[%d] = %v, want %v", i, v, float64(i)+0.5)
+		}
+	}
+
+	if _, err := records.ReadCompoundColumns([]string{"id", "bogus"}); err == nil {
+		t.Error("expected error for unknown member")
+	}
+
+	f2.Close()
+	f3, err := Open(skipIfNoTestdata(t, "minimal.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f3.Close()
+	plain, err := f3.OpenDataset("/data")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if _, err := plain.ReadCompoundColumns([]string{"x"}); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("non-compound dataset: got %v, want ErrUnsupported", err)
+	}
+}
+
+func TestReadCompoundStructs(t *testing.T) {
+	type sample struct {
+		ID     int32      `hdf5:"id"`
+		Pos    [2]float32 `hdf5:"pos"`
+		Weight float64    `hdf5:"weight"`
+	}
+	rows := []sample{{1, [2]float32{0.5, 1.5}, 10}, {2, [2]float32{2.5, 3.5}, 20}, {3, [2]float32{4.5, 5.5}, 30}}
+
+	path := filepath.Join(t.TempDir(), "compound_structs.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("samples", rows); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	ds, err := f.OpenDataset("samples")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+
+	var got []sample
+	if err := ds.Read(&got); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, rows) {
+		t.Errorf("Read = %+v, want %+v", got, rows)
+	}
+
+	// A different struct: untagged names match ignoring case, the array
+	// member fills a slice, and the weight member is not needed
+	var partial []struct {
+		Id  int
+		Pos []float64
+	}
+	if err := ds.Read(&partial); err != nil {
+		t.Fatalf("Read into partial struct failed: %v", err)
+	}
+	if len(partial) != 3 || partial[2].Id != 3 || !reflect.DeepEqual(partial[2].Pos, []float64{4.5, 5.5}) {
+		t.Errorf("partial = %+v", partial)
+	}
+
+	var wrong []struct{ Pos [3]float32 }
+	if err := ds.Read(&wrong); err == nil {
+		t.Error("2-element array member read into a 3-element array field")
+	}
+}
+
+func TestReadCompoundTable(t *testing.T) {
+	type location struct {
+		Lat float64 `hdf5:"lat"`
+		Lon float64 `hdf5:"lon"`
+	}
+	type row struct {
+		ID        int32    `hdf5:"id"`
+		Timestamp int64    `hdf5:"timestamp"`
+		Value     float64  `hdf5:"value"`
+		Name      string   `hdf5:"name"`
+		Loc       location `hdf5:"loc"`
+	}
+	rows := []row{
+		{1, 1700000000, 0.25, "alpha", location{51.5, -0.1}},
+		{2, 1700000060, 0.5, "beta", location{48.9, 2.35}},
+		{3, 1700000120, 0.75, "gamma", location{40.7, -74}},
+	}
+
+	f64 := message.NewFloatDatatype(8, message.OrderLE)
+	dt := message.NewCompoundDatatype(44, []message.CompoundMember{
+		{Name: "id", ByteOffset: 0, Type: message.NewFixedPointDatatype(4, true, message.OrderLE)},
+		{Name: "timestamp", ByteOffset: 4, Type: message.NewFixedPointDatatype(8, true, message.OrderLE)},
+		{Name: "value", ByteOffset: 12, Type: f64},
+		{Name: "name", ByteOffset: 20, Type: message.NewStringDatatype(8, message.PadNullPad, message.CharsetASCII)},
+		{Name: "loc", ByteOffset: 28, Type: message.NewCompoundDatatype(16, []message.CompoundMember{
+			{Name: "lat", ByteOffset: 0, Type: f64},
+			{Name: "lon", ByteOffset: 8, Type: f64},
+		})},
+	})
+
+	path := filepath.Join(t.TempDir(), "compound_table.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	table, err := f.Root().CreateDatasetWithType("table", []uint64{3}, dt)
+	if err != nil {
+		t.Fatalf("CreateDatasetWithType failed: %v", err)
+	}
+	if err := table.Write(rows); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	type reading struct {
+		ID    int32
+		Value float64
+	}
+	readings := make([]reading, 5)
+	for i := range readings {
+		readings[i] = reading{int32(i), float64(i) / 4}
+	}
+	if _, err := f.Root().CreateDataset("readings", readings, WithChunks(2)); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	ds, err := f.OpenDataset("table")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+
+	var got []row
+	if err := ds.ReadInto(&got); err != nil {
+		t.Fatalf("ReadInto failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, rows) {
+		t.Errorf("ReadInto = %+v, want %+v", got, rows)
+	}
+
+	maps, err := ds.ReadCompound()
+	if err != nil {
+		t.Fatalf("ReadCompound failed: %v", err)
+	}
+	if len(maps) != 3 || maps[1]["name"] != "beta" || maps[1]["timestamp"] != int64(1700000060) {
+		t.Errorf("ReadCompound row 1 = %v", maps[1])
+	}
+	if loc, ok := maps[2]["loc"].(map[string]interface{}); !ok || loc["lon"] != -74.0 {
+		t.Errorf("ReadCompound row 2 loc = %v, want a map with lon -74", maps[2]["loc"])
+	}
+
+	var ints []int32
+	if err := ds.ReadInto(&ints); err == nil {
+		t.Error("ReadInto accepted a slice of int32")
+	}
+
+	// Chunked storage is assembled before decoding
+	chunked, err := f.OpenDataset("readings")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	var gotReadings []reading
+	if err := chunked.ReadInto(&gotReadings); err != nil {
+		t.Fatalf("ReadInto (chunked) failed: %v", err)
+	}
+	if !reflect.DeepEqual(gotReadings, readings) {
+		t.Errorf("chunked ReadInto = %+v, want %+v", gotReadings, readings)
+	}
+
+	if err := chunked.ReadInto(nil); err == nil {
+		t.Error("ReadInto accepted a nil destination")
+	}
+}
@@ -0,0 +1,99 @@
+package hdf5
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// TestConcurrentReads reads every dataset of one file from many goroutines
+// at once. Run with -race to check that reads share no unsynchronized state.
+func TestConcurrentReads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "concurrent.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	want := make(map[string][]float64)
+	options := [][]DatasetOption{
+		nil,
+		{WithChunks(7)},
+		{WithChunks(16), WithCompression(6)},
+		{WithChunks(10), WithShuffle(), WithCompression(1)},
+		{WithChunks(25), WithFletcher32()},
+	}
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("ds_%d", i)
+		data := make([]float64, 100+i*10)
+		for j := range data {
+			data[j] = float64(i*1000 + j)
+		}
+		opts := append(options[i%len(options)], WithAttribute("index", int64(i)))
+		if _, err := f.Root().CreateDataset(name, data, opts...); err != nil {
+			t.Fatalf("CreateDataset %s failed: %v", name, err)
+		}
+		want[name] = data
+	}
+
+	// The same datasets reached through a group with dense links
+	var links []*message.Link
+	for _, link := range f.root.pendingLinks {
+		links = append(links, message.NewHardLink(link.Name, link.ObjectAddress))
+	}
+	writeObject(t, f, "dense", []message.Message{writeDenseLinks(t, f, links, false), message.NewGroupInfo()})
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	dense, err := f.OpenGroup("dense")
+	if err != nil {
+		t.Fatalf("OpenGroup failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 3*20*len(want))
+	for g := 0; g < 20; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < len(want); i++ {
+				name := fmt.Sprintf("ds_%d", (g+i)%len(want))
+				open, dsPath := f.OpenDataset, "/"+name
+				if g%2 == 1 {
+					open, dsPath = dense.OpenDataset, name
+				}
+				ds, err := open(dsPath)
+				if err != nil {
+					errs <- fmt.Errorf("OpenDataset(%s): %w", dsPath, err)
+					continue
+				}
+				got, err := ds.ReadFloat64()
+				if err != nil {
+					errs <- fmt.Errorf("ReadFloat64(%s): %w", dsPath, err)
+				} else if !reflect.DeepEqual(got, want[name]) {
+					errs <- fmt.Errorf("ReadFloat64(%s) returned wrong values", dsPath)
+				}
+				if attr := ds.Attr("index"); attr == nil {
+					errs <- fmt.Errorf("%s has no index attribute", dsPath)
+				} else if v, err := attr.ReadInt64(); err != nil || len(v) != 1 || v[0] != int64((g+i)%len(want)) {
+					errs <- fmt.Errorf("index attribute of %s = %v, %v", dsPath, v, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,583 @@
+package hdf5
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/robert-malhotra/go-hdf5/internal/heap"
+	"github.com/robert-malhotra/go-hdf5/internal/layout"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// maxHeapObjects is the most objects a global heap collection written by
+// CopyObject holds; their indexes are 16 bits.
+const maxHeapObjects = 0xFFFF
+
+// errMixedFilterMasks stops a raw chunk copy of a dataset whose chunks
+// skipped different filters, which the chunk indexes written here cannot
+// record.
+var errMixedFilterMasks = errors.New("chunks have different filter masks")
+
+// CopyObject copies the object at src in f to dstPath in dst, which must be
+// writable and may be f itself. A dataset is copied with its datatype,
+// dataspace, attributes and data, a named datatype with its attributes,
+// and a group with its attributes and everything below it. Missing groups
+// on the way to dstPath are created, as by CreateGroup. An error wrapping
+// ErrDuplicateLink is returned if dstPath exists, unless WithOverwrite is
+// given.
+//
+// Datasets keep their layout, chunk shape and filters unless opts says
+// otherwise: WithChunks re-chunks every copied dataset, WithGzip,
+// WithShuffle and WithFletcher32 replace their filters, and WithMaxDims
+// sets their maximum dimensions. Attributes given with WithAttribute are
+// added to the copy of src. Chunked datasets whose chunks and filters are
+// left alone have their chunks copied as stored, without being decoded
+// and encoded again. Files with a version 0 superblock take contiguous
+// copies.
+//
+// Soft and external links within a copied group are copied as links, with
+// their targets unchanged. An object reached through several hard links
+// is copied once: datasets and named datatypes are linked to the same
+// copy, and groups are soft-linked to the path of their first copy.
+// Variable-length data is copied to the global heap of dst. References,
+// which cannot point into the copy, and variable-length data nested in
+// compounds or arrays return an error wrapping ErrUnsupported. Fill
+// values are not copied.
+//
+// Example:
+//
+//	err := src.CopyObject("/run1/images", dst, "/images", hdf5.WithGzip(6))
+func (f *File) CopyObject(src string, dst *File, dstPath string, opts ...DatasetOption) error {
+	if f.closed || dst.closed {
+		return ErrClosed
+	}
+	if !dst.writable {
+		return fmt.Errorf("file is not writable")
+	}
+	options := defaultDatasetOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	parts := splitPath(dstPath)
+	if len(parts) == 0 {
+		return fmt.Errorf("%w: cannot copy to the root group", ErrInvalidPath)
+	}
+	for _, part := range parts {
+		if part == "." || part == ".." {
+			return fmt.Errorf("%w: destination %q", ErrInvalidPath, dstPath)
+		}
+	}
+
+	obj, err := f.root.open(src)
+	if err != nil {
+		return withPath(src, err)
+	}
+	if g, ok := obj.(*Group); ok && g.file == dst {
+		target := "/" + strings.Join(parts, "/")
+		if g.path == "/" || target == g.path || strings.HasPrefix(target, g.path+"/") {
+			return fmt.Errorf("%w: cannot copy %s into itself", ErrInvalidPath, g.path)
+		}
+	}
+
+	var extra []*message.Attribute
+	for _, attr := range options.attributes {
+		msg, err := createAttributeMessage(attr.name, attr.value)
+		if err != nil {
+			return fmt.Errorf("creating attribute %q: %w", attr.name, err)
+		}
+		extra = append(extra, msg)
+	}
+
+	parent := dst.root
+	for _, part := range parts[:len(parts)-1] {
+		if parent, err = parent.subgroupForWrite(part); err != nil {
+			return err
+		}
+	}
+	name := parts[len(parts)-1]
+	if err := parent.checkNewDataset(name, options); err != nil {
+		return err
+	}
+
+	c := &copier{dst: dst, options: options, copied: make(map[uint64]copiedObject)}
+	switch obj := obj.(type) {
+	case *Dataset:
+		_, err = c.dataset(obj, parent, name, extra)
+	case *NamedDatatype:
+		_, err = c.datatype(obj, parent, name, extra)
+	case *Group:
+		err = c.group(obj, parent, name, extra)
+	}
+	return err
+}
+
+// copier holds the state of a File.CopyObject.
+type copier struct {
+	dst     *File
+	options *datasetOptions
+	copied  map[uint64]copiedObject // Copies of the objects copied so far, by source header address
+}
+
+// copiedObject is where an object was copied to: the header address of a
+// dataset or named datatype, or the path of a group, whose header moves as
+// links are added to it.
+type copiedObject struct {
+	addr  uint64
+	path  string
+	group bool
+}
+
+// group copies g as name in parent, and then its members.
+func (c *copier) group(g *Group, parent *Group, name string, extra []*message.Attribute) error {
+	header, err := g.objectHeader()
+	if err != nil {
+		return err
+	}
+	attrs, err := c.attributes(g.file, header, g.path)
+	if err != nil {
+		return err
+	}
+	grp, err := parent.createGroup(name)
+	if err != nil {
+		return err
+	}
+	c.copied[g.addr] = copiedObject{path: grp.path, group: true}
+	grp.pendingAttrs = append(attrs, extra...)
+	if len(grp.pendingAttrs) > 0 {
+		if err := grp.rewriteHeader(); err != nil {
+			return withPath(grp.path, err)
+		}
+	}
+
+	members, err := g.memberLinks()
+	if err != nil {
+		return withPath(g.path, err)
+	}
+	for i := range members {
+		member := &members[i]
+		childPath := joinPath(g.path, member.name())
+		if !member.isHard() {
+			if err := grp.addLink(copyLink(member)); err != nil {
+				return withPath(childPath, err)
+			}
+			continue
+		}
+
+		res, err := g.resolveMember(member, g.file.newLinkChain())
+		if err != nil {
+			return withPath(childPath, err)
+		}
+		if prev, ok := c.copied[res.address]; ok {
+			link := message.NewHardLink(member.name(), prev.addr)
+			if prev.group {
+				link = message.NewSoftLink(member.name(), prev.path)
+			}
+			if err := grp.addLink(link); err != nil {
+				return withPath(childPath, err)
+			}
+			continue
+		}
+
+		var addr uint64
+		switch res.kind {
+		case ObjectTypeDataset:
+			ds, err := g.file.openDatasetAt(res.address, childPath)
+			if err != nil {
+				return withPath(childPath, err)
+			}
+			if addr, err = c.dataset(ds, grp, member.name(), nil); err != nil {
+				return err
+			}
+		case ObjectTypeNamedDatatype:
+			t, err := g.file.openDatatypeAt(res.address, childPath)
+			if err != nil {
+				return withPath(childPath, err)
+			}
+			if addr, err = c.datatype(t, grp, member.name(), nil); err != nil {
+				return err
+			}
+		default:
+			child, err := g.file.openGroupAt(res.address, childPath)
+			if err != nil {
+				return withPath(childPath, err)
+			}
+			if err := c.group(child, grp, member.name(), nil); err != nil {
+				return err
+			}
+			continue
+		}
+		c.copied[res.address] = copiedObject{addr: addr}
+	}
+	return nil
+}
+
+// copyLink returns a copy of a soft or external link.
+func copyLink(member *memberLink) *message.Link {
+	switch {
+	case member.link == nil:
+		return message.NewSoftLink(member.entry.Name, member.entry.SoftLinkValue)
+	case member.link.IsExternal():
+		return message.NewExternalLink(member.link.Name, member.link.ExternalFile, member.link.ExternalPath)
+	}
+	return message.NewSoftLink(member.link.Name, member.link.SoftLinkValue)
+}
+
+// datatype copies the named datatype t as name in parent and returns the
+// address of the copy.
+func (c *copier) datatype(t *NamedDatatype, parent *Group, name string, extra []*message.Attribute) (uint64, error) {
+	attrs, err := c.attributes(t.file, t.header, t.path)
+	if err != nil {
+		return 0, err
+	}
+	messages := []message.Message{t.datatype}
+	for _, attr := range append(attrs, extra...) {
+		messages = append(messages, attr)
+	}
+	return c.link(parent, name, messages)
+}
+
+// dataset copies ds as name in parent and returns the address of the copy.
+func (c *copier) dataset(ds *Dataset, parent *Group, name string, extra []*message.Attribute) (uint64, error) {
+	dt := ds.datatype
+	if err := c.checkDatatype(ds.file, dt); err != nil {
+		return 0, withPath(ds.path, err)
+	}
+	attrs, err := c.attributes(ds.file, ds.header, ds.path)
+	if err != nil {
+		return 0, err
+	}
+
+	dataspace := ds.dataspace
+	dims, maxDims := dataspace.Dimensions, dataspace.MaxDims
+	if c.options.maxDims != nil {
+		maxDims = c.options.maxDims
+		dataspace = message.NewDataspace(dims, maxDims)
+	}
+
+	// Keep the chunks and filters of the source unless told otherwise
+	var chunkDims []uint64
+	srcPipeline := ds.header.FilterPipeline()
+	chunked, _ := ds.layout.(*layout.Chunked)
+	if layoutMsg := ds.header.DataLayout(); chunked != nil && layoutMsg != nil {
+		for _, d := range layoutMsg.ChunkDims[:min(len(dims), len(layoutMsg.ChunkDims))] {
+			chunkDims = append(chunkDims, uint64(d))
+		}
+	}
+	chunks, pipeline := chunkDims, srcPipeline
+	if chunked == nil {
+		pipeline = nil
+	}
+	if c.options.chunks != nil {
+		chunks = c.options.chunks
+	}
+	if filters := c.options.filterPipeline(dt.Size); filters != nil {
+		pipeline = filters
+	}
+	switch {
+	case c.options.chunks == nil && pipeline == srcPipeline && c.dst.superblock.Version < 2:
+		chunks, pipeline = nil, nil
+	case pipeline != nil && chunks == nil:
+		return 0, withPath(ds.path, fmt.Errorf("filters require a chunked layout; use WithChunks"))
+	case chunks != nil && c.dst.superblock.Version < 2:
+		return 0, fmt.Errorf("%w: chunked datasets in files with a version %d superblock",
+			ErrUnsupported, c.dst.superblock.Version)
+	case chunks != nil && len(chunks) != len(dims):
+		return 0, withPath(ds.path, fmt.Errorf("dataset has rank %d, chunks have rank %d", len(dims), len(chunks)))
+	}
+
+	var dataLayout *message.DataLayout
+	if chunked != nil && chunks != nil && c.options.chunks == nil && pipeline == srcPipeline && !hasHeapData(dt) {
+		dataLayout, err = c.copyChunks(chunked, dims, maxDims, chunks, dt, pipeline)
+		if err != nil && !errors.Is(err, errMixedFilterMasks) {
+			return 0, withPath(ds.path, err)
+		}
+	}
+	if dataLayout == nil {
+		raw, err := ds.ReadRaw()
+		if err != nil {
+			return 0, err
+		}
+		if dt.Class == message.ClassVarLen {
+			if raw, err = c.copyHeapData(ds.file, raw); err != nil {
+				return 0, withPath(ds.path, err)
+			}
+		}
+		if dataLayout, err = c.dst.writeData(raw, dims, maxDims, chunks, dt, pipeline); err != nil {
+			return 0, withPath(ds.path, err)
+		}
+	}
+
+	messages := object.NewDatasetHeader(dataspace, dt, dataLayout)
+	if pipeline != nil {
+		messages = append(messages, pipeline)
+	}
+	for _, attr := range append(attrs, extra...) {
+		messages = append(messages, attr)
+	}
+	return c.link(parent, name, messages)
+}
+
+// link writes an object header with messages and links it as name in
+// parent, returning its address.
+func (c *copier) link(parent *Group, name string, messages []message.Message) (uint64, error) {
+	addr, err := c.dst.writeObjectHeader(messages, 0)
+	if err != nil {
+		return 0, fmt.Errorf("writing object header: %w", err)
+	}
+	if err := parent.addLink(message.NewHardLink(name, addr)); err != nil {
+		return 0, fmt.Errorf("adding link to parent: %w", err)
+	}
+	return addr, nil
+}
+
+// copyChunks copies the chunks of a dataset as stored, with the filter
+// pipeline they were written through, and returns the layout of the copy.
+// It indexes them as CreateDataset would: in an extensible array if a
+// dimension is unlimited, in the layout itself if one chunk holds the
+// whole dataset, and in a fixed array otherwise.
+func (c *copier) copyChunks(chunked *layout.Chunked, dims, maxDims, chunks []uint64, dt *message.Datatype,
+	pipeline *message.FilterPipeline) (*message.DataLayout, error) {
+	chunkDims := make([]uint32, len(chunks))
+	for i, d := range chunks {
+		chunkDims[i] = uint32(d)
+	}
+	unlimited := 0
+	for _, d := range maxDims {
+		if d == Unlimited {
+			unlimited++
+		}
+	}
+	if unlimited > 1 {
+		return nil, fmt.Errorf("%w: datasets with more than one unlimited dimension", ErrUnsupported)
+	}
+	cw, err := c.dst.chunkWriter(chunkDims, dt, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var offsets [][]uint64
+	var addrs []uint64
+	var sizes []uint64
+	err = chunked.ForEachRawChunk(func(chunk layout.RawChunk) error {
+		if len(offsets) == 0 {
+			cw.SetFilterMask(chunk.FilterMask)
+		} else if chunk.FilterMask != cw.FilterMask() {
+			return errMixedFilterMasks
+		}
+		addr, err := cw.WriteSingleChunk(chunk.Data)
+		if err != nil {
+			return fmt.Errorf("writing chunk: %w", err)
+		}
+		offsets = append(offsets, chunk.Offset)
+		addrs = append(addrs, addr)
+		sizes = append(sizes, uint64(len(chunk.Data)))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	single := true
+	for d := range dims {
+		single = single && dims[d] <= chunks[d]
+	}
+	var dataLayout *message.DataLayout
+	switch {
+	case unlimited == 1:
+		var indexAddrs []uint64
+		var indexSizes []uint64
+		for i, offset := range offsets {
+			idx := layout.ExtensibleArrayChunkIndex(offset, dims, maxDims, chunkDims)
+			for uint64(len(indexAddrs)) <= idx {
+				indexAddrs = append(indexAddrs, ^uint64(0))
+				indexSizes = append(indexSizes, 0)
+			}
+			indexAddrs[idx], indexSizes[idx] = addrs[i], sizes[i]
+		}
+		indexAddr, err := cw.WriteExtensibleArrayIndex(indexAddrs, indexSizes)
+		if err != nil {
+			return nil, fmt.Errorf("writing chunk index: %w", err)
+		}
+		dataLayout = message.NewChunkedLayout(chunkDims, dt.Size, message.ChunkIndexExtensibleArray)
+		dataLayout.ChunkIndexAddr = indexAddr
+	case single && len(addrs) == 1 && cw.Filtered():
+		dataLayout = message.NewChunkedLayout(chunkDims, dt.Size, message.ChunkIndexSingleChunk)
+		dataLayout.ChunkFlags = 0x02
+		dataLayout.ChunkIndexAddr = addrs[0]
+		dataLayout.FilteredChunkSize = sizes[0]
+		dataLayout.FilterMask = cw.FilterMask()
+	case single && len(addrs) == 1:
+		dataLayout = message.NewChunkedLayout(chunkDims, dt.Size, message.ChunkIndexImplicit)
+		dataLayout.ChunkIndexAddr = addrs[0]
+	default:
+		// Chunks are numbered in row-major order of their coordinates
+		perDim := make([]uint64, len(dims))
+		total := uint64(1)
+		for d := range dims {
+			perDim[d] = (dims[d] + chunks[d] - 1) / chunks[d]
+			total *= perDim[d]
+		}
+		indexAddrs := make([]uint64, total)
+		indexSizes := make([]uint64, total)
+		for i := range indexAddrs {
+			indexAddrs[i] = ^uint64(0)
+		}
+		for i, offset := range offsets {
+			idx := uint64(0)
+			for d := range dims {
+				idx = idx*perDim[d] + offset[d]/chunks[d]
+			}
+			indexAddrs[idx], indexSizes[idx] = addrs[i], sizes[i]
+		}
+		indexAddr, err := cw.WriteFixedArrayIndex(indexAddrs, indexSizes)
+		if err != nil {
+			return nil, fmt.Errorf("writing chunk index: %w", err)
+		}
+		dataLayout = message.NewChunkedLayout(chunkDims, dt.Size, message.ChunkIndexFixedArray)
+		dataLayout.ChunkIndexAddr = indexAddr
+	}
+	return dataLayout, nil
+}
+
+// attributes reads the attributes of an object for writing to its copy,
+// copying variable-length values to the destination's global heap.
+func (c *copier) attributes(f *File, header *object.Header, objPath string) ([]*message.Attribute, error) {
+	if header == nil {
+		return nil, nil
+	}
+	src, err := f.readAttributes(header)
+	if err != nil {
+		return nil, withPath(objPath, err)
+	}
+	attrs := make([]*message.Attribute, 0, len(src))
+	for _, a := range src {
+		if a.Datatype == nil || a.Dataspace == nil {
+			return nil, withPath(objPath, fmt.Errorf("%w: attribute %q with a shared datatype or dataspace",
+				ErrUnsupported, a.Name))
+		}
+		if err := c.checkDatatype(f, a.Datatype); err != nil {
+			return nil, withPath(objPath, fmt.Errorf("attribute %q: %w", a.Name, err))
+		}
+		data := a.Data
+		if a.Datatype.Class == message.ClassVarLen {
+			if data, err = c.copyHeapData(f, data); err != nil {
+				return nil, withPath(objPath, fmt.Errorf("attribute %q: %w", a.Name, err))
+			}
+		}
+		attrs = append(attrs, message.NewAttribute(a.Name, a.Datatype, a.Dataspace, data))
+	}
+	return attrs, nil
+}
+
+// checkDatatype checks that values of dt read from src can be copied:
+// that they hold no references, and heap IDs only as the elements
+// themselves, in IDs of the same size in both files.
+func (c *copier) checkDatatype(src *File, dt *message.Datatype) error {
+	if dt.Class != message.ClassVarLen {
+		if hasHeapData(dt) {
+			return fmt.Errorf("%w: copying references or nested variable-length data", ErrUnsupported)
+		}
+		return nil
+	}
+	if dt.VarLenType != nil && hasHeapData(dt.VarLenType) {
+		return fmt.Errorf("%w: copying nested variable-length data", ErrUnsupported)
+	}
+	if src.headerReader().OffsetSize() != c.dst.writer.OffsetSize() {
+		return fmt.Errorf("%w: copying variable-length data between files with %d- and %d-byte offsets",
+			ErrUnsupported, src.headerReader().OffsetSize(), c.dst.writer.OffsetSize())
+	}
+	return nil
+}
+
+// hasHeapData reports whether values of dt hold variable-length data or
+// references, which point at other parts of the file.
+func hasHeapData(dt *message.Datatype) bool {
+	switch dt.Class {
+	case message.ClassVarLen, message.ClassReference:
+		return true
+	case message.ClassCompound:
+		for _, m := range dt.Members {
+			if m.Type != nil && hasHeapData(m.Type) {
+				return true
+			}
+		}
+	case message.ClassArray:
+		return dt.BaseType != nil && hasHeapData(dt.BaseType)
+	}
+	return false
+}
+
+// copyHeapData copies the objects the variable-length elements in raw
+// refer to from the global heap of src to new collections in the
+// destination, and returns the elements updated to refer to the copies.
+// Each element is a 4-byte length and a global heap ID. Empty elements
+// refer to no object.
+func (c *copier) copyHeapData(src *File, raw []byte) ([]byte, error) {
+	offsetSize := src.headerReader().OffsetSize()
+	elemSize := 4 + offsetSize + 4
+	out := append([]byte(nil), raw...)
+
+	heaps := make(map[uint64]*heap.GlobalHeap)
+	ghw := heap.NewGlobalHeapWriter(c.dst.writer, c.dst.allocate)
+	var pending []int // Positions of the elements added to ghw
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		_, ids, err := ghw.Write()
+		if err != nil {
+			return fmt.Errorf("writing global heap: %w", err)
+		}
+		for i, pos := range pending {
+			id := ids[uint16(i+1)]
+			putAddress(out[pos+4:], id.CollectionAddress, offsetSize)
+			binary.LittleEndian.PutUint32(out[pos+4+offsetSize:], id.ObjectIndex)
+		}
+		pending = pending[:0]
+		ghw = heap.NewGlobalHeapWriter(c.dst.writer, c.dst.allocate)
+		return nil
+	}
+
+	for pos := 0; pos+elemSize <= len(out); pos += elemSize {
+		id, err := heap.ParseGlobalHeapID(out[pos+4:], offsetSize)
+		if err != nil {
+			return nil, err
+		}
+		if binary.LittleEndian.Uint32(out[pos:]) == 0 || id.CollectionAddress == 0 {
+			clear(out[pos+4 : pos+elemSize])
+			continue
+		}
+		gh, ok := heaps[id.CollectionAddress]
+		if !ok {
+			if gh, err = heap.ReadGlobalHeap(src.headerReader(), id.CollectionAddress); err != nil {
+				return nil, fmt.Errorf("reading global heap at 0x%x: %w", id.CollectionAddress, err)
+			}
+			heaps[id.CollectionAddress] = gh
+		}
+		obj, err := gh.GetObject(uint16(id.ObjectIndex))
+		if err != nil {
+			return nil, err
+		}
+		ghw.AddObject(obj)
+		pending = append(pending, pos)
+		if len(pending) == maxHeapObjects {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// putAddress stores a little-endian address of size bytes in b.
+func putAddress(b []byte, addr uint64, size int) {
+	for i := 0; i < size; i++ {
+		b[i] = byte(addr >> (8 * i))
+	}
+}
@@ -0,0 +1,280 @@
+package hdf5
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// copyInto copies each source path of the file at srcPath to the paired
+// destination path of a new file, with opts, and reopens the new file.
+func copyInto(t *testing.T, srcPath string, paths map[string]string, opts ...DatasetOption) (*File, *File) {
+	t.Helper()
+	src, err := Open(srcPath)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { src.Close() })
+
+	dstPath := filepath.Join(t.TempDir(), "copy.h5")
+	dst, err := Create(dstPath)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	for from, to := range paths {
+		if err := src.CopyObject(from, dst, to, opts...); err != nil {
+			t.Fatalf("CopyObject(%q, %q) failed: %v", from, to, err)
+		}
+	}
+	if err := dst.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if dst, err = Open(dstPath); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { dst.Close() })
+	return src, dst
+}
+
+// sameFloats checks that the datasets at srcPath in src and dstPath in dst
+// hold the same float64 values and shape.
+func sameFloats(t *testing.T, src *File, srcPath string, dst *File, dstPath string) *Dataset {
+	t.Helper()
+	want, err := src.OpenDataset(srcPath)
+	if err != nil {
+		t.Fatalf("OpenDataset(%q) failed: %v", srcPath, err)
+	}
+	got, err := dst.OpenDataset(dstPath)
+	if err != nil {
+		t.Fatalf("OpenDataset(%q) failed: %v", dstPath, err)
+	}
+	var wantData, gotData []float64
+	if err := want.Read(&wantData); err != nil {
+		t.Fatalf("Read %s failed: %v", srcPath, err)
+	}
+	if err := got.Read(&gotData); err != nil {
+		t.Fatalf("Read %s failed: %v", dstPath, err)
+	}
+	if !reflect.DeepEqual(gotData, wantData) || !reflect.DeepEqual(got.Shape(), want.Shape()) {
+		t.Errorf("%s: copy has shape %v and %d values, want shape %v and the source's %d",
+			dstPath, got.Shape(), len(gotData), want.Shape(), len(wantData))
+	}
+	return got
+}
+
+func TestCopyObjectChunks(t *testing.T) {
+	src, dst := copyInto(t, skipIfNoTestdata(t, "compressed.h5"), map[string]string{
+		"/gzip":         "/raw/gzip",
+		"/shuffle_gzip": "/shuffle_gzip",
+	})
+
+	// Chunks are copied as stored, so take the same space
+	for from, to := range map[string]string{"/gzip": "/raw/gzip", "/shuffle_gzip": "/shuffle_gzip"} {
+		got := sameFloats(t, src, from, dst, to)
+		want, _ := src.OpenDataset(from)
+		wantInfo, err := want.StorageInfo()
+		if err != nil {
+			t.Fatalf("StorageInfo failed: %v", err)
+		}
+		gotInfo, err := got.StorageInfo()
+		if err != nil {
+			t.Fatalf("StorageInfo failed: %v", err)
+		}
+		if gotInfo.StoredBytes != wantInfo.StoredBytes || gotInfo.Chunks != wantInfo.Chunks {
+			t.Errorf("%s: copy stores %d chunks in %d bytes, want %d in %d",
+				to, gotInfo.Chunks, gotInfo.StoredBytes, wantInfo.Chunks, wantInfo.StoredBytes)
+		}
+		if !reflect.DeepEqual(got.ChunkShape(), want.ChunkShape()) {
+			t.Errorf("%s: chunk shape %v, want %v", to, got.ChunkShape(), want.ChunkShape())
+		}
+	}
+
+	// Chunks indexed by a v2 B-tree are copied into a fixed array
+	src, dst = copyInto(t, skipIfNoTestdata(t, "btree_v2_compressed.h5"), map[string]string{"/compressed": "/c"})
+	sameFloats(t, src, "/compressed", dst, "/c")
+
+	// Resizable datasets keep their extensible array index
+	srcPath := filepath.Join(t.TempDir(), "log.h5")
+	f, err := Create(srcPath)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("log", []int32{1, 2, 3, 4, 5, 6, 7},
+		WithChunks(3), WithMaxDims(Unlimited), WithGzip(1)); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	_, dst = copyInto(t, srcPath, map[string]string{"/log": "/log"})
+	log := mustDataset(t, dst, "/log")
+	var values []int32
+	if err := log.Read(&values); err != nil || !reflect.DeepEqual(values, []int32{1, 2, 3, 4, 5, 6, 7}) {
+		t.Errorf("/log = %v, %v; want 1 to 7", values, err)
+	}
+	if max := log.MaxShape(); !reflect.DeepEqual(max, []uint64{Unlimited}) {
+		t.Errorf("MaxShape = %v, want [Unlimited]", max)
+	}
+
+	// Re-chunking and re-compressing decodes the data
+	src, dst = copyInto(t, skipIfNoTestdata(t, "chunked.h5"), map[string]string{"/chunked": "/chunked"},
+		WithChunks(5, 10), WithGzip(4))
+	got := sameFloats(t, src, "/chunked", dst, "/chunked")
+	if shape := got.ChunkShape(); !reflect.DeepEqual(shape, []uint64{5, 10}) {
+		t.Errorf("ChunkShape = %v, want [5 10]", shape)
+	}
+	if info, err := got.StorageInfo(); err != nil || info.CompressionRatio <= 1 {
+		t.Errorf("StorageInfo = %+v, %v; want compressed chunks", info, err)
+	}
+}
+
+func TestCopyObjectGroups(t *testing.T) {
+	// A group with variable-length strings and a dataset with
+	// variable-length string attributes
+	src, dst := copyInto(t, skipIfNoTestdata(t, "strings.h5"), map[string]string{"/": "/strings"})
+	for _, name := range []string{"fixed", "variable"} {
+		want, err := src.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset failed: %v", err)
+		}
+		got, err := dst.OpenDataset("/strings/" + name)
+		if err != nil {
+			t.Fatalf("OpenDataset failed: %v", err)
+		}
+		var wantData, gotData []string
+		if err := want.Read(&wantData); err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		if err := got.Read(&gotData); err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		if !reflect.DeepEqual(gotData, wantData) {
+			t.Errorf("%s = %q, want %q", name, gotData, wantData)
+		}
+	}
+
+	src, dst = copyInto(t, skipIfNoTestdata(t, "varlen_attrs.h5"), map[string]string{"/data": "/data"},
+		WithAttribute("copied", int32(1)))
+	want, err := mustDataset(t, src, "/data").AttrMap()
+	if err != nil {
+		t.Fatalf("AttrMap failed: %v", err)
+	}
+	want["copied"] = int64(1)
+	got, err := mustDataset(t, dst, "/data").AttrMap()
+	if err != nil {
+		t.Fatalf("AttrMap failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AttrMap = %v, want %v", got, want)
+	}
+
+	// Soft links are copied as links; /a/b/back and /a/dup reach objects
+	// already copied
+	srcPath := writeWalkFile(t)
+	_, dst = copyInto(t, srcPath, map[string]string{"/": "/copy"})
+	got2, err := dst.Export("/copy", ExportOptions{})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	src, err = Open(srcPath)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer src.Close()
+	want2, err := src.Export("/", ExportOptions{})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if !reflect.DeepEqual(got2["data"], want2["data"]) || len(got2) != len(want2) {
+		t.Errorf("Export of copy = %v, want %v", got2, want2)
+	}
+	copyRoot, err := dst.OpenGroup("/copy")
+	if err != nil {
+		t.Fatalf("OpenGroup failed: %v", err)
+	}
+	srcInfo, _ := src.Root().MembersInfo()
+	gotInfo, err := copyRoot.MembersInfo()
+	if err != nil {
+		t.Fatalf("MembersInfo failed: %v", err)
+	}
+	if !reflect.DeepEqual(gotInfo, srcInfo) {
+		t.Errorf("MembersInfo = %v, want %v", gotInfo, srcInfo)
+	}
+	back, err := dst.OpenGroup("/copy/a/b")
+	if err != nil {
+		t.Fatalf("OpenGroup failed: %v", err)
+	}
+	members, err := back.MembersInfo()
+	if err != nil {
+		t.Fatalf("MembersInfo failed: %v", err)
+	}
+	want3 := []MemberInfo{{"x", ObjectTypeDataset, "hard", false}, {"back", ObjectTypeGroup, "soft", false}}
+	if !reflect.DeepEqual(members, want3) {
+		t.Errorf("/copy/a/b members = %v, want %v", members, want3)
+	}
+}
+
+func TestCopyObjectErrors(t *testing.T) {
+	dir := t.TempDir()
+	f, err := Create(filepath.Join(dir, "src.h5"))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("data", []int32{1, 2, 3}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if _, err := f.Root().CreateGroup("g/h"); err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if f, err = OpenReadWrite(filepath.Join(dir, "src.h5")); err != nil {
+		t.Fatalf("OpenReadWrite failed: %v", err)
+	}
+	defer f.Close()
+
+	// Copies within a file
+	if err := f.CopyObject("/data", f, "/g/h/data"); err != nil {
+		t.Fatalf("CopyObject failed: %v", err)
+	}
+	if err := f.CopyObject("/g", f, "/g2"); err != nil {
+		t.Fatalf("CopyObject failed: %v", err)
+	}
+	var data []int32
+	if err := mustDataset(t, f, "/g2/h/data").Read(&data); err != nil || !reflect.DeepEqual(data, []int32{1, 2, 3}) {
+		t.Errorf("/g2/h/data = %v, %v; want [1 2 3]", data, err)
+	}
+
+	for _, tc := range []struct {
+		src, dst string
+		opts     []DatasetOption
+		want     error
+	}{
+		{"/data", "/g2/h/data", nil, ErrDuplicateLink},
+		{"/missing", "/x", nil, ErrNotFound},
+		{"/g", "/g/copy", nil, ErrInvalidPath},
+		{"/data", "/", nil, ErrInvalidPath},
+		{"/data", "/data/x", nil, ErrNotGroup},
+	} {
+		if err := f.CopyObject(tc.src, f, tc.dst, tc.opts...); !errors.Is(err, tc.want) {
+			t.Errorf("CopyObject(%q, %q) = %v, want %v", tc.src, tc.dst, err, tc.want)
+		}
+	}
+	if err := f.CopyObject("/data", f, "/g2/h/data", WithOverwrite(), WithAttribute("v", int32(2))); err != nil {
+		t.Errorf("CopyObject with WithOverwrite failed: %v", err)
+	} else if !mustDataset(t, f, "/g2/h/data").HasAttr("v") {
+		t.Errorf("overwritten copy has no attribute v")
+	}
+}
+
+// mustDataset opens the dataset at p in f.
+func mustDataset(t *testing.T, f *File, p string) *Dataset {
+	t.Helper()
+	ds, err := f.OpenDataset(p)
+	if err != nil {
+		t.Fatalf("OpenDataset(%q) failed: %v", p, err)
+	}
+	return ds
+}
@@ -1,9 +1,11 @@
 package hdf5
 
 import (
+	"errors"
 	"fmt"
 	"path"
 	"reflect"
+	"slices"
 
 	"github.com/robert-malhotra/go-hdf5/internal/dtype"
 	"github.com/robert-malhotra/go-hdf5/internal/layout"
@@ -12,27 +14,46 @@ import (
 )
 
 // Dataset represents an HDF5 dataset.
+//
+// Opening the same dataset more than once in a read-only file returns
+// distinct handles, differing only in Path, that share one datasetState:
+// the object header, dataspace, datatype and layout (including its lazily
+// loaded chunk index) are read once per file no matter how many handles
+// exist. The shared state is never modified after construction except for
+// the chunk index, which is loaded at most once under a sync.Once, so
+// handles may be read from concurrently.
 type Dataset struct {
-	file      *File
-	path      string
-	header    *object.Header
-	dataspace *message.Dataspace
-	datatype  *message.Datatype
-	layout    layout.Layout
+	*datasetState
+	file *File
+	path string
 
 	// Write support fields
+	addr        uint64 // Object header address of a dataset created for writing
 	dataAddr    uint64 // Address where data is stored
 	dataSize    uint64 // Size of data in bytes
 	numElements uint64 // Number of elements
 }
 
+// datasetState is the per-object state shared by every handle to a dataset.
+type datasetState struct {
+	header    *object.Header
+	dataspace *message.Dataspace
+	datatype  *message.Datatype
+	layout    layout.Layout
+}
+
 // newDataset creates a Dataset from an object header.
 func newDataset(f *File, path string, header *object.Header) (*Dataset, error) {
-	ds := &Dataset{
-		file:   f,
-		path:   path,
-		header: header,
+	state, err := newDatasetState(f, header)
+	if err != nil {
+		return nil, err
 	}
+	return &Dataset{datasetState: state, file: f, path: path}, nil
+}
+
+// newDatasetState decodes the dataset messages of an object header.
+func newDatasetState(f *File, header *object.Header) (*datasetState, error) {
+	ds := &datasetState{header: header}
 
 	// Get dataspace
 	ds.dataspace = header.Dataspace()
@@ -52,12 +73,42 @@ func newDataset(f *File, path string, header *object.Header) (*Dataset, error) {
 		return nil, fmt.Errorf("dataset missing layout message")
 	}
 
-	// Create layout handler
-	filterMsg := header.FilterPipeline()
+	// Raw data in external files is read from them instead
+	if efl := header.ExternalFiles(); efl != nil {
+		ds.layout = newExternalLayout(f, ds, layoutMsg.Class, efl)
+		return ds, nil
+	}
+
+	// Create layout handler; virtual datasets read from their sources
 	var err error
-	ds.layout, err = layout.New(layoutMsg, ds.dataspace, ds.datatype, filterMsg, f.reader)
+	if layoutMsg.Class == message.LayoutVirtual {
+		ds.layout = newVirtualLayout(f, ds, layoutMsg)
+	} else {
+		filterMsg := header.FilterPipeline()
+		ds.layout, err = layout.New(layoutMsg, ds.dataspace, ds.datatype, filterMsg, f.headerReader())
+		if err != nil {
+			return nil, fmt.Errorf("creating layout: %w", err)
+		}
+	}
+
+	// Chunks and contiguous data that were never written, and elements of
+	// virtual datasets no mapping covers, read as the fill value. A
+	// malformed fill value is reported by FillValue; chunk and virtual
+	// reads fall back to zeros, and contiguous data without a fill value
+	// fails with ErrNoStorage.
+	fill, err := fillValueBytes(header, ds.datatype)
 	if err != nil {
-		return nil, fmt.Errorf("creating layout: %w", err)
+		fill = nil
+	}
+	switch l := ds.layout.(type) {
+	case *layout.Chunked:
+		l.SetFillValue(fill)
+		// The policies are declared in the same order
+		l.SetChecksumPolicy(layout.ChecksumPolicy(f.checksums))
+	case *layout.Contiguous:
+		l.SetFillValue(fill)
+	case *layout.Virtual:
+		l.SetFillValue(fill)
 	}
 
 	return ds, nil
@@ -73,6 +124,11 @@ func (d *Dataset) Path() string {
 	return d.path
 }
 
+// Kind returns ObjectTypeDataset.
+func (d *Dataset) Kind() ObjectType {
+	return ObjectTypeDataset
+}
+
 // Shape returns the dimensions of the dataset.
 func (d *Dataset) Shape() []uint64 {
 	if d.dataspace.IsScalar() {
@@ -81,6 +137,22 @@ func (d *Dataset) Shape() []uint64 {
 	return d.dataspace.Dimensions
 }
 
+// Unlimited is the MaxShape of a dimension that can grow without bound.
+const Unlimited = message.Unlimited
+
+// MaxShape returns the maximum dimensions of the dataset, with Unlimited
+// for dimensions that can grow without bound. Datasets that cannot be
+// resized have the same maximum dimensions as Shape.
+func (d *Dataset) MaxShape() []uint64 {
+	if d.dataspace.IsScalar() {
+		return nil
+	}
+	if d.dataspace.MaxDims == nil {
+		return d.dataspace.Dimensions
+	}
+	return d.dataspace.MaxDims
+}
+
 // Rank returns the number of dimensions.
 func (d *Dataset) Rank() int {
 	return d.dataspace.Rank
@@ -91,6 +163,22 @@ func (d *Dataset) NumElements() uint64 {
 	return d.dataspace.NumElements()
 }
 
+// HasStorage reports whether the dataset's data has been allocated in the
+// file. HDF5 libraries allocate it lazily, so a dataset that was created
+// but never written has none; reading it gives the fill value, or fails
+// with ErrNoStorage if the dataset is contiguous and has no fill value
+// defined. Compact datasets and those in external files always have
+// storage.
+func (d *Dataset) HasStorage() bool {
+	switch l := d.layout.(type) {
+	case *layout.Contiguous:
+		return l.Allocated()
+	case *layout.Chunked:
+		return l.Allocated()
+	}
+	return true
+}
+
 // IsScalar returns true if the dataset is a scalar (single value).
 func (d *Dataset) IsScalar() bool {
 	return d.dataspace.IsScalar()
@@ -113,21 +201,130 @@ func (d *Dataset) GoType() (reflect.Type, error) {
 
 // Read reads all data from the dataset into dest.
 // dest should be a pointer to a slice of the appropriate type.
+//
+// Numeric, string and enum data is checked against the element type of
+// dest, and a destination that cannot hold every value fails with
+// ErrTypeMismatch: integers may widen, or become float64, and float32 may
+// become float64, but float64 data does not read into []int32 or
+// []float32. Enums also read into []string as member names. A pointer to
+// a single value such as *float64 reads a dataset of one element, and
+// nested slices such as *[][]float64 read a dataset of the same rank,
+// filled in row-major order.
+//
+// Compound data can be read into a struct, or a slice of structs, as well
+// as into maps. Each exported field is filled from the member named by its
+// `hdf5:"name"` tag, or else by the field name, compared exactly and then
+// ignoring case. A field with no matching member is an error unless tagged
+// `hdf5:"name,optional"`; fields tagged `hdf5:"-"` and members without a
+// field are ignored. Integer members may fill integer or float fields and
+// float members float fields; other mismatches are errors.
+//
+// Example:
+//
+//	type Point struct {
+//		X, Y  float64
+//		Label string `hdf5:"name"`
+//	}
+//	var points []Point
+//	err := ds.Read(&points)
 func (d *Dataset) Read(dest interface{}) error {
+	numElements := d.dataspace.NumElements()
+	target, err := d.readTarget(dest, numElements, d.Shape())
+	if err != nil {
+		return withPath(d.path, err)
+	}
+
 	// Read raw data
-	raw, err := d.layout.Read()
+	raw, err := d.readAll()
 	if err != nil {
-		return fmt.Errorf("reading data: %w", err)
+		return withPath(d.path, err)
 	}
+	return withPath(d.path, d.convert(target, raw, numElements))
+}
 
-	// Convert to Go types
-	numElements := d.dataspace.NumElements()
-	return dtype.Convert(d.datatype, raw, numElements, dest)
+// convert converts n raw values into target, resolving variable-length
+// data through the file.
+func (d *Dataset) convert(target *readTarget, raw []byte, n uint64) error {
+	if err := dtype.ConvertWithReader(d.datatype, raw, n, target.flat, d.file.reader); err != nil {
+		return err
+	}
+	target.finish()
+	return nil
 }
 
-// ReadRaw reads all data from the dataset as raw bytes.
+// ReadRaw reads all data from the dataset as raw bytes. In files opened
+// with WithMmap, the bytes of contiguous and compact datasets are shared
+// with the file: they must not be modified and are only valid until Close.
 func (d *Dataset) ReadRaw() ([]byte, error) {
-	return d.layout.Read()
+	raw, err := d.readAll()
+	if err != nil {
+		return nil, withPath(d.path, err)
+	}
+	return raw, nil
+}
+
+// ChunkError reports a chunk of a dataset that could not be decoded: its
+// Offset (the coordinates of its first element), its file Address and the
+// filter's error Err. Reads return one, and reads under
+// ChecksumWarnAndSkip record one for each chunk they take as the fill
+// value; see ReadErrors.
+type ChunkError = layout.ChunkError
+
+// ReadErrors returns the chunks that reads of the dataset took as the fill
+// value because their checksum did not match, with a file opened with
+// WithChecksumPolicy(ChecksumWarnAndSkip). Each chunk is listed once, in
+// the order the reads found them; the list is empty if every chunk read so
+// far was intact.
+func (d *Dataset) ReadErrors() []*ChunkError {
+	if chunked, ok := d.layout.(*layout.Chunked); ok {
+		return chunked.SkippedChunks()
+	}
+	return nil
+}
+
+// readAll returns the raw data of the whole dataset, without copying it
+// where the file is mapped into memory. Datasets without elements, such as
+// those with a null dataspace, read as no bytes whatever their layout.
+func (d *Dataset) readAll() ([]byte, error) {
+	if d.dataspace.NumElements() == 0 {
+		return []byte{}, nil
+	}
+	if d.file.mapped != nil {
+		switch l := d.layout.(type) {
+		case *layout.Contiguous:
+			if data, ok := l.ReadMapped(d.file.mapped); ok {
+				return data, nil
+			}
+		case *layout.Compact:
+			data, err := l.Data()
+			if err != nil {
+				return nil, d.storageError(err)
+			}
+			return data, nil
+		}
+	}
+	raw, err := d.layout.Read()
+	if err != nil {
+		return nil, d.storageError(err)
+	}
+	return raw, nil
+}
+
+// storageError wraps an error reading the dataset's raw data in a
+// ParseError for its storage: the chunk index of chunked datasets, or the
+// data of contiguous ones.
+func (d *Dataset) storageError(err error) error {
+	msg := d.header.DataLayout()
+	if msg == nil || errors.Is(err, ErrNoStorage) || d.header.ExternalFiles() != nil {
+		return err
+	}
+	switch msg.Class {
+	case message.LayoutChunked:
+		return structureError("chunked storage", msg.ChunkIndexAddr, err)
+	case message.LayoutContiguous:
+		return structureError("contiguous storage", msg.Address, err)
+	}
+	return err
 }
 
 // ReadSlice reads a hyperslab (rectangular selection) of the dataset.
@@ -140,25 +337,283 @@ func (d *Dataset) ReadRaw() ([]byte, error) {
 //	var result []float64
 //	err := ds.ReadSlice([]uint64{2, 5}, []uint64{5, 10}, &result)
 func (d *Dataset) ReadSlice(start, count []uint64, dest interface{}) error {
-	// Read raw slice data
-	raw, err := d.layout.ReadSlice(start, count)
-	if err != nil {
-		return fmt.Errorf("reading slice: %w", err)
+	return d.ReadSliceInto(dest, start, count)
+}
+
+// ReadSliceInto reads a hyperslab of the dataset into dest, which should be
+// a pointer to a slice of the appropriate type. It is ReadSlice with the
+// destination first. For chunked datasets only the chunks overlapping the
+// selection are read and decoded.
+//
+// start and count must have one entry per dimension, and the selection must
+// lie within the dataset's shape; otherwise an error wrapping ErrOutOfBounds
+// is returned. Scalar datasets cannot be sliced. dest is checked as in
+// Read, with nested slices matching the rank of count.
+func (d *Dataset) ReadSliceInto(dest interface{}, start, count []uint64) error {
+	if err := d.checkSelection(start, count); err != nil {
+		return withPath(d.path, err)
 	}
 
-	// Calculate number of elements in the slice
+	// Calculate number of elements in the slice; a null dataspace has none
 	numElements := uint64(1)
+	if d.dataspace.IsNull() {
+		numElements = 0
+	}
 	for _, c := range count {
 		numElements *= c
 	}
+	target, err := d.readTarget(dest, numElements, count)
+	if err != nil {
+		return withPath(d.path, err)
+	}
 
-	// Convert to Go types
-	return dtype.Convert(d.datatype, raw, numElements, dest)
+	raw, err := d.ReadSliceRaw(start, count)
+	if err != nil {
+		return err
+	}
+	return withPath(d.path, d.convert(target, raw, numElements))
 }
 
 // ReadSliceRaw reads a hyperslab as raw bytes without type conversion.
 func (d *Dataset) ReadSliceRaw(start, count []uint64) ([]byte, error) {
-	return d.layout.ReadSlice(start, count)
+	if err := d.checkSelection(start, count); err != nil {
+		return nil, withPath(d.path, err)
+	}
+	if slices.Contains(count, 0) || d.dataspace.IsNull() {
+		return []byte{}, nil
+	}
+	raw, err := d.layout.ReadSlice(start, count)
+	if err != nil {
+		return nil, withPath(d.path, d.storageError(err))
+	}
+	return raw, nil
+}
+
+// checkSelection validates a hyperslab against the dataset's shape.
+func (d *Dataset) checkSelection(start, count []uint64) error {
+	if d.dataspace.IsScalar() {
+		return fmt.Errorf("%w: dataset is scalar; slices only apply to datasets with dimensions", ErrUnsupported)
+	}
+	dims := d.dataspace.Dimensions
+	if len(start) != len(dims) || len(count) != len(dims) {
+		return fmt.Errorf("%w: dataset has rank %d, got start of length %d and count of length %d",
+			ErrOutOfBounds, len(dims), len(start), len(count))
+	}
+	for i, size := range dims {
+		// Written so that start+count cannot overflow
+		if count[i] > size || start[i] > size-count[i] {
+			return fmt.Errorf("%w: dimension %d has size %d, selection is start=%d count=%d",
+				ErrOutOfBounds, i, size, start[i], count[i])
+		}
+	}
+	return nil
+}
+
+// ReadPoints reads the elements at scattered coordinates into dest, which
+// should be a pointer to a slice of the appropriate type; element i of the
+// result is the one at coords[i]. For chunked datasets the points are
+// grouped by chunk and each chunk holding one is read and decoded once, so
+// a few points of a large dataset cost a few chunk reads. Contiguous
+// datasets read just the requested elements.
+//
+// Each point must have one coordinate per dimension and lie within the
+// dataset's shape; otherwise an error wrapping ErrOutOfBounds names the
+// offending point. The single element of a scalar dataset is the point with
+// no coordinates. dest is checked as in Read.
+//
+// Example for a 3D dataset:
+//
+//	var values []float32
+//	err := ds.ReadPoints([][]uint64{{0, 10, 20}, {500, 3, 7}}, &values)
+func (d *Dataset) ReadPoints(coords [][]uint64, dest interface{}) error {
+	if err := d.checkPoints(coords); err != nil {
+		return withPath(d.path, err)
+	}
+	n := uint64(len(coords))
+	target, err := d.readTarget(dest, n, []uint64{n})
+	if err != nil {
+		return withPath(d.path, err)
+	}
+	raw, err := d.layout.ReadPoints(coords)
+	if err != nil {
+		return withPath(d.path, d.storageError(err))
+	}
+	return withPath(d.path, d.convert(target, raw, n))
+}
+
+// ReadPoint reads the element at coords into dest, which should be a
+// pointer to a value of the appropriate type. It is ReadPoints for a single
+// point.
+//
+// Example:
+//
+//	var v float64
+//	err := ds.ReadPoint([]uint64{3, 4}, &v)
+func (d *Dataset) ReadPoint(coords []uint64, dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return withPath(d.path, fmt.Errorf("dest must be a non-nil pointer, got %T", dest))
+	}
+	values := reflect.New(reflect.SliceOf(v.Elem().Type()))
+	if err := d.ReadPoints([][]uint64{coords}, values.Interface()); err != nil {
+		return err
+	}
+	if values.Elem().Len() != 1 {
+		return withPath(d.path, fmt.Errorf("read %d values for one point", values.Elem().Len()))
+	}
+	v.Elem().Set(values.Elem().Index(0))
+	return nil
+}
+
+// checkPoints validates a point selection against the dataset's shape.
+func (d *Dataset) checkPoints(coords [][]uint64) error {
+	dims := d.dataspace.Dimensions
+	for i, point := range coords {
+		if len(point) != len(dims) {
+			return fmt.Errorf("%w: point %d has %d coordinates, dataset has rank %d",
+				ErrOutOfBounds, i, len(point), len(dims))
+		}
+		for j, size := range dims {
+			if point[j] >= size {
+				return fmt.Errorf("%w: point %d %v: dimension %d has size %d",
+					ErrOutOfBounds, i, point, j, size)
+			}
+		}
+	}
+	return nil
+}
+
+// ForEachChunk reads a chunked dataset one chunk at a time, calling fn with
+// each chunk's starting coordinates and its raw bytes, without ever holding
+// the whole dataset in memory. Only the chunk being visited is read and
+// decoded.
+//
+// Chunks are visited in the order of the file's chunk index. Chunks at the
+// upper edge of the dataset are trimmed to their part inside the dataset's
+// shape: data holds, in row-major order, the elements from offset up to
+// offset plus the chunk dimensions, clipped to Shape, each DtypeSize bytes.
+// Chunks that were never written are skipped; they read as the fill value.
+// fn may keep data. An error returned by fn stops the iteration and is
+// returned unchanged.
+//
+// Datasets with other layouts return an error wrapping ErrUnsupported.
+//
+// Example:
+//
+//	err := ds.ForEachChunk(func(offset []uint64, data []byte) error {
+//		fmt.Printf("chunk at %v: %d bytes\n", offset, len(data))
+//		return nil
+//	})
+func (d *Dataset) ForEachChunk(fn func(offset []uint64, data []byte) error) error {
+	chunked, ok := d.layout.(*layout.Chunked)
+	if !ok {
+		return withPath(d.path, fmt.Errorf("%w: dataset is not chunked", ErrUnsupported))
+	}
+	var fnErr error
+	err := chunked.ForEachChunk(func(offset []uint64, data []byte) error {
+		fnErr = fn(offset, data)
+		return fnErr
+	})
+	if err == nil || err == fnErr {
+		return err
+	}
+	return withPath(d.path, d.storageError(err))
+}
+
+// ReadCompoundColumns reads only the named members of a compound dataset and
+// returns one column of values per member, keyed by member name. Members that
+// are not requested are never decoded. An error listing every unknown name is
+// returned if any name is not a member of the datatype.
+//
+// Example:
+//
+//	cols, err := ds.ReadCompoundColumns([]string{"x", "y"})
+//	xs := cols["x"] // []interface{} with one value per element
+func (d *Dataset) ReadCompoundColumns(names []string) (map[string][]interface{}, error) {
+	if d.datatype.Class != message.ClassCompound {
+		return nil, withPath(d.path, fmt.Errorf("%w: dataset is not compound", ErrUnsupported))
+	}
+	if _, err := dtype.ProjectMembers(d.datatype, names); err != nil {
+		return nil, withPath(d.path, err)
+	}
+
+	raw, err := d.readAll()
+	if err != nil {
+		return nil, withPath(d.path, err)
+	}
+
+	cols, err := dtype.ConvertCompoundColumns(d.datatype, raw, d.dataspace.NumElements(), names, d.file.reader)
+	if err != nil {
+		return nil, withPath(d.path, err)
+	}
+	return cols, nil
+}
+
+// ReadVarLen reads a dataset of variable-length sequences (ragged rows),
+// returning one slice per element typed after the base type, for example
+// []int32 for a vlen int32 dataset. Empty sequences and null references
+// read as empty slices. To choose the row type, pass a pointer to a slice
+// of slices to Read instead:
+//
+//	var rows [][]float64
+//	err := ds.Read(&rows)
+//
+// Variable-length strings are read with ReadStrings.
+func (d *Dataset) ReadVarLen() ([]interface{}, error) {
+	if d.datatype.Class != message.ClassVarLen || d.datatype.IsVarLenString {
+		return nil, withPath(d.path, fmt.Errorf("%w: dataset is not a variable-length sequence", ErrUnsupported))
+	}
+	var result []interface{}
+	if err := d.Read(&result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ReadCompound reads a compound dataset as one map per element, keyed by
+// member name. Nested compound members are maps as well.
+func (d *Dataset) ReadCompound() ([]map[string]interface{}, error) {
+	if d.datatype.Class != message.ClassCompound {
+		return nil, withPath(d.path, fmt.Errorf("%w: dataset is not compound", ErrUnsupported))
+	}
+	var result []map[string]interface{}
+	if err := d.Read(&result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ReadInto reads a compound dataset into dest, which must be a pointer to a
+// slice of structs, or to a struct for a dataset of one element. Fields
+// are matched to members as described for Read; nested compound members
+// fill nested struct fields. Chunked and compressed datasets are decoded
+// in full first.
+//
+// Example:
+//
+//	type Row struct {
+//		ID        int32
+//		Timestamp int64
+//		Value     float64
+//	}
+//	var rows []Row
+//	err := ds.ReadInto(&rows)
+func (d *Dataset) ReadInto(dest interface{}) error {
+	if d.datatype.Class != message.ClassCompound {
+		return withPath(d.path, fmt.Errorf("%w: dataset is not compound", ErrUnsupported))
+	}
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return withPath(d.path, fmt.Errorf("dest must be a non-nil pointer to a struct or slice of structs, got %T", dest))
+	}
+	t := v.Type().Elem()
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return withPath(d.path, fmt.Errorf("dest must be a non-nil pointer to a struct or slice of structs, got %T", dest))
+	}
+	return d.Read(dest)
 }
 
 // ReadFloat64 reads the dataset as float64 values.
@@ -168,6 +623,34 @@ func (d *Dataset) ReadFloat64() ([]float64, error) {
 	return result, err
 }
 
+// ReadSliceFloat64 reads a hyperslab of the dataset as float64 values.
+func (d *Dataset) ReadSliceFloat64(start, count []uint64) ([]float64, error) {
+	var result []float64
+	err := d.ReadSliceInto(&result, start, count)
+	return result, err
+}
+
+// ReadSliceFloat32 reads a hyperslab of the dataset as float32 values.
+func (d *Dataset) ReadSliceFloat32(start, count []uint64) ([]float32, error) {
+	var result []float32
+	err := d.ReadSliceInto(&result, start, count)
+	return result, err
+}
+
+// ReadSliceInt64 reads a hyperslab of the dataset as int64 values.
+func (d *Dataset) ReadSliceInt64(start, count []uint64) ([]int64, error) {
+	var result []int64
+	err := d.ReadSliceInto(&result, start, count)
+	return result, err
+}
+
+// ReadSliceInt32 reads a hyperslab of the dataset as int32 values.
+func (d *Dataset) ReadSliceInt32(start, count []uint64) ([]int32, error) {
+	var result []int32
+	err := d.ReadSliceInto(&result, start, count)
+	return result, err
+}
+
 // ReadFloat32 reads the dataset as float32 values.
 func (d *Dataset) ReadFloat32() ([]float32, error) {
 	var result []float32
@@ -189,11 +672,65 @@ func (d *Dataset) ReadInt32() ([]int32, error) {
 	return result, err
 }
 
-// ReadString reads the dataset as string values.
+// ReadString reads the dataset as string values. It is the same as
+// ReadStrings.
 func (d *Dataset) ReadString() ([]string, error) {
+	return d.ReadStrings()
+}
+
+// ReadStrings reads a fixed-length or variable-length string dataset.
+// Fixed-length strings end at the first null byte, and space-padded ones
+// have their trailing spaces trimmed. Variable-length strings are read
+// from the global heap. Bytes are returned as stored, whatever the
+// character set.
+func (d *Dataset) ReadStrings() ([]string, error) {
+	if d.datatype.Class != message.ClassString && !d.datatype.IsVarLenString {
+		return nil, withPath(d.path, fmt.Errorf("%w: dataset is not a string type", ErrUnsupported))
+	}
 	var result []string
-	err := d.Read(&result)
-	return result, err
+	if err := d.Read(&result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ReadScalarString reads a string dataset holding a single value, such as
+// one with a scalar dataspace.
+func (d *Dataset) ReadScalarString() (string, error) {
+	if n := d.NumElements(); n != 1 {
+		return "", withPath(d.path, fmt.Errorf("dataset has %d elements, want 1", n))
+	}
+	vals, err := d.ReadStrings()
+	if err != nil {
+		return "", err
+	}
+	return vals[0], nil
+}
+
+// ReadEnumStrings reads an enum dataset as the names of its members.
+// Stored values that match no member are rendered as decimal numbers.
+func (d *Dataset) ReadEnumStrings() ([]string, error) {
+	if d.datatype.Class != message.ClassEnum {
+		return nil, withPath(d.path, fmt.Errorf("%w: dataset is not an enum", ErrUnsupported))
+	}
+	var result []string
+	if err := d.Read(&result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ReadOpaque reads an opaque dataset as one byte slice per element, along
+// with the tag describing the contents.
+func (d *Dataset) ReadOpaque() ([][]byte, string, error) {
+	if d.datatype.Class != message.ClassOpaque {
+		return nil, "", withPath(d.path, fmt.Errorf("%w: dataset is not opaque", ErrUnsupported))
+	}
+	var result [][]byte
+	if err := d.Read(&result); err != nil {
+		return nil, "", err
+	}
+	return result, d.datatype.OpaqueTag, nil
 }
 
 // ReadInt8 reads the dataset as int8 values.
@@ -241,19 +778,24 @@ func (d *Dataset) ReadUint64() ([]uint64, error) {
 // Attrs returns the attribute names for this dataset.
 func (d *Dataset) Attrs() []string {
 	var names []string
-	for _, msg := range d.header.GetMessages(message.TypeAttribute) {
-		attr := msg.(*message.Attribute)
+	for _, attr := range d.file.attributes(d.header, d.path) {
 		names = append(names, attr.Name)
 	}
 	return names
 }
 
+// AttrsOrdered returns the names of all attributes on this dataset in the
+// given order. Unlike Attrs, it fails if attributes in dense storage cannot
+// be read.
+func (d *Dataset) AttrsOrdered(by Order) ([]string, error) {
+	return d.file.attrsOrdered(d.header, d.path, by)
+}
+
 // Attr returns an attribute by name, or nil if not found.
 func (d *Dataset) Attr(name string) *Attribute {
-	for _, msg := range d.header.GetMessages(message.TypeAttribute) {
-		attr := msg.(*message.Attribute)
+	for _, attr := range d.file.attributes(d.header, d.path) {
 		if attr.Name == name {
-			return &Attribute{msg: attr, reader: d.file.reader}
+			return &Attribute{msg: attr, reader: d.file.reader, path: JoinAttrPath(d.path, name)}
 		}
 	}
 	return nil
@@ -0,0 +1,222 @@
+package hdf5
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/robert-malhotra/go-hdf5/internal/dtype"
+	"github.com/robert-malhotra/go-hdf5/internal/filter"
+	"github.com/robert-malhotra/go-hdf5/internal/layout"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// Append adds data to the end of the dataset along its first dimension,
+// growing it as h5py's resize followed by a write would. The dataset must
+// be chunked with an unlimited first dimension, created with
+// WithChunks and WithMaxDims(Unlimited, ...).
+//
+// data is a slice of values of the dataset's type, in row-major order; its
+// length must be a whole number of rows, each the size of the dataset's
+// other dimensions. A partly filled last chunk is read and written again
+// with the new rows, through the dataset's filters. The chunk index is
+// then written anew and the dataspace and layout messages updated in the
+// object header; the space of the old index and replaced chunks is not
+// reused.
+func (d *Dataset) Append(data interface{}) error {
+	if !d.file.writable {
+		return fmt.Errorf("file is not writable")
+	}
+
+	r := d.file.headerReader()
+	addr := d.headerAddr()
+	header, err := object.Read(r, addr)
+	if err != nil {
+		return fmt.Errorf("reading dataset header: %w", err)
+	}
+	dataspace, datatype, layoutMsg := header.Dataspace(), header.Datatype(), header.DataLayout()
+	if dataspace == nil || datatype == nil || layoutMsg == nil {
+		return fmt.Errorf("%s is missing dataset messages", d.path)
+	}
+	dims, maxDims := dataspace.Dimensions, dataspace.MaxDims
+	if !layoutMsg.IsChunked() || layoutMsg.ChunkIndexType != message.ChunkIndexExtensibleArray ||
+		len(dims) == 0 || len(maxDims) == 0 || maxDims[0] != Unlimited {
+		return fmt.Errorf("%w: appending to %s, which does not have a chunked, unlimited first dimension",
+			ErrUnsupported, d.path)
+	}
+
+	// Encode the new rows
+	val := reflect.ValueOf(data)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return fmt.Errorf("appending %T: data must be a slice", data)
+	}
+	if given, err := dtype.GoTypeToDatatype(val.Type().Elem()); err != nil ||
+		given.Class != datatype.Class || given.Size != datatype.Size {
+		return fmt.Errorf("%w: appending %T to %s", ErrTypeMismatch, data, d.path)
+	}
+	rowElements := uint64(1)
+	for _, n := range dims[1:] {
+		rowElements *= n
+	}
+	if rowElements == 0 || uint64(val.Len())%rowElements != 0 {
+		return fmt.Errorf("%w: %d values are not a whole number of %d-value rows",
+			ErrOutOfBounds, val.Len(), rowElements)
+	}
+	rows := uint64(val.Len()) / rowElements
+	if rows == 0 {
+		return nil
+	}
+	raw, err := dtype.Encode(datatype, val.Interface())
+	if err != nil {
+		return fmt.Errorf("encoding data: %w", err)
+	}
+
+	chunked, err := layout.New(layoutMsg, dataspace, datatype, header.FilterPipeline(), r)
+	if err != nil {
+		return fmt.Errorf("creating layout: %w", err)
+	}
+	c, ok := chunked.(*layout.Chunked)
+	if !ok {
+		return fmt.Errorf("%w: appending to %s, which is not chunked", ErrUnsupported, d.path)
+	}
+	addrs, sizes, err := c.ExtensibleArrayChunks()
+	if err != nil {
+		return fmt.Errorf("reading chunk index: %w", err)
+	}
+
+	// Rows already in a partly filled last chunk are written again with
+	// the new ones
+	chunkDims := layoutMsg.ChunkDims[:len(dims)]
+	tailStart := dims[0] / uint64(chunkDims[0]) * uint64(chunkDims[0])
+	if tailStart < dims[0] {
+		start := make([]uint64, len(dims))
+		count := append([]uint64{}, dims...)
+		start[0], count[0] = tailStart, dims[0]-tailStart
+		tail, err := c.ReadSlice(start, count)
+		if err != nil {
+			return fmt.Errorf("reading last chunk: %w", err)
+		}
+		raw = append(tail, raw...)
+	}
+
+	newDims := append([]uint64{}, dims...)
+	newDims[0] += rows
+	cw, err := d.file.chunkWriter(chunkDims, datatype, header.FilterPipeline())
+	if err != nil {
+		return err
+	}
+	addrs, sizes, err = writeExtensibleChunks(cw, raw, tailStart, newDims, maxDims, chunkDims, datatype.Size, addrs, sizes)
+	if err != nil {
+		return err
+	}
+	indexAddr, err := cw.WriteExtensibleArrayIndex(addrs, sizes)
+	if err != nil {
+		return fmt.Errorf("writing chunk index: %w", err)
+	}
+
+	// Point the header at the grown extent and the new index
+	newSpace := *dataspace
+	newSpace.Dimensions = newDims
+	newLayout := *layoutMsg
+	newLayout.ChunkIndexAddr = indexAddr
+	for _, msg := range []message.Serializable{&newSpace, &newLayout} {
+		err := object.ReplaceMessage(r, d.file.writer, addr, msg, d.file.allocate)
+		d.file.headers.invalidate(addr)
+		if err != nil {
+			return fmt.Errorf("updating dataset header: %w", err)
+		}
+	}
+
+	return d.reload()
+}
+
+// reload rereads the dataset's object header after it was rewritten.
+func (d *Dataset) reload() error {
+	header, err := object.Read(d.file.headerReader(), d.headerAddr())
+	if err != nil {
+		return fmt.Errorf("reading dataset header: %w", err)
+	}
+	state, err := newDatasetState(d.file, header)
+	if err != nil {
+		return err
+	}
+	d.datasetState = state
+	return nil
+}
+
+// headerAddr returns the address of the dataset's object header.
+func (d *Dataset) headerAddr() uint64 {
+	if d.header != nil {
+		return d.header.Address
+	}
+	return d.addr
+}
+
+// chunkWriter returns a writer of chunks of a dataset with the given filter
+// pipeline, which may be nil.
+func (f *File) chunkWriter(chunkDims []uint32, datatype *message.Datatype, pipeline *message.FilterPipeline) (*layout.ChunkWriter, error) {
+	cw := layout.NewChunkWriter(f.writer, chunkDims, datatype.Size, f.allocate)
+	if pipeline != nil {
+		filters, err := filter.NewPipeline(pipeline)
+		if err != nil {
+			return nil, fmt.Errorf("creating filter pipeline: %w", err)
+		}
+		cw.SetPipeline(filters)
+	}
+	return cw, nil
+}
+
+// writeExtensibleChunks writes the chunks of rows [origin, dims[0]) of a
+// dataset indexed by an extensible array, given their data in row-major
+// order, and returns addrs and sizes, the chunk addresses and stored sizes
+// by index in the array, updated with them. origin must be the start of a
+// chunk.
+func writeExtensibleChunks(cw *layout.ChunkWriter, raw []byte, origin uint64, dims, maxDims []uint64,
+	chunkDims []uint32, elementSize uint32, addrs []uint64, sizes []uint64) ([]uint64, []uint64, error) {
+	partDims := append([]uint64{}, dims...)
+	partDims[0] -= origin
+	for _, n := range partDims {
+		if n == 0 {
+			return addrs, sizes, nil
+		}
+	}
+
+	// Visit the chunks of the part in row-major order
+	var chunks [][]byte
+	var indexes []uint64
+	offset := make([]uint64, len(dims))
+	for {
+		chunks = append(chunks, layout.ExtractChunk(raw, partDims, offset, chunkDims, elementSize))
+		global := append([]uint64{}, offset...)
+		global[0] += origin
+		indexes = append(indexes, layout.ExtensibleArrayChunkIndex(global, dims, maxDims, chunkDims))
+
+		d := len(dims) - 1
+		for ; d >= 0; d-- {
+			offset[d] += uint64(chunkDims[d])
+			if offset[d] < partDims[d] {
+				break
+			}
+			offset[d] = 0
+		}
+		if d < 0 {
+			break
+		}
+	}
+
+	chunkAddrs, chunkSizes, err := cw.WriteChunks(chunks)
+	if err != nil {
+		return nil, nil, fmt.Errorf("writing chunks: %w", err)
+	}
+	for i, idx := range indexes {
+		for uint64(len(addrs)) <= idx {
+			addrs = append(addrs, ^uint64(0))
+			sizes = append(sizes, 0)
+		}
+		addrs[idx], sizes[idx] = chunkAddrs[i], chunkSizes[i]
+	}
+	return addrs, sizes, nil
+}
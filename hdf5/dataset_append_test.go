@@ -0,0 +1,113 @@
+package hdf5
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "append.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	var want []float64
+	for i := 0; i < 5; i++ {
+		want = append(want, float64(i))
+	}
+	series, err := f.Root().CreateDataset("series", want, WithChunks(4), WithMaxDims(Unlimited))
+	if err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	packed, err := f.Root().CreateDataset("packed", []int32{}, WithChunks(8), WithMaxDims(Unlimited),
+		WithShuffle(), WithGzip(4))
+	if err != nil {
+		t.Fatalf("CreateDataset packed failed: %v", err)
+	}
+
+	// Batches of uneven sizes, across chunk boundaries and past the
+	// elements the index block holds
+	var wantPacked []int32
+	for batch := 1; batch < 60; batch++ {
+		var rows []float64
+		var packedRows []int32
+		for i := 0; i < batch%7+batch/3; i++ {
+			rows = append(rows, float64(len(want)+len(rows)))
+			packedRows = append(packedRows, int32(len(wantPacked)+len(packedRows))/3)
+		}
+		if err := series.Append(rows); err != nil {
+			t.Fatalf("Append batch %d failed: %v", batch, err)
+		}
+		if err := packed.Append(packedRows); err != nil {
+			t.Fatalf("Append packed batch %d failed: %v", batch, err)
+		}
+		want = append(want, rows...)
+		wantPacked = append(wantPacked, packedRows...)
+	}
+	if got := series.Shape(); !reflect.DeepEqual(got, []uint64{uint64(len(want))}) {
+		t.Errorf("Shape = %v, want [%d]", got, len(want))
+	}
+	var got []float64
+	if err := series.Read(&got); err != nil {
+		t.Fatalf("Read before Close failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Read before Close differs")
+	}
+
+	fixed, err := f.Root().CreateDataset("fixed", []float64{1, 2}, WithChunks(2))
+	if err != nil {
+		t.Fatalf("CreateDataset fixed failed: %v", err)
+	}
+	if err := fixed.Append([]float64{3}); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Append to fixed-size dataset: got %v, want ErrUnsupported", err)
+	}
+	if err := series.Append([]int32{1}); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("Append of int32 to float64 dataset: got %v, want ErrTypeMismatch", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	ds, err := f.OpenDataset("/series")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if !reflect.DeepEqual(ds.MaxShape(), []uint64{Unlimited}) {
+		t.Errorf("MaxShape = %v, want [Unlimited]", ds.MaxShape())
+	}
+	got = nil
+	if err := ds.Read(&got); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("read %d values after reopening, want %d appended", len(got), len(want))
+	}
+
+	ds, err = f.OpenDataset("/packed")
+	if err != nil {
+		t.Fatalf("OpenDataset packed failed: %v", err)
+	}
+	var gotPacked []int32
+	if err := ds.Read(&gotPacked); err != nil {
+		t.Fatalf("Read packed failed: %v", err)
+	}
+	if !reflect.DeepEqual(gotPacked, wantPacked) {
+		t.Errorf("read %d packed values, want %d appended", len(gotPacked), len(wantPacked))
+	}
+	var part []int32
+	if err := ds.ReadSlice([]uint64{100}, []uint64{3}, &part); err != nil {
+		t.Fatalf("ReadSlice packed failed: %v", err)
+	}
+	if !reflect.DeepEqual(part, wantPacked[100:103]) {
+		t.Errorf("ReadSlice = %v, want %v", part, wantPacked[100:103])
+	}
+}
@@ -0,0 +1,118 @@
+package hdf5
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/robert-malhotra/go-hdf5/internal/dtype"
+	"github.com/robert-malhotra/go-hdf5/internal/layout"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// WriteSlice writes data into the hyperslab of the dataset starting at
+// start with count elements along each dimension, leaving the rest of the
+// dataset as it was. It is the counterpart of ReadSlice.
+//
+// data is a slice of values of the dataset's type holding the selection in
+// row-major order; its length must be the product of count, otherwise an
+// error wrapping ErrOutOfBounds is returned, as it is for a selection
+// outside the dataset's shape. Values of another type return an error
+// wrapping ErrTypeMismatch.
+//
+// Contiguous data is written in place. Chunks the selection covers only in
+// part are read, patched and written again; filtered chunks, and chunks not
+// yet written, are written to new space and the chunk index rewritten.
+// Chunked datasets indexed by a B-tree return an error wrapping
+// ErrUnsupported in that case.
+func (d *Dataset) WriteSlice(start, count []uint64, data interface{}) error {
+	if !d.file.writable {
+		return fmt.Errorf("file is not writable")
+	}
+
+	// Work from the header as it is in the file
+	if err := d.reload(); err != nil {
+		return err
+	}
+	if d.header.ExternalFiles() != nil {
+		return fmt.Errorf("%w: writing to %s, which is stored in external files", ErrUnsupported, d.path)
+	}
+	if err := d.checkSelection(start, count); err != nil {
+		return withPath(d.path, err)
+	}
+
+	val := reflect.ValueOf(data)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return fmt.Errorf("writing %T: data must be a slice", data)
+	}
+	if given, err := dtype.GoTypeToDatatype(val.Type().Elem()); err != nil ||
+		given.Class != d.datatype.Class || given.Size != d.datatype.Size {
+		return fmt.Errorf("%w: writing %T to %s", ErrTypeMismatch, data, d.path)
+	}
+	numElements := uint64(1)
+	for _, n := range count {
+		numElements *= n
+	}
+	if uint64(val.Len()) != numElements {
+		return fmt.Errorf("%w: %d values for a selection of %d elements", ErrOutOfBounds, val.Len(), numElements)
+	}
+	if numElements == 0 {
+		return nil
+	}
+	raw, err := dtype.Encode(d.datatype, val.Interface())
+	if err != nil {
+		return fmt.Errorf("encoding data: %w", err)
+	}
+
+	r := d.file.headerReader()
+	layoutMsg := d.header.DataLayout()
+	switch l := d.layout.(type) {
+	case *layout.Contiguous:
+		if err := l.WriteSlice(d.file.writer, start, count, raw); err != nil {
+			return fmt.Errorf("writing slice: %w", err)
+		}
+		return nil
+
+	case *layout.Compact:
+		compact, err := l.WriteSlice(start, count, raw)
+		if err != nil {
+			return fmt.Errorf("writing slice: %w", err)
+		}
+		newLayout := *layoutMsg
+		newLayout.CompactData = compact
+		err = object.ReplaceMessage(r, d.file.writer, d.headerAddr(), &newLayout, d.file.allocate)
+		d.file.headers.invalidate(d.headerAddr())
+		if err != nil {
+			return fmt.Errorf("updating dataset header: %w", err)
+		}
+
+	case *layout.Chunked:
+		chunkDims := layoutMsg.ChunkDims[:len(d.dataspace.Dimensions)]
+		cw, err := d.file.chunkWriter(chunkDims, d.datatype, d.header.FilterPipeline())
+		if err != nil {
+			return err
+		}
+		newLayout, err := l.WriteSlice(cw, start, count, raw)
+		if errors.Is(err, layout.ErrIndexNotWritable) {
+			return fmt.Errorf("%w: writing to %s: %v", ErrUnsupported, d.path, err)
+		}
+		if err != nil {
+			return fmt.Errorf("writing slice: %w", err)
+		}
+		// Without a new layout, every chunk was overwritten in place
+		if newLayout != nil {
+			err := object.ReplaceMessage(r, d.file.writer, d.headerAddr(), newLayout, d.file.allocate)
+			d.file.headers.invalidate(d.headerAddr())
+			if err != nil {
+				return fmt.Errorf("updating dataset header: %w", err)
+			}
+		}
+
+	default:
+		return fmt.Errorf("%w: writing to %s with layout class %d", ErrUnsupported, d.path, layoutMsg.Class)
+	}
+	return d.reload()
+}
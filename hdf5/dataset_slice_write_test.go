@@ -0,0 +1,144 @@
+package hdf5
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+func TestWriteSlice(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "slices.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// A checkerboard of 2x3 blocks over a 6x9 grid, written one block at a
+	// time into contiguous storage
+	const rows, cols = 6, 9
+	board, err := f.Root().CreateDatasetWithType("board", []uint64{rows, cols},
+		message.NewFixedPointDatatype(4, true, message.OrderLE))
+	if err != nil {
+		t.Fatalf("CreateDatasetWithType failed: %v", err)
+	}
+	wantBoard := make([]int32, rows*cols)
+	for bi := uint64(0); bi < rows; bi += 2 {
+		for bj := uint64(0); bj < cols; bj += 3 {
+			block := make([]int32, 6)
+			for k := range block {
+				if (bi/2+bj/3)%2 == 0 {
+					block[k] = 1
+				} else {
+					block[k] = int32(-10 - k)
+				}
+				wantBoard[(bi+uint64(k)/3)*cols+bj+uint64(k)%3] = block[k]
+			}
+			if err := board.WriteSlice([]uint64{bi, bj}, []uint64{2, 3}, block); err != nil {
+				t.Fatalf("WriteSlice block (%d, %d) failed: %v", bi, bj, err)
+			}
+		}
+	}
+	// Whole rows are written as one run
+	if err := board.WriteSlice([]uint64{4, 0}, []uint64{1, cols}, make([]int32, cols)); err != nil {
+		t.Fatalf("WriteSlice row failed: %v", err)
+	}
+	for j := 0; j < cols; j++ {
+		wantBoard[4*cols+j] = 0
+	}
+
+	// Chunked datasets, filtered across several chunks, unfiltered and
+	// written in place, and grown with Append
+	wantPacked := make([]float64, 20)
+	for i := range wantPacked {
+		wantPacked[i] = float64(i)
+	}
+	packed, err := f.Root().CreateDataset("packed", wantPacked, WithChunks(6), WithShuffle(), WithGzip(5))
+	if err != nil {
+		t.Fatalf("CreateDataset packed failed: %v", err)
+	}
+	plain, err := f.Root().CreateDataset("plain", wantPacked, WithChunks(6))
+	if err != nil {
+		t.Fatalf("CreateDataset plain failed: %v", err)
+	}
+	series, err := f.Root().CreateDataset("series", []float64{}, WithChunks(4), WithMaxDims(Unlimited), WithGzip(1))
+	if err != nil {
+		t.Fatalf("CreateDataset series failed: %v", err)
+	}
+	if err := series.Append(wantPacked[:10]); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	wantSeries := append([]float64{}, wantPacked[:10]...)
+	for _, sel := range [][2]uint64{{3, 6}, {17, 3}, {12, 6}, {0, 1}} {
+		values := make([]float64, sel[1])
+		for i := range values {
+			values[i] = -float64(sel[0]) - float64(i)/10
+		}
+		for _, ds := range []*Dataset{packed, plain} {
+			if err := ds.WriteSlice([]uint64{sel[0]}, []uint64{sel[1]}, values); err != nil {
+				t.Fatalf("WriteSlice %v to %s failed: %v", sel, ds.Name(), err)
+			}
+		}
+		copy(wantPacked[sel[0]:], values)
+		if sel[0]+sel[1] <= uint64(len(wantSeries)) {
+			if err := series.WriteSlice([]uint64{sel[0]}, []uint64{sel[1]}, values); err != nil {
+				t.Fatalf("WriteSlice %v to series failed: %v", sel, err)
+			}
+			copy(wantSeries[sel[0]:], values)
+		}
+	}
+
+	var got []float64
+	if err := packed.Read(&got); err != nil {
+		t.Fatalf("Read before Close failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, wantPacked) {
+		t.Errorf("Read before Close = %v, want %v", got, wantPacked)
+	}
+
+	if err := board.WriteSlice([]uint64{5, 0}, []uint64{2, 1}, []int32{1, 2}); !errors.Is(err, ErrOutOfBounds) {
+		t.Errorf("WriteSlice past the end: got %v, want ErrOutOfBounds", err)
+	}
+	if err := board.WriteSlice([]uint64{0, 0}, []uint64{2, 2}, []int32{1, 2}); !errors.Is(err, ErrOutOfBounds) {
+		t.Errorf("WriteSlice with too few values: got %v, want ErrOutOfBounds", err)
+	}
+	if err := packed.WriteSlice([]uint64{0}, []uint64{1}, []int32{1}); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("WriteSlice of int32 to float64 dataset: got %v, want ErrTypeMismatch", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	ds, err := f.OpenDataset("/board")
+	if err != nil {
+		t.Fatalf("OpenDataset board failed: %v", err)
+	}
+	var gotBoard []int32
+	if err := ds.Read(&gotBoard); err != nil {
+		t.Fatalf("Read board failed: %v", err)
+	}
+	if !reflect.DeepEqual(gotBoard, wantBoard) {
+		t.Errorf("board = %v, want %v", gotBoard, wantBoard)
+	}
+
+	for name, want := range map[string][]float64{"packed": wantPacked, "plain": wantPacked, "series": wantSeries} {
+		ds, err := f.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset %s failed: %v", name, err)
+		}
+		got = nil
+		if err := ds.Read(&got); err != nil {
+			t.Fatalf("Read %s failed: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+}
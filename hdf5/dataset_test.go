@@ -0,0 +1,42 @@
+package hdf5
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMaxShape(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "maxshape.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("fixed", []int32{1, 2, 3}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	data := []int32{1, 2, 3, 4, 5, 6}
+	if _, err := f.Root().CreateDataset("growable", data, WithChunks(4), WithMaxDims(0)); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	f.Close()
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	for name, want := range map[string][]uint64{
+		"fixed":    {3},
+		"growable": {Unlimited},
+	} {
+		ds, err := f.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset %s failed: %v", name, err)
+		}
+		if got := ds.MaxShape(); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: MaxShape() = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path"
 	"reflect"
+	"time"
 
 	"github.com/robert-malhotra/go-hdf5/internal/dtype"
 	"github.com/robert-malhotra/go-hdf5/internal/layout"
@@ -12,7 +13,9 @@ import (
 )
 
 // CreateDataset creates a new dataset with the given name, dimensions, and data type.
-// The datatype is inferred from the provided Go type.
+// The datatype is inferred from the provided Go type. An error wrapping
+// ErrDuplicateLink is returned if g already has a member named name, unless
+// WithOverwrite is given.
 func (g *Group) CreateDataset(name string, data interface{}, opts ...DatasetOption) (*Dataset, error) {
 	if !g.file.writable {
 		return nil, fmt.Errorf("file is not writable")
@@ -21,11 +24,17 @@ func (g *Group) CreateDataset(name string, data interface{}, opts ...DatasetOpti
 	if name == "" {
 		return nil, fmt.Errorf("dataset name cannot be empty")
 	}
-
 	options := defaultDatasetOptions()
 	for _, opt := range opts {
 		opt(options)
 	}
+	if options.chunks != nil && g.file.superblock.Version < 2 {
+		return nil, fmt.Errorf("%w: chunked datasets in files with a version %d superblock",
+			ErrUnsupported, g.file.superblock.Version)
+	}
+	if err := g.checkNewDataset(name, options); err != nil {
+		return nil, err
+	}
 
 	// Get the data value and type
 	dataVal := reflect.ValueOf(data)
@@ -60,26 +69,132 @@ func (g *Group) CreateDataset(name string, data interface{}, opts ...DatasetOpti
 		return nil, fmt.Errorf("encoding data: %w", err)
 	}
 
-	// Determine layout
-	var dataLayout *message.DataLayout
+	// Write the data
+	pipeline := options.filterPipeline(datatype.Size)
+	if pipeline != nil && options.chunks == nil {
+		return nil, fmt.Errorf("filters require a chunked layout; use WithChunks")
+	}
+	dataLayout, err := g.file.writeData(rawData, dims, options.maxDims, options.chunks, datatype, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create dataset object header
+	messages := object.NewDatasetHeader(dataspace, datatype, dataLayout)
+	if pipeline != nil {
+		messages = append(messages, pipeline)
+	}
+
+	// Add attributes if specified
+	for _, attr := range options.attributes {
+		attrMsg, err := createAttributeMessage(attr.name, attr.value)
+		if err != nil {
+			return nil, fmt.Errorf("creating attribute %q: %w", attr.name, err)
+		}
+		messages = append(messages, attrMsg)
+	}
+
+	// Write the dataset object header
+	datasetAddr, err := g.file.writeObjectHeader(messages, 0)
+	if err != nil {
+		return nil, fmt.Errorf("writing dataset header: %w", err)
+	}
+
+	// Create a hard link from parent group to this dataset
+	link := message.NewHardLink(name, datasetAddr)
+	if err := g.addLink(link); err != nil {
+		return nil, fmt.Errorf("adding link to parent: %w", err)
+	}
+
+	// Calculate the path for the new dataset
+	newPath := path.Join(g.path, name)
+	if g.path == "/" {
+		newPath = "/" + name
+	}
+
+	// Create the Dataset object
+	ds := &Dataset{
+		datasetState: &datasetState{
+			header:    nil, // Will be loaded on demand
+			dataspace: dataspace,
+			datatype:  datatype,
+			layout:    nil,
+		},
+		file: g.file,
+		path: newPath,
+		addr: datasetAddr,
+	}
 
-	if options.chunks != nil {
+	return ds, nil
+}
+
+// writeData writes the raw data of a new dataset of shape dims, in chunks
+// of shape chunks through pipeline if chunks is not nil and contiguously
+// otherwise, and returns the layout message describing where it went.
+func (f *File) writeData(rawData []byte, dims, maxDims, chunks []uint64, datatype *message.Datatype,
+	pipeline *message.FilterPipeline) (*message.DataLayout, error) {
+	var dataLayout *message.DataLayout
+	if chunks != nil {
 		// Chunked layout
-		chunkDims := make([]uint32, len(options.chunks))
-		for i, c := range options.chunks {
+		chunkDims := make([]uint32, len(chunks))
+		for i, c := range chunks {
 			chunkDims[i] = uint32(c)
 		}
 
 		// Create chunk writer
-		cw := layout.NewChunkWriter(g.file.writer, chunkDims, datatype.Size, g.file.allocate)
+		cw, err := f.chunkWriter(chunkDims, datatype, pipeline)
+		if err != nil {
+			return nil, err
+		}
 
 		// Check if data fits in a single chunk
 		chunkSize := cw.ChunkSize()
 		dataSize := uint64(len(rawData))
 
-		if dataSize <= chunkSize {
+		unlimited := 0
+		for _, d := range maxDims {
+			if d == Unlimited {
+				unlimited++
+			}
+		}
+
+		if unlimited > 1 {
+			return nil, fmt.Errorf("%w: datasets with more than one unlimited dimension", ErrUnsupported)
+		} else if unlimited == 1 {
+			// A dimension that can grow - use Extensible Array (EAHD), which
+			// Append rewrites as the dataset grows
+			if len(chunkDims) != len(dims) || len(maxDims) != len(dims) {
+				return nil, fmt.Errorf("dataset has rank %d, chunks have rank %d and maximum dimensions rank %d",
+					len(dims), len(chunkDims), len(maxDims))
+			}
+			chunkAddrs, chunkSizes, err := writeExtensibleChunks(cw, rawData, 0, dims, maxDims, chunkDims, datatype.Size, nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			indexAddr, err := cw.WriteExtensibleArrayIndex(chunkAddrs, chunkSizes)
+			if err != nil {
+				return nil, fmt.Errorf("writing chunk index: %w", err)
+			}
+
+			dataLayout = message.NewChunkedLayout(chunkDims, datatype.Size, message.ChunkIndexExtensibleArray)
+			dataLayout.ChunkIndexAddr = indexAddr
+		} else if dataSize <= chunkSize && cw.Filtered() {
+			// Single filtered chunk - the Single Chunk index records its
+			// stored size and filter mask in the layout message
+			padded := layout.PadChunk(rawData, dims, chunkDims, datatype.Size)
+			chunkAddrs, chunkSizes, err := cw.WriteChunks([][]byte{padded})
+			if err != nil {
+				return nil, fmt.Errorf("writing chunk: %w", err)
+			}
+
+			dataLayout = message.NewChunkedLayout(chunkDims, datatype.Size, message.ChunkIndexSingleChunk)
+			dataLayout.ChunkFlags = 0x02
+			dataLayout.ChunkIndexAddr = chunkAddrs[0]
+			dataLayout.FilteredChunkSize = chunkSizes[0]
+			dataLayout.FilterMask = cw.FilterMask()
+		} else if dataSize <= chunkSize {
 			// Single chunk - use Implicit index type (compatible with h5py)
-			chunkAddr, err := cw.WriteSingleChunk(rawData)
+			chunkAddr, err := cw.WriteSingleChunk(layout.PadChunk(rawData, dims, chunkDims, datatype.Size))
 			if err != nil {
 				return nil, fmt.Errorf("writing chunk: %w", err)
 			}
@@ -91,13 +206,13 @@ func (g *Group) CreateDataset(name string, data interface{}, opts ...DatasetOpti
 			// Multiple chunks - use Fixed Array (FAHD/FADB)
 			// Note: h5py compatibility is limited for multi-chunk datasets
 			chunks := layout.SplitIntoChunks(rawData, dims, chunkDims, datatype.Size)
-			chunkAddrs, err := cw.WriteChunks(chunks)
+			chunkAddrs, chunkSizes, err := cw.WriteChunks(chunks)
 			if err != nil {
 				return nil, fmt.Errorf("writing chunks: %w", err)
 			}
 
 			// Write the fixed array index
-			indexAddr, err := cw.WriteFixedArrayIndex(chunkAddrs, nil)
+			indexAddr, err := cw.WriteFixedArrayIndex(chunkAddrs, chunkSizes)
 			if err != nil {
 				return nil, fmt.Errorf("writing chunk index: %w", err)
 			}
@@ -108,10 +223,10 @@ func (g *Group) CreateDataset(name string, data interface{}, opts ...DatasetOpti
 	} else {
 		// Contiguous layout
 		dataSize := uint64(len(rawData))
-		dataAddr := g.file.allocate(int64(dataSize))
+		dataAddr := f.allocate(int64(dataSize))
 
 		// Write the raw data
-		w := g.file.writer.At(int64(dataAddr))
+		w := f.writer.At(int64(dataAddr))
 		if err := w.WriteBytes(rawData); err != nil {
 			return nil, fmt.Errorf("writing data: %w", err)
 		}
@@ -119,51 +234,22 @@ func (g *Group) CreateDataset(name string, data interface{}, opts ...DatasetOpti
 		dataLayout = message.NewContiguousLayout(dataAddr, dataSize)
 	}
 
-	// Create dataset object header
-	messages := object.NewDatasetHeader(dataspace, datatype, dataLayout)
+	return dataLayout, nil
+}
 
-	// Add attributes if specified
-	for _, attr := range options.attributes {
-		attrMsg, err := createAttributeMessage(attr.name, attr.value)
+// checkNewDataset checks that a dataset can be created as name in g,
+// unlinking an existing member first if the options allow it.
+func (g *Group) checkNewDataset(name string, options *datasetOptions) error {
+	if options.overwrite {
+		link, err := g.pendingLink(name)
 		if err != nil {
-			return nil, fmt.Errorf("creating attribute %q: %w", attr.name, err)
+			return err
+		}
+		if link != nil {
+			return g.Unlink(name)
 		}
-		messages = append(messages, attrMsg)
-	}
-
-	// Calculate header size and allocate
-	headerSize := object.HeaderSize(g.file.writer, messages)
-	datasetAddr := g.file.allocate(int64(headerSize))
-
-	// Write the dataset object header
-	hw := g.file.writer.At(int64(datasetAddr))
-	if _, err := object.WriteHeader(hw, messages); err != nil {
-		return nil, fmt.Errorf("writing dataset header: %w", err)
-	}
-
-	// Create a hard link from parent group to this dataset
-	link := message.NewHardLink(name, datasetAddr)
-	if err := g.addLink(link); err != nil {
-		return nil, fmt.Errorf("adding link to parent: %w", err)
-	}
-
-	// Calculate the path for the new dataset
-	newPath := path.Join(g.path, name)
-	if g.path == "/" {
-		newPath = "/" + name
-	}
-
-	// Create the Dataset object
-	ds := &Dataset{
-		file:      g.file,
-		path:      newPath,
-		header:    nil, // Will be loaded on demand
-		dataspace: dataspace,
-		datatype:  datatype,
-		layout:    nil,
 	}
-
-	return ds, nil
+	return g.checkNewLink(name)
 }
 
 // CreateDatasetWithType creates a new dataset with explicit dimensions and datatype.
@@ -175,11 +261,13 @@ func (g *Group) CreateDatasetWithType(name string, dims []uint64, dt *message.Da
 	if name == "" {
 		return nil, fmt.Errorf("dataset name cannot be empty")
 	}
-
 	options := defaultDatasetOptions()
 	for _, opt := range opts {
 		opt(options)
 	}
+	if err := g.checkNewDataset(name, options); err != nil {
+		return nil, err
+	}
 
 	// Create dataspace
 	dataspace := message.NewDataspace(dims, options.maxDims)
@@ -200,13 +288,9 @@ func (g *Group) CreateDatasetWithType(name string, dims []uint64, dt *message.Da
 	// Create dataset object header
 	messages := object.NewDatasetHeader(dataspace, dt, layout)
 
-	// Calculate header size and allocate
-	headerSize := object.HeaderSize(g.file.writer, messages)
-	datasetAddr := g.file.allocate(int64(headerSize))
-
 	// Write the dataset object header
-	hw := g.file.writer.At(int64(datasetAddr))
-	if _, err := object.WriteHeader(hw, messages); err != nil {
+	datasetAddr, err := g.file.writeObjectHeader(messages, 0)
+	if err != nil {
 		return nil, fmt.Errorf("writing dataset header: %w", err)
 	}
 
@@ -224,13 +308,16 @@ func (g *Group) CreateDatasetWithType(name string, dims []uint64, dt *message.Da
 
 	// Create the Dataset object with write capability
 	ds := &Dataset{
-		file:      g.file,
-		path:      newPath,
-		header:    nil,
-		dataspace: dataspace,
-		datatype:  dt,
-		layout:    nil,
+		datasetState: &datasetState{
+			header:    nil,
+			dataspace: dataspace,
+			datatype:  dt,
+			layout:    nil,
+		},
+		file: g.file,
+		path: newPath,
 		// Write support
+		addr:        datasetAddr,
 		dataAddr:    dataAddr,
 		dataSize:    dataSize,
 		numElements: numElements,
@@ -296,7 +383,20 @@ func inferDimensionsAndType(val reflect.Value) ([]uint64, reflect.Type, error) {
 }
 
 // createAttributeMessage creates an attribute message from a name and value.
+// Times are stored as int64 seconds since the Unix epoch, which h5py reads
+// as plain integers.
 func createAttributeMessage(name string, value interface{}) (*message.Attribute, error) {
+	switch v := value.(type) {
+	case time.Time:
+		value = v.Unix()
+	case []time.Time:
+		secs := make([]int64, len(v))
+		for i, t := range v {
+			secs[i] = t.Unix()
+		}
+		value = secs
+	}
+
 	// Get the value and type
 	val := reflect.ValueOf(value)
 	if val.Kind() == reflect.Ptr {
@@ -331,8 +431,25 @@ func createAttributeMessage(name string, value interface{}) (*message.Attribute,
 		elemType = val.Type()
 	}
 
-	// Create datatype from element type
-	datatype, err := dtype.GoTypeToDatatype(elemType)
+	// Create datatype from element type; structs become compound types, as
+	// do maps with string keys, whose member types come from their values
+	var datatype *message.Datatype
+	var err error
+	if elemType.Kind() == reflect.Map || elemType.Kind() == reflect.Interface {
+		sample := val
+		if dims != nil {
+			if val.Len() == 0 {
+				return nil, fmt.Errorf("empty compound attribute not supported")
+			}
+			sample = val.Index(0)
+		}
+		if sample.Kind() == reflect.Interface {
+			sample = sample.Elem()
+		}
+		datatype, err = dtype.MapDatatype(sample)
+	} else {
+		datatype, err = dtype.GoTypeToDatatype(elemType)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("unsupported attribute type %v: %w", elemType, err)
 	}
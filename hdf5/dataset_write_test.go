@@ -3,6 +3,7 @@ package hdf5
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/robert-malhotra/go-hdf5/internal/message"
@@ -427,3 +428,97 @@ func TestCreateMultiChunkDataset(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateCompressedDataset(t *testing.T) {
+	dir := t.TempDir()
+
+	// Smooth data compresses well, especially once shuffled
+	data := make([]float64, 10000)
+	for i := range data {
+		data[i] = float64(i / 10)
+	}
+	small := []int32{1, 2, 3, 4, 5, 6}
+
+	write := func(name string, opts ...DatasetOption) int64 {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		f, err := Create(path)
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if _, err := f.Root().CreateDataset("data", data, opts...); err != nil {
+			t.Fatalf("CreateDataset failed: %v", err)
+		}
+		// A single filtered chunk, larger than the data
+		if _, err := f.Root().CreateDataset("small", small, append(opts, WithChunks(8))...); err != nil {
+			t.Fatalf("CreateDataset small failed: %v", err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.Size()
+	}
+
+	plain := write("plain.h5", WithChunks(1024))
+	compressed := write("compressed.h5", WithChunks(1024), WithShuffle(), WithGzip(6))
+	write("checksummed.h5", WithChunks(1024), WithShuffle(), WithGzip(1), WithFletcher32())
+	if compressed*4 > plain {
+		t.Errorf("compressed file is %d bytes, plain %d", compressed, plain)
+	}
+
+	for _, name := range []string{"compressed.h5", "checksummed.h5"} {
+		f, err := Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Open %s failed: %v", name, err)
+		}
+		ds, err := f.Root().OpenDataset("data")
+		if err != nil {
+			t.Fatalf("OpenDataset failed: %v", err)
+		}
+		fp := ds.header.FilterPipeline()
+		if fp == nil || len(fp.Filters) < 2 || fp.Filters[0].ID != message.FilterShuffle || fp.Filters[1].ID != message.FilterDeflate {
+			t.Errorf("%s: filter pipeline %+v, want shuffle then deflate", name, fp)
+		}
+
+		var got []float64
+		if err := ds.Read(&got); err != nil {
+			t.Fatalf("%s: Read failed: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, data) {
+			t.Errorf("%s: data read back differs", name)
+		}
+		var part []float64
+		if err := ds.ReadSlice([]uint64{5000}, []uint64{3}, &part); err != nil {
+			t.Fatalf("%s: ReadSlice failed: %v", name, err)
+		}
+		if want := []float64{500, 500, 500}; !reflect.DeepEqual(part, want) {
+			t.Errorf("%s: ReadSlice = %v, want %v", name, part, want)
+		}
+
+		sds, err := f.Root().OpenDataset("small")
+		if err != nil {
+			t.Fatalf("OpenDataset small failed: %v", err)
+		}
+		var gotSmall []int32
+		if err := sds.Read(&gotSmall); err != nil {
+			t.Fatalf("%s: Read small failed: %v", name, err)
+		}
+		if !reflect.DeepEqual(gotSmall, small) {
+			t.Errorf("%s: small = %v, want %v", name, gotSmall, small)
+		}
+		f.Close()
+	}
+
+	f, err := Create(filepath.Join(dir, "contiguous.h5"))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.Root().CreateDataset("data", data, WithGzip(6)); err == nil {
+		t.Error("CreateDataset with gzip and no chunks succeeded")
+	}
+}
@@ -0,0 +1,103 @@
+package hdf5
+
+import (
+	"path"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// NamedDatatype is a datatype committed to the file as an object of its
+// own, such as one stored by h5py with f["name"] = np.dtype(...). Datasets
+// and attributes may share it; their datatypes are resolved to it when
+// they are opened.
+type NamedDatatype struct {
+	file     *File
+	path     string
+	header   *object.Header
+	datatype *message.Datatype
+}
+
+// OpenDatatype opens a named datatype by path.
+func (f *File) OpenDatatype(path string) (*NamedDatatype, error) {
+	if f.closed {
+		return nil, ErrClosed
+	}
+	return f.root.OpenDatatype(path)
+}
+
+// OpenDatatype opens a named datatype by relative path.
+func (g *Group) OpenDatatype(relativePath string) (*NamedDatatype, error) {
+	obj, err := g.open(relativePath)
+	if err != nil {
+		return nil, withPath(path.Join(g.path, relativePath), err)
+	}
+
+	dtype, ok := obj.(*NamedDatatype)
+	if !ok {
+		return nil, withPath(path.Join(g.path, relativePath), ErrNotDatatype)
+	}
+	return dtype, nil
+}
+
+// openDatatypeAt opens a named datatype at the given address.
+func (f *File) openDatatypeAt(address uint64, path string) (*NamedDatatype, error) {
+	header, err := f.readHeader(address)
+	if err != nil {
+		return nil, structureError("object header", address, err)
+	}
+	if err := f.checkTrailingBytes(header, path); err != nil {
+		return nil, err
+	}
+
+	dt := header.Datatype()
+	if dt == nil {
+		return nil, withPath(path, ErrNotDatatype)
+	}
+	return &NamedDatatype{file: f, path: path, header: header, datatype: dt}, nil
+}
+
+// Name returns the datatype name (last component of path).
+func (t *NamedDatatype) Name() string {
+	return path.Base(t.path)
+}
+
+// Path returns the full path to this datatype.
+func (t *NamedDatatype) Path() string {
+	return t.path
+}
+
+// Kind returns ObjectTypeNamedDatatype.
+func (t *NamedDatatype) Kind() ObjectType {
+	return ObjectTypeNamedDatatype
+}
+
+// DtypeInfo describes the committed datatype.
+func (t *NamedDatatype) DtypeInfo() DtypeInfo {
+	return newDtypeInfo(t.datatype)
+}
+
+// Attrs returns the names of all attributes on this datatype.
+func (t *NamedDatatype) Attrs() []string {
+	var names []string
+	for _, attr := range t.file.attributes(t.header, t.path) {
+		names = append(names, attr.Name)
+	}
+	return names
+}
+
+// Attr returns an attribute by name, or nil if not found.
+func (t *NamedDatatype) Attr(name string) *Attribute {
+	for _, attr := range t.file.attributes(t.header, t.path) {
+		if attr.Name == name {
+			return &Attribute{msg: attr, reader: t.file.reader, path: JoinAttrPath(t.path, name)}
+		}
+	}
+	return nil
+}
+
+// HasAttr returns true if the datatype has an attribute with the given
+// name.
+func (t *NamedDatatype) HasAttr(name string) bool {
+	return t.Attr(name) != nil
+}
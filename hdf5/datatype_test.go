@@ -0,0 +1,548 @@
+package hdf5
+
+import (
+	"encoding/binary"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/heap"
+	"github.com/robert-malhotra/go-hdf5/internal/layout"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+func TestNamedDatatype(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "named_datatype.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// A committed 32-bit integer, shared by a dataset and by an attribute
+	// of the root group
+	i32 := message.NewFixedPointDatatype(4, true, message.OrderLE)
+	units := message.NewScalarAttribute("units", message.NewStringDatatype(2, message.PadNullTerm, message.CharsetASCII), []byte("m\x00"))
+	typeAddr := writeObject(t, f, "mytype", []message.Message{i32, units})
+	shared := &message.SharedRef{MsgType: message.TypeDatatype, Location: message.SharedInHeader, Address: typeAddr}
+
+	data := f.allocate(12)
+	values := binary.LittleEndian.AppendUint32(nil, 7)
+	values = binary.LittleEndian.AppendUint32(values, 8)
+	values = binary.LittleEndian.AppendUint32(values, 9)
+	if err := f.writer.At(int64(data)).WriteBytes(values); err != nil {
+		t.Fatalf("writing data: %v", err)
+	}
+	writeObject(t, f, "data", []message.Message{
+		message.NewDataspace([]uint64{3}, nil), shared, message.NewContiguousLayout(data, 12),
+	})
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	dtype, err := f.OpenDatatype("mytype")
+	if err != nil {
+		t.Fatalf("OpenDatatype failed: %v", err)
+	}
+	if info := dtype.DtypeInfo(); info.Class != ClassInteger || info.Size != 4 || !info.Signed {
+		t.Errorf("DtypeInfo = %+v, want a signed 4-byte integer", info)
+	}
+	if dtype.Name() != "mytype" || dtype.Path() != "/mytype" {
+		t.Errorf("Name, Path = %q, %q", dtype.Name(), dtype.Path())
+	}
+	if v, err := f.ReadAttr("/mytype@units"); err != nil || v != "m" {
+		t.Errorf("units = %v, %v; want m", v, err)
+	}
+
+	ds, err := f.OpenDataset("data")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if !reflect.DeepEqual(ds.DtypeInfo(), dtype.DtypeInfo()) {
+		t.Errorf("dataset DtypeInfo = %+v, want the committed type", ds.DtypeInfo())
+	}
+	var got []int32
+	if err := ds.Read(&got); err != nil || !reflect.DeepEqual(got, []int32{7, 8, 9}) {
+		t.Errorf("Read = %v, %v; want [7 8 9]", got, err)
+	}
+
+	if obj, err := f.Open("mytype"); err != nil || obj.Kind() != ObjectTypeNamedDatatype {
+		t.Errorf("Open mytype = %v, %v; want the named datatype", obj, err)
+	}
+
+	// Named datatypes are neither groups nor datasets
+	if _, err := f.OpenGroup("mytype"); !errors.Is(err, ErrNotGroup) {
+		t.Errorf("OpenGroup: got %v, want ErrNotGroup", err)
+	}
+	if _, err := f.OpenDataset("mytype"); !errors.Is(err, ErrNotDataset) {
+		t.Errorf("OpenDataset: got %v, want ErrNotDataset", err)
+	}
+	if _, err := f.OpenDatatype("data"); !errors.Is(err, ErrNotDatatype) {
+		t.Errorf("OpenDatatype: got %v, want ErrNotDatatype", err)
+	}
+	if ok, err := f.ExistsGroup("mytype"); ok || err != nil {
+		t.Errorf("ExistsGroup = %v, %v; want false", ok, err)
+	}
+
+	info, err := f.Stat("mytype")
+	if err != nil || info.Kind != ObjectTypeNamedDatatype {
+		t.Errorf("Stat = %+v, %v; want a named datatype", info, err)
+	}
+	members, err := f.Root().MembersInfo()
+	if err != nil {
+		t.Fatalf("MembersInfo failed: %v", err)
+	}
+	kinds := map[string]ObjectType{}
+	for _, m := range members {
+		kinds[m.Name] = m.Type
+	}
+	if want := map[string]ObjectType{"mytype": ObjectTypeNamedDatatype, "data": ObjectTypeDataset}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("member kinds = %v, want %v", kinds, want)
+	}
+
+	var visited []string
+	err = f.Walk(func(p string, obj Object) error {
+		if _, ok := obj.(*NamedDatatype); ok {
+			visited = append(visited, p)
+		}
+		return nil
+	})
+	if err != nil || !reflect.DeepEqual(visited, []string{"/mytype"}) {
+		t.Errorf("Walk visited named datatypes %v, %v; want [/mytype]", visited, err)
+	}
+}
+
+func TestReadVarLenSequences(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ragged.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	i32 := message.NewFixedPointDatatype(4, true, message.OrderLE)
+	ds, err := f.Root().CreateDatasetWithType("ragged", []uint64{5}, message.NewVarLenSequenceDatatype(i32))
+	if err != nil {
+		t.Fatalf("CreateDatasetWithType failed: %v", err)
+	}
+
+	// Rows of differing lengths in one global heap collection; the second
+	// row is empty and the fourth a null reference
+	rows := [][]int32{{1, 2, 3}, {}, {4}, nil, {-5, 6}}
+	ghw := heap.NewGlobalHeapWriter(f.writer, f.allocate)
+	indexes := make([]uint16, len(rows))
+	for i, row := range rows {
+		if row == nil {
+			continue
+		}
+		obj := make([]byte, 4*len(row))
+		for j, v := range row {
+			binary.LittleEndian.PutUint32(obj[4*j:], uint32(v))
+		}
+		indexes[i] = ghw.AddObject(obj)
+	}
+	heapAddr, _, err := ghw.Write()
+	if err != nil {
+		t.Fatalf("writing global heap: %v", err)
+	}
+	raw := make([]byte, 16*len(rows))
+	for i, row := range rows {
+		if row == nil {
+			continue
+		}
+		ref := raw[16*i:]
+		binary.LittleEndian.PutUint32(ref, uint32(len(row)))
+		binary.LittleEndian.PutUint64(ref[4:], heapAddr)
+		binary.LittleEndian.PutUint32(ref[12:], uint32(indexes[i]))
+	}
+	if err := f.writer.At(int64(ds.dataAddr)).WriteBytes(raw); err != nil {
+		t.Fatalf("writing references: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	ds, err = f.OpenDataset("ragged")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+
+	got, err := ds.ReadVarLen()
+	if err != nil {
+		t.Fatalf("ReadVarLen failed: %v", err)
+	}
+	want := []interface{}{[]int32{1, 2, 3}, []int32{}, []int32{4}, []int32{}, []int32{-5, 6}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadVarLen = %v, want %v", got, want)
+	}
+
+	var floats [][]float64
+	if err := ds.Read(&floats); err != nil {
+		t.Fatalf("Read into [][]float64 failed: %v", err)
+	}
+	if len(floats) != 5 || len(floats[0]) != 3 || floats[4][0] != -5 || floats[3] == nil {
+		t.Errorf("Read into [][]float64 = %v", floats)
+	}
+}
+
+func TestReadStrings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "strings.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// Fixed-length strings in every layout
+	fixed := func(strs []string, size int) []byte {
+		raw := make([]byte, size*len(strs))
+		for i, s := range strs {
+			copy(raw[i*size:], s)
+		}
+		return raw
+	}
+	chunked := func(raw []byte, n uint64, size uint32) *message.DataLayout {
+		cw := layout.NewChunkWriter(f.writer, []uint32{2}, size, f.allocate)
+		addrs, sizes, err := cw.WriteChunks(layout.SplitIntoChunks(raw, []uint64{n}, []uint32{2}, size))
+		if err != nil {
+			t.Fatalf("writing chunks: %v", err)
+		}
+		index, err := cw.WriteFixedArrayIndex(addrs, sizes)
+		if err != nil {
+			t.Fatalf("writing chunk index: %v", err)
+		}
+		dl := message.NewChunkedLayout([]uint32{2}, size, message.ChunkIndexFixedArray)
+		dl.ChunkIndexAddr = index
+		return dl
+	}
+	spaced := message.NewStringDatatype(6, message.PadSpacePad, message.CharsetASCII)
+	contiguous := f.allocate(12)
+	if err := f.writer.At(int64(contiguous)).WriteBytes([]byte("ab    c d   ")); err != nil {
+		t.Fatalf("writing data: %v", err)
+	}
+	writeObject(t, f, "spaced", object.NewDatasetHeader(message.NewDataspace([]uint64{2}, nil), spaced,
+		message.NewContiguousLayout(contiguous, 12)))
+
+	utf8 := message.NewStringDatatype(8, message.PadNullTerm, message.CharsetUTF8)
+	writeObject(t, f, "compact", object.NewDatasetHeader(message.NewDataspace([]uint64{2}, nil), utf8,
+		message.NewCompactLayout(fixed([]string{"héllo", "日本"}, 8))))
+	writeObject(t, f, "scalar", object.NewDatasetHeader(message.NewScalarDataspace(), utf8,
+		message.NewCompactLayout(fixed([]string{"one"}, 8))))
+
+	words := []string{"alpha", "beta", "gamma", "delta", "epsilon"}
+	nullPad := message.NewStringDatatype(8, message.PadNullPad, message.CharsetASCII)
+	writeObject(t, f, "chunked", object.NewDatasetHeader(message.NewDataspace([]uint64{5}, nil), nullPad,
+		chunked(fixed(words, 8), 5, 8)))
+
+	// Variable-length strings, contiguous and chunked, the last reference
+	// of the broken one naming a missing heap object
+	ghw := heap.NewGlobalHeapWriter(f.writer, f.allocate)
+	indexes := make([]uint16, len(words))
+	for i, w := range words {
+		indexes[i] = ghw.AddString(w)
+	}
+	heapAddr, _, err := ghw.Write()
+	if err != nil {
+		t.Fatalf("writing global heap: %v", err)
+	}
+	refs := make([]byte, 16*len(words))
+	for i, w := range words {
+		binary.LittleEndian.PutUint32(refs[16*i:], uint32(len(w)))
+		binary.LittleEndian.PutUint64(refs[16*i+4:], heapAddr)
+		binary.LittleEndian.PutUint32(refs[16*i+12:], uint32(indexes[i]))
+	}
+	vlen := message.NewVarLenStringDatatype(message.CharsetUTF8)
+	writeObject(t, f, "vlen_chunked", object.NewDatasetHeader(message.NewDataspace([]uint64{5}, nil), vlen,
+		chunked(refs, 5, 16)))
+	broken := append([]byte(nil), refs...)
+	binary.LittleEndian.PutUint32(broken[16*4+12:], 99)
+	brokenAddr := f.allocate(int64(len(broken)))
+	if err := f.writer.At(int64(brokenAddr)).WriteBytes(broken); err != nil {
+		t.Fatalf("writing references: %v", err)
+	}
+	writeObject(t, f, "vlen_broken", object.NewDatasetHeader(message.NewDataspace([]uint64{5}, nil), vlen,
+		message.NewContiguousLayout(brokenAddr, uint64(len(broken)))))
+	if _, err := f.Root().CreateDataset("numbers", []int32{1, 2}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	open := func(name string) *Dataset {
+		t.Helper()
+		ds, err := f.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset %s failed: %v", name, err)
+		}
+		return ds
+	}
+
+	for _, tc := range []struct {
+		name string
+		want []string
+	}{
+		{"spaced", []string{"ab", "c d"}},
+		{"compact", []string{"héllo", "日本"}},
+		{"chunked", words},
+		{"vlen_chunked", words},
+	} {
+		if got, err := open(tc.name).ReadStrings(); err != nil || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: ReadStrings() = %q, %v, want %q", tc.name, got, err, tc.want)
+		}
+	}
+	if got, err := open("scalar").ReadScalarString(); err != nil || got != "one" {
+		t.Errorf("ReadScalarString() = %q, %v, want \"one\"", got, err)
+	}
+	if _, err := open("spaced").ReadScalarString(); err == nil {
+		t.Error("ReadScalarString succeeded on two strings")
+	}
+	if _, err := open("vlen_broken").ReadStrings(); err == nil || !strings.Contains(err.Error(), "string 4") {
+		t.Errorf("ReadStrings() with a bad reference error = %v, want one naming string 4", err)
+	}
+	if _, err := open("numbers").ReadStrings(); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("ReadStrings() on integers error = %v, want ErrUnsupported", err)
+	}
+
+	// Slices and points of variable-length strings resolve their references,
+	// and no strings read into numbers
+	var part []string
+	if err := open("vlen_chunked").ReadSliceInto(&part, []uint64{1}, []uint64{3}); err != nil || !reflect.DeepEqual(part, words[1:4]) {
+		t.Errorf("ReadSliceInto of vlen strings = %q, %v, want %q", part, err, words[1:4])
+	}
+	var word string
+	if err := open("vlen_chunked").ReadPoint([]uint64{4}, &word); err != nil || word != "epsilon" {
+		t.Errorf("ReadPoint of a vlen string = %q, %v, want \"epsilon\"", word, err)
+	}
+	for _, name := range []string{"chunked", "vlen_chunked"} {
+		for _, dest := range []interface{}{new([]int32), new([]float64), new(float64)} {
+			if err := open(name).ReadSliceInto(dest, []uint64{0}, []uint64{1}); !errors.Is(err, ErrTypeMismatch) {
+				t.Errorf("%s: ReadSliceInto %T error = %v, want ErrTypeMismatch", name, dest, err)
+			}
+			if err := open(name).ReadPoints([][]uint64{{0}}, dest); !errors.Is(err, ErrTypeMismatch) {
+				t.Errorf("%s: ReadPoints into %T error = %v, want ErrTypeMismatch", name, dest, err)
+			}
+		}
+	}
+
+	g, err := Open(skipIfNoTestdata(t, "strings.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer g.Close()
+	for name, want := range map[string][]string{
+		"fixed":    {"hello", "world"},
+		"variable": {"hello", "variable length world"},
+	} {
+		ds, err := g.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset %s failed: %v", name, err)
+		}
+		if got, err := ds.ReadStrings(); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("strings.h5 %s: ReadStrings() = %q, %v, want %q", name, got, err, want)
+		}
+	}
+}
+
+func TestReadEnum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "enum.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// An unsigned byte enum whose data holds one value with no member, and
+	// a big-endian signed one on an attribute
+	state := message.NewEnumDatatype(message.NewFixedPointDatatype(1, false, message.OrderLE),
+		[]string{"IDLE", "RUNNING", "FAILED"}, []int64{0, 1, 200})
+	level := message.NewEnumDatatype(message.NewFixedPointDatatype(2, true, message.OrderBE),
+		[]string{"LOW", "HIGH"}, []int64{-300, 300})
+	attr := message.NewAttribute("levels", level, message.NewDataspace([]uint64{3}, nil),
+		[]byte{0xFE, 0xD4, 0x01, 0x2C, 0x00, 0x05})
+	writeObject(t, f, "state", append(object.NewDatasetHeader(message.NewDataspace([]uint64{5}, nil), state,
+		message.NewCompactLayout([]byte{1, 0, 200, 7, 1})), attr))
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	ds, err := f.OpenDataset("state")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+
+	info := ds.DtypeInfo()
+	wantMembers := []EnumMember{{"IDLE", 0}, {"RUNNING", 1}, {"FAILED", 200}}
+	if info.Class != ClassEnum || info.Base == nil || info.Base.Size != 1 || info.Base.Signed ||
+		!reflect.DeepEqual(info.Enum, wantMembers) {
+		t.Errorf("DtypeInfo = %+v, want a 1-byte unsigned enum with members %v", info, wantMembers)
+	}
+	if got, err := ds.ReadEnumStrings(); err != nil ||
+		!reflect.DeepEqual(got, []string{"RUNNING", "IDLE", "FAILED", "7", "RUNNING"}) {
+		t.Errorf("ReadEnumStrings() = %q, %v", got, err)
+	}
+	if got, err := ds.ReadInt64(); err != nil || !reflect.DeepEqual(got, []int64{1, 0, 200, 7, 1}) {
+		t.Errorf("ReadInt64() = %v, %v, want [1 0 200 7 1]", got, err)
+	}
+
+	levels := ds.Attr("levels")
+	if levels == nil {
+		t.Fatal("levels attribute not found")
+	}
+	if got := levels.DtypeInfo().Enum; !reflect.DeepEqual(got, []EnumMember{{"LOW", -300}, {"HIGH", 300}}) {
+		t.Errorf("attribute enum members = %v", got)
+	}
+	if got, err := levels.ReadEnumStrings(); err != nil || !reflect.DeepEqual(got, []string{"LOW", "HIGH", "5"}) {
+		t.Errorf("attribute ReadEnumStrings() = %q, %v, want [LOW HIGH 5]", got, err)
+	}
+	if got, err := levels.ReadInt64(); err != nil || !reflect.DeepEqual(got, []int64{-300, 300, 5}) {
+		t.Errorf("attribute ReadInt64() = %v, %v, want [-300 300 5]", got, err)
+	}
+	if _, err := ds.ReadStrings(); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("ReadStrings() on an enum error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestDatasetDtypeInfo(t *testing.T) {
+	type row struct {
+		ID    int32   `hdf5:"id"`
+		Value float64 `hdf5:"value"`
+		Flag  uint8   `hdf5:"flag"`
+	}
+
+	path := filepath.Join(t.TempDir(), "dtype_info.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("rows", []row{{1, 0.5, 1}}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	ds, err := f.OpenDataset("rows")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+
+	info := ds.DtypeInfo()
+	if info.Class != ClassCompound || info.Size != 13 || len(info.Members) != 3 {
+		t.Fatalf("DtypeInfo = %+v, want 13-byte compound with 3 members", info)
+	}
+	want := []struct {
+		name   string
+		offset int
+		class  DtypeClass
+		signed bool
+	}{
+		{"id", 0, ClassInteger, true},
+		{"value", 4, ClassFloat, false},
+		{"flag", 12, ClassInteger, false},
+	}
+	for i, w := range want {
+		m := info.Members[i]
+		if m.Name != w.name || m.Offset != w.offset || m.Type.Class != w.class || m.Type.Signed != w.signed {
+			t.Errorf("member %d = %+v, want %+v", i, m, w)
+		}
+		if m.Type.ByteOrder != binary.LittleEndian {
+			t.Errorf("member %q byte order = %v, want little-endian", m.Name, m.Type.ByteOrder)
+		}
+	}
+}
+
+func TestDtypeInfoString(t *testing.T) {
+	i32 := message.NewFixedPointDatatype(4, true, message.OrderLE)
+	f64 := message.NewFloatDatatype(8, message.OrderLE)
+	tests := []struct {
+		dt   *message.Datatype
+		want string
+	}{
+		{i32, "H5T_STD_I32LE"},
+		{message.NewFixedPointDatatype(2, false, message.OrderBE), "H5T_STD_U16BE"},
+		{message.NewFloatDatatype(4, message.OrderBE), "H5T_IEEE_F32BE"},
+		{message.NewStringDatatype(10, message.PadSpacePad, message.CharsetASCII),
+			"H5T_STRING { STRSIZE 10; STRPAD H5T_STR_SPACEPAD; CSET H5T_CSET_ASCII; CTYPE H5T_C_S1; }"},
+		{message.NewVarLenStringDatatype(message.CharsetUTF8),
+			"H5T_STRING { STRSIZE H5T_VARIABLE; STRPAD H5T_STR_NULLTERM; CSET H5T_CSET_UTF8; CTYPE H5T_C_S1; }"},
+		{message.NewVarLenSequenceDatatype(i32), "H5T_VLEN { H5T_STD_I32LE }"},
+		{message.NewArrayDatatype([]uint32{2, 3}, f64), "H5T_ARRAY { [2][3] H5T_IEEE_F64LE }"},
+		{message.NewCompoundDatatype(12, []message.CompoundMember{
+			{Name: "id", ByteOffset: 0, Type: i32},
+			{Name: "xy", ByteOffset: 4, Type: message.NewArrayDatatype([]uint32{2}, message.NewFloatDatatype(4, message.OrderLE))},
+		}), `H5T_COMPOUND { H5T_STD_I32LE "id"; H5T_ARRAY { [2] H5T_IEEE_F32LE } "xy"; }`},
+		{message.NewEnumDatatype(message.NewFixedPointDatatype(1, true, message.OrderLE), []string{"NO", "YES"}, []int64{0, 1}),
+			`H5T_ENUM { H5T_STD_I8LE; "NO" 0; "YES" 1; }`},
+		{message.NewObjectReferenceDatatype(8), "H5T_REFERENCE"},
+	}
+	for _, tt := range tests {
+		if got := newDtypeInfo(tt.dt).String(); got != tt.want {
+			t.Errorf("String() = %s, want %s", got, tt.want)
+		}
+	}
+
+	info := newDtypeInfo(message.NewArrayDatatype([]uint32{2, 3}, f64))
+	if !reflect.DeepEqual(info.ArrayDims, []int{2, 3}) || info.Base == nil || info.Base.Class != ClassFloat {
+		t.Errorf("array DtypeInfo = %+v", info)
+	}
+	info = newDtypeInfo(message.NewStringDatatype(4, message.PadNullPad, message.CharsetUTF8))
+	if info.Padding != PadNullPad || info.Charset != CharsetUTF8 {
+		t.Errorf("string DtypeInfo = %+v", info)
+	}
+
+	// Files written by h5py, including attribute datatypes
+	f, err := Open(skipIfNoTestdata(t, "strings.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	for name, want := range map[string]DtypeInfo{
+		"fixed":    {Class: ClassString, Size: 10, Padding: PadNullPad, Charset: CharsetUTF8},
+		"variable": {Class: ClassVarLen, Size: 16, Charset: CharsetUTF8, VarLenString: true},
+	} {
+		ds, err := f.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset %s failed: %v", name, err)
+		}
+		if got := ds.DtypeInfo(); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s DtypeInfo = %+v, want %+v", name, got, want)
+		}
+	}
+	g, err := Open(skipIfNoTestdata(t, "attributes.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer g.Close()
+	ds, err := g.OpenDataset("data")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if got := ds.Attr("float_attr").DtypeInfo().String(); got != "H5T_IEEE_F64LE" {
+		t.Errorf("float_attr datatype = %s, want H5T_IEEE_F64LE", got)
+	}
+}
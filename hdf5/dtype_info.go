@@ -0,0 +1,280 @@
+package hdf5
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/robert-malhotra/go-hdf5/internal/dtype"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// DtypeClass is the class of an HDF5 datatype.
+type DtypeClass uint8
+
+// Datatype classes, numbered as in the file format.
+const (
+	ClassInteger   DtypeClass = 0
+	ClassFloat     DtypeClass = 1
+	ClassTime      DtypeClass = 2
+	ClassString    DtypeClass = 3
+	ClassBitfield  DtypeClass = 4
+	ClassOpaque    DtypeClass = 5
+	ClassCompound  DtypeClass = 6
+	ClassReference DtypeClass = 7
+	ClassEnum      DtypeClass = 8
+	ClassVarLen    DtypeClass = 9
+	ClassArray     DtypeClass = 10
+)
+
+// StringPadding is how a fixed-length string fills its unused bytes.
+type StringPadding uint8
+
+// String paddings, numbered as in the file format.
+const (
+	PadNullTerm StringPadding = 0 // Null-terminated
+	PadNullPad  StringPadding = 1 // Padded with nulls
+	PadSpacePad StringPadding = 2 // Padded with spaces
+)
+
+// Charset is the character set of a string datatype.
+type Charset uint8
+
+// Character sets, numbered as in the file format.
+const (
+	CharsetASCII Charset = 0
+	CharsetUTF8  Charset = 1
+)
+
+// DtypeInfo describes a datatype as stored in the file.
+type DtypeInfo struct {
+	Class DtypeClass
+	Size  int // Element size in bytes
+
+	// Signed reports whether an integer type is signed.
+	Signed bool
+
+	// ByteOrder is binary.LittleEndian or binary.BigEndian for integer,
+	// float, bitfield and time types, and nil for other classes.
+	ByteOrder binary.ByteOrder
+
+	// Padding and Charset describe fixed-length strings and, apart from
+	// the padding, variable-length ones.
+	Padding StringPadding
+	Charset Charset
+
+	// BitOffset and Precision locate the significant bits of a bitfield
+	// within its Size bytes; reads keep only those bits.
+	BitOffset int
+	Precision int
+
+	// Tag is the ASCII description of an opaque type's contents, such as
+	// "image/png".
+	Tag string
+
+	// VarLenString reports whether a variable-length type is a string
+	// rather than a sequence of Base.
+	VarLenString bool
+
+	// Members lists the members of a compound type in file order.
+	Members []DtypeMember
+
+	// ArrayDims holds the dimensions of an array type.
+	ArrayDims []int
+
+	// Base is the element type of an array or variable-length sequence, or
+	// the integer type underlying an enum.
+	Base *DtypeInfo
+
+	// Enum lists the members of an enum type in file order.
+	Enum []EnumMember
+}
+
+// DtypeMember is one member of a compound datatype.
+type DtypeMember struct {
+	Name   string
+	Offset int // Byte offset within the compound element
+	Type   DtypeInfo
+}
+
+// EnumMember is one named value of an enum datatype. Values of unsigned
+// 64-bit enums above math.MaxInt64 keep their bit pattern.
+type EnumMember struct {
+	Name  string
+	Value int64
+}
+
+// DtypeInfo describes the dataset's datatype.
+func (d *Dataset) DtypeInfo() DtypeInfo {
+	return newDtypeInfo(d.datatype)
+}
+
+// newDtypeInfo translates a datatype message into its public description.
+func newDtypeInfo(dt *message.Datatype) DtypeInfo {
+	info := DtypeInfo{
+		Class: DtypeClass(dt.Class),
+		Size:  int(dt.Size),
+	}
+
+	switch dt.Class {
+	case message.ClassFixedPoint, message.ClassFloatPoint, message.ClassTime, message.ClassBitfield:
+		info.Signed = dt.Class == message.ClassFixedPoint && dt.Signed
+		switch dt.ByteOrder {
+		case message.OrderLE:
+			info.ByteOrder = binary.LittleEndian
+		case message.OrderBE:
+			info.ByteOrder = binary.BigEndian
+		}
+		if dt.Class == message.ClassBitfield {
+			info.BitOffset = int(dt.BitOffset)
+			info.Precision = int(dt.BitPrecision)
+		}
+
+	case message.ClassOpaque:
+		info.Tag = dt.OpaqueTag
+
+	case message.ClassString:
+		info.Padding = StringPadding(dt.StringPadding)
+		info.Charset = Charset(dt.CharSet)
+
+	case message.ClassVarLen:
+		if dt.IsVarLenString {
+			// The padding and character set are in the class bits
+			info.VarLenString = true
+			info.Padding = StringPadding(dt.ClassBits >> 4 & 0x0F)
+			info.Charset = Charset(dt.ClassBits >> 8 & 0x0F)
+		} else if dt.VarLenType != nil {
+			base := newDtypeInfo(dt.VarLenType)
+			info.Base = &base
+		}
+
+	case message.ClassArray:
+		info.ArrayDims = make([]int, len(dt.ArrayDims))
+		for i, d := range dt.ArrayDims {
+			info.ArrayDims[i] = int(d)
+		}
+		if dt.BaseType != nil {
+			base := newDtypeInfo(dt.BaseType)
+			info.Base = &base
+		}
+
+	case message.ClassCompound:
+		info.Members = make([]DtypeMember, len(dt.Members))
+		for i, m := range dt.Members {
+			info.Members[i] = DtypeMember{
+				Name:   m.Name,
+				Offset: int(m.ByteOffset),
+				Type:   newDtypeInfo(m.Type),
+			}
+		}
+
+	case message.ClassEnum:
+		if dt.BaseType == nil {
+			break
+		}
+		base := newDtypeInfo(dt.BaseType)
+		info.Base = &base
+		values, err := dtype.ConvertToSlice[int64](dt.BaseType, bytes.Join(dt.EnumValues, nil), uint64(len(dt.EnumValues)))
+		if err != nil {
+			break
+		}
+		info.Enum = make([]EnumMember, 0, len(dt.EnumNames))
+		for i, name := range dt.EnumNames {
+			if i < len(values) {
+				info.Enum = append(info.Enum, EnumMember{Name: name, Value: values[i]})
+			}
+		}
+	}
+	return info
+}
+
+// String renders the datatype in the style of h5dump, for example
+// H5T_STD_I32LE or H5T_ARRAY { [3] H5T_IEEE_F64LE }, on a single line.
+func (info DtypeInfo) String() string {
+	order := func() string {
+		if info.ByteOrder == binary.BigEndian {
+			return "BE"
+		}
+		return "LE"
+	}
+
+	switch info.Class {
+	case ClassInteger:
+		sign := "U"
+		if info.Signed {
+			sign = "I"
+		}
+		return fmt.Sprintf("H5T_STD_%s%d%s", sign, info.Size*8, order())
+	case ClassFloat:
+		return fmt.Sprintf("H5T_IEEE_F%d%s", info.Size*8, order())
+	case ClassBitfield:
+		return fmt.Sprintf("H5T_STD_B%d%s", info.Size*8, order())
+	case ClassTime:
+		return "H5T_TIME"
+	case ClassString:
+		return stringDtype(strconv.Itoa(info.Size), info.Padding, info.Charset)
+	case ClassOpaque:
+		if info.Tag == "" {
+			return fmt.Sprintf("H5T_OPAQUE { OPQ_SIZE %d; }", info.Size)
+		}
+		return fmt.Sprintf("H5T_OPAQUE { OPQ_SIZE %d; OPQ_TAG %q; }", info.Size, info.Tag)
+	case ClassReference:
+		return "H5T_REFERENCE"
+	case ClassVarLen:
+		if info.VarLenString {
+			return stringDtype("H5T_VARIABLE", info.Padding, info.Charset)
+		}
+		if info.Base == nil {
+			return "H5T_VLEN"
+		}
+		return fmt.Sprintf("H5T_VLEN { %s }", info.Base)
+	case ClassCompound:
+		var b strings.Builder
+		b.WriteString("H5T_COMPOUND {")
+		for _, m := range info.Members {
+			fmt.Fprintf(&b, " %s %q;", m.Type, m.Name)
+		}
+		b.WriteString(" }")
+		return b.String()
+	case ClassArray:
+		var b strings.Builder
+		b.WriteString("H5T_ARRAY { ")
+		for _, d := range info.ArrayDims {
+			fmt.Fprintf(&b, "[%d]", d)
+		}
+		if info.Base != nil {
+			fmt.Fprintf(&b, " %s", info.Base)
+		}
+		b.WriteString(" }")
+		return b.String()
+	case ClassEnum:
+		var b strings.Builder
+		b.WriteString("H5T_ENUM {")
+		if info.Base != nil {
+			fmt.Fprintf(&b, " %s;", info.Base)
+		}
+		for _, m := range info.Enum {
+			fmt.Fprintf(&b, " %q %d;", m.Name, m.Value)
+		}
+		b.WriteString(" }")
+		return b.String()
+	default:
+		return fmt.Sprintf("class %d", info.Class)
+	}
+}
+
+// stringDtype renders a string datatype of the given size.
+func stringDtype(size string, pad StringPadding, cset Charset) string {
+	padName := map[StringPadding]string{
+		PadNullTerm: "H5T_STR_NULLTERM",
+		PadNullPad:  "H5T_STR_NULLPAD",
+		PadSpacePad: "H5T_STR_SPACEPAD",
+	}[pad]
+	csetName := "H5T_CSET_ASCII"
+	if cset == CharsetUTF8 {
+		csetName = "H5T_CSET_UTF8"
+	}
+	return fmt.Sprintf("H5T_STRING { STRSIZE %s; STRPAD %s; CSET %s; CTYPE H5T_C_S1; }", size, padName, csetName)
+}
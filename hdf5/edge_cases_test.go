@@ -2,9 +2,15 @@ package hdf5
 
 import (
 	"bytes"
+	"encoding/binary"
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
 )
 
 // === ERROR PATH TESTS ===
@@ -184,8 +190,101 @@ func TestEmptyDataset(t *testing.T) {
 		t.Errorf("expected 0 elements, got %d", ds.NumElements())
 	}
 
-	// For empty datasets, reading may return an error or empty slice
-	// depending on how the data is stored. We just verify the metadata is correct.
+	// The data was never allocated, and reads as no values
+	values, err := ds.ReadFloat64()
+	if err != nil || len(values) != 0 {
+		t.Errorf("ReadFloat64 = %v, %v; want no values", values, err)
+	}
+}
+
+// TestReadEmpty tests that datasets and attributes without elements read as
+// empty slices whatever their layout, as do slices selecting nothing.
+func TestReadEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "read_empty.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	layouts := map[string][]DatasetOption{
+		"contiguous": nil,
+		"chunked":    {WithChunks(4), WithMaxDims(0)},
+		"gzip":       {WithChunks(4), WithGzip(4)},
+	}
+	for name, opts := range layouts {
+		if _, err := f.Root().CreateDataset(name, []float64{}, opts...); err != nil {
+			t.Fatalf("CreateDataset %s failed: %v", name, err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	for name := range layouts {
+		ds, err := f.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset %s failed: %v", name, err)
+		}
+		if values, err := ds.ReadFloat64(); err != nil || len(values) != 0 {
+			t.Errorf("%s: ReadFloat64 = %v, %v; want no values", name, values, err)
+		}
+		if values, err := ds.ReadSliceFloat64([]uint64{0}, []uint64{0}); err != nil || len(values) != 0 {
+			t.Errorf("%s: ReadSliceFloat64 = %v, %v; want no values", name, values, err)
+		}
+		if raw, err := ds.ReadRaw(); err != nil || len(raw) != 0 {
+			t.Errorf("%s: ReadRaw = %v, %v; want no bytes", name, raw, err)
+		}
+	}
+	grids := []struct{ file, dataset string }{{"multidim.h5", "2d"}, {"chunked.h5", "chunked"}}
+	for _, grid := range grids {
+		gf, err := Open(skipIfNoTestdata(t, grid.file))
+		if err != nil {
+			t.Fatalf("Open %s failed: %v", grid.file, err)
+		}
+		defer gf.Close()
+		ds, err := gf.OpenDataset(grid.dataset)
+		if err != nil {
+			t.Fatalf("OpenDataset %s failed: %v", grid.dataset, err)
+		}
+		rows, cols := ds.Shape()[0], ds.Shape()[1]
+		for _, sel := range [][2][]uint64{{{1, 0}, {0, cols}}, {{0, cols}, {rows, 0}}, {{rows, cols}, {0, 0}}} {
+			raw, err := ds.ReadSliceRaw(sel[0], sel[1])
+			if err != nil || len(raw) != 0 {
+				t.Errorf("%s: ReadSliceRaw(%v, %v) = %v, %v; want no bytes", grid.dataset, sel[0], sel[1], raw, err)
+			}
+		}
+	}
+
+	// A null dataspace has no elements
+	ds, err := f.OpenDataset("contiguous")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	null := &Dataset{datasetState: &datasetState{
+		header:    ds.header,
+		dataspace: message.NewNullDataspace(),
+		datatype:  ds.datatype,
+		layout:    ds.layout,
+	}, file: f, path: "/null"}
+	if values, err := null.ReadFloat64(); err != nil || len(values) != 0 {
+		t.Errorf("null dataspace: ReadFloat64 = %v, %v; want no values", values, err)
+	}
+
+	attr := &Attribute{msg: &message.Attribute{
+		Name:      "null",
+		Datatype:  ds.datatype,
+		Dataspace: message.NewNullDataspace(),
+	}}
+	if values, err := attr.ReadFloat64(); err != nil || len(values) != 0 {
+		t.Errorf("null attribute: ReadFloat64 = %v, %v; want no values", values, err)
+	}
+	if value, err := attr.Value(); err != nil || reflect.ValueOf(value).Len() != 0 {
+		t.Errorf("null attribute: Value = %v, %v; want no values", value, err)
+	}
 }
 
 // TestDoubleClose tests that closing a file twice is safe.
@@ -447,6 +546,44 @@ func TestFilePath(t *testing.T) {
 	}
 }
 
+// TestEmptyV1Groups tests v1 groups whose B-tree is undefined or has no entries.
+func TestEmptyV1Groups(t *testing.T) {
+	for _, filename := range []string{
+		"v0_empty_undefined_btree.h5",
+		"v0_empty_btree_node.h5",
+		"v0_empty_snod.h5",
+	} {
+		t.Run(filename, func(t *testing.T) {
+			path := skipIfNoTestdata(t, filename)
+
+			f, err := Open(path)
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			defer f.Close()
+
+			members, err := f.Root().Members()
+			if err != nil {
+				t.Fatalf("Members failed: %v", err)
+			}
+			if members == nil {
+				t.Error("Members returned nil slice for empty group")
+			}
+			if len(members) != 0 {
+				t.Errorf("expected no members, got %v", members)
+			}
+
+			if _, err := f.OpenDataset("/data"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("OpenDataset: expected ErrNotFound, got %v", err)
+			}
+
+			if err := Walk(f.Root(), func(string, interface{}, error) error { return nil }); err != nil {
+				t.Errorf("Walk failed: %v", err)
+			}
+		})
+	}
+}
+
 // === HELPER FUNCTIONS ===
 
 // createScalarTestFile creates a test file with a scalar dataset.
@@ -474,3 +611,349 @@ func createEmptyTestFile(path string) error {
 	// For now, we skip if the file doesn't exist
 	return os.ErrNotExist
 }
+
+func TestTrailingGarbage(t *testing.T) {
+	path := copyTestdata(t, "minimal.h5")
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	n, err := f.TrailingBytes()
+	f.Close()
+	if err != nil {
+		t.Fatalf("TrailingBytes failed: %v", err)
+	}
+	if n != 0 {
+		t.Fatalf("clean file: TrailingBytes = %d, want 0", n)
+	}
+
+	garbage := make([]byte, 1024)
+	for i := range garbage {
+		garbage[i] = byte(i * 7)
+	}
+	out, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := out.Write(garbage); err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open with trailing garbage failed: %v", err)
+	}
+	defer f.Close()
+
+	if n, err := f.TrailingBytes(); err != nil || n != 1024 {
+		t.Errorf("TrailingBytes = %d, %v; want 1024", n, err)
+	}
+
+	ds, err := f.OpenDataset("/data")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	data, err := ds.ReadFloat64()
+	if err != nil {
+		t.Fatalf("ReadFloat64 failed: %v", err)
+	}
+	if len(data) != 4 || data[0] != 1.0 || data[3] != 4.0 {
+		t.Errorf("got %v, want [1 2 3 4]", data)
+	}
+}
+
+// createDuplicateLinkFile writes a file whose group /dup holds two links
+// named "x" (to /a, then /b) followed by a link "y" to /b.
+func createDuplicateLinkFile(t *testing.T) (path string, addrA, addrB uint64) {
+	t.Helper()
+	path = filepath.Join(t.TempDir(), "duplicate_links.h5")
+
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("a", []int32{1, 1}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("b", []int32{2, 2}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	f.Close()
+
+	f, err = OpenReadWrite(path)
+	if err != nil {
+		t.Fatalf("OpenReadWrite failed: %v", err)
+	}
+	if addrA, _, err = f.Root().findChild("a"); err != nil {
+		t.Fatalf("finding a: %v", err)
+	}
+	if addrB, _, err = f.Root().findChild("b"); err != nil {
+		t.Fatalf("finding b: %v", err)
+	}
+
+	messages := object.NewGroupHeader([]*message.Link{
+		message.NewHardLink("x", addrA),
+		message.NewHardLink("x", addrB),
+		message.NewHardLink("y", addrB),
+	})
+	groupAddr := f.allocate(int64(object.HeaderSize(f.writer, messages)))
+	if _, err := object.WriteHeader(f.writer.At(int64(groupAddr)), messages); err != nil {
+		t.Fatalf("writing group header: %v", err)
+	}
+	if err := f.Root().addLink(message.NewHardLink("dup", groupAddr)); err != nil {
+		t.Fatalf("linking group: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return path, addrA, addrB
+}
+
+func TestDuplicateLinks(t *testing.T) {
+	path, addrA, addrB := createDuplicateLinkFile(t)
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	g, err := f.OpenGroup("/dup")
+	if err != nil {
+		t.Fatalf("OpenGroup failed: %v", err)
+	}
+	members, err := g.Members()
+	if err != nil {
+		t.Fatalf("Members failed: %v", err)
+	}
+	if len(members) != 2 || members[0] != "x" || members[1] != "y" {
+		t.Errorf("Members = %v, want [x y]", members)
+	}
+	info, err := g.MembersInfo()
+	if err != nil {
+		t.Fatalf("MembersInfo failed: %v", err)
+	}
+	if len(info) != 2 {
+		t.Errorf("MembersInfo returned %d entries, want 2", len(info))
+	}
+
+	// The first link wins
+	ds, err := f.OpenDataset("/dup/x")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	var data []int32
+	if err := ds.Read(&data); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(data) != 2 || data[0] != 1 {
+		t.Errorf("/dup/x read %v, want the data of /a", data)
+	}
+
+	warnings := f.Warnings()
+	if len(warnings) != 1 {
+		t.Fatalf("got %d warnings, want 1: %v", len(warnings), warnings)
+	}
+	w := warnings[0]
+	if w.Kind != WarnDuplicateLink || w.Path != "/dup/x" {
+		t.Errorf("warning = %v, want DuplicateLink on /dup/x", w)
+	}
+	if len(w.Addresses) != 2 || w.Addresses[0] != addrA || w.Addresses[1] != addrB {
+		t.Errorf("warning addresses = %v, want [%d %d]", w.Addresses, addrA, addrB)
+	}
+
+	t.Run("strict", func(t *testing.T) {
+		f, err := Open(path, WithStrict())
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		defer f.Close()
+
+		g, err := f.OpenGroup("/dup")
+		if err != nil {
+			t.Fatalf("OpenGroup failed: %v", err)
+		}
+		if _, err := g.Members(); !errors.Is(err, ErrDuplicateLink) {
+			t.Errorf("Members: got %v, want ErrDuplicateLink", err)
+		}
+		if _, err := f.OpenDataset("/dup/y"); !errors.Is(err, ErrDuplicateLink) {
+			t.Errorf("OpenDataset: got %v, want ErrDuplicateLink", err)
+		}
+	})
+}
+
+func TestTrailingBytesInMessage(t *testing.T) {
+	path := copyTestdata(t, "v0_minimal.h5")
+
+	// The dataset's v1 header pads its 20-byte datatype message to 24
+	// bytes; fill the padding with junk
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	addr := datasetAddress(t, f, "data")
+	raw, err := object.ReadRaw(f.reader, addr)
+	if err != nil {
+		t.Fatalf("ReadRaw failed: %v", err)
+	}
+	f.Close()
+
+	var junkAt int64 = -1
+	for _, m := range raw.Messages {
+		if m.Type == message.TypeDatatype && len(m.Data) == 24 {
+			junkAt = int64(m.DataOffset) + 20
+		}
+	}
+	if junkAt < 0 {
+		t.Fatalf("no padded datatype message in %+v", raw.Messages)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteAt([]byte{0xde, 0xad, 0xbe, 0xef}, junkAt); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	ds, err := f.OpenDataset("data")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	data, err := ds.ReadFloat64()
+	if err != nil {
+		t.Fatalf("ReadFloat64 failed: %v", err)
+	}
+	if len(data) != 4 || data[0] != 1.0 || data[3] != 4.0 {
+		t.Errorf("got %v, want [1 2 3 4]", data)
+	}
+
+	warnings := f.Warnings()
+	if len(warnings) != 1 {
+		t.Fatalf("got %d warnings, want 1: %v", len(warnings), warnings)
+	}
+	if w := warnings[0]; w.Kind != WarnTrailingBytes || w.Path != "/data" || w.Count != 4 ||
+		len(w.Addresses) != 1 || w.Addresses[0] != addr {
+		t.Errorf("warning = %v, want 4 TrailingBytes on /data at %d", w, addr)
+	}
+
+	t.Run("strict", func(t *testing.T) {
+		f, err := Open(path, WithStrict())
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		defer f.Close()
+
+		if _, err := f.OpenDataset("data"); !errors.Is(err, ErrTrailingBytes) {
+			t.Errorf("OpenDataset: got %v, want ErrTrailingBytes", err)
+		}
+	})
+}
+
+// rewriteLayoutV1 replaces the version 3 layout message of /chunked in a
+// copy of chunked_v1.h5 with its version 1 encoding, as HDF5 1.4 wrote it.
+// The v1 message is 8 bytes longer; they are taken from the NIL message
+// that follows it.
+func rewriteLayoutV1(t *testing.T) string {
+	t.Helper()
+	path := copyTestdata(t, "chunked_v1.h5")
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	raw, err := object.ReadRaw(f.reader, datasetAddress(t, f, "chunked"))
+	if err != nil {
+		t.Fatalf("ReadRaw failed: %v", err)
+	}
+	reader := f.reader
+	f.Close()
+
+	var layoutMsg, nilMsg *object.RawMessage
+	for i, m := range raw.Messages {
+		if m.Type == message.TypeDataLayout && i+1 < len(raw.Messages) {
+			layoutMsg, nilMsg = &raw.Messages[i], &raw.Messages[i+1]
+		}
+	}
+	if layoutMsg == nil || nilMsg.Type != message.TypeNIL || nilMsg.Size < 16 {
+		t.Fatalf("no layout message followed by free space in %+v", raw.Messages)
+	}
+	msg, err := message.Parse(message.TypeDataLayout, layoutMsg.Data, 0, reader)
+	if err != nil {
+		t.Fatalf("parsing layout: %v", err)
+	}
+	layout := msg.(*message.DataLayout)
+
+	body := []byte{1, byte(len(layout.ChunkDims)), byte(message.LayoutChunked), 0, 0, 0, 0, 0}
+	body = binary.LittleEndian.AppendUint64(body, layout.ChunkIndexAddr)
+	for _, d := range layout.ChunkDims {
+		body = binary.LittleEndian.AppendUint32(body, d)
+	}
+	body = append(body, make([]byte, (8-len(body)%8)%8)...)
+	grow := len(body) - len(layoutMsg.Data)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary.LittleEndian.PutUint16(data[layoutMsg.Offset+2:], uint16(len(body)))
+	copy(data[layoutMsg.DataOffset:], body)
+	nilAt := nilMsg.Offset + uint64(grow)
+	copy(data[nilAt:nilAt+8], []byte{0, 0, 0, 0, 0, 0, 0, 0})
+	binary.LittleEndian.PutUint16(data[nilAt+2:], uint16(int(nilMsg.Size)-grow))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadChunkedLayoutV1(t *testing.T) {
+	path := rewriteLayoutV1(t)
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	ds, err := f.OpenDataset("chunked")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	layout := ds.header.DataLayout()
+	if layout.Version != 1 {
+		t.Fatalf("layout version %d, want the rewritten version 1", layout.Version)
+	}
+	if dims := layout.ChunkDims; len(dims) != 3 || dims[0] != 5 || dims[1] != 5 || dims[2] != 8 {
+		t.Errorf("ChunkDims = %v, want [5 5 8]", dims)
+	}
+
+	data, err := ds.ReadFloat64()
+	if err != nil {
+		t.Fatalf("ReadFloat64 failed: %v", err)
+	}
+	if len(data) != 100 {
+		t.Fatalf("expected 100 elements, got %d", len(data))
+	}
+	for i, v := range data {
+		if v != float64(i) {
+			t.Fatalf("data[%d] = %v, want %d", i, v, i)
+		}
+	}
+
+	var got []float64
+	if err := ds.ReadSlice([]uint64{3, 4}, []uint64{2, 3}, &got); err != nil {
+		t.Fatalf("ReadSlice failed: %v", err)
+	}
+	if want := []float64{34, 35, 36, 44, 45, 46}; !reflect.DeepEqual(got, want) {
+		t.Errorf("slice = %v, want %v", got, want)
+	}
+}
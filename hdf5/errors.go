@@ -1,7 +1,13 @@
 // Package hdf5 provides a pure Go implementation for reading HDF5 files.
 package hdf5
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/robert-malhotra/go-hdf5/internal/filter"
+	"github.com/robert-malhotra/go-hdf5/internal/layout"
+)
 
 // Common errors
 var (
@@ -9,10 +15,61 @@ var (
 	ErrNotFound      = errors.New("object not found")
 	ErrNotDataset    = errors.New("object is not a dataset")
 	ErrNotGroup      = errors.New("object is not a group")
+	ErrNotDatatype   = errors.New("object is not a named datatype")
 	ErrUnsupported   = errors.New("unsupported feature")
 	ErrInvalidPath   = errors.New("invalid path")
 	ErrClosed        = errors.New("file is closed")
 	ErrLinkDepth     = errors.New("maximum link depth exceeded")
+	ErrFileLocked    = errors.New("file is locked by another process")
+	ErrDuplicateLink = errors.New("duplicate link name in group")
+	ErrTrailingBytes = errors.New("junk after header message fields")
+	ErrOutOfBounds   = errors.New("selection out of bounds")
+
+	// ErrNoCreationOrder is returned when listing an object's members or
+	// attributes ByCreationOrder if the object does not track it.
+	ErrNoCreationOrder = errors.New("creation order not tracked")
+
+	// ErrExternalStorageUnsupported is returned when reading a dataset whose
+	// raw data is stored in external files without a contiguous layout; see
+	// Dataset.ExternalSegments.
+	ErrExternalStorageUnsupported = errors.New("external data storage not supported")
+
+	// ErrExternalLinksDisabled is returned when following an external link
+	// in a file opened with WithoutExternalLinks.
+	ErrExternalLinksDisabled = errors.New("external links disabled")
+
+	// ErrTypeMismatch is returned when Dataset.Read is given a destination
+	// that cannot hold the dataset's values without loss.
+	ErrTypeMismatch = errors.New("destination type does not match datatype")
+
+	// ErrChecksumMismatch is returned when a chunk's Fletcher-32 checksum
+	// or a metadata structure's lookup3 checksum does not match its data;
+	// see WithChecksumPolicy and WithVerifyChecksums.
+	ErrChecksumMismatch = filter.ErrChecksumMismatch
+
+	// ErrShuffleElementSize is returned in strict mode when opening a
+	// chunked dataset whose shuffle filter shuffles elements of other than
+	// its datatype's size; otherwise a WarnShuffleElementSize warning is
+	// recorded.
+	ErrShuffleElementSize = filter.ErrShuffleElementSize
+
+	// ErrFilterRegistered is returned by RegisterFilter for a filter that
+	// is implemented by this package or already registered.
+	ErrFilterRegistered = filter.ErrFilterRegistered
+
+	// ErrFilterUnavailable is returned when reading a chunk encoded with an
+	// optional filter that is neither built in nor registered.
+	ErrFilterUnavailable = filter.ErrFilterUnavailable
+
+	// ErrNoStorage is returned when reading a contiguous dataset whose
+	// data was never allocated and which has no fill value defined; see
+	// Dataset.HasStorage.
+	ErrNoStorage = layout.ErrNoStorage
+
+	// ErrIncompleteWrite is returned by File.Flush when space was allocated
+	// in a writable file but never written, so that the file on disk would
+	// end before its recorded end of file.
+	ErrIncompleteWrite = errors.New("allocated space not written")
 
 	// Specific not-found errors for different object types
 	ErrDatasetNotFound   = errors.New("dataset not found")
@@ -20,6 +77,72 @@ var (
 	ErrAttributeNotFound = errors.New("attribute not found")
 )
 
-// MaxLinkDepth is the maximum number of soft/external links that can be followed
-// in a single path resolution. This prevents stack overflow from deeply nested links.
+// ParseError reports an error reading an object of a file. Path is the
+// object the operation concerned: a group or dataset path, or an attribute
+// path as JoinAttrPath builds it. Structure and Offset, when set, name the
+// kind of file structure that could not be read (such as "object header" or
+// "chunk index") and its address. Errors returned by Group.OpenDataset,
+// Dataset.Read and Attribute.Read, and the methods built on them, carry a
+// ParseError with the path; errors.Is sees the sentinels above through it.
+//
+// A ParseError with a Path may wrap another that names a structure found
+// along the way, such as the object header of a group on the path:
+//
+//	var perr *hdf5.ParseError
+//	if errors.As(err, &perr) {
+//		log.Printf("%s: %v", perr.Path, perr.Err)
+//	}
+type ParseError struct {
+	Path      string // Object path
+	Offset    uint64 // File address of Structure
+	Structure string // Kind of structure that failed to read, "" if none
+	Err       error
+}
+
+func (e *ParseError) Error() string {
+	msg := e.Err.Error()
+	if e.Structure != "" {
+		msg = fmt.Sprintf("reading %s at %d: %s", e.Structure, e.Offset, msg)
+	}
+	if e.Path != "" {
+		msg = e.Path + ": " + msg
+	}
+	return msg
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// structureError wraps err in a ParseError for the structure at offset.
+func structureError(structure string, offset uint64, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ParseError{Offset: offset, Structure: structure, Err: err}
+}
+
+// withPath attaches path to err: a ParseError without a path gets it, and
+// other errors are wrapped in one. Errors already carrying a path, which
+// is the more specific one, and ErrClosed are returned unchanged, as are
+// all errors if path is empty.
+func withPath(path string, err error) error {
+	if err == nil || path == "" || err == ErrClosed {
+		return err
+	}
+	var perr *ParseError
+	if errors.As(err, &perr) && perr.Path != "" {
+		return err
+	}
+	if perr, ok := err.(*ParseError); ok {
+		copied := *perr
+		copied.Path = path
+		return &copied
+	}
+	return &ParseError{Path: path, Err: err}
+}
+
+// MaxLinkDepth is the default maximum number of soft/external links that can
+// lead one to another in a single path resolution; see WithMaxLinkDepth.
+// This prevents stack overflow from deeply nested links.
 const MaxLinkDepth = 100
@@ -0,0 +1,164 @@
+package hdf5
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// HasMember reports whether this group has a link named name. Only the
+// group's own link messages or symbol table are consulted: the member's
+// object header is not read and soft or external links are not followed, so
+// a dangling link still counts as a member.
+func (g *Group) HasMember(name string) (bool, error) {
+	_, err := g.lookupMember(name)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Exists reports whether path resolves: every component names a link, and
+// soft and external links along the way lead to an object. The target's
+// object header is not read, so Exists says nothing about what kind of
+// object it is; use ExistsDataset or ExistsGroup for that.
+//
+// Missing components, dangling links and external links to missing files
+// report false with a nil error. Other failures, such as a corrupt group or a
+// link cycle, are returned.
+func (f *File) Exists(path string) (bool, error) {
+	if f.closed {
+		return false, ErrClosed
+	}
+	_, _, err := f.root.locate(path, f.newLinkChain())
+	return existsResult(err)
+}
+
+// ExistsDataset reports whether path resolves to a dataset. Like Exists, it
+// does not construct the dataset; only the target's header messages are
+// scanned to tell its kind.
+func (f *File) ExistsDataset(path string) (bool, error) {
+	return f.existsKind(path, ObjectTypeDataset)
+}
+
+// ExistsGroup reports whether path resolves to a group. Like Exists, it does
+// not construct the group; only the target's header messages are scanned to
+// tell its kind.
+func (f *File) ExistsGroup(path string) (bool, error) {
+	return f.existsKind(path, ObjectTypeGroup)
+}
+
+func (f *File) existsKind(path string, want ObjectType) (bool, error) {
+	if f.closed {
+		return false, ErrClosed
+	}
+	addr, file, err := f.root.locate(path, f.newLinkChain())
+	if ok, err := existsResult(err); !ok {
+		return false, err
+	}
+
+	kind, err := file.probeKind(addr)
+	if err != nil {
+		return false, err
+	}
+	return kind == want, nil
+}
+
+// existsResult maps a resolution error to an existence answer: not-found
+// conditions are a plain false, anything else is a real error.
+func existsResult(err error) (bool, error) {
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrNotGroup), errors.Is(err, fs.ErrNotExist):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// probeKind tells datasets, named datatypes and groups apart by scanning
+// the message framing of the header at address, without parsing any
+// message bodies.
+func (f *File) probeKind(address uint64) (ObjectType, error) {
+	raw, err := object.ReadRaw(f.reader, address)
+	if err != nil {
+		return ObjectTypeUnknown, err
+	}
+
+	// A dataset has a dataspace message, and a named datatype a datatype
+	// message only
+	kind := ObjectTypeGroup
+	for _, msg := range raw.Messages {
+		switch msg.Type {
+		case message.TypeDataspace:
+			return ObjectTypeDataset, nil
+		case message.TypeDatatype:
+			kind = ObjectTypeNamedDatatype
+		}
+	}
+	return kind, nil
+}
+
+// locate resolves relativePath to its target's address and file without
+// reading the target's header. Intermediate components are traversed as in
+// open.
+func (g *Group) locate(relativePath string, chain *linkChain) (uint64, *File, error) {
+	parts := splitPath(relativePath)
+	if len(parts) == 0 {
+		return g.addr, g.file, nil
+	}
+
+	parent, err := g.openParent(parts, chain)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	name := parts[len(parts)-1]
+	member, err := parent.lookupMember(name)
+	if err != nil {
+		return 0, nil, fmt.Errorf("finding %q: %w", name, err)
+	}
+	return parent.locateMember(member, chain)
+}
+
+// locateMember follows a member's link to its target, like resolveLink and
+// resolveEntry but without reading the target's header.
+func (g *Group) locateMember(member *memberLink, chain *linkChain) (uint64, *File, error) {
+	var targetPath string
+	switch {
+	case member.entry != nil && member.entry.LinkType == 1:
+		targetPath = member.entry.SoftLinkValue
+	case member.entry != nil:
+		return member.entry.ObjectAddress, g.file, nil
+	case member.link.IsHard():
+		return member.link.ObjectAddress, g.file, nil
+	case member.link.IsSoft():
+		targetPath = member.link.SoftLinkValue
+	case member.link.IsExternal():
+		link := member.link
+		if err := chain.follow(link.ExternalFile + ":" + link.ExternalPath); err != nil {
+			return 0, nil, err
+		}
+		defer chain.done()
+		extFile, err := g.file.openExternalFile(link.ExternalFile, link.ExternalPath)
+		if err != nil {
+			return 0, nil, err
+		}
+		return extFile.root.locate(link.ExternalPath, chain)
+	default:
+		return 0, nil, fmt.Errorf("unknown link type: %d", member.link.LinkType)
+	}
+
+	if err := chain.follow(targetPath); err != nil {
+		return 0, nil, err
+	}
+	defer chain.done()
+	return g.file.root.locate(targetPath, chain)
+}
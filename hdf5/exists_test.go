@@ -0,0 +1,151 @@
+package hdf5
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestExists(t *testing.T) {
+	tests := []struct {
+		file    string
+		path    string
+		exists  bool
+		dataset bool
+		group   bool
+	}{
+		{"groups.h5", "/", true, false, true},
+		{"groups.h5", "/group1", true, false, true},
+		{"groups.h5", "group1/subgroup/nested", true, true, false},
+		{"groups.h5", "/group1/missing", false, false, false},
+		{"groups.h5", "/missing/nested", false, false, false},
+		{"groups.h5", "/group1/data/child", false, false, false},
+		{"softlink.h5", "/link_to_dataset", true, true, false},
+		{"softlink.h5", "/link_to_group/nested", true, true, false},
+		{"dangling_link.h5", "/missing", false, false, false},
+		{"dangling_link.h5", "/missing_nested", false, false, false},
+		{"external_missing.h5", "/missing_file", false, false, false},
+		{"external_source.h5", "/link_to_subgroup", true, false, true},
+		{"v1_softlinks.h5", "/link_to_group/nested", true, true, false},
+		{"v1_softlinks.h5", "/soft_link", true, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file+tt.path, func(t *testing.T) {
+			f, err := Open(skipIfNoTestdata(t, tt.file))
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			defer f.Close()
+
+			if got, err := f.Exists(tt.path); err != nil || got != tt.exists {
+				t.Errorf("Exists = %v, %v; want %v", got, err, tt.exists)
+			}
+			if got, err := f.ExistsDataset(tt.path); err != nil || got != tt.dataset {
+				t.Errorf("ExistsDataset = %v, %v; want %v", got, err, tt.dataset)
+			}
+			if got, err := f.ExistsGroup(tt.path); err != nil || got != tt.group {
+				t.Errorf("ExistsGroup = %v, %v; want %v", got, err, tt.group)
+			}
+		})
+	}
+}
+
+func TestExistsCircularLink(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "circular_chain.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Exists("/link_a"); err == nil {
+		t.Error("Exists followed a link cycle without error")
+	}
+}
+
+func TestHasMember(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "dangling_link.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	// Dangling links are still members of their group
+	for name, want := range map[string]bool{"real_data": true, "missing": true, "absent": false} {
+		got, err := f.Root().HasMember(name)
+		if err != nil {
+			t.Fatalf("HasMember(%q) failed: %v", name, err)
+		}
+		if got != want {
+			t.Errorf("HasMember(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	v1, err := Open(skipIfNoTestdata(t, "v1_softlinks.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer v1.Close()
+	if ok, err := v1.Root().HasMember("mygroup"); err != nil || !ok {
+		t.Errorf("HasMember(mygroup) = %v, %v in v1 group", ok, err)
+	}
+}
+
+func TestExistsClosed(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "minimal.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	f.Close()
+
+	if _, err := f.Exists("/data"); err != ErrClosed {
+		t.Errorf("Exists on closed file: got %v, want ErrClosed", err)
+	}
+}
+
+// Existence checks stop at the link lookup; opening resolves the target's
+// kind, reads its header and builds the object.
+func BenchmarkExists(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "members.h5")
+	f, err := Create(path)
+	if err != nil {
+		b.Fatalf("Create failed: %v", err)
+	}
+	const n = 300
+	for i := 0; i < n; i++ {
+		if _, err := f.Root().CreateDataset(fmt.Sprintf("ds%03d", i), []float64{float64(i)}); err != nil {
+			b.Fatalf("CreateDataset failed: %v", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		b.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		b.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	b.Run("HasMember", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if ok, err := f.Root().HasMember(fmt.Sprintf("ds%03d", i%n)); err != nil || !ok {
+				b.Fatal(ok, err)
+			}
+		}
+	})
+	b.Run("Exists", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if ok, err := f.Exists(fmt.Sprintf("/ds%03d", i%n)); err != nil || !ok {
+				b.Fatal(ok, err)
+			}
+		}
+	})
+	b.Run("OpenAndDiscard", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := f.OpenDataset(fmt.Sprintf("/ds%03d", i%n)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
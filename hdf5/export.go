@@ -0,0 +1,266 @@
+package hdf5
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// DefaultExportMaxElements is the most elements a dataset may have for
+// Export to include its data, unless ExportOptions says otherwise.
+const DefaultExportMaxElements = 1 << 16
+
+// ExportOptions controls File.Export. The zero value exports the data of
+// datasets with up to DefaultExportMaxElements elements.
+type ExportOptions struct {
+	// MaxElements is the most elements a dataset may have for its data to
+	// be exported; larger datasets are exported as a descriptor. 0 means
+	// DefaultExportMaxElements.
+	MaxElements uint64
+}
+
+// Keys of the maps Export describes objects with. Member names beginning
+// with "@" may be shadowed by them.
+const (
+	ExportAttrsKey = "@attrs" // Attribute values of the object
+	ExportDataKey  = "@data"  // Data of a dataset that has attributes
+	ExportShapeKey = "@shape" // Shape of a dataset exported without data
+	ExportDtypeKey = "@dtype" // Datatype of such a dataset or a named datatype
+	ExportLinkKey  = "@link"  // Path an object was already exported under
+)
+
+// Export reads the group at groupPath and everything below it into nested
+// maps, for converting small configuration-style files to JSON. A group
+// becomes a map from member names to their exports, with its attribute
+// values, as AttrMap decodes them, under "@attrs" if it has any.
+//
+// A dataset becomes its values, nested in slices that follow its Shape,
+// such as [][]float64 for a two-dimensional float dataset, or a single
+// value if it is scalar. Integers are exported as int64 (uint64 if
+// unsigned), floats as float64, strings and enums as strings, and
+// compounds as maps. A dataset with attributes becomes a map holding the
+// values under "@data" beside "@attrs". Datasets with more elements than
+// opts.MaxElements are exported as a map holding their shape under
+// "@shape" and their datatype, as DtypeInfo.String renders it, under
+// "@dtype" instead of the values. Named datatypes are exported in the
+// same way, with only "@dtype".
+//
+// Only hard links are followed. An object reached again through another
+// hard link, such as a link back to a parent group, is exported as a map
+// holding the path it was first exported under in "@link". Attributes
+// that cannot be decoded are left out. Non-finite floats are exported as
+// they are, which encoding/json rejects.
+//
+// Example:
+//
+//	doc, err := f.Export("/", hdf5.ExportOptions{})
+//	if err != nil {
+//	    return err
+//	}
+//	out, err := json.MarshalIndent(doc, "", "  ")
+func (f *File) Export(groupPath string, opts ExportOptions) (map[string]interface{}, error) {
+	if f.closed {
+		return nil, ErrClosed
+	}
+	g, err := f.root.OpenGroup(groupPath)
+	if err != nil {
+		return nil, err
+	}
+	if opts.MaxElements == 0 {
+		opts.MaxElements = DefaultExportMaxElements
+	}
+	e := &exporter{opts: opts, exported: map[uint64]string{g.addr: g.path}}
+	return e.group(g)
+}
+
+// exporter holds the state of a File.Export.
+type exporter struct {
+	opts     ExportOptions
+	exported map[uint64]string // Paths of exported objects, by header address
+}
+
+// group exports g and its members.
+func (e *exporter) group(g *Group) (map[string]interface{}, error) {
+	out := make(map[string]interface{})
+	header, err := g.objectHeader()
+	if err != nil {
+		return nil, err
+	}
+	if err := e.attrs(out, g.file, header, g.path); err != nil {
+		return nil, err
+	}
+
+	members, err := g.memberLinks()
+	if err != nil {
+		return nil, withPath(g.path, err)
+	}
+	for i := range members {
+		member := &members[i]
+		if !member.isHard() {
+			continue
+		}
+		childPath := joinPath(g.path, member.name())
+		res, err := g.resolveMember(member, g.file.newLinkChain())
+		if err != nil {
+			return nil, withPath(childPath, err)
+		}
+		if first, ok := e.exported[res.address]; ok {
+			out[member.name()] = map[string]interface{}{ExportLinkKey: first}
+			continue
+		}
+		e.exported[res.address] = childPath
+
+		var value interface{}
+		switch res.kind {
+		case ObjectTypeDataset:
+			ds, err := g.file.openDatasetAt(res.address, childPath)
+			if err != nil {
+				return nil, withPath(childPath, err)
+			}
+			value, err = e.dataset(ds)
+			if err != nil {
+				return nil, err
+			}
+		case ObjectTypeNamedDatatype:
+			t, err := g.file.openDatatypeAt(res.address, childPath)
+			if err != nil {
+				return nil, withPath(childPath, err)
+			}
+			desc := map[string]interface{}{ExportDtypeKey: t.DtypeInfo().String()}
+			if err := e.attrs(desc, t.file, t.header, t.path); err != nil {
+				return nil, err
+			}
+			value = desc
+		default:
+			child, err := g.file.openGroupAt(res.address, childPath)
+			if err != nil {
+				return nil, withPath(childPath, err)
+			}
+			value, err = e.group(child)
+			if err != nil {
+				return nil, err
+			}
+		}
+		out[member.name()] = value
+	}
+	return out, nil
+}
+
+// dataset exports the values of ds, or a descriptor of it if it is too
+// large.
+func (e *exporter) dataset(ds *Dataset) (interface{}, error) {
+	desc := make(map[string]interface{})
+	if err := e.attrs(desc, ds.file, ds.header, ds.path); err != nil {
+		return nil, err
+	}
+	if ds.NumElements() > e.opts.MaxElements {
+		shape := ds.Shape()
+		if shape == nil {
+			shape = []uint64{}
+		}
+		desc[ExportShapeKey] = shape
+		desc[ExportDtypeKey] = ds.DtypeInfo().String()
+		return desc, nil
+	}
+
+	data, err := ds.values()
+	if err != nil {
+		return nil, err
+	}
+	if len(desc) == 0 {
+		return data, nil
+	}
+	desc[ExportDataKey] = data
+	return desc, nil
+}
+
+// attrs stores the decodable attribute values of an object in out under
+// ExportAttrsKey, if it has any.
+func (e *exporter) attrs(out map[string]interface{}, f *File, header *object.Header, objPath string) error {
+	values, err := f.attrMap(header, objPath)
+	if err != nil {
+		return err
+	}
+	for name, v := range values {
+		if _, ok := v.(UnreadableAttr); ok {
+			delete(values, name)
+		}
+	}
+	if len(values) > 0 {
+		out[ExportAttrsKey] = values
+	}
+	return nil
+}
+
+// values reads the dataset's values into slices nested to its rank, or a
+// single value if it is scalar, of the Go types Export describes.
+func (d *Dataset) values() (interface{}, error) {
+	var flat reflect.Value
+	switch d.datatype.Class {
+	case message.ClassOpaque:
+		elems, _, err := d.ReadOpaque()
+		if err != nil {
+			return nil, err
+		}
+		flat = reflect.ValueOf(elems)
+	case message.ClassReference:
+		refs, err := d.ReadReferences()
+		if err != nil {
+			return nil, err
+		}
+		flat = reflect.ValueOf(refs)
+	default:
+		elem, err := d.exportType()
+		if err != nil {
+			return nil, withPath(d.path, err)
+		}
+		dest := reflect.New(reflect.SliceOf(elem))
+		if err := d.Read(dest.Interface()); err != nil {
+			return nil, err
+		}
+		flat = dest.Elem()
+	}
+
+	shape := d.Shape()
+	switch {
+	case d.IsScalar():
+		if flat.Len() == 0 {
+			return nil, nil
+		}
+		return flat.Index(0).Interface(), nil
+	case len(shape) == 0:
+		return flat.Interface(), nil
+	}
+	typ := flat.Type()
+	for range shape[1:] {
+		typ = reflect.SliceOf(typ)
+	}
+	return reshape(flat, typ, shape).Interface(), nil
+}
+
+// exportType returns the Go type Export reads each element of the dataset
+// as.
+func (d *Dataset) exportType() (reflect.Type, error) {
+	dt := d.datatype
+	switch {
+	case dt.Class == message.ClassFixedPoint && dt.Signed, dt.Class == message.ClassTime:
+		return reflect.TypeOf(int64(0)), nil
+	case dt.Class == message.ClassFixedPoint, dt.Class == message.ClassBitfield:
+		return reflect.TypeOf(uint64(0)), nil
+	case dt.Class == message.ClassFloatPoint:
+		return reflect.TypeOf(float64(0)), nil
+	case dt.Class == message.ClassString, dt.Class == message.ClassEnum, dt.IsVarLenString:
+		return reflect.TypeOf(""), nil
+	case dt.Class == message.ClassCompound:
+		return reflect.TypeOf(map[string]interface{}(nil)), nil
+	case dt.Class == message.ClassVarLen:
+		return reflect.TypeOf((*interface{})(nil)).Elem(), nil
+	}
+	t, err := d.GoType()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	return t, nil
+}
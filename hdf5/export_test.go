@@ -0,0 +1,126 @@
+package hdf5
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// exportJSON exports the group at groupPath of the file at path and
+// returns it as JSON.
+func exportJSON(t *testing.T, path, groupPath string, opts ExportOptions) string {
+	t.Helper()
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	doc, err := f.Export(groupPath, opts)
+	if err != nil {
+		t.Fatalf("Export(%q) failed: %v", groupPath, err)
+	}
+	out, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	return string(out)
+}
+
+func TestExportFiles(t *testing.T) {
+	for _, tc := range []struct {
+		file string
+		want string
+	}{
+		{"scalar.h5", `{"scalar":42}`},
+		{"groups.h5", `{"group1":{"data":[1,2,3],"subgroup":{"nested":[4,5,6]}},"group2":{}}`},
+		{"attributes.h5", `{"@attrs":{"file_attr":"file level attribute"},` +
+			`"data":{"@attrs":{"float_attr":3.14,"int_attr":42,"string_attr":"hello"},"@data":[1,2,3]}}`},
+	} {
+		if got := exportJSON(t, skipIfNoTestdata(t, tc.file), "/", ExportOptions{}); got != tc.want {
+			t.Errorf("%s: Export = %s, want %s", tc.file, got, tc.want)
+		}
+	}
+
+	// Datasets nest to their rank as typed slices
+	f, err := Open(skipIfNoTestdata(t, "multidim.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	doc, err := f.Export("/", ExportOptions{})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	want2d := [][]int64{{0, 1, 2, 3}, {4, 5, 6, 7}, {8, 9, 10, 11}}
+	if got := doc["2d"]; !reflect.DeepEqual(got, want2d) {
+		t.Errorf("2d = %#v, want %v", got, want2d)
+	}
+	if got, ok := doc["3d"].([][][]float64); !ok || len(got) != 2 || len(got[1]) != 3 || got[1][2][3] != 23 {
+		t.Errorf("3d = %#v, want [2][3][4] float64s ending in 23", doc["3d"])
+	}
+}
+
+func TestExport(t *testing.T) {
+	type point struct {
+		X, Y int32
+	}
+	path := filepath.Join(t.TempDir(), "export.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	grp, err := f.Root().CreateGroup("config")
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	for name, data := range map[string]interface{}{
+		"points": []point{{1, 2}, {3, 4}},
+		"scale":  0.5,
+		"big":    make([]uint8, 100),
+	} {
+		if _, err := grp.CreateDataset(name, data); err != nil {
+			t.Fatalf("CreateDataset %s failed: %v", name, err)
+		}
+	}
+	if err := grp.SetAttr("version", int64(2)); err != nil {
+		t.Fatalf("SetAttr failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	got := exportJSON(t, path, "/config", ExportOptions{MaxElements: 10})
+	want := `{"@attrs":{"version":2},"big":{"@dtype":"H5T_STD_U8LE","@shape":[100]},` +
+		`"points":[{"X":1,"Y":2},{"X":3,"Y":4}],"scale":[0.5]}`
+	if got != want {
+		t.Errorf("Export = %s, want %s", got, want)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	doc, err := f.Export("/config", ExportOptions{})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if big := doc["big"]; !reflect.DeepEqual(big, make([]uint64, 100)) {
+		t.Errorf("big = %#v, want 100 uint64 zeros under the default limit", big)
+	}
+	if _, err := f.Export("/config/scale", ExportOptions{}); !errors.Is(err, ErrNotGroup) {
+		t.Errorf("Export of a dataset: got %v, want ErrNotGroup", err)
+	}
+}
+
+func TestExportCycles(t *testing.T) {
+	// /a/b/back links back to /a and /a/dup to /data; soft links are not
+	// followed
+	got := exportJSON(t, writeWalkFile(t), "/", ExportOptions{})
+	want := `{"a":{"b":{"back":{"@link":"/a"},"x":[0]},"dup":{"@link":"/data"}},"data":[1,2,3]}`
+	if got != want {
+		t.Errorf("Export = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,155 @@
+package hdf5
+
+import (
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+	"github.com/robert-malhotra/go-hdf5/internal/superblock"
+)
+
+// FileSpaceStrategy is how the library that wrote a file allocates space
+// in it.
+type FileSpaceStrategy uint8
+
+// File space strategies, numbered as in the file format.
+const (
+	FileSpaceFSMAggr FileSpaceStrategy = 0 // Free-space managers and aggregators, the default
+	FileSpacePage    FileSpaceStrategy = 1 // Free-space managers with paged aggregation
+	FileSpaceAggr    FileSpaceStrategy = 2 // Aggregators only, free space is not tracked
+	FileSpaceNone    FileSpaceStrategy = 3 // From the end of the file only
+)
+
+// String returns the name of the strategy as h5repack takes it, such as
+// "FSM_AGGR".
+func (s FileSpaceStrategy) String() string {
+	switch s {
+	case FileSpaceFSMAggr:
+		return "FSM_AGGR"
+	case FileSpacePage:
+		return "PAGE"
+	case FileSpaceAggr:
+		return "AGGR"
+	case FileSpaceNone:
+		return "NONE"
+	}
+	return "unknown"
+}
+
+// FileSpaceInfo describes how space is allocated in a file created with a
+// non-default file space strategy, such as h5py's fs_strategy and
+// fs_persist options set.
+type FileSpaceInfo struct {
+	Strategy FileSpaceStrategy
+
+	// Persist is set when free space is tracked across opens of the file,
+	// in the free-space managers at FreeSpaceManagers.
+	Persist bool
+
+	Threshold        uint64 // Smallest free-space section tracked, in bytes
+	PageSize         uint64 // Size of file space pages, 0 if not recorded
+	PageEndThreshold uint16 // Smallest free space kept at the end of a page
+
+	// EndOfAllocation is the end of the space allocated in the file, which
+	// can be past the superblock's end of file when free space persists; 0
+	// if not recorded.
+	EndOfAllocation uint64
+
+	// FreeSpaceManagers holds the addresses of the persistent free-space
+	// managers, one for each kind of allocation, 0 for kinds without one.
+	// It is nil unless Persist is set.
+	FreeSpaceManagers []uint64
+}
+
+// ExtensionMessage is a message of the superblock extension this package
+// does not interpret, with its body as stored.
+type ExtensionMessage struct {
+	Type uint16
+	Data []byte
+}
+
+// SuperblockExtension describes the superblock extension of a file: the
+// object header a version 2 or 3 superblock points to for file-wide
+// settings.
+type SuperblockExtension struct {
+	Address uint64 // Address of the extension's object header
+
+	// SharedMessageTable is the address of the table of shared object
+	// header message indexes, and SharedMessageIndexes the number of
+	// indexes; both are 0 if header messages are not shared.
+	SharedMessageTable   uint64
+	SharedMessageIndexes int
+
+	// Driver identifies the file driver the file was written with, such
+	// as "NCSAmult", and DriverInfo holds its settings; both are empty if
+	// the driver records none.
+	Driver     string
+	DriverInfo []byte
+
+	// FileSpace is nil for files with the default file space strategy.
+	FileSpace *FileSpaceInfo
+
+	// Unknown holds the extension's other messages, in header order.
+	Unknown []ExtensionMessage
+}
+
+// SuperblockExtension returns what the file's superblock extension holds,
+// or nil if it has none, as files with a version 0 or 1 superblock and
+// files made with Create do not.
+func (f *File) SuperblockExtension() *SuperblockExtension {
+	ext := f.superblock.Extension
+	if ext == nil {
+		return nil
+	}
+	info := &SuperblockExtension{Address: ext.Address}
+	if t := ext.SharedMessageTable; t != nil {
+		info.SharedMessageTable = t.TableAddress
+		info.SharedMessageIndexes = t.NumIndexes
+	}
+	if d := ext.DriverInfo; d != nil {
+		info.Driver = d.Driver
+		info.DriverInfo = d.Info
+	}
+	if fs := ext.FileSpaceInfo; fs != nil {
+		info.FileSpace = &FileSpaceInfo{
+			Strategy:         FileSpaceStrategy(fs.Strategy),
+			Persist:          fs.Persist,
+			Threshold:        fs.Threshold,
+			PageSize:         fs.PageSize,
+			PageEndThreshold: fs.PageEndThreshold,
+			EndOfAllocation:  f.definedOffset(fs.EOA),
+		}
+		for _, addr := range fs.Managers {
+			info.FileSpace.FreeSpaceManagers = append(info.FileSpace.FreeSpaceManagers, f.definedOffset(addr))
+		}
+	}
+	for _, msg := range ext.Messages {
+		if u, ok := msg.(*message.Unknown); ok {
+			info.Unknown = append(info.Unknown, ExtensionMessage{Type: uint16(u.Type()), Data: u.Data()})
+		}
+	}
+	return info
+}
+
+// definedOffset returns addr, or 0 if it is the undefined address.
+func (f *File) definedOffset(addr uint64) uint64 {
+	if f.reader.IsUndefinedOffset(addr) {
+		return 0
+	}
+	return addr
+}
+
+// readSuperblockExtension reads the superblock extension of a version 2 or
+// 3 superblock, if it has one, into f.superblock.Extension. Messages not
+// interpreted are kept as they are.
+func (f *File) readSuperblockExtension() error {
+	sb := f.superblock
+	addr := sb.SuperblockExtensionAddress
+	if sb.Version < 2 || addr == 0 || f.reader.IsUndefinedOffset(addr) {
+		return nil
+	}
+	hdr, err := object.Read(f.reader, addr)
+	if err != nil {
+		return structureError("superblock extension", addr, err)
+	}
+	sb.Extension = superblock.NewExtension(addr, hdr.Messages)
+	return nil
+}
@@ -0,0 +1,142 @@
+package hdf5
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// createWithExtension creates a file whose superblock points to an
+// extension holding messages.
+func createWithExtension(t *testing.T, path string, messages []message.Message) {
+	t.Helper()
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if f.SuperblockExtension() != nil {
+		t.Error("new file has a superblock extension")
+	}
+	addr, err := f.writeObjectHeader(messages, 0)
+	if err != nil {
+		t.Fatalf("writing superblock extension: %v", err)
+	}
+	f.superblock.SuperblockExtensionAddress = addr
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+}
+
+func TestSuperblockExtension(t *testing.T) {
+	le := binary.LittleEndian
+	driver := le.AppendUint16(append([]byte{0}, "NCSAmult"...), 3)
+	driver = append(driver, 7, 8, 9)
+	fsinfo := le.AppendUint64([]byte{1, byte(FileSpacePage), 1}, 1)
+	fsinfo = le.AppendUint64(fsinfo, 4096)
+	fsinfo = le.AppendUint16(fsinfo, 0)
+	fsinfo = le.AppendUint64(fsinfo, 0x8000)
+	for i := range 12 {
+		addr := ^uint64(0)
+		if i == 0 {
+			addr = 0x6000
+		}
+		fsinfo = le.AppendUint64(fsinfo, addr)
+	}
+	mdci := le.AppendUint64([]byte{0}, 0x7000) // Metadata cache image, not interpreted
+	mdci = le.AppendUint64(mdci, 512)
+
+	path := filepath.Join(t.TempDir(), "ext.h5")
+	createWithExtension(t, path, []message.Message{
+		&rawMessage{message.TypeSharedMessageTable, append(le.AppendUint64([]byte{0}, 0x5000), 2)},
+		&rawMessage{message.TypeDriverInfo, driver},
+		&rawMessage{message.TypeFileSpaceInfo, fsinfo},
+		&rawMessage{message.Type(0x0018), mdci},
+	})
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	ext := f.SuperblockExtension()
+	if ext == nil {
+		t.Fatal("SuperblockExtension() = nil")
+	}
+	if ext.Address != f.superblock.SuperblockExtensionAddress || ext.SharedMessageTable != 0x5000 || ext.SharedMessageIndexes != 2 {
+		t.Errorf("extension at %#x with shared message table at %#x", ext.Address, ext.SharedMessageTable)
+	}
+	if ext.Driver != "NCSAmult" || !bytes.Equal(ext.DriverInfo, []byte{7, 8, 9}) {
+		t.Errorf("driver %q with info %v", ext.Driver, ext.DriverInfo)
+	}
+	want := &FileSpaceInfo{
+		Strategy:          FileSpacePage,
+		Persist:           true,
+		Threshold:         1,
+		PageSize:          4096,
+		EndOfAllocation:   0x8000,
+		FreeSpaceManagers: append([]uint64{0x6000}, make([]uint64, 11)...),
+	}
+	if !reflect.DeepEqual(ext.FileSpace, want) {
+		t.Errorf("FileSpace = %+v, want %+v", ext.FileSpace, want)
+	}
+	if ext.FileSpace.Strategy.String() != "PAGE" {
+		t.Errorf("Strategy.String() = %q", ext.FileSpace.Strategy)
+	}
+	if len(ext.Unknown) != 1 || ext.Unknown[0].Type != 0x0018 || !bytes.Equal(ext.Unknown[0].Data, mdci) {
+		t.Errorf("Unknown = %+v, want the metadata cache image message", ext.Unknown)
+	}
+	if _, err := f.OpenGroup("/"); err != nil {
+		t.Errorf("OpenGroup failed: %v", err)
+	}
+
+	// Files opened for writing read the extension too, and keep it
+	w, err := OpenReadWrite(path)
+	if err != nil {
+		t.Fatalf("OpenReadWrite failed: %v", err)
+	}
+	if got := w.SuperblockExtension(); !reflect.DeepEqual(got, ext) {
+		t.Errorf("read-write SuperblockExtension() = %+v, want %+v", got, ext)
+	}
+	if _, err := w.Root().CreateDataset("added", []int32{1, 2}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	r, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+	if got := r.SuperblockExtension(); !reflect.DeepEqual(got, ext) {
+		t.Errorf("SuperblockExtension() after writing = %+v, want %+v", got, ext)
+	}
+
+	// Files without an extension, and files whose extension is unreadable
+	v0, err := Open(skipIfNoTestdata(t, "v0_minimal.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer v0.Close()
+	if v0.SuperblockExtension() != nil {
+		t.Error("v0 file has a superblock extension")
+	}
+	bad := filepath.Join(t.TempDir(), "bad.h5")
+	g, err := Create(bad)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	g.superblock.SuperblockExtensionAddress = 1 // Inside the signature
+	if err := g.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	var perr *ParseError
+	if _, err := Open(bad); !errors.As(err, &perr) || perr.Structure != "superblock extension" {
+		t.Errorf("Open() error = %v, want a superblock extension ParseError", err)
+	}
+}
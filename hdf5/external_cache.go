@@ -0,0 +1,102 @@
+package hdf5
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+// externalCache holds the files a File opened to follow external links, so
+// that every link into the same file shares one open File. It is safe for
+// concurrent use.
+type externalCache struct {
+	mu     sync.Mutex
+	byName map[string]*File // By the file name stored in the links
+	byPath map[string]*File // By absolute path, for files that have one
+}
+
+// lookupName returns the file opened for links naming filename.
+func (c *externalCache) lookupName(filename string) (*File, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f, ok := c.byName[cacheName(filename)]
+	return f, ok
+}
+
+// lookupPath returns the open file at path, which is made absolute.
+func (c *externalCache) lookupPath(path string) (*File, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f, ok := c.byPath[abs]
+	return f, ok
+}
+
+// add caches f as the file for links naming filename and returns the file
+// to use. When a file at the same path was added meanwhile, f is closed and
+// that file is returned instead.
+func (c *externalCache) add(filename string, f *File) *File {
+	abs := ""
+	if f.path != "" {
+		abs, _ = filepath.Abs(f.path)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if abs != "" {
+		if cached, ok := c.byPath[abs]; ok && cached != f {
+			f.Close()
+			f = cached
+		}
+	}
+	if c.byName == nil {
+		c.byName = make(map[string]*File)
+		c.byPath = make(map[string]*File)
+	}
+	c.byName[cacheName(filename)] = f
+	if abs != "" {
+		c.byPath[abs] = f
+	}
+	return f
+}
+
+// cacheName normalizes the file name stored in a link, so that names such
+// as "./a.h5" and "a.h5", or "sub\a.h5" and "sub/a.h5" on Windows, share
+// a cache entry.
+func cacheName(filename string) string {
+	return filepath.Clean(filepath.FromSlash(filename))
+}
+
+// len returns the number of distinct files in the cache.
+func (c *externalCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.files())
+}
+
+// files returns the distinct files in the cache. The caller holds c.mu.
+func (c *externalCache) files() []*File {
+	seen := make(map[*File]bool)
+	var files []*File
+	for _, f := range c.byName {
+		if !seen[f] {
+			seen[f] = true
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// closeAll closes every file in the cache and empties it.
+func (c *externalCache) closeAll() {
+	c.mu.Lock()
+	files := c.files()
+	c.byName, c.byPath = nil, nil
+	c.mu.Unlock()
+
+	for _, f := range files {
+		f.Close()
+	}
+}
@@ -0,0 +1,311 @@
+package hdf5
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+func TestExternalLinkOptions(t *testing.T) {
+	source := skipIfNoTestdata(t, "external_source.h5")
+	target := skipIfNoTestdata(t, "external_target.h5")
+	data, err := os.ReadFile(source)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	want := []float64{1, 2, 3, 4, 5}
+
+	readLinked := func(t *testing.T, f *File) ([]float64, error) {
+		t.Helper()
+		ds, err := f.OpenDataset("link_to_data")
+		if err != nil {
+			return nil, err
+		}
+		return ds.ReadFloat64()
+	}
+
+	t.Run("prefix", func(t *testing.T) {
+		// The source alone in a directory finds the target through the
+		// search path
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "source.h5"), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		f, err := Open(filepath.Join(dir, "source.h5"))
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		defer f.Close()
+		if _, err := readLinked(t, f); err == nil {
+			t.Fatal("external link resolved without the target's directory")
+		}
+
+		f, err = Open(filepath.Join(dir, "source.h5"),
+			WithExternalLinkPrefix(t.TempDir(), filepath.Dir(target)))
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		defer f.Close()
+		if got, err := readLinked(t, f); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("ReadFloat64() = %v, %v, want %v", got, err, want)
+		}
+
+		g, err := OpenBytes(data, WithExternalLinkPrefix(filepath.Dir(target)))
+		if err != nil {
+			t.Fatalf("OpenBytes failed: %v", err)
+		}
+		defer g.Close()
+		if got, err := readLinked(t, g); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("ReadFloat64() from bytes = %v, %v, want %v", got, err, want)
+		}
+	})
+
+	t.Run("resolver", func(t *testing.T) {
+		var calls []string
+		var opened *File
+		resolve := func(parent *File, filename, objPath string) (*File, error) {
+			calls = append(calls, filename+":"+objPath)
+			var err error
+			opened, err = Open(target)
+			return opened, err
+		}
+		f, err := OpenBytes(data, WithExternalLinkResolver(resolve))
+		if err != nil {
+			t.Fatalf("OpenBytes failed: %v", err)
+		}
+		if got, err := readLinked(t, f); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("ReadFloat64() = %v, %v, want %v", got, err, want)
+		}
+		if _, err := readLinked(t, f); err != nil {
+			t.Errorf("second read failed: %v", err)
+		}
+		if len(calls) != 1 {
+			t.Errorf("resolver called %d times (%v), want once", len(calls), calls)
+		}
+		if err := f.Close(); err != nil {
+			t.Errorf("Close failed: %v", err)
+		}
+		if !opened.closed {
+			t.Error("Close left the resolved file open")
+		}
+
+		// A nil file falls back to the search, and errors are returned
+		fallback := func(*File, string, string) (*File, error) { return nil, nil }
+		f, err = Open(source, WithExternalLinkResolver(fallback))
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		defer f.Close()
+		if _, err := readLinked(t, f); err != nil {
+			t.Errorf("fallback read failed: %v", err)
+		}
+
+		errDenied := errors.New("denied")
+		deny := func(*File, string, string) (*File, error) { return nil, errDenied }
+		f, err = Open(source, WithExternalLinkResolver(deny))
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		defer f.Close()
+		if _, err := readLinked(t, f); !errors.Is(err, errDenied) {
+			t.Errorf("read error = %v, want resolver error", err)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		f, err := Open(source, WithoutExternalLinks())
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		defer f.Close()
+		if _, err := f.OpenDataset("local_data"); err != nil {
+			t.Errorf("OpenDataset local_data failed: %v", err)
+		}
+		if _, err := readLinked(t, f); !errors.Is(err, ErrExternalLinksDisabled) {
+			t.Errorf("read error = %v, want ErrExternalLinksDisabled", err)
+		}
+		if f.externalFiles.len() != 0 {
+			t.Errorf("opened %d external files", f.externalFiles.len())
+		}
+	})
+}
+
+func TestExternalFileCache(t *testing.T) {
+	target := skipIfNoTestdata(t, "external_target.h5")
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	dir := t.TempDir()
+	targetPath := filepath.Join(dir, "target.h5")
+	if err := os.WriteFile(targetPath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Three links naming the same file differently
+	path := filepath.Join(dir, "source.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	for _, link := range []*message.Link{
+		message.NewExternalLink("plain", "target.h5", "/data"),
+		message.NewExternalLink("dotted", "./target.h5", "/data"),
+		message.NewExternalLink("absolute", targetPath, "/data"),
+	} {
+		if err := f.Root().addLink(link); err != nil {
+			t.Fatalf("adding link %s: %v", link.Name, err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	openFDs := func() int {
+		entries, err := os.ReadDir("/proc/self/fd")
+		if err != nil {
+			t.Skipf("cannot count open files: %v", err)
+		}
+		return len(entries)
+	}
+	before := openFDs()
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	var files []*File
+	for _, name := range []string{"plain", "plain", "dotted", "absolute"} {
+		ds, err := f.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset %s failed: %v", name, err)
+		}
+		files = append(files, ds.file)
+	}
+	for i, ext := range files {
+		if ext == f || ext != files[0] {
+			t.Errorf("link %d opened file %p, want the shared %p", i, ext, files[0])
+		}
+	}
+	if n := f.externalFiles.len(); n != 1 {
+		t.Errorf("cached %d external files, want 1", n)
+	}
+	if got := openFDs(); got != before+2 {
+		t.Errorf("%d files open, want %d", got, before+2)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if !files[0].closed {
+		t.Error("Close left the external file open")
+	}
+	if got := openFDs(); got != before {
+		t.Errorf("%d files open after Close, want %d", got, before)
+	}
+}
+
+func TestExternalLinkToSelf(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("d", []float64{1, 2}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	for name, target := range map[string]string{"self": "/d", "loop": "/loop"} {
+		if err := f.Root().CreateExternalLink(name, "data.h5", target); err != nil {
+			t.Fatalf("CreateExternalLink %s failed: %v", name, err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Opened through another spelling of the path, the link still leads
+	// back into the same File
+	f, err = Open(filepath.Join(dir, ".", "data.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	ds, err := f.OpenDataset("self")
+	if err != nil {
+		t.Fatalf("OpenDataset self failed: %v", err)
+	}
+	if ds.file != f {
+		t.Error("link to the file itself opened it again")
+	}
+	if got, err := ds.ReadFloat64(); err != nil || !reflect.DeepEqual(got, []float64{1, 2}) {
+		t.Errorf("ReadFloat64() = %v, %v", got, err)
+	}
+	if n := f.externalFiles.len(); n != 0 {
+		t.Errorf("cached %d external files, want 0", n)
+	}
+	if _, err := f.OpenDataset("loop"); err == nil || !strings.Contains(err.Error(), "circular") {
+		t.Errorf("OpenDataset loop: got %v, want circular link error", err)
+	}
+}
+
+func TestExternalLinkRelativePath(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "run"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "calib"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cal, err := Create(filepath.Join(dir, "calib", "cal.h5"))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := cal.Root().CreateDataset("c", []int32{5}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if err := cal.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	path := filepath.Join(dir, "run", "main.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	// Link file names use '/' whatever the platform
+	for name, file := range map[string]string{"cal": "../calib/cal.h5", "dotted": "./../calib/cal.h5"} {
+		if err := f.Root().CreateExternalLink(name, file, "/c"); err != nil {
+			t.Fatalf("CreateExternalLink %s failed: %v", name, err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// The names are relative to main.h5, not to the working directory
+	t.Chdir(t.TempDir())
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	for _, name := range []string{"cal", "dotted"} {
+		ds, err := f.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset %s failed: %v", name, err)
+		}
+		if got, err := ds.ReadInt32(); err != nil || !reflect.DeepEqual(got, []int32{5}) {
+			t.Errorf("%s: ReadInt32() = %v, %v", name, got, err)
+		}
+	}
+	if n := f.externalFiles.len(); n != 1 {
+		t.Errorf("cached %d external files, want 1", n)
+	}
+}
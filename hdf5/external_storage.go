@@ -0,0 +1,136 @@
+package hdf5
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/robert-malhotra/go-hdf5/internal/heap"
+	"github.com/robert-malhotra/go-hdf5/internal/layout"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// ExternalSegment is one piece of a dataset's raw data stored in a file
+// outside the HDF5 file. A dataset's data is the concatenation of its
+// segments, in order.
+type ExternalSegment struct {
+	Name      string // External file name, as recorded in the file
+	Offset    uint64 // Byte offset of the segment within the external file
+	Size      uint64 // Segment size in bytes (meaningless if Unlimited)
+	Unlimited bool   // Segment extends to the end of the external file
+}
+
+// ExternalSegments returns the external raw data segments of the dataset, or
+// nil if its data is stored in the HDF5 file itself. Reads of such datasets
+// look for the segment files like the files of external links: by absolute
+// name, then in each WithExternalLinkPrefix directory and in the directory
+// of the HDF5 file.
+func (d *Dataset) ExternalSegments() ([]ExternalSegment, error) {
+	if d.header == nil {
+		return nil, nil
+	}
+	efl := d.header.ExternalFiles()
+	if efl == nil {
+		return nil, nil
+	}
+	return resolveExternalSegments(d.file, efl)
+}
+
+// resolveExternalSegments looks up the segment file names in the message's local heap.
+func resolveExternalSegments(f *File, efl *message.ExternalFiles) ([]ExternalSegment, error) {
+	names, err := heap.ReadLocalHeap(f.reader, efl.HeapAddress)
+	if err != nil {
+		return nil, fmt.Errorf("reading external file name heap: %w", err)
+	}
+
+	segments := make([]ExternalSegment, len(efl.Slots))
+	for i, slot := range efl.Slots {
+		segments[i] = ExternalSegment{
+			Name:      names.GetString(slot.NameOffset),
+			Offset:    slot.Offset,
+			Size:      slot.Size,
+			Unlimited: slot.Size == message.ExternalSizeUnlimited,
+		}
+	}
+	return segments, nil
+}
+
+// newExternalLayout returns the layout of a dataset whose raw data lives in
+// external files. Only contiguous datasets can store their data there, as
+// in the HDF5 library; reads of any other, or of a dataset whose segments
+// cannot be looked up, fail instead of returning whatever the (unallocated)
+// in-file layout would yield.
+func newExternalLayout(f *File, ds *datasetState, class message.LayoutClass, efl *message.ExternalFiles) layout.Layout {
+	segments, err := resolveExternalSegments(f, efl)
+	if err != nil {
+		return &unreadableLayout{class: class, err: err}
+	}
+	if class != message.LayoutContiguous {
+		files := make([]string, 0, len(segments))
+		for _, seg := range segments {
+			if !containsString(files, seg.Name) {
+				files = append(files, seg.Name)
+			}
+		}
+		return &unreadableLayout{class: class, err: fmt.Errorf("%w: non-contiguous data stored in %s",
+			ErrExternalStorageUnsupported, strings.Join(files, ", "))}
+	}
+
+	layoutSegments := make([]layout.ExternalSegment, len(segments))
+	for i, seg := range segments {
+		layoutSegments[i] = layout.ExternalSegment{Name: seg.Name, Offset: seg.Offset, Size: seg.Size}
+	}
+	return layout.NewExternalContiguous(layoutSegments, ds.dataspace, ds.datatype, f.openExternalData)
+}
+
+// openExternalData opens the file of an external raw data segment,
+// looking for it where openExternalFile looks for the files of external
+// links.
+func (f *File) openExternalData(filename string) (layout.ExternalFile, error) {
+	if f.extLinks.disabled {
+		return nil, fmt.Errorf("%w: data stored in %s", ErrExternalLinksDisabled, filename)
+	}
+	candidates := f.externalCandidates(filename)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w: external data file %q of a file opened from a reader",
+			ErrUnsupported, filename)
+	}
+	var err error
+	for _, path := range candidates {
+		var file *os.File
+		file, err = os.Open(path)
+		if err == nil {
+			return file, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+	}
+	return nil, fmt.Errorf("opening external data file %q: %w", filename, err)
+}
+
+// unreadableLayout stands in for the layout of a dataset whose raw data,
+// in external files or virtual dataset sources, cannot be read, so that
+// every read reports why.
+type unreadableLayout struct {
+	class message.LayoutClass
+	err   error
+}
+
+func (l *unreadableLayout) Read() ([]byte, error) {
+	return nil, l.err
+}
+
+func (l *unreadableLayout) ReadSlice(start, count []uint64) ([]byte, error) {
+	return nil, l.err
+}
+
+func (l *unreadableLayout) ReadPoints(points [][]uint64) ([]byte, error) {
+	return nil, l.err
+}
+
+func (l *unreadableLayout) Class() message.LayoutClass {
+	return l.class
+}
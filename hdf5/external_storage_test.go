@@ -0,0 +1,174 @@
+package hdf5
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// writeNameHeap writes a local heap holding names at 8-byte aligned offsets
+// starting at 8, and returns its address and the name offsets.
+func writeNameHeap(t *testing.T, f *File, names []string) (uint64, []uint64) {
+	t.Helper()
+
+	data := make([]byte, 8) // Offset 0 holds the empty string
+	offsets := make([]uint64, len(names))
+	for i, name := range names {
+		offsets[i] = uint64(len(data))
+		entry := make([]byte, (len(name)+8)/8*8)
+		copy(entry, name)
+		data = append(data, entry...)
+	}
+
+	heapAddr := f.allocate(int64(32 + len(data)))
+	hdr := make([]byte, 32)
+	copy(hdr, "HEAP")
+	binary.LittleEndian.PutUint64(hdr[8:], uint64(len(data)))
+	binary.LittleEndian.PutUint64(hdr[16:], 0xFFFFFFFFFFFFFFFF) // No free list
+	binary.LittleEndian.PutUint64(hdr[24:], heapAddr+32)
+	if err := f.writer.At(int64(heapAddr)).WriteBytes(append(hdr, data...)); err != nil {
+		t.Fatalf("writing heap: %v", err)
+	}
+	return heapAddr, offsets
+}
+
+func TestExternalStorageDataset(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "efl.h5")
+
+	w, err := Create(testFile)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	heapAddr, offsets := writeNameHeap(t, w, []string{"raw_a.bin", "raw_b.bin"})
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err := Open(testFile)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	header := &object.Header{Messages: []message.Message{
+		message.NewDataspace([]uint64{8}, nil),
+		message.NewFloatDatatype(8, message.OrderLE),
+		message.NewContiguousLayout(0xFFFFFFFFFFFFFFFF, 64),
+		&message.ExternalFiles{
+			Version:     1,
+			HeapAddress: heapAddr,
+			Slots: []message.ExternalFileSlot{
+				{NameOffset: offsets[0], Offset: 0, Size: 32},
+				{NameOffset: offsets[1], Offset: 1024, Size: message.ExternalSizeUnlimited},
+			},
+		},
+	}}
+	ds, err := newDataset(f, "/ext", header)
+	if err != nil {
+		t.Fatalf("newDataset failed: %v", err)
+	}
+
+	segments, err := ds.ExternalSegments()
+	if err != nil {
+		t.Fatalf("ExternalSegments failed: %v", err)
+	}
+	want := []ExternalSegment{
+		{Name: "raw_a.bin", Offset: 0, Size: 32},
+		{Name: "raw_b.bin", Offset: 1024, Size: message.ExternalSizeUnlimited, Unlimited: true},
+	}
+	if len(segments) != len(want) {
+		t.Fatalf("got %d segments, want %d", len(segments), len(want))
+	}
+	for i := range want {
+		if segments[i] != want[i] {
+			t.Errorf("segment %d: got %+v, want %+v", i, segments[i], want[i])
+		}
+	}
+
+	// Reads name the missing segment file
+	_, err = ds.ReadFloat64()
+	if !errors.Is(err, fs.ErrNotExist) || !strings.Contains(err.Error(), "raw_a.bin") {
+		t.Fatalf("ReadFloat64 without the segment files: got %v, want an error naming raw_a.bin", err)
+	}
+
+	// Elements 0-3 are at the start of raw_a.bin, and 4-6 at 1024 bytes
+	// into raw_b.bin, which ends before element 7
+	dir := filepath.Dir(testFile)
+	if err := os.WriteFile(filepath.Join(dir, "raw_a.bin"), float64Bytes(0, 1, 2, 3), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "raw_b.bin"), append(make([]byte, 1024), float64Bytes(4, 5, 6)...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	vals, err := ds.ReadFloat64()
+	if err != nil {
+		t.Fatalf("ReadFloat64 failed: %v", err)
+	}
+	if want := []float64{0, 1, 2, 3, 4, 5, 6, 0}; !reflect.DeepEqual(vals, want) {
+		t.Errorf("ReadFloat64 = %v, want %v", vals, want)
+	}
+	if vals, err := ds.ReadSliceFloat64([]uint64{2}, []uint64{4}); err != nil || !reflect.DeepEqual(vals, []float64{2, 3, 4, 5}) {
+		t.Errorf("ReadSliceFloat64 = %v, %v; want [2 3 4 5]", vals, err)
+	}
+	var points []float64
+	if err := ds.ReadPoints([][]uint64{{5}, {1}}, &points); err != nil || !reflect.DeepEqual(points, []float64{5, 1}) {
+		t.Errorf("ReadPoints = %v, %v; want [5 1]", points, err)
+	}
+
+	// A 2x4 view of the same data slices across both files
+	header.Messages[0] = message.NewDataspace([]uint64{2, 4}, nil)
+	grid, err := newDataset(f, "/grid", header)
+	if err != nil {
+		t.Fatalf("newDataset failed: %v", err)
+	}
+	if vals, err := grid.ReadSliceFloat64([]uint64{0, 2}, []uint64{2, 2}); err != nil || !reflect.DeepEqual(vals, []float64{2, 3, 6, 0}) {
+		t.Errorf("2D ReadSliceFloat64 = %v, %v; want [2 3 6 0]", vals, err)
+	}
+
+	// Only contiguous data can be stored externally
+	header.Messages[2] = message.NewChunkedLayout([]uint32{4}, 8, message.ChunkIndexFixedArray)
+	chunked, err := newDataset(f, "/chunked", header)
+	if err != nil {
+		t.Fatalf("newDataset failed: %v", err)
+	}
+	if _, err := chunked.ReadFloat64(); !errors.Is(err, ErrExternalStorageUnsupported) {
+		t.Errorf("chunked ReadFloat64: got %v, want ErrExternalStorageUnsupported", err)
+	}
+
+	// Files whose external links are disabled do not open segment files either
+	closed, err := Open(testFile, WithoutExternalLinks())
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer closed.Close()
+	header.Messages[2] = message.NewContiguousLayout(0xFFFFFFFFFFFFFFFF, 64)
+	ds, err = newDataset(closed, "/ext", header)
+	if err != nil {
+		t.Fatalf("newDataset failed: %v", err)
+	}
+	if _, err := ds.ReadFloat64(); !errors.Is(err, ErrExternalLinksDisabled) {
+		t.Errorf("ReadFloat64 with external links disabled: got %v, want ErrExternalLinksDisabled", err)
+	}
+
+	// Datasets stored in the file report no segments
+	minimal, err := Open(skipIfNoTestdata(t, "minimal.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer minimal.Close()
+	plain, err := minimal.OpenDataset("/data")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if segments, err := plain.ExternalSegments(); err != nil || segments != nil {
+		t.Errorf("in-file dataset: got %v, %v; want no segments", segments, err)
+	}
+}
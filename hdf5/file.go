@@ -1,60 +1,157 @@
 package hdf5
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 
 	"github.com/robert-malhotra/go-hdf5/internal/alloc"
 	"github.com/robert-malhotra/go-hdf5/internal/binary"
-	"github.com/robert-malhotra/go-hdf5/internal/object"
 	"github.com/robert-malhotra/go-hdf5/internal/superblock"
 )
 
 // File represents an open HDF5 file.
+//
+// A File opened for reading, and the groups, datasets and attributes
+// obtained from it, may be used by multiple goroutines at once: all reads
+// go through io.ReaderAt at explicit offsets, and the state cached after
+// opening (dense links, chunk indexes, external files and warnings) is
+// synchronized. Close must not run concurrently with other calls. Files
+// opened with Create are not safe for concurrent use.
 type File struct {
 	path          string
-	file          *os.File
+	file          *os.File // Nil for files opened with OpenReader
+	size          int64    // Size of the data for files opened with OpenReader
+	mapped        []byte   // Read-only map of the file, with WithMmap
 	reader        *binary.Reader
 	superblock    *superblock.Superblock
 	root          *Group
 	closed        bool
-	externalFiles map[string]*File // Cache of opened external files
+	locked        bool            // Advisory lock held on file
+	strict        bool            // Report structural anomalies as errors
+	checksums     ChecksumPolicy  // How reads treat chunks failing their checksum
+	warnings      warningLog      // Anomalies recorded outside strict mode
+	externalFiles externalCache   // Files opened for external links
+	datasets      datasetRegistry // State shared by repeated dataset opens
+	sharedTable   sharedMsgTable  // Shared header message indexes, read on first use
+	headers       headerCache     // Recently read object headers
+	extLinks      externalLinkOptions
+	maxLinkDepth  int // Most links followed one from another, see WithMaxLinkDepth
 
 	// Write support fields
 	writable  bool
 	writer    *binary.Writer
 	allocator *alloc.Allocator // Space allocator for writing
+
+	// Groups below the root whose links are being written, by path
+	writeGroups map[string]*Group
 }
 
 // Open opens an HDF5 file for reading.
-func Open(path string) (*File, error) {
+// Use WithFileLock to hold an advisory lock while the file is open.
+func Open(path string, opts ...FileOption) (*File, error) {
+	options := defaultFileOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("opening file: %w", err)
 	}
 
-	// Parse superblock
-	sb, err := superblock.Read(f)
+	if err := acquireLock(f, options.lock, false); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	// Reading through a section of the file's size lets reads of sizes
+	// past its end, as in corrupt files, fail before allocating
+	var r io.ReaderAt = f
+	if info, err := f.Stat(); err == nil {
+		r = io.NewSectionReader(f, 0, info.Size())
+	}
+	hdf, err := openReaderAt(r, options)
 	if err != nil {
 		f.Close()
-		return nil, fmt.Errorf("reading superblock: %w", err)
+		return nil, err
 	}
+	hdf.path = path
+	hdf.file = f
+	hdf.locked = options.lock != lockNone
+
+	// Reads copy from the file if it cannot be mapped
+	if options.mmap {
+		if mapped, err := mapFile(f); err == nil {
+			hdf.mapped = mapped
+		}
+	}
+	return hdf, nil
+}
+
+// OpenReader opens HDF5 data of the given size read from r, such as an
+// object fetched into memory or a range-reading client for remote storage.
+// The file's Path is empty and Close does not close r. External links are
+// only followed through WithExternalLinkPrefix or WithExternalLinkResolver,
+// since there is no directory to resolve them against. Locking options are
+// not supported.
+func OpenReader(r io.ReaderAt, size int64, opts ...FileOption) (*File, error) {
+	options := defaultFileOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.lock != lockNone {
+		return nil, fmt.Errorf("%w: locking a file opened from a reader", ErrUnsupported)
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("invalid size %d", size)
+	}
+
+	hdf, err := openReaderAt(io.NewSectionReader(r, 0, size), options)
+	if err != nil {
+		return nil, err
+	}
+	hdf.size = size
+	return hdf, nil
+}
 
-	// Create reader with correct configuration
-	reader := binary.NewReader(f, sb.ReaderConfig())
+// OpenBytes opens HDF5 data held in memory, like OpenReader.
+func OpenBytes(data []byte, opts ...FileOption) (*File, error) {
+	return OpenReader(bytes.NewReader(data), int64(len(data)), opts...)
+}
+
+// openReaderAt reads the superblock and root group from r.
+func openReaderAt(r io.ReaderAt, options *fileOptions) (*File, error) {
+	// Parse superblock
+	sb, err := superblock.Read(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading superblock: %w", err)
+	}
 
 	hdf := &File{
-		path:       path,
-		file:       f,
-		reader:     reader,
 		superblock: sb,
+		strict:     options.strict,
+		checksums:  options.checksums,
+		extLinks:   options.extLinks,
+	}
+	hdf.headers.limit = options.headerCache
+	hdf.maxLinkDepth = options.maxLinkDepth
+	cfg := sb.ReaderConfig()
+	cfg.VerifyChecksums = options.verifyChecksums
+	cfg.SharedMessages = hdf.sharedMessage
+	hdf.reader = binary.NewReader(r, cfg)
+
+	if err := hdf.readSuperblockExtension(); err != nil {
+		return nil, err
 	}
 
 	// Load root group
 	root, err := hdf.openGroupAt(sb.RootGroupAddress, "/")
 	if err != nil {
-		f.Close()
 		return nil, fmt.Errorf("opening root group: %w", err)
 	}
 	hdf.root = root
@@ -78,10 +175,21 @@ func (f *File) Close() error {
 	}
 
 	// Close all external files
-	for _, extFile := range f.externalFiles {
-		extFile.Close()
+	f.externalFiles.closeAll()
+
+	if f.mapped != nil {
+		unmapFile(f.mapped)
+		f.mapped = nil
+	}
+
+	// Readers passed to OpenReader belong to the caller
+	if f.file == nil {
+		return nil
+	}
+
+	if f.locked {
+		unlockFile(f.file)
 	}
-	f.externalFiles = nil
 
 	return f.file.Close()
 }
@@ -91,7 +199,7 @@ func (f *File) Root() *Group {
 	return f.root
 }
 
-// Path returns the file path.
+// Path returns the file path, or "" for files opened with OpenReader.
 func (f *File) Path() string {
 	return f.path
 }
@@ -101,6 +209,52 @@ func (f *File) Version() int {
 	return int(f.superblock.Version)
 }
 
+// TrailingBytes returns how many bytes follow the logical end of file recorded
+// in the superblock. Data appended by other tools (checksum trailers, archive
+// padding) is ignored when reading, as in the C library; a file shorter than
+// its logical EOF reports 0.
+func (f *File) TrailingBytes() (uint64, error) {
+	if f.closed {
+		return 0, ErrClosed
+	}
+
+	size := uint64(f.size)
+	if f.file != nil {
+		info, err := f.file.Stat()
+		if err != nil {
+			return 0, fmt.Errorf("stat: %w", err)
+		}
+		size = uint64(info.Size())
+	}
+
+	eof := f.superblock.BaseAddress + f.superblock.EOFAddress
+	if size <= eof {
+		return 0, nil
+	}
+	return size - eof, nil
+}
+
+// Open opens the object at path, whatever its kind: a *Group, a *Dataset
+// or a *NamedDatatype. Unlike trying OpenDataset and then OpenGroup, the
+// path is resolved once, and an error is about the path itself. See
+// Group.Open.
+//
+// Example:
+//
+//	obj, err := f.Open("/sensors/temp")
+//	switch obj.Kind() {
+//	case hdf5.ObjectTypeDataset:
+//	    data, err := obj.(*hdf5.Dataset).ReadFloat64()
+//	case hdf5.ObjectTypeGroup:
+//	    members, err := obj.(*hdf5.Group).Members()
+//	}
+func (f *File) Open(path string) (Object, error) {
+	if f.closed {
+		return nil, ErrClosed
+	}
+	return f.root.Open(path)
+}
+
 // OpenGroup opens a group by path.
 func (f *File) OpenGroup(path string) (*Group, error) {
 	if f.closed {
@@ -119,26 +273,51 @@ func (f *File) OpenDataset(path string) (*Dataset, error) {
 
 // openGroupAt opens a group at the given address.
 func (f *File) openGroupAt(address uint64, path string) (*Group, error) {
-	header, err := object.Read(f.reader, address)
+	header, err := f.readHeader(address)
 	if err != nil {
-		return nil, fmt.Errorf("reading object header: %w", err)
+		return nil, structureError("object header", address, err)
+	}
+	if err := f.checkTrailingBytes(header, path); err != nil {
+		return nil, err
 	}
 
 	return &Group{
 		file:   f,
 		path:   path,
 		header: header,
+		addr:   address,
 	}, nil
 }
 
 // openDatasetAt opens a dataset at the given address.
+// In read-only files, handles to the same object share their state; writable
+// files always build fresh state since their headers may be rewritten.
 func (f *File) openDatasetAt(address uint64, path string) (*Dataset, error) {
-	header, err := object.Read(f.reader, address)
+	if !f.writable {
+		if state := f.datasets.lookup(address); state != nil {
+			return &Dataset{datasetState: state, file: f, path: path}, nil
+		}
+	}
+
+	header, err := f.readHeader(address)
 	if err != nil {
-		return nil, fmt.Errorf("reading object header: %w", err)
+		return nil, structureError("object header", address, err)
+	}
+	if err := f.checkTrailingBytes(header, path); err != nil {
+		return nil, err
 	}
 
-	return newDataset(f, path, header)
+	ds, err := newDataset(f, path, header)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.checkShuffleSize(ds); err != nil {
+		return nil, err
+	}
+	if !f.writable {
+		ds.datasetState = f.datasets.register(address, ds.datasetState)
+	}
+	return ds, nil
 }
 
 // splitPath splits a path into its components.
@@ -150,10 +329,17 @@ func splitPath(path string) []string {
 // GetAttr returns an attribute by path.
 // Path format: /group/object@attribute_name
 //
+// The path is split at its last '@'; an '@' in the object path or the
+// attribute name is written as `\@`, as ParseAttrPath describes, or the
+// names are passed to GetAttrAt instead. An error wrapping ErrNotFound is
+// returned if the object does not exist, and one wrapping
+// ErrAttributeNotFound if it has no such attribute.
+//
 // Examples:
 //   - "/@root_attr" - attribute on root group
 //   - "/data@units" - attribute on dataset 'data'
 //   - "/sensors/temp@calibration" - attribute on nested dataset
+//   - `/data@meta\@v2` - attribute 'meta@v2' on dataset 'data'
 func (f *File) GetAttr(path string) (*Attribute, error) {
 	if f.closed {
 		return nil, ErrClosed
@@ -163,19 +349,17 @@ func (f *File) GetAttr(path string) (*Attribute, error) {
 	if err != nil {
 		return nil, err
 	}
+	return f.root.getAttr(objectPath, attrName)
+}
 
-	// Get the object (group or dataset) at the path
-	obj, err := f.getAttributeHolder(objectPath)
-	if err != nil {
-		return nil, fmt.Errorf("opening object %s: %w", objectPath, err)
-	}
-
-	// Get the attribute from the object
-	attr := obj.Attr(attrName)
-	if attr == nil {
-		return nil, ErrAttributeNotFound
+// GetAttrAt returns the attribute attrName of the object at objectPath,
+// which is "/" or "" for the root group. Neither argument is parsed, so
+// both may contain '@'.
+func (f *File) GetAttrAt(objectPath, attrName string) (*Attribute, error) {
+	if f.closed {
+		return nil, ErrClosed
 	}
-	return attr, nil
+	return f.root.getAttr(objectPath, attrName)
 }
 
 // ReadAttr reads an attribute value by path.
@@ -193,53 +377,42 @@ func (f *File) ReadAttr(path string) (interface{}, error) {
 	return attr.Value()
 }
 
+// ReadAttrAt reads the value of the attribute attrName of the object at
+// objectPath. It combines GetAttrAt and Attribute.Value().
+func (f *File) ReadAttrAt(objectPath, attrName string) (interface{}, error) {
+	attr, err := f.GetAttrAt(objectPath, attrName)
+	if err != nil {
+		return nil, err
+	}
+	return attr.Value()
+}
+
 // attributeHolder is an interface for objects that can have attributes.
 type attributeHolder interface {
 	Attr(name string) *Attribute
 }
 
-// getAttributeHolder returns the group or dataset at the given path.
-func (f *File) getAttributeHolder(path string) (attributeHolder, error) {
-	if path == "/" {
-		return f.root, nil
-	}
-
-	// Try opening as a group first
-	group, err := f.OpenGroup(path)
-	if err == nil {
-		return group, nil
-	}
-
-	// If that failed, try as a dataset
-	dataset, err := f.OpenDataset(path)
-	if err == nil {
-		return dataset, nil
-	}
-
-	return nil, ErrNotFound
-}
-
 // findByAbsolutePath navigates an absolute path and returns the target's address.
-// This is used for resolving soft links. The visited map tracks paths to detect cycles.
-func (f *File) findByAbsolutePath(absPath string, visited map[string]bool) (uint64, bool, error) {
-	res, err := f.findByAbsolutePathFull(absPath, visited)
+// This is used for resolving soft links. The chain holds the links followed to reach it.
+func (f *File) findByAbsolutePath(absPath string, chain *linkChain) (uint64, ObjectType, error) {
+	res, err := f.findByAbsolutePathFull(absPath, chain)
 	if err != nil {
-		return 0, false, err
+		return 0, ObjectTypeUnknown, err
 	}
-	return res.address, res.isDataset, nil
+	return res.address, res.kind, nil
 }
 
 // findByAbsolutePathFull navigates an absolute path and returns the full resolution info.
 // This handles cases where the target is in an external file.
-func (f *File) findByAbsolutePathFull(absPath string, visited map[string]bool) (*linkResolution, error) {
+func (f *File) findByAbsolutePathFull(absPath string, chain *linkChain) (*linkResolution, error) {
 	parts := splitPath(absPath)
 	if len(parts) == 0 {
 		// Path is "/" - return root group
 		// Root group address comes from superblock
 		return &linkResolution{
-			address:   f.superblock.RootGroupAddress,
-			isDataset: false,
-			file:      nil,
+			address: f.superblock.RootGroupAddress,
+			kind:    ObjectTypeGroup,
+			file:    nil,
 		}, nil
 	}
 
@@ -247,7 +420,7 @@ func (f *File) findByAbsolutePathFull(absPath string, visited map[string]bool) (
 	currentFile := f
 
 	for i, name := range parts {
-		res, err := current.findChildFull(name, visited)
+		res, err := current.findChildFull(name, chain)
 		if err != nil {
 			return nil, fmt.Errorf("resolving %q in path %s: %w", name, absPath, err)
 		}
@@ -263,7 +436,7 @@ func (f *File) findByAbsolutePathFull(absPath string, visited map[string]bool) (
 		}
 
 		// Not the last component - must be a group to continue traversal
-		if res.isDataset {
+		if res.kind != ObjectTypeGroup {
 			return nil, fmt.Errorf("%q is not a group in path %s", name, absPath)
 		}
 
@@ -279,61 +452,119 @@ func (f *File) findByAbsolutePathFull(absPath string, visited map[string]bool) (
 	return nil, fmt.Errorf("empty path")
 }
 
-// openExternalFile opens an external file by name, relative to the current file's directory.
-// Files are cached to avoid repeated opens.
-func (f *File) openExternalFile(filename string) (*File, error) {
+// openExternalFile opens the file an external link to objPath in filename
+// points to: the file the WithExternalLinkResolver resolver returns, if
+// any, or else the first one found by externalCandidates. Opened files are
+// cached by normalized name and by absolute path, so that links naming the
+// same file differently share it, and closed with f. A link back into f
+// itself, found at one of the candidate paths, uses f rather than opening
+// the file again.
+func (f *File) openExternalFile(filename, objPath string) (*File, error) {
+	if f.extLinks.disabled {
+		return nil, fmt.Errorf("%w: link to %s:%s", ErrExternalLinksDisabled, filename, objPath)
+	}
+
 	// Check cache first
-	if f.externalFiles != nil {
-		if extFile, ok := f.externalFiles[filename]; ok {
-			return extFile, nil
+	if extFile, ok := f.externalFiles.lookupName(filename); ok {
+		return extFile, nil
+	}
+
+	var extFile *File
+	if f.extLinks.resolver != nil {
+		var err error
+		extFile, err = f.extLinks.resolver(f, filename, objPath)
+		if err != nil {
+			return nil, fmt.Errorf("resolving external file %q: %w", filename, err)
+		}
+		if extFile == f {
+			return f, nil
+		}
+	}
+	if extFile == nil {
+		candidates := f.externalCandidates(filename)
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("%w: external link to %q in a file opened from a reader",
+				ErrUnsupported, filename)
+		}
+		var err error
+		for _, extPath := range candidates {
+			if f.isAt(extPath) {
+				return f, nil
+			}
+			if cached, ok := f.externalFiles.lookupPath(extPath); ok {
+				extFile, err = cached, nil
+				break
+			}
+			extFile, err = Open(extPath, withExternalLinks(f.extLinks))
+			if err == nil || !errors.Is(err, fs.ErrNotExist) {
+				break
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("opening external file %q: %w", filename, err)
 		}
 	}
 
-	// Resolve path relative to current file's directory
-	baseDir := filepath.Dir(f.path)
-	extPath := filepath.Join(baseDir, filename)
+	return f.externalFiles.add(filename, extFile), nil
+}
 
-	// Open the external file
-	extFile, err := Open(extPath)
-	if err != nil {
-		return nil, fmt.Errorf("opening external file %q: %w", extPath, err)
+// externalCandidates lists the paths an external link's file is looked for
+// at, in order, like the HDF5 library: an absolute name as is, then the name
+// in each WithExternalLinkPrefix directory and in the directory of f. The
+// directories are searched for the base name of absolute names. Relative
+// names, "../" ones included, are taken from those directories and never
+// from the working directory; '/' separates their components on every
+// platform.
+func (f *File) externalCandidates(filename string) []string {
+	var candidates []string
+	name := filepath.FromSlash(filename)
+	if filepath.IsAbs(name) {
+		candidates = append(candidates, name)
+		name = filepath.Base(name)
 	}
-
-	// Cache it
-	if f.externalFiles == nil {
-		f.externalFiles = make(map[string]*File)
+	for _, dir := range f.extLinks.prefix {
+		candidates = append(candidates, filepath.Join(dir, name))
 	}
-	f.externalFiles[filename] = extFile
-
-	return extFile, nil
+	if f.path != "" {
+		candidates = append(candidates, filepath.Join(filepath.Dir(f.path), name))
+	}
+	return candidates
 }
 
-// resolveExternalLink resolves an external link and returns the target's address and file.
-// The visited map tracks paths to detect cycles across files.
-func (f *File) resolveExternalLink(extFile string, extPath string, visited map[string]bool) (uint64, bool, *File, error) {
-	// Check depth limit
-	if len(visited) >= MaxLinkDepth {
-		return 0, false, nil, ErrLinkDepth
+// isAt reports whether path names the file f was opened from, however it is
+// spelled. Files opened from a reader are not at any path.
+func (f *File) isAt(path string) bool {
+	if f.file == nil {
+		return false
 	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	own, err := f.file.Stat()
+	return err == nil && os.SameFile(info, own)
+}
 
-	// Create a unique key for cycle detection
-	linkKey := extFile + ":" + extPath
-	if visited[linkKey] {
-		return 0, false, nil, fmt.Errorf("circular external link detected: %s", linkKey)
+// resolveExternalLink resolves an external link and returns the target's address and file.
+// The chain holds the links followed to reach it, across files.
+func (f *File) resolveExternalLink(extFile string, extPath string, chain *linkChain) (uint64, ObjectType, *File, error) {
+	// The file and path together identify the target across files
+	if err := chain.follow(extFile + ":" + extPath); err != nil {
+		return 0, ObjectTypeUnknown, nil, err
 	}
-	visited[linkKey] = true
+	defer chain.done()
 
 	// Open the external file
-	targetFile, err := f.openExternalFile(extFile)
+	targetFile, err := f.openExternalFile(extFile, extPath)
 	if err != nil {
-		return 0, false, nil, err
+		return 0, ObjectTypeUnknown, nil, err
 	}
 
 	// Resolve the path in the external file
-	addr, isDataset, err := targetFile.findByAbsolutePath(extPath, visited)
+	addr, kind, err := targetFile.findByAbsolutePath(extPath, chain)
 	if err != nil {
-		return 0, false, nil, fmt.Errorf("resolving path %q in external file %q: %w", extPath, extFile, err)
+		return 0, ObjectTypeUnknown, nil, fmt.Errorf("resolving path %q in external file %q: %w", extPath, extFile, err)
 	}
 
-	return addr, isDataset, targetFile, nil
+	return addr, kind, targetFile, nil
 }
@@ -2,10 +2,12 @@ package hdf5
 
 import (
 	"encoding/binary"
+	"fmt"
 	"os"
 
 	"github.com/robert-malhotra/go-hdf5/internal/alloc"
 	binpkg "github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
 	"github.com/robert-malhotra/go-hdf5/internal/object"
 	"github.com/robert-malhotra/go-hdf5/internal/superblock"
 )
@@ -13,18 +15,27 @@ import (
 // Note: encoding/binary is still needed for Create() which uses binary.LittleEndian
 
 // Create creates a new HDF5 file at the given path.
-// The file will be created with a V2 superblock and V2 object headers.
+// The file will be created with a V3 superblock and V2 object headers,
+// unless WithSuperblockVersion selects another superblock version.
 func Create(path string, opts ...FileOption) (*File, error) {
 	options := defaultFileOptions()
 	for _, opt := range opts {
 		opt(options)
 	}
 
-	// Create the file
-	osFile, err := os.Create(path)
+	// Create the file, locking it before any existing contents are truncated
+	osFile, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
 	if err != nil {
 		return nil, err
 	}
+	if err := acquireLock(osFile, options.lock, true); err != nil {
+		osFile.Close()
+		return nil, err
+	}
+	if err := osFile.Truncate(0); err != nil {
+		osFile.Close()
+		return nil, err
+	}
 
 	// Create writer
 	cfg := binpkg.Config{
@@ -34,81 +45,123 @@ func Create(path string, opts ...FileOption) (*File, error) {
 	}
 	writer := binpkg.NewWriter(osFile, cfg)
 
-	// Create superblock
+	// Create superblock; version 0 superblocks also set the sizes of group
+	// B-tree nodes, as the HDF5 library does by default
 	sb := superblock.NewSuperblock()
+	sb.Version = uint8(options.superblockVersion)
 	sb.OffsetSize = uint8(options.offsetSize)
 	sb.LengthSize = uint8(options.lengthSize)
+	if sb.Version < 2 {
+		sb.GroupLeafNodeK = 4
+		sb.GroupInternalNodeK = 16
+	}
 
-	// Write superblock (will need to update EOF and root group address later)
-	sbSize := sb.Size()
-
-	// Calculate root group address (right after superblock)
-	rootGroupAddr := uint64(sbSize)
-	sb.RootGroupAddress = rootGroupAddr
-
-	// Create root group object header (empty group)
-	rootMessages := object.NewEmptyGroupHeader()
+	// Objects are allocated after the superblock
+	f := &File{
+		path:       path,
+		file:       osFile,
+		superblock: sb,
+		locked:     options.lock != lockNone,
+		strict:     options.strict,
+		checksums:  options.checksums,
+		writable:   true,
+		writer:     writer,
+		allocator:  alloc.New(uint64(sb.Size())),
+	}
+	f.headers.limit = options.headerCache
+	f.maxLinkDepth = options.maxLinkDepth
 
-	// Calculate header size to determine EOF
-	// Use minimum chunk size for compatibility with h5py
-	headerSize := object.HeaderSizeWithMinChunk(writer, rootMessages, object.MinGroupChunkSize)
-	eofAddr := uint64(sbSize + headerSize)
-	sb.EOFAddress = eofAddr
+	// Objects are read back through the same file, as in OpenReadWrite
+	readerCfg := cfg
+	readerCfg.VerifyChecksums = options.verifyChecksums
+	readerCfg.SharedMessages = f.sharedMessage
+	f.reader = binpkg.NewReader(osFile, readerCfg)
 
-	// Now write the superblock with correct addresses
-	if _, err := sb.Write(writer); err != nil {
+	// Create the empty root group right after the superblock, with the
+	// minimum chunk size for compatibility with h5py
+	f.root = &Group{
+		file:         f,
+		path:         "/",
+		header:       nil, // Will be loaded on demand
+		pendingLinks: []*message.Link{},
+	}
+	if err := f.root.rewriteHeader(); err != nil {
 		osFile.Close()
 		os.Remove(path)
 		return nil, err
 	}
 
-	// Write root group object header with minimum chunk size
-	if _, err := object.WriteHeaderWithMinChunk(writer, rootMessages, object.MinGroupChunkSize); err != nil {
+	// Write the superblock with the root group's address; Flush updates
+	// the end-of-file address
+	sb.EOFAddress = f.allocator.EOFAddr()
+	if _, err := sb.Write(writer); err != nil {
 		osFile.Close()
 		os.Remove(path)
 		return nil, err
 	}
 
-	// Create allocator starting at EOF
-	allocator := alloc.New(eofAddr)
+	return f, nil
+}
 
-	// Create File structure
-	f := &File{
-		path:       path,
-		file:       osFile,
-		superblock: sb,
-		writable:   true,
-		writer:     writer,
-		allocator:  allocator,
+// writeObjectHeader allocates and writes an object header holding
+// messages, with at least minSize bytes of messages, and returns its
+// address. Files with a version 0 or 1 superblock get V1 headers, aligned
+// to 8 bytes, and others V2 headers.
+func (f *File) writeObjectHeader(messages []message.Message, minSize int) (uint64, error) {
+	if f.superblock.Version < 2 {
+		size := object.HeaderSizeV1(f.writer, messages, minSize)
+		addr := f.allocator.AllocAligned(uint64(size), 8)
+		if _, err := object.WriteHeaderV1(f.writer.At(int64(addr)), messages, minSize); err != nil {
+			return 0, err
+		}
+		f.headers.invalidate(addr)
+		return addr, nil
 	}
-
-	// Create root group
-	f.root = &Group{
-		file:   f,
-		path:   "/",
-		header: nil, // Will be loaded on demand
-		addr:   rootGroupAddr,
+	size := object.HeaderSizeWithMinChunk(f.writer, messages, minSize)
+	addr := f.allocate(int64(size))
+	if _, err := object.WriteHeaderWithMinChunk(f.writer.At(int64(addr)), messages, minSize); err != nil {
+		return 0, err
 	}
-
-	return f, nil
+	f.headers.invalidate(addr)
+	return addr, nil
 }
 
-// Flush writes any pending changes to disk.
+// Flush makes the file on disk complete: it records the end of the
+// allocated space as the superblock's end-of-file address and syncs the
+// file. Groups, datasets and attributes are written as they are created,
+// so a copy of the file taken after Flush reads back everything created
+// before it; objects created later stay unreachable from it until the
+// next Flush. Close calls Flush for writable files.
+//
+// Flush checks that the allocations do not overlap and that the file
+// reaches the end of the last one; if it does not, space was allocated
+// but never written, and an error wrapping ErrIncompleteWrite is returned
+// without updating the superblock.
 func (f *File) Flush() error {
 	if !f.writable {
 		return nil
 	}
 
-	// Update superblock with current EOF from allocator
-	f.superblock.EOFAddress = f.allocator.EOFAddr()
+	if err := f.allocator.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrIncompleteWrite, err)
+	}
+	eof := f.allocator.EOFAddr()
+	info, err := f.file.Stat()
+	if err != nil {
+		return fmt.Errorf("stat: %w", err)
+	}
+	if end := f.superblock.BaseAddress + eof; uint64(info.Size()) < end {
+		return fmt.Errorf("%w: file ends at %d, allocated space at %d", ErrIncompleteWrite, info.Size(), end)
+	}
 
-	// Rewrite superblock at beginning of file
-	w := f.writer.At(0)
-	if _, err := f.superblock.Write(w); err != nil {
+	// The data must be on disk before the superblock points past it
+	if err := f.file.Sync(); err != nil {
+		return err
+	}
+	f.superblock.EOFAddress = eof
+	if _, err := f.superblock.Write(f.writer.At(f.superblock.FileOffset)); err != nil {
 		return err
 	}
-
-	// Sync to disk
 	return f.file.Sync()
 }
 
@@ -137,12 +190,22 @@ func (f *File) closeWritable() error {
 
 // OpenReadWrite opens an existing HDF5 file for reading and writing.
 // This allows adding new groups, datasets, and attributes to existing files.
-func OpenReadWrite(path string) (*File, error) {
+// Use WithFileLock to hold an exclusive advisory lock while the file is open.
+func OpenReadWrite(path string, opts ...FileOption) (*File, error) {
+	options := defaultFileOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
 	// Open file with read-write permissions
 	osFile, err := os.OpenFile(path, os.O_RDWR, 0)
 	if err != nil {
 		return nil, err
 	}
+	if err := acquireLock(osFile, options.lock, true); err != nil {
+		osFile.Close()
+		return nil, err
+	}
 
 	// Parse existing superblock
 	sb, err := superblock.Read(osFile)
@@ -153,7 +216,7 @@ func OpenReadWrite(path string) (*File, error) {
 
 	// Create reader with correct configuration
 	readerCfg := sb.ReaderConfig()
-	reader := binpkg.NewReader(osFile, readerCfg)
+	readerCfg.VerifyChecksums = options.verifyChecksums
 
 	// Create writer with same configuration as reader
 	// This ensures we use the same byte order, offset size, and length size
@@ -166,12 +229,23 @@ func OpenReadWrite(path string) (*File, error) {
 	f := &File{
 		path:       path,
 		file:       osFile,
-		reader:     reader,
 		superblock: sb,
+		locked:     options.lock != lockNone,
+		strict:     options.strict,
+		checksums:  options.checksums,
 		writable:   true,
 		writer:     writer,
 		allocator:  allocator,
 	}
+	f.headers.limit = options.headerCache
+	f.maxLinkDepth = options.maxLinkDepth
+	readerCfg.SharedMessages = f.sharedMessage
+	f.reader = binpkg.NewReader(osFile, readerCfg)
+
+	if err := f.readSuperblockExtension(); err != nil {
+		osFile.Close()
+		return nil, err
+	}
 
 	// Load root group
 	root, err := f.openGroupAt(sb.RootGroupAddress, "/")
@@ -1,8 +1,11 @@
 package hdf5
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -98,6 +101,124 @@ func TestCreateWithOptions(t *testing.T) {
 	}
 }
 
+// TestCreateSuperblockV0 writes a file laid out as by HDF5 1.6, with
+// symbol table groups large enough for a two-level B-tree, and extends it
+// with OpenReadWrite.
+func TestCreateSuperblockV0(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "v0.h5")
+	f, err := Create(path, WithSuperblockVersion(0), WithOffsetSize(4))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	b, err := f.Root().CreateGroup("a/b")
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	if _, err := b.CreateDataset("floats", []float64{1.5, 2.5}, WithAttribute("units", "m")); err != nil {
+		t.Fatalf("CreateDataset floats failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("ints", []int32{1, 2, 3}); err != nil {
+		t.Fatalf("CreateDataset ints failed: %v", err)
+	}
+	if err := f.Root().CreateSoftLink("link", "a/b/floats"); err != nil {
+		t.Fatalf("CreateSoftLink failed: %v", err)
+	}
+	if err := f.Root().SetAttr("title", "legacy"); err != nil {
+		t.Fatalf("SetAttr failed: %v", err)
+	}
+
+	// 300 members need 38 symbol table nodes, more than one B-tree node
+	// holds
+	many, err := f.Root().CreateGroup("many")
+	if err != nil {
+		t.Fatalf("CreateGroup many failed: %v", err)
+	}
+	var wantMany []string
+	for i := 0; i < 300; i++ {
+		name := fmt.Sprintf("l%03d", i)
+		if err := many.CreateSoftLink(name, "/ints"); err != nil {
+			t.Fatalf("CreateSoftLink %s failed: %v", name, err)
+		}
+		wantMany = append(wantMany, name)
+	}
+
+	if _, err := f.Root().CreateDataset("chunked", []int32{1}, WithChunks(1)); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("CreateDataset chunked: got %v, want ErrUnsupported", err)
+	}
+	if err := f.Root().CreateExternalLink("ext", "other.h5", "/x"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("CreateExternalLink: got %v, want ErrUnsupported", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Add to a symbol table group of the file
+	f, err = OpenReadWrite(path)
+	if err != nil {
+		t.Fatalf("OpenReadWrite failed: %v", err)
+	}
+	a, err := f.OpenGroup("/a")
+	if err != nil {
+		t.Fatalf("OpenGroup failed: %v", err)
+	}
+	if _, err := a.CreateDataset("added", []int64{7}); err != nil {
+		t.Fatalf("CreateDataset added failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path, WithStrict())
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	if a, err = f.OpenGroup("/a"); err != nil {
+		t.Fatalf("OpenGroup failed: %v", err)
+	}
+	if f.Version() != 0 {
+		t.Errorf("Version = %d, want 0", f.Version())
+	}
+	if members, err := f.Root().Members(); err != nil || !reflect.DeepEqual(members, []string{"a", "ints", "link", "many"}) {
+		t.Errorf("root Members = %v, %v; want [a ints link many]", members, err)
+	}
+	if members, err := a.Members(); err != nil || !reflect.DeepEqual(members, []string{"added", "b"}) {
+		t.Errorf("/a Members = %v, %v; want [added b]", members, err)
+	}
+	manyGroup, err := f.OpenGroup("/many")
+	if err != nil {
+		t.Fatalf("OpenGroup many failed: %v", err)
+	}
+	if members, err := manyGroup.Members(); err != nil || !reflect.DeepEqual(members, wantMany) {
+		t.Errorf("/many has %d members, %v; want %d", len(members), err, len(wantMany))
+	}
+	if title, err := f.Root().Attr("title").ReadScalarString(); err != nil || title != "legacy" {
+		t.Errorf("title = %q, %v; want legacy", title, err)
+	}
+
+	ds, err := f.OpenDataset("/link")
+	if err != nil {
+		t.Fatalf("OpenDataset through soft link failed: %v", err)
+	}
+	var floats []float64
+	if err := ds.Read(&floats); err != nil || !reflect.DeepEqual(floats, []float64{1.5, 2.5}) {
+		t.Errorf("floats = %v, %v; want [1.5 2.5]", floats, err)
+	}
+	if units, err := ds.Attr("units").ReadScalarString(); err != nil || units != "m" {
+		t.Errorf("units = %q, %v; want m", units, err)
+	}
+	for name, want := range map[string]interface{}{"/ints": []int32{1, 2, 3}, "/a/added": []int64{7}, "/many/l299": []int32{1, 2, 3}} {
+		ds, err := f.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset %s failed: %v", name, err)
+		}
+		got := reflect.New(reflect.TypeOf(want))
+		if err := ds.Read(got.Interface()); err != nil || !reflect.DeepEqual(got.Elem().Interface(), want) {
+			t.Errorf("%s = %v, %v; want %v", name, got.Elem().Interface(), err, want)
+		}
+	}
+}
+
 func TestCreateFlush(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "hdf5-test-*")
 	if err != nil {
@@ -127,6 +248,89 @@ func TestCreateFlush(t *testing.T) {
 	f2.Close()
 }
 
+// TestFlushSnapshot copies the file's bytes after a Flush, as a crash
+// would leave them, and reads the copy.
+func TestFlushSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "live.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	defer f.Close()
+	grp, err := f.Root().CreateGroup("runs")
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	if _, err := grp.CreateDataset("first", []float64{1, 2, 3}, WithChunks(2), WithGzip(6)); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if err := f.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	snapshot := func(name string) *File {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		copyPath := filepath.Join(dir, name)
+		if err := os.WriteFile(copyPath, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		c, err := Open(copyPath, WithStrict())
+		if err != nil {
+			t.Fatalf("Open %s failed: %v", name, err)
+		}
+		t.Cleanup(func() { c.Close() })
+		return c
+	}
+
+	c := snapshot("flushed.h5")
+	if trailing, err := c.TrailingBytes(); err != nil || trailing != 0 {
+		t.Errorf("TrailingBytes = %d, %v; want 0", trailing, err)
+	}
+	ds, err := c.OpenDataset("/runs/first")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	var got []float64
+	if err := ds.Read(&got); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, []float64{1, 2, 3}) {
+		t.Errorf("first = %v, want [1 2 3]", got)
+	}
+
+	// Objects created after the Flush are not reachable from a copy taken
+	// before the next one, but the copy still reads
+	if _, err := grp.CreateDataset("second", []int32{4}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	c = snapshot("unflushed.h5")
+	runs, err := c.OpenGroup("/runs")
+	if err != nil {
+		t.Fatalf("OpenGroup failed: %v", err)
+	}
+	if members, err := runs.Members(); err != nil || !reflect.DeepEqual(members, []string{"first"}) {
+		t.Errorf("Members = %v, %v; want [first]", members, err)
+	}
+	if err := f.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	c = snapshot("reflushed.h5")
+	if _, err := c.OpenDataset("/runs/second"); err != nil {
+		t.Errorf("OpenDataset after the second Flush failed: %v", err)
+	}
+
+	// Space allocated and never written fails the Flush
+	f.allocate(64)
+	if err := f.Flush(); !errors.Is(err, ErrIncompleteWrite) {
+		t.Errorf("Flush with unwritten space: got %v, want ErrIncompleteWrite", err)
+	}
+}
+
 func TestOpenReadWrite(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "hdf5-test-*")
 	if err != nil {
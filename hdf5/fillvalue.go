@@ -0,0 +1,57 @@
+package hdf5
+
+import (
+	"fmt"
+
+	"github.com/robert-malhotra/go-hdf5/internal/dtype"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// fillValueBytes returns the fill value of a dataset as the bytes of one
+// element, or nil if it is undefined. The fill value message takes
+// precedence over the old one; a defined fill value without bytes is the
+// default of all zeros.
+func fillValueBytes(header *object.Header, dt *message.Datatype) ([]byte, error) {
+	var value []byte
+	if fv := header.FillValue(); fv != nil {
+		if !fv.IsDefined {
+			return nil, nil
+		}
+		value = fv.Value
+	} else if old := header.FillValueOld(); old != nil {
+		value = old.Value
+	} else {
+		return nil, nil
+	}
+
+	if value == nil {
+		return make([]byte, dt.Size), nil
+	}
+	if len(value) != int(dt.Size) {
+		return nil, fmt.Errorf("fill value has %d bytes, datatype has %d", len(value), dt.Size)
+	}
+	return value, nil
+}
+
+// FillValue returns the value that elements never written to read as,
+// converted like a single element of the dataset: a number, a string, or a
+// map for compound types. It returns nil if the dataset has no fill value
+// defined, in which case those elements read as zeros.
+//
+// Example:
+//
+//	fill, err := ds.FillValue()
+//	if v, ok := fill.(float64); ok && math.IsNaN(v) {
+//		// Missing values are NaN
+//	}
+func (d *Dataset) FillValue() (interface{}, error) {
+	value, err := fillValueBytes(d.header, d.datatype)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", d.path, err)
+	}
+	if value == nil {
+		return nil, nil
+	}
+	return dtype.ConvertValue(d.datatype, value, d.file.reader)
+}
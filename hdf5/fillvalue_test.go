@@ -0,0 +1,276 @@
+package hdf5
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	binpkg "github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/layout"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// rawMessage is a header message written from a prepared body.
+type rawMessage struct {
+	typ  message.Type
+	body []byte
+}
+
+func (m *rawMessage) Type() message.Type                  { return m.typ }
+func (m *rawMessage) Serialize(w *binpkg.Writer) error    { return w.WriteBytes(m.body) }
+func (m *rawMessage) SerializedSize(w *binpkg.Writer) int { return len(m.body) }
+
+// fillValueV3 returns the body of a version 3 fill value message holding
+// value, or marked undefined if value is nil.
+func fillValueV3(value []byte) *rawMessage {
+	if value == nil {
+		return &rawMessage{message.TypeFillValue, []byte{3, 0x12}}
+	}
+	body := binary.LittleEndian.AppendUint32([]byte{3, 0x22}, uint32(len(value)))
+	return &rawMessage{message.TypeFillValue, append(body, value...)}
+}
+
+// writeSparseDataset adds a 1D dataset of n elements stored in chunks of
+// chunkLen elements, of which only those in chunks are allocated.
+func writeSparseDataset(t *testing.T, f *File, name string, dt *message.Datatype, n uint64, chunkLen uint32, chunks map[int][]byte, fill message.Message) {
+	t.Helper()
+	chunkDims := []uint32{chunkLen}
+	cw := layout.NewChunkWriter(f.writer, chunkDims, dt.Size, f.allocate)
+	dataLayout := message.NewChunkedLayout(chunkDims, dt.Size, message.ChunkIndexFixedArray)
+	dataLayout.ChunkIndexAddr = ^uint64(0)
+
+	if len(chunks) > 0 {
+		addrs := make([]uint64, (n+uint64(chunkLen)-1)/uint64(chunkLen))
+		for i := range addrs {
+			addrs[i] = ^uint64(0)
+			if data, ok := chunks[i]; ok {
+				addr, err := cw.WriteSingleChunk(data)
+				if err != nil {
+					t.Fatalf("writing chunk %d: %v", i, err)
+				}
+				addrs[i] = addr
+			}
+		}
+		indexAddr, err := cw.WriteFixedArrayIndex(addrs, nil)
+		if err != nil {
+			t.Fatalf("writing chunk index: %v", err)
+		}
+		dataLayout.ChunkIndexAddr = indexAddr
+	}
+
+	messages := object.NewDatasetHeader(message.NewDataspace([]uint64{n}, nil), dt, dataLayout)
+	if fill != nil {
+		messages = append(messages, fill)
+	}
+	addr := f.allocate(int64(object.HeaderSize(f.writer, messages)))
+	if _, err := object.WriteHeader(f.writer.At(int64(addr)), messages); err != nil {
+		t.Fatalf("writing dataset header: %v", err)
+	}
+	if err := f.Root().addLink(message.NewHardLink(name, addr)); err != nil {
+		t.Fatalf("linking dataset: %v", err)
+	}
+}
+
+// writeUnallocatedDataset adds a contiguous 1D dataset of n elements whose
+// data was never allocated, as HDF5 libraries create datasets lazily.
+func writeUnallocatedDataset(t *testing.T, f *File, name string, dt *message.Datatype, n uint64, fill message.Message) {
+	t.Helper()
+	dataLayout := message.NewContiguousLayout(^uint64(0), n*uint64(dt.Size))
+	messages := object.NewDatasetHeader(message.NewDataspace([]uint64{n}, nil), dt, dataLayout)
+	if fill != nil {
+		messages = append(messages, fill)
+	}
+	addr := f.allocate(int64(object.HeaderSize(f.writer, messages)))
+	if _, err := object.WriteHeader(f.writer.At(int64(addr)), messages); err != nil {
+		t.Fatalf("writing dataset header: %v", err)
+	}
+	if err := f.Root().addLink(message.NewHardLink(name, addr)); err != nil {
+		t.Fatalf("linking dataset: %v", err)
+	}
+}
+
+func float64Bytes(vals ...float64) []byte {
+	var b []byte
+	for _, v := range vals {
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(v))
+	}
+	return b
+}
+
+func TestFillValueChunked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fill.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	f64 := message.NewFloatDatatype(8, message.OrderLE)
+	i32 := message.NewFixedPointDatatype(4, true, message.OrderLE)
+	chunks := map[int][]byte{0: float64Bytes(1, 2, 3, 4), 2: float64Bytes(9, 10, 11, 12)}
+
+	writeSparseDataset(t, f, "nan", f64, 12, 4, chunks, fillValueV3(float64Bytes(math.NaN())))
+	writeSparseDataset(t, f, "undefined", f64, 12, 4, chunks, fillValueV3(nil))
+	writeSparseDataset(t, f, "empty", i32, 6, 4, nil,
+		&rawMessage{message.TypeFillValueOld, binary.LittleEndian.AppendUint32([]byte{4, 0, 0, 0}, uint32(0xFFFFD8F1))})
+
+	// A compound of an int32 and a 4-byte string
+	compound := message.NewCompoundDatatype(8, []message.CompoundMember{
+		{Name: "id", ByteOffset: 0, Type: i32},
+		{Name: "tag", ByteOffset: 4, Type: message.NewStringDatatype(4, message.PadNullPad, message.CharsetASCII)},
+	})
+	writeSparseDataset(t, f, "records", compound, 4, 2,
+		map[int][]byte{1: {7, 0, 0, 0, 'o', 'k', 0, 0, 8, 0, 0, 0, 'o', 'k', 0, 0}},
+		fillValueV3([]byte{0xFF, 0xFF, 0xFF, 0xFF, 'n', '/', 'a', 0}))
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	ds, err := f.OpenDataset("nan")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	vals, err := ds.ReadFloat64()
+	if err != nil {
+		t.Fatalf("ReadFloat64 failed: %v", err)
+	}
+	for i, v := range vals {
+		if unwritten := i >= 4 && i < 8; unwritten != math.IsNaN(v) {
+			t.Errorf("element %d = %v", i, v)
+		}
+	}
+	slice, err := ds.ReadSliceFloat64([]uint64{2}, []uint64{4})
+	if err != nil {
+		t.Fatalf("ReadSliceFloat64 failed: %v", err)
+	}
+	if slice[0] != 3 || slice[1] != 4 || !math.IsNaN(slice[2]) || !math.IsNaN(slice[3]) {
+		t.Errorf("slice = %v, want [3 4 NaN NaN]", slice)
+	}
+	if fill, err := ds.FillValue(); err != nil || !math.IsNaN(fill.(float64)) {
+		t.Errorf("FillValue() = %v, %v; want NaN", fill, err)
+	}
+
+	ds, err = f.OpenDataset("undefined")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if vals, err = ds.ReadFloat64(); err != nil || vals[5] != 0 || vals[8] != 9 {
+		t.Errorf("undefined fill: read %v, %v; want zeros in the unwritten chunk", vals, err)
+	}
+	if fill, err := ds.FillValue(); err != nil || fill != nil {
+		t.Errorf("FillValue() = %v, %v; want nil", fill, err)
+	}
+
+	ds, err = f.OpenDataset("empty")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	ints, err := ds.ReadInt32()
+	if err != nil {
+		t.Fatalf("ReadInt32 failed: %v", err)
+	}
+	if want := []int32{-9999, -9999, -9999, -9999, -9999, -9999}; !reflect.DeepEqual(ints, want) {
+		t.Errorf("no allocated chunks: read %v, want %v", ints, want)
+	}
+	if fill, err := ds.FillValue(); err != nil || fill != int32(-9999) {
+		t.Errorf("FillValue() = %v, %v; want -9999", fill, err)
+	}
+
+	ds, err = f.OpenDataset("records")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	records, err := ds.ReadCompound()
+	if err != nil {
+		t.Fatalf("ReadCompound failed: %v", err)
+	}
+	for i, r := range records {
+		want := map[string]interface{}{"id": int32(-1), "tag": "n/a"}
+		if i >= 2 {
+			want = map[string]interface{}{"id": int32(i + 5), "tag": "ok"}
+		}
+		if !reflect.DeepEqual(r, want) {
+			t.Errorf("record %d = %v, want %v", i, r, want)
+		}
+	}
+	fill, err := ds.FillValue()
+	if err != nil {
+		t.Fatalf("FillValue failed: %v", err)
+	}
+	if want := map[string]interface{}{"id": int32(-1), "tag": "n/a"}; !reflect.DeepEqual(fill, want) {
+		t.Errorf("FillValue() = %v, want %v", fill, want)
+	}
+}
+
+func TestFillValueUnallocated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "unallocated.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	f64 := message.NewFloatDatatype(8, message.OrderLE)
+	writeUnallocatedDataset(t, f, "filled", f64, 4, fillValueV3(float64Bytes(-1)))
+	writeUnallocatedDataset(t, f, "nofill", f64, 4, nil)
+	writeUnallocatedDataset(t, f, "undefined", f64, 4, fillValueV3(nil))
+	writeSparseDataset(t, f, "chunked", f64, 4, 2, nil, nil)
+	if _, err := f.Root().CreateDataset("written", []float64{1, 2}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	ds, err := f.OpenDataset("filled")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if ds.HasStorage() {
+		t.Error("filled: HasStorage() = true, want false")
+	}
+	if vals, err := ds.ReadFloat64(); err != nil || !reflect.DeepEqual(vals, []float64{-1, -1, -1, -1}) {
+		t.Errorf("filled: ReadFloat64 = %v, %v; want the fill value", vals, err)
+	}
+	if vals, err := ds.ReadSliceFloat64([]uint64{1}, []uint64{2}); err != nil || !reflect.DeepEqual(vals, []float64{-1, -1}) {
+		t.Errorf("filled: ReadSliceFloat64 = %v, %v; want the fill value", vals, err)
+	}
+
+	for _, name := range []string{"nofill", "undefined"} {
+		ds, err := f.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset %s failed: %v", name, err)
+		}
+		if ds.HasStorage() {
+			t.Errorf("%s: HasStorage() = true, want false", name)
+		}
+		if _, err := ds.ReadFloat64(); !errors.Is(err, ErrNoStorage) {
+			t.Errorf("%s: ReadFloat64 error = %v, want ErrNoStorage", name, err)
+		}
+		if _, err := ds.ReadSliceFloat64([]uint64{0}, []uint64{2}); !errors.Is(err, ErrNoStorage) {
+			t.Errorf("%s: ReadSliceFloat64 error = %v, want ErrNoStorage", name, err)
+		}
+	}
+
+	for name, want := range map[string]bool{"chunked": false, "written": true} {
+		ds, err := f.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset %s failed: %v", name, err)
+		}
+		if got := ds.HasStorage(); got != want {
+			t.Errorf("%s: HasStorage() = %v, want %v", name, got, want)
+		}
+	}
+}
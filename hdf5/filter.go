@@ -0,0 +1,50 @@
+package hdf5
+
+import "github.com/robert-malhotra/go-hdf5/internal/filter"
+
+// Filter decodes the chunks of datasets written with a filter this package
+// does not implement; see RegisterFilter.
+type Filter interface {
+	// ID returns the filter identifier.
+	ID() uint16
+
+	// Decode transforms one encoded chunk to decoded form.
+	Decode(input []byte) ([]byte, error)
+}
+
+// RegisterFilter makes datasets using filter id readable: when a dataset's
+// filter pipeline names id, factory is called with the client data of its
+// pipeline entry to create the Filter that decodes its chunks. A filter
+// that also has an Encode(input []byte) ([]byte, error) method can be
+// used when writing to such a dataset too.
+//
+// Registered filters are used by every file opened afterwards. It is an
+// error, wrapping ErrFilterRegistered, to register one of the filters this
+// package implements or an id registered before. RegisterFilter is safe to
+// call from many goroutines, and while files are being read.
+//
+// Datasets with an optional filter that is neither built in nor registered
+// can still be opened, and their chunks read if the filter was skipped for
+// them, as the HDF5 library skips optional filters that fail; reading
+// chunks the filter was applied to fails.
+//
+// For example, to read datasets compressed with the bzip2 filter (307):
+//
+//	err := hdf5.RegisterFilter(307, func([]uint32) (hdf5.Filter, error) {
+//		return bzip2Filter{}, nil
+//	})
+//
+// where bzip2Filter's Decode returns io.ReadAll(bzip2.NewReader(
+// bytes.NewReader(input))).
+func RegisterFilter(id uint16, factory func(clientData []uint32) (Filter, error)) error {
+	if factory == nil {
+		return filter.Register(id, nil)
+	}
+	return filter.Register(id, func(clientData []uint32) (filter.Filter, error) {
+		f, err := factory(clientData)
+		if f == nil || err != nil {
+			return nil, err
+		}
+		return f, nil
+	})
+}
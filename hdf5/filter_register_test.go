@@ -0,0 +1,191 @@
+package hdf5
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/filter"
+	"github.com/robert-malhotra/go-hdf5/internal/layout"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// swapFilter is a user filter that stores elements of its client data's
+// size with their bytes in reverse order.
+type swapFilter struct{ size int }
+
+func (swapFilter) ID() uint16 { return swapFilterID }
+
+func (f swapFilter) Decode(input []byte) ([]byte, error) {
+	out := slices.Clone(input)
+	for i := 0; i+f.size <= len(out); i += f.size {
+		slices.Reverse(out[i : i+f.size])
+	}
+	return out, nil
+}
+
+const swapFilterID = 32101
+
+// registerSwapFilter registers swapFilter once for all tests, as filters
+// cannot be unregistered.
+var registerSwapFilter = sync.OnceValue(func() error {
+	return RegisterFilter(swapFilterID, func(clientData []uint32) (Filter, error) {
+		if len(clientData) != 1 || clientData[0] == 0 {
+			return nil, fmt.Errorf("invalid client data %v", clientData)
+		}
+		return swapFilter{int(clientData[0])}, nil
+	})
+})
+
+func TestRegisterFilter(t *testing.T) {
+	// Shuffled, the chunks still fit in the space of the original ones
+	swap := pipelineV2(swapFilterID, 8)
+	shuffle := pipelineV2(message.FilterShuffle, 8)
+	deflate := pipelineV2(message.FilterDeflate, 9)
+	pipeline := append([]byte{2, 3}, swap[2:]...)
+	pipeline = append(pipeline, shuffle[2:]...)
+	pipeline = append(pipeline, deflate[2:]...)
+	path, original := rewriteGzipDataset(t, chunkRecoding{
+		elemSize: 8,
+		pipeline: pipeline,
+		encode: func(chunk []float64) []byte {
+			raw := make([]byte, 0, 8*len(chunk))
+			for _, v := range chunk {
+				raw = binary.BigEndian.AppendUint64(raw, math.Float64bits(v))
+			}
+			shuffled := make([]byte, len(raw))
+			for i := range len(chunk) {
+				for j := range 8 {
+					shuffled[j*len(chunk)+i] = raw[i*8+j]
+				}
+			}
+			var buf bytes.Buffer
+			w, _ := zlib.NewWriterLevel(&buf, zlib.BestCompression)
+			w.Write(shuffled)
+			w.Close()
+			return buf.Bytes()
+		},
+	})
+
+	if err := registerSwapFilter(); err != nil {
+		t.Fatalf("RegisterFilter failed: %v", err)
+	}
+	got, err := openRecoded(t, path).ReadFloat64()
+	if err != nil {
+		t.Fatalf("ReadFloat64 failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, original) {
+		t.Error("values differ from the original")
+	}
+
+	factory := func([]uint32) (Filter, error) { return swapFilter{8}, nil }
+	for _, id := range []uint16{swapFilterID, message.FilterDeflate} {
+		if err := RegisterFilter(id, factory); !errors.Is(err, ErrFilterRegistered) {
+			t.Errorf("RegisterFilter(%d): err = %v, want ErrFilterRegistered", id, err)
+		}
+	}
+}
+
+// bzip2Filter decodes the bzip2 filter (ID 307) with the standard
+// library's decompressor, as the README's RegisterFilter example does.
+type bzip2Filter struct{}
+
+func (bzip2Filter) ID() uint16 { return 307 }
+
+func (bzip2Filter) Decode(input []byte) ([]byte, error) {
+	return io.ReadAll(bzip2.NewReader(bytes.NewReader(input)))
+}
+
+var registerBzip2 = sync.OnceValue(func() error {
+	return RegisterFilter(307, func(clientData []uint32) (Filter, error) {
+		return bzip2Filter{}, nil
+	})
+})
+
+// bzip2Chunks holds the int32 values 0-7 and 8-15, each compressed by
+// Python's bz2.compress.
+var bzip2Chunks = [][]byte{
+	{
+		0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x22, 0xf7, 0x3e, 0x4b, 0x00, 0x00,
+		0x01, 0xc0, 0x00, 0x7f, 0x80, 0x20, 0x00, 0x21, 0xb5, 0x03, 0x21, 0x0c, 0x08, 0x94, 0x2b, 0xcd,
+		0xe7, 0x18, 0x85, 0xe2, 0xee, 0x48, 0xa7, 0x0a, 0x12, 0x04, 0x5e, 0xe7, 0xc9, 0x60,
+	},
+	{
+		0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0xcd, 0x8c, 0x52, 0xb1, 0x00, 0x00,
+		0x0c, 0x40, 0x00, 0x40, 0x7f, 0xa0, 0x00, 0x21, 0xa0, 0x68, 0xf5, 0x08, 0x32, 0x62, 0x0a, 0xbc,
+		0x7b, 0xc0, 0x92, 0x8f, 0x17, 0x72, 0x45, 0x38, 0x50, 0x90, 0xcd, 0x8c, 0x52, 0xb1,
+	},
+}
+
+// TestReadBzip2Dataset registers the bzip2 filter as README.md shows and
+// reads a dataset whose chunks it compressed.
+func TestReadBzip2Dataset(t *testing.T) {
+	if err := registerBzip2(); err != nil {
+		t.Fatalf("RegisterFilter failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "bzip2.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	pipelineMsg := message.NewFilterPipeline(message.FilterInfo{ID: 307, ClientData: []uint32{9}})
+	pipeline, err := filter.NewPipeline(pipelineMsg)
+	if err != nil {
+		t.Fatalf("NewPipeline failed: %v", err)
+	}
+	cw := layout.NewChunkWriter(f.writer, []uint32{8}, 4, f.allocate)
+	cw.SetPipeline(pipeline)
+	var addrs, sizes []uint64
+	for _, chunk := range bzip2Chunks {
+		addr, err := cw.WriteSingleChunk(chunk)
+		if err != nil {
+			t.Fatalf("writing chunk: %v", err)
+		}
+		addrs, sizes = append(addrs, addr), append(sizes, uint64(len(chunk)))
+	}
+	index, err := cw.WriteFixedArrayIndex(addrs, sizes)
+	if err != nil {
+		t.Fatalf("writing chunk index: %v", err)
+	}
+	dataLayout := message.NewChunkedLayout([]uint32{8}, 4, message.ChunkIndexFixedArray)
+	dataLayout.ChunkIndexAddr = index
+	writeObject(t, f, "data", append(object.NewDatasetHeader(message.NewDataspace([]uint64{16}, nil),
+		message.NewFixedPointDatatype(4, true, message.OrderLE), dataLayout), pipelineMsg))
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	ds, err := f.OpenDataset("data")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	got, err := ds.ReadInt32()
+	if err != nil {
+		t.Fatalf("ReadInt32 failed: %v", err)
+	}
+	for i, v := range got {
+		if v != int32(i) {
+			t.Fatalf("ReadInt32() = %v, want 0 to 15", got)
+		}
+	}
+	if len(got) != 16 {
+		t.Errorf("read %d values, want 16", len(got))
+	}
+}
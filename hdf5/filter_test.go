@@ -0,0 +1,1076 @@
+package hdf5
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	bin "github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/filter"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// recordingReaderAt records the reads made through it while enabled.
+type recordingReaderAt struct {
+	r io.ReaderAt
+
+	mu      sync.Mutex
+	enabled bool
+	reads   [][2]int64 // Offset and length
+}
+
+func (c *recordingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	c.mu.Lock()
+	if c.enabled {
+		c.reads = append(c.reads, [2]int64{off, int64(len(p))})
+	}
+	c.mu.Unlock()
+	return c.r.ReadAt(p, off)
+}
+
+// bitPacker builds a most-significant-bit-first bit stream.
+type bitPacker struct {
+	bits []byte // One entry per bit
+}
+
+func (p *bitPacker) put(v uint64, n int) {
+	for k := n - 1; k >= 0; k-- {
+		p.bits = append(p.bits, byte(v>>k&1))
+	}
+}
+
+func (p *bitPacker) bytes() []byte {
+	out := make([]byte, (len(p.bits)+7)/8)
+	for i, b := range p.bits {
+		out[i/8] |= b << (7 - i%8)
+	}
+	return out
+}
+
+// pipelineV2 returns a version 2 filter pipeline message body holding one
+// filter with the given client data. Filters from 256 up get an empty name.
+func pipelineV2(id uint16, clientData ...uint32) []byte {
+	body := []byte{2, 1}
+	body = binary.LittleEndian.AppendUint16(body, id)
+	if id >= 256 {
+		body = append(body, 0, 0) // Name length
+	}
+	body = append(body, 0, 0)
+	body = binary.LittleEndian.AppendUint16(body, uint16(len(clientData)))
+	for _, v := range clientData {
+		body = binary.LittleEndian.AppendUint32(body, v)
+	}
+	return body
+}
+
+// chunkRecoding describes how rewriteGzipDataset recodes the gzip dataset
+// of compressed.h5 (100x100 float64 in 10x10 chunks).
+type chunkRecoding struct {
+	datatype []byte                       // New datatype message body, or nil to keep float64
+	elemSize byte                         // Element size to record in the layout
+	pipeline []byte                       // New filter pipeline message body
+	encode   func(chunk []float64) []byte // Codes one chunk of the original values
+
+	// Record each chunk's coded size in the chunk index, for filters that
+	// do not ignore bytes past their data; otherwise chunks keep their
+	// original size
+	trim bool
+
+	// If set, returns the filter mask of the chunk encode last coded, with
+	// bit i set if it skipped filter i; recorded in the chunk index with
+	// the chunk's size
+	mask func() uint32
+}
+
+// rewriteGzipDataset rewrites the gzip dataset of compressed.h5 as if it
+// had been written with another filter: every chunk is recoded in place and
+// the datatype, filter pipeline and layout messages are patched. It returns
+// the path of the rewritten file and the dataset's original values.
+//
+// The chunks come from encoders written here or in this package, so tests
+// built on it are smoke tests of the read path only; each filter is checked
+// against libhdf5's output by a test reading an h5py fixture.
+func rewriteGzipDataset(t *testing.T, rc chunkRecoding) (string, []float64) {
+	t.Helper()
+	path := copyTestdata(t, "compressed.h5")
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	addr := datasetAddress(t, f, "gzip")
+	raw, err := object.ReadRaw(f.reader, addr)
+	if err != nil {
+		t.Fatalf("ReadRaw failed: %v", err)
+	}
+	recorder := &recordingReaderAt{r: f.file}
+	f.reader = bin.NewReader(recorder, f.superblock.ReaderConfig())
+	ds, err := f.OpenDataset("gzip")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	original, err := ds.ReadFloat64()
+	if err != nil {
+		t.Fatalf("ReadFloat64 failed: %v", err)
+	}
+	// With the chunk index loaded, a second read touches only chunk data
+	recorder.enabled = true
+	if _, err := ds.ReadRaw(); err != nil {
+		t.Fatalf("ReadRaw failed: %v", err)
+	}
+	f.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder.reads) != 100 {
+		t.Fatalf("recorded %d chunk reads, want 100", len(recorder.reads))
+	}
+	sizes := make(map[uint64]uint64) // Coded size of the chunk at each address
+	masks := make(map[uint64]uint32) // Filter mask of the chunk at each address
+	for _, rd := range recorder.reads {
+		off, n := rd[0], rd[1]
+		zr, err := zlib.NewReader(bytes.NewReader(data[off : off+n]))
+		if err != nil {
+			t.Fatalf("chunk at %d: %v", off, err)
+		}
+		chunk, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("chunk at %d: %v", off, err)
+		}
+		values := make([]float64, len(chunk)/8)
+		for i := range values {
+			values[i] = math.Float64frombits(binary.LittleEndian.Uint64(chunk[i*8:]))
+		}
+		coded := rc.encode(values)
+		if int64(len(coded)) > n {
+			t.Fatalf("recoded chunk of %d bytes does not fit in %d", len(coded), n)
+		}
+		copy(data[off:], coded)
+		sizes[uint64(off)] = uint64(len(coded))
+		if rc.mask != nil {
+			masks[uint64(off)] = rc.mask()
+		}
+	}
+
+	var dtMsg, filterMsg, layoutMsg, nilMsg *object.RawMessage
+	for i := range raw.Messages {
+		m := &raw.Messages[i]
+		switch m.Type {
+		case message.TypeDatatype:
+			dtMsg = m
+		case message.TypeFilterPipeline:
+			filterMsg = m
+		case message.TypeDataLayout:
+			layoutMsg = m
+		case message.TypeNIL:
+			if filterMsg != nil && nilMsg == nil {
+				nilMsg = m
+			}
+		}
+	}
+	if raw.Version != 2 || dtMsg == nil || filterMsg == nil || layoutMsg == nil || nilMsg == nil ||
+		len(rc.datatype) > int(dtMsg.Size) || layoutMsg.Data[0] != 4 || layoutMsg.Data[7] != 8 {
+		t.Fatalf("unexpected header layout: %+v", raw.Messages)
+	}
+
+	if rc.datatype != nil {
+		body := data[dtMsg.DataOffset : dtMsg.DataOffset+uint64(dtMsg.Size)]
+		copy(body, make([]byte, len(body)))
+		copy(body, rc.datatype)
+	}
+	data[layoutMsg.DataOffset+7] = rc.elemSize
+
+	grow := uint64(len(rc.pipeline)) - uint64(filterMsg.Size)
+	if grow > uint64(nilMsg.Size) {
+		t.Fatalf("no room to grow the filter pipeline message")
+	}
+
+	// Rebuild the messages from the pipeline to the end of the free space
+	frameLen := filterMsg.DataOffset - filterMsg.Offset
+	end := nilMsg.DataOffset + uint64(nilMsg.Size)
+	var region []byte
+	region = append(region, data[filterMsg.Offset:filterMsg.DataOffset]...)
+	binary.LittleEndian.PutUint16(region[1:], uint16(len(rc.pipeline)))
+	region = append(region, rc.pipeline...)
+	region = append(region, data[filterMsg.DataOffset+uint64(filterMsg.Size):nilMsg.Offset]...)
+	nilFrame := append([]byte(nil), data[nilMsg.Offset:nilMsg.DataOffset]...)
+	binary.LittleEndian.PutUint16(nilFrame[1:], uint16(uint64(nilMsg.Size)-grow))
+	region = append(region, nilFrame...)
+	region = append(region, make([]byte, end-filterMsg.Offset-uint64(len(region)))...)
+	if frameLen != 4 || uint64(len(region)) != end-filterMsg.Offset {
+		t.Fatalf("unexpected message framing")
+	}
+	copy(data[filterMsg.Offset:], region)
+
+	if rc.mask != nil && !rc.trim {
+		t.Fatalf("chunk filter masks are only recorded with trimmed sizes")
+	}
+	if rc.trim {
+		trimChunkSizes(t, data, binary.LittleEndian.Uint64(layoutMsg.Data[len(layoutMsg.Data)-8:]), sizes, masks)
+	}
+
+	chunkEnd := raw.Chunks[0].Address + raw.Chunks[0].Length
+	binary.LittleEndian.PutUint32(data[chunkEnd:], bin.ChecksumLookup3(data[raw.Address:chunkEnd], 0))
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path, original
+}
+
+// trimChunkSizes sets the sizes and filter masks of the chunks in the
+// unpaged fixed array chunk index at indexAddr of the file data to those in
+// sizes and masks, keyed by chunk address. Chunks missing from masks get a
+// mask of 0.
+func trimChunkSizes(t *testing.T, data []byte, indexAddr uint64, sizes map[uint64]uint64, masks map[uint64]uint32) {
+	t.Helper()
+	header := data[indexAddr:]
+	if string(header[:4]) != "FAHD" || header[5] != 1 {
+		t.Fatalf("chunk index at %d is not a filtered fixed array", indexAddr)
+	}
+	entrySize := int(header[6])
+	count := int(binary.LittleEndian.Uint64(header[8:]))
+	blockAddr := binary.LittleEndian.Uint64(header[16:])
+	if count > 1<<header[7] || string(data[blockAddr:blockAddr+4]) != "FADB" {
+		t.Fatalf("unexpected fixed array data block at %d", blockAddr)
+	}
+
+	entries := data[blockAddr+14 : blockAddr+14+uint64(count*entrySize)]
+	for i := 0; i < count; i++ {
+		entry := entries[i*entrySize : (i+1)*entrySize]
+		addr := binary.LittleEndian.Uint64(entry)
+		size, ok := sizes[addr]
+		if !ok {
+			t.Fatalf("chunk at %d was not recoded", addr)
+		}
+		for j := 8; j < entrySize-4; j++ {
+			entry[j] = byte(size >> (8 * (j - 8)))
+		}
+		binary.LittleEndian.PutUint32(entry[entrySize-4:], masks[addr])
+	}
+	blockEnd := blockAddr + 14 + uint64(count*entrySize)
+	binary.LittleEndian.PutUint32(data[blockEnd:], bin.ChecksumLookup3(data[blockAddr:blockEnd], 0))
+}
+
+// openRecoded opens the rewritten gzip dataset.
+func openRecoded(t *testing.T, path string) *Dataset {
+	t.Helper()
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { f.Close() })
+	ds, err := f.OpenDataset("gzip")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	return ds
+}
+
+// szipUncompressed codes float32 values the way the szip filter stores
+// 32-bit pixels (bytes interleaved, scanlines padded to whole blocks) using
+// only uncompressed blocks, after the filter's four byte size prefix.
+func szipUncompressed(values []float64, pixelsPerBlock, pixelsPerScanline int) []byte {
+	words := len(values)
+	interleaved := make([]byte, 4*words)
+	for i, v := range values {
+		bits := math.Float32bits(float32(v))
+		for j := 0; j < 4; j++ {
+			interleaved[j*words+i] = byte(bits >> (8 * j))
+		}
+	}
+
+	var padded []byte
+	lineBlocks := (pixelsPerScanline + pixelsPerBlock - 1) / pixelsPerBlock
+	for i := 0; i < len(interleaved); i += pixelsPerScanline {
+		line := interleaved[i:min(i+pixelsPerScanline, len(interleaved))]
+		padded = append(padded, line...)
+		padded = append(padded, make([]byte, lineBlocks*pixelsPerBlock-len(line))...)
+	}
+
+	// Each block is the 3-bit uncompressed id 111 followed by its samples
+	p := &bitPacker{}
+	for i, b := range padded {
+		if i%pixelsPerBlock == 0 {
+			p.put(7, 3)
+		}
+		p.put(uint64(b), 8)
+	}
+	return append(binary.LittleEndian.AppendUint32(nil, uint32(len(interleaved))), p.bytes()...)
+}
+
+// packScaleOffset codes offsets from minval the way the scale-offset
+// filter does: a 21-byte header, then each offset in minbits bits.
+func packScaleOffset(minbits int, minval uint64, offsets []uint64) []byte {
+	out := binary.LittleEndian.AppendUint32(nil, uint32(minbits))
+	out = append(out, 8)
+	out = binary.LittleEndian.AppendUint64(out, minval)
+	out = append(out, make([]byte, 8)...)
+	p := &bitPacker{}
+	for _, v := range offsets {
+		p.put(v, minbits)
+	}
+	return append(out, p.bytes()...)
+}
+
+func TestReadSzipDataset(t *testing.T) {
+	// Client data as libhdf5's set_local callback stores it: options mask
+	// (EC, LSB, raw, allow K13), pixels per block, bits per pixel and pixels
+	// per scanline
+	path, original := rewriteGzipDataset(t, chunkRecoding{
+		datatype: []byte{0x11, 0x20, 0x1F, 0x00, 4, 0, 0, 0, 0, 0, 32, 0, 23, 8, 0, 23, 127, 0, 0, 0},
+		elemSize: 4,
+		pipeline: pipelineV2(message.FilterSZIP, 141, 32, 32, 100),
+		encode: func(chunk []float64) []byte {
+			return szipUncompressed(chunk, 32, 100)
+		},
+	})
+	ds := openRecoded(t, path)
+	if ds.DtypeSize() != 4 {
+		t.Fatalf("DtypeSize = %d, want the rewritten 4", ds.DtypeSize())
+	}
+
+	got, err := ds.ReadFloat32()
+	if err != nil {
+		t.Fatalf("ReadFloat32 failed: %v", err)
+	}
+	if len(got) != len(original) {
+		t.Fatalf("read %d values, want %d", len(got), len(original))
+	}
+	for i, v := range original {
+		if got[i] != float32(v) {
+			t.Fatalf("value %d = %v, want %v", i, got[i], float32(v))
+		}
+	}
+
+	window, err := ds.ReadSliceFloat32([]uint64{95, 37}, []uint64{5, 6})
+	if err != nil {
+		t.Fatalf("ReadSliceFloat32 failed: %v", err)
+	}
+	for i, v := range window {
+		if want := float32(original[(95+i/6)*100+37+i%6]); v != want {
+			t.Fatalf("window value %d = %v, want %v", i, v, want)
+		}
+	}
+}
+
+// TestReadSzipH5py reads float32 datasets that libhdf5 compressed with
+// libaec, with entropy coding and with nearest neighbour preprocessing, and
+// compares them to their uncompressed twin.
+func TestReadSzipH5py(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "szip.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	twin, err := f.OpenDataset("float32")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	want, err := twin.ReadFloat32()
+	if err != nil {
+		t.Fatalf("ReadFloat32 failed: %v", err)
+	}
+	for _, name := range []string{"szip_ec", "szip_nn"} {
+		ds, err := f.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset(%s) failed: %v", name, err)
+		}
+		got, err := ds.ReadFloat32()
+		if err != nil {
+			t.Fatalf("%s: ReadFloat32 failed: %v", name, err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("%s: read %d values, want %d", name, len(got), len(want))
+		}
+		for i := range want {
+			if math.Float32bits(got[i]) != math.Float32bits(want[i]) {
+				t.Fatalf("%s: value %d = %v, want %v", name, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+// A dataset whose shuffle filter shuffles 16-byte elements of its 8-byte
+// values reads as the filter says, with a warning.
+func TestShuffleElementSizeMismatch(t *testing.T) {
+	const size = 16
+	shuffle := pipelineV2(message.FilterShuffle, size)
+	deflate := pipelineV2(message.FilterDeflate, 9)
+	pipeline := append([]byte{2, 2}, shuffle[2:]...)
+	pipeline = append(pipeline, deflate[2:]...)
+	path, original := rewriteGzipDataset(t, chunkRecoding{
+		elemSize: 8,
+		pipeline: pipeline,
+		encode: func(chunk []float64) []byte {
+			raw := make([]byte, 0, 8*len(chunk))
+			for _, v := range chunk {
+				raw = binary.LittleEndian.AppendUint64(raw, math.Float64bits(v))
+			}
+			shuffled := make([]byte, len(raw))
+			n := len(raw) / size
+			for i := 0; i < n; i++ {
+				for j := 0; j < size; j++ {
+					shuffled[j*n+i] = raw[i*size+j]
+				}
+			}
+			var buf bytes.Buffer
+			w, _ := zlib.NewWriterLevel(&buf, zlib.BestCompression)
+			w.Write(shuffled)
+			w.Close()
+			return buf.Bytes()
+		},
+	})
+
+	ds := openRecoded(t, path)
+	got, err := ds.ReadFloat64()
+	if err != nil {
+		t.Fatalf("ReadFloat64 failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, original) {
+		t.Error("values differ from the original")
+	}
+	warnings := ds.file.Warnings()
+	if len(warnings) != 1 || warnings[0].Kind != WarnShuffleElementSize || warnings[0].Path != "/gzip" ||
+		!errors.Is(warnings[0].Err, ErrShuffleElementSize) {
+		t.Fatalf("warnings = %v, want one ShuffleElementSize for /gzip", warnings)
+	}
+	if msg := warnings[0].Err.Error(); !strings.Contains(msg, "size 16") || !strings.Contains(msg, "size 8") {
+		t.Errorf("warning error %q does not give both sizes", msg)
+	}
+
+	f, err := Open(path, WithStrict())
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.OpenDataset("gzip"); !errors.Is(err, ErrShuffleElementSize) {
+		t.Errorf("OpenDataset in strict mode: err = %v, want ErrShuffleElementSize", err)
+	}
+}
+
+func TestReadZstdDataset(t *testing.T) {
+	shuffle := pipelineV2(message.FilterShuffle, 8)
+	zstd := pipelineV2(message.FilterZstd, 19)
+	pipeline := append([]byte{2, 2}, shuffle[2:]...)
+	pipeline = append(pipeline, zstd[2:]...)
+	path, original := rewriteGzipDataset(t, chunkRecoding{
+		elemSize: 8,
+		pipeline: pipeline,
+		encode: func(chunk []float64) []byte {
+			shuffled := make([]byte, 8*len(chunk))
+			for i, v := range chunk {
+				var elem [8]byte
+				binary.LittleEndian.PutUint64(elem[:], math.Float64bits(v))
+				for j, b := range elem {
+					shuffled[j*len(chunk)+i] = b
+				}
+			}
+			coded, err := filter.NewZstd([]uint32{19}).Encode(shuffled)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return coded
+		},
+		trim: true,
+	})
+
+	got, err := openRecoded(t, path).ReadFloat64()
+	if err != nil {
+		t.Fatalf("ReadFloat64 failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, original) {
+		t.Error("values differ from the original")
+	}
+}
+
+func TestReadLzfDataset(t *testing.T) {
+	// Unshuffled, chunks of random values do not shrink, and are stored
+	// without LZF, as the HDF5 library stores them when the optional filter
+	// fails
+	for _, shuffled := range []bool{false, true} {
+		lzf := pipelineV2(message.FilterLZF, 4, 0x0105, 800)
+		pipeline := lzf
+		if shuffled {
+			shuffle := pipelineV2(message.FilterShuffle, 8)
+			pipeline = append([]byte{2, 2}, shuffle[2:]...)
+			pipeline = append(pipeline, lzf[2:]...)
+		}
+		stored := 0
+		var mask uint32 // Filter mask of the last chunk
+		lzfBit := uint32(1)
+		if shuffled {
+			lzfBit = 2
+		}
+		path, original := rewriteGzipDataset(t, chunkRecoding{
+			elemSize: 8,
+			pipeline: pipeline,
+			encode: func(chunk []float64) []byte {
+				raw := make([]byte, 8*len(chunk))
+				for i, v := range chunk {
+					var elem [8]byte
+					binary.LittleEndian.PutUint64(elem[:], math.Float64bits(v))
+					for j, b := range elem {
+						if shuffled {
+							raw[j*len(chunk)+i] = b
+						} else {
+							raw[8*i+j] = b
+						}
+					}
+				}
+				mask = 0
+				coded, err := filter.NewLzf([]uint32{4, 0x0105, 800}).Encode(raw)
+				if errors.Is(err, filter.ErrIncompressible) {
+					stored++
+					mask = lzfBit
+					return raw
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				return coded
+			},
+			trim: true,
+			mask: func() uint32 { return mask },
+		})
+		if shuffled != (stored == 0) {
+			t.Errorf("shuffled %v: %d of 100 chunks stored uncompressed", shuffled, stored)
+		}
+
+		got, err := openRecoded(t, path).ReadFloat64()
+		if err != nil {
+			t.Fatalf("shuffled %v: ReadFloat64 failed: %v", shuffled, err)
+		}
+		if !reflect.DeepEqual(got, original) {
+			t.Errorf("shuffled %v: values differ from the original", shuffled)
+		}
+	}
+}
+
+// TestReadLzfH5py reads a dataset written by h5py with compression="lzf".
+func TestReadLzfH5py(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "lzf.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	for _, name := range []string{"lzf", "shuffle_lzf", "random_lzf"} {
+		ds, err := f.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset(%s) failed: %v", name, err)
+		}
+		got, err := ds.ReadFloat64()
+		if err != nil {
+			t.Fatalf("%s: ReadFloat64 failed: %v", name, err)
+		}
+		if len(got) != 100*100 {
+			t.Fatalf("%s: read %d values, want 10000", name, len(got))
+		}
+		if name == "random_lzf" {
+			// Chunks that did not shrink are stored unfiltered
+			twin, err := f.OpenDataset("random")
+			if err != nil {
+				t.Fatalf("OpenDataset(random) failed: %v", err)
+			}
+			want, err := twin.ReadFloat64()
+			if err != nil {
+				t.Fatalf("random: ReadFloat64 failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s differs from random", name)
+			}
+			continue
+		}
+		for i, v := range got {
+			if want := float64(i) / 7; v != want {
+				t.Fatalf("%s: value %d = %v, want %v", name, i, v, want)
+			}
+		}
+	}
+}
+
+// TestReadZstdHDF5Plugin reads a dataset written by h5py with hdf5plugin.
+func TestReadZstdHDF5Plugin(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "zstd.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	for _, name := range []string{"zstd", "shuffle_zstd"} {
+		ds, err := f.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset(%s) failed: %v", name, err)
+		}
+		got, err := ds.ReadFloat64()
+		if err != nil {
+			t.Fatalf("%s: ReadFloat64 failed: %v", name, err)
+		}
+		if len(got) != 100*100 {
+			t.Fatalf("%s: read %d values, want 10000", name, len(got))
+		}
+		for i, v := range got {
+			if want := float64(i) / 7; v != want {
+				t.Fatalf("%s: value %d = %v, want %v", name, i, v, want)
+			}
+		}
+	}
+}
+
+func TestReadScaleOffsetInt32(t *testing.T) {
+	minval := int64(-1000)
+	toInt := func(v float64) int32 { return int32(v * 1e6) }
+
+	// Scale type, scale factor, elements per chunk, class, size, signed,
+	// byte order, fill value defined, then unused fill value words
+	cd := []uint32{2, 0, 100, 0, 4, 1, 0, 0}
+	cd = append(cd, make([]uint32, 12)...)
+	path, original := rewriteGzipDataset(t, chunkRecoding{
+		datatype: []byte{0x10, 0x08, 0, 0, 4, 0, 0, 0, 0, 0, 32, 0},
+		elemSize: 4,
+		pipeline: pipelineV2(message.FilterScaleOffset, cd...),
+		encode: func(chunk []float64) []byte {
+			offsets := make([]uint64, len(chunk))
+			for i, v := range chunk {
+				offsets[i] = uint64(int64(toInt(v)) - minval)
+			}
+			return packScaleOffset(20, uint64(minval), offsets)
+		},
+	})
+
+	got, err := openRecoded(t, path).ReadInt32()
+	if err != nil {
+		t.Fatalf("ReadInt32 failed: %v", err)
+	}
+	if len(got) != len(original) {
+		t.Fatalf("read %d values, want %d", len(got), len(original))
+	}
+	for i, v := range original {
+		if got[i] != toInt(v) {
+			t.Fatalf("value %d = %d, want %d", i, got[i], toInt(v))
+		}
+	}
+}
+
+func TestReadScaleOffsetFloat64(t *testing.T) {
+	// D-scaling keeps three decimal places of each offset from the minimum
+	const minval, scale = -0.5, 1000.0
+	offset := func(v float64) uint64 { return uint64(math.Round((v - minval) * scale)) }
+
+	cd := []uint32{0, 3, 100, 1, 8, 1, 0, 0}
+	cd = append(cd, make([]uint32, 12)...)
+	path, original := rewriteGzipDataset(t, chunkRecoding{
+		elemSize: 8,
+		pipeline: pipelineV2(message.FilterScaleOffset, cd...),
+		encode: func(chunk []float64) []byte {
+			offsets := make([]uint64, len(chunk))
+			for i, v := range chunk {
+				offsets[i] = offset(v)
+			}
+			return packScaleOffset(11, math.Float64bits(minval), offsets)
+		},
+	})
+
+	got, err := openRecoded(t, path).ReadFloat64()
+	if err != nil {
+		t.Fatalf("ReadFloat64 failed: %v", err)
+	}
+	if len(got) != len(original) {
+		t.Fatalf("read %d values, want %d", len(got), len(original))
+	}
+	for i, v := range original {
+		want := float64(offset(v))/scale + minval
+		if got[i] != want || math.Abs(got[i]-v) > 0.0005 {
+			t.Fatalf("value %d = %v, want %v (originally %v)", i, got[i], want, v)
+		}
+	}
+}
+
+// TestReadScaleOffsetH5py reads datasets that libhdf5's scale-offset filter
+// coded and compares them to their uncompressed twins: integers exactly,
+// D-scaled floats to half a unit of their last decimal digit.
+func TestReadScaleOffsetH5py(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "scaleoffset.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	open := func(name string) *Dataset {
+		t.Helper()
+		ds, err := f.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset(%s) failed: %v", name, err)
+		}
+		return ds
+	}
+
+	for _, name := range []string{"int32", "int32_fill"} {
+		want, err := open(name).ReadInt32()
+		if err != nil {
+			t.Fatalf("%s: ReadInt32 failed: %v", name, err)
+		}
+		got, err := open("scaleoffset_" + name).ReadInt32()
+		if err != nil {
+			t.Fatalf("scaleoffset_%s: ReadInt32 failed: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("scaleoffset_%s differs from %s", name, name)
+		}
+	}
+
+	want64, err := open("float64").ReadFloat64()
+	if err != nil {
+		t.Fatalf("float64: ReadFloat64 failed: %v", err)
+	}
+	got64, err := open("scaleoffset_float64").ReadFloat64()
+	if err != nil {
+		t.Fatalf("scaleoffset_float64: ReadFloat64 failed: %v", err)
+	}
+	if len(got64) != len(want64) {
+		t.Fatalf("scaleoffset_float64: read %d values, want %d", len(got64), len(want64))
+	}
+	for i, v := range want64 {
+		if math.Abs(got64[i]-v) > 0.5e-3+1e-9 {
+			t.Fatalf("scaleoffset_float64: value %d = %v, want %v to 3 decimal places", i, got64[i], v)
+		}
+	}
+
+	want32, err := open("float32").ReadFloat32()
+	if err != nil {
+		t.Fatalf("float32: ReadFloat32 failed: %v", err)
+	}
+	got32, err := open("scaleoffset_float32").ReadFloat32()
+	if err != nil {
+		t.Fatalf("scaleoffset_float32: ReadFloat32 failed: %v", err)
+	}
+	if len(got32) != len(want32) {
+		t.Fatalf("scaleoffset_float32: read %d values, want %d", len(got32), len(want32))
+	}
+	for i, v := range want32 {
+		if math.Abs(float64(got32[i])-float64(v)) > 0.5e-2+1e-4 {
+			t.Fatalf("scaleoffset_float32: value %d = %v, want %v to 2 decimal places", i, got32[i], v)
+		}
+	}
+}
+
+func TestReadNBitInt16(t *testing.T) {
+	// 12 significant bits of a signed int16, so the sign bit of each packed
+	// value must be extended on reading
+	toInt := func(v float64) int16 { return int16(math.Floor(v*4000)) - 2000 }
+
+	// Parameter count, stored unpacked, elements per chunk, then the
+	// atomic type: class, size, byte order, precision, offset
+	cd := []uint32{8, 0, 100, 1, 2, 0, 12, 0}
+	path, original := rewriteGzipDataset(t, chunkRecoding{
+		datatype: []byte{0x10, 0x08, 0, 0, 2, 0, 0, 0, 0, 0, 12, 0},
+		elemSize: 2,
+		pipeline: pipelineV2(message.FilterNBit, cd...),
+		encode: func(chunk []float64) []byte {
+			p := &bitPacker{}
+			for _, v := range chunk {
+				p.put(uint64(uint16(toInt(v))), 12)
+			}
+			return p.bytes()
+		},
+	})
+
+	got, err := openRecoded(t, path).ReadInt16()
+	if err != nil {
+		t.Fatalf("ReadInt16 failed: %v", err)
+	}
+	if len(got) != len(original) {
+		t.Fatalf("read %d values, want %d", len(got), len(original))
+	}
+	negative := false
+	for i, v := range original {
+		if got[i] != toInt(v) {
+			t.Fatalf("value %d = %d, want %d", i, got[i], toInt(v))
+		}
+		negative = negative || got[i] < 0
+	}
+	if !negative {
+		t.Error("no negative values exercised sign extension")
+	}
+}
+
+// TestReadNBitH5py reads signed integers that libhdf5's n-bit filter packed
+// to 12 and 20 bits and compares them to their uncompressed twins.
+func TestReadNBitH5py(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "nbit.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	open := func(name string) *Dataset {
+		t.Helper()
+		ds, err := f.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset(%s) failed: %v", name, err)
+		}
+		return ds
+	}
+
+	want16, err := open("int16").ReadInt16()
+	if err != nil {
+		t.Fatalf("int16: ReadInt16 failed: %v", err)
+	}
+	got16, err := open("nbit_int16").ReadInt16()
+	if err != nil {
+		t.Fatalf("nbit_int16: ReadInt16 failed: %v", err)
+	}
+	if !reflect.DeepEqual(got16, want16) {
+		t.Error("nbit_int16 differs from int16")
+	}
+
+	want32, err := open("int32").ReadInt32()
+	if err != nil {
+		t.Fatalf("int32: ReadInt32 failed: %v", err)
+	}
+	got32, err := open("nbit_int32").ReadInt32()
+	if err != nil {
+		t.Fatalf("nbit_int32: ReadInt32 failed: %v", err)
+	}
+	if !reflect.DeepEqual(got32, want32) {
+		t.Error("nbit_int32 differs from int32")
+	}
+}
+
+// TestFletcher32Corruption damages one chunk of a checksummed dataset and
+// reads it under each ChecksumPolicy.
+func TestFletcher32Corruption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fletcher.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	want := make([]int32, 12)
+	for i := range want {
+		want[i] = 1000 + int32(i)
+	}
+	if _, err := f.Root().CreateDataset("values", want, WithChunks(4), WithFletcher32()); err != nil {
+		t.Fatalf("CreateDataset values failed: %v", err)
+	}
+	// Chunks of an odd number of bytes are checksummed with a last
+	// half-filled word
+	odd := []int8{1, -2, 3, -4, 5, -6, 7}
+	if _, err := f.Root().CreateDataset("odd", odd, WithChunks(3), WithFletcher32()); err != nil {
+		t.Fatalf("CreateDataset odd failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Flip a bit of the second chunk of values
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var second []byte
+	for _, v := range want[4:8] {
+		second = binary.LittleEndian.AppendUint32(second, uint32(v))
+	}
+	chunkAddr := bytes.Index(data, second)
+	if chunkAddr < 0 {
+		t.Fatal("second chunk not found")
+	}
+	data[chunkAddr+1] ^= 0x10
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(policy ChecksumPolicy) (*Dataset, []int32, error) {
+		t.Helper()
+		f, err := Open(path, WithChecksumPolicy(policy))
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		t.Cleanup(func() { f.Close() })
+		oddDS, err := f.OpenDataset("odd")
+		if err != nil {
+			t.Fatalf("OpenDataset odd failed: %v", err)
+		}
+		var gotOdd []int8
+		if err := oddDS.Read(&gotOdd); err != nil || !reflect.DeepEqual(gotOdd, odd) {
+			t.Errorf("odd = %v, %v; want %v", gotOdd, err, odd)
+		}
+		ds, err := f.OpenDataset("values")
+		if err != nil {
+			t.Fatalf("OpenDataset values failed: %v", err)
+		}
+		var got []int32
+		err = ds.Read(&got)
+		return ds, got, err
+	}
+
+	_, _, err = read(ChecksumStrict)
+	var chunkErr *ChunkError
+	if !errors.Is(err, ErrChecksumMismatch) || !errors.As(err, &chunkErr) {
+		t.Fatalf("strict Read: got %v, want a ChunkError wrapping ErrChecksumMismatch", err)
+	}
+	if !reflect.DeepEqual(chunkErr.Offset, []uint64{4}) || chunkErr.Address != uint64(chunkAddr) {
+		t.Errorf("ChunkError at offset %v, address %d; want [4], %d", chunkErr.Offset, chunkErr.Address, chunkAddr)
+	}
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Path != "/values" || perr.Structure != "chunked storage" {
+		t.Errorf("strict Read: got %v, want a ParseError for the chunked storage of /values", err)
+	}
+
+	ds, got, err := read(ChecksumWarnAndSkip)
+	if err != nil {
+		t.Fatalf("Read skipping bad chunks failed: %v", err)
+	}
+	skipped := append([]int32{}, want...)
+	copy(skipped[4:8], []int32{0, 0, 0, 0})
+	if !reflect.DeepEqual(got, skipped) {
+		t.Errorf("values = %v, want %v", got, skipped)
+	}
+	// A second read records the chunk only once
+	if err := ds.Read(&got); err != nil {
+		t.Fatalf("second Read failed: %v", err)
+	}
+	if errs := ds.ReadErrors(); len(errs) != 1 || errs[0].Address != uint64(chunkAddr) || !errors.Is(errs[0], ErrChecksumMismatch) {
+		t.Errorf("ReadErrors = %v, want the second chunk", errs)
+	}
+
+	_, got, err = read(ChecksumIgnore)
+	if err != nil {
+		t.Fatalf("Read ignoring checksums failed: %v", err)
+	}
+	damaged := append([]int32{}, want...)
+	damaged[4] ^= 0x1000
+	if !reflect.DeepEqual(got, damaged) {
+		t.Errorf("values = %v, want %v", got, damaged)
+	}
+}
+
+func TestVerifyChecksums(t *testing.T) {
+	want := []int32{1, 2, 3, 4, 5, 6, 7, 8}
+	create := func(t *testing.T, opts []FileOption, dsOpts []DatasetOption) []byte {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "verify.h5")
+		f, err := Create(path, opts...)
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if _, err := f.Root().CreateDataset("values", want, dsOpts...); err != nil {
+			t.Fatalf("CreateDataset failed: %v", err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	read := func(data []byte, opts ...FileOption) error {
+		f, err := OpenBytes(data, opts...)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		ds, err := f.OpenDataset("values")
+		if err != nil {
+			return err
+		}
+		var got []int32
+		if err := ds.Read(&got); err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(got, want) {
+			return errors.New("wrong values")
+		}
+		return nil
+	}
+
+	for _, tc := range []struct {
+		name     string
+		opts     []FileOption
+		dsOpts   []DatasetOption
+		corrupt  func(t *testing.T, data []byte) string // Returns the expected error
+		mismatch bool
+	}{
+		{
+			// Flip a bit of the root group's padding, which readers skip.
+			// The superblock holds its address after the signature,
+			// version, sizes, flags and base, extension and EOF addresses.
+			name: "object header",
+			corrupt: func(t *testing.T, data []byte) string {
+				addr := int(binary.LittleEndian.Uint64(data[36:]))
+				flags := data[addr+5]
+				pos := addr + 6
+				if flags&0x20 != 0 {
+					pos += 16
+				}
+				if flags&0x10 != 0 {
+					pos += 4
+				}
+				size := 1 << (flags & 0x03)
+				var chunk0 [8]byte
+				copy(chunk0[:], data[pos:pos+size])
+				end := pos + size + int(binary.LittleEndian.Uint64(chunk0[:]))
+				data[end-1] ^= 0x01
+				return fmt.Sprintf("OHDR at %d: checksum mismatch", addr)
+			},
+			mismatch: true,
+		},
+		{
+			name:   "fixed array header",
+			dsOpts: []DatasetOption{WithChunks(4)},
+			corrupt: func(t *testing.T, data []byte) string {
+				// The checksum follows the signature, version, client ID,
+				// entry size, page bits, entry count and data block address
+				addr := bytes.Index(data, []byte("FAHD"))
+				data[addr+24] ^= 0x01
+				return fmt.Sprintf("FAHD at %d: checksum mismatch", addr)
+			},
+			mismatch: true,
+		},
+		{
+			name: "local heap",
+			opts: []FileOption{WithSuperblockVersion(0)},
+			corrupt: func(t *testing.T, data []byte) string {
+				// Point the free list of the root group's heap past the end
+				// of its data segment. The heap address is cached last in
+				// the superblock's root symbol table entry.
+				addr := int(binary.LittleEndian.Uint64(data[88:]))
+				binary.LittleEndian.PutUint64(data[addr+16:], 1<<20)
+				return fmt.Sprintf("HEAP at %d: free list offset", addr)
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data := create(t, tc.opts, tc.dsOpts)
+			if err := read(data, WithVerifyChecksums(true)); err != nil {
+				t.Fatalf("reading intact file: %v", err)
+			}
+			want := tc.corrupt(t, data)
+			if err := read(data); err != nil {
+				t.Errorf("reading without verification: %v", err)
+			}
+			err := read(data, WithVerifyChecksums(true))
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Fatalf("reading with verification: err = %v, want %q", err, want)
+			}
+			if errors.Is(err, ErrChecksumMismatch) != tc.mismatch {
+				t.Errorf("errors.Is(%v, ErrChecksumMismatch) = %v", err, !tc.mismatch)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package hdf5
+
+import (
+	"os"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+func TestForensicHeader(t *testing.T) {
+	for _, filename := range []string{"minimal.h5", "v0_minimal.h5"} {
+		t.Run(filename, func(t *testing.T) {
+			path := skipIfNoTestdata(t, filename)
+
+			f, err := Open(path)
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			defer f.Close()
+
+			addr := datasetAddress(t, f, "data")
+			fh, err := f.ForensicHeader(addr)
+			if err != nil {
+				t.Fatalf("ForensicHeader failed: %v", err)
+			}
+			if fh.FramingError != nil {
+				t.Errorf("unexpected framing error: %v", fh.FramingError)
+			}
+
+			found := make(map[uint16]bool)
+			for _, m := range fh.Messages {
+				found[m.Type] = true
+				if uint32(len(m.Data)) != m.Size {
+					t.Errorf("%s: got %d body bytes, declared %d", m.TypeName, len(m.Data), m.Size)
+				}
+			}
+			for _, typ := range []message.Type{message.TypeDataspace, message.TypeDatatype, message.TypeDataLayout} {
+				if !found[uint16(typ)] {
+					t.Errorf("missing %s message", typ)
+				}
+			}
+		})
+	}
+}
+
+func TestForensicHeaderDamagedFraming(t *testing.T) {
+	path := copyTestdata(t, "v0_minimal.h5")
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	addr := datasetAddress(t, f, "data")
+	orig, err := f.ForensicHeader(addr)
+	f.Close()
+	if err != nil {
+		t.Fatalf("ForensicHeader failed: %v", err)
+	}
+	if len(orig.Messages) < 3 {
+		t.Fatalf("expected at least 3 messages, got %d", len(orig.Messages))
+	}
+
+	// Declare an impossible size for the third message
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sizeField := orig.Messages[2].Offset + 2
+	data[sizeField], data[sizeField+1] = 0xFF, 0xFF
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	fh, err := f.ForensicHeader(addr)
+	if err != nil {
+		t.Fatalf("ForensicHeader failed: %v", err)
+	}
+	if fh.FramingError == nil {
+		t.Fatal("expected framing error")
+	}
+	if fh.FramingErrorOffset != orig.Messages[2].Offset {
+		t.Errorf("framing error offset: got 0x%x, want 0x%x", fh.FramingErrorOffset, orig.Messages[2].Offset)
+	}
+	if len(fh.Messages) != 2 {
+		t.Errorf("expected the 2 intact messages, got %d", len(fh.Messages))
+	}
+}
+
+func TestForensicHeaderInvalidAddress(t *testing.T) {
+	path := skipIfNoTestdata(t, "minimal.h5")
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := f.ForensicHeader(0); err == nil {
+		t.Error("expected error for superblock address")
+	}
+}
+
+// datasetAddress resolves a root-level dataset name to its header address.
+func datasetAddress(t *testing.T, f *File, name string) uint64 {
+	t.Helper()
+	res, err := f.root.findChildFull(name, f.newLinkChain())
+	if err != nil {
+		t.Fatalf("resolving %q: %v", name, err)
+	}
+	return res.address
+}
@@ -0,0 +1,206 @@
+package hdf5
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// MatchOption configures FindFirst and GlobPaths.
+type MatchOption func(*matchOptions)
+
+type matchOptions struct {
+	caseInsensitive bool
+}
+
+// CaseInsensitive makes patterns match names regardless of case, so
+// "temp*" matches "Temperature" and "TEMP_1".
+func CaseInsensitive() MatchOption {
+	return func(o *matchOptions) {
+		o.caseInsensitive = true
+	}
+}
+
+// nameMatcher matches names against one path.Match pattern.
+type nameMatcher struct {
+	pattern string
+	fold    bool
+}
+
+// newNameMatcher checks pattern and returns a matcher for it.
+func newNameMatcher(pattern string, opts []MatchOption) (*nameMatcher, error) {
+	o := &matchOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	m := &nameMatcher{pattern: pattern, fold: o.caseInsensitive}
+	if m.fold {
+		m.pattern = strings.ToLower(pattern)
+	}
+	if _, err := path.Match(m.pattern, ""); err != nil {
+		return nil, fmt.Errorf("pattern %q: %w", pattern, err)
+	}
+	return m, nil
+}
+
+func (m *nameMatcher) match(name string) bool {
+	if m.fold {
+		name = strings.ToLower(name)
+	}
+	ok, _ := path.Match(m.pattern, name)
+	return ok
+}
+
+// Glob returns the names of the members of the group matching pattern, in
+// storage order. Patterns have the syntax of path.Match and are matched
+// against whole member names: the group's subgroups are not searched, and
+// a '/' in the pattern matches nothing. Only the group's links are read,
+// not the objects they point to.
+func (g *Group) Glob(pattern string) ([]string, error) {
+	m, err := newNameMatcher(pattern, nil)
+	if err != nil {
+		return nil, err
+	}
+	return g.glob(m)
+}
+
+func (g *Group) glob(m *nameMatcher) ([]string, error) {
+	members, err := g.Members()
+	if err != nil {
+		return nil, err
+	}
+	matches := []string{}
+	for _, name := range members {
+		if m.match(name) {
+			matches = append(matches, name)
+		}
+	}
+	return matches, nil
+}
+
+// FindFirst returns the name of the first member of the group, in storage
+// order, that matches pattern as for Glob. With CaseInsensitive, "temp*"
+// finds a member named "Temperature". An error wrapping ErrNotFound is
+// returned if no member matches.
+func (g *Group) FindFirst(pattern string, opts ...MatchOption) (string, error) {
+	m, err := newNameMatcher(pattern, opts)
+	if err != nil {
+		return "", err
+	}
+	matches, err := g.glob(m)
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("%w: no member of %s matches %q", ErrNotFound, g.path, pattern)
+	}
+	return matches[0], nil
+}
+
+// GlobPaths returns the absolute paths of the objects and links in the
+// file matching pattern, in the order File.Walk visits them. The pattern
+// is split at '/' and each component matched against a path component as
+// by Glob, except that a "**" component matches any number of components,
+// none included: "**/temp*" matches "/temp1" and "/a/b/temp2". A leading
+// '/' is optional, as paths are always taken from the root group.
+//
+// As with File.Walk without options, only hard links are followed, and a
+// group reached by several of them is searched once; soft and external
+// links are reported if their own paths match. Objects are not opened, and
+// the object headers of members are read only to find the groups to search
+// when the pattern could match below them.
+func (f *File) GlobPaths(pattern string, opts ...MatchOption) ([]string, error) {
+	if f.closed {
+		return nil, ErrClosed
+	}
+	parts := splitPath(pattern)
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("%w: empty glob pattern", ErrInvalidPath)
+	}
+	g := &globber{visited: map[uint64]bool{f.root.addr: true}, matches: []string{}}
+	for _, part := range parts {
+		if part == "**" {
+			g.pattern = append(g.pattern, nil)
+			continue
+		}
+		m, err := newNameMatcher(part, opts)
+		if err != nil {
+			return nil, err
+		}
+		g.pattern = append(g.pattern, m)
+	}
+	if err := g.walk(f.root, nil); err != nil {
+		return nil, err
+	}
+	return g.matches, nil
+}
+
+// globber holds the state of a GlobPaths search. A nil pattern component
+// stands for "**".
+type globber struct {
+	pattern []*nameMatcher
+	visited map[uint64]bool
+	matches []string
+}
+
+// walk searches the members of grp, whose path components are dir.
+func (gl *globber) walk(grp *Group, dir []string) error {
+	members, err := grp.memberLinks()
+	if err != nil {
+		return fmt.Errorf("globbing %s: %w", grp.path, err)
+	}
+	for i := range members {
+		member := &members[i]
+		parts := append(dir[:len(dir):len(dir)], member.name())
+		childPath := joinPath(grp.path, member.name())
+		if matchGlob(gl.pattern, parts) {
+			gl.matches = append(gl.matches, childPath)
+		}
+		if !member.isHard() || !matchGlobPrefix(gl.pattern, parts) {
+			continue
+		}
+
+		res, err := grp.resolveMember(member, grp.file.newLinkChain())
+		if err != nil {
+			return fmt.Errorf("globbing %s: %w", childPath, err)
+		}
+		if res.kind != ObjectTypeGroup || gl.visited[res.address] {
+			continue
+		}
+		gl.visited[res.address] = true
+		child, err := grp.file.openGroupAt(res.address, childPath)
+		if err != nil {
+			return fmt.Errorf("globbing %s: %w", childPath, err)
+		}
+		if err := gl.walk(child, parts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchGlob reports whether the path components parts match pattern.
+func matchGlob(pattern []*nameMatcher, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == nil {
+		return matchGlob(pattern[1:], parts) || (len(parts) > 0 && matchGlob(pattern, parts[1:]))
+	}
+	return len(parts) > 0 && pattern[0].match(parts[0]) && matchGlob(pattern[1:], parts[1:])
+}
+
+// matchGlobPrefix reports whether some path below the components parts
+// could match pattern.
+func matchGlobPrefix(pattern []*nameMatcher, parts []string) bool {
+	if len(parts) == 0 {
+		return len(pattern) > 0
+	}
+	if len(pattern) == 0 {
+		return false
+	}
+	if pattern[0] == nil {
+		return true
+	}
+	return pattern[0].match(parts[0]) && matchGlobPrefix(pattern[1:], parts[1:])
+}
@@ -0,0 +1,176 @@
+package hdf5
+
+import (
+	"errors"
+	"path"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGlob(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "groups.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	cached := f.headers.len()
+	for _, tt := range []struct {
+		pattern string
+		want    []string
+	}{
+		{"group*", []string{"group1", "group2"}},
+		{"group[2-9]", []string{"group2"}},
+		{"*", []string{"group1", "group2"}},
+		{"Group*", []string{}},
+		{"group1/*", []string{}},
+	} {
+		got, err := f.Root().Glob(tt.pattern)
+		if err != nil {
+			t.Errorf("Glob(%q) failed: %v", tt.pattern, err)
+		} else if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Glob(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+	if n := f.headers.len(); n != cached {
+		t.Errorf("Glob read %d object headers", n-cached)
+	}
+
+	if _, err := f.Root().Glob("group["); !errors.Is(err, path.ErrBadPattern) {
+		t.Errorf("Glob with a bad pattern error = %v, want ErrBadPattern", err)
+	}
+
+	g, err := f.OpenGroup("group1")
+	if err != nil {
+		t.Fatalf("OpenGroup failed: %v", err)
+	}
+	if name, err := g.FindFirst("SUB*", CaseInsensitive()); err != nil || name != "subgroup" {
+		t.Errorf("FindFirst SUB* = %q, %v, want subgroup", name, err)
+	}
+	if _, err := g.FindFirst("SUB*"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("case-sensitive FindFirst SUB* error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGlobPaths(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "groups.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	for _, tt := range []struct {
+		pattern string
+		want    []string
+	}{
+		{"**/n*", []string{"/group1/subgroup/nested"}},
+		{"/group1/*", []string{"/group1/data", "/group1/subgroup"}},
+		{"*/data", []string{"/group1/data"}},
+		{"**", []string{"/group1", "/group1/data", "/group1/subgroup", "/group1/subgroup/nested", "/group2"}},
+		{"group1/**/*", []string{"/group1/data", "/group1/subgroup", "/group1/subgroup/nested"}},
+		{"**/missing", []string{}},
+	} {
+		got, err := f.GlobPaths(tt.pattern)
+		if err != nil {
+			t.Errorf("GlobPaths(%q) failed: %v", tt.pattern, err)
+		} else if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GlobPaths(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+
+	// Only the headers of group1's members are read to search it
+	f2, err := Open(skipIfNoTestdata(t, "groups.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f2.Close()
+	cached := f2.headers.len()
+	if got, err := f2.GlobPaths("group2/*"); err != nil || len(got) != 0 {
+		t.Errorf("GlobPaths group2/* = %q, %v", got, err)
+	}
+	if n := f2.headers.len() - cached; n != 1 {
+		t.Errorf("GlobPaths group2/* read %d object headers, want 1", n)
+	}
+}
+
+func TestGlobPathsV0Deep(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "v0_deep_nested.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	got, err := f.GlobPaths("**/data[3-5]")
+	if err != nil {
+		t.Fatalf("GlobPaths failed: %v", err)
+	}
+	want := []string{
+		"/level1/level2/level3/data3",
+		"/level1/level2/level3/level4/data4",
+		"/level1/level2/level3/level4/level5/data5",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GlobPaths **/data[3-5] = %q, want %q", got, want)
+	}
+	if got, err := f.GlobPaths("**/SIBLING*", CaseInsensitive()); err != nil || len(got) != 3 {
+		t.Errorf("GlobPaths **/SIBLING* = %q, %v, want 3 paths", got, err)
+	}
+}
+
+// TestGlobPathsDeep searches a tree of nested groups with mixed-case
+// names.
+func TestGlobPathsDeep(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "deep.h5")
+	f, err := Create(p)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	g := f.Root()
+	for _, name := range []string{"Site_A", "Run1", "Raw", "Sensors"} {
+		if _, err := g.CreateDataset("Temp_"+name, []float64{1}); err != nil {
+			t.Fatalf("CreateDataset failed: %v", err)
+		}
+		if g, err = g.CreateGroup(name); err != nil {
+			t.Fatalf("CreateGroup failed: %v", err)
+		}
+	}
+	if _, err := g.CreateDataset("temperature", []float64{1}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if err := f.Root().CreateSoftLink("TEMP_link", "/Site_A/Run1/Raw/Sensors/temperature"); err != nil {
+		t.Fatalf("CreateSoftLink failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(p)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	got, err := f.GlobPaths("**/temp*")
+	if err != nil {
+		t.Fatalf("GlobPaths failed: %v", err)
+	}
+	if want := []string{"/Site_A/Run1/Raw/Sensors/temperature"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GlobPaths **/temp* = %q, want %q", got, want)
+	}
+	got, err = f.GlobPaths("**/temp*", CaseInsensitive())
+	if err != nil {
+		t.Fatalf("GlobPaths failed: %v", err)
+	}
+	want := []string{"/Temp_Site_A", "/Site_A/Temp_Run1", "/Site_A/Run1/Temp_Raw",
+		"/Site_A/Run1/Raw/Temp_Sensors", "/Site_A/Run1/Raw/Sensors/temperature", "/TEMP_link"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GlobPaths **/temp* case-insensitive = %q, want %q", got, want)
+	}
+	if got, err := f.GlobPaths("site_a/*/raw/**/t*", CaseInsensitive()); err != nil || len(got) != 2 {
+		t.Errorf("GlobPaths site_a/*/raw/**/t* = %q, %v, want 2 paths", got, err)
+	}
+	for _, pattern := range []string{"", "/"} {
+		if _, err := f.GlobPaths(pattern); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("GlobPaths(%q) error = %v, want ErrInvalidPath", pattern, err)
+		}
+	}
+}
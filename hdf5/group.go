@@ -3,6 +3,8 @@ package hdf5
 import (
 	"fmt"
 	"path"
+	"strings"
+	"sync"
 
 	"github.com/robert-malhotra/go-hdf5/internal/btree"
 	"github.com/robert-malhotra/go-hdf5/internal/heap"
@@ -17,8 +19,12 @@ type Group struct {
 	header *object.Header
 	addr   uint64 // Object header address (for write support)
 
+	denseMu    sync.Mutex      // Guards denseLinks for concurrent readers
+	denseLinks []*message.Link // Links in dense storage, read on first use
+
 	// Write support fields
-	pendingLinks []*message.Link // Links to be written
+	pendingLinks []*message.Link      // Links to be written
+	pendingAttrs []*message.Attribute // Attributes to be written
 }
 
 // ObjectType indicates the type of an HDF5 object.
@@ -28,6 +34,13 @@ const (
 	ObjectTypeGroup   ObjectType = "group"
 	ObjectTypeDataset ObjectType = "dataset"
 	ObjectTypeUnknown ObjectType = "unknown"
+
+	// ObjectTypeNamedDatatype is a datatype committed to the file
+	ObjectTypeNamedDatatype ObjectType = "datatype"
+
+	// Links themselves, reported by Stat with StatNoFollow
+	ObjectTypeSoftLink     ObjectType = "soft link"
+	ObjectTypeExternalLink ObjectType = "external link"
 )
 
 // MemberInfo contains information about a group member.
@@ -35,13 +48,17 @@ type MemberInfo struct {
 	Name     string     // Name of the member
 	Type     ObjectType // Type of the object ("group", "dataset", or "unknown")
 	LinkType string     // Type of the link ("hard", "soft", or "external")
+
+	// Dangling marks a soft or external link whose target does not exist;
+	// its Type is ObjectTypeUnknown
+	Dangling bool
 }
 
 // linkResolution holds the result of resolving a link.
 type linkResolution struct {
-	address   uint64 // Object address
-	isDataset bool   // True if target is a dataset
-	file      *File  // Target file (nil = same file, non-nil = external file)
+	address uint64     // Object address
+	kind    ObjectType // Dataset, group or named datatype
+	file    *File      // Target file (nil = same file, non-nil = external file)
 }
 
 // Name returns the group name (last component of path).
@@ -57,16 +74,34 @@ func (g *Group) Path() string {
 	return g.path
 }
 
+// Kind returns ObjectTypeGroup.
+func (g *Group) Kind() ObjectType {
+	return ObjectTypeGroup
+}
+
+// Open opens the object at a relative path, whatever its kind, resolving
+// the path once: the result is a *Group, a *Dataset or a *NamedDatatype,
+// which its Kind tells apart. An empty path or "/" opens the group itself.
+// As with OpenGroup, an object header of no recognized kind is opened as a
+// group.
+func (g *Group) Open(relativePath string) (Object, error) {
+	obj, err := g.open(relativePath)
+	if err != nil {
+		return nil, withPath(path.Join(g.path, relativePath), err)
+	}
+	return obj.(Object), nil
+}
+
 // OpenGroup opens a subgroup by relative path.
 func (g *Group) OpenGroup(relativePath string) (*Group, error) {
 	obj, err := g.open(relativePath)
 	if err != nil {
-		return nil, err
+		return nil, withPath(path.Join(g.path, relativePath), err)
 	}
 
 	group, ok := obj.(*Group)
 	if !ok {
-		return nil, ErrNotGroup
+		return nil, withPath(path.Join(g.path, relativePath), ErrNotGroup)
 	}
 	return group, nil
 }
@@ -75,51 +110,92 @@ func (g *Group) OpenGroup(relativePath string) (*Group, error) {
 func (g *Group) OpenDataset(relativePath string) (*Dataset, error) {
 	obj, err := g.open(relativePath)
 	if err != nil {
-		return nil, err
+		return nil, withPath(path.Join(g.path, relativePath), err)
 	}
 
 	dataset, ok := obj.(*Dataset)
 	if !ok {
-		return nil, ErrNotDataset
+		return nil, withPath(path.Join(g.path, relativePath), ErrNotDataset)
 	}
 	return dataset, nil
 }
 
+// Child opens the member of the group named name: a *Group, a *Dataset or
+// a *NamedDatatype. Soft and external links are followed as by OpenGroup,
+// but name is taken as a single link name, as Members returns it, without
+// splitting it at '/'; "." and ".." name members of those names, not this
+// group or its parent. An error wrapping ErrNotFound is returned if there is
+// no such member.
+func (g *Group) Child(name string) (Object, error) {
+	childPath := joinPath(g.path, name)
+	if name == "" {
+		return nil, withPath(childPath, ErrNotFound)
+	}
+	obj, err := g.openParts([]string{name})
+	if err != nil {
+		return nil, withPath(childPath, err)
+	}
+	return obj.(Object), nil
+}
+
 // open opens an object by relative path.
 func (g *Group) open(relativePath string) (interface{}, error) {
 	parts := splitPath(relativePath)
 	if len(parts) == 0 {
 		return g, nil
 	}
+	return g.openParts(parts)
+}
 
-	current := g
-	visited := make(map[string]bool)
+// openParts opens the object the link names in parts lead to from g.
+func (g *Group) openParts(parts []string) (interface{}, error) {
+	chain := g.file.newLinkChain()
+	parent, err := g.openParent(parts, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	name := parts[len(parts)-1]
+	res, err := parent.findChildFull(name, chain)
+	if err != nil {
+		return nil, fmt.Errorf("finding %q: %w", name, err)
+	}
+
+	// Determine which file to use for opening the object
+	targetFile := parent.file
+	if res.file != nil {
+		targetFile = res.file
+	}
+
+	fullPath := joinPath(parent.path, name)
+	switch res.kind {
+	case ObjectTypeDataset:
+		return targetFile.openDatasetAt(res.address, fullPath)
+	case ObjectTypeNamedDatatype:
+		return targetFile.openDatatypeAt(res.address, fullPath)
+	}
+	return targetFile.openGroupAt(res.address, fullPath)
+}
 
-	for i, name := range parts {
-		res, err := current.findChildFull(name, visited)
+// openParent opens the group holding the last component of parts, following
+// links through each intermediate component.
+func (g *Group) openParent(parts []string, chain *linkChain) (*Group, error) {
+	current := g
+	for _, name := range parts[:len(parts)-1] {
+		res, err := current.findChildFull(name, chain)
 		if err != nil {
 			return nil, fmt.Errorf("finding %q: %w", name, err)
 		}
 
-		// Determine which file to use for opening the object
 		targetFile := current.file
 		if res.file != nil {
 			targetFile = res.file
 		}
 
-		fullPath := path.Join(current.path, name)
-
-		// If this is the last component, open as appropriate type
-		if i == len(parts)-1 {
-			if res.isDataset {
-				return targetFile.openDatasetAt(res.address, fullPath)
-			}
-			return targetFile.openGroupAt(res.address, fullPath)
-		}
-
-		// Otherwise, must be a group to continue traversal
-		if res.isDataset {
-			return nil, fmt.Errorf("%q is not a group", fullPath)
+		// Intermediate components must be groups to continue traversal
+		fullPath := joinPath(current.path, name)
+		if res.kind != ObjectTypeGroup {
+			return nil, fmt.Errorf("%w: %q", ErrNotGroup, fullPath)
 		}
 
 		nextGroup, err := targetFile.openGroupAt(res.address, fullPath)
@@ -128,88 +204,207 @@ func (g *Group) open(relativePath string) (interface{}, error) {
 		}
 		current = nextGroup
 	}
-
 	return current, nil
 }
 
 // findChild finds a child object by name and returns its address.
-// Returns (address, isDataset, error).
-func (g *Group) findChild(name string) (uint64, bool, error) {
-	res, err := g.findChildFull(name, make(map[string]bool))
+// Returns (address, kind, error).
+func (g *Group) findChild(name string) (uint64, ObjectType, error) {
+	res, err := g.findChildFull(name, g.file.newLinkChain())
 	if err != nil {
-		return 0, false, err
+		return 0, ObjectTypeUnknown, err
 	}
-	return res.address, res.isDataset, nil
+	return res.address, res.kind, nil
 }
 
 // findChildFull finds a child and returns full resolution info including external file.
-func (g *Group) findChildFull(name string, visited map[string]bool) (*linkResolution, error) {
-	// Try to find via Link messages (v2 groups)
-	for _, msg := range g.header.GetMessages(message.TypeLink) {
-		link := msg.(*message.Link)
-		if link.Name == name {
-			return g.resolveLink(link, visited)
+func (g *Group) findChildFull(name string, chain *linkChain) (*linkResolution, error) {
+	member, err := g.lookupMember(name)
+	if err != nil {
+		return nil, err
+	}
+	return g.resolveMember(member, chain)
+}
+
+// memberLink is a group member's link as stored in the group. Exactly one of
+// link (v2 groups) and entry (v1 symbol tables) is set.
+type memberLink struct {
+	link  *message.Link
+	entry *btree.GroupEntry
+}
+
+// name returns the member's name.
+func (m *memberLink) name() string {
+	if m.link != nil {
+		return m.link.Name
+	}
+	return m.entry.Name
+}
+
+// isHard reports whether the member is a hard link.
+func (m *memberLink) isHard() bool {
+	if m.link != nil {
+		return m.link.IsHard()
+	}
+	return m.entry.LinkType != 1
+}
+
+// linkType returns "hard", "soft" or "external", as MemberInfo reports it.
+func (m *memberLink) linkType() string {
+	switch {
+	case m.link != nil && !m.link.IsHard() && !m.link.IsSoft() && !m.link.IsExternal():
+		return "unknown"
+	case m.isExternal():
+		return "external"
+	case m.isHard():
+		return "hard"
+	default:
+		return "soft"
+	}
+}
+
+// isExternal reports whether the member is an external link.
+func (m *memberLink) isExternal() bool {
+	return m.link != nil && m.link.IsExternal()
+}
+
+// resolveMember resolves a member's link to its target object.
+func (g *Group) resolveMember(member *memberLink, chain *linkChain) (*linkResolution, error) {
+	if member.link != nil {
+		return g.resolveLink(member.link, chain)
+	}
+	return g.resolveEntry(member.entry, chain)
+}
+
+// lookupMember finds the link named name without reading its target.
+// Symbol tables are searched by key first, so opening one member of a
+// large v1 group reads only the nodes leading to it; a miss falls back to
+// listing every member, in case the tree's keys are out of order.
+func (g *Group) lookupMember(name string) (*memberLink, error) {
+	links, err := g.links()
+	if err != nil {
+		return nil, err
+	}
+	if len(links) > 0 {
+		for _, link := range links {
+			if link.Name == name {
+				return &memberLink{link: link}, nil
+			}
+		}
+		return nil, ErrNotFound
+	}
+
+	symTable := g.symbolTable()
+	if symTable == nil {
+		return nil, ErrNotFound
+	}
+	entry, found, err := g.lookupMemberV1(symTable, name)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		return &memberLink{entry: entry}, nil
+	}
+
+	entries, err := g.getMembersV1(symTable)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if entries[i].Name == name {
+			return &memberLink{entry: &entries[i]}, nil
 		}
 	}
+	return nil, ErrNotFound
+}
+
+// memberLinks lists the group's links in storage order: its Link messages
+// and dense links (v2 groups), or failing those its symbol table (v1
+// groups).
+func (g *Group) memberLinks() ([]memberLink, error) {
+	links, err := g.links()
+	if err != nil {
+		return nil, err
+	}
+	if len(links) > 0 {
+		members := make([]memberLink, len(links))
+		for i, link := range links {
+			members[i].link = link
+		}
+		return members, nil
+	}
+
+	symTable := g.symbolTable()
+	if symTable == nil {
+		return nil, nil
+	}
+	entries, err := g.getMembersV1(symTable)
+	if err != nil {
+		return nil, err
+	}
+	members := make([]memberLink, len(entries))
+	for i := range entries {
+		members[i].entry = &entries[i]
+	}
+	return members, nil
+}
 
-	// Try symbol table (v1 groups) - requires B-tree traversal
-	symMsg := g.header.GetMessage(message.TypeSymbolTable)
-	if symMsg != nil {
-		symTable := symMsg.(*message.SymbolTable)
-		return g.findChildV1Full(name, symTable, visited)
+// symbolTable returns the group's v1 symbol table, or nil for v2 groups.
+func (g *Group) symbolTable() *message.SymbolTable {
+	if symMsg := g.header.GetMessage(message.TypeSymbolTable); symMsg != nil {
+		return symMsg.(*message.SymbolTable)
 	}
 
 	// Fallback for root group: use cached addresses from superblock scratch pad
 	if g.path == "/" && g.file.superblock.RootGroupBTreeAddress != 0 {
-		symTable := &message.SymbolTable{
+		return &message.SymbolTable{
 			BTreeAddress:     g.file.superblock.RootGroupBTreeAddress,
 			LocalHeapAddress: g.file.superblock.RootGroupLocalHeapAddress,
 		}
-		return g.findChildV1Full(name, symTable, visited)
 	}
-
-	return nil, ErrNotFound
+	return nil
 }
 
 // resolveLink resolves a link to get the target object's address.
-func (g *Group) resolveLink(link *message.Link, visited map[string]bool) (*linkResolution, error) {
+func (g *Group) resolveLink(link *message.Link, chain *linkChain) (*linkResolution, error) {
 	switch {
 	case link.IsHard():
-		isDataset, err := g.isDataset(link.ObjectAddress)
+		kind, err := g.objectKind(link.ObjectAddress)
 		if err != nil {
 			return nil, err
 		}
 		return &linkResolution{
-			address:   link.ObjectAddress,
-			isDataset: isDataset,
-			file:      nil, // Same file
+			address: link.ObjectAddress,
+			kind:    kind,
+			file:    nil, // Same file
 		}, nil
 
 	case link.IsSoft():
+		// Relative targets are resolved from the group holding the link
 		targetPath := link.SoftLinkValue
-		if len(visited) >= MaxLinkDepth {
-			return nil, ErrLinkDepth
+		if !strings.HasPrefix(targetPath, "/") {
+			targetPath = joinPath(g.path, targetPath)
 		}
-		if visited[targetPath] {
-			return nil, fmt.Errorf("circular soft link detected: %s", targetPath)
+		if err := chain.follow(targetPath); err != nil {
+			return nil, err
 		}
-		visited[targetPath] = true
-		res, err := g.file.findByAbsolutePathFull(targetPath, visited)
+		defer chain.done()
+		res, err := g.file.findByAbsolutePathFull(targetPath, chain)
 		if err != nil {
 			return nil, err
 		}
 		return res, nil
 
 	case link.IsExternal():
-		addr, isDs, extFile, err := g.file.resolveExternalLink(
-			link.ExternalFile, link.ExternalPath, visited)
+		addr, kind, extFile, err := g.file.resolveExternalLink(
+			link.ExternalFile, link.ExternalPath, chain)
 		if err != nil {
 			return nil, err
 		}
 		return &linkResolution{
-			address:   addr,
-			isDataset: isDs,
-			file:      extFile,
+			address: addr,
+			kind:    kind,
+			file:    extFile,
 		}, nil
 
 	default:
@@ -218,87 +413,90 @@ func (g *Group) resolveLink(link *message.Link, visited map[string]bool) (*linkR
 }
 
 // findChildV1 finds a child in a v1 group using the symbol table.
-func (g *Group) findChildV1(name string, symTable *message.SymbolTable) (uint64, bool, error) {
-	res, err := g.findChildV1Full(name, symTable, make(map[string]bool))
+func (g *Group) findChildV1(name string, symTable *message.SymbolTable) (uint64, ObjectType, error) {
+	res, err := g.findChildV1Full(name, symTable, g.file.newLinkChain())
 	if err != nil {
-		return 0, false, err
+		return 0, ObjectTypeUnknown, err
 	}
-	return res.address, res.isDataset, nil
+	return res.address, res.kind, nil
 }
 
 // findChildV1Full finds a child in a v1 group with full resolution info.
-func (g *Group) findChildV1Full(name string, symTable *message.SymbolTable, visited map[string]bool) (*linkResolution, error) {
-	// Read the local heap to get string names
-	localHeap, err := heap.ReadLocalHeap(g.file.reader, symTable.LocalHeapAddress)
-	if err != nil {
-		return nil, fmt.Errorf("reading local heap: %w", err)
-	}
-
-	// Read the B-tree to get group entries
-	entries, err := btree.ReadGroupEntries(g.file.reader, symTable.BTreeAddress, localHeap)
+func (g *Group) findChildV1Full(name string, symTable *message.SymbolTable, chain *linkChain) (*linkResolution, error) {
+	entries, err := g.getMembersV1(symTable)
 	if err != nil {
-		return nil, fmt.Errorf("reading B-tree: %w", err)
+		return nil, err
 	}
 
 	// Find the named entry
-	for _, entry := range entries {
-		if entry.Name == name {
-			// Check if this is a soft link
-			if entry.LinkType == 1 {
-				// Soft link - resolve the target path
-				targetPath := entry.SoftLinkValue
-				if len(visited) >= MaxLinkDepth {
-					return nil, ErrLinkDepth
-				}
-				if visited[targetPath] {
-					return nil, fmt.Errorf("circular soft link detected: %s", targetPath)
-				}
-				visited[targetPath] = true
-				addr, isDs, err := g.file.findByAbsolutePath(targetPath, visited)
-				if err != nil {
-					return nil, err
-				}
-				return &linkResolution{
-					address:   addr,
-					isDataset: isDs,
-					file:      nil, // Same file (v1 groups don't support external links)
-				}, nil
-			}
-
-			// Hard link - return object address
-			isDataset, err := g.isDataset(entry.ObjectAddress)
-			if err != nil {
-				return nil, err
-			}
-			return &linkResolution{
-				address:   entry.ObjectAddress,
-				isDataset: isDataset,
-				file:      nil,
-			}, nil
+	for i := range entries {
+		if entries[i].Name == name {
+			return g.resolveEntry(&entries[i], chain)
 		}
 	}
 
 	return nil, ErrNotFound
 }
 
-// isDataset checks if an object at the given address is a dataset.
-func (g *Group) isDataset(address uint64) (bool, error) {
-	header, err := object.Read(g.file.reader, address)
+// resolveEntry resolves a v1 symbol table entry to its target object.
+func (g *Group) resolveEntry(entry *btree.GroupEntry, chain *linkChain) (*linkResolution, error) {
+	// Check if this is a soft link
+	if entry.LinkType == 1 {
+		// Soft link - resolve the target path
+		targetPath := entry.SoftLinkValue
+		if err := chain.follow(targetPath); err != nil {
+			return nil, err
+		}
+		defer chain.done()
+		addr, kind, err := g.file.findByAbsolutePath(targetPath, chain)
+		if err != nil {
+			return nil, err
+		}
+		return &linkResolution{
+			address: addr,
+			kind:    kind,
+			file:    nil, // Same file (v1 groups don't support external links)
+		}, nil
+	}
+
+	// Hard link - return object address
+	kind, err := g.objectKind(entry.ObjectAddress)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
+	return &linkResolution{
+		address: entry.ObjectAddress,
+		kind:    kind,
+		file:    nil,
+	}, nil
+}
 
-	// A dataset has a dataspace message
-	return header.GetMessage(message.TypeDataspace) != nil, nil
+// objectKind tells whether the object at the given address is a dataset, a
+// named datatype or a group. Headers of no known kind are taken for groups.
+func (g *Group) objectKind(address uint64) (ObjectType, error) {
+	header, err := g.file.readHeader(address)
+	if err != nil {
+		return ObjectTypeUnknown, structureError("object header", address, err)
+	}
+	if kind := headerKind(header); kind != ObjectTypeUnknown {
+		return kind, nil
+	}
+	return ObjectTypeGroup, nil
 }
 
 // Members returns the names of all members (groups and datasets) in this group.
+// An empty group yields an empty, non-nil slice. Links are not followed, so
+// soft and external links whose targets are missing are listed too; see
+// MembersInfo and LinkInfo to tell them apart.
 func (g *Group) Members() ([]string, error) {
-	var names []string
+	names := []string{}
 
 	// Collect from Link messages (v2 groups)
-	for _, msg := range g.header.GetMessages(message.TypeLink) {
-		link := msg.(*message.Link)
+	links, err := g.links()
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range links {
 		names = append(names, link.Name)
 	}
 
@@ -333,123 +531,162 @@ func (g *Group) Members() ([]string, error) {
 	return names, nil
 }
 
+// loadDenseLinks returns the links in the dense storage info points to,
+// reading them on first use.
+func (g *Group) loadDenseLinks(info *message.LinkInfo) ([]*message.Link, error) {
+	g.denseMu.Lock()
+	defer g.denseMu.Unlock()
+	if g.denseLinks == nil {
+		dense, err := g.file.readDenseLinks(info)
+		if err != nil {
+			return nil, fmt.Errorf("reading dense links of %q: %w", g.path, err)
+		}
+		g.denseLinks = dense
+	}
+	return g.denseLinks, nil
+}
+
+// objectHeader returns the group's object header. Groups created in a
+// writable file are written without reading their headers back, so the
+// first read through one reads its header; rewriting it with new links
+// then keeps it current.
+func (g *Group) objectHeader() (*object.Header, error) {
+	if g.header == nil {
+		header, err := g.file.readHeader(g.addr)
+		if err != nil {
+			return nil, withPath(g.path, structureError("object header", g.addr, err))
+		}
+		g.header = header
+	}
+	return g.header, nil
+}
+
+// links returns the group's links, those in Link messages followed by any
+// in dense storage, with duplicate names removed: the first link with a name
+// wins, and each later one is recorded as a WarnDuplicateLink warning, or
+// fails with ErrDuplicateLink in strict mode. Every lookup and listing goes
+// through links so that they agree on the group's contents.
+func (g *Group) links() ([]*message.Link, error) {
+	header, err := g.objectHeader()
+	if err != nil {
+		return nil, err
+	}
+	msgs := header.GetMessages(message.TypeLink)
+	all := make([]*message.Link, 0, len(msgs))
+	for _, msg := range msgs {
+		all = append(all, msg.(*message.Link))
+	}
+
+	if info := header.LinkInfo(); info != nil && !g.file.reader.IsUndefinedOffset(info.FractalHeapAddr) {
+		dense, err := g.loadDenseLinks(info)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, dense...)
+	}
+
+	links := make([]*message.Link, 0, len(all))
+	seen := make(map[string]bool, len(all))
+	for _, link := range all {
+		if !seen[link.Name] {
+			seen[link.Name] = true
+			links = append(links, link)
+			continue
+		}
+
+		linkPath := joinPath(g.path, link.Name)
+		if g.file.strict {
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateLink, linkPath)
+		}
+		g.file.warnings.add(Warning{
+			Kind:      WarnDuplicateLink,
+			Path:      linkPath,
+			Addresses: duplicateTargets(all, link.Name),
+		})
+	}
+	return links, nil
+}
+
+// duplicateTargets lists the hard link targets of every link named name.
+func duplicateTargets(links []*message.Link, name string) []uint64 {
+	var addrs []uint64
+	for _, link := range links {
+		if link.Name != name {
+			continue
+		}
+		if link.IsHard() {
+			addrs = append(addrs, link.ObjectAddress)
+		} else {
+			addrs = append(addrs, 0)
+		}
+	}
+	return addrs
+}
+
 // getMembersV1 gets all members from a v1 group using the symbol table.
 func (g *Group) getMembersV1(symTable *message.SymbolTable) ([]btree.GroupEntry, error) {
 	// Read the local heap to get string names
 	localHeap, err := heap.ReadLocalHeap(g.file.reader, symTable.LocalHeapAddress)
 	if err != nil {
-		return nil, fmt.Errorf("reading local heap: %w", err)
+		return nil, structureError("local heap", symTable.LocalHeapAddress, err)
 	}
 
 	// Read the B-tree to get group entries
-	return btree.ReadGroupEntries(g.file.reader, symTable.BTreeAddress, localHeap)
+	sb := g.file.superblock
+	k := btree.GroupK{Leaf: int(sb.GroupLeafNodeK), Internal: int(sb.GroupInternalNodeK)}
+	entries, err := btree.ReadGroupEntries(g.file.reader, symTable.BTreeAddress, localHeap, k)
+	if err != nil {
+		return nil, structureError("group B-tree", symTable.BTreeAddress, err)
+	}
+	return entries, nil
+}
+
+// lookupMemberV1 finds the symbol table entry named name by descending
+// the group's B-tree.
+func (g *Group) lookupMemberV1(symTable *message.SymbolTable, name string) (*btree.GroupEntry, bool, error) {
+	localHeap, err := heap.ReadLocalHeap(g.file.reader, symTable.LocalHeapAddress)
+	if err != nil {
+		return nil, false, structureError("local heap", symTable.LocalHeapAddress, err)
+	}
+	sb := g.file.superblock
+	k := btree.GroupK{Leaf: int(sb.GroupLeafNodeK), Internal: int(sb.GroupInternalNodeK)}
+	entry, found, err := btree.LookupGroupEntry(g.file.reader, symTable.BTreeAddress, localHeap, k, name)
+	if err != nil {
+		return nil, false, structureError("group B-tree", symTable.BTreeAddress, err)
+	}
+	return &entry, found, nil
 }
 
 // MembersInfo returns detailed information about all members in this group.
-// This includes the object type and link type for each member.
+// This includes the object type and link type for each member. Soft and
+// external links are followed to tell the type of their targets; those whose
+// targets do not exist are included, marked Dangling.
 func (g *Group) MembersInfo() ([]MemberInfo, error) {
 	var members []MemberInfo
 
-	// Collect from Link messages (v2 groups)
-	for _, msg := range g.header.GetMessages(message.TypeLink) {
-		link := msg.(*message.Link)
+	links, err := g.memberLinks()
+	if err != nil {
+		return nil, err
+	}
+	for i := range links {
+		member := &links[i]
 		info := MemberInfo{
-			Name:     link.Name,
-			LinkType: g.linkTypeString(link),
+			Name:     member.name(),
+			LinkType: member.linkType(),
+			Type:     ObjectTypeUnknown,
 		}
-
-		// Determine object type by checking if it's a dataset or group
-		objType := ObjectTypeUnknown
-		if link.IsHard() {
-			isDs, err := g.isDataset(link.ObjectAddress)
-			if err == nil {
-				if isDs {
-					objType = ObjectTypeDataset
-				} else {
-					objType = ObjectTypeGroup
-				}
-			}
-		} else if link.IsSoft() || link.IsExternal() {
-			// For soft/external links, try to resolve and check type
-			res, err := g.resolveLink(link, make(map[string]bool))
-			if err == nil {
-				if res.isDataset {
-					objType = ObjectTypeDataset
-				} else {
-					objType = ObjectTypeGroup
-				}
-			}
+		res, err := g.resolveMember(member, g.file.newLinkChain())
+		if err == nil {
+			info.Type = res.kind
+		} else if !member.isHard() {
+			exists, _ := existsResult(err)
+			info.Dangling = !exists
 		}
-		info.Type = objType
 		members = append(members, info)
 	}
-
-	// If using symbol table (v1 groups), traverse the B-tree
-	if len(members) == 0 {
-		symMsg := g.header.GetMessage(message.TypeSymbolTable)
-		var symTable *message.SymbolTable
-		if symMsg != nil {
-			symTable = symMsg.(*message.SymbolTable)
-		} else if g.path == "/" && g.file.superblock.RootGroupBTreeAddress != 0 {
-			// Fallback for root group
-			symTable = &message.SymbolTable{
-				BTreeAddress:     g.file.superblock.RootGroupBTreeAddress,
-				LocalHeapAddress: g.file.superblock.RootGroupLocalHeapAddress,
-			}
-		}
-
-		if symTable != nil {
-			entries, err := g.getMembersV1(symTable)
-			if err != nil {
-				return nil, err
-			}
-			for _, entry := range entries {
-				info := MemberInfo{
-					Name: entry.Name,
-				}
-
-				// Determine link type
-				if entry.LinkType == 1 {
-					info.LinkType = "soft"
-				} else {
-					info.LinkType = "hard"
-				}
-
-				// Determine object type
-				objType := ObjectTypeUnknown
-				if entry.LinkType == 0 && entry.ObjectAddress != 0 {
-					isDs, err := g.isDataset(entry.ObjectAddress)
-					if err == nil {
-						if isDs {
-							objType = ObjectTypeDataset
-						} else {
-							objType = ObjectTypeGroup
-						}
-					}
-				}
-				info.Type = objType
-				members = append(members, info)
-			}
-		}
-	}
-
 	return members, nil
 }
 
-// linkTypeString returns a string representation of the link type.
-func (g *Group) linkTypeString(link *message.Link) string {
-	switch {
-	case link.IsHard():
-		return "hard"
-	case link.IsSoft():
-		return "soft"
-	case link.IsExternal():
-		return "external"
-	default:
-		return "unknown"
-	}
-}
-
 // NumObjects returns the number of objects in this group.
 func (g *Group) NumObjects() (int, error) {
 	members, err := g.Members()
@@ -461,20 +698,37 @@ func (g *Group) NumObjects() (int, error) {
 
 // Attrs returns the attribute names for this group.
 func (g *Group) Attrs() []string {
+	header, err := g.objectHeader()
+	if err != nil {
+		return nil
+	}
 	var names []string
-	for _, msg := range g.header.GetMessages(message.TypeAttribute) {
-		attr := msg.(*message.Attribute)
+	for _, attr := range g.file.attributes(header, g.path) {
 		names = append(names, attr.Name)
 	}
 	return names
 }
 
+// AttrsOrdered returns the names of all attributes on this group in the
+// given order. Unlike Attrs, it fails if attributes in dense storage cannot
+// be read.
+func (g *Group) AttrsOrdered(by Order) ([]string, error) {
+	header, err := g.objectHeader()
+	if err != nil {
+		return nil, err
+	}
+	return g.file.attrsOrdered(header, g.path, by)
+}
+
 // Attr returns an attribute by name, or nil if not found.
 func (g *Group) Attr(name string) *Attribute {
-	for _, msg := range g.header.GetMessages(message.TypeAttribute) {
-		attr := msg.(*message.Attribute)
+	header, err := g.objectHeader()
+	if err != nil {
+		return nil
+	}
+	for _, attr := range g.file.attributes(header, g.path) {
 		if attr.Name == name {
-			return &Attribute{msg: attr, reader: g.file.reader}
+			return &Attribute{msg: attr, reader: g.file.reader, path: JoinAttrPath(g.path, name)}
 		}
 	}
 	return nil
@@ -484,3 +738,52 @@ func (g *Group) Attr(name string) *Attribute {
 func (g *Group) HasAttr(name string) bool {
 	return g.Attr(name) != nil
 }
+
+// GetAttr returns an attribute by a path relative to the group, in the
+// format of File.GetAttr: "@units" names an attribute of the group itself
+// and "data@units" one of its member data.
+func (g *Group) GetAttr(path string) (*Attribute, error) {
+	objectPath, attrName, err := splitAttrPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return g.getAttr(objectPath, attrName)
+}
+
+// ReadAttr reads the value of an attribute by a path relative to the
+// group. It combines GetAttr and Attribute.Value().
+func (g *Group) ReadAttr(path string) (interface{}, error) {
+	attr, err := g.GetAttr(path)
+	if err != nil {
+		return nil, err
+	}
+	return attr.Value()
+}
+
+// getAttr returns the attribute attrName of the group or dataset at a path
+// relative to g.
+func (g *Group) getAttr(relativePath, attrName string) (*Attribute, error) {
+	attrPath := JoinAttrPath(path.Join(g.path, relativePath), attrName)
+	if attrName == "" {
+		return nil, withPath(attrPath, fmt.Errorf("attribute name cannot be empty"))
+	}
+	obj, err := g.attributeHolder(relativePath)
+	if err != nil {
+		return nil, err
+	}
+	attr := obj.Attr(attrName)
+	if attr == nil {
+		return nil, withPath(attrPath, ErrAttributeNotFound)
+	}
+	return attr, nil
+}
+
+// attributeHolder returns the group, dataset or named datatype at a path
+// relative to g.
+func (g *Group) attributeHolder(relativePath string) (attributeHolder, error) {
+	obj, err := g.open(relativePath)
+	if err != nil {
+		return nil, withPath(path.Join(g.path, relativePath), err)
+	}
+	return obj.(attributeHolder), nil
+}
@@ -0,0 +1,64 @@
+package hdf5
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/robert-malhotra/go-hdf5/internal/btree"
+	"github.com/robert-malhotra/go-hdf5/internal/fheap"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+const (
+	// Size of the fractal heap IDs in dense link index records
+	denseLinkHeapIDSize = 7
+
+	// Bytes before the heap ID in name and creation order index records:
+	// the hash of the name, and the creation order
+	linkNameKeySize          = 4
+	linkCreationOrderKeySize = 8
+)
+
+// readDenseLinks reads the links a link info message points to. They are
+// returned in creation order when the group indexes it, and sorted by name
+// otherwise; the name index itself is ordered by hash.
+func (f *File) readDenseLinks(info *message.LinkInfo) ([]*message.Link, error) {
+	heap, err := fheap.ReadFractalHeap(f.reader, info.FractalHeapAddr)
+	if err != nil {
+		return nil, structureError("fractal heap", info.FractalHeapAddr, err)
+	}
+
+	indexAddr, wantType, keySize := info.NameIndexBTreeAddr, btree.BTreeV2TypeLinkName, linkNameKeySize
+	byCreation := info.IndexesCreationOrder() && !f.reader.IsUndefinedOffset(info.CreationOrderBTreeAddr)
+	if byCreation {
+		indexAddr, wantType, keySize = info.CreationOrderBTreeAddr, btree.BTreeV2TypeLinkCreationOrder, linkCreationOrderKeySize
+	}
+	typ, records, err := btree.ReadRecordsV2(f.reader, indexAddr)
+	if err != nil {
+		return nil, structureError("link index", indexAddr, err)
+	}
+	if typ != wantType {
+		return nil, fmt.Errorf("link index has B-tree type %d, expected %d", typ, wantType)
+	}
+
+	links := make([]*message.Link, 0, len(records))
+	for _, rec := range records {
+		if len(rec) < keySize+denseLinkHeapIDSize {
+			return nil, fmt.Errorf("link index record too short: %d bytes", len(rec))
+		}
+		data, err := heap.Object(rec[keySize : keySize+denseLinkHeapIDSize])
+		if err != nil {
+			return nil, err
+		}
+		msg, err := message.Parse(message.TypeLink, data, 0, f.reader)
+		if err != nil {
+			return nil, fmt.Errorf("parsing dense link: %w", err)
+		}
+		links = append(links, msg.(*message.Link))
+	}
+
+	if !byCreation {
+		sort.SliceStable(links, func(i, j int) bool { return links[i].Name < links[j].Name })
+	}
+	return links, nil
+}
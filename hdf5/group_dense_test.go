@@ -0,0 +1,135 @@
+package hdf5
+
+import (
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	binpkg "github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// writeDenseLinks stores links in dense storage and returns the link info
+// message pointing to it. With byCreation, a creation order index is
+// written too, ordered as links are.
+func writeDenseLinks(t *testing.T, f *File, links []*message.Link, byCreation bool) *rawMessage {
+	t.Helper()
+	heap := newTestHeap(t, f, 7)
+	ids := make([]*[]byte, len(links))
+	for i, link := range links {
+		ids[i] = heap.add(link)
+	}
+	heap.write()
+
+	// The name index is ordered by the hash of the name
+	var byName, byOrder [][]byte
+	for i, link := range links {
+		byName = append(byName, append(binary.LittleEndian.AppendUint32(nil,
+			binpkg.ChecksumLookup3([]byte(link.Name), 0)), *ids[i]...))
+		byOrder = append(byOrder, append(binary.LittleEndian.AppendUint64(nil, uint64(i)), *ids[i]...))
+	}
+	sort.Slice(byName, func(i, j int) bool {
+		return binary.LittleEndian.Uint32(byName[i]) < binary.LittleEndian.Uint32(byName[j])
+	})
+
+	body := []byte{0, 0}
+	if byCreation {
+		body = binary.LittleEndian.AppendUint64([]byte{0, 0x03}, uint64(len(links)))
+	}
+	body = binary.LittleEndian.AppendUint64(body, heap.addr)
+	body = binary.LittleEndian.AppendUint64(body, writeTestBTreeV2(t, f, 5, 11, byName))
+	if byCreation {
+		body = binary.LittleEndian.AppendUint64(body, writeTestBTreeV2(t, f, 6, 15, byOrder))
+	}
+	return &rawMessage{message.TypeLinkInfo, body}
+}
+
+func TestDenseLinks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dense_links.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// 1,000 single-element datasets, each holding its index
+	f64 := message.NewFloatDatatype(8, message.OrderLE)
+	var links []*message.Link
+	for i := 0; i < 1000; i++ {
+		data := f.allocate(8)
+		if err := f.writer.At(int64(data)).WriteBytes(float64Bytes(float64(i))); err != nil {
+			t.Fatalf("writing data: %v", err)
+		}
+		msgs := object.NewDatasetHeader(message.NewDataspace([]uint64{1}, nil), f64, message.NewContiguousLayout(data, 8))
+		addr := f.allocate(int64(object.HeaderSize(f.writer, msgs)))
+		if _, err := object.WriteHeader(f.writer.At(int64(addr)), msgs); err != nil {
+			t.Fatalf("writing dataset header: %v", err)
+		}
+		links = append(links, message.NewHardLink(fmt.Sprintf("ds_%04d", i), addr))
+	}
+	writeObject(t, f, "many", []message.Message{writeDenseLinks(t, f, links, false), message.NewGroupInfo()})
+
+	// Creation order is used when it is indexed; a Link message with the
+	// name of a dense link comes first and shadows it
+	ordered := []*message.Link{links[2], links[0], links[1]}
+	writeObject(t, f, "ordered", []message.Message{writeDenseLinks(t, f, ordered, true), message.NewGroupInfo(),
+		message.NewHardLink("ds_0000", links[5].ObjectAddress)})
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	grp, err := f.OpenGroup("many")
+	if err != nil {
+		t.Fatalf("OpenGroup failed: %v", err)
+	}
+	names, err := grp.Members()
+	if err != nil {
+		t.Fatalf("Members failed: %v", err)
+	}
+	if len(names) != 1000 || names[0] != "ds_0000" || names[999] != "ds_0999" || !sort.StringsAreSorted(names) {
+		t.Fatalf("Members() = %d names, want ds_0000..ds_0999 in order", len(names))
+	}
+	for i, name := range names {
+		ds, err := grp.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset(%s) failed: %v", name, err)
+		}
+		if vals, err := ds.ReadFloat64(); err != nil || len(vals) != 1 || vals[0] != float64(i) {
+			t.Fatalf("%s = %v, %v", name, vals, err)
+		}
+	}
+	if _, err := f.OpenDataset("/many/ds_0500"); err != nil {
+		t.Errorf("OpenDataset by path failed: %v", err)
+	}
+	if len(f.Warnings()) != 0 {
+		t.Errorf("unexpected warnings: %v", f.Warnings())
+	}
+
+	grp, err = f.OpenGroup("ordered")
+	if err != nil {
+		t.Fatalf("OpenGroup failed: %v", err)
+	}
+	names, err = grp.Members()
+	if want := []string{"ds_0000", "ds_0002", "ds_0001"}; err != nil || !reflect.DeepEqual(names, want) {
+		t.Errorf("Members() = %v, %v, want %v", names, err, want)
+	}
+	ds, err := grp.OpenDataset("ds_0000")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if vals, err := ds.ReadFloat64(); err != nil || vals[0] != 5 {
+		t.Errorf("ds_0000 = %v, %v, want the Link message's target", vals, err)
+	}
+	if w := f.Warnings(); len(w) == 0 || w[0].Kind != WarnDuplicateLink || w[0].Path != "/ordered/ds_0000" {
+		t.Errorf("Warnings() = %v, want DuplicateLink for /ordered/ds_0000", w)
+	}
+}
@@ -1,9 +1,14 @@
 package hdf5
 
 import (
+	"encoding/binary"
 	"fmt"
 	"path"
+	"slices"
+	"strings"
 
+	"github.com/robert-malhotra/go-hdf5/internal/btree"
+	"github.com/robert-malhotra/go-hdf5/internal/heap"
 	"github.com/robert-malhotra/go-hdf5/internal/message"
 	"github.com/robert-malhotra/go-hdf5/internal/object"
 )
@@ -13,7 +18,12 @@ type pendingLink struct {
 	link *message.Link
 }
 
-// CreateGroup creates a new subgroup with the given name.
+// CreateGroup creates a new subgroup and returns it, ready for datasets and
+// further subgroups. name may be a relative path such as "a/b/c", in which
+// case missing intermediate groups are created as well and existing ones
+// are reused. An error wrapping ErrDuplicateLink is returned if the group
+// already exists, and one wrapping ErrNotGroup if an intermediate name is a
+// dataset.
 func (g *Group) CreateGroup(name string) (*Group, error) {
 	if !g.file.writable {
 		return nil, fmt.Errorf("file is not writable")
@@ -22,6 +32,29 @@ func (g *Group) CreateGroup(name string) (*Group, error) {
 	if name == "" {
 		return nil, fmt.Errorf("group name cannot be empty")
 	}
+	parts := strings.Split(name, "/")
+	for _, part := range parts {
+		if part == "" || part == "." || part == ".." {
+			return nil, fmt.Errorf("%w: group name %q", ErrInvalidPath, name)
+		}
+	}
+
+	parent := g
+	for _, part := range parts[:len(parts)-1] {
+		child, err := parent.subgroupForWrite(part)
+		if err != nil {
+			return nil, err
+		}
+		parent = child
+	}
+	return parent.createGroup(parts[len(parts)-1])
+}
+
+// createGroup creates a subgroup named name directly in g.
+func (g *Group) createGroup(name string) (*Group, error) {
+	if err := g.checkNewLink(name); err != nil {
+		return nil, err
+	}
 
 	// Calculate the path for the new group
 	newPath := path.Join(g.path, name)
@@ -29,18 +62,18 @@ func (g *Group) CreateGroup(name string) (*Group, error) {
 		newPath = "/" + name
 	}
 
-	// Create an empty group object header
-	groupMessages := object.NewEmptyGroupHeader()
-
-	// Calculate header size and allocate space
-	headerSize := object.HeaderSize(g.file.writer, groupMessages)
-	groupAddr := g.file.allocate(int64(headerSize))
-
-	// Write the group object header
-	w := g.file.writer.At(int64(groupAddr))
-	if _, err := object.WriteHeader(w, groupMessages); err != nil {
+	// Write an empty group object header
+	newGroup := &Group{
+		file:         g.file,
+		path:         newPath,
+		header:       nil,
+		pendingLinks: []*message.Link{},
+	}
+	groupAddr, _, err := newGroup.writeHeader(0)
+	if err != nil {
 		return nil, fmt.Errorf("writing group header: %w", err)
 	}
+	newGroup.addr = groupAddr
 
 	// Create a hard link from parent to this group
 	link := message.NewHardLink(name, groupAddr)
@@ -50,41 +83,222 @@ func (g *Group) CreateGroup(name string) (*Group, error) {
 		return nil, fmt.Errorf("adding link to parent: %w", err)
 	}
 
-	// Create the Group object
-	newGroup := &Group{
-		file:         g.file,
-		path:         newPath,
-		header:       nil, // Will be loaded on demand if needed
-		addr:         groupAddr,
-		pendingLinks: nil,
-	}
+	// The group starts with no links of its own, so nothing needs to be
+	// read back from the header
+	g.file.trackWriteGroup(newGroup)
 
 	return newGroup, nil
 }
 
-// addLink adds a link message to this group.
-// For writable files, this updates the group's object header.
-func (g *Group) addLink(link *message.Link) error {
+// CreateSoftLink creates a soft link named name in g pointing at
+// targetPath, which is resolved when the link is followed: it may be
+// absolute or relative to g, and need not exist yet. An error wrapping
+// ErrDuplicateLink is returned if g already has a member named name.
+func (g *Group) CreateSoftLink(name, targetPath string) error {
+	if err := g.checkLinkName(name); err != nil {
+		return err
+	}
+	if targetPath == "" {
+		return fmt.Errorf("%w: soft link target cannot be empty", ErrInvalidPath)
+	}
+	return g.addLink(message.NewSoftLink(name, targetPath))
+}
+
+// CreateExternalLink creates an external link named name in g pointing at
+// the object at objectPath in another HDF5 file. file is stored as given
+// and, when relative, resolved against the linking file's directory and the
+// prefixes set with WithExternalLinkPrefix. An error wrapping
+// ErrDuplicateLink is returned if g already has a member named name.
+func (g *Group) CreateExternalLink(name, file, objectPath string) error {
+	if err := g.checkLinkName(name); err != nil {
+		return err
+	}
+	if file == "" || objectPath == "" {
+		return fmt.Errorf("%w: external link needs a file and an object path, got %q and %q",
+			ErrInvalidPath, file, objectPath)
+	}
+	return g.addLink(message.NewExternalLink(name, file, objectPath))
+}
+
+// Unlink removes the link named name from g. Whatever it pointed to is no
+// longer reachable through g; the space of an object nothing else links to
+// is not reclaimed. Groups holding their links in a symbol table, such as
+// the root of a file with a version 0 superblock, have the entry removed
+// from the symbol table in place. An error wrapping ErrNotFound is
+// returned if g has no member named name.
+func (g *Group) Unlink(name string) error {
+	if err := g.checkLinkName(name); err != nil {
+		return err
+	}
+	childPath := path.Join(g.path, name)
+
+	// A symbol table whose links were not loaded for rewriting is edited
+	// in place
+	if g.header != nil && g.pendingLinks == nil {
+		if symTable := g.symbolTable(); symTable != nil {
+			if err := g.removeSymbolTableEntry(symTable, name); err != nil {
+				return err
+			}
+			g.file.forgetWriteGroups(childPath)
+			return nil
+		}
+	}
+
+	link, err := g.pendingLink(name)
+	if err != nil {
+		return err
+	}
+	if link == nil {
+		return fmt.Errorf("%w: %q in %s", ErrNotFound, name, g.path)
+	}
+	links := g.pendingLinks[:0]
+	for _, l := range g.pendingLinks {
+		if l != link {
+			links = append(links, l)
+		}
+	}
+	g.pendingLinks = links
+	g.file.forgetWriteGroups(childPath)
+	return g.rewriteHeader()
+}
+
+// removeSymbolTableEntry removes the entry named name from a v1 group's
+// symbol table.
+func (g *Group) removeSymbolTableEntry(symTable *message.SymbolTable, name string) error {
+	r := g.file.headerReader()
+	localHeap, err := heap.ReadLocalHeap(r, symTable.LocalHeapAddress)
+	if err != nil {
+		return fmt.Errorf("reading local heap: %w", err)
+	}
+	found, err := btree.RemoveGroupEntry(r, g.file.writer, symTable.BTreeAddress, localHeap, name)
+	if err != nil {
+		return fmt.Errorf("removing symbol table entry: %w", err)
+	}
+	if !found {
+		return fmt.Errorf("%w: %q in %s", ErrNotFound, name, g.path)
+	}
+	return nil
+}
+
+// forgetWriteGroups stops tracking the group at p and the groups below it,
+// once they are unlinked.
+func (f *File) forgetWriteGroups(p string) {
+	for groupPath := range f.writeGroups {
+		if groupPath == p || strings.HasPrefix(groupPath, p+"/") {
+			delete(f.writeGroups, groupPath)
+		}
+	}
+}
+
+// checkLinkName checks that name can name a new link directly in g.
+func (g *Group) checkLinkName(name string) error {
 	if !g.file.writable {
 		return fmt.Errorf("file is not writable")
 	}
+	if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+		return fmt.Errorf("%w: link name %q", ErrInvalidPath, name)
+	}
+	return nil
+}
+
+// subgroupForWrite returns the subgroup named name of g for adding links
+// to, creating it if it does not exist.
+func (g *Group) subgroupForWrite(name string) (*Group, error) {
+	childPath := path.Join(g.path, name)
+	if grp := g.file.writeGroups[childPath]; grp != nil {
+		return grp, nil
+	}
+
+	link, err := g.pendingLink(name)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil {
+		return g.createGroup(name)
+	}
 
-	// If pendingLinks is nil, we need to load existing links from the header
+	// Objects created since the file was opened are all tracked, so an
+	// untracked one must be read from the file; without a reader it is
+	// a dataset written this session
+	if g.file.reader == nil || link.LinkType != message.LinkTypeHard {
+		return nil, fmt.Errorf("%w: %q", ErrNotGroup, childPath)
+	}
+	obj, err := g.open(name)
+	if err != nil {
+		return nil, err
+	}
+	grp, ok := obj.(*Group)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrNotGroup, childPath)
+	}
+	g.file.trackWriteGroup(grp)
+	return grp, nil
+}
+
+// checkNewLink checks that g has no link named name yet.
+func (g *Group) checkNewLink(name string) error {
+	link, err := g.pendingLink(name)
+	if err != nil {
+		return err
+	}
+	if link != nil {
+		return fmt.Errorf("%w: %q already exists in %s", ErrDuplicateLink, name, g.path)
+	}
+	return nil
+}
+
+// pendingLink returns the link named name among the links being written
+// to g, or nil if there is none.
+func (g *Group) pendingLink(name string) (*message.Link, error) {
 	if g.pendingLinks == nil {
 		if err := g.loadExistingLinks(); err != nil {
-			return fmt.Errorf("loading existing links: %w", err)
+			return nil, fmt.Errorf("loading existing links: %w", err)
+		}
+	}
+	for _, link := range g.pendingLinks {
+		if link.Name == name {
+			return link, nil
 		}
 	}
+	return nil, nil
+}
+
+// trackWriteGroup records a group being written, so that rewriting one of
+// its children's headers updates this handle's links.
+func (f *File) trackWriteGroup(g *Group) {
+	if f.writeGroups == nil {
+		f.writeGroups = make(map[string]*Group)
+	}
+	f.writeGroups[g.path] = g
+}
+
+// addLink adds a link message to this group.
+// For writable files, this updates the group's object header.
+func (g *Group) addLink(link *message.Link) error {
+	if !g.file.writable {
+		return fmt.Errorf("file is not writable")
+	}
+
+	if err := g.checkNewLink(link.Name); err != nil {
+		return err
+	}
 
 	g.pendingLinks = append(g.pendingLinks, link)
 
-	// Rewrite the group's object header with the new link
-	return g.rewriteHeader()
+	// Rewrite the group's object header with the new link, dropping it
+	// again if it cannot be written
+	if err := g.rewriteHeader(); err != nil {
+		g.pendingLinks = g.pendingLinks[:len(g.pendingLinks)-1]
+		return err
+	}
+	return nil
 }
 
-// loadExistingLinks loads existing link messages from the group's object header.
+// loadExistingLinks loads existing link and attribute messages from the
+// group's object header, which rewriteHeader writes back.
 func (g *Group) loadExistingLinks() error {
 	g.pendingLinks = make([]*message.Link, 0)
+	g.pendingAttrs = nil
 
 	// If we don't have a header loaded, try to load it
 	if g.header == nil && g.file.reader != nil {
@@ -96,43 +310,69 @@ func (g *Group) loadExistingLinks() error {
 		g.header = header
 	}
 
-	// If we have a header, extract existing link messages
+	// If we have a header, extract existing link and attribute messages, or
+	// symbol table entries, which are written back as a new symbol table.
+	// Links and attributes in dense storage would be lost when the header
+	// is rewritten.
 	if g.header != nil {
+		if info := g.header.LinkInfo(); info != nil && !g.file.reader.IsUndefinedOffset(info.FractalHeapAddr) {
+			return fmt.Errorf("%w: adding links to a group in dense storage", ErrUnsupported)
+		}
+		if info := g.header.AttributeInfo(); info != nil && !g.file.reader.IsUndefinedOffset(info.FractalHeapAddr) {
+			return fmt.Errorf("%w: rewriting a group with attributes in dense storage", ErrUnsupported)
+		}
+		if symTable := g.symbolTable(); symTable != nil {
+			entries, err := g.getMembersV1(symTable)
+			if err != nil {
+				return err
+			}
+			for _, entry := range entries {
+				if entry.LinkType == 1 {
+					g.pendingLinks = append(g.pendingLinks, message.NewSoftLink(entry.Name, entry.SoftLinkValue))
+				} else {
+					g.pendingLinks = append(g.pendingLinks, message.NewHardLink(entry.Name, entry.ObjectAddress))
+				}
+			}
+		}
 		linkMsgs := g.header.GetMessages(message.TypeLink)
 		for _, msg := range linkMsgs {
 			if linkMsg, ok := msg.(*message.Link); ok {
 				g.pendingLinks = append(g.pendingLinks, linkMsg)
 			}
 		}
+		for _, msg := range g.header.GetMessages(message.TypeAttribute) {
+			g.pendingAttrs = append(g.pendingAttrs, msg.(*message.Attribute))
+		}
 	}
 
 	return nil
 }
 
-// rewriteHeader rewrites the group's object header with all pending links.
+// rewriteHeader rewrites the group's object header with all pending links
+// and attributes.
 func (g *Group) rewriteHeader() error {
-	// Create group header with LinkInfo and all links
-	messages := object.NewGroupHeader(g.pendingLinks)
-
-	// Calculate new header size with minimum chunk size for h5py compatibility
-	headerSize := object.HeaderSizeWithMinChunk(g.file.writer, messages, object.MinGroupChunkSize)
-
-	// Allocate new space (we can't resize in place, so allocate new)
-	newAddr := g.file.allocate(int64(headerSize))
-
-	// Write the new header
-	w := g.file.writer.At(int64(newAddr))
-	if _, err := object.WriteHeaderWithMinChunk(w, messages, object.MinGroupChunkSize); err != nil {
+	// Allocate new space (we can't resize in place, so allocate new), with
+	// the minimum chunk size for h5py compatibility
+	newAddr, symTable, err := g.writeHeader(object.MinGroupChunkSize)
+	if err != nil {
 		return err
 	}
 
 	// Update our address
 	oldAddr := g.addr
 	g.addr = newAddr
+	g.file.headers.invalidate(oldAddr)
 
-	// If this is the root group, update the superblock
+	// If this is the root group, update the superblock, whose root entry
+	// caches the addresses of a symbol table
 	if g.path == "/" {
 		g.file.superblock.RootGroupAddress = newAddr
+		g.file.superblock.RootGroupBTreeAddress = 0
+		g.file.superblock.RootGroupLocalHeapAddress = 0
+		if symTable != nil {
+			g.file.superblock.RootGroupBTreeAddress = symTable.BTreeAddress
+			g.file.superblock.RootGroupLocalHeapAddress = symTable.LocalHeapAddress
+		}
 	} else {
 		// Update parent's link to point to new address
 		if err := g.updateParentLink(oldAddr, newAddr); err != nil {
@@ -140,9 +380,111 @@ func (g *Group) rewriteHeader() error {
 		}
 	}
 
+	// Lookups through g read its header
+	if g.header != nil {
+		header, err := object.Read(g.file.headerReader(), g.addr)
+		if err != nil {
+			return fmt.Errorf("reading group header: %w", err)
+		}
+		g.header = header
+	}
 	return nil
 }
 
+// writeHeader writes a new object header for the group with all pending
+// links and attributes, with at least minSize bytes of messages, and
+// returns its address. Groups using a symbol table get a new one, which is
+// also returned.
+func (g *Group) writeHeader(minSize int) (uint64, *message.SymbolTable, error) {
+	var messages []message.Message
+	var symTable *message.SymbolTable
+	if g.usesSymbolTable() {
+		var err error
+		if symTable, err = g.writeSymbolTable(); err != nil {
+			return 0, nil, err
+		}
+		messages = []message.Message{symTable}
+	} else {
+		messages = object.NewGroupHeader(g.pendingLinks)
+	}
+	for _, attr := range g.pendingAttrs {
+		messages = append(messages, attr)
+	}
+
+	addr, err := g.file.writeObjectHeader(messages, minSize)
+	if err != nil {
+		return 0, nil, err
+	}
+	return addr, symTable, nil
+}
+
+// usesSymbolTable reports whether the group keeps its links in a symbol
+// table, as groups of files with a version 0 or 1 superblock do.
+func (g *Group) usesSymbolTable() bool {
+	if g.header != nil {
+		return g.symbolTable() != nil
+	}
+	return g.file.superblock.Version < 2
+}
+
+// writeSymbolTable writes a local heap and B-tree holding the group's
+// pending links and returns the symbol table message pointing at them.
+// The heap holds the link names and soft link values; external links
+// cannot be stored in a symbol table and return an error wrapping
+// ErrUnsupported.
+func (g *Group) writeSymbolTable() (*message.SymbolTable, error) {
+	links := slices.Clone(g.pendingLinks)
+	slices.SortFunc(links, func(a, b *message.Link) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	// Names and soft link values, in the order of the links
+	var strs []string
+	for _, link := range links {
+		strs = append(strs, link.Name)
+		switch link.LinkType {
+		case message.LinkTypeHard:
+		case message.LinkTypeSoft:
+			strs = append(strs, link.SoftLinkValue)
+		default:
+			return nil, fmt.Errorf("%w: link %q of type %d in %s, which uses a symbol table",
+				ErrUnsupported, link.Name, link.LinkType, g.path)
+		}
+	}
+	heapAddr, offsets, err := heap.WriteLocalHeap(g.file.writer, g.file.allocate, strs)
+	if err != nil {
+		return nil, fmt.Errorf("writing local heap: %w", err)
+	}
+
+	entries := make([]btree.SymbolTableEntry, len(links))
+	for i, link := range links {
+		entries[i].NameOffset = offsets[0]
+		offsets = offsets[1:]
+		if link.LinkType == message.LinkTypeSoft {
+			entries[i].ObjectAddress = g.file.writer.UndefinedOffset()
+			entries[i].CacheType = 2
+			binary.LittleEndian.PutUint32(entries[i].Scratch[:], uint32(offsets[0]))
+			offsets = offsets[1:]
+		} else {
+			entries[i].ObjectAddress = link.ObjectAddress
+		}
+	}
+
+	// Group B-tree node sizes, as the HDF5 library defaults them
+	leafK, internalK := int(g.file.superblock.GroupLeafNodeK), int(g.file.superblock.GroupInternalNodeK)
+	if leafK == 0 {
+		leafK = 4
+	}
+	if internalK == 0 {
+		internalK = 16
+	}
+	btreeAddr, err := btree.WriteGroupBTree(g.file.writer, g.file.allocate, entries, leafK, internalK)
+	if err != nil {
+		return nil, fmt.Errorf("writing group B-tree: %w", err)
+	}
+	return &message.SymbolTable{BTreeAddress: btreeAddr, LocalHeapAddress: heapAddr}, nil
+}
+
 // updateParentLink updates the parent group's link to point to the new address.
 func (g *Group) updateParentLink(oldAddr, newAddr uint64) error {
 	// Find parent group
@@ -161,18 +503,21 @@ func (g *Group) updateParentLink(oldAddr, newAddr uint64) error {
 	}
 
 	// Update the link in parent's pending links
-	for _, link := range parent.pendingLinks {
-		if link.Name == name {
-			link.ObjectAddress = newAddr
-			break
-		}
+	link, err := parent.pendingLink(name)
+	if err != nil {
+		return err
+	}
+	if link != nil {
+		link.ObjectAddress = newAddr
 	}
 
 	// Rewrite parent's header
 	return parent.rewriteHeader()
 }
 
-// findParent finds the parent group in the file's group hierarchy.
+// findParent finds the parent group in the file's group hierarchy: the
+// root, a group created or extended since the file was opened, or else the
+// group read back from the file.
 func (g *Group) findParent() *Group {
 	if g.path == "/" {
 		return nil
@@ -183,12 +528,19 @@ func (g *Group) findParent() *Group {
 		parentPath = "/"
 	}
 
-	// For now, if parent is root, return root
 	if parentPath == "/" {
 		return g.file.root
 	}
-
-	// For nested groups, we'd need to traverse
-	// This is a simplification - proper implementation would maintain a group cache
-	return nil
+	if parent := g.file.writeGroups[parentPath]; parent != nil {
+		return parent
+	}
+	if g.file.reader == nil {
+		return nil
+	}
+	parent, err := g.file.root.OpenGroup(parentPath)
+	if err != nil {
+		return nil
+	}
+	g.file.trackWriteGroup(parent)
+	return parent
 }
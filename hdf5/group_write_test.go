@@ -1,9 +1,17 @@
 package hdf5
 
 import (
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/dtype"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
 )
 
 func TestCreateGroup(t *testing.T) {
@@ -154,3 +162,637 @@ func TestCreateMultipleGroups(t *testing.T) {
 		}
 	}
 }
+
+// TestCreateGroupTree writes datasets into groups nested several levels
+// deep, whose headers move as links are added, and extends the tree after
+// reopening the file for writing.
+func TestCreateGroupTree(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	run1, err := f.Root().CreateGroup("exp/run1")
+	if err != nil {
+		t.Fatalf("CreateGroup exp/run1 failed: %v", err)
+	}
+	if run1.Path() != "/exp/run1" {
+		t.Errorf("Path = %q, want /exp/run1", run1.Path())
+	}
+	if _, err := run1.CreateDataset("data", []float64{1, 2, 3}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	deep, err := run1.CreateGroup("a/b")
+	if err != nil {
+		t.Fatalf("CreateGroup a/b failed: %v", err)
+	}
+	if _, err := deep.CreateDataset("x", []int32{7}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	// Reuses the existing exp
+	if _, err := f.Root().CreateGroup("exp/run2"); err != nil {
+		t.Fatalf("CreateGroup exp/run2 failed: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		want error
+	}{
+		{"exp", ErrDuplicateLink},
+		{"exp/run1/a", ErrDuplicateLink},
+		{"exp/run1/data/sub", ErrNotGroup},
+		{"exp//run3", ErrInvalidPath},
+		{"/exp", ErrInvalidPath},
+		{"exp/../run3", ErrInvalidPath},
+	} {
+		if _, err := f.Root().CreateGroup(tc.name); !errors.Is(err, tc.want) {
+			t.Errorf("CreateGroup(%q) = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+	if _, err := run1.CreateDataset("data", []float64{4}); !errors.Is(err, ErrDuplicateLink) {
+		t.Errorf("CreateDataset of an existing name = %v, want ErrDuplicateLink", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Add to the tree in the reopened file
+	f, err = OpenReadWrite(path)
+	if err != nil {
+		t.Fatalf("OpenReadWrite failed: %v", err)
+	}
+	run3, err := f.Root().CreateGroup("exp/run3")
+	if err != nil {
+		t.Fatalf("CreateGroup exp/run3 failed: %v", err)
+	}
+	if _, err := run3.CreateDataset("data", []float64{9}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if _, err := f.Root().CreateGroup("exp/run1/a/b"); !errors.Is(err, ErrDuplicateLink) {
+		t.Errorf("CreateGroup of an existing group = %v, want ErrDuplicateLink", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	for name, want := range map[string][]float64{
+		"/exp/run1/data":  {1, 2, 3},
+		"/exp/run1/a/b/x": {7},
+		"/exp/run3/data":  {9},
+	} {
+		ds, err := f.OpenDataset(name)
+		if err != nil {
+			t.Errorf("OpenDataset %s failed: %v", name, err)
+			continue
+		}
+		if got, err := ds.ReadFloat64(); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%s = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	exp, err := f.OpenGroup("exp")
+	if err != nil {
+		t.Fatalf("OpenGroup exp failed: %v", err)
+	}
+	members, err := exp.Members()
+	if err != nil || !reflect.DeepEqual(members, []string{"run1", "run2", "run3"}) {
+		t.Errorf("exp members = %v, %v, want [run1 run2 run3]", members, err)
+	}
+}
+
+// TestReadWhileWriting reads a file from Create before closing it, through
+// the root group and through the handles that created its groups, and
+// keeps writing after the reads.
+func TestReadWhileWriting(t *testing.T) {
+	for _, version := range []int{3, 0} {
+		path := filepath.Join(t.TempDir(), fmt.Sprintf("v%d.h5", version))
+		f, err := Create(path, WithSuperblockVersion(version))
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		// Nothing written yet
+		if members, err := f.Root().Members(); err != nil || len(members) != 0 {
+			t.Errorf("v%d: empty root members = %v, %v", version, members, err)
+		}
+		if _, err := f.OpenDataset("d"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("v%d: OpenDataset of a missing dataset error = %v, want ErrNotFound", version, err)
+		}
+
+		if _, err := f.Root().CreateDataset("d", []float64{1, 2}); err != nil {
+			t.Fatalf("CreateDataset failed: %v", err)
+		}
+		g, err := f.Root().CreateGroup("g")
+		if err != nil {
+			t.Fatalf("CreateGroup failed: %v", err)
+		}
+		if _, err := g.CreateDataset("e", []int32{3}); err != nil {
+			t.Fatalf("CreateDataset failed: %v", err)
+		}
+		if err := f.Root().SetAttr("units", "m"); err != nil {
+			t.Fatalf("SetAttr failed: %v", err)
+		}
+
+		check := func(stage string, rootMembers []string) {
+			t.Helper()
+			ds, err := f.OpenDataset("d")
+			if err != nil {
+				t.Fatalf("v%d %s: OpenDataset failed: %v", version, stage, err)
+			}
+			if got, err := ds.ReadFloat64(); err != nil || !reflect.DeepEqual(got, []float64{1, 2}) {
+				t.Errorf("v%d %s: d = %v, %v", version, stage, got, err)
+			}
+			if members, err := f.Root().Members(); err != nil || !reflect.DeepEqual(members, rootMembers) {
+				t.Errorf("v%d %s: root members = %v, %v, want %v", version, stage, members, err, rootMembers)
+			}
+			if members, err := g.Members(); err != nil || !reflect.DeepEqual(members, []string{"e"}) {
+				t.Errorf("v%d %s: members of the created group = %v, %v, want [e]", version, stage, members, err)
+			}
+			if got, err := f.ReadAttr("/@units"); err != nil || got != "m" {
+				t.Errorf("v%d %s: root units = %v, %v", version, stage, got, err)
+			}
+		}
+		check("while writing", []string{"d", "g"})
+
+		// Writing after the reads keeps the root's header current
+		if _, err := f.Root().CreateDataset("later", []float64{5}); err != nil {
+			t.Fatalf("CreateDataset after reads failed: %v", err)
+		}
+		if _, err := f.Root().CreateGroup("d/sub"); !errors.Is(err, ErrNotGroup) {
+			t.Errorf("v%d: CreateGroup under a dataset error = %v, want ErrNotGroup", version, err)
+		}
+		check("after more writes", []string{"d", "g", "later"})
+		if err := f.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+
+		f, err = Open(path)
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		if g, err = f.OpenGroup("g"); err != nil {
+			t.Fatalf("OpenGroup failed: %v", err)
+		}
+		check("reopened", []string{"d", "g", "later"})
+		f.Close()
+	}
+}
+
+func TestCreateLinks(t *testing.T) {
+	dir := t.TempDir()
+	sibling, err := Create(filepath.Join(dir, "sibling.h5"))
+	if err != nil {
+		t.Fatalf("Create sibling failed: %v", err)
+	}
+	if _, err := sibling.Root().CreateDataset("data", []int32{7, 8, 9}); err != nil {
+		t.Fatalf("CreateDataset in sibling failed: %v", err)
+	}
+	if err := sibling.Close(); err != nil {
+		t.Fatalf("Close sibling failed: %v", err)
+	}
+
+	path := filepath.Join(dir, "links.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	runs, err := f.Root().CreateGroup("runs")
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	run, err := runs.CreateGroup("2024-06")
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	if _, err := run.CreateDataset("values", []float64{1.5, 2.5}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	for _, link := range []struct {
+		group        *Group
+		name, target string
+	}{
+		{f.Root(), "latest", "/runs/2024-06"},
+		{runs, "current", "2024-06"},
+		{f.Root(), "dernière", "/runs/2024-06"},
+		{f.Root(), "dangling", "/nowhere"},
+	} {
+		if err := link.group.CreateSoftLink(link.name, link.target); err != nil {
+			t.Fatalf("CreateSoftLink %s failed: %v", link.name, err)
+		}
+	}
+	if err := f.Root().CreateExternalLink("shared", "sibling.h5", "/data"); err != nil {
+		t.Fatalf("CreateExternalLink failed: %v", err)
+	}
+
+	if err := f.Root().CreateSoftLink("latest", "/runs"); !errors.Is(err, ErrDuplicateLink) {
+		t.Errorf("CreateSoftLink over a soft link: got %v, want ErrDuplicateLink", err)
+	}
+	if err := f.Root().CreateExternalLink("runs", "sibling.h5", "/data"); !errors.Is(err, ErrDuplicateLink) {
+		t.Errorf("CreateExternalLink over a group: got %v, want ErrDuplicateLink", err)
+	}
+	if err := f.Root().CreateSoftLink("a/b", "/runs"); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("CreateSoftLink with a path as name: got %v, want ErrInvalidPath", err)
+	}
+	if err := f.Root().CreateExternalLink("empty", "", "/data"); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("CreateExternalLink without a file: got %v, want ErrInvalidPath", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	for _, name := range []string{"/latest/values", "/runs/current/values", "/dernière/values"} {
+		ds, err := f.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset %s failed: %v", name, err)
+		}
+		var got []float64
+		if err := ds.Read(&got); err != nil {
+			t.Fatalf("Read %s failed: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, []float64{1.5, 2.5}) {
+			t.Errorf("%s = %v, want [1.5 2.5]", name, got)
+		}
+	}
+	ds, err := f.OpenDataset("/shared")
+	if err != nil {
+		t.Fatalf("OpenDataset /shared failed: %v", err)
+	}
+	var shared []int32
+	if err := ds.Read(&shared); err != nil {
+		t.Fatalf("Read /shared failed: %v", err)
+	}
+	if !reflect.DeepEqual(shared, []int32{7, 8, 9}) {
+		t.Errorf("/shared = %v, want [7 8 9]", shared)
+	}
+
+	members, err := f.Root().MembersInfo()
+	if err != nil {
+		t.Fatalf("MembersInfo failed: %v", err)
+	}
+	linkTypes := make(map[string]string)
+	for _, m := range members {
+		linkTypes[m.Name] = m.LinkType
+	}
+	want := map[string]string{"runs": "hard", "latest": "soft", "dernière": "soft", "dangling": "soft", "shared": "external"}
+	if !reflect.DeepEqual(linkTypes, want) {
+		t.Errorf("link types = %v, want %v", linkTypes, want)
+	}
+}
+
+func TestUnlink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "unlink.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	root := f.Root()
+	for _, name := range []string{"a", "b", "c"} {
+		if _, err := root.CreateDataset(name, []int32{1, 2, 3}); err != nil {
+			t.Fatalf("CreateDataset %s failed: %v", name, err)
+		}
+	}
+	if _, err := root.CreateGroup("runs/old"); err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	if err := root.CreateSoftLink("latest", "/runs/old"); err != nil {
+		t.Fatalf("CreateSoftLink failed: %v", err)
+	}
+
+	for _, name := range []string{"a", "runs", "latest"} {
+		if err := root.Unlink(name); err != nil {
+			t.Fatalf("Unlink %s failed: %v", name, err)
+		}
+	}
+	if err := root.Unlink("a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Unlink of a removed member: got %v, want ErrNotFound", err)
+	}
+	if _, err := root.CreateDataset("b", []int32{4}); !errors.Is(err, ErrDuplicateLink) {
+		t.Errorf("CreateDataset over an existing dataset: got %v, want ErrDuplicateLink", err)
+	}
+	if _, err := root.CreateDataset("b", []float64{4, 5}, WithOverwrite()); err != nil {
+		t.Fatalf("CreateDataset with WithOverwrite failed: %v", err)
+	}
+	// The name of a removed group can be used again
+	if _, err := root.CreateGroup("runs/new"); err != nil {
+		t.Fatalf("CreateGroup after Unlink failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = OpenReadWrite(path)
+	if err != nil {
+		t.Fatalf("OpenReadWrite failed: %v", err)
+	}
+	members, err := f.Root().Members()
+	if err != nil {
+		t.Fatalf("Members failed: %v", err)
+	}
+	// Members are listed in storage order; replaced members come last
+	if want := []string{"c", "b", "runs"}; !reflect.DeepEqual(members, want) {
+		t.Errorf("Members = %v, want %v", members, want)
+	}
+	if members, err := f.Root().OpenGroup("runs"); err != nil {
+		t.Errorf("OpenGroup runs failed: %v", err)
+	} else if names, _ := members.Members(); !reflect.DeepEqual(names, []string{"new"}) {
+		t.Errorf("runs members = %v, want [new]", names)
+	}
+	ds, err := f.OpenDataset("b")
+	if err != nil {
+		t.Fatalf("OpenDataset b failed: %v", err)
+	}
+	var got []float64
+	if err := ds.Read(&got); err != nil {
+		t.Fatalf("Read b failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, []float64{4, 5}) {
+		t.Errorf("b = %v, want the overwriting [4 5]", got)
+	}
+
+	// Unlinking in a file opened for writing is seen at once
+	if err := f.Root().Unlink("c"); err != nil {
+		t.Fatalf("Unlink c after reopening failed: %v", err)
+	}
+	if _, err := f.OpenDataset("c"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("OpenDataset of unlinked c: got %v, want ErrNotFound", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	if members, _ := f.Root().Members(); !reflect.DeepEqual(members, []string{"b", "runs"}) {
+		t.Errorf("Members after reopening = %v, want [b runs]", members)
+	}
+}
+
+func TestUnlinkSymbolTable(t *testing.T) {
+	src := skipIfNoTestdata(t, "v0_many_entries.h5")
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "v0.h5")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := OpenReadWrite(path)
+	if err != nil {
+		t.Fatalf("OpenReadWrite failed: %v", err)
+	}
+	want, err := f.Root().Members()
+	if err != nil {
+		t.Fatalf("Members failed: %v", err)
+	}
+	// Entries from the first, a middle and the last symbol table node
+	for _, name := range []string{"group_000", "group_020", "group_049"} {
+		if err := f.Root().Unlink(name); err != nil {
+			t.Fatalf("Unlink %s failed: %v", name, err)
+		}
+		for i, m := range want {
+			if m == name {
+				want = append(want[:i], want[i+1:]...)
+				break
+			}
+		}
+	}
+	if err := f.Root().Unlink("group_020"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Unlink of a removed entry: got %v, want ErrNotFound", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	got, err := f.Root().Members()
+	if err != nil {
+		t.Fatalf("Members failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Members = %v, want %v", got, want)
+	}
+	if _, err := f.OpenGroup("group_021"); err != nil {
+		t.Errorf("OpenGroup of a kept entry failed: %v", err)
+	}
+}
+
+// TestSymbolTableManyMembers reads a version 0 group whose B-tree has
+// internal nodes: 5000 members fill 625 symbol table nodes, more than the
+// 32 children one B-tree node holds, so the tree has three levels.
+func TestSymbolTableManyMembers(t *testing.T) {
+	const n = 5000
+	path := filepath.Join(t.TempDir(), "many.h5")
+	f, err := Create(path, WithSuperblockVersion(0))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	many, err := f.Root().CreateGroup("many")
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+
+	// Link all the datasets at once rather than rewriting the group's
+	// B-tree for each
+	dt, err := dtype.GoTypeToDatatype(reflect.TypeOf(int32(0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dims := []uint64{1}
+	want := make([]string, n)
+	for i := range want {
+		want[i] = fmt.Sprintf("d%04d", i)
+		layout, err := f.writeData(binary.LittleEndian.AppendUint32(nil, uint32(i)), dims, nil, nil, dt, nil)
+		if err != nil {
+			t.Fatalf("writeData failed: %v", err)
+		}
+		addr, err := f.writeObjectHeader(object.NewDatasetHeader(message.NewDataspace(dims, nil), dt, layout), 0)
+		if err != nil {
+			t.Fatalf("writeObjectHeader failed: %v", err)
+		}
+		many.pendingLinks = append(many.pendingLinks, message.NewHardLink(want[i], addr))
+	}
+	if err := many.rewriteHeader(); err != nil {
+		t.Fatalf("rewriteHeader failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path, WithStrict())
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	g, err := f.OpenGroup("/many")
+	if err != nil {
+		t.Fatalf("OpenGroup failed: %v", err)
+	}
+	members, err := g.Members()
+	if err != nil {
+		t.Fatalf("Members failed: %v", err)
+	}
+	if !reflect.DeepEqual(members, want) {
+		t.Fatalf("Members returned %d names, want the %d written", len(members), n)
+	}
+	for i, name := range members {
+		var got []int32
+		if err := mustDataset(t, f, "/many/"+name).Read(&got); err != nil || len(got) != 1 || got[0] != int32(i) {
+			t.Fatalf("%s = %v, %v; want [%d]", name, got, err, i)
+		}
+	}
+}
+
+// TestLiteralNames round-trips names with spaces, dots and non-ASCII
+// characters through paths, Members and Child. Links named "." and "..",
+// which the writer refuses, are made by renaming links before the root
+// group is written.
+func TestLiteralNames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "names.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	root := f.Root()
+	names := []string{"a b ratio", "..data", "x.y", "température", "данные"}
+	for i, name := range append(names, "dot") {
+		if _, err := root.CreateDataset(name, []int32{int32(i)}); err != nil {
+			t.Fatalf("CreateDataset %q failed: %v", name, err)
+		}
+	}
+	grp, err := root.CreateGroup("группа")
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	if _, err := grp.CreateDataset("温度", []float64{21.5}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if _, err := root.CreateGroup("dotdot"); err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	if err := root.CreateSoftLink("link", "группа/温度"); err != nil {
+		t.Fatalf("CreateSoftLink failed: %v", err)
+	}
+	if err := grp.CreateSoftLink("up", "../данные"); err != nil {
+		t.Fatalf("CreateSoftLink failed: %v", err)
+	}
+	for _, link := range root.pendingLinks {
+		switch link.Name {
+		case "dot":
+			link.Name = "."
+		case "dotdot":
+			link.Name = ".."
+		}
+	}
+	if err := root.rewriteHeader(); err != nil {
+		t.Fatalf("rewriteHeader failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path, WithStrict())
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	members, err := f.Root().Members()
+	if err != nil {
+		t.Fatalf("Members failed: %v", err)
+	}
+	want := append(names, ".", "группа", "..", "link")
+	if !reflect.DeepEqual(members, want) {
+		t.Errorf("Members = %q, want %q", members, want)
+	}
+
+	for i, name := range names {
+		ds, err := f.OpenDataset(name)
+		if err != nil {
+			t.Errorf("OpenDataset %q failed: %v", name, err)
+			continue
+		}
+		if got, err := ds.ReadInt32(); err != nil || !reflect.DeepEqual(got, []int32{int32(i)}) {
+			t.Errorf("%q = %v, %v", name, got, err)
+		}
+		if ds.Path() != "/"+name || ds.Name() != name {
+			t.Errorf("%q has path %q, name %q", name, ds.Path(), ds.Name())
+		}
+	}
+	for _, p := range []string{"группа/温度", "/группа//温度/", "link"} {
+		if got, err := f.OpenDataset(p); err != nil {
+			t.Errorf("OpenDataset %q failed: %v", p, err)
+		} else if v, err := got.ReadFloat64(); err != nil || !reflect.DeepEqual(v, []float64{21.5}) {
+			t.Errorf("%q = %v, %v", p, v, err)
+		}
+	}
+
+	// ".." is a link name, not the parent, in paths and link targets alike
+	if _, err := f.OpenDataset("../data"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("OpenDataset ../data error = %v, want ErrNotFound", err)
+	}
+	if _, err := f.OpenDataset("группа/up"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("OpenDataset through ../данные error = %v, want ErrNotFound", err)
+	}
+	if g, err := f.OpenGroup(".."); err != nil || g.Path() != "/.." {
+		t.Errorf("OpenGroup .. = %v, %v, want the group named ..", g, err)
+	}
+
+	obj, err := f.Root().Child(".")
+	if err != nil {
+		t.Fatalf("Child . failed: %v", err)
+	}
+	if ds, ok := obj.(*Dataset); !ok || ds.Path() != "/." {
+		t.Errorf("Child . = %T at %q, want the dataset named .", obj, obj.Path())
+	} else if got, err := ds.ReadInt32(); err != nil || !reflect.DeepEqual(got, []int32{5}) {
+		t.Errorf(". = %v, %v", got, err)
+	}
+	if obj, err := f.Root().Child(".."); err != nil {
+		t.Errorf("Child .. failed: %v", err)
+	} else if _, ok := obj.(*Group); !ok || obj.Name() != ".." {
+		t.Errorf("Child .. = %T named %q", obj, obj.Name())
+	}
+	if obj, err := f.Root().Child("température"); err != nil || obj.Path() != "/température" {
+		t.Errorf("Child température = %v, %v", obj, err)
+	}
+	if obj, err := f.Root().Child("link"); err != nil {
+		t.Errorf("Child link failed: %v", err)
+	} else if _, ok := obj.(*Dataset); !ok {
+		t.Errorf("Child link = %T, want the dataset it links to", obj)
+	}
+	for _, name := range []string{"группа/温度", "/a b ratio", ""} {
+		if _, err := f.Root().Child(name); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Child %q error = %v, want ErrNotFound", name, err)
+		}
+	}
+
+	// Walk reports paths that open the objects they name
+	err = f.Walk(func(p string, obj Object) error {
+		if p == "/" {
+			return nil
+		}
+		if _, err := f.Stat(p); err != nil {
+			t.Errorf("Stat %q of walked object failed: %v", p, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Walk failed: %v", err)
+	}
+}
@@ -1,8 +1,10 @@
 package hdf5
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -283,6 +285,70 @@ func TestGroupNavigation(t *testing.T) {
 	}
 }
 
+func TestOpenAny(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "groups.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	for _, tt := range []struct {
+		path string
+		kind ObjectType
+		want string
+	}{
+		{"/", ObjectTypeGroup, "/"},
+		{"group1", ObjectTypeGroup, "/group1"},
+		{"/group1/subgroup/", ObjectTypeGroup, "/group1/subgroup"},
+		{"group1/data", ObjectTypeDataset, "/group1/data"},
+		{"/group1/subgroup/nested", ObjectTypeDataset, "/group1/subgroup/nested"},
+	} {
+		obj, err := f.Open(tt.path)
+		if err != nil {
+			t.Errorf("Open(%q) failed: %v", tt.path, err)
+			continue
+		}
+		if obj.Kind() != tt.kind || obj.Path() != tt.want {
+			t.Errorf("Open(%q) = %s at %q, want %s at %q", tt.path, obj.Kind(), obj.Path(), tt.kind, tt.want)
+		}
+		switch obj.(type) {
+		case *Group:
+			if tt.kind != ObjectTypeGroup {
+				t.Errorf("Open(%q) returned a *Group for a %s", tt.path, tt.kind)
+			}
+		case *Dataset:
+			if tt.kind != ObjectTypeDataset {
+				t.Errorf("Open(%q) returned a *Dataset for a %s", tt.path, tt.kind)
+			}
+		}
+	}
+
+	grp, err := f.OpenGroup("group1")
+	if err != nil {
+		t.Fatalf("OpenGroup failed: %v", err)
+	}
+	obj, err := grp.Open("subgroup/nested")
+	if err != nil {
+		t.Fatalf("Group.Open failed: %v", err)
+	}
+	if got, err := obj.(*Dataset).ReadInt64(); err != nil || !slices.Equal(got, []int64{4, 5, 6}) {
+		t.Errorf("nested = %v, %v", got, err)
+	}
+
+	_, err = f.Open("group1/missing")
+	var perr *ParseError
+	if !errors.Is(err, ErrNotFound) || !errors.As(err, &perr) || perr.Path != "/group1/missing" {
+		t.Errorf("Open missing error = %v, want ErrNotFound at /group1/missing", err)
+	}
+	if _, err := f.Open("group1/data/x"); !errors.Is(err, ErrNotGroup) {
+		t.Errorf("Open below a dataset error = %v, want ErrNotGroup", err)
+	}
+
+	f.Close()
+	if _, err := f.Open("group1"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Open after Close error = %v, want ErrClosed", err)
+	}
+}
+
 func TestDatasetAttributes(t *testing.T) {
 	path := skipIfNoTestdata(t, "attributes.h5")
 
@@ -593,6 +659,54 @@ func TestCompoundAttributes(t *testing.T) {
 	}
 }
 
+func TestCompoundAttributeStructs(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "compound_attrs.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	ds, err := f.OpenDataset("data")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+
+	// Field names match members x, y and z ignoring case
+	var point struct{ X, Y, Z float64 }
+	if err := ds.Attr("point").Read(&point); err != nil {
+		t.Fatalf("Read point failed: %v", err)
+	}
+	if point.X != 1 || point.Y != 2 || point.Z != 3 {
+		t.Errorf("point = %+v, want {1 2 3}", point)
+	}
+
+	// Tags rename fields, int32 members widen, optional fields may be absent
+	var record struct {
+		Ident int64   `hdf5:"id"`
+		Value float64 `hdf5:"value"`
+		Count float32 `hdf5:"count"`
+		Notes string  `hdf5:"notes,optional"`
+		Skip  int     `hdf5:"-"`
+	}
+	if err := ds.Attr("record").Read(&record); err != nil {
+		t.Fatalf("Read record failed: %v", err)
+	}
+	if record.Ident != 42 || record.Value != 3.14 || record.Count != 100 || record.Notes != "" {
+		t.Errorf("record = %+v, want {42 3.14 100}", record)
+	}
+
+	var missing struct{ ID, Total int32 }
+	if err := ds.Attr("record").Read(&missing); err == nil || !strings.Contains(err.Error(), "Total") {
+		t.Errorf("missing member: err = %v, want an error naming field Total", err)
+	}
+	var truncating struct {
+		Value int64 `hdf5:"value"`
+	}
+	if err := ds.Attr("record").Read(&truncating); err == nil {
+		t.Error("float member read into an int64 field")
+	}
+}
+
 func TestArrayAttributes(t *testing.T) {
 	path := skipIfNoTestdata(t, "array_attrs.h5")
 
@@ -634,6 +748,13 @@ func TestArrayAttributes(t *testing.T) {
 		t.Fatal("matrix attribute not found")
 	}
 
+	if matrixAttr.Rank() != 2 || !slices.Equal(matrixAttr.Shape(), []uint64{2, 2}) {
+		t.Errorf("matrix rank %d, shape %v; want 2, [2 2]", matrixAttr.Rank(), matrixAttr.Shape())
+	}
+	if vectorAttr.Rank() != 1 || !slices.Equal(vectorAttr.Shape(), []uint64{3}) {
+		t.Errorf("vector rank %d, shape %v; want 1, [3]", vectorAttr.Rank(), vectorAttr.Shape())
+	}
+
 	// Read as int32 slice (flattened)
 	matrixVal, err := matrixAttr.ReadInt32()
 	if err != nil {
@@ -651,6 +772,70 @@ func TestArrayAttributes(t *testing.T) {
 	}
 }
 
+func TestMultiDimAttributes(t *testing.T) {
+	for _, file := range []string{"nd_attrs.h5", "v0_nd_attrs.h5"} {
+		t.Run(file, func(t *testing.T) {
+			f, err := Open(skipIfNoTestdata(t, file))
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			defer f.Close()
+			ds, err := f.OpenDataset("data")
+			if err != nil {
+				t.Fatalf("OpenDataset failed: %v", err)
+			}
+
+			matrix := ds.Attr("matrix")
+			if matrix == nil || matrix.Rank() != 2 || !slices.Equal(matrix.Shape(), []uint64{2, 3}) {
+				t.Fatalf("matrix = %v, want a 2x3 attribute", matrix)
+			}
+			if v, err := matrix.ReadFloat64(); err != nil || !slices.Equal(v, []float64{0, 1, 2, 3, 4, 5}) {
+				t.Errorf("matrix values = %v, %v", v, err)
+			}
+
+			cube := ds.Attr("cube")
+			if cube == nil || cube.Rank() != 3 || !slices.Equal(cube.Shape(), []uint64{2, 3, 4}) {
+				t.Fatalf("cube = %v, want a 2x3x4 attribute", cube)
+			}
+			v, err := cube.ReadInt32()
+			if err != nil || len(v) != 24 {
+				t.Fatalf("cube values = %v, %v", v, err)
+			}
+			for i, x := range v {
+				if x != int32(i) {
+					t.Fatalf("cube value %d = %d", i, x)
+				}
+			}
+		})
+	}
+}
+
+func TestUTF8AttributesH5py(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "utf8_attrs.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	ds, err := f.OpenDataset("data")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	names := ds.Attrs()
+	slices.Sort(names)
+	if want := []string{"température", "привет", "温度"}; !slices.Equal(names, want) {
+		t.Errorf("Attrs = %q, want %q", names, want)
+	}
+	if v, err := ds.Attr("température").ReadScalarFloat64(); err != nil || v != 21.5 {
+		t.Errorf("température = %v, %v", v, err)
+	}
+	if v, err := ds.Attr("привет").ReadScalarInt64(); err != nil || v != 7 {
+		t.Errorf("привет = %v, %v", v, err)
+	}
+	if v, err := ds.Attr("温度").ReadScalarFloat64(); err != nil || v != -3.5 {
+		t.Errorf("温度 (committed datatype) = %v, %v", v, err)
+	}
+}
+
 func TestFileAttributes(t *testing.T) {
 	path := skipIfNoTestdata(t, "attributes.h5")
 
@@ -1166,6 +1351,90 @@ func TestDeepLinkChain(t *testing.T) {
 	}
 }
 
+func TestWithMaxLinkDepth(t *testing.T) {
+	t.Run("DeepChain", func(t *testing.T) {
+		path := skipIfNoTestdata(t, "deep_chain.h5")
+
+		// link_10 leads through nine more links to target
+		f, err := Open(path, WithMaxLinkDepth(10))
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		if _, err := f.OpenDataset("link_10"); err != nil {
+			t.Errorf("OpenDataset link_10 with depth 10 failed: %v", err)
+		}
+		f.Close()
+
+		f, err = Open(path, WithMaxLinkDepth(9))
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		defer f.Close()
+		_, err = f.OpenDataset("link_10")
+		if !errors.Is(err, ErrLinkDepth) {
+			t.Fatalf("OpenDataset link_10 with depth 9: got %v, want ErrLinkDepth", err)
+		}
+		if !strings.Contains(err.Error(), "/link_9 -> /link_8") || !strings.Contains(err.Error(), "/link_1 -> /target") {
+			t.Errorf("error does not show the chain: %v", err)
+		}
+	})
+
+	t.Run("SeparateChains", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "chains.h5")
+		f, err := Create(path)
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if _, err := f.Root().CreateDataset("d", []int32{7}); err != nil {
+			t.Fatalf("CreateDataset failed: %v", err)
+		}
+		g, err := f.Root().CreateGroup("g")
+		if err != nil {
+			t.Fatalf("CreateGroup failed: %v", err)
+		}
+		links := []struct {
+			grp          *Group
+			name, target string
+		}{
+			{g, "x", "/d"},
+			{f.Root(), "toG", "/g"},
+			{f.Root(), "a", "/d"},
+			{f.Root(), "b", "/a"},
+		}
+		for _, l := range links {
+			if err := l.grp.CreateSoftLink(l.name, l.target); err != nil {
+				t.Fatalf("CreateSoftLink %s failed: %v", l.name, err)
+			}
+		}
+		if err := f.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+
+		f, err = Open(path, WithMaxLinkDepth(1))
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		defer f.Close()
+
+		// Two links one after another along the path are each one deep
+		if _, err := f.OpenDataset("toG/x"); err != nil {
+			t.Errorf("OpenDataset toG/x failed: %v", err)
+		}
+		if ok, err := f.Exists("toG/x"); !ok || err != nil {
+			t.Errorf("Exists toG/x = %v, %v", ok, err)
+		}
+
+		// A link to a link is two deep
+		_, err = f.OpenDataset("b")
+		if !errors.Is(err, ErrLinkDepth) || !strings.Contains(err.Error(), "/a -> /d") {
+			t.Errorf("OpenDataset b: got %v, want ErrLinkDepth showing /a -> /d", err)
+		}
+		if _, err := f.Stat("b"); !errors.Is(err, ErrLinkDepth) {
+			t.Errorf("Stat b: got %v, want ErrLinkDepth", err)
+		}
+	})
+}
+
 func TestExternalLinkMissingFile(t *testing.T) {
 	path := skipIfNoTestdata(t, "external_missing.h5")
 
@@ -1376,6 +1645,87 @@ func TestErrLinkDepthExists(t *testing.T) {
 	}
 }
 
+func TestParseError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "parse_error.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	g, err := f.Root().CreateGroup("g")
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	if _, err := g.CreateDataset("data", []float64{1, 2, 3}, WithAttribute("units", "m")); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	check := func(t *testing.T, err error, sentinel error, wantPath string) *ParseError {
+		t.Helper()
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Fatalf("got %v, want a *ParseError", err)
+		}
+		if perr.Path != wantPath {
+			t.Errorf("Path = %q, want %q", perr.Path, wantPath)
+		}
+		if sentinel != nil && !errors.Is(err, sentinel) {
+			t.Errorf("got %v, want an error wrapping %v", err, sentinel)
+		}
+		if !strings.HasPrefix(err.Error(), wantPath+": ") {
+			t.Errorf("error %q does not start with the path", err)
+		}
+		return perr
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	_, err = f.OpenDataset("/g/missing")
+	check(t, err, ErrNotFound, "/g/missing")
+	_, err = f.OpenDataset("g")
+	check(t, err, ErrNotDataset, "/g")
+	ds, err := f.OpenDataset("g/data")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	var ints []int32
+	check(t, ds.Read(&ints), ErrTypeMismatch, "/g/data")
+	_, err = ds.Attr("units").ReadEnumStrings()
+	check(t, err, ErrUnsupported, "/g/data@units")
+	info, err := f.Stat("/g/data")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	f.Close()
+
+	// Break the dataset's object header
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(data[info.Address:], "JUNK")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	_, err = f.OpenDataset("g/data")
+	perr := check(t, err, nil, "/g/data")
+	// The header is read while resolving the link, so the structure is
+	// named by a ParseError inside the one with the path
+	var inner *ParseError
+	if !errors.As(perr.Err, &inner) || inner.Structure != "object header" || inner.Offset != info.Address {
+		t.Errorf("got %v, want an error reading the object header at %d", err, info.Address)
+	}
+}
+
 // TestBTreeV2Chunked tests reading a dataset with B-tree v2 chunk indexing
 func TestBTreeV2Chunked(t *testing.T) {
 	path := skipIfNoTestdata(t, "btree_v2.h5")
@@ -0,0 +1,98 @@
+package hdf5
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// defaultHeaderCacheSize is how many parsed object headers a file keeps
+// unless WithHeaderCache says otherwise.
+const defaultHeaderCacheSize = 1024
+
+// headerCache keeps the most recently read object headers of a file by
+// address, so that looking up a path does not parse the headers of the
+// groups along it again. Cached headers are shared by every handle opened
+// from them and must not be modified. It is safe for concurrent use; a
+// cache with a limit of 0 holds nothing.
+type headerCache struct {
+	mu      sync.Mutex
+	limit   int
+	order   list.List                // Of *headerEntry, most recently used first
+	entries map[uint64]*list.Element // By header address
+}
+
+// headerEntry is one header held by a headerCache.
+type headerEntry struct {
+	address uint64
+	header  *object.Header
+}
+
+// get returns the header cached for address, if any.
+func (c *headerCache) get(address uint64) (*object.Header, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[address]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*headerEntry).header, true
+}
+
+// add caches header as the header at address, evicting the least recently
+// used header once the cache is full.
+func (c *headerCache) add(address uint64, header *object.Header) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.limit <= 0 {
+		return
+	}
+	if elem, ok := c.entries[address]; ok {
+		elem.Value.(*headerEntry).header = header
+		c.order.MoveToFront(elem)
+		return
+	}
+	if c.entries == nil {
+		c.entries = make(map[uint64]*list.Element)
+	}
+	c.entries[address] = c.order.PushFront(&headerEntry{address: address, header: header})
+	for c.order.Len() > c.limit {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*headerEntry).address)
+	}
+}
+
+// invalidate drops the header cached for address, after the header there
+// was written.
+func (c *headerCache) invalidate(address uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[address]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, address)
+	}
+}
+
+// len returns the number of cached headers.
+func (c *headerCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// readHeader returns the object header at address, parsing it unless it is
+// in the file's header cache.
+func (f *File) readHeader(address uint64) (*object.Header, error) {
+	if header, ok := f.headers.get(address); ok {
+		return header, nil
+	}
+	header, err := object.Read(f.reader, address)
+	if err != nil {
+		return nil, err
+	}
+	f.headers.add(address, header)
+	return header, nil
+}
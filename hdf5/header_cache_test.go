@@ -0,0 +1,196 @@
+package hdf5
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// readCountingReaderAt counts the calls to ReadAt made through it.
+type readCountingReaderAt struct {
+	r     io.ReaderAt
+	reads atomic.Int64
+}
+
+func (c *readCountingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	c.reads.Add(1)
+	return c.r.ReadAt(p, off)
+}
+
+// writeTree writes a tree of groups depth levels deep, each holding fanout
+// subgroups and a dataset, and returns the file's contents.
+func writeTree(tb testing.TB, depth, fanout int) []byte {
+	tb.Helper()
+	p := filepath.Join(tb.TempDir(), "tree.h5")
+	f, err := Create(p)
+	if err != nil {
+		tb.Fatalf("Create failed: %v", err)
+	}
+	var fill func(g *Group, level int)
+	fill = func(g *Group, level int) {
+		if _, err := g.CreateDataset("data", []int32{int32(level)}); err != nil {
+			tb.Fatalf("CreateDataset failed: %v", err)
+		}
+		if level == depth {
+			return
+		}
+		for i := 0; i < fanout; i++ {
+			child, err := g.CreateGroup(fmt.Sprintf("g%d", i))
+			if err != nil {
+				tb.Fatalf("CreateGroup failed: %v", err)
+			}
+			fill(child, level+1)
+		}
+	}
+	fill(f.Root(), 0)
+	if err := f.Close(); err != nil {
+		tb.Fatalf("Close failed: %v", err)
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		tb.Fatalf("ReadFile failed: %v", err)
+	}
+	return data
+}
+
+// walkTree opens every dataset of a tree written by writeTree by its full
+// path, as a directory walk does, and returns how many it opened.
+func walkTree(tb testing.TB, f *File) int {
+	tb.Helper()
+	var paths []string
+	err := f.Walk(func(p string, obj Object) error {
+		if _, ok := obj.(*Dataset); ok {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	if err != nil {
+		tb.Fatalf("Walk failed: %v", err)
+	}
+	for _, p := range paths {
+		if _, err := f.OpenGroup(path.Dir(p)); err != nil {
+			tb.Fatalf("OpenGroup %s failed: %v", path.Dir(p), err)
+		}
+		if _, err := f.OpenDataset(p); err != nil {
+			tb.Fatalf("OpenDataset %s failed: %v", p, err)
+		}
+	}
+	return len(paths)
+}
+
+func TestHeaderCache(t *testing.T) {
+	c := headerCache{limit: 2}
+	a, b, d := &object.Header{}, &object.Header{}, &object.Header{}
+	c.add(1, a)
+	c.add(2, b)
+	if got, ok := c.get(1); !ok || got != a {
+		t.Fatalf("get(1) = %p, %v; want %p", got, ok, a)
+	}
+	c.add(3, d) // Evicts 2, the least recently used
+	if _, ok := c.get(2); ok {
+		t.Error("header 2 still cached after eviction")
+	}
+	if _, ok := c.get(1); !ok {
+		t.Error("header 1 evicted")
+	}
+	c.invalidate(1)
+	if _, ok := c.get(1); ok || c.len() != 1 {
+		t.Errorf("after invalidate: cached %d headers, want only 3", c.len())
+	}
+	off := headerCache{}
+	off.add(1, a)
+	if off.len() != 0 {
+		t.Error("cache without a limit holds headers")
+	}
+
+	// Opening objects again reads fewer times with the cache
+	data := writeTree(t, 3, 2)
+	reads := map[int]int64{}
+	for _, size := range []int{0, defaultHeaderCacheSize} {
+		r := &readCountingReaderAt{r: bytes.NewReader(data)}
+		f, err := OpenReader(r, int64(len(data)), WithHeaderCache(size))
+		if err != nil {
+			t.Fatalf("OpenReader failed: %v", err)
+		}
+		if n := walkTree(t, f); n != 15 {
+			t.Errorf("cache size %d: opened %d datasets, want 15", size, n)
+		}
+		reads[size] = r.reads.Load()
+		if size > 0 && f.headers.len() == 0 {
+			t.Error("no headers cached")
+		}
+		f.Close()
+	}
+	if reads[defaultHeaderCacheSize] >= reads[0] {
+		t.Errorf("reads with the cache = %d, without = %d", reads[defaultHeaderCacheSize], reads[0])
+	}
+}
+
+func TestHeaderCacheWrites(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "cache_writes.h5")
+	f, err := Create(p)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("data", []float64{1, 2}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = OpenReadWrite(p)
+	if err != nil {
+		t.Fatalf("OpenReadWrite failed: %v", err)
+	}
+	defer f.Close()
+	ds, err := f.OpenDataset("data")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if err := ds.SetAttr("units", "m"); err != nil {
+		t.Fatalf("SetAttr failed: %v", err)
+	}
+	if _, err := f.Root().CreateGroup("grp"); err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+
+	// Opening again reads the headers as written, not as cached
+	ds, err = f.OpenDataset("data")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if !ds.HasAttr("units") {
+		t.Error("reopened dataset lacks the attribute set through another handle")
+	}
+	if ok, err := f.ExistsGroup("grp"); err != nil || !ok {
+		t.Errorf("ExistsGroup = %v, %v; want true", ok, err)
+	}
+}
+
+// Walking a tree and opening each dataset by path reads the group headers
+// along every path; with the cache, each is parsed once.
+func BenchmarkHeaderCache(b *testing.B) {
+	data := writeTree(b, 5, 3)
+	for _, size := range []int{0, defaultHeaderCacheSize} {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			r := &readCountingReaderAt{r: bytes.NewReader(data)}
+			for i := 0; i < b.N; i++ {
+				f, err := OpenReader(r, int64(len(data)), WithHeaderCache(size))
+				if err != nil {
+					b.Fatalf("OpenReader failed: %v", err)
+				}
+				walkTree(b, f)
+				f.Close()
+			}
+			b.ReportMetric(float64(r.reads.Load())/float64(b.N), "reads/op")
+		})
+	}
+}
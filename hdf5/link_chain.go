@@ -0,0 +1,47 @@
+package hdf5
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// linkChain holds the targets of the soft and external links being
+// followed to resolve a path, outermost first. A link is on the chain only
+// while its target is being resolved, so links met one after another along
+// a path do not add up; only links leading to links do.
+type linkChain struct {
+	targets []string
+	max     int
+}
+
+// newLinkChain returns an empty chain limited to the file's maximum link
+// depth.
+func (f *File) newLinkChain() *linkChain {
+	return &linkChain{max: f.maxLinkDepth}
+}
+
+// follow adds target, a path or, for external links, "file:path", to the
+// chain. It fails if target is already being resolved, which would never
+// end, or if the chain is as long as the maximum link depth. Every
+// successful follow must be matched by a done once target is resolved.
+func (c *linkChain) follow(target string) error {
+	if slices.Contains(c.targets, target) {
+		return fmt.Errorf("circular link detected: %s", c.trace(target))
+	}
+	if len(c.targets) >= c.max {
+		return fmt.Errorf("%w (%d): %s", ErrLinkDepth, c.max, c.trace(target))
+	}
+	c.targets = append(c.targets, target)
+	return nil
+}
+
+// done removes the last target added by follow.
+func (c *linkChain) done() {
+	c.targets = c.targets[:len(c.targets)-1]
+}
+
+// trace shows the chain followed by next.
+func (c *linkChain) trace(next string) string {
+	return strings.Join(append(slices.Clone(c.targets), next), " -> ")
+}
@@ -0,0 +1,88 @@
+package hdf5
+
+// LinkInfo describes a link of a group as it is stored, without following
+// it; see Group.LinkInfo.
+type LinkInfo struct {
+	// Name is the link name
+	Name string
+
+	// Type is "hard", "soft" or "external", as in MemberInfo
+	Type string
+
+	// Address is the object header address of a hard link's object
+	Address uint64
+
+	// Target is the path of a soft link, or the object path in the file of
+	// an external link, exactly as stored. Relative soft link targets are
+	// relative to the group holding the link.
+	Target string
+
+	// File is the file name of an external link, as stored
+	File string
+
+	// CreationOrder is the link's creation order index in groups that
+	// track it, when HasCreationOrder is set
+	CreationOrder    uint64
+	HasCreationOrder bool
+}
+
+// LinkInfo describes the link named name in the group, read from its link
+// message or, in v1 groups, its symbol table entry. The link is not
+// followed, so soft and external links whose targets are missing are
+// described like any other. An error wrapping ErrNotFound is returned if
+// the group has no such link.
+//
+// Example:
+//
+//	info, err := f.Root().LinkInfo("latest")
+//	if info.Type == "soft" {
+//	    fmt.Println("latest ->", info.Target)
+//	}
+func (g *Group) LinkInfo(name string) (*LinkInfo, error) {
+	member, err := g.lookupMember(name)
+	if err != nil {
+		return nil, withPath(joinPath(g.path, name), err)
+	}
+	return g.describeLink(member), nil
+}
+
+// Links describes every link of the group in storage order, as LinkInfo
+// does, dangling ones included.
+func (g *Group) Links() ([]*LinkInfo, error) {
+	members, err := g.memberLinks()
+	if err != nil {
+		return nil, err
+	}
+	links := make([]*LinkInfo, len(members))
+	for i := range members {
+		links[i] = g.describeLink(&members[i])
+	}
+	return links, nil
+}
+
+// describeLink returns the LinkInfo of one of g's members.
+func (g *Group) describeLink(member *memberLink) *LinkInfo {
+	info := &LinkInfo{Name: member.name(), Type: member.linkType()}
+	if member.entry != nil {
+		if member.isHard() {
+			info.Address = member.entry.ObjectAddress
+		} else {
+			info.Target = member.entry.SoftLinkValue
+		}
+		return info
+	}
+
+	link := member.link
+	switch {
+	case link.IsHard():
+		info.Address = link.ObjectAddress
+	case link.IsSoft():
+		info.Target = link.SoftLinkValue
+	case link.IsExternal():
+		info.Target, info.File = link.ExternalPath, link.ExternalFile
+	}
+	if order := g.header.LinkInfo(); order != nil && order.TracksCreationOrder() {
+		info.CreationOrder, info.HasCreationOrder = link.CreationOrder, true
+	}
+	return info
+}
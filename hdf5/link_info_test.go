@@ -0,0 +1,150 @@
+package hdf5
+
+import (
+	"encoding/binary"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+func TestLinkInfoDangling(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "dangling_link.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	root := f.Root()
+
+	info, err := root.LinkInfo("missing")
+	if err != nil {
+		t.Fatalf("LinkInfo failed: %v", err)
+	}
+	if info.Type != "soft" || info.Target != "/does_not_exist" || info.Address != 0 {
+		t.Errorf("missing = %+v, want a soft link to /does_not_exist", info)
+	}
+	if info, err := root.LinkInfo("real_data"); err != nil || info.Type != "hard" || info.Address == 0 || info.Target != "" {
+		t.Errorf("real_data = %+v, %v, want a hard link", info, err)
+	}
+	if _, err := root.LinkInfo("nope"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("LinkInfo nope error = %v, want ErrNotFound", err)
+	}
+
+	links, err := root.Links()
+	if err != nil || len(links) != 3 {
+		t.Fatalf("Links = %v, %v, want 3 links", links, err)
+	}
+
+	members, err := root.MembersInfo()
+	if err != nil {
+		t.Fatalf("MembersInfo failed: %v", err)
+	}
+	want := map[string]MemberInfo{
+		"real_data":      {Name: "real_data", Type: ObjectTypeDataset, LinkType: "hard"},
+		"missing":        {Name: "missing", Type: ObjectTypeUnknown, LinkType: "soft", Dangling: true},
+		"missing_nested": {Name: "missing_nested", Type: ObjectTypeUnknown, LinkType: "soft", Dangling: true},
+	}
+	if len(members) != len(want) {
+		t.Errorf("MembersInfo = %+v", members)
+	}
+	for _, m := range members {
+		if m != want[m.Name] {
+			t.Errorf("member %q = %+v, want %+v", m.Name, m, want[m.Name])
+		}
+	}
+}
+
+func TestLinkInfoExternal(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "external_missing.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	info, err := f.Root().LinkInfo("missing_file")
+	if err != nil {
+		t.Fatalf("LinkInfo failed: %v", err)
+	}
+	if info.Type != "external" || info.File != "nonexistent_file.h5" || info.Target != "/data" {
+		t.Errorf("missing_file = %+v", info)
+	}
+	members, err := f.Root().MembersInfo()
+	if err != nil {
+		t.Fatalf("MembersInfo failed: %v", err)
+	}
+	for _, m := range members {
+		if m.Dangling != (m.Name == "missing_file") {
+			t.Errorf("member %+v", m)
+		}
+	}
+}
+
+func TestLinkInfoV1(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "v1_softlinks.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	info, err := f.Root().LinkInfo("soft_link")
+	if err != nil {
+		t.Fatalf("LinkInfo failed: %v", err)
+	}
+	if info.Type != "soft" || info.Target != "/target" || info.HasCreationOrder {
+		t.Errorf("soft_link = %+v", info)
+	}
+	members, err := f.Root().MembersInfo()
+	if err != nil {
+		t.Fatalf("MembersInfo failed: %v", err)
+	}
+	for _, m := range members {
+		if m.Name == "soft_link" && (m.Type != ObjectTypeDataset || m.Dangling) {
+			t.Errorf("soft_link member = %+v, want a dataset", m)
+		}
+	}
+}
+
+func TestLinkInfoCreationOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "link_order.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	empty := object.NewEmptyGroupHeader()
+	target := f.allocate(int64(object.HeaderSize(f.writer, empty)))
+	if _, err := object.WriteHeader(f.writer.At(int64(target)), empty); err != nil {
+		t.Fatalf("writing group header: %v", err)
+	}
+	linkInfo := binary.LittleEndian.AppendUint64([]byte{0, 0x01}, 2)
+	linkInfo = binary.LittleEndian.AppendUint64(linkInfo, message.UndefinedAddress)
+	linkInfo = binary.LittleEndian.AppendUint64(linkInfo, message.UndefinedAddress)
+	writeObject(t, f, "tracked", []message.Message{
+		&rawMessage{message.TypeLinkInfo, linkInfo}, message.NewGroupInfo(),
+		trackedLink("first", 0, target), trackedLink("second", 1, target),
+	})
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	g, err := f.OpenGroup("tracked")
+	if err != nil {
+		t.Fatalf("OpenGroup failed: %v", err)
+	}
+	info, err := g.LinkInfo("second")
+	if err != nil {
+		t.Fatalf("LinkInfo failed: %v", err)
+	}
+	if !info.HasCreationOrder || info.CreationOrder != 1 || info.Address != target {
+		t.Errorf("second = %+v, want creation order 1 and address %d", info, target)
+	}
+	if info, err := f.Root().LinkInfo("tracked"); err != nil || info.HasCreationOrder {
+		t.Errorf("tracked = %+v, %v, want no creation order", info, err)
+	}
+}
@@ -0,0 +1,28 @@
+package hdf5
+
+import (
+	"fmt"
+	"os"
+)
+
+// lockMode selects the advisory lock held on an open file.
+type lockMode int
+
+const (
+	lockNone lockMode = iota
+	lockShared
+	lockExclusive
+)
+
+// acquireLock takes the requested advisory lock on f without blocking.
+// Writable files always get an exclusive lock when any lock is requested.
+func acquireLock(f *os.File, mode lockMode, writable bool) error {
+	if mode == lockNone {
+		return nil
+	}
+	exclusive := mode == lockExclusive || writable
+	if err := lockFile(f, exclusive); err != nil {
+		return fmt.Errorf("locking %s: %w", f.Name(), err)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly || windows)
+
+package hdf5
+
+import (
+	"fmt"
+	"os"
+)
+
+// lockFile reports that advisory locking is not available on this platform.
+func lockFile(f *os.File, exclusive bool) error {
+	return fmt.Errorf("file locking: %w", ErrUnsupported)
+}
+
+// unlockFile is a no-op on platforms without locking.
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,123 @@
+package hdf5
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// copyTestdata copies a testdata file into a temporary directory.
+func copyTestdata(t *testing.T, filename string) string {
+	t.Helper()
+	src := skipIfNoTestdata(t, filename)
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(t.TempDir(), filename)
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dst
+}
+
+func skipIfNoLocking(t *testing.T, path string) {
+	t.Helper()
+	f, err := Open(path, WithFileLock(true))
+	if errors.Is(err, ErrUnsupported) {
+		t.Skip("file locking not supported on this platform")
+	}
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	f.Close()
+}
+
+func TestFileLockSharedReaders(t *testing.T) {
+	path := copyTestdata(t, "minimal.h5")
+	skipIfNoLocking(t, path)
+
+	f1, err := Open(path, WithFileLock(true))
+	if err != nil {
+		t.Fatalf("first shared Open failed: %v", err)
+	}
+	defer f1.Close()
+
+	f2, err := Open(path, WithFileLock(true))
+	if err != nil {
+		t.Fatalf("second shared Open failed: %v", err)
+	}
+	defer f2.Close()
+
+	if _, err := f2.OpenDataset("data"); err != nil {
+		t.Errorf("OpenDataset failed: %v", err)
+	}
+}
+
+func TestFileLockExclusion(t *testing.T) {
+	path := copyTestdata(t, "minimal.h5")
+	skipIfNoLocking(t, path)
+
+	reader, err := Open(path, WithFileLock(true))
+	if err != nil {
+		t.Fatalf("shared Open failed: %v", err)
+	}
+
+	// A writer must not get in while a reader holds the lock
+	if _, err := OpenReadWrite(path, WithFileLock(false)); !errors.Is(err, ErrFileLocked) {
+		t.Fatalf("OpenReadWrite: expected ErrFileLocked, got %v", err)
+	}
+	if _, err := Open(path, WithFileLock(false)); !errors.Is(err, ErrFileLocked) {
+		t.Fatalf("exclusive Open: expected ErrFileLocked, got %v", err)
+	}
+
+	// Opening without a lock is never blocked
+	unlocked, err := Open(path, WithFileLock(true), WithoutFileLock())
+	if err != nil {
+		t.Fatalf("unlocked Open failed: %v", err)
+	}
+	unlocked.Close()
+
+	// Closing the reader releases the lock
+	reader.Close()
+
+	writer, err := OpenReadWrite(path, WithFileLock(true))
+	if err != nil {
+		t.Fatalf("OpenReadWrite after release failed: %v", err)
+	}
+	defer writer.Close()
+
+	if _, err := Open(path, WithFileLock(true)); !errors.Is(err, ErrFileLocked) {
+		t.Errorf("shared Open while writing: expected ErrFileLocked, got %v", err)
+	}
+}
+
+func TestFileLockCreate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "locked.h5")
+
+	f, err := Create(path, WithFileLock(false))
+	if errors.Is(err, ErrUnsupported) {
+		t.Skip("file locking not supported on this platform")
+	}
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	if _, err := Open(path, WithFileLock(true)); !errors.Is(err, ErrFileLocked) {
+		t.Errorf("Open during Create: expected ErrFileLocked, got %v", err)
+	}
+	if _, err := Create(path, WithFileLock(false)); !errors.Is(err, ErrFileLocked) {
+		t.Errorf("second Create: expected ErrFileLocked, got %v", err)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f2, err := Open(path, WithFileLock(true))
+	if err != nil {
+		t.Fatalf("Open after Close failed: %v", err)
+	}
+	f2.Close()
+}
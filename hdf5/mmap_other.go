@@ -0,0 +1,16 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package hdf5
+
+import "os"
+
+// mapFile reports no mapping where memory maps are not supported, so that
+// reads fall back to copying.
+func mapFile(f *os.File) ([]byte, error) {
+	return nil, nil
+}
+
+// unmapFile releases a mapping made by mapFile.
+func unmapFile(data []byte) error {
+	return nil
+}
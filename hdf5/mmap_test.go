@@ -0,0 +1,123 @@
+package hdf5
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/layout"
+)
+
+func TestMmap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mmap.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	floats := make([]float64, 1000)
+	for i := range floats {
+		floats[i] = float64(i) / 4
+	}
+	if _, err := f.Root().CreateDataset("floats", floats); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("ints", []int32{-3, 1, 4, -1, 5}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("chunked", floats, WithChunks(64), WithCompression(4)); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	plain, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer plain.Close()
+	mapped, err := Open(path, WithMmap())
+	if err != nil {
+		t.Fatalf("Open with WithMmap failed: %v", err)
+	}
+
+	// Every read matches the copying path
+	for _, name := range []string{"floats", "ints", "chunked"} {
+		want, err := plain.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset %s failed: %v", name, err)
+		}
+		ds, err := mapped.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset %s failed: %v", name, err)
+		}
+		wantRaw, err := want.ReadRaw()
+		if err != nil {
+			t.Fatalf("ReadRaw %s failed: %v", name, err)
+		}
+		raw, err := ds.ReadRaw()
+		if err != nil || !bytes.Equal(raw, wantRaw) {
+			t.Errorf("mapped ReadRaw %s = %d bytes, %v, want %d bytes", name, len(raw), err, len(wantRaw))
+		}
+		var got, wantVals []float64
+		if err := want.Read(&wantVals); err != nil {
+			t.Fatalf("Read %s failed: %v", name, err)
+		}
+		if err := ds.Read(&got); err != nil || !reflect.DeepEqual(got, wantVals) {
+			t.Errorf("mapped Read %s = %v, %v, want %v", name, got, err, wantVals)
+		}
+	}
+
+	compactPath := skipIfNoTestdata(t, "compact.h5")
+	compactWant, err := Open(compactPath)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer compactWant.Close()
+	compactFile, err := Open(compactPath, WithMmap())
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer compactFile.Close()
+	wantDS, _ := compactWant.OpenDataset("compact")
+	ds, err := compactFile.OpenDataset("compact")
+	if err != nil {
+		t.Fatalf("OpenDataset compact failed: %v", err)
+	}
+	wantRaw, _ := wantDS.ReadRaw()
+	if raw, err := ds.ReadRaw(); err != nil || !bytes.Equal(raw, wantRaw) {
+		t.Errorf("mapped compact ReadRaw = %v, %v, want %v", raw, err, wantRaw)
+	}
+
+	if mapped.mapped == nil {
+		t.Skip("memory maps not supported")
+	}
+
+	// Contiguous data is a view of the mapping
+	ds, err = mapped.OpenDataset("floats")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	raw, err := ds.ReadRaw()
+	if err != nil {
+		t.Fatalf("ReadRaw failed: %v", err)
+	}
+	addr := ds.layout.(*layout.Contiguous).Address()
+	if &raw[0] != &mapped.mapped[addr] || cap(raw) != len(raw) {
+		t.Error("ReadRaw copied the mapped data")
+	}
+
+	if err := mapped.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if mapped.mapped != nil {
+		t.Error("Close left the file mapped")
+	}
+	if err := mapped.Close(); err != nil {
+		t.Errorf("second Close failed: %v", err)
+	}
+	if _, err := ds.ReadRaw(); err == nil {
+		t.Error("ReadRaw succeeded after Close")
+	}
+}
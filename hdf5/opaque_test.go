@@ -0,0 +1,91 @@
+package hdf5
+
+import (
+	"encoding/binary"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+func TestOpaqueAndBitfield(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "opaque.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// Two 4-byte opaque elements, with a scalar opaque attribute
+	png := message.NewOpaqueDatatype(4, "image/png")
+	magic := message.NewScalarAttribute("magic", message.NewOpaqueDatatype(2, "bytes"), []byte{0xCA, 0xFE})
+	data := f.allocate(8)
+	if err := f.writer.At(int64(data)).WriteBytes([]byte{1, 2, 3, 4, 5, 6, 7, 8}); err != nil {
+		t.Fatalf("writing data: %v", err)
+	}
+	writeObject(t, f, "blob", []message.Message{
+		message.NewDataspace([]uint64{2}, nil), png, message.NewContiguousLayout(data, 8), magic,
+	})
+
+	// Big-endian 16-bit bitfields with 8 significant bits at bit offset 4,
+	// the other bits all set
+	flags := &message.Datatype{
+		Class: message.ClassBitfield, ClassBits: 0x01, Size: 2,
+		ByteOrder: message.OrderBE, BitOffset: 4, BitPrecision: 8,
+	}
+	var raw []byte
+	for _, v := range []uint16{0x00, 0xA5, 0xFF} {
+		raw = binary.BigEndian.AppendUint16(raw, v<<4|0xF00F)
+	}
+	data = f.allocate(int64(len(raw)))
+	if err := f.writer.At(int64(data)).WriteBytes(raw); err != nil {
+		t.Fatalf("writing data: %v", err)
+	}
+	writeObject(t, f, "flags", []message.Message{
+		message.NewDataspace([]uint64{3}, nil), flags, message.NewContiguousLayout(data, uint64(len(raw))),
+	})
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	ds, err := f.OpenDataset("blob")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if info := ds.DtypeInfo(); info.Tag != "image/png" || info.String() != `H5T_OPAQUE { OPQ_SIZE 4; OPQ_TAG "image/png"; }` {
+		t.Errorf("DtypeInfo = %+v (%s), want tag image/png", info, info)
+	}
+	elems, tag, err := ds.ReadOpaque()
+	if err != nil || tag != "image/png" || !reflect.DeepEqual(elems, [][]byte{{1, 2, 3, 4}, {5, 6, 7, 8}}) {
+		t.Errorf("ReadOpaque = %v, %q, %v", elems, tag, err)
+	}
+	attr := ds.Attr("magic")
+	if elems, tag, err := attr.ReadOpaque(); err != nil || tag != "bytes" || !reflect.DeepEqual(elems, [][]byte{{0xCA, 0xFE}}) {
+		t.Errorf("attribute ReadOpaque = %v, %q, %v", elems, tag, err)
+	}
+	if v, err := attr.Value(); err != nil || !reflect.DeepEqual(v, []byte{0xCA, 0xFE}) {
+		t.Errorf("attribute Value = %v, %v; want [202 254]", v, err)
+	}
+
+	ds, err = f.OpenDataset("flags")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	info := ds.DtypeInfo()
+	if info.Class != ClassBitfield || info.ByteOrder != binary.BigEndian || info.BitOffset != 4 || info.Precision != 8 {
+		t.Errorf("DtypeInfo = %+v, want big-endian with 8 bits at offset 4", info)
+	}
+	if got, err := ds.ReadUint16(); err != nil || !reflect.DeepEqual(got, []uint16{0x00, 0xA5, 0xFF}) {
+		t.Errorf("ReadUint16 = %#x, %v; want [0 0xa5 0xff]", got, err)
+	}
+	if _, _, err := ds.ReadOpaque(); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("ReadOpaque on a bitfield: got %v, want ErrUnsupported", err)
+	}
+}
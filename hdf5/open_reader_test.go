@@ -0,0 +1,143 @@
+package hdf5
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// closeTrackingReader is an io.ReaderAt that records whether it was closed.
+type closeTrackingReader struct {
+	*bytes.Reader
+	closed bool
+}
+
+func (r *closeTrackingReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestOpenReader(t *testing.T) {
+	path := skipIfNoTestdata(t, "groups.h5")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+
+	want, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer want.Close()
+	wantData, err := want.OpenDataset("/group1/subgroup/nested")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	wantValues, err := wantData.ReadFloat64()
+	if err != nil {
+		t.Fatalf("ReadFloat64 failed: %v", err)
+	}
+
+	// Data beyond the given size is not part of the file
+	r := &closeTrackingReader{Reader: bytes.NewReader(append(data, "junk"...))}
+	f, err := OpenReader(r, int64(len(data)))
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	if f.Path() != "" {
+		t.Errorf("Path() = %q, want empty", f.Path())
+	}
+	if n, err := f.TrailingBytes(); err != nil || n != 0 {
+		t.Errorf("TrailingBytes() = %d, %v, want 0", n, err)
+	}
+	members, err := f.Root().Members()
+	if err != nil || !reflect.DeepEqual(members, []string{"group1", "group2"}) {
+		t.Errorf("Members() = %v, %v", members, err)
+	}
+	ds, err := f.OpenDataset("/group1/subgroup/nested")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if values, err := ds.ReadFloat64(); err != nil || !reflect.DeepEqual(values, wantValues) {
+		t.Errorf("ReadFloat64() = %v, %v, want %v", values, err, wantValues)
+	}
+	if err := f.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	if r.closed {
+		t.Error("Close closed the caller's reader")
+	}
+
+	f, err = OpenBytes(data)
+	if err != nil {
+		t.Fatalf("OpenBytes failed: %v", err)
+	}
+	defer f.Close()
+	if ok, err := f.ExistsDataset("/group1/data"); err != nil || !ok {
+		t.Errorf("ExistsDataset() = %v, %v", ok, err)
+	}
+
+	if _, err := OpenBytes(data[:20]); err == nil {
+		t.Error("OpenBytes succeeded on truncated data")
+	}
+	if _, err := OpenBytes(data, WithFileLock(true)); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("OpenBytes with a lock error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestOpenReaderExternalLink(t *testing.T) {
+	path := skipIfNoTestdata(t, "external_source.h5")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+
+	f, err := OpenBytes(data)
+	if err != nil {
+		t.Fatalf("OpenBytes failed: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.OpenDataset("local_data"); err != nil {
+		t.Errorf("OpenDataset local_data failed: %v", err)
+	}
+	if _, err := f.OpenDataset("link_to_data"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("OpenDataset via external link error = %v, want ErrUnsupported", err)
+	}
+}
+
+// Parsing a header or B-tree node reads it at once rather than field by
+// field, so walking a file takes about one ReadAt per object.
+func TestOpenReaderReadsAhead(t *testing.T) {
+	data := writeTree(t, 3, 4)
+	r := &readCountingReaderAt{r: bytes.NewReader(data)}
+	f, err := OpenReader(r, int64(len(data)))
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer f.Close()
+	objects := 0
+	if err := f.Walk(func(string, Object) error { objects++; return nil }); err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+	if reads := r.reads.Load(); reads > int64(2*objects) {
+		t.Errorf("walking %d objects took %d reads, want at most %d", objects, reads, 2*objects)
+	}
+}
+
+func BenchmarkOpenManyObjects(b *testing.B) {
+	data := writeTree(b, 3, 4)
+	r := &readCountingReaderAt{r: bytes.NewReader(data)}
+	for i := 0; i < b.N; i++ {
+		f, err := OpenReader(r, int64(len(data)))
+		if err != nil {
+			b.Fatalf("OpenReader failed: %v", err)
+		}
+		if err := f.Walk(func(string, Object) error { return nil }); err != nil {
+			b.Fatalf("Walk failed: %v", err)
+		}
+		f.Close()
+	}
+	b.ReportMetric(float64(r.reads.Load())/float64(b.N), "reads/op")
+}
@@ -1,17 +1,40 @@
 package hdf5
 
-// FileOption configures file creation options.
+import "github.com/robert-malhotra/go-hdf5/internal/message"
+
+// FileOption configures file creation and opening options.
+// Size options only apply to Create and are ignored when opening existing files.
 type FileOption func(*fileOptions)
 
 type fileOptions struct {
-	offsetSize int
-	lengthSize int
+	offsetSize        int
+	lengthSize        int
+	superblockVersion int
+	lock              lockMode
+	strict            bool
+	checksums         ChecksumPolicy
+	verifyChecksums   bool
+	mmap              bool
+	extLinks          externalLinkOptions
+	headerCache       int
+	maxLinkDepth      int
+}
+
+// externalLinkOptions controls how a file's external links are followed.
+// Files opened for external links inherit them.
+type externalLinkOptions struct {
+	resolver ExternalLinkResolver
+	prefix   []string
+	disabled bool
 }
 
 func defaultFileOptions() *fileOptions {
 	return &fileOptions{
-		offsetSize: 8,
-		lengthSize: 8,
+		offsetSize:        8,
+		lengthSize:        8,
+		superblockVersion: 3,
+		headerCache:       defaultHeaderCacheSize,
+		maxLinkDepth:      MaxLinkDepth,
 	}
 }
 
@@ -37,6 +60,182 @@ func WithLengthSize(size int) FileOption {
 	}
 }
 
+// WithSuperblockVersion sets the version of the superblock Create writes.
+// Valid values are 0, 2 and 3 (the default); any other value will cause a
+// panic. Version 0 files are laid out as by HDF5 1.6 and read by the
+// oldest tools: groups keep their members in symbol tables, a B-tree and
+// a local heap, and objects have version 1 headers.
+//
+// Datasets in a version 0 file are always contiguous: chunks would need a
+// version 1 B-tree index, which is not written. CreateDataset with
+// WithChunks, which filters and Append depend on, returns an error
+// wrapping ErrUnsupported, and CopyObject writes chunked sources
+// contiguously. External links cannot be created either.
+func WithSuperblockVersion(version int) FileOption {
+	if version != 0 && version != 2 && version != 3 {
+		panic("WithSuperblockVersion: version must be 0, 2, or 3")
+	}
+	return func(o *fileOptions) {
+		o.superblockVersion = version
+	}
+}
+
+// WithFileLock takes an advisory lock on the file while it is open, so that
+// readers and writers in other processes (including the HDF5 C library with
+// file locking enabled) exclude each other. The lock covers the whole file,
+// as the C library's does.
+//
+// Open takes a shared lock if shared is true and an exclusive lock otherwise.
+// Create and OpenReadWrite always take an exclusive lock. Locks are never
+// waited for: if the file is already locked incompatibly, opening fails with
+// ErrFileLocked.
+func WithFileLock(shared bool) FileOption {
+	return func(o *fileOptions) {
+		if shared {
+			o.lock = lockShared
+		} else {
+			o.lock = lockExclusive
+		}
+	}
+}
+
+// WithoutFileLock opens the file without taking any advisory lock.
+// This is the default; it can be used to override an earlier WithFileLock.
+func WithoutFileLock() FileOption {
+	return func(o *fileOptions) {
+		o.lock = lockNone
+	}
+}
+
+// WithStrict makes structural anomalies that are normally tolerated, such as
+// duplicate link names in a group, fail with an error instead of being
+// recorded as warnings (see File.Warnings).
+func WithStrict() FileOption {
+	return func(o *fileOptions) {
+		o.strict = true
+	}
+}
+
+// ChecksumPolicy says how reads treat chunks of a dataset whose Fletcher-32
+// checksum does not match their data.
+type ChecksumPolicy int
+
+const (
+	// ChecksumStrict fails the read with an error wrapping
+	// ErrChecksumMismatch that names the chunk's offset and address. This
+	// is the default.
+	ChecksumStrict ChecksumPolicy = iota
+
+	// ChecksumWarnAndSkip reads the chunk as the dataset's fill value and
+	// records the failure, for Dataset.ReadErrors, so that the rest of the
+	// dataset can be recovered.
+	ChecksumWarnAndSkip
+
+	// ChecksumIgnore reads the chunk's data without checking its checksum.
+	ChecksumIgnore
+)
+
+// WithChecksumPolicy sets how reads treat chunks failing their Fletcher-32
+// checksum; see ChecksumPolicy.
+func WithChecksumPolicy(policy ChecksumPolicy) FileOption {
+	return func(o *fileOptions) {
+		o.checksums = policy
+	}
+}
+
+// WithVerifyChecksums sets whether the lookup3 checksums of metadata are
+// verified on read: object headers, v2 B-trees, fractal heap blocks and
+// fixed array indexes. A mismatch fails with an error wrapping
+// ErrChecksumMismatch that names the structure's signature and address.
+// Local heaps and symbol table nodes, which have no checksum, are checked
+// to lie within their bounds instead. Structures whose checksums are
+// always verified, such as superblocks and fractal heap headers, are
+// unaffected.
+func WithVerifyChecksums(verify bool) FileOption {
+	return func(o *fileOptions) {
+		o.verifyChecksums = verify
+	}
+}
+
+// WithMmap maps the file into memory and reads uncompressed contiguous
+// datasets straight from the mapping, so that Read converts from it without
+// an intermediate copy and ReadRaw returns a view of it rather than a copy.
+// Compact datasets likewise return the data held in their object header.
+// Raw data returned this way is read-only and only valid until the file is
+// closed; the file must not be truncated while it is open. Chunked data is
+// read as usual, and where memory maps are not supported, or for files
+// opened with OpenReader, every read copies.
+func WithMmap() FileOption {
+	return func(o *fileOptions) {
+		o.mmap = true
+	}
+}
+
+// WithHeaderCache keeps up to n parsed object headers in memory, so that
+// opening objects does not read and parse the headers of the groups on
+// their paths again. The default is 1024; 0 disables the cache. Headers
+// written through a writable file are dropped from its cache.
+func WithHeaderCache(n int) FileOption {
+	return func(o *fileOptions) {
+		o.headerCache = max(n, 0)
+	}
+}
+
+// WithMaxLinkDepth limits how many soft and external links may lead one to
+// another while a path is resolved: a link to a link to an object is two
+// deep, however many links the path itself crosses. Going deeper fails with
+// an error wrapping ErrLinkDepth that shows the chain of link targets. The
+// default is MaxLinkDepth; values below 1 are taken as 1. Links that lead
+// back to a link being resolved fail as circular at any depth.
+func WithMaxLinkDepth(n int) FileOption {
+	return func(o *fileOptions) {
+		o.maxLinkDepth = max(n, 1)
+	}
+}
+
+// ExternalLinkResolver opens the file an external link in parent points
+// to, given the file name and object path stored in the link. Returning a
+// nil File and a nil error falls back to the default search. The returned
+// file is cached for later links to the same name and closed when parent
+// is closed.
+type ExternalLinkResolver func(parent *File, filename, objPath string) (*File, error)
+
+// WithExternalLinkResolver consults resolve for the file of each external
+// link before searching for it. Files opened by the search inherit the
+// resolver.
+func WithExternalLinkResolver(resolve ExternalLinkResolver) FileOption {
+	return func(o *fileOptions) {
+		o.extLinks.resolver = resolve
+	}
+}
+
+// WithExternalLinkPrefix searches dirs, in order, for the files of external
+// links before the directory of the file holding the link, like the
+// HDF5_EXT_PREFIX search path of the C library. Absolute file names are
+// tried as is first, and then searched for by their base name.
+func WithExternalLinkPrefix(dirs ...string) FileOption {
+	return func(o *fileOptions) {
+		o.extLinks.prefix = append([]string(nil), dirs...)
+	}
+}
+
+// WithoutExternalLinks makes following an external link fail with
+// ErrExternalLinksDisabled, so that untrusted files cannot make the reader
+// open other files.
+func WithoutExternalLinks() FileOption {
+	return func(o *fileOptions) {
+		o.extLinks.disabled = true
+	}
+}
+
+// withExternalLinks passes a file's external link options on to the files
+// its links open.
+func withExternalLinks(ext externalLinkOptions) FileOption {
+	return func(o *fileOptions) {
+		o.extLinks = ext
+	}
+}
+
 // DatasetOption configures dataset creation options.
 type DatasetOption func(*datasetOptions)
 
@@ -49,10 +248,12 @@ type attrDef struct {
 type datasetOptions struct {
 	chunks         []uint64
 	maxDims        []uint64
+	gzip           bool
 	compressionLvl int
 	shuffle        bool
 	fletcher32     bool
 	attributes     []attrDef
+	overwrite      bool
 }
 
 func defaultDatasetOptions() *datasetOptions {
@@ -63,6 +264,30 @@ func defaultDatasetOptions() *datasetOptions {
 	}
 }
 
+// filterPipeline returns the filter pipeline message for the options, in
+// the order the HDF5 library applies them: shuffle, then DEFLATE, then the
+// Fletcher-32 checksum. It returns nil when no filter is enabled.
+func (o *datasetOptions) filterPipeline(elementSize uint32) *message.FilterPipeline {
+	var filters []message.FilterInfo
+	if o.shuffle {
+		filters = append(filters, message.FilterInfo{
+			ID: message.FilterShuffle, Flags: 0x01, ClientData: []uint32{elementSize},
+		})
+	}
+	if o.gzip {
+		filters = append(filters, message.FilterInfo{
+			ID: message.FilterDeflate, Flags: 0x01, ClientData: []uint32{uint32(o.compressionLvl)},
+		})
+	}
+	if o.fletcher32 {
+		filters = append(filters, message.FilterInfo{ID: message.FilterFletcher32})
+	}
+	if len(filters) == 0 {
+		return nil
+	}
+	return message.NewFilterPipeline(filters...)
+}
+
 // WithChunks sets the chunk dimensions for a chunked dataset.
 // Required for resizable datasets and compression.
 func WithChunks(dims ...uint64) DatasetOption {
@@ -72,33 +297,57 @@ func WithChunks(dims ...uint64) DatasetOption {
 }
 
 // WithMaxDims sets the maximum dimensions for a resizable dataset.
-// Use 0 for unlimited dimension.
+// Use Unlimited (or 0) for unlimited dimension.
 func WithMaxDims(dims ...uint64) DatasetOption {
 	return func(o *datasetOptions) {
-		o.maxDims = dims
+		o.maxDims = make([]uint64, len(dims))
+		for i, d := range dims {
+			if d == 0 {
+				d = Unlimited
+			}
+			o.maxDims[i] = d
+		}
+	}
+}
+
+// WithGzip compresses the dataset's chunks with the DEFLATE (gzip) filter
+// at the given level, from 0 (no compression, but still filtered) through
+// 9 (maximum compression). Any value outside this range will cause a panic.
+// Filters need a chunked layout, set with WithChunks.
+func WithGzip(level int) DatasetOption {
+	if level < 0 || level > 9 {
+		panic("WithGzip: level must be 0-9")
+	}
+	return func(o *datasetOptions) {
+		o.gzip = true
+		o.compressionLvl = level
 	}
 }
 
 // WithCompression sets the compression level.
 // Valid values are 0 (no compression) through 9 (maximum compression).
-// Any value outside this range will cause a panic.
+// Any value outside this range will cause a panic. Levels above 0 are the
+// same as WithGzip.
 func WithCompression(level int) DatasetOption {
 	if level < 0 || level > 9 {
 		panic("WithCompression: level must be 0-9")
 	}
 	return func(o *datasetOptions) {
+		o.gzip = level > 0
 		o.compressionLvl = level
 	}
 }
 
-// WithShuffle enables the shuffle filter (improves compression).
+// WithShuffle enables the shuffle filter (improves compression). It is
+// applied before compression.
 func WithShuffle() DatasetOption {
 	return func(o *datasetOptions) {
 		o.shuffle = true
 	}
 }
 
-// WithFletcher32 enables Fletcher32 checksum validation.
+// WithFletcher32 enables Fletcher32 checksum validation. The checksum is
+// computed after compression.
 func WithFletcher32() DatasetOption {
 	return func(o *datasetOptions) {
 		o.fletcher32 = true
@@ -106,10 +355,21 @@ func WithFletcher32() DatasetOption {
 }
 
 // WithAttribute adds an attribute to the dataset.
-// The value can be a scalar or slice of: int, int8-64, uint, uint8-64, float32, float64, string.
+// The value can be a scalar or slice of: int, int8-64, uint, uint8-64, float32, float64, string,
+// or time.Time, stored as int64 seconds since the Unix epoch.
 // Multiple WithAttribute options can be used to add multiple attributes.
 func WithAttribute(name string, value interface{}) DatasetOption {
 	return func(o *datasetOptions) {
 		o.attributes = append(o.attributes, attrDef{name: name, value: value})
 	}
 }
+
+// WithOverwrite replaces a member of the group with the same name as the
+// new dataset, unlinking it first as Group.Unlink does. Without it,
+// creating a dataset under a name already in use fails with an error
+// wrapping ErrDuplicateLink.
+func WithOverwrite() DatasetOption {
+	return func(o *datasetOptions) {
+		o.overwrite = true
+	}
+}
@@ -0,0 +1,111 @@
+package hdf5
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// Order selects the order in which Group.MembersOrdered and AttrsOrdered
+// list names. Members and Attrs list them in storage order instead.
+type Order int
+
+const (
+	// ByName lists names in increasing byte order.
+	ByName Order = iota
+
+	// ByCreationOrder lists names in the order they were created, as
+	// h5py does for objects that track it. Objects that do not fail with
+	// ErrNoCreationOrder.
+	ByCreationOrder
+)
+
+// String returns the name of the order.
+func (o Order) String() string {
+	switch o {
+	case ByName:
+		return "name"
+	case ByCreationOrder:
+		return "creation order"
+	default:
+		return fmt.Sprintf("Order(%d)", int(o))
+	}
+}
+
+// MembersOrdered returns the names of all members of the group in the given
+// order.
+func (g *Group) MembersOrdered(by Order) ([]string, error) {
+	switch by {
+	case ByName:
+		names, err := g.Members()
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(names)
+		return names, nil
+
+	case ByCreationOrder:
+		header, err := g.objectHeader()
+		if err != nil {
+			return nil, err
+		}
+		info := header.LinkInfo()
+		if info == nil || !info.TracksCreationOrder() {
+			return nil, fmt.Errorf("%w: links of %q", ErrNoCreationOrder, g.path)
+		}
+		links, err := g.links()
+		if err != nil {
+			return nil, err
+		}
+		sort.SliceStable(links, func(i, j int) bool { return links[i].CreationOrder < links[j].CreationOrder })
+		names := make([]string, len(links))
+		for i, link := range links {
+			names[i] = link.Name
+		}
+		return names, nil
+
+	default:
+		return nil, fmt.Errorf("unknown order: %v", by)
+	}
+}
+
+// attrsOrdered returns the names of the attributes of the object at objPath
+// in the given order. Unlike attributes, it fails if dense storage cannot
+// be read.
+func (f *File) attrsOrdered(header *object.Header, objPath string, by Order) ([]string, error) {
+	attrs, err := f.readAttributes(header)
+	if err != nil {
+		return nil, err
+	}
+
+	switch by {
+	case ByName:
+		sort.SliceStable(attrs, func(i, j int) bool { return attrs[i].Name < attrs[j].Name })
+
+	case ByCreationOrder:
+		if !tracksAttrCreationOrder(header) {
+			return nil, fmt.Errorf("%w: attributes of %q", ErrNoCreationOrder, objPath)
+		}
+		sort.SliceStable(attrs, func(i, j int) bool { return attrs[i].CreationOrder < attrs[j].CreationOrder })
+
+	default:
+		return nil, fmt.Errorf("unknown order: %v", by)
+	}
+
+	names := make([]string, len(attrs))
+	for i, attr := range attrs {
+		names[i] = attr.Name
+	}
+	return names, nil
+}
+
+// tracksAttrCreationOrder reports whether an object records the creation
+// order of its attributes, in its header flags or its attribute info.
+func tracksAttrCreationOrder(header *object.Header) bool {
+	if header.TracksAttrCreationOrder() {
+		return true
+	}
+	info := header.AttributeInfo()
+	return info != nil && info.TracksCreationOrder()
+}
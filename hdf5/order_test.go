@@ -0,0 +1,117 @@
+package hdf5
+
+import (
+	"encoding/binary"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// trackedLink returns a hard link message recording its creation order,
+// which NewHardLink links do not.
+func trackedLink(name string, order uint64, addr uint64) *rawMessage {
+	body := binary.LittleEndian.AppendUint64([]byte{1, 0x04}, order)
+	body = append(append(body, byte(len(name))), name...)
+	return &rawMessage{message.TypeLink, binary.LittleEndian.AppendUint64(body, addr)}
+}
+
+func TestOrdered(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ordered.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	empty := object.NewEmptyGroupHeader()
+	target := f.allocate(int64(object.HeaderSize(f.writer, empty)))
+	if _, err := object.WriteHeader(f.writer.At(int64(target)), empty); err != nil {
+		t.Fatalf("writing group header: %v", err)
+	}
+
+	// Links stored out of creation order, in a group tracking it
+	linkInfo := binary.LittleEndian.AppendUint64([]byte{0, 0x01}, 3)
+	linkInfo = binary.LittleEndian.AppendUint64(linkInfo, message.UndefinedAddress)
+	linkInfo = binary.LittleEndian.AppendUint64(linkInfo, message.UndefinedAddress)
+	writeObject(t, f, "tracked", []message.Message{
+		&rawMessage{message.TypeLinkInfo, linkInfo}, message.NewGroupInfo(),
+		trackedLink("alpha", 2, target), trackedLink("zeta", 0, target), trackedLink("mid", 1, target),
+	})
+	writeObject(t, f, "plain", object.NewGroupHeader([]*message.Link{
+		message.NewHardLink("b", target), message.NewHardLink("a", target),
+	}))
+
+	// Dense attributes tracking creation order without indexing it, which
+	// are read in name order
+	u8 := message.NewFixedPointDatatype(1, false, message.OrderLE)
+	var attrs []*message.Attribute
+	for _, name := range []string{"c", "a", "b"} {
+		attrs = append(attrs, message.NewScalarAttribute(name, u8, []byte{1}))
+	}
+	writeObject(t, f, "attrs", append(object.NewEmptyGroupHeader(), writeDenseAttributes(t, f, attrs, 0x01)))
+	writeObject(t, f, "untracked", append(object.NewEmptyGroupHeader(), writeDenseAttributes(t, f, attrs, 0)))
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	grp, err := f.OpenGroup("tracked")
+	if err != nil {
+		t.Fatalf("OpenGroup failed: %v", err)
+	}
+	for _, tc := range []struct {
+		by   Order
+		want []string
+	}{
+		{ByName, []string{"alpha", "mid", "zeta"}},
+		{ByCreationOrder, []string{"zeta", "mid", "alpha"}},
+	} {
+		if got, err := grp.MembersOrdered(tc.by); err != nil || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("MembersOrdered(%v) = %v, %v, want %v", tc.by, got, err, tc.want)
+		}
+	}
+	if got, err := grp.Members(); err != nil || !reflect.DeepEqual(got, []string{"alpha", "zeta", "mid"}) {
+		t.Errorf("Members() = %v, %v, want storage order", got, err)
+	}
+
+	grp, err = f.OpenGroup("plain")
+	if err != nil {
+		t.Fatalf("OpenGroup failed: %v", err)
+	}
+	if got, err := grp.MembersOrdered(ByName); err != nil || !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("MembersOrdered(ByName) = %v, %v", got, err)
+	}
+	if _, err := grp.MembersOrdered(ByCreationOrder); !errors.Is(err, ErrNoCreationOrder) {
+		t.Errorf("MembersOrdered(ByCreationOrder) error = %v, want ErrNoCreationOrder", err)
+	}
+
+	grp, err = f.OpenGroup("attrs")
+	if err != nil {
+		t.Fatalf("OpenGroup failed: %v", err)
+	}
+	if got := grp.Attrs(); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("Attrs() = %v, want name order", got)
+	}
+	if got, err := grp.AttrsOrdered(ByCreationOrder); err != nil || !reflect.DeepEqual(got, []string{"c", "a", "b"}) {
+		t.Errorf("AttrsOrdered(ByCreationOrder) = %v, %v", got, err)
+	}
+
+	grp, err = f.OpenGroup("untracked")
+	if err != nil {
+		t.Fatalf("OpenGroup failed: %v", err)
+	}
+	if got, err := grp.AttrsOrdered(ByName); err != nil || !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("AttrsOrdered(ByName) = %v, %v", got, err)
+	}
+	if _, err := grp.AttrsOrdered(ByCreationOrder); !errors.Is(err, ErrNoCreationOrder) {
+		t.Errorf("AttrsOrdered(ByCreationOrder) error = %v, want ErrNoCreationOrder", err)
+	}
+}
@@ -8,64 +8,125 @@ import (
 // ParseAttrPath parses an attribute path into object path and attribute name.
 // Path format: /group/subgroup/object@attribute_name
 //
+// The path is split at its last '@' that is not escaped with a backslash,
+// so a `\@` stands for an '@' in either name, and a `\\` for a backslash.
+// Other backslashes are kept as they are.
+//
 // Examples:
 //   - "/@root_attr" -> objectPath="/", attrName="root_attr"
+//   - "@root_attr" -> objectPath="/", attrName="root_attr"
 //   - "/data@units" -> objectPath="/data", attrName="units"
 //   - "/sensors/temp@calibration" -> objectPath="/sensors/temp", attrName="calibration"
+//   - `/data@meta\@v2` -> objectPath="/data", attrName="meta@v2"
+//   - `/run\@3@units` -> objectPath="/run@3", attrName="units"
 //
 // Returns an error if the path is invalid or missing the @ separator.
 func ParseAttrPath(path string) (objectPath, attrName string, err error) {
+	objectPath, attrName, err = splitAttrPath(path)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Handle root case: "/@attr" -> objectPath should be "/"
+	if objectPath == "" {
+		objectPath = "/"
+	}
+
+	// Normalize object path
+	if !strings.HasPrefix(objectPath, "/") {
+		objectPath = "/" + objectPath
+	}
+
+	return objectPath, attrName, nil
+}
+
+// splitAttrPath splits an attribute path at its last unescaped '@' and
+// unescapes both parts, leaving the object path as written.
+func splitAttrPath(path string) (objectPath, attrName string, err error) {
 	if path == "" {
 		return "", "", fmt.Errorf("empty attribute path")
 	}
 
-	// Find the @ separator
-	atIdx := strings.LastIndex(path, "@")
+	// Find the last @ separator, skipping escaped characters
+	atIdx := -1
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			i++
+		case '@':
+			atIdx = i
+		}
+	}
 	if atIdx == -1 {
 		return "", "", fmt.Errorf("attribute path must contain '@' separator: %s", path)
 	}
 
-	objectPath = path[:atIdx]
-	attrName = path[atIdx+1:]
-
+	attrName = unescapeAttrPath(path[atIdx+1:])
 	if attrName == "" {
 		return "", "", fmt.Errorf("attribute name cannot be empty: %s", path)
 	}
+	return unescapeAttrPath(path[:atIdx]), attrName, nil
+}
 
-	// Handle root case: "/@attr" -> objectPath should be "/"
-	if objectPath == "" {
-		objectPath = "/"
+// unescapeAttrPath replaces the escapes `\@` and `\\` in s with '@' and a
+// backslash.
+func unescapeAttrPath(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
 	}
-
-	// Normalize object path
-	if !strings.HasPrefix(objectPath, "/") {
-		objectPath = "/" + objectPath
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && (s[i+1] == '@' || s[i+1] == '\\') {
+			i++
+		}
+		b.WriteByte(s[i])
 	}
-
-	return objectPath, attrName, nil
+	return b.String()
 }
 
+// attrPathEscaper escapes the characters ParseAttrPath treats specially.
+var attrPathEscaper = strings.NewReplacer(`\`, `\\`, "@", `\@`)
+
 // JoinAttrPath creates an attribute path from object path and attribute name.
+// Any '@' or backslash in either is escaped, so that ParseAttrPath returns
+// them unchanged.
 func JoinAttrPath(objectPath, attrName string) string {
+	attrName = attrPathEscaper.Replace(attrName)
 	if objectPath == "/" {
 		return "/@" + attrName
 	}
-	return objectPath + "@" + attrName
+	return attrPathEscaper.Replace(objectPath) + "@" + attrName
 }
 
 // SplitPath splits a path into its components.
 // Leading and trailing slashes are handled, empty components are removed.
+// Components are names taken as they are: "." and ".." are not resolved,
+// so "..data" or a name with spaces or non-ASCII characters splits like
+// any other.
 //
 // Examples:
 //   - "/" -> []string{}
 //   - "/foo" -> []string{"foo"}
 //   - "/foo/bar" -> []string{"foo", "bar"}
+//   - "foo//bar" -> []string{"foo", "bar"}
 func SplitPath(path string) []string {
-	path = strings.Trim(path, "/")
-	if path == "" {
-		return []string{}
+	parts := []string{}
+	for _, part := range strings.Split(path, "/") {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// joinPath returns the path of the member name of the group at dir. Unlike
+// path.Join it does not clean the result, so members named "." or ".."
+// keep paths of their own.
+func joinPath(dir, name string) string {
+	if dir == "/" {
+		return "/" + name
 	}
-	return strings.Split(path, "/")
+	return dir + "/" + name
 }
 
 // CleanPath normalizes a path, ensuring it starts with "/" and has no trailing slash.
@@ -0,0 +1,239 @@
+package hdf5
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/robert-malhotra/go-hdf5/internal/dtype"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// readTarget is where Read converts a dataset's values: the destination
+// itself, or a flat slice that is then reshaped into nested slices or
+// unwrapped into a single value.
+type readTarget struct {
+	dest   reflect.Value // The value dest points to
+	flat   interface{}   // Pointer handed to the converter
+	shape  []uint64      // Dimensions to reshape the flat slice into
+	scalar bool          // Whether dest receives the one element of the flat slice
+}
+
+// readTarget checks that dest can hold n values of the dataset, selected
+// with the given shape, and decides how to convert into it. Checks apply to
+// numeric, string, enum, compound, array and opaque data; sequence and
+// reference destinations are left to the converter.
+func (d *Dataset) readTarget(dest interface{}, n uint64, shape []uint64) (*readTarget, error) {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil, fmt.Errorf("dest must be a non-nil pointer, got %T", dest)
+	}
+	target := &readTarget{dest: v.Elem(), flat: dest}
+	if !scalarClass(d.datatype) {
+		single, err := checkComposite(d.datatype, target.dest.Type())
+		if err != nil {
+			return nil, fmt.Errorf("%w: dataset is %s, destination is %v", ErrTypeMismatch, describeElem(d.datatype), target.dest.Type())
+		}
+		if single && n != 1 && target.dest.Kind() != reflect.Interface {
+			return nil, fmt.Errorf("cannot read %d elements into %v; pass a pointer to a slice", n, target.dest.Type())
+		}
+		return target, nil
+	}
+
+	depth, leaf := 0, target.dest.Type()
+	for leaf.Kind() == reflect.Slice {
+		depth++
+		leaf = leaf.Elem()
+	}
+	if err := checkElemType(d.datatype, leaf); err != nil {
+		return nil, fmt.Errorf("%w: dataset is %s, destination is %v", ErrTypeMismatch, describeElem(d.datatype), target.dest.Type())
+	}
+
+	switch {
+	case depth == 0:
+		if n != 1 {
+			return nil, fmt.Errorf("cannot read %d elements into %v; pass a pointer to a slice", n, target.dest.Type())
+		}
+		target.scalar = true
+	case depth > 1:
+		if depth != len(shape) {
+			return nil, fmt.Errorf("destination %v has %d dimensions but the selection has %d; read into a flat slice and use Shape",
+				target.dest.Type(), depth, len(shape))
+		}
+		target.shape = shape
+	default:
+		return target, nil
+	}
+	target.flat = reflect.New(reflect.SliceOf(leaf)).Interface()
+	return target, nil
+}
+
+// checkDest checks that the elements of the slice, or the value, dest
+// points to can hold every value of dt, for attribute reads that convert
+// into dest directly.
+func checkDest(dt *message.Datatype, dest interface{}) error {
+	if dest == nil {
+		return nil
+	}
+	if !scalarClass(dt) {
+		typ := reflect.TypeOf(dest)
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if _, err := checkComposite(dt, typ); err != nil {
+			return fmt.Errorf("%w: values are %s, destination is %T", ErrTypeMismatch, describeElem(dt), dest)
+		}
+		return nil
+	}
+	leaf := reflect.TypeOf(dest)
+	for leaf.Kind() == reflect.Ptr || leaf.Kind() == reflect.Slice {
+		leaf = leaf.Elem()
+	}
+	if err := checkElemType(dt, leaf); err != nil {
+		return fmt.Errorf("%w: values are %s, destination is %T", ErrTypeMismatch, describeElem(dt), dest)
+	}
+	return nil
+}
+
+// finish moves values converted into a separate flat slice into dest.
+func (t *readTarget) finish() {
+	flat := reflect.ValueOf(t.flat).Elem()
+	switch {
+	case t.scalar:
+		if flat.Len() > 0 {
+			t.dest.Set(flat.Index(0))
+		}
+	case t.shape != nil:
+		t.dest.Set(reshape(flat, t.dest.Type(), t.shape))
+	}
+}
+
+// reshape splits a flat slice in row-major order into nested slices of
+// type typ with the given dimensions.
+func reshape(flat reflect.Value, typ reflect.Type, shape []uint64) reflect.Value {
+	if len(shape) == 1 {
+		return flat
+	}
+	n := int(shape[0])
+	stride := flat.Len() / max(n, 1)
+	out := reflect.MakeSlice(typ, n, n)
+	for i := 0; i < n; i++ {
+		out.Index(i).Set(reshape(flat.Slice(i*stride, (i+1)*stride), typ.Elem(), shape[1:]))
+	}
+	return out
+}
+
+// scalarClass reports whether each element of dt converts to a single Go
+// number or string.
+func scalarClass(dt *message.Datatype) bool {
+	switch dt.Class {
+	case message.ClassFixedPoint, message.ClassFloatPoint, message.ClassString,
+		message.ClassEnum, message.ClassBitfield, message.ClassTime:
+		return true
+	case message.ClassVarLen:
+		return dt.IsVarLenString
+	}
+	return false
+}
+
+// checkComposite checks that typ can hold the values of dt, a datatype
+// outside scalarClass: as a slice of elements, or as one element, which
+// single reports. Compound elements go into structs, into
+// map[string]interface{} or, in a [][]byte, as raw bytes; array elements
+// into slices of their base type; opaque elements into []byte. Any element
+// fits an interface{}, and sequences and references are left to the
+// converter.
+func checkComposite(dt *message.Datatype, typ reflect.Type) (single bool, err error) {
+	if typ.Kind() == reflect.Slice && compositeElem(dt, typ.Elem(), false) {
+		return false, nil
+	}
+	if compositeElem(dt, typ, true) {
+		return true, nil
+	}
+	return false, fmt.Errorf("cannot hold %v", typ)
+}
+
+// compositeElem reports whether elem can hold one value of dt, as the whole
+// destination when top is set and as the element of a slice otherwise. A
+// []byte destination is a slice of numbers, not one raw compound element.
+func compositeElem(dt *message.Datatype, elem reflect.Type, top bool) bool {
+	if elem.Kind() == reflect.Interface {
+		return true
+	}
+	bytes := elem.Kind() == reflect.Slice && elem.Elem().Kind() == reflect.Uint8
+	switch dt.Class {
+	case message.ClassCompound:
+		return elem.Kind() == reflect.Struct || elem == reflect.TypeOf(map[string]interface{}{}) || bytes && !top
+	case message.ClassArray:
+		base, err := dtype.GoType(dt.BaseType)
+		return err == nil && elem == reflect.SliceOf(base)
+	case message.ClassOpaque:
+		return bytes
+	}
+	return true
+}
+
+// checkElemType checks that elem can hold every value of dt. Integers may
+// widen, unsigned integers may become larger signed ones, and floats may
+// widen. Integers convert to float64, and those of up to 16 bits to
+// float32, as numpy's safe casting allows. Enums also read as the names
+// of their members, and times as the signed integers they are stored as.
+func checkElemType(dt *message.Datatype, elem reflect.Type) error {
+	if elem.Kind() == reflect.Interface {
+		return nil
+	}
+	if dt.Class == message.ClassTime {
+		dt = dtype.TimeInteger(dt)
+	}
+	kind, size := elem.Kind(), int(elem.Size())
+	mismatch := fmt.Errorf("cannot hold %v", elem)
+
+	switch dt.Class {
+	case message.ClassString, message.ClassVarLen:
+		if kind == reflect.String {
+			return nil
+		}
+		return mismatch
+
+	case message.ClassFloatPoint:
+		if (kind == reflect.Float32 || kind == reflect.Float64) && size >= int(dt.Size) {
+			return nil
+		}
+		return mismatch
+	}
+
+	// Integers: fixed-point, bitfield and enum values
+	srcSize, signed := int(dt.Size), dt.Class == message.ClassFixedPoint && dt.Signed
+	if dt.Class == message.ClassEnum {
+		if kind == reflect.String {
+			return nil
+		}
+		if dt.BaseType != nil {
+			srcSize, signed = int(dt.BaseType.Size), dt.BaseType.Signed
+		}
+	}
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if size > srcSize || size == srcSize && signed {
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if !signed && size >= srcSize {
+			return nil
+		}
+	case reflect.Float64:
+		return nil
+	case reflect.Float32:
+		if srcSize <= 2 {
+			return nil
+		}
+	}
+	return mismatch
+}
+
+// describeElem names the Go type matching dt, for error messages.
+func describeElem(dt *message.Datatype) string {
+	if t, err := dtype.GoType(dt); err == nil {
+		return t.String()
+	}
+	return newDtypeInfo(dt).String()
+}
@@ -0,0 +1,317 @@
+package hdf5
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+func TestReadDestinations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dest.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	grid := [][]float64{{1, 2, 3}, {4, 5, 6}}
+	for name, data := range map[string]interface{}{
+		"int16":  []int16{-1, 2, math.MaxInt16},
+		"uint32": []uint32{0, 7, math.MaxUint32},
+	} {
+		if _, err := f.Root().CreateDataset(name, data); err != nil {
+			t.Fatalf("CreateDataset %s failed: %v", name, err)
+		}
+	}
+	f64 := message.NewFloatDatatype(8, message.OrderLE)
+	writeObject(t, f, "grid", object.NewDatasetHeader(message.NewDataspace([]uint64{2, 3}, nil),
+		f64, message.NewCompactLayout(float64Bytes(1, 2, 3, 4, 5, 6))))
+	writeObject(t, f, "scalar", object.NewDatasetHeader(message.NewScalarDataspace(),
+		f64, message.NewCompactLayout(float64Bytes(2.5))))
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	open := func(name string) *Dataset {
+		t.Helper()
+		ds, err := f.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset %s failed: %v", name, err)
+		}
+		return ds
+	}
+
+	// Lossless conversions succeed and lossy ones fail before reading
+	tests := []struct {
+		name string
+		dest interface{}
+		want interface{} // nil when the read must fail with ErrTypeMismatch
+	}{
+		{"grid", new([]float64), []float64{1, 2, 3, 4, 5, 6}},
+		{"grid", new([]interface{}), []interface{}{1.0, 2.0, 3.0, 4.0, 5.0, 6.0}},
+		{"grid", new([]float32), nil},
+		{"grid", new([]int32), nil},
+		{"grid", new([]string), nil},
+		{"int16", new([]int16), []int16{-1, 2, math.MaxInt16}},
+		{"int16", new([]int64), []int64{-1, 2, math.MaxInt16}},
+		{"int16", new([]float32), []float32{-1, 2, math.MaxInt16}},
+		{"int16", new([]float64), []float64{-1, 2, math.MaxInt16}},
+		{"int16", new([]int8), nil},
+		{"int16", new([]uint16), nil},
+		{"uint32", new([]uint64), []uint64{0, 7, math.MaxUint32}},
+		{"uint32", new([]int64), []int64{0, 7, math.MaxUint32}},
+		{"uint32", new([]float64), []float64{0, 7, math.MaxUint32}},
+		{"uint32", new([]int32), nil},
+		{"uint32", new([]float32), nil},
+	}
+	for _, tt := range tests {
+		err := open(tt.name).Read(tt.dest)
+		got := reflect.ValueOf(tt.dest).Elem().Interface()
+		if tt.want == nil {
+			if !errors.Is(err, ErrTypeMismatch) {
+				t.Errorf("%s into %T: error = %v, want ErrTypeMismatch", tt.name, tt.dest, err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s into %T = %v, %v, want %v", tt.name, tt.dest, got, err, tt.want)
+		}
+	}
+	var ints []int32
+	if err := open("grid").Read(&ints); err == nil || !strings.Contains(err.Error(), "dataset is float64, destination is []int32") {
+		t.Errorf("mismatch error = %v", err)
+	}
+
+	// Nested slices of the dataset's rank
+	var nested [][]float64
+	if err := open("grid").Read(&nested); err != nil || !reflect.DeepEqual(nested, grid) {
+		t.Errorf("Read into [][]float64 = %v, %v, want %v", nested, err, grid)
+	}
+	var deep [][][]float64
+	if err := open("grid").Read(&deep); err == nil || !strings.Contains(err.Error(), "flat slice") {
+		t.Errorf("Read into [][][]float64 error = %v, want a rank mismatch", err)
+	}
+	var rows [][]float64
+	if err := open("int16").Read(&rows); err == nil {
+		t.Error("Read of a 1-D dataset into [][]float64 succeeded")
+	}
+
+	// Single values
+	var v float64
+	if err := open("scalar").Read(&v); err != nil || v != 2.5 {
+		t.Errorf("Read into *float64 = %v, %v, want 2.5", v, err)
+	}
+	if err := open("grid").Read(&v); err == nil {
+		t.Error("Read of 6 elements into *float64 succeeded")
+	}
+	var n int32
+	if err := open("scalar").Read(&n); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("Read of float64 into *int32 error = %v, want ErrTypeMismatch", err)
+	}
+
+	if err := open("grid").Read(nil); err == nil {
+		t.Error("Read accepted a nil destination")
+	}
+	if err := open("grid").Read([]float64{}); err == nil {
+		t.Error("Read accepted a slice that is not a pointer")
+	}
+}
+
+// TestReadCompositeDestinations reads compound, array and opaque datasets
+// into destinations that can hold their elements and into ones that cannot.
+func TestReadCompositeDestinations(t *testing.T) {
+	type row struct {
+		A int32
+		B float64
+	}
+	path := filepath.Join(t.TempDir(), "composite.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("rows", []row{{1, 0.5}, {2, 1.5}}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	pair := message.NewArrayDatatype([]uint32{2}, message.NewFloatDatatype(8, message.OrderLE))
+	writeObject(t, f, "pairs", object.NewDatasetHeader(message.NewDataspace([]uint64{2}, nil),
+		pair, message.NewCompactLayout(float64Bytes(1, 2, 3, 4))))
+	writeObject(t, f, "blobs", object.NewDatasetHeader(message.NewDataspace([]uint64{2}, nil),
+		message.NewOpaqueDatatype(3, "raw"), message.NewCompactLayout([]byte{1, 2, 3, 4, 5, 6})))
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	open := func(name string) *Dataset {
+		t.Helper()
+		ds, err := f.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset %s failed: %v", name, err)
+		}
+		return ds
+	}
+
+	tests := []struct {
+		name string
+		dest interface{}
+		want interface{} // nil when the read must fail with ErrTypeMismatch
+	}{
+		{"rows", new([]row), []row{{1, 0.5}, {2, 1.5}}},
+		{"rows", new([]map[string]interface{}), []map[string]interface{}{{"A": int32(1), "B": 0.5}, {"A": int32(2), "B": 1.5}}},
+		{"rows", new([]float64), nil},
+		{"rows", new([]string), nil},
+		{"rows", new([]int16), nil},
+		{"rows", new([]map[string]float64), nil},
+		{"rows", new([]byte), nil},
+		{"pairs", new([][]float64), [][]float64{{1, 2}, {3, 4}}},
+		{"pairs", new([]interface{}), []interface{}{[]float64{1, 2}, []float64{3, 4}}},
+		{"pairs", new([]string), nil},
+		{"pairs", new([][]int32), nil},
+		{"blobs", new([][]byte), [][]byte{{1, 2, 3}, {4, 5, 6}}},
+		{"blobs", new([]int32), nil},
+		{"blobs", new([]string), nil},
+	}
+	for _, tt := range tests {
+		err := open(tt.name).Read(tt.dest)
+		got := reflect.ValueOf(tt.dest).Elem().Interface()
+		if tt.want == nil {
+			if !errors.Is(err, ErrTypeMismatch) {
+				t.Errorf("%s into %T: error = %v, want ErrTypeMismatch", tt.name, tt.dest, err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s into %T = %v, %v, want %v", tt.name, tt.dest, got, err, tt.want)
+		}
+	}
+
+	// One struct, map or array element cannot hold two elements
+	for _, c := range []struct {
+		name string
+		dest interface{}
+	}{
+		{"rows", new(row)},
+		{"rows", new(map[string]interface{})},
+		{"pairs", new([]float64)},
+	} {
+		if err := open(c.name).Read(c.dest); err == nil || !strings.Contains(err.Error(), "pointer to a slice") {
+			t.Errorf("%s into %T: error = %v, want a request for a slice", c.name, c.dest, err)
+		}
+	}
+}
+
+func TestReadIntegerHelpers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ints.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	enum := message.NewEnumDatatype(message.NewFixedPointDatatype(1, false, message.OrderLE),
+		[]string{"OFF", "ON"}, []int64{0, 1})
+	writeObject(t, f, "enum", object.NewDatasetHeader(message.NewDataspace([]uint64{3}, nil),
+		enum, message.NewCompactLayout([]byte{1, 0, 1})))
+	for name, value := range map[string]interface{}{
+		"int16":  []int16{-1, 2},
+		"uint16": []uint16{1, math.MaxUint16},
+		"int64":  []int64{1, math.MaxInt64},
+		"uint8":  []uint8{3, 4},
+		"point":  struct{ X, Y int16 }{1, 2},
+		"points": []struct{ X, Y int16 }{{1, 2}, {3, 4}},
+	} {
+		if err := f.Root().SetAttr(name, value); err != nil {
+			t.Fatalf("SetAttr %s failed: %v", name, err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	// Enums read as their underlying integers
+	ds, err := f.OpenDataset("enum")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if got, err := ds.ReadUint8(); err != nil || !reflect.DeepEqual(got, []uint8{1, 0, 1}) {
+		t.Errorf("enum ReadUint8 = %v, %v", got, err)
+	}
+	if got, err := ds.ReadInt16(); err != nil || !reflect.DeepEqual(got, []int16{1, 0, 1}) {
+		t.Errorf("enum ReadInt16 = %v, %v", got, err)
+	}
+	if _, err := ds.ReadInt8(); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("enum ReadInt8 error = %v, want ErrTypeMismatch", err)
+	}
+
+	// Attributes widen like datasets and fail rather than truncate
+	attr := func(name string) *Attribute {
+		t.Helper()
+		a := f.Root().Attr(name)
+		if a == nil {
+			t.Fatalf("attribute %s not found", name)
+		}
+		return a
+	}
+	if got, err := attr("int16").ReadInt16(); err != nil || !reflect.DeepEqual(got, []int16{-1, 2}) {
+		t.Errorf("int16 ReadInt16 = %v, %v", got, err)
+	}
+	if got, err := attr("int16").ReadInt32(); err != nil || !reflect.DeepEqual(got, []int32{-1, 2}) {
+		t.Errorf("int16 ReadInt32 = %v, %v", got, err)
+	}
+	if got, err := attr("uint16").ReadUint16(); err != nil || !reflect.DeepEqual(got, []uint16{1, math.MaxUint16}) {
+		t.Errorf("uint16 ReadUint16 = %v, %v", got, err)
+	}
+	if got, err := attr("uint16").ReadUint64(); err != nil || !reflect.DeepEqual(got, []uint64{1, math.MaxUint16}) {
+		t.Errorf("uint16 ReadUint64 = %v, %v", got, err)
+	}
+	if got, err := attr("uint8").ReadUint8(); err != nil || !reflect.DeepEqual(got, []uint8{3, 4}) {
+		t.Errorf("uint8 ReadUint8 = %v, %v", got, err)
+	}
+	if got, err := attr("uint8").ReadUint32(); err != nil || !reflect.DeepEqual(got, []uint32{3, 4}) {
+		t.Errorf("uint8 ReadUint32 = %v, %v", got, err)
+	}
+	for _, read := range []func() error{
+		func() error { _, err := attr("int64").ReadInt32(); return err },
+		func() error { _, err := attr("int16").ReadInt8(); return err },
+		func() error { _, err := attr("int16").ReadUint16(); return err },
+		func() error { _, err := attr("uint16").ReadInt16(); return err },
+	} {
+		if err := read(); !errors.Is(err, ErrTypeMismatch) {
+			t.Errorf("lossy read error = %v, want ErrTypeMismatch", err)
+		}
+	}
+
+	// Compound attributes hold no numbers
+	for _, name := range []string{"point", "points"} {
+		if got, err := attr(name).ReadInt16(); !errors.Is(err, ErrTypeMismatch) {
+			t.Errorf("%s ReadInt16 = %v, %v, want ErrTypeMismatch", name, got, err)
+		}
+		if got, err := attr(name).ReadUint8(); !errors.Is(err, ErrTypeMismatch) {
+			t.Errorf("%s ReadUint8 = %v, %v, want ErrTypeMismatch", name, got, err)
+		}
+		var floats []float64
+		if err := attr(name).Read(&floats); !errors.Is(err, ErrTypeMismatch) {
+			t.Errorf("%s Read into []float64 = %v, %v, want ErrTypeMismatch", name, floats, err)
+		}
+	}
+	if got, err := attr("points").ReadCompound(); err != nil || len(got) != 2 || got[1]["Y"] != int16(4) {
+		t.Errorf("points ReadCompound = %v, %v", got, err)
+	}
+}
@@ -0,0 +1,120 @@
+package hdf5
+
+import (
+	"fmt"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// Reference is an HDF5 object reference: the address of the referenced
+// object's header in the file. Use File.Dereference to open the object.
+type Reference uint64
+
+// IsNull reports whether the reference points at no object. HDF5 writes
+// null references as address zero or as the undefined address.
+func (r Reference) IsNull() bool {
+	return r == 0 || r == Reference(^uint64(0))
+}
+
+// String returns the referenced address in hexadecimal.
+func (r Reference) String() string {
+	if r.IsNull() {
+		return "<null reference>"
+	}
+	return fmt.Sprintf("<object at %#x>", uint64(r))
+}
+
+// ReadReferences reads a dataset of object references. Region references
+// are not supported.
+func (d *Dataset) ReadReferences() ([]Reference, error) {
+	if !d.datatype.IsObjectReference() {
+		return nil, fmt.Errorf("%w: %s is not an object reference dataset", ErrUnsupported, d.path)
+	}
+	var refs []Reference
+	if err := d.Read(&refs); err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+// ReadReferences reads an attribute of object references. Region
+// references are not supported.
+func (a *Attribute) ReadReferences() ([]Reference, error) {
+	if a.msg.Datatype == nil || !a.msg.Datatype.IsObjectReference() {
+		return nil, fmt.Errorf("%w: attribute %q is not an object reference", ErrUnsupported, a.msg.Name)
+	}
+	var refs []Reference
+	if err := a.Read(&refs); err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+// Dereference opens the object a reference points to, returning a *Dataset
+// or a *Group. The object's path is found by searching the file's hard
+// links from the root group; an object that no link reaches gets an empty
+// path.
+//
+// Example:
+//
+//	refs, err := ds.ReadReferences()
+//	obj, err := f.Dereference(refs[0])
+//	if target, ok := obj.(*hdf5.Dataset); ok {
+//		data, err := target.ReadFloat64()
+//	}
+func (f *File) Dereference(ref Reference) (interface{}, error) {
+	if f.closed {
+		return nil, ErrClosed
+	}
+	if ref.IsNull() {
+		return nil, fmt.Errorf("%w: null reference", ErrNotFound)
+	}
+	addr := uint64(ref)
+
+	header, err := f.readHeader(addr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: reading referenced object at %#x: %v", ErrNotFound, addr, err)
+	}
+	objPath := f.pathOf(addr)
+	if header.GetMessage(message.TypeDataspace) != nil {
+		return f.openDatasetAt(addr, objPath)
+	}
+	return f.openGroupAt(addr, objPath)
+}
+
+// pathOf returns the path of the first hard link to the object at addr,
+// searching breadth-first from the root group, or "" if none leads there.
+func (f *File) pathOf(addr uint64) string {
+	if addr == f.root.addr {
+		return "/"
+	}
+
+	visited := map[uint64]bool{f.root.addr: true}
+	queue := []*Group{f.root}
+	for len(queue) > 0 {
+		g := queue[0]
+		queue = queue[1:]
+
+		children, err := g.hardLinkedChildren()
+		if err != nil {
+			continue
+		}
+		for _, child := range children {
+			childPath := joinPath(g.path, child.name)
+			if child.address == addr {
+				return childPath
+			}
+			if visited[child.address] {
+				continue
+			}
+			visited[child.address] = true
+			if kind, err := g.objectKind(child.address); err != nil || kind != ObjectTypeGroup {
+				continue
+			}
+			if sub, err := f.openGroupAt(child.address, childPath); err == nil {
+				queue = append(queue, sub)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+package hdf5
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+func TestObjectReferences(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "references.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("a", []float64{1, 2, 3}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	grp, err := f.Root().CreateGroup("grp")
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	if _, err := grp.CreateDataset("b", []int32{7, 8}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	refs, err := f.Root().CreateDatasetWithType("refs", []uint64{4}, message.NewObjectReferenceDatatype(8))
+	if err != nil {
+		t.Fatalf("CreateDatasetWithType failed: %v", err)
+	}
+	refsData := int64(refs.dataAddr)
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Headers move as links are added, so look the targets up afterwards
+	// and patch the references into the file
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	addrA := datasetAddress(t, f, "a")
+	addrGrp := datasetAddress(t, f, "grp")
+	g, err := f.OpenGroup("grp")
+	if err != nil {
+		t.Fatalf("OpenGroup failed: %v", err)
+	}
+	res, err := g.findChildFull("b", g.file.newLinkChain())
+	if err != nil {
+		t.Fatalf("resolving grp/b: %v", err)
+	}
+	f.Close()
+
+	raw := make([]byte, 32)
+	binary.LittleEndian.PutUint64(raw[0:], addrA)
+	binary.LittleEndian.PutUint64(raw[8:], res.address)
+	binary.LittleEndian.PutUint64(raw[16:], addrGrp)
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("opening for patching: %v", err)
+	}
+	if _, err := file.WriteAt(raw, refsData); err != nil {
+		t.Fatalf("patching references: %v", err)
+	}
+	file.Close()
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	ds, err := f.OpenDataset("refs")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	got, err := ds.ReadReferences()
+	if err != nil {
+		t.Fatalf("ReadReferences failed: %v", err)
+	}
+	if len(got) != 4 || !got[3].IsNull() || got[0].IsNull() {
+		t.Fatalf("ReadReferences = %v, want three references and a null", got)
+	}
+
+	obj, err := f.Dereference(got[0])
+	if err != nil {
+		t.Fatalf("Dereference a failed: %v", err)
+	}
+	a, ok := obj.(*Dataset)
+	if !ok {
+		t.Fatalf("Dereference a = %T, want *Dataset", obj)
+	}
+	if vals, err := a.ReadFloat64(); err != nil || len(vals) != 3 || vals[2] != 3 {
+		t.Errorf("a = %v, %v; want [1 2 3]", vals, err)
+	}
+	if a.Path() != "/a" {
+		t.Errorf("a.Path() = %q, want /a", a.Path())
+	}
+
+	obj, err = f.Dereference(got[1])
+	if err != nil {
+		t.Fatalf("Dereference grp/b failed: %v", err)
+	}
+	if b, ok := obj.(*Dataset); !ok || b.Path() != "/grp/b" {
+		t.Errorf("Dereference grp/b = %#v, want dataset /grp/b", obj)
+	}
+
+	obj, err = f.Dereference(got[2])
+	if err != nil {
+		t.Fatalf("Dereference grp failed: %v", err)
+	}
+	if group, ok := obj.(*Group); !ok || group.Path() != "/grp" {
+		t.Errorf("Dereference grp = %#v, want group /grp", obj)
+	}
+
+	if _, err := f.Dereference(got[3]); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Dereference null: err = %v, want ErrNotFound", err)
+	}
+	if _, err := f.Dereference(Reference(3)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Dereference garbage: err = %v, want ErrNotFound", err)
+	}
+
+	plain, err := f.OpenDataset("a")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if _, err := plain.ReadReferences(); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("ReadReferences on float64 data: err = %v, want ErrUnsupported", err)
+	}
+}
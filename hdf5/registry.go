@@ -0,0 +1,63 @@
+package hdf5
+
+import (
+	"runtime"
+	"sync"
+	"weak"
+)
+
+// datasetRegistry maps object addresses to the state of datasets that are
+// currently open, so that repeated opens of one object share a single
+// datasetState. Entries are weak: once every handle to a dataset is
+// unreachable its state is collected and the entry removed.
+type datasetRegistry struct {
+	mu      sync.Mutex
+	entries map[uint64]weak.Pointer[datasetState]
+}
+
+// lookup returns the live state registered for address, or nil.
+func (r *datasetRegistry) lookup(address uint64) *datasetState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.entries[address].Value()
+}
+
+// register records state for address and returns the state to use: if
+// another goroutine registered the same object first, its state wins.
+func (r *datasetRegistry) register(address uint64, state *datasetState) *datasetState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing := r.entries[address].Value(); existing != nil {
+		return existing
+	}
+	if r.entries == nil {
+		r.entries = make(map[uint64]weak.Pointer[datasetState])
+	}
+	wp := weak.Make(state)
+	r.entries[address] = wp
+	runtime.AddCleanup(state, r.remove, registryKey{address, wp})
+	return state
+}
+
+// registryKey identifies one registration, so that a stale cleanup does not
+// remove a newer entry for the same address.
+type registryKey struct {
+	address uint64
+	ptr     weak.Pointer[datasetState]
+}
+
+func (r *datasetRegistry) remove(key registryKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.entries[key.address] == key.ptr {
+		delete(r.entries, key.address)
+	}
+}
+
+// size returns the number of registered entries, live or not yet cleaned up.
+func (r *datasetRegistry) size() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.entries)
+}
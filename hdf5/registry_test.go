@@ -0,0 +1,120 @@
+package hdf5
+
+import (
+	"io"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/binary"
+)
+
+// countingReaderAt counts reads starting at a watched offset.
+type countingReaderAt struct {
+	r     io.ReaderAt
+	watch int64
+
+	mu   sync.Mutex
+	hits int
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off == c.watch {
+		c.mu.Lock()
+		c.hits++
+		c.mu.Unlock()
+	}
+	return c.r.ReadAt(p, off)
+}
+
+func (c *countingReaderAt) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits
+}
+
+func TestDatasetHandlesShareState(t *testing.T) {
+	path := skipIfNoTestdata(t, "chunked.h5")
+
+	// Find the chunk index address, then reopen through a counting reader
+	pf, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	probe, err := pf.OpenDataset("/chunked")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	indexAddr := probe.header.DataLayout().ChunkIndexAddr
+	pf.Close()
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	counter := &countingReaderAt{r: f.file, watch: int64(indexAddr)}
+	f.reader = binary.NewReader(counter, f.superblock.ReaderConfig())
+
+	a, err := f.OpenDataset("/chunked")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	b, err := f.OpenDataset("chunked")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if a.datasetState != b.datasetState {
+		t.Fatal("repeated opens did not share dataset state")
+	}
+
+	dataA, err := a.ReadFloat64()
+	if err != nil {
+		t.Fatalf("ReadFloat64 failed: %v", err)
+	}
+	loads := counter.count()
+	if loads == 0 {
+		t.Fatal("counting reader saw no chunk index reads")
+	}
+
+	dataB, err := b.ReadFloat64()
+	if err != nil {
+		t.Fatalf("ReadFloat64 failed: %v", err)
+	}
+	if _, err := b.ReadSliceRaw([]uint64{0, 0}, []uint64{2, 2}); err != nil {
+		t.Fatalf("ReadSliceRaw failed: %v", err)
+	}
+	if got := counter.count(); got != loads {
+		t.Errorf("chunk index read %d times after second handle, want %d", got, loads)
+	}
+	if len(dataA) != len(dataB) {
+		t.Errorf("handles returned %d and %d values", len(dataA), len(dataB))
+	}
+}
+
+func TestDatasetRegistryReleasesState(t *testing.T) {
+	path := skipIfNoTestdata(t, "minimal.h5")
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	ds, err := f.OpenDataset("/data")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if _, err := ds.ReadFloat64(); err != nil {
+		t.Fatalf("ReadFloat64 failed: %v", err)
+	}
+	ds = nil
+
+	for i := 0; i < 10 && f.datasets.size() > 0; i++ {
+		runtime.GC()
+		runtime.Gosched()
+	}
+	if n := f.datasets.size(); n != 0 {
+		t.Errorf("registry still holds %d entries after handles were dropped", n)
+	}
+}
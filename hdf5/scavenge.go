@@ -0,0 +1,257 @@
+package hdf5
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+
+	binpkg "github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+	"github.com/robert-malhotra/go-hdf5/internal/superblock"
+)
+
+// OrphanObject is an object header found by Scavenge. Nothing is known about
+// where, or whether, it is linked into the group hierarchy; open it with
+// File.OpenDatasetAt or File.OpenGroupAt.
+type OrphanObject struct {
+	Address uint64     // Object header address
+	Kind    ObjectType // Dataset, group, or unknown (e.g. a named datatype)
+
+	// Dataset description; zero for other kinds
+	Shape      []uint64
+	DtypeClass message.DatatypeClass
+	DtypeSize  int
+}
+
+// ScavengeOption configures Scavenge.
+type ScavengeOption func(*scavengeOptions)
+
+type scavengeOptions struct {
+	progress func(scanned, total int64)
+}
+
+// WithScavengeProgress calls fn after each block of the file is scanned with
+// the number of bytes scanned so far and the total size.
+func WithScavengeProgress(fn func(scanned, total int64)) ScavengeOption {
+	if fn == nil {
+		panic("hdf5: WithScavengeProgress requires a non-nil callback")
+	}
+	return func(o *scavengeOptions) {
+		o.progress = fn
+	}
+}
+
+const (
+	// scavengeBlockSize is how much of the file is scanned per read.
+	scavengeBlockSize = 1 << 20
+
+	// scavengeOverlap extends each read so that signatures and v1 header
+	// prefixes straddling a block boundary are still seen whole.
+	scavengeOverlap = 20
+)
+
+// Scavenge recovers objects from a damaged file by scanning its first size
+// bytes for object headers instead of following links from the root group.
+// It finds v2 headers by their "OHDR" signature, the children listed in v1
+// symbol table nodes ("SNOD"), and v1 headers by a heuristic check of their
+// prefix. Every candidate is parsed; candidates that fail to parse, and
+// datasets whose dataspace, datatype or layout cannot be decoded, are
+// skipped, so false positives in raw data are harmless. Results are sorted by
+// address.
+//
+// The superblock is used for the file's offset and length sizes if it is
+// intact; otherwise 8-byte sizes are assumed.
+func Scavenge(r io.ReaderAt, size int64, opts ...ScavengeOption) ([]OrphanObject, error) {
+	options := &scavengeOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	cfg := binpkg.DefaultConfig()
+	sb, err := superblock.Read(r)
+	if err == nil {
+		cfg = sb.ReaderConfig()
+	}
+	s := &scavenger{
+		file: &File{reader: binpkg.NewReader(r, cfg), superblock: sb},
+		seen: make(map[uint64]bool),
+	}
+
+	buf := make([]byte, scavengeBlockSize+scavengeOverlap)
+	for start := int64(0); start < size; start += scavengeBlockSize {
+		n := int64(len(buf))
+		if start+n > size {
+			n = size - start
+		}
+		block := buf[:n]
+		if _, err := r.ReadAt(block, start); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("reading at %d: %w", start, err)
+		}
+
+		scanEnd := int64(scavengeBlockSize)
+		if scanEnd > n {
+			scanEnd = n
+		}
+		s.scanBlock(block, start, int(scanEnd))
+
+		if options.progress != nil {
+			options.progress(start+scanEnd, size)
+		}
+	}
+
+	sort.Slice(s.found, func(i, j int) bool { return s.found[i].Address < s.found[j].Address })
+	return s.found, nil
+}
+
+// scavenger holds the state of one Scavenge call.
+type scavenger struct {
+	file  *File
+	seen  map[uint64]bool
+	found []OrphanObject
+}
+
+var (
+	sigOHDR = []byte("OHDR")
+	sigSNOD = []byte("SNOD")
+)
+
+// scanBlock looks for candidates starting in block[:end]; block starts at
+// file offset start and may extend past end into the overlap.
+func (s *scavenger) scanBlock(block []byte, start int64, end int) {
+	for _, pos := range signatureOffsets(block, end, sigOHDR) {
+		s.try(uint64(start)+uint64(pos), true)
+	}
+	for _, pos := range signatureOffsets(block, end, sigSNOD) {
+		s.scanSymbolNode(uint64(start) + uint64(pos))
+	}
+	for pos := 0; pos < end; pos++ {
+		if plausibleV1Header(block[pos:]) {
+			s.try(uint64(start)+uint64(pos), false)
+		}
+	}
+}
+
+// signatureOffsets returns every offset below end at which sig occurs.
+func signatureOffsets(block []byte, end int, sig []byte) []int {
+	var offsets []int
+	for pos := 0; pos < end; {
+		i := bytes.Index(block[pos:], sig)
+		if i < 0 || pos+i >= end {
+			break
+		}
+		offsets = append(offsets, pos+i)
+		pos += i + 1
+	}
+	return offsets
+}
+
+// plausibleV1Header applies cheap checks to the 16-byte prefix of a v1
+// object header and the framing of its first message, rejecting nearly all
+// raw data before a full parse is attempted.
+func plausibleV1Header(b []byte) bool {
+	if len(b) < 20 || b[0] != 1 || b[1] != 0 {
+		return false
+	}
+	numMessages := binary.LittleEndian.Uint16(b[2:])
+	refCount := binary.LittleEndian.Uint32(b[4:])
+	headerSize := binary.LittleEndian.Uint32(b[8:])
+	if numMessages == 0 || numMessages > 4096 || refCount == 0 || refCount > 1<<16 {
+		return false
+	}
+	if headerSize < 8 || headerSize > 1<<20 {
+		return false
+	}
+	// The prefix is padded to 16 bytes with zeros
+	if binary.LittleEndian.Uint32(b[12:]) != 0 {
+		return false
+	}
+	msgType := binary.LittleEndian.Uint16(b[16:])
+	msgSize := binary.LittleEndian.Uint16(b[18:])
+	return msgType <= uint16(message.TypeObjectRefCount) && uint32(msgSize) <= headerSize
+}
+
+// scanSymbolNode adds the objects listed in the v1 symbol table node at pos.
+func (s *scavenger) scanSymbolNode(pos uint64) {
+	r := s.file.reader.At(int64(pos) + 4)
+	version, err := r.ReadUint8()
+	if err != nil || version != 1 {
+		return
+	}
+	r.Skip(1) // Reserved
+	count, err := r.ReadUint16()
+	if err != nil {
+		return
+	}
+
+	for i := 0; i < int(count); i++ {
+		if _, err := r.ReadOffset(); err != nil { // Link name offset
+			return
+		}
+		addr, err := r.ReadOffset()
+		if err != nil {
+			return
+		}
+		r.Skip(8 + 16) // Cache type, reserved, scratch pad
+		if !r.IsUndefinedOffset(addr) {
+			s.try(addr, true)
+		}
+	}
+}
+
+// try parses the candidate header at addr. Weak candidates, found only by
+// the v1 heuristic, are kept only if they are datasets or groups.
+func (s *scavenger) try(addr uint64, strong bool) {
+	if s.seen[addr] {
+		return
+	}
+	s.seen[addr] = true
+
+	header, err := object.Read(s.file.reader, addr)
+	if err != nil {
+		return
+	}
+
+	obj := OrphanObject{Address: addr, Kind: ObjectTypeUnknown}
+	switch {
+	case header.Dataspace() != nil:
+		state, err := newDatasetState(s.file, header)
+		if err != nil {
+			return
+		}
+		obj.Kind = ObjectTypeDataset
+		if !state.dataspace.IsScalar() {
+			obj.Shape = state.dataspace.Dimensions
+		}
+		obj.DtypeClass = state.datatype.Class
+		obj.DtypeSize = int(state.datatype.Size)
+	case header.GetMessage(message.TypeSymbolTable) != nil,
+		header.GetMessage(message.TypeLinkInfo) != nil,
+		header.GetMessage(message.TypeLink) != nil:
+		obj.Kind = ObjectTypeGroup
+	case !strong || len(header.Messages) == 0:
+		return
+	}
+
+	s.found = append(s.found, obj)
+}
+
+// OpenDatasetAt opens the dataset whose object header is at address, such as
+// one found by Scavenge, without a path. The returned dataset's Path is empty.
+func (f *File) OpenDatasetAt(address uint64) (*Dataset, error) {
+	if f.closed {
+		return nil, ErrClosed
+	}
+	return f.openDatasetAt(address, "")
+}
+
+// OpenGroupAt opens the group whose object header is at address, such as one
+// found by Scavenge, without a path. The returned group's Path is empty.
+func (f *File) OpenGroupAt(address uint64) (*Group, error) {
+	if f.closed {
+		return nil, ErrClosed
+	}
+	return f.openGroupAt(address, "")
+}
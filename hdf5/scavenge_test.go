@@ -0,0 +1,141 @@
+package hdf5
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// scavengeFile runs Scavenge over the file at path.
+func scavengeFile(t *testing.T, path string, opts ...ScavengeOption) []OrphanObject {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := Scavenge(bytes.NewReader(data), int64(len(data)), opts...)
+	if err != nil {
+		t.Fatalf("Scavenge failed: %v", err)
+	}
+	return found
+}
+
+func countKind(objects []OrphanObject, kind ObjectType) int {
+	n := 0
+	for _, obj := range objects {
+		if obj.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+func TestScavengeFindsAllObjects(t *testing.T) {
+	tests := []struct {
+		file     string
+		datasets int
+		groups   int // Including the root group
+	}{
+		{"groups.h5", 2, 4},
+		{"v0_integers.h5", 2, 1},
+		{"compressed.h5", 2, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			found := scavengeFile(t, skipIfNoTestdata(t, tt.file))
+			if n := countKind(found, ObjectTypeDataset); n != tt.datasets {
+				t.Errorf("found %d datasets, want %d: %+v", n, tt.datasets, found)
+			}
+			if n := countKind(found, ObjectTypeGroup); n != tt.groups {
+				t.Errorf("found %d groups, want %d: %+v", n, tt.groups, found)
+			}
+		})
+	}
+}
+
+func TestScavengeDamagedRootGroup(t *testing.T) {
+	path := copyTestdata(t, "v0_minimal.h5")
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	btreeAddr := f.superblock.RootGroupBTreeAddress
+	want := datasetAddress(t, f, "data")
+	f.Close()
+
+	// Destroy the root group's B-tree, then its symbol table node, so that
+	// only the v1 header heuristic can find the dataset
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(data[btreeAddr:btreeAddr+48], make([]byte, 48))
+	if i := bytes.Index(data, []byte("SNOD")); i >= 0 {
+		copy(data[i:i+4], "XXXX")
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.Root().Members(); err == nil {
+		t.Fatal("Members succeeded on a destroyed B-tree")
+	}
+
+	var calls int
+	var last, total int64
+	found := scavengeFile(t, path, WithScavengeProgress(func(scanned, size int64) {
+		calls++
+		last, total = scanned, size
+	}))
+	if calls == 0 || last != total || total != int64(len(data)) {
+		t.Errorf("progress: %d calls, last %d/%d, want %d", calls, last, total, len(data))
+	}
+
+	var orphan *OrphanObject
+	for i := range found {
+		if found[i].Kind == ObjectTypeDataset {
+			orphan = &found[i]
+		}
+	}
+	if orphan == nil {
+		t.Fatalf("no dataset found: %+v", found)
+	}
+	if orphan.Address != want || len(orphan.Shape) != 1 || orphan.Shape[0] != 4 || orphan.DtypeSize != 8 {
+		t.Errorf("found %+v, want dataset at %d with shape [4] and 8-byte type", *orphan, want)
+	}
+
+	ds, err := f.OpenDatasetAt(orphan.Address)
+	if err != nil {
+		t.Fatalf("OpenDatasetAt failed: %v", err)
+	}
+	values, err := ds.ReadFloat64()
+	if err != nil {
+		t.Fatalf("ReadFloat64 failed: %v", err)
+	}
+	if len(values) != 4 || values[0] != 1 || values[3] != 4 {
+		t.Errorf("recovered %v, want [1 2 3 4]", values)
+	}
+}
+
+func TestScavengeIgnoresFalsePositives(t *testing.T) {
+	// Signatures and v1-like prefixes in data that is not an HDF5 file
+	data := make([]byte, 4096)
+	copy(data[100:], "OHDR\x02\x00garbage")
+	copy(data[700:], "SNOD\x01\x00\x05\x00")
+	copy(data[1600:], []byte{1, 0, 3, 0, 1, 0, 0, 0, 64, 0, 0, 0, 0, 0, 0, 0, 1, 0, 8, 0})
+
+	found, err := Scavenge(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Scavenge failed: %v", err)
+	}
+	if len(found) != 0 {
+		t.Errorf("found %+v in garbage", found)
+	}
+}
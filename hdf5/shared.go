@@ -0,0 +1,48 @@
+package hdf5
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// sharedMsgTable is a file's table of shared object header message
+// indexes, read from its superblock extension on first use.
+type sharedMsgTable struct {
+	once  sync.Once
+	table *object.SharedMessageTable
+	err   error
+}
+
+// sharedMessage returns the body of a header message of type typ stored in
+// the file's shared object header message heap under the heap ID id. It is
+// the SharedMessages function of the file's reader.
+func (f *File) sharedMessage(typ uint16, id []byte) ([]byte, error) {
+	f.sharedTable.once.Do(func() {
+		f.sharedTable.table, f.sharedTable.err = f.readSharedMessageTable()
+	})
+	if f.sharedTable.err != nil {
+		return nil, f.sharedTable.err
+	}
+	return f.sharedTable.table.Message(f.reader, message.Type(typ), id)
+}
+
+// readSharedMessageTable reads the table the shared message table message
+// in the superblock extension points to.
+func (f *File) readSharedMessageTable() (*object.SharedMessageTable, error) {
+	ext := f.superblock.Extension
+	if ext == nil {
+		return nil, fmt.Errorf("shared message in a file without a superblock extension")
+	}
+	msg := ext.SharedMessageTable
+	if msg == nil {
+		return nil, fmt.Errorf("shared message in a file without a shared message table")
+	}
+	table, err := object.ReadSharedMessageTable(f.reader, msg.TableAddress, msg.NumIndexes)
+	if err != nil {
+		return nil, structureError("shared message table", msg.TableAddress, err)
+	}
+	return table, nil
+}
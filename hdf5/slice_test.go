@@ -0,0 +1,458 @@
+package hdf5
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// byteCountingReaderAt counts the bytes read through it.
+type byteCountingReaderAt struct {
+	r io.ReaderAt
+
+	mu sync.Mutex
+	n  int64
+}
+
+func (c *byteCountingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.r.ReadAt(p, off)
+	c.mu.Lock()
+	c.n += int64(n)
+	c.mu.Unlock()
+	return n, err
+}
+
+func (c *byteCountingReaderAt) reset() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := c.n
+	c.n = 0
+	return n
+}
+
+func TestReadSliceFloat64Window(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "chunked.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	ds, err := f.OpenDataset("chunked")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+
+	// A 3x4 window straddling all four 5x5 chunks of arange(100).reshape(10, 10)
+	got, err := ds.ReadSliceFloat64([]uint64{3, 3}, []uint64{3, 4})
+	if err != nil {
+		t.Fatalf("ReadSliceFloat64 failed: %v", err)
+	}
+	want := []float64{33, 34, 35, 36, 43, 44, 45, 46, 53, 54, 55, 56}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("window = %v, want %v", got, want)
+	}
+
+	var rows [][]float64
+	if err := ds.ReadSliceInto(&rows, []uint64{8, 8}, []uint64{2, 2}); err != nil {
+		t.Fatalf("ReadSliceInto failed: %v", err)
+	}
+	if !reflect.DeepEqual(rows, [][]float64{{88, 89}, {98, 99}}) {
+		t.Errorf("ReadSliceInto = %v, want [[88 89] [98 99]]", rows)
+	}
+
+	// Like Read, float64 data does not narrow to int32
+	var ints []int32
+	if err := ds.ReadSliceInto(&ints, []uint64{9, 8}, []uint64{1, 2}); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("ReadSliceInto into []int32 error = %v, want ErrTypeMismatch", err)
+	}
+}
+
+// TestReadSliceBTreeLookup reads windows of a dataset with a v1 B-tree
+// chunk index, whose chunks small selections look up one at a time, and
+// checks them against the whole dataset.
+func TestReadSliceBTreeLookup(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "chunked_v1.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	ds, err := f.OpenDataset("chunked")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	for _, w := range []struct{ start, count []uint64 }{
+		{[]uint64{0, 0}, []uint64{1, 1}},
+		{[]uint64{4, 4}, []uint64{2, 2}},
+		{[]uint64{7, 2}, []uint64{3, 8}},
+		{[]uint64{3, 9}, []uint64{0, 1}},
+	} {
+		got, err := ds.ReadSliceFloat64(w.start, w.count)
+		if err != nil {
+			t.Fatalf("ReadSliceFloat64(%v, %v) failed: %v", w.start, w.count, err)
+		}
+		var want []float64
+		for r := w.start[0]; r < w.start[0]+w.count[0]; r++ {
+			for c := w.start[1]; c < w.start[1]+w.count[1]; c++ {
+				want = append(want, float64(r*10+c))
+			}
+		}
+		if len(got) != len(want) || len(want) > 0 && !reflect.DeepEqual(got, want) {
+			t.Errorf("ReadSliceFloat64(%v, %v) = %v, want %v", w.start, w.count, got, want)
+		}
+	}
+
+	// Reading the whole dataset loads the index, which later slices use
+	all, err := ds.ReadFloat64()
+	if err != nil || len(all) != 100 || all[57] != 57 {
+		t.Fatalf("ReadFloat64 = %v, %v", all, err)
+	}
+	if got, err := ds.ReadSliceFloat64([]uint64{5, 5}, []uint64{1, 2}); err != nil || !reflect.DeepEqual(got, []float64{55, 56}) {
+		t.Errorf("ReadSliceFloat64 after Read = %v, %v", got, err)
+	}
+}
+
+func TestReadSliceDecodesOnlyOverlappingChunks(t *testing.T) {
+	path := skipIfNoTestdata(t, "btree_v2_compressed.h5")
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	counter := &byteCountingReaderAt{r: f.file}
+	f.reader = binary.NewReader(counter, f.superblock.ReaderConfig())
+
+	ds, err := f.OpenDataset("compressed")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+
+	// The full read also loads the chunk index, so the slice below only
+	// pays for chunk data
+	all, err := ds.ReadFloat64()
+	if err != nil {
+		t.Fatalf("ReadFloat64 failed: %v", err)
+	}
+	full := counter.reset()
+
+	// A 10x10 window over four of the hundred gzip-compressed 10x10 chunks
+	got, err := ds.ReadSliceFloat64([]uint64{45, 15}, []uint64{10, 10})
+	if err != nil {
+		t.Fatalf("ReadSliceFloat64 failed: %v", err)
+	}
+	window := counter.reset()
+
+	for i, v := range got {
+		row, col := 45+uint64(i/10), 15+uint64(i%10)
+		if want := all[row*100+col]; v != want {
+			t.Fatalf("element (%d,%d) = %v, want %v", row, col, v, want)
+		}
+	}
+	if window == 0 || window*10 > full {
+		t.Errorf("slice read %d bytes, full read %d; want under a tenth", window, full)
+	}
+}
+
+func TestReadSliceValidation(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "chunked.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	ds, err := f.OpenDataset("chunked")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		start, count []uint64
+	}{
+		{"short start", []uint64{0}, []uint64{1, 1}},
+		{"long count", []uint64{0, 0}, []uint64{1, 1, 1}},
+		{"past end", []uint64{8, 0}, []uint64{3, 1}},
+		{"start past end", []uint64{0, 11}, []uint64{1, 0}},
+		{"overflow", []uint64{^uint64(0), 0}, []uint64{2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ds.ReadSliceFloat64(tt.start, tt.count); !errors.Is(err, ErrOutOfBounds) {
+				t.Errorf("err = %v, want ErrOutOfBounds", err)
+			}
+		})
+	}
+}
+
+// TestReadSliceDestinations checks slice and point destinations the way Read
+// checks them.
+func TestReadSliceDestinations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dest.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("shorts", []int16{-3, -2, -1, 0, 1, 2}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	i32 := message.NewFixedPointDatatype(4, true, message.OrderLE)
+	grid, err := f.Root().CreateDatasetWithType("grid", []uint64{2, 3}, i32)
+	if err != nil {
+		t.Fatalf("CreateDatasetWithType failed: %v", err)
+	}
+	if err := grid.Write([]int32{1, 2, 3, 4, 5, 6}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	shorts, err := f.OpenDataset("shorts")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	grid, err = f.OpenDataset("grid")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+
+	// Destinations that cannot hold the values
+	var nested [][]float64
+	if err := shorts.ReadSliceInto(&nested, []uint64{1}, []uint64{3}); err == nil {
+		t.Errorf("ReadSliceInto of a 1-D slice into [][]float64 = %v, want an error", nested)
+	}
+	var strs []string
+	if err := grid.ReadSliceInto(&strs, []uint64{0, 0}, []uint64{1, 2}); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("ReadSliceInto of integers into []string error = %v, want ErrTypeMismatch", err)
+	}
+	if err := grid.ReadPoints([][]uint64{{1, 1}}, &strs); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("ReadPoints of integers into []string error = %v, want ErrTypeMismatch", err)
+	}
+	var one int32
+	if err := grid.ReadSliceInto(&one, []uint64{0, 0}, []uint64{1, 2}); err == nil {
+		t.Error("ReadSliceInto of 2 elements into one int32 succeeded")
+	}
+
+	// Destinations that can, including the typed helpers
+	var rows [][]float64
+	if err := grid.ReadSliceInto(&rows, []uint64{0, 1}, []uint64{2, 2}); err != nil || !reflect.DeepEqual(rows, [][]float64{{2, 3}, {5, 6}}) {
+		t.Errorf("ReadSliceInto [][]float64 = %v, %v", rows, err)
+	}
+	if err := grid.ReadSliceInto(&one, []uint64{1, 2}, []uint64{1, 1}); err != nil || one != 6 {
+		t.Errorf("ReadSliceInto of one element = %v, %v, want 6", one, err)
+	}
+	if got, err := shorts.ReadSliceInt32([]uint64{0}, []uint64{2}); err != nil || !reflect.DeepEqual(got, []int32{-3, -2}) {
+		t.Errorf("ReadSliceInt32 = %v, %v", got, err)
+	}
+	if got, err := shorts.ReadSliceFloat32([]uint64{4}, []uint64{2}); err != nil || !reflect.DeepEqual(got, []float32{1, 2}) {
+		t.Errorf("ReadSliceFloat32 = %v, %v", got, err)
+	}
+	if _, err := grid.ReadSliceFloat32([]uint64{0, 0}, []uint64{1, 1}); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("ReadSliceFloat32 of int32 data error = %v, want ErrTypeMismatch", err)
+	}
+}
+
+func TestReadSliceScalar(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "scalar.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	members, err := f.Root().Members()
+	if err != nil {
+		t.Fatalf("Members failed: %v", err)
+	}
+	for _, name := range members {
+		ds, err := f.OpenDataset(name)
+		if err != nil || !ds.IsScalar() {
+			continue
+		}
+		if _, err := ds.ReadSliceRaw(nil, nil); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("%s: err = %v, want ErrUnsupported", name, err)
+		}
+		return
+	}
+	t.Fatal("no scalar dataset in scalar.h5")
+}
+
+func TestReadPoints(t *testing.T) {
+	points := [][]uint64{{9, 9}, {0, 0}, {3, 7}, {3, 8}, {6, 2}, {0, 0}}
+	want := []float64{99, 0, 37, 38, 62, 0}
+
+	// Fixed array and v1 B-tree chunk indexes
+	for _, name := range []string{"chunked.h5", "chunked_v1.h5"} {
+		f, err := Open(skipIfNoTestdata(t, name))
+		if err != nil {
+			t.Fatalf("Open %s failed: %v", name, err)
+		}
+		defer f.Close()
+		ds, err := f.OpenDataset("chunked")
+		if err != nil {
+			t.Fatalf("OpenDataset failed: %v", err)
+		}
+		var got []float64
+		if err := ds.ReadPoints(points, &got); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: ReadPoints = %v, %v, want %v", name, got, err, want)
+		}
+		var v float64
+		if err := ds.ReadPoint([]uint64{4, 5}, &v); err != nil || v != 45 {
+			t.Errorf("%s: ReadPoint = %v, %v, want 45", name, v, err)
+		}
+		var narrow int32
+		if err := ds.ReadPoint([]uint64{4, 5}, &narrow); !errors.Is(err, ErrTypeMismatch) {
+			t.Errorf("%s: ReadPoint into int32 error = %v, want ErrTypeMismatch", name, err)
+		}
+	}
+
+	// Contiguous and compact storage
+	f, err := Open(skipIfNoTestdata(t, "multidim.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	ds, err := f.OpenDataset("3d")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	var got []float64
+	if err := ds.ReadPoints([][]uint64{{1, 2, 3}, {0, 1, 0}}, &got); err != nil || !reflect.DeepEqual(got, []float64{23, 4}) {
+		t.Errorf("3d ReadPoints = %v, %v, want [23 4]", got, err)
+	}
+	compact, err := Open(skipIfNoTestdata(t, "compact.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer compact.Close()
+	ds, err = compact.OpenDataset("compact")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	var ints []int32
+	if err := ds.ReadPoints([][]uint64{{3}, {1}}, &ints); err != nil || !reflect.DeepEqual(ints, []int32{4, 2}) {
+		t.Errorf("compact ReadPoints = %v, %v, want [4 2]", ints, err)
+	}
+
+	// Offending points are named
+	for _, tc := range []struct {
+		points [][]uint64
+		index  string
+	}{
+		{[][]uint64{{0}, {4}}, "point 1"},
+		{[][]uint64{{0}, {1}, {0, 0}}, "point 2"},
+	} {
+		err := ds.ReadPoints(tc.points, &ints)
+		if !errors.Is(err, ErrOutOfBounds) || !strings.Contains(err.Error(), tc.index) {
+			t.Errorf("ReadPoints(%v) = %v, want ErrOutOfBounds naming %s", tc.points, err, tc.index)
+		}
+	}
+}
+
+// TestReadPointsDecodesOnlyNeededChunks reads scattered points of a
+// compressed dataset with a v2 B-tree chunk index, first looking up their
+// chunks one at a time, then through the full index.
+func TestReadPointsDecodesOnlyNeededChunks(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "btree_v2_compressed.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	counter := &byteCountingReaderAt{r: f.file}
+	f.reader = binary.NewReader(counter, f.superblock.ReaderConfig())
+
+	ds, err := f.OpenDataset("compressed")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	counter.reset()
+
+	// Three points in two of the hundred chunks
+	var got []float64
+	if err := ds.ReadPoints([][]uint64{{55, 17}, {99, 0}, {51, 12}}, &got); err != nil {
+		t.Fatalf("ReadPoints failed: %v", err)
+	}
+	points := counter.reset()
+	if want := []float64{5517, 9900, 5112}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadPoints = %v, want %v", got, want)
+	}
+
+	all, err := ds.ReadFloat64()
+	if err != nil {
+		t.Fatalf("ReadFloat64 failed: %v", err)
+	}
+	full := counter.reset()
+	if points == 0 || points*10 > full {
+		t.Errorf("points read %d bytes, full read %d; want under a tenth", points, full)
+	}
+
+	// Every element, in reverse, through the full index
+	var every [][]uint64
+	for i := len(all) - 1; i >= 0; i-- {
+		every = append(every, []uint64{uint64(i / 100), uint64(i % 100)})
+	}
+	if err := ds.ReadPoints(every, &got); err != nil || len(got) != len(all) {
+		t.Fatalf("ReadPoints of every element = %d values, %v", len(got), err)
+	}
+	for i, v := range got {
+		if want := all[len(all)-1-i]; v != want {
+			t.Fatalf("point %d = %v, want %v", i, v, want)
+		}
+	}
+}
+
+// TestReadPointsContiguous checks that a few points of a large contiguous
+// dataset are read without reading the dataset.
+func TestReadPointsContiguous(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "points.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	data := make([]float64, 100000)
+	for i := range data {
+		data[i] = float64(i) / 2
+	}
+	if _, err := f.Root().CreateDataset("data", data); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	counter := &byteCountingReaderAt{r: f.file}
+	f.reader = binary.NewReader(counter, f.superblock.ReaderConfig())
+	ds, err := f.OpenDataset("data")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	counter.reset()
+
+	var got []float64
+	if err := ds.ReadPoints([][]uint64{{99999}, {0}, {12345}}, &got); err != nil {
+		t.Fatalf("ReadPoints failed: %v", err)
+	}
+	if want := []float64{49999.5, 0, 6172.5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadPoints = %v, want %v", got, want)
+	}
+	if n := counter.reset(); n > 1024 {
+		t.Errorf("ReadPoints read %d bytes, want a few elements", n)
+	}
+}
@@ -0,0 +1,384 @@
+package hdf5
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/robert-malhotra/go-hdf5/internal/btree"
+	"github.com/robert-malhotra/go-hdf5/internal/fheap"
+	"github.com/robert-malhotra/go-hdf5/internal/heap"
+	"github.com/robert-malhotra/go-hdf5/internal/layout"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// Default K values of v1 B-trees, for superblocks that do not record them.
+const (
+	defaultGroupLeafK     = 4
+	defaultGroupInternalK = 16
+	defaultChunkK         = 32
+)
+
+// SpaceReport accounts for the bytes of a file by what they hold, as
+// File.SpaceReport finds them.
+type SpaceReport struct {
+	EOF uint64 // End of the file's address space, from the superblock
+
+	Superblock    uint64 // Superblock and superblock extension
+	ObjectHeaders uint64 // Object headers, with their continuation blocks
+	BTrees        uint64 // v1 and v2 B-trees, symbol table nodes and chunk indexes
+	Heaps         uint64 // Local, global and fractal heaps
+	RawData       uint64 // Contiguous data and allocated chunks
+
+	// Unaccounted is the bytes before EOF that none of the above covers:
+	// free space left by deleted or rewritten objects, objects no longer
+	// reachable from the root group, and structures not sized here, such
+	// as free-space managers and shared message tables.
+	Unaccounted uint64
+
+	// Overlap is the bytes counted more than once above. It is 0 for a
+	// sound file; anything else means the file is corrupt or one of its
+	// structures was sized wrongly.
+	Overlap uint64
+}
+
+// Metadata returns the bytes of everything but raw data and unaccounted
+// space.
+func (s *SpaceReport) Metadata() uint64 {
+	return s.Superblock + s.ObjectHeaders + s.BTrees + s.Heaps
+}
+
+// SpaceReport walks every object reachable from the root group through
+// hard links, with its index structures, and reports how much of the file
+// each kind of structure takes, like the h5stat tool. It is meant for
+// deciding whether a file is worth repacking or re-chunking.
+//
+// Nodes and blocks are counted at their allocated size, so a v1 B-tree
+// node takes the room of 2K entries however many it holds. The
+// global heap collections of variable-length datasets and attributes are
+// found by reading their heap IDs, which reads the data of such datasets;
+// collections referred to only from within compound or array elements are
+// not found, and count as unaccounted. Objects in files reached through
+// external links are not counted.
+func (f *File) SpaceReport() (*SpaceReport, error) {
+	if f.closed {
+		return nil, ErrClosed
+	}
+	sb := f.superblock
+	report := &SpaceReport{EOF: sb.EOFAddress}
+	if f.writable {
+		report.EOF = f.allocator.EOFAddr()
+	}
+	w := &spaceWalker{
+		f:           f,
+		report:      report,
+		headers:     make(map[uint64]bool),
+		collections: make(map[uint64]bool),
+	}
+
+	if uint64(sb.FileOffset) >= sb.BaseAddress {
+		w.add(uint64(sb.FileOffset)-sb.BaseAddress, uint64(sb.Size()), &report.Superblock)
+	}
+	if ext := sb.SuperblockExtensionAddress; ext != 0 && !f.reader.IsUndefinedOffset(ext) {
+		if err := w.header(ext, &report.Superblock); err != nil {
+			return nil, structureError("superblock extension", ext, err)
+		}
+	}
+	if err := w.group(f.root); err != nil {
+		return nil, err
+	}
+	w.tally()
+	return report, nil
+}
+
+// spaceWalker holds the state of a File.SpaceReport.
+type spaceWalker struct {
+	f           *File
+	report      *SpaceReport
+	extents     []spaceExtent
+	headers     map[uint64]bool // Object headers counted, by address
+	collections map[uint64]bool // Global heap collections counted, by address
+}
+
+// spaceExtent is a range of the file and the report field it counts in.
+type spaceExtent struct {
+	addr, size uint64
+	bucket     *uint64
+}
+
+// add counts size bytes at addr in bucket.
+func (w *spaceWalker) add(addr, size uint64, bucket *uint64) {
+	if size > 0 {
+		w.extents = append(w.extents, spaceExtent{addr, size, bucket})
+	}
+}
+
+// adder returns a function counting the extents it is called with in
+// bucket.
+func (w *spaceWalker) adder(bucket *uint64) func(addr, size uint64) {
+	return func(addr, size uint64) { w.add(addr, size, bucket) }
+}
+
+// tally adds up the extents into the report, and derives the unaccounted
+// and overlapping bytes from their union.
+func (w *spaceWalker) tally() {
+	sort.Slice(w.extents, func(i, j int) bool { return w.extents[i].addr < w.extents[j].addr })
+	var total, union, covered, end uint64
+	for _, e := range w.extents {
+		*e.bucket += e.size
+		total += e.size
+		start, stop := max(e.addr, end), e.addr+e.size
+		if stop <= start {
+			continue
+		}
+		union += stop - start
+		if start < w.report.EOF {
+			covered += min(stop, w.report.EOF) - start
+		}
+		end = stop
+	}
+	w.report.Overlap = total - union
+	w.report.Unaccounted = w.report.EOF - covered
+}
+
+// header counts the object header at addr, with its continuation blocks,
+// in bucket. The prefix of a version 2 header's first chunk precedes its
+// messages and a checksum follows them; continuation blocks are counted
+// as their continuation messages give them.
+func (w *spaceWalker) header(addr uint64, bucket *uint64) error {
+	raw, err := object.ReadRaw(w.f.reader, addr)
+	if err != nil {
+		return err
+	}
+	if raw.FramingError != nil {
+		return raw.FramingError
+	}
+	for i, chunk := range raw.Chunks {
+		if i > 0 {
+			w.add(chunk.Address, chunk.Length, bucket)
+			continue
+		}
+		end := chunk.Address + chunk.Length
+		if raw.Version == 2 {
+			end += 4
+		}
+		w.add(addr, end-addr, bucket)
+	}
+	return nil
+}
+
+// object counts the header of the object at addr and reports whether it
+// had not been counted before.
+func (w *spaceWalker) object(addr uint64, objPath string) (bool, error) {
+	if w.headers[addr] {
+		return false, nil
+	}
+	w.headers[addr] = true
+	if err := w.header(addr, &w.report.ObjectHeaders); err != nil {
+		return false, withPath(objPath, structureError("object header", addr, err))
+	}
+	return true, nil
+}
+
+// group counts g, its attributes, its link storage and then its members.
+func (w *spaceWalker) group(g *Group) error {
+	if first, err := w.object(g.addr, g.path); err != nil || !first {
+		return err
+	}
+	header, err := g.objectHeader()
+	if err != nil {
+		return err
+	}
+	if err := w.attributes(header, g.path); err != nil {
+		return err
+	}
+
+	r, sb := w.f.reader, w.f.superblock
+	if st := g.symbolTable(); st != nil {
+		leafK, internalK := int(sb.GroupLeafNodeK), int(sb.GroupInternalNodeK)
+		if leafK == 0 || internalK == 0 {
+			leafK, internalK = defaultGroupLeafK, defaultGroupInternalK
+		}
+		if err := btree.GroupSpace(r, st.BTreeAddress, internalK, leafK, w.adder(&w.report.BTrees)); err != nil {
+			return withPath(g.path, structureError("group B-tree", st.BTreeAddress, err))
+		}
+		localHeap, err := heap.ReadLocalHeap(r, st.LocalHeapAddress)
+		if err != nil {
+			return withPath(g.path, structureError("local heap", st.LocalHeapAddress, err))
+		}
+		w.add(st.LocalHeapAddress, uint64(8+2*r.LengthSize()+r.OffsetSize()), &w.report.Heaps)
+		w.add(localHeap.DataAddress, localHeap.DataSize, &w.report.Heaps)
+	}
+	if info := header.LinkInfo(); info != nil && !r.IsUndefinedOffset(info.FractalHeapAddr) {
+		if err := w.dense(info.FractalHeapAddr, info.NameIndexBTreeAddr, info.CreationOrderBTreeAddr); err != nil {
+			return withPath(g.path, err)
+		}
+	}
+
+	members, err := g.memberLinks()
+	if err != nil {
+		return withPath(g.path, err)
+	}
+	for i := range members {
+		member := &members[i]
+		if !member.isHard() {
+			continue
+		}
+		childPath := joinPath(g.path, member.name())
+		res, err := g.resolveMember(member, g.file.newLinkChain())
+		if err != nil {
+			return withPath(childPath, err)
+		}
+		if w.headers[res.address] {
+			continue
+		}
+		switch res.kind {
+		case ObjectTypeDataset:
+			ds, err := w.f.openDatasetAt(res.address, childPath)
+			if err != nil {
+				return withPath(childPath, err)
+			}
+			if err := w.dataset(ds, res.address); err != nil {
+				return err
+			}
+		case ObjectTypeNamedDatatype:
+			t, err := w.f.openDatatypeAt(res.address, childPath)
+			if err != nil {
+				return withPath(childPath, err)
+			}
+			if _, err := w.object(res.address, childPath); err != nil {
+				return err
+			}
+			if err := w.attributes(t.header, childPath); err != nil {
+				return err
+			}
+		default:
+			child, err := w.f.openGroupAt(res.address, childPath)
+			if err != nil {
+				return withPath(childPath, err)
+			}
+			if err := w.group(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dataset counts the header of the dataset at addr, its attributes, its
+// data and chunk index, and the global heap collections its
+// variable-length elements are stored in.
+func (w *spaceWalker) dataset(ds *Dataset, addr uint64) error {
+	if _, err := w.object(addr, ds.path); err != nil {
+		return err
+	}
+	if err := w.attributes(ds.header, ds.path); err != nil {
+		return err
+	}
+
+	switch l := ds.layout.(type) {
+	case *layout.Contiguous:
+		if l.Allocated() {
+			w.add(l.Address(), l.Size(), &w.report.RawData)
+		}
+	case *layout.Chunked:
+		k := int(w.f.superblock.IndexedStorageK)
+		if k == 0 {
+			k = defaultChunkK
+		}
+		err := l.Space(k, func(addr, size uint64, chunk bool) {
+			if chunk {
+				w.add(addr, size, &w.report.RawData)
+			} else {
+				w.add(addr, size, &w.report.BTrees)
+			}
+		})
+		if err != nil {
+			return withPath(ds.path, err)
+		}
+	}
+
+	if ds.datatype.Class == message.ClassVarLen {
+		raw, err := ds.ReadRaw()
+		if err != nil {
+			return err
+		}
+		if err := w.heapIDs(raw); err != nil {
+			return withPath(ds.path, err)
+		}
+	}
+	return nil
+}
+
+// attributes counts the dense attribute storage of the object with header
+// and the global heap collections its variable-length attributes are
+// stored in.
+func (w *spaceWalker) attributes(header *object.Header, objPath string) error {
+	if header == nil {
+		return nil
+	}
+	if info := header.AttributeInfo(); info != nil && !w.f.reader.IsUndefinedOffset(info.FractalHeapAddr) {
+		if err := w.dense(info.FractalHeapAddr, info.NameIndexBTreeAddr, info.CreationOrderBTreeAddr); err != nil {
+			return withPath(objPath, err)
+		}
+	}
+	attrs, err := w.f.readAttributes(header)
+	if err != nil {
+		return withPath(objPath, err)
+	}
+	for _, a := range attrs {
+		if a.Datatype != nil && a.Datatype.Class == message.ClassVarLen {
+			if err := w.heapIDs(a.Data); err != nil {
+				return withPath(objPath, fmt.Errorf("attribute %q: %w", a.Name, err))
+			}
+		}
+	}
+	return nil
+}
+
+// dense counts the fractal heap and v2 B-tree indexes of dense link or
+// attribute storage.
+func (w *spaceWalker) dense(heapAddr, nameIndex, orderIndex uint64) error {
+	r := w.f.reader
+	fh, err := fheap.ReadFractalHeap(r, heapAddr)
+	if err != nil {
+		return structureError("fractal heap", heapAddr, err)
+	}
+	if err := fh.Space(w.adder(&w.report.Heaps)); err != nil {
+		return structureError("fractal heap", heapAddr, err)
+	}
+	for _, addr := range []uint64{nameIndex, orderIndex} {
+		if addr == 0 || r.IsUndefinedOffset(addr) {
+			continue
+		}
+		if err := btree.SpaceV2(r, addr, w.adder(&w.report.BTrees)); err != nil {
+			return structureError("B-tree", addr, err)
+		}
+	}
+	return nil
+}
+
+// heapIDs counts the global heap collections the variable-length elements
+// in raw refer to. Each element is a 4-byte length and a global heap ID.
+func (w *spaceWalker) heapIDs(raw []byte) error {
+	r := w.f.reader
+	elemSize := 4 + r.OffsetSize() + 4
+	for pos := 0; pos+elemSize <= len(raw); pos += elemSize {
+		id, err := heap.ParseGlobalHeapID(raw[pos+4:], r.OffsetSize())
+		if err != nil {
+			return err
+		}
+		if binary.LittleEndian.Uint32(raw[pos:]) == 0 || id.CollectionAddress == 0 ||
+			r.IsUndefinedOffset(id.CollectionAddress) || w.collections[id.CollectionAddress] {
+			continue
+		}
+		w.collections[id.CollectionAddress] = true
+		gh, err := heap.ReadGlobalHeap(r, id.CollectionAddress)
+		if err != nil {
+			return structureError("global heap", id.CollectionAddress, err)
+		}
+		w.add(id.CollectionAddress, gh.CollectionSize, &w.report.Heaps)
+	}
+	return nil
+}
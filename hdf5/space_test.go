@@ -0,0 +1,106 @@
+package hdf5
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// spaceReport returns the space report of the file at path.
+func spaceReport(t *testing.T, path string) *SpaceReport {
+	t.Helper()
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	report, err := f.SpaceReport()
+	if err != nil {
+		t.Fatalf("SpaceReport failed: %v", err)
+	}
+	return report
+}
+
+func TestSpaceReportFiles(t *testing.T) {
+	for _, tc := range []struct {
+		file        string
+		unaccounted uint64
+		heaps       uint64
+	}{
+		// Files written by the HDF5 library leave the unused part of
+		// its metadata block before the raw data
+		{"attributes.h5", 1541, 0},
+		{"compressed.h5", 2709, 0},
+		{"btree_v2.h5", 409, 0},
+		{"chunked_v1.h5", 0, 120},
+		{"v0_many_entries.h5", 912, 11536},
+		{"strings.h5", 1273, 4096},
+		{"varlen_attrs.h5", 1523, 4096},
+	} {
+		r := spaceReport(t, skipIfNoTestdata(t, tc.file))
+		if r.Overlap != 0 || r.Unaccounted != tc.unaccounted || r.Heaps != tc.heaps {
+			t.Errorf("%s: %+v, want no overlap, %d bytes unaccounted and %d of heaps",
+				tc.file, *r, tc.unaccounted, tc.heaps)
+		}
+		if got := r.Metadata() + r.RawData + r.Unaccounted; got != r.EOF {
+			t.Errorf("%s: buckets add up to %d, EOF is %d", tc.file, got, r.EOF)
+		}
+	}
+
+	// Raw data is the chunks as stored
+	path := skipIfNoTestdata(t, "compressed.h5")
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	var stored uint64
+	for _, name := range []string{"/gzip", "/shuffle_gzip"} {
+		info, err := mustDataset(t, f, name).StorageInfo()
+		if err != nil {
+			t.Fatalf("StorageInfo failed: %v", err)
+		}
+		stored += info.StoredBytes
+	}
+	if r := spaceReport(t, path); r.RawData != stored {
+		t.Errorf("RawData = %d, want the %d bytes of stored chunks", r.RawData, stored)
+	}
+}
+
+func TestSpaceReportUnlink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "space.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("big", make([]float64, 1000)); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("log", make([]int32, 100), WithChunks(10), WithMaxDims(Unlimited)); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("packed", make([]float64, 1000), WithChunks(100), WithGzip(1)); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	before := spaceReport(t, path)
+	if before.Overlap != 0 || before.BTrees == 0 || before.RawData < 8000+400 {
+		t.Errorf("report = %+v, want no overlap, chunk indexes and the data of all datasets", *before)
+	}
+
+	// The data of an unlinked dataset is left unreachable
+	if f, err = OpenReadWrite(path); err != nil {
+		t.Fatalf("OpenReadWrite failed: %v", err)
+	}
+	if err := f.Root().Unlink("big"); err != nil {
+		t.Fatalf("Unlink failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	after := spaceReport(t, path)
+	if after.RawData != before.RawData-8000 || after.Unaccounted < before.Unaccounted+8000 {
+		t.Errorf("after Unlink: %+v, before: %+v; want 8000 bytes moved from raw data to unaccounted", *after, *before)
+	}
+}
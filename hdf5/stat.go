@@ -0,0 +1,159 @@
+package hdf5
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// ObjectInfo describes an object, or with StatNoFollow a soft or external
+// link, as reported by Stat.
+type ObjectInfo struct {
+	// Name is the last component of the path, or "/" for the root group
+	Name string
+
+	// Kind is the object's type, or the link's with StatNoFollow
+	Kind ObjectType
+
+	// Address is the file address of the object header, in the external
+	// file for objects reached through external links. Zero for links.
+	Address uint64
+
+	// HeaderVersion is the object header version, 1 or 2. Zero for links.
+	HeaderVersion int
+
+	// NumAttrs is the number of attributes, compact and dense
+	NumAttrs int
+
+	// ModTime is the modification time recorded in v2 object headers that
+	// store times, and the zero time otherwise
+	ModTime time.Time
+
+	// LinkTarget is the path a soft or external link points to
+	LinkTarget string
+
+	// LinkFile is the file an external link points to
+	LinkFile string
+}
+
+// StatOption configures Stat.
+type StatOption func(*statOptions)
+
+type statOptions struct {
+	noFollow bool
+}
+
+// StatNoFollow makes Stat describe a soft or external link named by the
+// last path component instead of the object it points to. Links along the
+// way are still followed, and hard links are described as their objects.
+func StatNoFollow() StatOption {
+	return func(o *statOptions) {
+		o.noFollow = true
+	}
+}
+
+// Stat describes the object at path without opening it as a group or
+// dataset. Soft and external links are followed unless StatNoFollow is
+// given.
+func (f *File) Stat(path string, opts ...StatOption) (*ObjectInfo, error) {
+	if f.closed {
+		return nil, ErrClosed
+	}
+	return f.root.Stat(path, opts...)
+}
+
+// Stat describes the object at the path relative to this group, like
+// File.Stat.
+func (g *Group) Stat(name string, opts ...StatOption) (*ObjectInfo, error) {
+	o := &statOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	parts := splitPath(name)
+	if len(parts) > 0 && o.noFollow {
+		parent, err := g.openParent(parts, g.file.newLinkChain())
+		if err != nil {
+			return nil, err
+		}
+		last := parts[len(parts)-1]
+		member, err := parent.lookupMember(last)
+		if err != nil {
+			return nil, fmt.Errorf("finding %q: %w", last, err)
+		}
+		if info := linkInfo(member); info != nil {
+			return info, nil
+		}
+	}
+
+	addr, file, err := g.locate(name, g.file.newLinkChain())
+	if err != nil {
+		return nil, err
+	}
+	header, err := file.readHeader(addr)
+	if err != nil {
+		return nil, structureError("object header", addr, err)
+	}
+	attrs, err := file.readAttributes(header)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ObjectInfo{
+		Name:          "/",
+		Kind:          headerKind(header),
+		Address:       addr,
+		HeaderVersion: int(header.Version),
+		NumAttrs:      len(attrs),
+	}
+	if len(parts) > 0 {
+		info.Name = parts[len(parts)-1]
+	} else if g.path != "/" {
+		info.Name = path.Base(g.path)
+	}
+	if header.HasTimes() {
+		info.ModTime = time.Unix(int64(header.ModTime), 0)
+	}
+	return info, nil
+}
+
+// linkInfo describes a soft or external member link, or returns nil for
+// hard links.
+func linkInfo(member *memberLink) *ObjectInfo {
+	switch {
+	case member.isExternal():
+		return &ObjectInfo{
+			Name:       member.name(),
+			Kind:       ObjectTypeExternalLink,
+			LinkTarget: member.link.ExternalPath,
+			LinkFile:   member.link.ExternalFile,
+		}
+	case member.isHard():
+		return nil
+	case member.link != nil:
+		return &ObjectInfo{Name: member.name(), Kind: ObjectTypeSoftLink, LinkTarget: member.link.SoftLinkValue}
+	default:
+		return &ObjectInfo{Name: member.name(), Kind: ObjectTypeSoftLink, LinkTarget: member.entry.SoftLinkValue}
+	}
+}
+
+// headerKind tells the kind of object a header belongs to from its
+// messages: datasets have a dataspace, groups a symbol table or links, and
+// named datatypes only a datatype.
+func headerKind(header *object.Header) ObjectType {
+	switch {
+	case header.GetMessage(message.TypeDataspace) != nil:
+		return ObjectTypeDataset
+	case header.GetMessage(message.TypeSymbolTable) != nil,
+		header.GetMessage(message.TypeLinkInfo) != nil,
+		header.GetMessage(message.TypeLink) != nil:
+		return ObjectTypeGroup
+	case header.GetMessage(message.TypeDatatype) != nil:
+		return ObjectTypeNamedDatatype
+	default:
+		return ObjectTypeUnknown
+	}
+}
@@ -0,0 +1,108 @@
+package hdf5
+
+import (
+	"encoding/binary"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+func TestStat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stat.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("data", []float64{1, 2, 3}, WithAttribute("units", "m")); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	writeObject(t, f, "dtype", []message.Message{message.NewFloatDatatype(8, message.OrderLE)})
+
+	// A group whose v2 header stores times, built by hand since headers
+	// are written without them
+	linkInfo := message.NewLinkInfo()
+	body := []byte{0, 0}
+	body = binary.LittleEndian.AppendUint64(body, linkInfo.FractalHeapAddr)
+	body = binary.LittleEndian.AppendUint64(body, linkInfo.NameIndexBTreeAddr)
+	hdr := []byte("OHDR\x02\x20")
+	for _, ts := range []uint32{100, 1700000000, 300, 50} {
+		hdr = binary.LittleEndian.AppendUint32(hdr, ts)
+	}
+	hdr = append(hdr, byte(4+len(body)), byte(message.TypeLinkInfo), byte(len(body)), 0, 0)
+	hdr = append(append(hdr, body...), 0, 0, 0, 0)
+	timed := f.allocate(int64(len(hdr)))
+	if err := f.writer.At(int64(timed)).WriteBytes(hdr); err != nil {
+		t.Fatalf("writing header: %v", err)
+	}
+	for _, link := range []*message.Link{
+		message.NewHardLink("timed", timed),
+		message.NewSoftLink("soft", "/data"),
+		message.NewExternalLink("ext", "missing.h5", "/x"),
+	} {
+		if err := f.Root().addLink(link); err != nil {
+			t.Fatalf("adding link %s: %v", link.Name, err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	dataAddr := datasetAddress(t, f, "data")
+	for _, tc := range []struct {
+		path     string
+		opts     []StatOption
+		kind     ObjectType
+		name     string
+		addr     uint64
+		numAttrs int
+		target   string
+	}{
+		{"/", nil, ObjectTypeGroup, "/", f.root.addr, 0, ""},
+		{"/data", nil, ObjectTypeDataset, "data", dataAddr, 1, ""},
+		{"/soft", nil, ObjectTypeDataset, "soft", dataAddr, 1, ""},
+		{"/soft", []StatOption{StatNoFollow()}, ObjectTypeSoftLink, "soft", 0, 0, "/data"},
+		{"/data", []StatOption{StatNoFollow()}, ObjectTypeDataset, "data", dataAddr, 1, ""},
+		{"/ext", []StatOption{StatNoFollow()}, ObjectTypeExternalLink, "ext", 0, 0, "/x"},
+		{"/dtype", nil, ObjectTypeNamedDatatype, "dtype", datasetAddress(t, f, "dtype"), 0, ""},
+	} {
+		info, err := f.Stat(tc.path, tc.opts...)
+		if err != nil {
+			t.Errorf("Stat(%s) failed: %v", tc.path, err)
+			continue
+		}
+		if info.Kind != tc.kind || info.Name != tc.name || info.Address != tc.addr ||
+			info.NumAttrs != tc.numAttrs || info.LinkTarget != tc.target {
+			t.Errorf("Stat(%s) = %+v", tc.path, info)
+		}
+		if tc.kind == ObjectTypeExternalLink && info.LinkFile != "missing.h5" {
+			t.Errorf("Stat(%s).LinkFile = %q", tc.path, info.LinkFile)
+		}
+		if info.Address != 0 && (info.HeaderVersion != 2 || !info.ModTime.IsZero()) {
+			t.Errorf("Stat(%s) = version %d, time %v", tc.path, info.HeaderVersion, info.ModTime)
+		}
+	}
+
+	info, err := f.Root().Stat("timed")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Kind != ObjectTypeGroup || !info.ModTime.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("Stat(timed) = %+v, want a group modified at 1700000000", info)
+	}
+
+	if _, err := f.Stat("/ext"); err == nil {
+		t.Error("Stat followed an external link to a missing file")
+	}
+	if _, err := f.Stat("/missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat(/missing) error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,149 @@
+package hdf5
+
+import (
+	"fmt"
+
+	"github.com/robert-malhotra/go-hdf5/internal/layout"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// LayoutClass is how a dataset's raw data is stored.
+type LayoutClass uint8
+
+// Layout classes, numbered as in the file format.
+const (
+	LayoutCompact    LayoutClass = 0 // In the object header
+	LayoutContiguous LayoutClass = 1 // In one block of the file
+	LayoutChunked    LayoutClass = 2 // In chunks found through a chunk index
+	LayoutVirtual    LayoutClass = 3 // Mapped from other datasets
+)
+
+// String returns the name of the layout class, such as "chunked".
+func (c LayoutClass) String() string {
+	switch c {
+	case LayoutCompact:
+		return "compact"
+	case LayoutContiguous:
+		return "contiguous"
+	case LayoutChunked:
+		return "chunked"
+	case LayoutVirtual:
+		return "virtual"
+	}
+	return fmt.Sprintf("layout class %d", uint8(c))
+}
+
+// StorageInfo describes the space a dataset's raw data occupies in the
+// file, as h5stat reports it.
+type StorageInfo struct {
+	Layout LayoutClass
+
+	// StoredBytes is the space allocated for the data in the file: the
+	// stored, possibly compressed, size of its chunks, the size of its
+	// contiguous block, or the size of compact data. Data kept in external
+	// files takes none.
+	StoredBytes uint64
+
+	// Chunks is the number of chunks allocated inside the dataset's
+	// extent. Chunks never written are not stored.
+	Chunks int
+
+	// CompressionRatio is the uncompressed size of the stored data divided
+	// by StoredBytes, or 0 if nothing is stored. It is 1 for unfiltered
+	// data.
+	CompressionRatio float64
+}
+
+// storedState returns the dataset's state. Handles returned by
+// CreateDataset carry no header, so theirs is read back from the file.
+func (d *Dataset) storedState() (*datasetState, error) {
+	if d.header != nil {
+		return d.datasetState, nil
+	}
+	header, err := object.Read(d.file.headerReader(), d.headerAddr())
+	if err != nil {
+		return nil, fmt.Errorf("reading dataset header: %w", err)
+	}
+	return newDatasetState(d.file, header)
+}
+
+// Layout returns how the dataset's raw data is stored, like the storage
+// layout h5py reports through the dataset's creation properties. Datasets
+// whose header cannot be read back report LayoutContiguous.
+func (d *Dataset) Layout() LayoutClass {
+	state, err := d.storedState()
+	if err != nil {
+		return LayoutContiguous
+	}
+	return LayoutClass(state.header.DataLayout().Class)
+}
+
+// ChunkShape returns the dimensions of the dataset's chunks, like h5py's
+// Dataset.chunks, or nil if the dataset is not chunked.
+func (d *Dataset) ChunkShape() []uint64 {
+	state, err := d.storedState()
+	if err != nil {
+		return nil
+	}
+	msg := state.header.DataLayout()
+	if msg.Class != message.LayoutChunked {
+		return nil
+	}
+	// Chunk dimensions carry an extra one for the element size
+	dims := msg.ChunkDims
+	if rank := max(len(d.dataspace.Dimensions), 1); len(dims) > rank {
+		dims = dims[:rank]
+	}
+	shape := make([]uint64, len(dims))
+	for i, dim := range dims {
+		shape[i] = uint64(dim)
+	}
+	return shape
+}
+
+// StorageInfo reports the space the dataset's raw data occupies in the
+// file. Chunked datasets have their chunk index read, but no chunk is read
+// or decompressed.
+func (d *Dataset) StorageInfo() (StorageInfo, error) {
+	state, err := d.storedState()
+	if err != nil {
+		return StorageInfo{}, withPath(d.path, err)
+	}
+	msg := state.header.DataLayout()
+	info := StorageInfo{Layout: LayoutClass(msg.Class)}
+	logical := d.NumElements() * uint64(d.datatype.Size)
+
+	switch {
+	case state.header.ExternalFiles() != nil:
+		// Stored outside the file
+
+	case msg.Class == message.LayoutCompact:
+		info.StoredBytes = uint64(len(msg.CompactData))
+
+	case msg.Class == message.LayoutContiguous:
+		if msg.Size != 0 && !d.file.headerReader().IsUndefinedOffset(msg.Address) {
+			info.StoredBytes = msg.Size
+		}
+
+	case msg.Class == message.LayoutVirtual:
+		// Stored in the source datasets
+
+	case msg.Class == message.LayoutChunked:
+		chunked := state.layout.(*layout.Chunked)
+		chunks, stored, chunkBytes, err := chunked.Storage()
+		if err != nil {
+			return StorageInfo{}, withPath(d.path, structureError("chunked storage", msg.ChunkIndexAddr, err))
+		}
+		info.Chunks, info.StoredBytes = chunks, stored
+		logical = uint64(chunks) * chunkBytes
+
+	default:
+		return StorageInfo{}, withPath(d.path, fmt.Errorf("%w: %s layout", ErrUnsupported, info.Layout))
+	}
+
+	if info.StoredBytes > 0 {
+		info.CompressionRatio = float64(logical) / float64(info.StoredBytes)
+	}
+	return info, nil
+}
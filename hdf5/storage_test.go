@@ -0,0 +1,101 @@
+package hdf5
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+func TestStorageInfo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("contiguous", []float64{1, 2, 3}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	zeros := make([]int32, 1000)
+	ds, err := f.Root().CreateDataset("gzip", zeros, WithChunks(400), WithGzip(6))
+	if err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	// Handles from CreateDataset read their layout back
+	if ds.Layout() != LayoutChunked || !reflect.DeepEqual(ds.ChunkShape(), []uint64{400}) {
+		t.Errorf("new handle: Layout, ChunkShape = %v, %v", ds.Layout(), ds.ChunkShape())
+	}
+	if info, err := ds.StorageInfo(); err != nil || info.Chunks != 3 {
+		t.Errorf("new handle: StorageInfo = %+v, %v; want 3 chunks", info, err)
+	}
+	if _, err := f.Root().CreateDataset("chunked", zeros, WithChunks(400)); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	writeObject(t, f, "compact", []message.Message{
+		message.NewDataspace([]uint64{4}, nil), message.NewFixedPointDatatype(4, true, message.OrderLE),
+		message.NewCompactLayout(make([]byte, 16)),
+	})
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	for _, tc := range []struct {
+		name   string
+		layout LayoutClass
+		chunks []uint64
+		want   StorageInfo
+	}{
+		{"contiguous", LayoutContiguous, nil, StorageInfo{Layout: LayoutContiguous, StoredBytes: 24, CompressionRatio: 1}},
+		{"compact", LayoutCompact, nil, StorageInfo{Layout: LayoutCompact, StoredBytes: 16, CompressionRatio: 1}},
+		{"chunked", LayoutChunked, []uint64{400}, StorageInfo{Layout: LayoutChunked, StoredBytes: 4800, Chunks: 3, CompressionRatio: 1}},
+	} {
+		ds, err := f.OpenDataset(tc.name)
+		if err != nil {
+			t.Fatalf("OpenDataset %s failed: %v", tc.name, err)
+		}
+		if ds.Layout() != tc.layout || !reflect.DeepEqual(ds.ChunkShape(), tc.chunks) {
+			t.Errorf("%s: Layout, ChunkShape = %v, %v; want %v, %v", tc.name, ds.Layout(), ds.ChunkShape(), tc.layout, tc.chunks)
+		}
+		if info, err := ds.StorageInfo(); err != nil || info != tc.want {
+			t.Errorf("%s: StorageInfo = %+v, %v; want %+v", tc.name, info, err, tc.want)
+		}
+	}
+
+	// Compressed chunks are counted at their stored size
+	ds, err = f.OpenDataset("gzip")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	info, err := ds.StorageInfo()
+	if err != nil || info.Chunks != 3 || info.StoredBytes == 0 || info.StoredBytes >= 4800 {
+		t.Errorf("gzip: StorageInfo = %+v, %v; want 3 chunks stored in under 4800 bytes", info, err)
+	}
+	if want := 4800 / float64(info.StoredBytes); info.CompressionRatio != want {
+		t.Errorf("gzip: CompressionRatio = %v, want %v", info.CompressionRatio, want)
+	}
+}
+
+func TestStorageInfoFiles(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "btree_v2_compressed.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	ds, err := f.OpenDataset("compressed")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if !reflect.DeepEqual(ds.ChunkShape(), []uint64{10, 10}) {
+		t.Errorf("ChunkShape = %v, want [10 10]", ds.ChunkShape())
+	}
+	info, err := ds.StorageInfo()
+	if err != nil || info.Chunks != 100 || info.CompressionRatio <= 1 {
+		t.Errorf("StorageInfo = %+v, %v; want 100 compressed chunks", info, err)
+	}
+}
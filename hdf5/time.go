@@ -0,0 +1,70 @@
+package hdf5
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeOption configures how Dataset.ReadTimes and Attribute.ReadScalarTime
+// interpret stored values.
+type TimeOption func(*timeOptions)
+
+type timeOptions struct {
+	unit time.Duration // Duration of one stored unit since the epoch
+}
+
+// WithMilliseconds interprets stored values as milliseconds since the Unix
+// epoch instead of seconds.
+func WithMilliseconds() TimeOption {
+	return func(o *timeOptions) {
+		o.unit = time.Millisecond
+	}
+}
+
+// newTimeOptions applies opts to the default of whole seconds.
+func newTimeOptions(opts []TimeOption) *timeOptions {
+	o := &timeOptions{unit: time.Second}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// time returns the time v units after the Unix epoch, in UTC.
+func (o *timeOptions) time(v int64) time.Time {
+	if o.unit == time.Millisecond {
+		return time.UnixMilli(v).UTC()
+	}
+	return time.Unix(v, 0).UTC()
+}
+
+// ReadTimes reads the dataset as times in UTC. Values are taken as seconds
+// since the Unix epoch, or milliseconds with WithMilliseconds; the dataset
+// may hold integers that fit in an int64, such as int64 or uint32 values,
+// or the HDF5 time class.
+func (d *Dataset) ReadTimes(opts ...TimeOption) ([]time.Time, error) {
+	vals, err := d.ReadInt64()
+	if err != nil {
+		return nil, err
+	}
+	o := newTimeOptions(opts)
+	times := make([]time.Time, len(vals))
+	for i, v := range vals {
+		times[i] = o.time(v)
+	}
+	return times, nil
+}
+
+// ReadScalarTime reads an attribute holding a single time, such as one
+// written by SetAttr with a time.Time. Its value is interpreted as by
+// Dataset.ReadTimes.
+func (a *Attribute) ReadScalarTime(opts ...TimeOption) (time.Time, error) {
+	vals, err := a.ReadInt64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(vals) != 1 {
+		return time.Time{}, withPath(a.path, fmt.Errorf("attribute has %d values, want 1", len(vals)))
+	}
+	return newTimeOptions(opts).time(vals[0]), nil
+}
@@ -0,0 +1,102 @@
+package hdf5
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+func TestTimes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "times.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	start := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	stamps := []time.Time{start, start.Add(time.Hour)}
+	ds, err := f.Root().CreateDataset("seconds", []int64{start.Unix(), start.Unix() + 3600},
+		WithAttribute("start", start.In(time.FixedZone("X", 3600))))
+	if err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if err := ds.SetAttr("stamps", stamps); err != nil {
+		t.Fatalf("SetAttr failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("u32", []uint32{uint32(start.Unix())}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("millis", []int64{start.UnixMilli() + 250}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+
+	// A dataset of the HDF5 time class: class 2, little-endian, 8 bytes
+	// with a precision of 64 bits
+	timeType := &rawMessage{typ: message.TypeDatatype, body: []byte{0x12, 0, 0, 0, 8, 0, 0, 0, 64, 0}}
+	data := f.allocate(8)
+	if err := f.writer.At(int64(data)).WriteBytes(binary.LittleEndian.AppendUint64(nil, uint64(start.Unix()))); err != nil {
+		t.Fatalf("writing data: %v", err)
+	}
+	writeObject(t, f, "h5time", []message.Message{
+		message.NewDataspace([]uint64{1}, nil), timeType, message.NewContiguousLayout(data, 8),
+	})
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	ds, err = f.OpenDataset("seconds")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if got, err := ds.ReadTimes(); err != nil || !reflect.DeepEqual(got, stamps) {
+		t.Errorf("ReadTimes = %v, %v; want %v", got, err, stamps)
+	}
+	if got, err := ds.Attr("start").ReadScalarTime(); err != nil || !got.Equal(start) || got.Location() != time.UTC {
+		t.Errorf("start = %v, %v; want %v", got, err, start)
+	}
+	if got, err := ds.Attr("start").ReadScalarInt64(); err != nil || got != start.Unix() {
+		t.Errorf("start as int64 = %v, %v; want %d", got, err, start.Unix())
+	}
+	if got, err := ds.Attr("stamps").ReadInt64(); err != nil || len(got) != 2 || got[1] != start.Unix()+3600 {
+		t.Errorf("stamps = %v, %v", got, err)
+	}
+	if _, err := ds.Attr("stamps").ReadScalarTime(); err == nil {
+		t.Error("ReadScalarTime succeeded on two values")
+	}
+
+	for name, want := range map[string]time.Time{"u32": start, "h5time": start} {
+		ds, err := f.OpenDataset(name)
+		if err != nil {
+			t.Fatalf("OpenDataset %s failed: %v", name, err)
+		}
+		if got, err := ds.ReadTimes(); err != nil || len(got) != 1 || !got[0].Equal(want) {
+			t.Errorf("%s: ReadTimes = %v, %v; want [%v]", name, got, err, want)
+		}
+	}
+	ds, err = f.OpenDataset("h5time")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if info := ds.DtypeInfo(); info.Class != ClassTime || info.Size != 8 {
+		t.Errorf("h5time DtypeInfo = %+v, want an 8-byte time", info)
+	}
+
+	ds, err = f.OpenDataset("millis")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	want := start.Add(250 * time.Millisecond)
+	if got, err := ds.ReadTimes(WithMilliseconds()); err != nil || len(got) != 1 || !got[0].Equal(want) {
+		t.Errorf("millis = %v, %v; want [%v]", got, err, want)
+	}
+}
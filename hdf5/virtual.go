@@ -0,0 +1,137 @@
+package hdf5
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/robert-malhotra/go-hdf5/internal/heap"
+	"github.com/robert-malhotra/go-hdf5/internal/layout"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// newVirtualLayout returns the layout of a virtual dataset, which reads
+// its elements from the source datasets named in its mapping list. Each
+// mapping must take one box of the virtual dataset, such as a plane of a
+// stack, from one box of its source, or the whole source; reads of a
+// dataset with other mappings, or whose mapping list cannot be read, fail
+// with the reason.
+func newVirtualLayout(f *File, ds *datasetState, msg *message.DataLayout) layout.Layout {
+	mappings, err := virtualMappings(f, ds, msg)
+	if err != nil {
+		return &unreadableLayout{class: message.LayoutVirtual, err: err}
+	}
+	return layout.NewVirtual(mappings, ds.dataspace, ds.datatype, f.virtualSourceOpener(ds))
+}
+
+// virtualMappings reads the mapping list of a virtual dataset from the
+// global heap.
+func virtualMappings(f *File, ds *datasetState, msg *message.DataLayout) ([]layout.VirtualMapping, error) {
+	r := f.headerReader()
+	gh, err := heap.ReadGlobalHeap(r, msg.VirtualHeapAddress)
+	if err != nil {
+		return nil, structureError("virtual dataset mapping list", msg.VirtualHeapAddress, err)
+	}
+	if msg.VirtualHeapIndex > math.MaxUint16 {
+		return nil, structureError("virtual dataset mapping list", msg.VirtualHeapAddress,
+			fmt.Errorf("object index %d out of range", msg.VirtualHeapIndex))
+	}
+	data, err := gh.GetObject(uint16(msg.VirtualHeapIndex))
+	if err != nil {
+		return nil, structureError("virtual dataset mapping list", msg.VirtualHeapAddress, err)
+	}
+	entries, err := message.ParseVirtualMappings(data, r)
+	if err != nil {
+		return nil, structureError("virtual dataset mapping list", msg.VirtualHeapAddress, err)
+	}
+
+	dims := ds.dataspace.Dimensions
+	mappings := make([]layout.VirtualMapping, 0, len(entries))
+	for i, e := range entries {
+		// Mappings of no elements read nothing
+		if e.VirtualSelection.Type == message.SelectNone || e.SourceSelection.Type == message.SelectNone {
+			continue
+		}
+		m := layout.VirtualMapping{File: e.SourceFile, Dataset: e.SourceDataset}
+		if m.Start, m.Count, err = e.VirtualSelection.Box(); err != nil {
+			return nil, fmt.Errorf("%w: virtual dataset mapping %d: %v", ErrUnsupported, i, err)
+		}
+		if m.Count == nil {
+			m.Start, m.Count = make([]uint64, len(dims)), dims
+		}
+		if len(m.Start) != len(dims) {
+			return nil, fmt.Errorf("virtual dataset mapping %d has rank %d, dataset has rank %d", i, len(m.Start), len(dims))
+		}
+		if m.SourceStart, m.SourceCount, err = e.SourceSelection.Box(); err != nil {
+			return nil, fmt.Errorf("%w: virtual dataset mapping %d: source %v", ErrUnsupported, i, err)
+		}
+		mappings = append(mappings, m)
+	}
+	return mappings, nil
+}
+
+// virtualSourceOpener returns the function the virtual dataset ds of f
+// opens its sources with. A source file named "." is f itself; others are
+// looked for like the files of external links, through any
+// WithExternalLinkResolver, and kept open until f is closed. Sources must
+// have the virtual dataset's datatype, down to byte order and sign, as no
+// conversion is done.
+func (f *File) virtualSourceOpener(ds *datasetState) layout.SourceOpener {
+	return func(file, dataset string) (layout.Layout, []uint64, error) {
+		src := f
+		if file != "." {
+			var err error
+			if src, err = f.openExternalFile(file, dataset); err != nil {
+				return nil, nil, err
+			}
+		}
+		d, err := src.OpenDataset(dataset)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !sameDatatype(d.datatype, ds.datatype) {
+			return nil, nil, fmt.Errorf("%w: source datatype (class %d, %d-byte elements, %s) differs from the virtual dataset's (class %d, %d-byte elements, %s)",
+				ErrUnsupported, d.datatype.Class, d.datatype.Size, describeOrder(d.datatype),
+				ds.datatype.Class, ds.datatype.Size, describeOrder(ds.datatype))
+		}
+		return d.layout, d.Shape(), nil
+	}
+}
+
+// sameDatatype reports whether elements of datatypes a and b are encoded
+// alike, so that a source of one can be read as the other unconverted.
+func sameDatatype(a, b *message.Datatype) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Class != b.Class || a.ClassBits != b.ClassBits || a.Size != b.Size ||
+		a.ByteOrder != b.ByteOrder || a.Signed != b.Signed ||
+		a.BitOffset != b.BitOffset || a.BitPrecision != b.BitPrecision ||
+		a.StringPadding != b.StringPadding || a.CharSet != b.CharSet ||
+		a.IsVarLenString != b.IsVarLenString || a.OpaqueTag != b.OpaqueTag ||
+		!slices.Equal(a.ArrayDims, b.ArrayDims) || !slices.Equal(a.EnumNames, b.EnumNames) ||
+		!slices.EqualFunc(a.EnumValues, b.EnumValues, bytes.Equal) ||
+		!sameDatatype(a.BaseType, b.BaseType) || !sameDatatype(a.VarLenType, b.VarLenType) {
+		return false
+	}
+	return slices.EqualFunc(a.Members, b.Members, func(x, y message.CompoundMember) bool {
+		return x.Name == y.Name && x.ByteOffset == y.ByteOffset && sameDatatype(x.Type, y.Type)
+	})
+}
+
+// describeOrder names the byte order and, for integers, the sign of dt's
+// elements.
+func describeOrder(dt *message.Datatype) string {
+	order := "little-endian"
+	if dt.ByteOrder == message.OrderBE {
+		order = "big-endian"
+	}
+	if dt.Class != message.ClassFixedPoint {
+		return order
+	}
+	if dt.Signed {
+		return "signed " + order
+	}
+	return "unsigned " + order
+}
@@ -0,0 +1,268 @@
+package hdf5
+
+import (
+	"encoding/binary"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	binpkg "github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/heap"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// virtualRow is a mapping of a virtual dataset taking row row of n
+// columns from the whole of a source dataset.
+type virtualRow struct {
+	file, dataset string
+	row, n        uint32
+}
+
+// writeVirtualDataset adds a rows x cols int32 virtual dataset with the
+// given mappings and a fill value of -1 to f, as h5py writes one stacking
+// sources along its first dimension.
+func writeVirtualDataset(t *testing.T, f *File, name string, rows, cols uint64, mappings []virtualRow) {
+	t.Helper()
+	le := binary.LittleEndian
+	list := le.AppendUint64([]byte{0}, uint64(len(mappings)))
+	for _, m := range mappings {
+		list = append(list, m.file+"\x00"+m.dataset+"\x00"...)
+		// Source: all; virtual: one block, version 1
+		list = le.AppendUint32(le.AppendUint32(list, uint32(message.SelectAll)), 1)
+		list = le.AppendUint64(list, 0)
+		list = le.AppendUint32(le.AppendUint32(list, uint32(message.SelectHyperslabs)), 1)
+		list = le.AppendUint64(list, 0)
+		list = le.AppendUint32(le.AppendUint32(list, 2), 1)
+		for _, v := range []uint32{m.row, 0, m.row, m.n - 1} {
+			list = le.AppendUint32(list, v)
+		}
+	}
+	list = le.AppendUint32(list, binpkg.ChecksumLookup3(list, 0))
+
+	gh := heap.NewGlobalHeapWriter(f.writer, f.allocate)
+	index := gh.AddObject(list)
+	heapAddr, _, err := gh.Write()
+	if err != nil {
+		t.Fatalf("writing mapping list: %v", err)
+	}
+	body := le.AppendUint64([]byte{4, byte(message.LayoutVirtual)}, heapAddr)
+	body = le.AppendUint32(body, uint32(index))
+
+	writeObject(t, f, name, []message.Message{
+		message.NewDataspace([]uint64{rows, cols}, nil),
+		message.NewFixedPointDatatype(4, true, message.OrderLE),
+		fillValueV3([]byte{0xff, 0xff, 0xff, 0xff}),
+		&rawMessage{message.TypeDataLayout, body},
+	})
+}
+
+func TestVirtualDataset(t *testing.T) {
+	dir := t.TempDir()
+	frames, err := Create(filepath.Join(dir, "frames.h5"))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	for name, values := range map[string][]int32{"f0": {1, 2, 3}, "f1": {4, 5, 6}} {
+		if _, err := frames.Root().CreateDataset(name, values); err != nil {
+			t.Fatalf("CreateDataset %s failed: %v", name, err)
+		}
+	}
+	if err := frames.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Rows 0 and 1 come from frames.h5, row 2 from the file itself, and
+	// row 3 from a file that does not exist; row 4 is not mapped
+	path := filepath.Join(dir, "vds.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("local", []int32{7, 8, 9}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	writeVirtualDataset(t, f, "stack", 5, 3, []virtualRow{
+		{"frames.h5", "/f0", 0, 3},
+		{"frames.h5", "/f1", 1, 3},
+		{".", "/local", 2, 3},
+		{"missing.h5", "/f1", 3, 3},
+	})
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	ds, err := f.OpenDataset("stack")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if info, err := ds.StorageInfo(); err != nil || info.Layout != LayoutVirtual || info.StoredBytes != 0 {
+		t.Errorf("StorageInfo() = %+v, %v", info, err)
+	}
+
+	got, err := ds.ReadSliceInt32([]uint64{0, 1}, []uint64{3, 2})
+	if err != nil {
+		t.Fatalf("ReadSliceInt32 failed: %v", err)
+	}
+	if want := []int32{2, 3, 5, 6, 8, 9}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadSliceInt32() = %v, want %v", got, want)
+	}
+	got, err = ds.ReadSliceInt32([]uint64{4, 0}, []uint64{1, 3})
+	if err != nil || !reflect.DeepEqual(got, []int32{-1, -1, -1}) {
+		t.Errorf("unmapped row = %v, %v, want the fill value", got, err)
+	}
+	if _, err := ds.ReadInt32(); err == nil {
+		t.Error("reading a row from a missing file succeeded")
+	}
+	if n := f.externalFiles.len(); n != 1 {
+		t.Errorf("opened %d source files, want 1", n)
+	}
+
+	// Source files are looked for like the files of external links
+	var calls int
+	resolve := func(parent *File, filename, objPath string) (*File, error) {
+		calls++
+		if filename == "missing.h5" {
+			return Open(filepath.Join(dir, "frames.h5"))
+		}
+		return nil, nil
+	}
+	g, err := Open(path, WithExternalLinkResolver(resolve))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer g.Close()
+	ds, err = g.OpenDataset("stack")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	got, err = ds.ReadInt32()
+	if err != nil {
+		t.Fatalf("ReadInt32 failed: %v", err)
+	}
+	if want := []int32{1, 2, 3, 4, 5, 6, 7, 8, 9, 4, 5, 6, -1, -1, -1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadInt32() = %v, want %v", got, want)
+	}
+	if calls != 2 {
+		t.Errorf("resolver called %d times, want 2", calls)
+	}
+
+	h, err := Open(path, WithoutExternalLinks())
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer h.Close()
+	ds, err = h.OpenDataset("stack")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if _, err := ds.ReadInt32(); !errors.Is(err, ErrExternalLinksDisabled) {
+		t.Errorf("ReadInt32() error = %v, want ErrExternalLinksDisabled", err)
+	}
+	if got, err := ds.ReadSliceInt32([]uint64{2, 0}, []uint64{1, 3}); err != nil || !reflect.DeepEqual(got, []int32{7, 8, 9}) {
+		t.Errorf("row from the file itself = %v, %v", got, err)
+	}
+}
+
+func TestVirtualDatasetSourceType(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vds.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("unsigned", []uint32{1, 2, 3}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	writeObject(t, f, "big", object.NewDatasetHeader(message.NewDataspace([]uint64{3}, nil),
+		message.NewFixedPointDatatype(4, true, message.OrderBE),
+		message.NewCompactLayout([]byte{0, 0, 0, 4, 0, 0, 0, 5, 0, 0, 0, 6})))
+	if _, err := f.Root().CreateDataset("same", []int32{7, 8, 9}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	writeVirtualDataset(t, f, "stack", 3, 3, []virtualRow{
+		{".", "/unsigned", 0, 3},
+		{".", "/big", 1, 3},
+		{".", "/same", 2, 3},
+	})
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	ds, err := f.OpenDataset("stack")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	for row, source := range []string{"unsigned", "big-endian"} {
+		_, err := ds.ReadSliceInt32([]uint64{uint64(row), 0}, []uint64{1, 3})
+		if !errors.Is(err, ErrUnsupported) {
+			t.Errorf("row from a %s source: err = %v, want ErrUnsupported", source, err)
+		}
+	}
+	if got, err := ds.ReadSliceInt32([]uint64{2, 0}, []uint64{1, 3}); err != nil || !reflect.DeepEqual(got, []int32{7, 8, 9}) {
+		t.Errorf("row from a source of the same type = %v, %v", got, err)
+	}
+}
+
+func TestVirtualDatasetH5py(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "vds_stack.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	ds, err := f.OpenDataset("stack")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if ds.Layout() != LayoutVirtual {
+		t.Errorf("Layout() = %v, want LayoutVirtual", ds.Layout())
+	}
+	got, err := ds.ReadInt32()
+	if err != nil {
+		t.Fatalf("ReadInt32 failed: %v", err)
+	}
+	for i, v := range got {
+		want := int32(i)
+		if i >= 40 {
+			want = -1
+		}
+		if v != want {
+			t.Fatalf("element %d = %d, want %d", i, v, want)
+		}
+	}
+}
+
+// TestVirtualDatasetSourceTypeH5py reads a virtual dataset written by h5py
+// whose sources libhdf5 converts to its little-endian int32: rows from an
+// unsigned and a big-endian source are refused, the row from a source of
+// the same type is read.
+func TestVirtualDatasetSourceTypeH5py(t *testing.T) {
+	f, err := Open(skipIfNoTestdata(t, "vds_types.h5"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	ds, err := f.OpenDataset("stack")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	for row, source := range []string{"unsigned", "big-endian"} {
+		_, err := ds.ReadSliceInt32([]uint64{uint64(row), 0}, []uint64{1, 3})
+		if !errors.Is(err, ErrUnsupported) {
+			t.Errorf("row from a %s source: err = %v, want ErrUnsupported", source, err)
+		}
+	}
+	if got, err := ds.ReadSliceInt32([]uint64{2, 0}, []uint64{1, 3}); err != nil || !reflect.DeepEqual(got, []int32{7, 8, 9}) {
+		t.Errorf("row from a source of the same type = %v, %v", got, err)
+	}
+}
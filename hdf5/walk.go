@@ -1,18 +1,19 @@
 package hdf5
 
 import (
-	"path"
+	"errors"
+	"fmt"
 )
 
 // WalkFunc is called for each object during traversal.
 // path is the full path to the object.
-// obj is either *Group or *Dataset.
+// obj is a *Group, *Dataset or *NamedDatatype.
 // err is any error encountered opening the object.
 // Return nil to continue walking, or an error to stop.
 type WalkFunc func(path string, obj interface{}, err error) error
 
-// Walk traverses all objects (groups and datasets) in the hierarchy starting from g.
-// The callback is called for each group and dataset, including the starting group.
+// Walk traverses all objects (groups, datasets and named datatypes) in the hierarchy
+// starting from g. The callback is called for each object, including the starting group.
 //
 // Example:
 //
@@ -47,7 +48,7 @@ func walkGroup(g *Group, fn WalkFunc) error {
 
 	// Process each child
 	for _, name := range members {
-		childPath := path.Join(g.Path(), name)
+		childPath := joinPath(g.Path(), name)
 
 		// Try as group first
 		childGroup, err := g.OpenGroup(name)
@@ -59,7 +60,7 @@ func walkGroup(g *Group, fn WalkFunc) error {
 			continue
 		}
 
-		// Try as dataset
+		// Try as dataset, then as named datatype
 		dataset, err := g.OpenDataset(name)
 		if err == nil {
 			if err := fn(childPath, dataset, nil); err != nil {
@@ -67,8 +68,17 @@ func walkGroup(g *Group, fn WalkFunc) error {
 			}
 			continue
 		}
+		if errors.Is(err, ErrNotDataset) {
+			dtype, dtErr := g.OpenDatatype(name)
+			if dtErr == nil {
+				if err := fn(childPath, dtype, nil); err != nil {
+					return err
+				}
+				continue
+			}
+		}
 
-		// Could not open as either - call fn with error
+		// Could not open as any - call fn with error
 		if err := fn(childPath, nil, err); err != nil {
 			return err
 		}
@@ -77,6 +87,182 @@ func walkGroup(g *Group, fn WalkFunc) error {
 	return nil
 }
 
+// Object is an object of the file: a *Group, a *Dataset or a
+// *NamedDatatype, as returned by File.Open and visited by File.Walk.
+type Object interface {
+	// Kind is ObjectTypeGroup, ObjectTypeDataset or
+	// ObjectTypeNamedDatatype, for switching on the object without a type
+	// switch.
+	Kind() ObjectType
+
+	Name() string
+	Path() string
+	Attrs() []string
+	Attr(name string) *Attribute
+	HasAttr(name string) bool
+}
+
+// SkipGroup can be returned from a VisitFunc called for a group to skip
+// its members. The walk continues with the group's siblings.
+var SkipGroup = errors.New("skip this group")
+
+// VisitFunc is called by File.Walk for each object, with its full path.
+// Returning SkipGroup for a group prunes descent into it (for other objects
+// it is treated like nil); any other error stops the walk and is returned
+// by Walk.
+type VisitFunc func(path string, obj Object) error
+
+// WalkOptions controls which links File.Walk follows. The zero value
+// follows hard links only.
+type WalkOptions struct {
+	// FollowSoftLinks visits the targets of soft links, under the link's
+	// path. Without it soft links are skipped.
+	FollowSoftLinks bool
+
+	// FollowExternalLinks visits the targets of external links, opening
+	// the files they point to. Without it external links are skipped.
+	FollowExternalLinks bool
+
+	// OnError is called with the path of a member that cannot be opened.
+	// Returning nil skips the member and continues the walk; returning an
+	// error stops it. If OnError is nil, the error stops the walk.
+	OnError func(path string, err error) error
+}
+
+// Walk visits every object in the file depth-first, starting with the
+// root group, calling fn for each group before its members. Members are
+// visited in storage order. Each object is visited once, at the first path
+// reaching it, so objects with several hard links and cycles of links are
+// not visited again. At most one WalkOptions may be given.
+//
+// Example:
+//
+//	err := f.Walk(func(path string, obj hdf5.Object) error {
+//	    if ds, ok := obj.(*hdf5.Dataset); ok {
+//	        fmt.Println(path, ds.Shape())
+//	    }
+//	    return nil
+//	})
+func (f *File) Walk(fn VisitFunc, opts ...WalkOptions) error {
+	if f.closed {
+		return ErrClosed
+	}
+	w := &fileWalker{fn: fn, visited: make(map[walkKey]bool)}
+	if len(opts) > 0 {
+		w.opts = opts[0]
+	}
+	w.visited[walkKey{f, f.root.addr}] = true
+	if err := w.walkGroup(f.root); err != nil {
+		return err.(walkAbort).error
+	}
+	return nil
+}
+
+// walkKey identifies an object header across the files a walk reaches.
+type walkKey struct {
+	file *File
+	addr uint64
+}
+
+// fileWalker holds the state of a File.Walk.
+type fileWalker struct {
+	fn      VisitFunc
+	opts    WalkOptions
+	visited map[walkKey]bool
+}
+
+// walkGroup visits g, which is already marked visited, and its members.
+// The error stopping the walk, if any, is returned as a walkAbort.
+func (w *fileWalker) walkGroup(g *Group) error {
+	if err := w.fn(g.path, g); err != nil {
+		if err == SkipGroup {
+			return nil
+		}
+		return walkAbort{err}
+	}
+
+	members, err := g.memberLinks()
+	if err != nil {
+		return w.onError(g.path, err)
+	}
+	for i := range members {
+		member := &members[i]
+		switch {
+		case member.isExternal() && !w.opts.FollowExternalLinks:
+			continue
+		case !member.isHard() && !member.isExternal() && !w.opts.FollowSoftLinks:
+			continue
+		}
+
+		childPath := joinPath(g.path, member.name())
+		if err := w.walkMember(g, member, childPath); err != nil {
+			if err := w.onError(childPath, err); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// walkMember visits the target of a member link unless it was visited
+// before. Errors that stop the walk are returned as a walkAbort, so that
+// they are not passed to OnError.
+func (w *fileWalker) walkMember(g *Group, member *memberLink, childPath string) error {
+	res, err := g.resolveMember(member, g.file.newLinkChain())
+	if err != nil {
+		return err
+	}
+	targetFile := g.file
+	if res.file != nil {
+		targetFile = res.file
+	}
+	key := walkKey{targetFile, res.address}
+	if w.visited[key] {
+		return nil
+	}
+	w.visited[key] = true
+
+	var obj Object
+	switch res.kind {
+	case ObjectTypeDataset:
+		obj, err = targetFile.openDatasetAt(res.address, childPath)
+	case ObjectTypeNamedDatatype:
+		obj, err = targetFile.openDatatypeAt(res.address, childPath)
+	default:
+		child, err := targetFile.openGroupAt(res.address, childPath)
+		if err != nil {
+			return err
+		}
+		return w.walkGroup(child)
+	}
+	if err != nil {
+		return err
+	}
+	if err := w.fn(childPath, obj); err != nil && err != SkipGroup {
+		return walkAbort{err}
+	}
+	return nil
+}
+
+// onError reports an error opening the object at objPath to OnError, and
+// returns the error that stops the walk, if any, as a walkAbort.
+func (w *fileWalker) onError(objPath string, err error) error {
+	if _, ok := err.(walkAbort); ok {
+		return err
+	}
+	if w.opts.OnError == nil {
+		return walkAbort{fmt.Errorf("walking %q: %w", objPath, err)}
+	}
+	if err := w.opts.OnError(objPath, err); err != nil {
+		return walkAbort{err}
+	}
+	return nil
+}
+
+// walkAbort carries an error that stops a walk up through the groups
+// being walked.
+type walkAbort struct{ error }
+
 // AttrInfo contains information about an attribute during walking.
 type AttrInfo struct {
 	// Path is the full attribute path (e.g., "/group/dataset@attr")
@@ -124,10 +310,15 @@ func (f *File) WalkAttrs(fn WalkAttrsFunc) error {
 // walkGroupAttrs recursively walks attributes in a group and its children.
 func (f *File) walkGroupAttrs(g *Group, fn WalkAttrsFunc) error {
 	// Process attributes on this group
-	for _, name := range g.Attrs() {
-		attr := g.Attr(name)
+	header, err := g.objectHeader()
+	if err != nil {
+		return err
+	}
+	for _, msg := range f.attributes(header, g.path) {
+		name := msg.Name
+		attr := &Attribute{msg: msg, reader: f.reader, path: JoinAttrPath(g.Path(), name)}
 		info := AttrInfo{
-			Path:       JoinAttrPath(g.Path(), name),
+			Path:       attr.path,
 			ObjectPath: g.Path(),
 			ObjectType: "group",
 			Name:       name,
@@ -135,11 +326,9 @@ func (f *File) walkGroupAttrs(g *Group, fn WalkAttrsFunc) error {
 		}
 
 		// Try to read the value
-		if attr != nil {
-			val, err := attr.Value()
-			info.Value = val
-			info.Err = err
-		}
+		val, err := attr.Value()
+		info.Value = val
+		info.Err = err
 
 		if err := fn(info); err != nil {
 			return err
@@ -154,7 +343,7 @@ func (f *File) walkGroupAttrs(g *Group, fn WalkAttrsFunc) error {
 
 	// Process each child
 	for _, name := range members {
-		childPath := path.Join(g.Path(), name)
+		childPath := joinPath(g.Path(), name)
 
 		// Try as group first
 		childGroup, err := g.OpenGroup(name)
@@ -174,10 +363,11 @@ func (f *File) walkGroupAttrs(g *Group, fn WalkAttrsFunc) error {
 		}
 
 		// Process attributes on this dataset
-		for _, attrName := range dataset.Attrs() {
-			attr := dataset.Attr(attrName)
+		for _, msg := range f.attributes(dataset.header, dataset.path) {
+			attrName := msg.Name
+			attr := &Attribute{msg: msg, reader: f.reader, path: JoinAttrPath(childPath, attrName)}
 			info := AttrInfo{
-				Path:       JoinAttrPath(childPath, attrName),
+				Path:       attr.path,
 				ObjectPath: childPath,
 				ObjectType: "dataset",
 				Name:       attrName,
@@ -185,11 +375,9 @@ func (f *File) walkGroupAttrs(g *Group, fn WalkAttrsFunc) error {
 			}
 
 			// Try to read the value
-			if attr != nil {
-				val, err := attr.Value()
-				info.Value = val
-				info.Err = err
-			}
+			val, err := attr.Value()
+			info.Value = val
+			info.Err = err
 
 			if err := fn(info); err != nil {
 				return err
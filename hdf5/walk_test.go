@@ -1,8 +1,14 @@
 package hdf5
 
 import (
+	"errors"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
 )
 
 func TestParseAttrPath(t *testing.T) {
@@ -20,6 +26,12 @@ func TestParseAttrPath(t *testing.T) {
 		{"", "", "", true},                    // empty
 		{"/path/no/at", "", "", true},         // missing @
 		{"/path@", "", "", true},              // empty attr name
+		{"@attr", "/", "attr", false},
+		{`/data@meta\@v2`, "/data", "meta@v2", false},
+		{`/run\@3@units`, "/run@3", "units", false},
+		{`/data@a\\@b`, "/data@a\\", "b", false},
+		{`/data\x@a\y`, `/data\x`, `a\y`, false},
+		{`/data\@units`, "", "", true},
 	}
 
 	for _, tt := range tests {
@@ -54,6 +66,8 @@ func TestJoinAttrPath(t *testing.T) {
 		{"/", "attr", "/@attr"},
 		{"/data", "units", "/data@units"},
 		{"/group/dataset", "calibration", "/group/dataset@calibration"},
+		{"/data", "meta@v2", `/data@meta\@v2`},
+		{"/run@3", `a\b`, `/run\@3@a\\b`},
 	}
 
 	for _, tt := range tests {
@@ -62,6 +76,10 @@ func TestJoinAttrPath(t *testing.T) {
 			if got != tt.want {
 				t.Errorf("got %q, want %q", got, tt.want)
 			}
+			obj, attr, err := ParseAttrPath(got)
+			if err != nil || obj != tt.objectPath || attr != tt.attrName {
+				t.Errorf("ParseAttrPath(%q) = %q, %q, %v", got, obj, attr, err)
+			}
 		})
 	}
 }
@@ -215,6 +233,77 @@ func TestGetAttrNotFound(t *testing.T) {
 	}
 }
 
+func TestGetAttrAt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "attr_at.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := f.Root().SetAttr("version", int64(2)); err != nil {
+		t.Fatalf("SetAttr failed: %v", err)
+	}
+	g, err := f.Root().CreateGroup("inst")
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	if _, err := g.CreateDataset("run@3", []int32{1, 2}, WithAttribute("meta@v2", "x"), WithAttribute("units", "m")); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	inst, err := f.OpenGroup("inst")
+	if err != nil {
+		t.Fatalf("OpenGroup failed: %v", err)
+	}
+
+	reads := []struct {
+		name string
+		read func() (interface{}, error)
+		want interface{}
+	}{
+		{"root", func() (interface{}, error) { return f.ReadAttr("@version") }, int64(2)},
+		{"root at", func() (interface{}, error) { return f.ReadAttrAt("", "version") }, int64(2)},
+		{"escaped", func() (interface{}, error) { return f.ReadAttr(`/inst/run\@3@meta\@v2`) }, "x"},
+		{"at", func() (interface{}, error) { return f.ReadAttrAt("/inst/run@3", "meta@v2") }, "x"},
+		{"group", func() (interface{}, error) { return inst.ReadAttr(`run\@3@units`) }, "m"},
+		{"group root", func() (interface{}, error) { return f.Root().ReadAttr("@version") }, int64(2)},
+	}
+	for _, tt := range reads {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.read()
+			if err != nil || got != tt.want {
+				t.Errorf("got %v, %v; want %v", got, err, tt.want)
+			}
+		})
+	}
+
+	// Attribute paths round-trip through JoinAttrPath
+	ds, err := inst.OpenDataset("run@3")
+	if err != nil {
+		t.Fatalf("OpenDataset failed: %v", err)
+	}
+	if got, err := f.GetAttr(ds.Attr("meta@v2").path); err != nil || got.Name() != "meta@v2" {
+		t.Errorf("GetAttr(%q) = %v, %v", ds.Attr("meta@v2").path, got, err)
+	}
+
+	if _, err := f.GetAttrAt("/inst/run@3", "nope"); !errors.Is(err, ErrAttributeNotFound) || errors.Is(err, ErrNotFound) {
+		t.Errorf("missing attribute: got %v, want ErrAttributeNotFound", err)
+	}
+	if _, err := f.GetAttrAt("/inst/nope", "units"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing object: got %v, want ErrNotFound", err)
+	}
+	if _, err := f.GetAttrAt("/inst/run@3", ""); err == nil {
+		t.Error("empty attribute name: expected an error")
+	}
+}
+
 func TestWalkAttrs(t *testing.T) {
 	path := skipIfNoTestdata(t, "attributes.h5")
 
@@ -372,3 +461,132 @@ func TestAttrInfoFields(t *testing.T) {
 		t.Fatalf("WalkAttrs failed: %v", err)
 	}
 }
+
+// writeWalkFile writes a file whose hierarchy has a dataset linked twice,
+// a cycle of hard links between /a and /a/b, and soft links:
+//
+//	/data
+//	/a/b/x
+//	/a/b/back -> /a
+//	/a/dup -> /data
+//	/a/soft -> /data (soft)
+//	/a/dangling -> /missing (soft)
+func writeWalkFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "walk.h5")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := f.Root().CreateDataset("data", []float64{1, 2, 3}); err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	dataAddr := f.root.pendingLinks[0].ObjectAddress
+
+	xData := f.allocate(8)
+	f64 := message.NewFloatDatatype(8, message.OrderLE)
+	xMsgs := object.NewDatasetHeader(message.NewDataspace([]uint64{1}, nil), f64, message.NewContiguousLayout(xData, 8))
+	xAddr := f.allocate(int64(object.HeaderSize(f.writer, xMsgs)))
+	if _, err := object.WriteHeader(f.writer.At(int64(xAddr)), xMsgs); err != nil {
+		t.Fatalf("writing /a/b/x: %v", err)
+	}
+
+	// Reserve both group headers, whose sizes do not depend on the
+	// addresses their links hold, before writing them
+	aLinks := func(b uint64) []*message.Link {
+		return []*message.Link{
+			message.NewHardLink("b", b),
+			message.NewHardLink("dup", dataAddr),
+			message.NewSoftLink("soft", "/data"),
+			message.NewSoftLink("dangling", "/missing"),
+		}
+	}
+	bLinks := func(a uint64) []*message.Link {
+		return []*message.Link{message.NewHardLink("x", xAddr), message.NewHardLink("back", a)}
+	}
+	aAddr := f.allocate(int64(object.HeaderSize(f.writer, object.NewGroupHeader(aLinks(0)))))
+	bAddr := f.allocate(int64(object.HeaderSize(f.writer, object.NewGroupHeader(bLinks(0)))))
+	for _, g := range []struct {
+		addr  uint64
+		links []*message.Link
+	}{{aAddr, aLinks(bAddr)}, {bAddr, bLinks(aAddr)}} {
+		if _, err := object.WriteHeader(f.writer.At(int64(g.addr)), object.NewGroupHeader(g.links)); err != nil {
+			t.Fatalf("writing group header: %v", err)
+		}
+	}
+	if err := f.Root().addLink(message.NewHardLink("a", aAddr)); err != nil {
+		t.Fatalf("linking /a: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return path
+}
+
+func TestFileWalk(t *testing.T) {
+	f, err := Open(writeWalkFile(t))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	walk := func(opts WalkOptions, visit func(path string, obj Object) error) ([]string, error) {
+		var paths []string
+		err := f.Walk(func(path string, obj Object) error {
+			kind := "G"
+			if _, ok := obj.(*Dataset); ok {
+				kind = "D"
+			}
+			paths = append(paths, kind+" "+path)
+			if visit != nil {
+				return visit(path, obj)
+			}
+			return nil
+		}, opts)
+		return paths, err
+	}
+
+	// Hard links only: /a/dup and /a/b/back reach visited objects
+	paths, err := walk(WalkOptions{}, nil)
+	want := []string{"G /", "D /data", "G /a", "G /a/b", "D /a/b/x"}
+	if err != nil || !reflect.DeepEqual(paths, want) {
+		t.Errorf("Walk() = %v, %v, want %v", paths, err, want)
+	}
+
+	// Following soft links reaches the dangling one
+	_, err = walk(WalkOptions{FollowSoftLinks: true}, nil)
+	if !errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "/a/dangling") {
+		t.Errorf("Walk(FollowSoftLinks) error = %v, want ErrNotFound for /a/dangling", err)
+	}
+	var failed []string
+	paths, err = walk(WalkOptions{FollowSoftLinks: true, OnError: func(path string, err error) error {
+		failed = append(failed, path)
+		return nil
+	}}, nil)
+	if err != nil || !reflect.DeepEqual(paths, want) || !reflect.DeepEqual(failed, []string{"/a/dangling"}) {
+		t.Errorf("Walk(OnError) = %v, %v, failed %v", paths, err, failed)
+	}
+
+	// SkipGroup prunes /a
+	paths, err = walk(WalkOptions{}, func(path string, obj Object) error {
+		if path == "/a" {
+			return SkipGroup
+		}
+		return nil
+	})
+	if want := []string{"G /", "D /data", "G /a"}; err != nil || !reflect.DeepEqual(paths, want) {
+		t.Errorf("Walk(SkipGroup) = %v, %v, want %v", paths, err, want)
+	}
+
+	// Other errors stop the walk and are returned as is
+	errStop := errors.New("stop")
+	paths, err = walk(WalkOptions{}, func(path string, obj Object) error {
+		if path == "/a/b" {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop || len(paths) != 4 {
+		t.Errorf("Walk(stop) = %v, %v, want 4 paths and errStop", paths, err)
+	}
+}
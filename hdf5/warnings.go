@@ -0,0 +1,164 @@
+package hdf5
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/robert-malhotra/go-hdf5/internal/layout"
+	"github.com/robert-malhotra/go-hdf5/internal/object"
+)
+
+// WarningKind identifies the kind of structural anomaly a Warning reports.
+type WarningKind int
+
+const (
+	// WarnDuplicateLink reports a group holding more than one link with the
+	// same name. The first link wins everywhere; later ones are ignored.
+	WarnDuplicateLink WarningKind = iota
+
+	// WarnTrailingBytes reports an object header message whose body ends
+	// in nonzero bytes after the fields its version defines. The bytes
+	// are ignored.
+	WarnTrailingBytes
+
+	// WarnUnreadableAttributes reports an object whose attributes in dense
+	// storage could not be read. Only the attributes in its object header
+	// are listed. Attrs and Attr have no error result, so this is recorded
+	// in strict mode too; CollectAttrs fails instead.
+	WarnUnreadableAttributes
+
+	// WarnShuffleElementSize reports a chunked dataset whose shuffle filter
+	// shuffles elements of other than its datatype's size, which the HDF5
+	// library never writes. Chunks are unshuffled with the filter's size,
+	// as the HDF5 library reads them.
+	WarnShuffleElementSize
+)
+
+func (k WarningKind) String() string {
+	switch k {
+	case WarnDuplicateLink:
+		return "DuplicateLink"
+	case WarnTrailingBytes:
+		return "TrailingBytes"
+	case WarnUnreadableAttributes:
+		return "UnreadableAttributes"
+	case WarnShuffleElementSize:
+		return "ShuffleElementSize"
+	default:
+		return fmt.Sprintf("WarningKind(%d)", int(k))
+	}
+}
+
+// Warning describes a recoverable anomaly found while reading a file.
+// In strict mode (see WithStrict) the same anomalies are errors instead.
+type Warning struct {
+	Kind WarningKind
+	Path string // Object path the warning concerns
+
+	// Addresses lists the object addresses involved; for WarnDuplicateLink,
+	// the target of each link with the name, in header order (0 for links
+	// that are not hard links); for WarnTrailingBytes, the object header.
+	Addresses []uint64
+
+	// Count is the number of junk bytes for WarnTrailingBytes, summed over
+	// the object's messages.
+	Count int
+
+	// Err is the error reading failed with, for WarnUnreadableAttributes,
+	// and the sizes that differ, for WarnShuffleElementSize.
+	Err error
+}
+
+func (w Warning) String() string {
+	switch w.Kind {
+	case WarnTrailingBytes:
+		return fmt.Sprintf("%s: %s %v (%d bytes)", w.Kind, w.Path, w.Addresses, w.Count)
+	case WarnUnreadableAttributes, WarnShuffleElementSize:
+		return fmt.Sprintf("%s: %s %v: %v", w.Kind, w.Path, w.Addresses, w.Err)
+	}
+	return fmt.Sprintf("%s: %s %v", w.Kind, w.Path, w.Addresses)
+}
+
+// warningLog collects warnings, recording each (kind, path) pair once.
+type warningLog struct {
+	mu       sync.Mutex
+	warnings []Warning
+	seen     map[warningKey]bool
+}
+
+type warningKey struct {
+	kind WarningKind
+	path string
+}
+
+func (l *warningLog) add(w Warning) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := warningKey{w.Kind, w.Path}
+	if l.seen[key] {
+		return
+	}
+	if l.seen == nil {
+		l.seen = make(map[warningKey]bool)
+	}
+	l.seen[key] = true
+	l.warnings = append(l.warnings, w)
+}
+
+// Warnings returns the anomalies recorded so far, in the order they were
+// found. Warnings are recorded lazily as objects are read, so the list grows
+// as more of the file is accessed.
+func (f *File) Warnings() []Warning {
+	f.warnings.mu.Lock()
+	defer f.warnings.mu.Unlock()
+	return append([]Warning(nil), f.warnings.warnings...)
+}
+
+// checkTrailingBytes records a WarnTrailingBytes warning for an object whose
+// header messages end in junk, or fails with ErrTrailingBytes in strict mode.
+func (f *File) checkTrailingBytes(header *object.Header, objPath string) error {
+	if len(header.TrailingBytes) == 0 {
+		return nil
+	}
+
+	count := 0
+	for _, tb := range header.TrailingBytes {
+		count += tb.Count
+	}
+	if f.strict {
+		return fmt.Errorf("%w: %d bytes after %s message in %q", ErrTrailingBytes,
+			header.TrailingBytes[0].Count, header.TrailingBytes[0].Type, objPath)
+	}
+	f.warnings.add(Warning{
+		Kind:      WarnTrailingBytes,
+		Path:      objPath,
+		Addresses: []uint64{header.Address},
+		Count:     count,
+	})
+	return nil
+}
+
+// checkShuffleSize records a WarnShuffleElementSize warning for a dataset
+// whose shuffle filter does not match its datatype, or fails with
+// ErrShuffleElementSize in strict mode.
+func (f *File) checkShuffleSize(ds *Dataset) error {
+	chunked, ok := ds.layout.(*layout.Chunked)
+	if !ok {
+		return nil
+	}
+	err := chunked.CheckShuffle()
+	if err == nil {
+		return nil
+	}
+	if f.strict {
+		return withPath(ds.path, err)
+	}
+	f.warnings.add(Warning{
+		Kind:      WarnShuffleElementSize,
+		Path:      ds.path,
+		Addresses: []uint64{ds.headerAddr()},
+		Err:       err,
+	})
+	return nil
+}
@@ -3,6 +3,7 @@ package alloc
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 )
 
@@ -198,15 +199,15 @@ func (a *Allocator) Validate() error {
 		}
 	}
 
-	// Check for overlaps (simple O(n²) check for debugging)
-	for i := 0; i < len(a.allocations); i++ {
-		for j := i + 1; j < len(a.allocations); j++ {
-			a1, a2 := a.allocations[i], a.allocations[j]
-			// Check if they overlap
-			if a1.Addr < a2.Addr+a2.Size && a2.Addr < a1.Addr+a1.Size {
-				return fmt.Errorf("overlapping allocations: [0x%x, size %d] and [0x%x, size %d]",
-					a1.Addr, a1.Size, a2.Addr, a2.Size)
-			}
+	// Check for overlaps between neighbours in address order
+	sorted := make([]Allocation, len(a.allocations))
+	copy(sorted, a.allocations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Addr < sorted[j].Addr })
+	for i := 1; i < len(sorted); i++ {
+		a1, a2 := sorted[i-1], sorted[i]
+		if a2.Addr < a1.Addr+a1.Size {
+			return fmt.Errorf("overlapping allocations: [0x%x, size %d] and [0x%x, size %d]",
+				a1.Addr, a1.Size, a2.Addr, a2.Size)
 		}
 	}
 
@@ -1,15 +1,26 @@
 package binary
 
-// Lookup3Checksum computes the Jenkins lookup3 hash used by HDF5 for
-// metadata checksums (superblock v2/v3, object header v2, etc.).
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrChecksumMismatch is returned when the checksum stored with a metadata
+// structure or a chunk does not match its contents.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ChecksumLookup3 computes Bob Jenkins' lookup3 hash (hashlittle) of data,
+// exactly as the HDF5 library's H5_checksum_lookup3 does, including its
+// handling of a 1-12 byte tail and of empty input.
 //
-// This implementation follows the HDF5 library's H5_checksum_lookup3
-// which uses the hashlittle variant with initial values of 0.
-func Lookup3Checksum(data []byte) uint32 {
+// Every checksummed metadata structure (superblock v2/v3, object header v2,
+// B-tree v2, fractal heap and extensible/fixed array blocks) uses an init of
+// 0. The library passes other initial values only when hashing lookup keys,
+// such as shared message hashes, which are not checksums.
+func ChecksumLookup3(data []byte, init uint32) uint32 {
 	length := len(data)
 	// Jenkins hashlittle: a = b = c = 0xdeadbeef + length + initval
-	// HDF5 uses initval = 0
-	initval := uint32(0xdeadbeef) + uint32(length)
+	initval := uint32(0xdeadbeef) + uint32(length) + init
 	a, b, c := initval, initval, initval
 	k := data
 
@@ -120,28 +131,35 @@ func rotl32(x uint32, k uint) uint32 {
 // Fletcher32 computes the Fletcher-32 checksum used by HDF5 for data
 // verification (filter pipeline checksum).
 //
-// The input is treated as a sequence of 16-bit words in little-endian order.
-// If the input has an odd number of bytes, it is padded with a zero byte.
+// As in the HDF5 library, the input is treated as a sequence of 16-bit
+// big-endian words; an odd final byte is the high byte of a last word whose
+// low byte is zero. The sums are reduced with end-around carry, so a sum
+// congruent to 0 modulo 65535 is 0xFFFF unless it is zero.
 func Fletcher32(data []byte) uint32 {
 	var sum1, sum2 uint32
-
-	// Process 16-bit words
-	length := len(data)
-	i := 0
-	for ; i+1 < length; i += 2 {
-		word := uint32(data[i]) | uint32(data[i+1])<<8
-		sum1 = (sum1 + word) % 65535
-		sum2 = (sum2 + sum1) % 65535
+	fold := func() {
+		sum1 = sum1&0xFFFF + sum1>>16
+		sum2 = sum2&0xFFFF + sum2>>16
 	}
 
-	// Handle odd byte (pad with zero)
-	if i < length {
-		word := uint32(data[i])
-		sum1 = (sum1 + word) % 65535
-		sum2 = (sum2 + sum1) % 65535
+	// Blocks of 360 words cannot overflow the 32-bit sums before folding
+	words := len(data) / 2
+	for i := 0; i < words; {
+		end := min(i+360, words)
+		for ; i < end; i++ {
+			sum1 += uint32(data[2*i])<<8 | uint32(data[2*i+1])
+			sum2 += sum1
+		}
+		fold()
 	}
+	if len(data)%2 != 0 {
+		sum1 += uint32(data[len(data)-1]) << 8
+		sum2 += sum1
+		fold()
+	}
+	fold()
 
-	return (sum2 << 16) | sum1
+	return sum2<<16 | sum1
 }
 
 // VerifyFletcher32 verifies data against an expected Fletcher-32 checksum.
@@ -149,7 +167,36 @@ func VerifyFletcher32(data []byte, expected uint32) bool {
 	return Fletcher32(data) == expected
 }
 
-// VerifyLookup3 verifies data against an expected lookup3 checksum.
+// VerifyLookup3 verifies data against an expected metadata checksum.
 func VerifyLookup3(data []byte, expected uint32) bool {
-	return Lookup3Checksum(data) == expected
+	return ChecksumLookup3(data, 0) == expected
+}
+
+// CheckLookup3 checks the lookup3 checksum in the last four bytes of block,
+// a structure with the given signature read from addr. A mismatch wraps
+// ErrChecksumMismatch and names the structure and its address.
+func CheckLookup3(block []byte, structure string, addr uint64) error {
+	n := len(block)
+	if n < 4 {
+		return fmt.Errorf("%s at %d: %d bytes is too short for a checksum", structure, addr, n)
+	}
+	stored := uint32(block[n-4]) | uint32(block[n-3])<<8 | uint32(block[n-2])<<16 | uint32(block[n-1])<<24
+	if computed := ChecksumLookup3(block[:n-4], 0); computed != stored {
+		return fmt.Errorf("%s at %d: %w: stored %#08x, computed %#08x", structure, addr, ErrChecksumMismatch, stored, computed)
+	}
+	return nil
+}
+
+// CheckLookup3At reads the size bytes of a structure at addr, ending with
+// its lookup3 checksum, and checks them with CheckLookup3 if the reader
+// was configured to verify checksums. Otherwise it does nothing.
+func (r *Reader) CheckLookup3At(structure string, addr uint64, size int) error {
+	if !r.verify {
+		return nil
+	}
+	block, err := r.AtSize(int64(addr), size).ReadBytes(size)
+	if err != nil {
+		return fmt.Errorf("reading %s at %d: %w", structure, addr, err)
+	}
+	return CheckLookup3(block, structure, addr)
 }
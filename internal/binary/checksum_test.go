@@ -1,6 +1,14 @@
 package binary
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -19,10 +27,10 @@ func TestLookup3Checksum(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result1 := Lookup3Checksum(tt.input)
-			result2 := Lookup3Checksum(tt.input)
+			result1 := ChecksumLookup3(tt.input, 0)
+			result2 := ChecksumLookup3(tt.input, 0)
 			if result1 != result2 {
-				t.Errorf("Lookup3Checksum not consistent: got 0x%08x then 0x%08x",
+				t.Errorf("ChecksumLookup3 not consistent: got 0x%08x then 0x%08x",
 					result1, result2)
 			}
 		})
@@ -38,7 +46,7 @@ func TestLookup3ChecksumLengthVariations(t *testing.T) {
 		for i := range data {
 			data[i] = byte(i)
 		}
-		cs := Lookup3Checksum(data)
+		cs := ChecksumLookup3(data, 0)
 		checksums[cs] = length
 	}
 
@@ -48,6 +56,52 @@ func TestLookup3ChecksumLengthVariations(t *testing.T) {
 	}
 }
 
+// TestChecksumLookup3Vectors checks against checksums written by the HDF5 C
+// library; see testdata/lookup3.txt.
+func TestChecksumLookup3Vectors(t *testing.T) {
+	file, err := os.Open("testdata/lookup3.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	count := 0
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 3 {
+			t.Fatalf("line %d: want 3 fields, got %d", line, len(fields))
+		}
+		init, err1 := strconv.ParseUint(fields[0], 16, 32)
+		want, err2 := strconv.ParseUint(fields[1], 16, 32)
+		var data []byte
+		var err3 error
+		if fields[2] != "-" {
+			data, err3 = hex.DecodeString(fields[2])
+		}
+		if err1 != nil || err2 != nil || err3 != nil {
+			t.Fatalf("line %d: malformed vector", line)
+		}
+
+		if got := ChecksumLookup3(data, uint32(init)); got != uint32(want) {
+			t.Errorf("line %d: %d bytes, init 0x%x: got 0x%08x, want 0x%08x",
+				line, len(data), init, got, want)
+		}
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if count == 0 {
+		t.Fatal("no vectors found")
+	}
+}
+
 func TestFletcher32(t *testing.T) {
 	// Test basic properties and consistency
 	tests := []struct {
@@ -76,6 +130,27 @@ func TestFletcher32(t *testing.T) {
 	if result := Fletcher32([]byte{}); result != 0 {
 		t.Errorf("Fletcher32(empty) should be 0, got 0x%08x", result)
 	}
+
+	// Values of the HDF5 library's H5_checksum_fletcher32, which reads
+	// big-endian words and reduces with end-around carry
+	long := make([]byte, 2048)
+	for i := range long {
+		long[i] = byte(i)
+	}
+	for _, tt := range []struct {
+		input []byte
+		want  uint32
+	}{
+		{[]byte("abcde"), 0x4ff029c7},
+		{[]byte("abcdef"), 0x50562a2d},
+		{[]byte{0xff, 0xff}, 0xffffffff},
+		{[]byte{0x01, 0x02, 0x03}, 0x05040402},
+		{long, 0x282e01fe},
+	} {
+		if got := Fletcher32(tt.input); got != tt.want {
+			t.Errorf("Fletcher32(% x...) = 0x%08x, want 0x%08x", tt.input[:min(len(tt.input), 8)], got, tt.want)
+		}
+	}
 }
 
 func TestFletcher32OddLength(t *testing.T) {
@@ -107,7 +182,7 @@ func TestVerifyFletcher32(t *testing.T) {
 
 func TestVerifyLookup3(t *testing.T) {
 	data := []byte("test data for verification")
-	checksum := Lookup3Checksum(data)
+	checksum := ChecksumLookup3(data, 0)
 
 	if !VerifyLookup3(data, checksum) {
 		t.Error("VerifyLookup3 should return true for matching checksum")
@@ -118,6 +193,30 @@ func TestVerifyLookup3(t *testing.T) {
 	}
 }
 
+func TestCheckLookup3At(t *testing.T) {
+	block := []byte("TEST block contents")
+	sum := ChecksumLookup3(block, 0)
+	block = append(block, byte(sum), byte(sum>>8), byte(sum>>16), byte(sum>>24))
+	file := append(make([]byte, 16), block...)
+
+	r := NewReader(bytes.NewReader(file), Config{ByteOrder: binary.LittleEndian, OffsetSize: 8, LengthSize: 8, VerifyChecksums: true})
+	if err := r.CheckLookup3At("TEST", 16, len(block)); err != nil {
+		t.Errorf("CheckLookup3At on intact block: %v", err)
+	}
+
+	file[20] ^= 0x01
+	err := r.CheckLookup3At("TEST", 16, len(block))
+	if !errors.Is(err, ErrChecksumMismatch) || !strings.HasPrefix(err.Error(), "TEST at 16: checksum mismatch") {
+		t.Errorf("CheckLookup3At on corrupt block: err = %v", err)
+	}
+
+	// Readers not configured to verify skip the check
+	r = NewReader(bytes.NewReader(file), DefaultConfig())
+	if err := r.At(100).CheckLookup3At("TEST", 16, len(block)); err != nil {
+		t.Errorf("CheckLookup3At without verification: %v", err)
+	}
+}
+
 func BenchmarkLookup3Checksum(b *testing.B) {
 	data := make([]byte, 4096)
 	for i := range data {
@@ -126,7 +225,7 @@ func BenchmarkLookup3Checksum(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		Lookup3Checksum(data)
+		ChecksumLookup3(data, 0)
 	}
 }
 
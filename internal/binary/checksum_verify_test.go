@@ -16,7 +16,7 @@ func TestVerifyMinimalChecksum(t *testing.T) {
     t.Log("=== Superblock Checksum ===")
     sbData := data[:44]
     sbChecksum := binary.LittleEndian.Uint32(data[44:48])
-    calculatedSb := Lookup3Checksum(sbData)
+    calculatedSb := ChecksumLookup3(sbData, 0)
     t.Logf("Stored: 0x%08x", sbChecksum)
     t.Logf("Calculated: 0x%08x", calculatedSb)
     if sbChecksum != calculatedSb {
@@ -35,7 +35,7 @@ func TestVerifyMinimalChecksum(t *testing.T) {
     checksumOffset := ohdrStart + 7 + chunkSize // checksum is at end of chunk
     ohdrData := data[ohdrStart:checksumOffset]
     storedChecksum := binary.LittleEndian.Uint32(data[checksumOffset:])
-    calculatedOhdr := Lookup3Checksum(ohdrData)
+    calculatedOhdr := ChecksumLookup3(ohdrData, 0)
     
     t.Logf("Checksum at 0x%x", checksumOffset)
     t.Logf("Stored: 0x%08x", storedChecksum)
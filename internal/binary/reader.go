@@ -4,27 +4,69 @@ package binary
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
 )
 
 // ErrInvalidSize is returned when an invalid offset or length size is specified.
 var ErrInvalidSize = errors.New("invalid offset/length size: must be 2, 4, or 8")
 
+// DefaultReadAhead is the number of bytes a reader from At reads at once
+// to serve the field reads that follow from memory.
+const DefaultReadAhead = 4096
+
+const (
+	maxReadAhead = 1 << 20 // Largest read-ahead AtSize allows
+	maxFieldRead = 64      // Largest read that fills the read-ahead buffer
+)
+
 // Reader provides methods for reading HDF5 binary data with variable-width
 // offset and length fields.
+//
+// If the underlying io.ReaderAt has a Size method, as bytes.Reader and
+// io.SectionReader do, reads past its end fail before anything is
+// allocated, so that sizes read from a corrupt file cannot exhaust memory.
+//
+// Readers from At and AtSize read ahead: a field-sized read fills a buffer
+// from the current position, and later reads within it are served from
+// memory, so that parsing a header or B-tree node field by field takes one
+// ReadAt call instead of one per field. Larger reads outside the buffer,
+// such as of chunk data, go to the underlying reader directly. The buffer
+// belongs to one reader, so a reader must not be kept across writes to the
+// region it has read.
 type Reader struct {
 	r          io.ReaderAt
 	order      binary.ByteOrder
 	offsetSize int
 	lengthSize int
 	pos        int64
+	verify     bool
+	shared     SharedMessageFunc
+	size       int64 // Size of r, or -1 if unknown
+
+	readAhead int    // Bytes to buffer at once; 0 reads every field directly
+	buf       []byte // Bytes of r read ahead, from bufStart
+	bufStart  int64
 }
 
+// SharedMessageFunc returns the body of a header message of type typ
+// stored in a file's shared object header message heap under the heap ID
+// id.
+type SharedMessageFunc func(typ uint16, id []byte) ([]byte, error)
+
 // Config holds reader configuration, typically derived from the superblock.
 type Config struct {
 	ByteOrder  binary.ByteOrder
 	OffsetSize int // 2, 4, or 8 bytes
 	LengthSize int // 2, 4, or 8 bytes
+
+	// VerifyChecksums makes readers of checksummed metadata structures
+	// check checksums they would otherwise skip; see Reader.CheckLookup3At.
+	VerifyChecksums bool
+
+	// SharedMessages looks up header messages stored in the file's shared
+	// object header message heap; see Reader.SharedMessage.
+	SharedMessages SharedMessageFunc
 }
 
 // DefaultConfig returns a configuration suitable for initial superblock reading.
@@ -45,18 +87,45 @@ func NewReader(r io.ReaderAt, cfg Config) *Reader {
 		offsetSize: cfg.OffsetSize,
 		lengthSize: cfg.LengthSize,
 		pos:        0,
+		verify:     cfg.VerifyChecksums,
+		shared:     cfg.SharedMessages,
+		size:       readerSize(r),
 	}
 }
 
+// readerSize returns the size of r if it reports one, or -1.
+func readerSize(r io.ReaderAt) int64 {
+	if s, ok := r.(interface{ Size() int64 }); ok {
+		return s.Size()
+	}
+	return -1
+}
+
 // At returns a new reader positioned at the given offset.
-// The new reader shares the underlying io.ReaderAt but has independent position.
+// The new reader shares the underlying io.ReaderAt but has independent
+// position and read-ahead buffer, reading ahead DefaultReadAhead bytes.
 func (r *Reader) At(offset int64) *Reader {
+	return r.AtSize(offset, DefaultReadAhead)
+}
+
+// AtSize is like At for reading a structure of known size n at offset: it
+// reads ahead n bytes, up to 1MiB, so that the whole structure is read at
+// once. An n of 0 or less reads ahead DefaultReadAhead bytes.
+func (r *Reader) AtSize(offset int64, n int) *Reader {
+	if n <= 0 {
+		n = DefaultReadAhead
+	}
+	n = min(n, maxReadAhead)
 	return &Reader{
 		r:          r.r,
 		order:      r.order,
 		offsetSize: r.offsetSize,
 		lengthSize: r.lengthSize,
 		pos:        offset,
+		verify:     r.verify,
+		shared:     r.shared,
+		size:       r.size,
+		readAhead:  n,
 	}
 }
 
@@ -69,6 +138,10 @@ func (r *Reader) WithSizes(offsetSize, lengthSize int) *Reader {
 		offsetSize: offsetSize,
 		lengthSize: lengthSize,
 		pos:        r.pos,
+		verify:     r.verify,
+		shared:     r.shared,
+		size:       r.size,
+		readAhead:  r.readAhead,
 	}
 }
 
@@ -77,13 +150,16 @@ func (r *Reader) Pos() int64 {
 	return r.pos
 }
 
-// ReadBytes reads exactly n bytes from the current position.
+// ReadBytes reads exactly n bytes from the current position. A negative n,
+// as a size from the file truncated to int can be, is an error.
 func (r *Reader) ReadBytes(n int) ([]byte, error) {
-	if n <= 0 {
+	if n < 0 {
+		return nil, fmt.Errorf("reading %d bytes: negative length", n)
+	}
+	if n == 0 {
 		return nil, nil
 	}
-	buf := make([]byte, n)
-	_, err := r.r.ReadAt(buf, r.pos)
+	buf, err := r.read(n)
 	if err != nil {
 		return nil, err
 	}
@@ -91,6 +167,69 @@ func (r *Reader) ReadBytes(n int) ([]byte, error) {
 	return buf, nil
 }
 
+// ReadInto reads len(buf) bytes from the current position into buf, for
+// callers that reuse a buffer from one read to the next.
+func (r *Reader) ReadInto(buf []byte) error {
+	if err := r.readInto(buf); err != nil {
+		return err
+	}
+	r.pos += int64(len(buf))
+	return nil
+}
+
+// read returns n bytes from the current position without advancing it.
+func (r *Reader) read(n int) ([]byte, error) {
+	if err := r.checkAvailable(n); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, n)
+	if err := r.readInto(buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// readInto fills buf from the current position without advancing it, from
+// the read-ahead buffer if the reader has one.
+func (r *Reader) readInto(buf []byte) error {
+	n := len(buf)
+	if err := r.checkAvailable(n); err != nil {
+		return err
+	}
+	if r.readAhead > 0 {
+		if !r.buffered(n) && n <= maxFieldRead && n < r.readAhead {
+			r.fill()
+		}
+		if r.buffered(n) {
+			copy(buf, r.buf[r.pos-r.bufStart:])
+			return nil
+		}
+	}
+	_, err := r.r.ReadAt(buf, r.pos)
+	return err
+}
+
+// buffered reports whether the n bytes at the current position are in the
+// read-ahead buffer.
+func (r *Reader) buffered(n int) bool {
+	return r.pos >= r.bufStart && r.pos-r.bufStart <= int64(len(r.buf))-int64(n)
+}
+
+// fill reads ahead from the current position, up to the end of a reader of
+// known size. What is read before an error is kept; a read it left short
+// goes to the underlying reader, which reports the error.
+func (r *Reader) fill() {
+	n := int64(r.readAhead)
+	if r.size >= 0 && r.size-r.pos < n {
+		n = r.size - r.pos
+	}
+	if int64(cap(r.buf)) < n {
+		r.buf = make([]byte, n)
+	}
+	m, _ := r.r.ReadAt(r.buf[:n], r.pos)
+	r.buf, r.bufStart = r.buf[:m], r.pos
+}
+
 // ReadUint8 reads an unsigned 8-bit integer.
 func (r *Reader) ReadUint8() (uint8, error) {
 	buf, err := r.ReadBytes(1)
@@ -154,6 +293,17 @@ func (r *Reader) ReadLength() (uint64, error) {
 	return r.decodeUint(buf, r.lengthSize), nil
 }
 
+// DecodeUintLE decodes a little-endian unsigned integer of up to 8 bytes,
+// as the variable-width fields of packed heap IDs and index records are
+// stored.
+func DecodeUintLE(b []byte) uint64 {
+	var v uint64
+	for i := len(b) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(b[i])
+	}
+	return v
+}
+
 // decodeUint decodes a variable-width unsigned integer.
 func (r *Reader) decodeUint(buf []byte, size int) uint64 {
 	switch size {
@@ -167,11 +317,7 @@ func (r *Reader) decodeUint(buf []byte, size int) uint64 {
 		return r.order.Uint64(buf)
 	default:
 		// Handle arbitrary sizes (little-endian assumed for non-standard)
-		var val uint64
-		for i := size - 1; i >= 0; i-- {
-			val = (val << 8) | uint64(buf[i])
-		}
-		return val
+		return DecodeUintLE(buf[:size])
 	}
 }
 
@@ -228,12 +374,21 @@ func (r *Reader) Peek(n int) ([]byte, error) {
 	if n <= 0 {
 		return nil, nil
 	}
-	buf := make([]byte, n)
-	_, err := r.r.ReadAt(buf, r.pos)
-	if err != nil {
-		return nil, err
+	return r.read(n)
+}
+
+// checkAvailable returns io.ErrUnexpectedEOF if n bytes at the current
+// position would run past the end of a reader of known size.
+func (r *Reader) checkAvailable(n int) error {
+	if r.size >= 0 && (r.pos < 0 || r.pos > r.size || int64(n) > r.size-r.pos) {
+		return io.ErrUnexpectedEOF
 	}
-	return buf, nil
+	return nil
+}
+
+// Size returns the size of the data read, or -1 if it is unknown.
+func (r *Reader) Size() int64 {
+	return r.size
 }
 
 // OffsetSize returns the configured offset size in bytes.
@@ -246,6 +401,22 @@ func (r *Reader) LengthSize() int {
 	return r.lengthSize
 }
 
+// VerifyChecksums reports whether the reader was configured to verify
+// optional metadata checksums.
+func (r *Reader) VerifyChecksums() bool {
+	return r.verify
+}
+
+// SharedMessage returns the body of a header message of type typ stored in
+// the file's shared object header message heap under the heap ID id, using
+// the configured SharedMessages function.
+func (r *Reader) SharedMessage(typ uint16, id []byte) ([]byte, error) {
+	if r.shared == nil {
+		return nil, fmt.Errorf("no shared object header message heap to look up message type %d in", typ)
+	}
+	return r.shared(typ, id)
+}
+
 // ByteOrder returns the configured byte order.
 func (r *Reader) ByteOrder() binary.ByteOrder {
 	return r.order
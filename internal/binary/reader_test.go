@@ -3,6 +3,8 @@ package binary
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
+	"io"
 	"testing"
 )
 
@@ -131,6 +133,24 @@ func TestReaderReadOffset(t *testing.T) {
 	}
 }
 
+func TestDecodeUintLE(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		expected uint64
+	}{
+		{nil, 0},
+		{[]byte{0x12}, 0x12},
+		{[]byte{0x56, 0x34, 0x12}, 0x123456},
+		{[]byte{0x9A, 0x78, 0x56, 0x34, 0x12}, 0x123456789A},
+		{[]byte{0xF0, 0xDE, 0xBC, 0x9A, 0x78, 0x56, 0x34, 0x12}, 0x123456789ABCDEF0},
+	}
+	for _, tt := range tests {
+		if v := DecodeUintLE(tt.data); v != tt.expected {
+			t.Errorf("DecodeUintLE(%x) = 0x%x, want 0x%x", tt.data, v, tt.expected)
+		}
+	}
+}
+
 func TestReaderAt(t *testing.T) {
 	data := bytesReaderAt{0x00, 0x01, 0x02, 0x03, 0x04, 0x05}
 	r := NewReader(data, DefaultConfig())
@@ -271,3 +291,113 @@ func TestReaderWithSizes(t *testing.T) {
 		t.Errorf("expected 0x1234, got 0x%x", v)
 	}
 }
+
+func TestReaderReadPastEnd(t *testing.T) {
+	r := NewReader(bytes.NewReader(make([]byte, 16)), DefaultConfig())
+	if r.Size() != 16 {
+		t.Errorf("Size() = %d, want 16", r.Size())
+	}
+	// A size read from a corrupt file fails before it is allocated
+	if _, err := r.At(8).ReadBytes(1 << 62); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("ReadBytes past the end: err = %v, want io.ErrUnexpectedEOF", err)
+	}
+	if _, err := r.At(20).Peek(1); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Peek past the end: err = %v, want io.ErrUnexpectedEOF", err)
+	}
+	if b, err := r.At(8).ReadBytes(8); err != nil || len(b) != 8 {
+		t.Errorf("ReadBytes to the end = %d bytes, %v", len(b), err)
+	}
+}
+
+// countingReaderAt counts the ReadAt calls made on r. It has no Size
+// method, so readers of it do not know where it ends.
+type countingReaderAt struct {
+	r     io.ReaderAt
+	reads int
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	c.reads++
+	return c.r.ReadAt(p, off)
+}
+
+func TestReaderReadAhead(t *testing.T) {
+	data := make([]byte, 3*DefaultReadAhead)
+	for i := 0; i < len(data)/4; i++ {
+		binary.LittleEndian.PutUint32(data[4*i:], uint32(i))
+	}
+	c := &countingReaderAt{r: bytes.NewReader(data)}
+	r := NewReader(c, DefaultConfig())
+
+	// Fields of a structure are read at once
+	a := r.At(400)
+	for i := 100; i < 200; i++ {
+		v, err := a.ReadUint32()
+		if err != nil || v != uint32(i) {
+			t.Fatalf("field %d = %d, %v", i, v, err)
+		}
+	}
+	if c.reads != 1 {
+		t.Errorf("%d reads for 100 fields, want 1", c.reads)
+	}
+
+	// Readers from At have their own position and buffer
+	b := r.At(0)
+	if v, err := b.ReadUint32(); err != nil || v != 0 {
+		t.Errorf("second reader read %d, %v; want 0", v, err)
+	}
+	if v, err := a.ReadUint32(); err != nil || v != 200 || c.reads != 2 {
+		t.Errorf("first reader read %d, %v after %d reads; want 200 after 2", v, err, c.reads)
+	}
+
+	// Payloads go to the underlying reader, and fields past the buffer
+	// fill it again
+	a.Skip(DefaultReadAhead)
+	if buf, err := a.ReadBytes(1000); err != nil || binary.LittleEndian.Uint32(buf) != 1225 || c.reads != 3 {
+		t.Errorf("payload read starts with %d, %v after %d reads; want 1225 after 3",
+			binary.LittleEndian.Uint32(buf), err, c.reads)
+	}
+	if v, err := a.ReadUint32(); err != nil || v != 1475 || c.reads != 4 {
+		t.Errorf("field past the buffer = %d, %v after %d reads; want 1475 after 4", v, err, c.reads)
+	}
+
+	// AtSize reads ahead only the structure's size
+	s := r.AtSize(0, 8)
+	if _, err := s.ReadUint32(); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.buf) != 8 {
+		t.Errorf("AtSize(0, 8) read ahead %d bytes, want 8", len(s.buf))
+	}
+
+	// The reader given to NewReader reads every field directly
+	c.reads = 0
+	for i := 0; i < 4; i++ {
+		if _, err := r.ReadUint32(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c.reads != 4 {
+		t.Errorf("%d reads for 4 fields without read-ahead, want 4", c.reads)
+	}
+}
+
+func TestReaderReadAheadAtEnd(t *testing.T) {
+	data := []byte{1, 2, 3, 4, 5, 6}
+	r := NewReader(&countingReaderAt{r: bytes.NewReader(data)}, DefaultConfig())
+	if r.Size() != -1 {
+		t.Fatalf("Size() = %d, want -1", r.Size())
+	}
+
+	// The short read ahead at the end of the data is kept
+	a := r.At(2)
+	if v, err := a.ReadUint16(); err != nil || v != 0x0403 {
+		t.Errorf("ReadUint16 = %#x, %v; want 0x403", v, err)
+	}
+	if v, err := a.ReadUint16(); err != nil || v != 0x0605 {
+		t.Errorf("ReadUint16 = %#x, %v; want 0x605", v, err)
+	}
+	if _, err := a.ReadUint8(); !errors.Is(err, io.EOF) {
+		t.Errorf("ReadUint8 past the end: err = %v, want io.EOF", err)
+	}
+}
@@ -204,12 +204,76 @@ func TestReadGroupEntriesInvalidSignature(t *testing.T) {
 		LengthSize: 8,
 	})
 
-	_, err := ReadGroupEntries(r, 0, nil)
+	_, err := ReadGroupEntries(r, 0, nil, GroupK{})
 	if err == nil {
 		t.Error("expected error for invalid signature")
 	}
 }
 
+func TestReadGroupEntriesUndefinedAddress(t *testing.T) {
+	r := binary.NewReader(bytes.NewReader(nil), binary.DefaultConfig())
+
+	entries, err := ReadGroupEntries(r, 0xFFFFFFFFFFFFFFFF, nil, GroupK{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if entries == nil || len(entries) != 0 {
+		t.Errorf("expected empty non-nil slice, got %#v", entries)
+	}
+}
+
+func TestReadGroupEntriesZeroEntryNode(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString("TREE")
+	buf.Write([]byte{0, 0})                   // node type (group), level (leaf)
+	buf.Write([]byte{0, 0})                   // entries used
+	buf.Write(bytes.Repeat([]byte{0xFF}, 16)) // left/right siblings
+	buf.Write(make([]byte, 8))                // key 0
+
+	r := binary.NewReader(bytes.NewReader(buf.Bytes()), binary.DefaultConfig())
+
+	entries, err := ReadGroupEntries(r, 0, nil, GroupK{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if entries == nil || len(entries) != 0 {
+		t.Errorf("expected empty non-nil slice, got %#v", entries)
+	}
+}
+
+func TestReadGroupEntriesCycle(t *testing.T) {
+	// An internal node whose two children are the node itself
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString("TREE")
+	buf.Write([]byte{0, 1})                   // node type (group), level 1
+	buf.Write([]byte{2, 0})                   // entries used
+	buf.Write(bytes.Repeat([]byte{0xFF}, 16)) // left/right siblings
+	buf.Write(make([]byte, 8*5))              // keys and children at address 0
+
+	r := binary.NewReader(bytes.NewReader(buf.Bytes()), binary.DefaultConfig())
+	if _, err := ReadGroupEntries(r, 0, nil, GroupK{}); err == nil {
+		t.Error("expected error for a B-tree node that is its own child")
+	}
+}
+
+func TestReadGroupEntriesOverfullNode(t *testing.T) {
+	// A leaf node listing three children when K allows two
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString("TREE")
+	buf.Write([]byte{0, 0})                   // node type (group), level (leaf)
+	buf.Write([]byte{3, 0})                   // entries used
+	buf.Write(bytes.Repeat([]byte{0xFF}, 16)) // left/right siblings
+	buf.Write(make([]byte, 8*7))              // keys and children
+
+	r := binary.NewReader(bytes.NewReader(buf.Bytes()), binary.DefaultConfig())
+	if _, err := ReadGroupEntries(r, 0, nil, GroupK{Internal: 1}); err == nil {
+		t.Error("expected error for a node holding more children than K allows")
+	}
+	if _, _, err := LookupGroupEntry(r, 0, nil, GroupK{Internal: 1}, "a"); err == nil {
+		t.Error("expected lookup error for a node holding more children than K allows")
+	}
+}
+
 // B-tree v2 tests
 
 func TestReadChunkIndexV2InvalidSignature(t *testing.T) {
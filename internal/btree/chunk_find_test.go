@@ -0,0 +1,369 @@
+package btree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"testing"
+
+	binpkg "github.com/robert-malhotra/go-hdf5/internal/binary"
+)
+
+// testChunks returns the entries of a rows x cols grid of 10x10 chunks,
+// leaving out every seventh one as unallocated.
+func testChunks(rows, cols uint64) []ChunkEntry {
+	var entries []ChunkEntry
+	for i := uint64(0); i < rows*cols; i++ {
+		if i%7 == 3 {
+			continue
+		}
+		entries = append(entries, ChunkEntry{
+			Offset:  []uint64{i / cols * 10, i % cols * 10},
+			Size:    uint64(100 + i%50),
+			Address: 1<<40 + i*1000,
+		})
+	}
+	return entries
+}
+
+// buildChunkTreeV1 writes a v1 B-tree chunk index for 2-D entries, in order,
+// with at most fanout children per node, and returns the file contents and
+// the root address.
+func buildChunkTreeV1(entries []ChunkEntry, fanout int) ([]byte, uint64) {
+	var buf bytes.Buffer
+	key := func(b []byte, e ChunkEntry) []byte {
+		b = binary.LittleEndian.AppendUint32(b, uint32(e.Size))
+		b = binary.LittleEndian.AppendUint32(b, e.FilterMask)
+		b = binary.LittleEndian.AppendUint64(b, e.Offset[0])
+		b = binary.LittleEndian.AppendUint64(b, e.Offset[1])
+		return binary.LittleEndian.AppendUint64(b, 0)
+	}
+
+	// Each level lists its nodes by their first key and address; a node's
+	// final key is the first key of the next node
+	level := entries
+	end := ChunkEntry{Offset: []uint64{1 << 62, 0}}
+	for depth := uint8(0); ; depth++ {
+		var next []ChunkEntry
+		for start := 0; start < len(level); start += fanout {
+			children := level[start:min(start+fanout, len(level))]
+			last := end
+			if start+fanout < len(level) {
+				last = level[start+fanout]
+			}
+			node := []byte("TREE\x01")
+			node = append(node, depth)
+			node = binary.LittleEndian.AppendUint16(node, uint16(len(children)))
+			node = binary.LittleEndian.AppendUint64(node, ^uint64(0))
+			node = binary.LittleEndian.AppendUint64(node, ^uint64(0))
+			for _, child := range children {
+				node = key(node, child)
+				node = binary.LittleEndian.AppendUint64(node, child.Address)
+			}
+			node = key(node, last)
+
+			addr := uint64(buf.Len())
+			buf.Write(node)
+			next = append(next, ChunkEntry{Offset: children[0].Offset, Address: addr})
+		}
+		if len(next) == 1 {
+			return buf.Bytes(), next[0].Address
+		}
+		level = next
+	}
+}
+
+// buildChunkTreeV2 writes a type 10 v2 B-tree chunk index for 2-D entries,
+// in order, with the given depth and at most fanout records per node, and
+// returns the file contents. The header is at address 0.
+func buildChunkTreeV2(t testing.TB, entries []ChunkEntry, depth, fanout int) []byte {
+	t.Helper()
+	const nodeSize, recSize = 4096, 24
+	header := &btreeV2Header{Type: BTreeV2TypeChunkNoFilter, NodeSize: nodeSize, RecordSize: recSize, Depth: uint16(depth)}
+	w := &v2Walker{r: binpkg.NewReader(bytes.NewReader(nil), binpkg.DefaultConfig()), header: header}
+	w.computeNodeInfo()
+
+	buf := bytes.NewBuffer(make([]byte, 64)) // Room for the header
+	record := func(b []byte, e ChunkEntry) []byte {
+		b = binary.LittleEndian.AppendUint64(b, e.Offset[0])
+		b = binary.LittleEndian.AppendUint64(b, e.Offset[1])
+		return binary.LittleEndian.AppendUint64(b, e.Address)
+	}
+	appendUint := func(b []byte, v uint64, size int) []byte {
+		return binary.LittleEndian.AppendUint64(b, v)[:len(b)+size]
+	}
+
+	// build writes the subtree of the given depth holding entries and
+	// returns its address and the number of records in its root
+	var build func(entries []ChunkEntry, depth int) (uint64, int)
+	build = func(entries []ChunkEntry, depth int) (uint64, int) {
+		if uint64(len(entries)) > w.info[depth].cumMaxRecords {
+			t.Fatalf("%d records do not fit a subtree of depth %d", len(entries), depth)
+		}
+		node := []byte{'B', 'T', 'L', 'F', 0, header.Type}
+		nrec := len(entries)
+		if depth == 0 {
+			for _, e := range entries {
+				node = record(node, e)
+			}
+		} else {
+			// Split the records evenly between fanout+1 children, with one
+			// record between each pair
+			node[2], node[3] = 'I', 'N'
+			children := min(fanout+1, len(entries)/2+1)
+			nrec = children - 1
+			per := (len(entries) - (children - 1)) / children
+			var pointers []byte
+			for c, start := 0, 0; c < children; c++ {
+				n := per
+				if c == children-1 {
+					n = len(entries) - start
+				}
+				sub := entries[start : start+n]
+				childAddr, childRecords := build(sub, depth-1)
+				pointers = appendUint(pointers, childAddr, 8)
+				pointers = appendUint(pointers, uint64(childRecords), w.maxNrecSize)
+				if depth > 1 {
+					pointers = appendUint(pointers, uint64(n), w.info[depth-1].cumSize)
+				}
+				start += n
+				if c < children-1 {
+					node = record(node, entries[start])
+					start++
+				}
+			}
+			node = append(node, pointers...)
+		}
+		node = binary.LittleEndian.AppendUint32(node, binpkg.ChecksumLookup3(node, 0))
+		addr := uint64(buf.Len())
+		buf.Write(node)
+		return addr, nrec
+	}
+	root, rootRecords := build(entries, depth)
+
+	data := buf.Bytes()
+	hdr := []byte{'B', 'T', 'H', 'D', 0, header.Type}
+	hdr = binary.LittleEndian.AppendUint32(hdr, nodeSize)
+	hdr = binary.LittleEndian.AppendUint16(hdr, recSize)
+	hdr = binary.LittleEndian.AppendUint16(hdr, uint16(depth))
+	hdr = append(hdr, 100, 40)
+	hdr = binary.LittleEndian.AppendUint64(hdr, root)
+	hdr = binary.LittleEndian.AppendUint16(hdr, uint16(rootRecords))
+	hdr = binary.LittleEndian.AppendUint64(hdr, uint64(len(entries)))
+	hdr = binary.LittleEndian.AppendUint32(hdr, binpkg.ChecksumLookup3(hdr, 0))
+	copy(data, hdr)
+	return data
+}
+
+func checkFindChunk(t *testing.T, find func([]uint64) (ChunkEntry, bool, error), entries []ChunkEntry, rows, cols uint64) {
+	t.Helper()
+	want := make(map[[2]uint64]ChunkEntry, len(entries))
+	for _, e := range entries {
+		want[[2]uint64{e.Offset[0], e.Offset[1]}] = e
+	}
+	for r := uint64(0); r <= rows; r += 3 {
+		for c := uint64(0); c <= cols; c += 5 {
+			coords := []uint64{r * 10, c * 10}
+			got, ok, err := find(coords)
+			if err != nil {
+				t.Fatalf("find(%v) failed: %v", coords, err)
+			}
+			e, exists := want[[2]uint64{r * 10, c * 10}]
+			if ok != exists || ok && got.Address != e.Address {
+				t.Fatalf("find(%v) = %+v, %v, want %+v, %v", coords, got, ok, e, exists)
+			}
+		}
+	}
+	if _, ok, err := find([]uint64{15, 20}); ok || err != nil {
+		t.Errorf("find of a point inside a chunk = %v, %v, want not found", ok, err)
+	}
+	if _, _, err := find([]uint64{0}); err == nil {
+		t.Error("find with the wrong rank succeeded")
+	}
+}
+
+func TestFindChunkV1(t *testing.T) {
+	for _, tc := range []struct {
+		rows, cols uint64
+		fanout     int
+	}{{1, 1, 8}, {4, 4, 64}, {60, 50, 16}} {
+		t.Run(fmt.Sprintf("%dx%d", tc.rows, tc.cols), func(t *testing.T) {
+			entries := testChunks(tc.rows, tc.cols)
+			data, root := buildChunkTreeV1(entries, tc.fanout)
+			r := binpkg.NewReader(bytes.NewReader(data), binpkg.DefaultConfig())
+
+			idx, err := ReadChunkIndex(r, root, 2)
+			if err != nil || len(idx.Entries) != len(entries) {
+				t.Fatalf("ReadChunkIndex = %v entries, %v, want %d", idx, err, len(entries))
+			}
+			checkFindChunk(t, func(coords []uint64) (ChunkEntry, bool, error) {
+				return FindChunkV1(r, root, 2, coords)
+			}, entries, tc.rows, tc.cols)
+		})
+	}
+}
+
+func TestFindChunkV2(t *testing.T) {
+	for _, tc := range []struct {
+		rows, cols uint64
+		depth      int
+	}{{1, 1, 0}, {12, 12, 0}, {60, 50, 1}, {200, 200, 2}} {
+		t.Run(fmt.Sprintf("%dx%d", tc.rows, tc.cols), func(t *testing.T) {
+			entries := testChunks(tc.rows, tc.cols)
+			data := buildChunkTreeV2(t, entries, tc.depth, 30)
+			r := binpkg.NewReader(bytes.NewReader(data), binpkg.DefaultConfig())
+
+			checkFindChunk(t, func(coords []uint64) (ChunkEntry, bool, error) {
+				return FindChunkV2(r, 0, 2, coords)
+			}, entries, tc.rows, tc.cols)
+		})
+	}
+}
+
+// BenchmarkFindChunk compares looking up one chunk in indexes of about
+// 100,000 chunks with reading the whole index.
+func BenchmarkFindChunk(b *testing.B) {
+	entries := testChunks(340, 340)
+	coords := []uint64{1700, 2340}
+
+	v1, root := buildChunkTreeV1(entries, 64)
+	r1 := binpkg.NewReader(bytes.NewReader(v1), binpkg.DefaultConfig())
+	v2 := buildChunkTreeV2(b, entries, 2, 100)
+	r2 := binpkg.NewReader(bytes.NewReader(v2), binpkg.DefaultConfig())
+
+	b.Run("v1/find", func(b *testing.B) {
+		for b.Loop() {
+			if _, ok, err := FindChunkV1(r1, root, 2, coords); !ok || err != nil {
+				b.Fatalf("FindChunkV1 = %v, %v", ok, err)
+			}
+		}
+	})
+	b.Run("v1/read_index", func(b *testing.B) {
+		for b.Loop() {
+			idx, err := ReadChunkIndex(r1, root, 2)
+			if err != nil || idx.FindChunk(coords, []uint32{10, 10}) == nil {
+				b.Fatalf("ReadChunkIndex failed: %v", err)
+			}
+		}
+	})
+	b.Run("v2/find", func(b *testing.B) {
+		for b.Loop() {
+			if _, ok, err := FindChunkV2(r2, 0, 2, coords); !ok || err != nil {
+				b.Fatalf("FindChunkV2 = %v, %v", ok, err)
+			}
+		}
+	})
+	b.Run("v2/read_records", func(b *testing.B) {
+		for b.Loop() {
+			if _, records, err := ReadRecordsV2(r2, 0); err != nil || len(records) != len(entries) {
+				b.Fatalf("ReadRecordsV2 = %d records, %v", len(records), err)
+			}
+		}
+	})
+}
+
+func TestReadChunkIndexV1Levels(t *testing.T) {
+	// 2572 chunks, four to a node, make a tree six levels deep
+	entries := testChunks(60, 50)
+	data, root := buildChunkTreeV1(entries, 4)
+	r := binpkg.NewReader(bytes.NewReader(data), binpkg.DefaultConfig())
+	if level := data[root+5]; level != 5 {
+		t.Fatalf("root level = %d, want 5", level)
+	}
+
+	idx, err := ReadChunkIndex(r, root, 2)
+	if err != nil {
+		t.Fatalf("ReadChunkIndex failed: %v", err)
+	}
+	if len(idx.Entries) != len(entries) {
+		t.Fatalf("got %d entries, want %d", len(idx.Entries), len(entries))
+	}
+	for i, e := range idx.Entries {
+		if !slices.Equal(e.Offset, entries[i].Offset) || e.Address != entries[i].Address {
+			t.Fatalf("entry %d = %+v, want %+v", i, e, entries[i])
+		}
+	}
+}
+
+func TestReadChunkIndexV1KeysOutOfOrder(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		i, j int
+	}{
+		{"same node", 1, 2},
+		{"adjacent nodes", 3, 4},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			entries := testChunks(6, 5)
+			entries[tc.i], entries[tc.j] = entries[tc.j], entries[tc.i]
+			data, root := buildChunkTreeV1(entries, 4)
+			r := binpkg.NewReader(bytes.NewReader(data), binpkg.DefaultConfig())
+			if _, err := ReadChunkIndex(r, root, 2); err == nil {
+				t.Error("expected error for keys out of order")
+			}
+		})
+	}
+}
+
+func TestReadChunkIndexV1TooDeep(t *testing.T) {
+	data, root := buildChunkTreeV1(testChunks(1, 1), 8)
+	data[root+5] = maxChunkBTreeLevel + 1
+	r := binpkg.NewReader(bytes.NewReader(data), binpkg.DefaultConfig())
+	if _, err := ReadChunkIndex(r, root, 2); err == nil {
+		t.Error("expected error for a root level beyond the limit")
+	}
+}
+
+func TestChunkIndexFindChunkGrid(t *testing.T) {
+	entries := testChunks(60, 50)
+	idx := &ChunkIndex{NDims: 2, Entries: entries}
+	chunkDims := []uint32{10, 10}
+	for r := uint64(0); r < 620; r += 7 {
+		for c := uint64(0); c < 520; c += 9 {
+			offset := []uint64{r, c}
+			got, want := idx.FindChunk(offset, chunkDims), idx.scanChunk(offset, chunkDims)
+			if got != want {
+				t.Fatalf("FindChunk(%v) = %+v, want %+v", offset, got, want)
+			}
+		}
+	}
+	if got := idx.Grid; !slices.Equal(got, []uint64{60, 50}) {
+		t.Errorf("Grid = %v, want the [60 50] the entries span", got)
+	}
+
+	// Chunks past a grid set by the caller are not part of the data
+	idx = &ChunkIndex{NDims: 2, Entries: entries, Grid: []uint64{30, 50}}
+	if e := idx.FindChunk([]uint64{5, 5}, chunkDims); e == nil || e.Address != entries[0].Address {
+		t.Errorf("FindChunk inside the grid = %+v, want %+v", e, entries[0])
+	}
+	if e := idx.FindChunk([]uint64{305, 5}, chunkDims); e != nil {
+		t.Errorf("FindChunk past the grid = %+v, want nil", e)
+	}
+}
+
+// BenchmarkChunkIndexFindChunk compares finding a chunk in loaded indexes
+// of growing size by chunk number with scanning the entries.
+func BenchmarkChunkIndexFindChunk(b *testing.B) {
+	chunkDims := []uint32{10, 10}
+	for _, side := range []uint64{10, 100, 1000} {
+		idx := &ChunkIndex{NDims: 2, Entries: testChunks(side, side)}
+		last := idx.Entries[len(idx.Entries)-1].Offset
+		offset := []uint64{last[0] + 5, last[1] + 5}
+		idx.FindChunk(offset, chunkDims) // Number the chunks outside the loop
+		b.Run(fmt.Sprintf("find/%d", side*side), func(b *testing.B) {
+			for b.Loop() {
+				if idx.FindChunk(offset, chunkDims) == nil {
+					b.Fatal("chunk not found")
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("scan/%d", side*side), func(b *testing.B) {
+			for b.Loop() {
+				if idx.scanChunk(offset, chunkDims) == nil {
+					b.Fatal("chunk not found")
+				}
+			}
+		})
+	}
+}
@@ -30,9 +30,18 @@
 //
 //   - [ReadChunkIndex] reads a v1 B-tree chunk index
 //   - [ReadChunkIndexV2] reads a v2 B-tree chunk index
+//   - [FindChunkV1] and [FindChunkV2] look up a single chunk, reading only
+//     the nodes on the path to it
 //   - [ChunkEntry] contains the chunk offset, address, size, and filter mask
 //   - [ChunkIndex] provides a FindChunk method for coordinate-based lookup
 //
+// # Dense Storage
+//
+// Objects with many attributes or links keep them in a fractal heap, indexed
+// by v2 B-trees of types 5 and 6 (links) or 8 and 9 (attributes).
+// [ReadRecordsV2] returns the raw records of any v2 B-tree in key order for
+// the caller to decode.
+//
 // # Key Types
 //
 //   - [ChunkEntry]: Represents a single chunk with its file address and metadata
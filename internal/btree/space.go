@@ -0,0 +1,116 @@
+package btree
+
+import (
+	"fmt"
+
+	"github.com/robert-malhotra/go-hdf5/internal/binary"
+)
+
+// GroupSpace calls fn with the address and size of each node of the v1
+// group B-tree at btreeAddr and of each symbol table node below it.
+// internalK and leafK are the superblock's group node K values: nodes are
+// allocated for 2K children whether or not they hold them all.
+func GroupSpace(r *binary.Reader, btreeAddr uint64, internalK, leafK int, fn func(addr, size uint64)) error {
+	if r.IsUndefinedOffset(btreeAddr) {
+		return nil
+	}
+	snodSize := uint64(GroupK{Leaf: leafK}.symbolNodeSize(r))
+	return walkV1Nodes(r, btreeAddr, 0, r.LengthSize(), -1, make(map[uint64]bool),
+		func(addr uint64, level uint8, children []uint64) {
+			fn(addr, v1NodeSize(r, internalK, len(children), r.LengthSize()))
+			if level == 0 {
+				for _, snod := range children {
+					fn(snod, snodSize)
+				}
+			}
+		})
+}
+
+// ChunkSpace calls fn with the address and size of each node of the v1
+// B-tree chunk index at btreeAddr, for a dataset of ndims dimensions. k is
+// the superblock's indexed storage K value. The chunks themselves are not
+// included.
+func ChunkSpace(r *binary.Reader, btreeAddr uint64, ndims, k int, fn func(addr, size uint64)) error {
+	keySize := 4 + 4 + 8*(ndims+1)
+	return walkV1Nodes(r, btreeAddr, 1, keySize, -1, make(map[uint64]bool),
+		func(addr uint64, level uint8, children []uint64) {
+			fn(addr, v1NodeSize(r, k, len(children), keySize))
+		})
+}
+
+// v1NodeSize returns the size of a v1 B-tree node with room for 2k
+// children, or for n if a node holds more than its K allows.
+func v1NodeSize(r *binary.Reader, k, n, keySize int) uint64 {
+	entries := max(2*k, n)
+	return uint64(8 + 2*r.OffsetSize() + (entries+1)*keySize + entries*r.OffsetSize())
+}
+
+// walkV1Nodes calls fn with the address, level and child addresses of the
+// v1 B-tree node at address, of the given type and key size, and of every
+// node below it. Levels and repeated nodes are checked as in readBTreeNode.
+func walkV1Nodes(r *binary.Reader, address uint64, nodeType uint8, keySize, level int, seen map[uint64]bool,
+	fn func(addr uint64, level uint8, children []uint64)) error {
+	if seen[address] {
+		return fmt.Errorf("B-tree node at %d is reached twice", address)
+	}
+	seen[address] = true
+	nr := r.At(int64(address))
+	header, err := nr.ReadBytes(8)
+	if err != nil {
+		return fmt.Errorf("reading B-tree node at %d: %w", address, err)
+	}
+	if string(header[:4]) != string(btreeSignature) {
+		return fmt.Errorf("invalid B-tree signature: got %q, expected \"TREE\"", string(header[:4]))
+	}
+	if header[4] != nodeType {
+		return fmt.Errorf("unexpected B-tree node type: %d (expected %d)", header[4], nodeType)
+	}
+	nodeLevel := header[5]
+	if level >= 0 && int(nodeLevel) != level {
+		return fmt.Errorf("B-tree node at %d has level %d, expected %d", address, nodeLevel, level)
+	}
+	entriesUsed := int(header[6]) | int(header[7])<<8
+	nr.Skip(int64(2 * r.OffsetSize())) // Left and right siblings
+
+	children := make([]uint64, entriesUsed)
+	for i := range children {
+		nr.Skip(int64(keySize))
+		if children[i], err = nr.ReadOffset(); err != nil {
+			return err
+		}
+	}
+	fn(address, nodeLevel, children)
+	if nodeLevel == 0 {
+		return nil
+	}
+	for _, child := range children {
+		if err := walkV1Nodes(r, child, nodeType, keySize, int(nodeLevel)-1, seen, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SpaceV2 calls fn with the address and size of the header and of each
+// node of the v2 B-tree at btreeAddr. Nodes take the node size from the
+// header however many records they hold.
+func SpaceV2(r *binary.Reader, btreeAddr uint64, fn func(addr, size uint64)) error {
+	header, err := readBTreeV2Header(r, btreeAddr)
+	if err != nil {
+		return fmt.Errorf("reading B-tree v2 header: %w", err)
+	}
+	fn(btreeAddr, uint64(22+r.OffsetSize()+r.LengthSize()))
+	if header.TotalRecords == 0 || r.IsUndefinedOffset(header.RootAddr) {
+		return nil
+	}
+	if header.RecordSize == 0 || header.NodeSize <= btreeV2PrefixSize {
+		return fmt.Errorf("B-tree v2 has invalid node size %d or record size %d",
+			header.NodeSize, header.RecordSize)
+	}
+
+	w := &v2Walker{r: r, header: header, visit: func(addr uint64) {
+		fn(addr, uint64(header.NodeSize))
+	}}
+	w.computeNodeInfo()
+	return w.walk(header.RootAddr, uint64(header.NumRootRecords), int(header.Depth))
+}
@@ -2,6 +2,10 @@ package btree
 
 import (
 	"fmt"
+	"math/bits"
+	"slices"
+	"sort"
+	"sync"
 
 	"github.com/robert-malhotra/go-hdf5/internal/binary"
 )
@@ -18,7 +22,9 @@ type ChunkEntry struct {
 	FilterMask uint32
 
 	// Size is the size of the chunk data on disk (possibly compressed).
-	Size uint32
+	// B-tree v2 and array indexes of filtered chunks store it in up to 8
+	// bytes.
+	Size uint64
 
 	// Address is the file offset where chunk data is stored.
 	Address uint64
@@ -31,25 +37,102 @@ type ChunkIndex struct {
 
 	// Entries contains all chunk entries.
 	Entries []ChunkEntry
+
+	// Grid is the number of chunks along each dimension of the dataset,
+	// which numbers chunks in row-major order for FindChunk. Callers that
+	// know the dataset's extent set it; chunks starting past it are not
+	// found. If it is nil, FindChunk takes the smallest grid holding every
+	// entry.
+	Grid []uint64
+
+	lookupOnce sync.Once
+	lookup     map[uint64]int // Entry index by chunk number; nil to scan
 }
 
-// ReadChunkIndex reads a v1 B-tree chunk index.
+// maxChunkBTreeLevel is the highest root level accepted for a v1 chunk
+// B-tree. Even with the smallest K a tree this tall would index more
+// chunks than a file can hold, so a higher level marks a corrupt node.
+const maxChunkBTreeLevel = 32
+
+// ReadChunkIndex reads a v1 B-tree chunk index, following the children of
+// internal nodes down to every leaf, however many levels the tree has.
 // ndims is the number of dataset dimensions (not including the +1 used in B-tree keys).
+// Chunks must come out in key order; a tree whose keys decrease is
+// reported as corrupt rather than read with chunks missing.
 func ReadChunkIndex(r *binary.Reader, btreeAddr uint64, ndims int) (*ChunkIndex, error) {
 	index := &ChunkIndex{
 		NDims: ndims,
 	}
 
-	entries, err := readChunkBTreeNode(r, btreeAddr, ndims)
+	entries, err := readChunkBTreeNode(r, btreeAddr, ndims, -1, make(map[uint64]bool))
 	if err != nil {
 		return nil, err
 	}
+	for i := 1; i < len(entries); i++ {
+		if slices.Compare(entries[i-1].Offset, entries[i].Offset) > 0 {
+			return nil, fmt.Errorf("B-tree chunks out of order: %v follows %v", entries[i].Offset, entries[i-1].Offset)
+		}
+	}
 	index.Entries = entries
 
 	return index, nil
 }
 
-func readChunkBTreeNode(r *binary.Reader, address uint64, ndims int) ([]ChunkEntry, error) {
+// readChunkBTreeNode returns the chunks below the node at address, whose
+// level must be level unless it is the root (-1). Each level must be one
+// below its parent's and no node may be reached twice, recorded in seen,
+// so that a corrupt tree pointing back at its own nodes cannot recurse
+// without end or be read over and over.
+func readChunkBTreeNode(r *binary.Reader, address uint64, ndims int, level int, seen map[uint64]bool) ([]ChunkEntry, error) {
+	if seen[address] {
+		return nil, fmt.Errorf("B-tree node at %d is reached twice", address)
+	}
+	seen[address] = true
+	node, err := readChunkNode(r, address, ndims)
+	if err != nil {
+		return nil, err
+	}
+	if level >= 0 && int(node.level) != level {
+		return nil, fmt.Errorf("B-tree node at %d has level %d, expected %d", address, node.level, level)
+	}
+	if node.level > maxChunkBTreeLevel {
+		return nil, fmt.Errorf("B-tree node at %d has level %d, at most %d is supported", address, node.level, maxChunkBTreeLevel)
+	}
+
+	var entries []ChunkEntry
+	if node.level == 0 {
+		// Only include chunks that have valid addresses
+		for _, entry := range node.children {
+			if entry.Address != 0xFFFFFFFFFFFFFFFF && entry.Size > 0 {
+				entries = append(entries, entry)
+			}
+		}
+		return entries, nil
+	}
+
+	// Internal node - recurse into children
+	for _, child := range node.children {
+		childEntries, err := readChunkBTreeNode(r, child.Address, ndims, int(node.level)-1, seen)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, childEntries...)
+	}
+	return entries, nil
+}
+
+// chunkNode is a node of a v1 B-tree chunk index.
+type chunkNode struct {
+	level uint8 // 0 for leaves
+
+	// One entry per child: the key to its left and its address. In leaves
+	// the key describes the chunk at the address; in internal nodes, the
+	// first chunk below the child.
+	children []ChunkEntry
+}
+
+// readChunkNode reads the v1 B-tree chunk index node at address.
+func readChunkNode(r *binary.Reader, address uint64, ndims int) (*chunkNode, error) {
 	nr := r.At(int64(address))
 
 	// Check signature
@@ -71,7 +154,8 @@ func readChunkBTreeNode(r *binary.Reader, address uint64, ndims int) ([]ChunkEnt
 	}
 
 	// Node level (1 byte): 0 = leaf
-	nodeLevel, err := nr.ReadUint8()
+	node := &chunkNode{}
+	node.level, err = nr.ReadUint8()
 	if err != nil {
 		return nil, err
 	}
@@ -82,118 +166,178 @@ func readChunkBTreeNode(r *binary.Reader, address uint64, ndims int) ([]ChunkEnt
 		return nil, err
 	}
 
-	// Left sibling address
-	_, err = nr.ReadOffset()
-	if err != nil {
-		return nil, err
-	}
+	// Left and right sibling addresses
+	nr.Skip(int64(2 * r.OffsetSize()))
 
-	// Right sibling address
-	_, err = nr.ReadOffset()
-	if err != nil {
-		return nil, err
-	}
+	// Key layout for chunked data (per HDF5 spec):
+	// - Chunk size in bytes (4 bytes)
+	// - Filter mask (4 bytes)
+	// - Chunk offsets (ndims+1 values, each 8 bytes)
+	// Keys and child pointers alternate, with one more key than children;
+	// the last key only bounds the last child.
+	node.children = make([]ChunkEntry, 0, entriesUsed)
+	for i := uint16(0); i < entriesUsed; i++ {
+		var entry ChunkEntry
+		size, err := nr.ReadUint32()
+		if err != nil {
+			return nil, fmt.Errorf("reading chunk size: %w", err)
+		}
+		entry.Size = uint64(size)
 
-	var entries []ChunkEntry
+		entry.FilterMask, err = nr.ReadUint32()
+		if err != nil {
+			return nil, fmt.Errorf("reading filter mask: %w", err)
+		}
 
-	if nodeLevel == 0 {
-		// Leaf node - contains actual chunk entries
-		// Key layout for chunked data (per HDF5 spec):
-		// - Chunk size in bytes (4 bytes)
-		// - Filter mask (4 bytes)
-		// - Chunk offsets (ndims+1 values, each 8 bytes)
-		// Child pointer:
-		// - Address of chunk data (offset-sized)
-
-		for i := uint16(0); i <= entriesUsed; i++ {
-			// Read key
-			chunkSize, err := nr.ReadUint32()
+		// Chunk offsets - HDF5 uses ndims+1 dimensions in the B-tree
+		// The last dimension is typically the element size
+		offsets := make([]uint64, ndims+1)
+		for j := 0; j <= ndims; j++ {
+			offsets[j], err = nr.ReadUint64()
 			if err != nil {
-				return nil, fmt.Errorf("reading chunk size: %w", err)
+				return nil, fmt.Errorf("reading chunk offset %d: %w", j, err)
 			}
+		}
+		entry.Offset = offsets[:ndims] // Exclude the last dimension (element size)
 
-			filterMask, err := nr.ReadUint32()
-			if err != nil {
-				return nil, fmt.Errorf("reading filter mask: %w", err)
-			}
+		entry.Address, err = nr.ReadOffset()
+		if err != nil {
+			return nil, fmt.Errorf("reading child address: %w", err)
+		}
+		if i > 0 && slices.Compare(node.children[i-1].Offset, entry.Offset) > 0 {
+			return nil, fmt.Errorf("B-tree node at %d has keys out of order: %v follows %v",
+				address, entry.Offset, node.children[i-1].Offset)
+		}
+		node.children = append(node.children, entry)
+	}
 
-			// Chunk offsets - HDF5 uses ndims+1 dimensions in the B-tree
-			// The last dimension is typically the element size
-			offsets := make([]uint64, ndims+1)
-			for j := 0; j <= ndims; j++ {
-				offsets[j], err = nr.ReadUint64()
-				if err != nil {
-					return nil, fmt.Errorf("reading chunk offset %d: %w", j, err)
-				}
-			}
+	return node, nil
+}
 
-			// For the last entry (i == entriesUsed), we only read the key
-			// to know the upper bound, but there's no child pointer
-			if i == entriesUsed {
-				break
-			}
+// FindChunkV1 looks up the chunk whose first element is at coords in a v1
+// B-tree chunk index, reading only the nodes on the path to it rather than
+// the whole tree. ndims is the number of dataset dimensions, and coords has
+// one element offset per dimension. It reports false if the chunk is not
+// allocated.
+func FindChunkV1(r *binary.Reader, btreeAddr uint64, ndims int, coords []uint64) (ChunkEntry, bool, error) {
+	if len(coords) != ndims {
+		return ChunkEntry{}, false, fmt.Errorf("chunk coordinates have %d dimensions, expected %d", len(coords), ndims)
+	}
 
-			// Read child pointer (chunk data address)
-			chunkAddr, err := nr.ReadOffset()
-			if err != nil {
-				return nil, fmt.Errorf("reading chunk address: %w", err)
-			}
+	addr := btreeAddr
+	var parent *chunkNode
+	for {
+		node, err := readChunkNode(r, addr, ndims)
+		if err != nil {
+			return ChunkEntry{}, false, err
+		}
+		if parent != nil && node.level != parent.level-1 {
+			return ChunkEntry{}, false, fmt.Errorf("B-tree node at %d has level %d, expected %d",
+				addr, node.level, parent.level-1)
+		}
 
-			// Only include chunks that have valid addresses
-			if chunkAddr != 0xFFFFFFFFFFFFFFFF && chunkSize > 0 {
-				entry := ChunkEntry{
-					Offset:     offsets[:ndims], // Exclude the last dimension (element size)
-					FilterMask: filterMask,
-					Size:       chunkSize,
-					Address:    chunkAddr,
-				}
-				entries = append(entries, entry)
-			}
+		// Keys are ordered by offset, compared one dimension at a time; the
+		// chunk is below the last child whose key does not exceed it
+		i := sort.Search(len(node.children), func(i int) bool {
+			return slices.Compare(node.children[i].Offset, coords) > 0
+		}) - 1
+		if i < 0 {
+			return ChunkEntry{}, false, nil
 		}
-	} else {
-		// Internal node - recurse into children
-		for i := uint16(0); i <= entriesUsed; i++ {
-			// Read key (same format as leaf)
-			_, err := nr.ReadUint32() // chunk size
-			if err != nil {
-				return nil, err
-			}
-			_, err = nr.ReadUint32() // filter mask
-			if err != nil {
-				return nil, err
-			}
-			for j := 0; j <= ndims; j++ {
-				_, err = nr.ReadUint64() // offset
-				if err != nil {
-					return nil, err
-				}
+		child := node.children[i]
+		if node.level == 0 {
+			if !slices.Equal(child.Offset, coords) || child.Address == 0xFFFFFFFFFFFFFFFF || child.Size == 0 {
+				return ChunkEntry{}, false, nil
 			}
+			return child, true, nil
+		}
+		addr, parent = child.Address, node
+	}
+}
 
-			// For the last entry, no child pointer
-			if i == entriesUsed {
-				break
-			}
+// FindChunk finds the chunk entry that contains the given offset.
+// Returns nil if no chunk contains the offset.
+//
+// The first call numbers the entries by their place in the chunk grid, so
+// that later ones find a chunk in constant time; reading every entry, as
+// a whole-dataset read does, never pays for it. chunkDims must be the same
+// on every call.
+func (idx *ChunkIndex) FindChunk(offset []uint64, chunkDims []uint32) *ChunkEntry {
+	idx.lookupOnce.Do(func() { idx.buildLookup(chunkDims) })
+	if idx.lookup == nil {
+		return idx.scanChunk(offset, chunkDims)
+	}
+	n, ok := idx.chunkNumber(offset, chunkDims)
+	if !ok {
+		return nil
+	}
+	if i, found := idx.lookup[n]; found {
+		return &idx.Entries[i]
+	}
+	return nil
+}
 
-			// Child pointer - address of child B-tree node
-			childAddr, err := nr.ReadOffset()
-			if err != nil {
-				return nil, err
+// buildLookup numbers the entries for FindChunk. It leaves the lookup nil
+// if the chunk dimensions do not fit the entries or the grid has more
+// chunks than a uint64 can number, so that FindChunk scans instead.
+func (idx *ChunkIndex) buildLookup(chunkDims []uint32) {
+	if len(chunkDims) == 0 || slices.Contains(chunkDims, 0) {
+		return
+	}
+	if idx.Grid == nil {
+		idx.Grid = make([]uint64, len(chunkDims))
+		for _, entry := range idx.Entries {
+			for d := 0; d < len(chunkDims) && d < len(entry.Offset); d++ {
+				idx.Grid[d] = max(idx.Grid[d], entry.Offset[d]/uint64(chunkDims[d])+1)
 			}
+		}
+	}
+	if len(idx.Grid) != len(chunkDims) {
+		return
+	}
+	total := uint64(1)
+	for _, n := range idx.Grid {
+		hi, lo := bits.Mul64(total, n)
+		if hi != 0 {
+			return
+		}
+		total = lo
+	}
 
-			childEntries, err := readChunkBTreeNode(r, childAddr, ndims)
-			if err != nil {
-				return nil, err
+	lookup := make(map[uint64]int, len(idx.Entries))
+	for i, entry := range idx.Entries {
+		if len(entry.Offset) < len(chunkDims) {
+			continue
+		}
+		// The first entry for a chunk wins, as in a scan
+		if n, ok := idx.chunkNumber(entry.Offset, chunkDims); ok {
+			if _, dup := lookup[n]; !dup {
+				lookup[n] = i
 			}
-			entries = append(entries, childEntries...)
 		}
 	}
+	idx.lookup = lookup
+}
 
-	return entries, nil
+// chunkNumber returns the row-major number in the grid of the chunk
+// containing offset, and false if it lies outside the grid.
+func (idx *ChunkIndex) chunkNumber(offset []uint64, chunkDims []uint32) (uint64, bool) {
+	if len(offset) < len(idx.Grid) {
+		return 0, false
+	}
+	var n uint64
+	for d, size := range idx.Grid {
+		i := offset[d] / uint64(chunkDims[d])
+		if i >= size {
+			return 0, false
+		}
+		n = n*size + i
+	}
+	return n, true
 }
 
-// FindChunk finds the chunk entry that contains the given offset.
-// Returns nil if no chunk contains the offset.
-func (idx *ChunkIndex) FindChunk(offset []uint64, chunkDims []uint32) *ChunkEntry {
+// scanChunk finds the chunk containing offset by checking every entry.
+func (idx *ChunkIndex) scanChunk(offset []uint64, chunkDims []uint32) *ChunkEntry {
 	for i := range idx.Entries {
 		entry := &idx.Entries[i]
 		match := true
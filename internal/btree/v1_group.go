@@ -22,22 +22,151 @@ var btreeSignature = []byte{'T', 'R', 'E', 'E'}
 // Symbol table node signature: "SNOD"
 var snodSignature = []byte{'S', 'N', 'O', 'D'}
 
-// ReadGroupEntries reads all entries from a v1 group B-tree.
-func ReadGroupEntries(r *binary.Reader, btreeAddr uint64, localHeap *heap.LocalHeap) ([]GroupEntry, error) {
-	var entries []GroupEntry
+// GroupK holds the K values of a file's v1 group B-trees, from its
+// superblock: B-tree nodes have room for 2*Internal children and symbol
+// table nodes for 2*Leaf entries. Zero values are not checked.
+type GroupK struct {
+	Leaf     int
+	Internal int
+}
+
+// nodeSize returns the size of a group B-tree node, or 0 if k.Internal is
+// not known.
+func (k GroupK) nodeSize(r *binary.Reader) int {
+	if k.Internal <= 0 {
+		return 0
+	}
+	return int(v1NodeSize(r, k.Internal, 0, r.LengthSize()))
+}
+
+// symbolNodeSize returns the size of a symbol table node, or 0 if k.Leaf
+// is not known.
+func (k GroupK) symbolNodeSize(r *binary.Reader) int {
+	if k.Leaf <= 0 {
+		return 0
+	}
+	return 8 + 2*k.Leaf*(2*r.OffsetSize()+24)
+}
+
+// ReadGroupEntries reads all entries from a v1 group B-tree, following
+// the children of internal nodes down to every symbol table node, however
+// many levels the tree has. A node holding more entries than k allows is
+// reported as corrupt. A name listed twice is returned once, with its
+// first entry.
+// Empty groups are returned as an empty, non-nil slice; this covers an
+// undefined B-tree address as well as nodes or symbol table nodes with no entries.
+func ReadGroupEntries(r *binary.Reader, btreeAddr uint64, localHeap *heap.LocalHeap, k GroupK) ([]GroupEntry, error) {
+	entries := []GroupEntry{}
+
+	// Some writers store empty groups without allocating a B-tree
+	if r.IsUndefinedOffset(btreeAddr) {
+		return entries, nil
+	}
 
 	// Read B-tree node
-	nodeEntries, err := readBTreeNode(r, btreeAddr, localHeap)
+	nodeEntries, err := readBTreeNode(r, btreeAddr, localHeap, k, -1, make(map[uint64]bool))
 	if err != nil {
 		return nil, err
 	}
-	entries = append(entries, nodeEntries...)
+	names := make(map[string]bool, len(nodeEntries))
+	for _, entry := range nodeEntries {
+		if !names[entry.Name] {
+			names[entry.Name] = true
+			entries = append(entries, entry)
+		}
+	}
 
 	return entries, nil
 }
 
-func readBTreeNode(r *binary.Reader, address uint64, localHeap *heap.LocalHeap) ([]GroupEntry, error) {
-	nr := r.At(int64(address))
+// LookupGroupEntry finds the entry named name in a v1 group B-tree by
+// following its keys, reading only the nodes on the way to the symbol table
+// node that should hold it. Each key names the last entry of the child
+// before it, so a lookup descends into the first child whose right key is
+// not less than name. It reports whether the entry was found; trees whose
+// keys are out of order may hide entries that ReadGroupEntries lists.
+func LookupGroupEntry(r *binary.Reader, btreeAddr uint64, localHeap *heap.LocalHeap, k GroupK, name string) (GroupEntry, bool, error) {
+	if r.IsUndefinedOffset(btreeAddr) {
+		return GroupEntry{}, false, nil
+	}
+	address, level := btreeAddr, -1
+	seen := make(map[uint64]bool)
+	for {
+		if seen[address] {
+			return GroupEntry{}, false, fmt.Errorf("B-tree node at %d is reached twice", address)
+		}
+		seen[address] = true
+		nr := r.AtSize(int64(address), k.nodeSize(r))
+		header, err := nr.ReadBytes(8)
+		if err != nil {
+			return GroupEntry{}, false, fmt.Errorf("reading btree signature: %w", err)
+		}
+		if string(header[:4]) != string(btreeSignature) {
+			return GroupEntry{}, false, fmt.Errorf("invalid B-tree signature: got %q, expected \"TREE\"", string(header[:4]))
+		}
+		if header[4] != 0 {
+			return GroupEntry{}, false, fmt.Errorf("unexpected B-tree node type: %d (expected 0 for group)", header[4])
+		}
+		nodeLevel := int(header[5])
+		if level >= 0 && nodeLevel != level {
+			return GroupEntry{}, false, fmt.Errorf("B-tree node at %d has level %d, expected %d", address, nodeLevel, level)
+		}
+		entriesUsed := int(r.ByteOrder().Uint16(header[6:8]))
+		if k.Internal > 0 && entriesUsed > 2*k.Internal {
+			return GroupEntry{}, false, fmt.Errorf("B-tree node at %d has %d children, at most %d fit", address, entriesUsed, 2*k.Internal)
+		}
+		nr.Skip(int64(2 * r.OffsetSize())) // Left and right siblings
+
+		// The left key bounds nothing a lookup needs
+		if _, err := nr.ReadLength(); err != nil {
+			return GroupEntry{}, false, err
+		}
+		var child uint64
+		found := false
+		for i := 0; i < entriesUsed; i++ {
+			childAddr, err := nr.ReadOffset()
+			if err != nil {
+				return GroupEntry{}, false, err
+			}
+			rightKey, err := nr.ReadLength()
+			if err != nil {
+				return GroupEntry{}, false, err
+			}
+			if name <= localHeap.GetString(rightKey) {
+				child, found = childAddr, true
+				break
+			}
+		}
+		if !found {
+			return GroupEntry{}, false, nil
+		}
+
+		if nodeLevel > 0 {
+			address, level = child, nodeLevel-1
+			continue
+		}
+		entries, err := readSymbolTableNode(r, child, localHeap, k)
+		if err != nil {
+			return GroupEntry{}, false, fmt.Errorf("reading symbol table node: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.Name == name {
+				return entry, true, nil
+			}
+		}
+		return GroupEntry{}, false, nil
+	}
+}
+
+// readBTreeNode returns the entries below the node at address, whose level
+// must be level unless it is the root (-1), and which must not be in seen,
+// as readChunkBTreeNode checks.
+func readBTreeNode(r *binary.Reader, address uint64, localHeap *heap.LocalHeap, k GroupK, level int, seen map[uint64]bool) ([]GroupEntry, error) {
+	if seen[address] {
+		return nil, fmt.Errorf("B-tree node at %d is reached twice", address)
+	}
+	seen[address] = true
+	nr := r.AtSize(int64(address), k.nodeSize(r))
 
 	// Check signature
 	sig, err := nr.ReadBytes(4)
@@ -62,12 +191,18 @@ func readBTreeNode(r *binary.Reader, address uint64, localHeap *heap.LocalHeap)
 	if err != nil {
 		return nil, err
 	}
+	if level >= 0 && int(nodeLevel) != level {
+		return nil, fmt.Errorf("B-tree node at %d has level %d, expected %d", address, nodeLevel, level)
+	}
 
 	// Entries used (2 bytes)
 	entriesUsed, err := nr.ReadUint16()
 	if err != nil {
 		return nil, err
 	}
+	if k.Internal > 0 && int(entriesUsed) > 2*k.Internal {
+		return nil, fmt.Errorf("B-tree node at %d has %d children, at most %d fit", address, entriesUsed, 2*k.Internal)
+	}
 
 	// Left sibling address
 	_, err = nr.ReadOffset()
@@ -81,7 +216,7 @@ func readBTreeNode(r *binary.Reader, address uint64, localHeap *heap.LocalHeap)
 		return nil, err
 	}
 
-	var entries []GroupEntry
+	entries := []GroupEntry{}
 
 	if nodeLevel == 0 {
 		// Leaf node - contains keys pointing to symbol table nodes
@@ -99,7 +234,7 @@ func readBTreeNode(r *binary.Reader, address uint64, localHeap *heap.LocalHeap)
 			}
 
 			// Read symbol table node entries
-			snodEntries, err := readSymbolTableNode(r, snodAddr, localHeap)
+			snodEntries, err := readSymbolTableNode(r, snodAddr, localHeap, k)
 			if err != nil {
 				return nil, fmt.Errorf("reading symbol table node: %w", err)
 			}
@@ -120,7 +255,7 @@ func readBTreeNode(r *binary.Reader, address uint64, localHeap *heap.LocalHeap)
 				return nil, err
 			}
 
-			childEntries, err := readBTreeNode(r, childAddr, localHeap)
+			childEntries, err := readBTreeNode(r, childAddr, localHeap, k, int(nodeLevel)-1, seen)
 			if err != nil {
 				return nil, err
 			}
@@ -131,8 +266,8 @@ func readBTreeNode(r *binary.Reader, address uint64, localHeap *heap.LocalHeap)
 	return entries, nil
 }
 
-func readSymbolTableNode(r *binary.Reader, address uint64, localHeap *heap.LocalHeap) ([]GroupEntry, error) {
-	nr := r.At(int64(address))
+func readSymbolTableNode(r *binary.Reader, address uint64, localHeap *heap.LocalHeap, k GroupK) ([]GroupEntry, error) {
+	nr := r.AtSize(int64(address), k.symbolNodeSize(r))
 
 	// Check signature
 	sig, err := nr.ReadBytes(4)
@@ -160,12 +295,15 @@ func readSymbolTableNode(r *binary.Reader, address uint64, localHeap *heap.Local
 	if err != nil {
 		return nil, err
 	}
+	if k.Leaf > 0 && int(numSymbols) > 2*k.Leaf {
+		return nil, fmt.Errorf("symbol table node at %d has %d entries, at most %d fit", address, numSymbols, 2*k.Leaf)
+	}
 
-	var entries []GroupEntry
+	entries := make([]GroupEntry, 0, numSymbols)
 	for i := uint16(0); i < numSymbols; i++ {
 		entry, err := readSymbolTableEntry(nr, localHeap)
 		if err != nil {
-			return nil, fmt.Errorf("reading symbol table entry %d: %w", i, err)
+			return nil, fmt.Errorf("reading symbol table entry %d of SNOD at %d: %w", i, address, err)
 		}
 		if entry.Name != "" { // Skip empty entries
 			entries = append(entries, entry)
@@ -212,6 +350,17 @@ func readSymbolTableEntry(r *binary.Reader, localHeap *heap.LocalHeap) (GroupEnt
 		return entry, err
 	}
 
+	// With checksum verification, which stands in for the checksum symbol
+	// table nodes lack, names must lie in the local heap
+	if r.VerifyChecksums() {
+		if nameOffset >= localHeap.DataSize {
+			return entry, fmt.Errorf("name offset %d is outside the %d-byte local heap", nameOffset, localHeap.DataSize)
+		}
+		if cacheType > cacheTypeSoftLink {
+			return entry, fmt.Errorf("invalid cache type %d", cacheType)
+		}
+	}
+
 	// Get name from local heap
 	entry.Name = localHeap.GetString(nameOffset)
 	entry.ObjectAddress = objAddr
@@ -227,6 +376,9 @@ func readSymbolTableEntry(r *binary.Reader, localHeap *heap.LocalHeap) (GroupEnt
 		// The offset is stored as a 4-byte value at the start of scratch-pad
 		linkOffset := uint64(scratchPad[0]) | uint64(scratchPad[1])<<8 |
 			uint64(scratchPad[2])<<16 | uint64(scratchPad[3])<<24
+		if r.VerifyChecksums() && linkOffset >= localHeap.DataSize {
+			return entry, fmt.Errorf("soft link value offset %d is outside the %d-byte local heap", linkOffset, localHeap.DataSize)
+		}
 		entry.LinkType = 1
 		entry.SoftLinkValue = localHeap.GetString(linkOffset)
 		entry.ObjectAddress = 0 // Not meaningful for soft links
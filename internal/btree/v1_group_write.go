@@ -0,0 +1,266 @@
+package btree
+
+import (
+	"fmt"
+
+	"github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/heap"
+)
+
+// RemoveGroupEntry removes the entry named name from a v1 group B-tree,
+// rewriting the symbol table node holding it in place: later entries move
+// down one slot and the node's symbol count drops by one. It reports
+// whether an entry was found.
+//
+// The name stays in the local heap, and a node left empty stays in the
+// tree; both are unreachable by name, as the C library's readers expect.
+func RemoveGroupEntry(r *binary.Reader, w *binary.Writer, btreeAddr uint64, localHeap *heap.LocalHeap, name string) (bool, error) {
+	if r.IsUndefinedOffset(btreeAddr) {
+		return false, nil
+	}
+	nodes, err := symbolTableNodes(r, btreeAddr, -1, make(map[uint64]bool))
+	if err != nil {
+		return false, err
+	}
+
+	entrySize := 2*r.OffsetSize() + 4 + 4 + 16
+	for _, addr := range nodes {
+		// Signature, version, reserved byte and number of symbols
+		header, err := r.At(int64(addr)).ReadBytes(8)
+		if err != nil {
+			return false, fmt.Errorf("reading symbol table node: %w", err)
+		}
+		if string(header[:4]) != string(snodSignature) {
+			return false, fmt.Errorf("invalid symbol table node signature: got %q, expected \"SNOD\"", string(header[:4]))
+		}
+		numSymbols := int(r.ByteOrder().Uint16(header[6:8]))
+		raw, err := r.At(int64(addr) + 8).ReadBytes(numSymbols * entrySize)
+		if err != nil {
+			return false, fmt.Errorf("reading symbol table entries: %w", err)
+		}
+
+		for i := 0; i < numSymbols; i++ {
+			nameOffset, err := r.At(int64(addr) + 8 + int64(i*entrySize)).ReadOffset()
+			if err != nil {
+				return false, err
+			}
+			if localHeap.GetString(nameOffset) != name {
+				continue
+			}
+			copy(raw[i*entrySize:], raw[(i+1)*entrySize:])
+			clear(raw[(numSymbols-1)*entrySize:])
+			if err := w.At(int64(addr) + 6).WriteUint16(uint16(numSymbols - 1)); err != nil {
+				return false, err
+			}
+			if err := w.At(int64(addr) + 8).WriteBytes(raw); err != nil {
+				return false, err
+			}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// symbolTableNodes returns the addresses of the symbol table nodes of a v1
+// group B-tree, in key order. The node at address must have the given
+// level unless it is the root (-1), and must not be in seen, as in
+// readBTreeNode.
+func symbolTableNodes(r *binary.Reader, address uint64, level int, seen map[uint64]bool) ([]uint64, error) {
+	if seen[address] {
+		return nil, fmt.Errorf("B-tree node at %d is reached twice", address)
+	}
+	seen[address] = true
+	nr := r.At(int64(address))
+	sig, err := nr.ReadBytes(4)
+	if err != nil {
+		return nil, fmt.Errorf("reading btree signature: %w", err)
+	}
+	if string(sig) != string(btreeSignature) {
+		return nil, fmt.Errorf("invalid B-tree signature: got %q, expected \"TREE\"", string(sig))
+	}
+	nodeType, err := nr.ReadUint8()
+	if err != nil {
+		return nil, err
+	}
+	if nodeType != 0 {
+		return nil, fmt.Errorf("unexpected B-tree node type: %d (expected 0 for group)", nodeType)
+	}
+	nodeLevel, err := nr.ReadUint8()
+	if err != nil {
+		return nil, err
+	}
+	if level >= 0 && int(nodeLevel) != level {
+		return nil, fmt.Errorf("B-tree node at %d has level %d, expected %d", address, nodeLevel, level)
+	}
+	entriesUsed, err := nr.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	// Left and right siblings
+	nr.Skip(int64(2 * r.OffsetSize()))
+
+	var nodes []uint64
+	for i := uint16(0); i < entriesUsed; i++ {
+		if _, err := nr.ReadLength(); err != nil {
+			return nil, err
+		}
+		child, err := nr.ReadOffset()
+		if err != nil {
+			return nil, err
+		}
+		if nodeLevel == 0 {
+			nodes = append(nodes, child)
+			continue
+		}
+		children, err := symbolTableNodes(r, child, int(nodeLevel)-1, seen)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, children...)
+	}
+	return nodes, nil
+}
+
+// SymbolTableEntry is a member of a v1 group as WriteGroupBTree stores it
+// in a symbol table node.
+type SymbolTableEntry struct {
+	NameOffset    uint64   // Offset of the member's name in the group's local heap
+	ObjectAddress uint64   // Object header address, undefined for soft links
+	CacheType     uint32   // 0 for objects, 2 for soft links
+	Scratch       [16]byte // Scratch-pad: a soft link's value offset
+}
+
+// groupNode is a node written by WriteGroupBTree with the keys bounding
+// the names below it.
+type groupNode struct {
+	addr              uint64
+	leftKey, rightKey uint64
+}
+
+// WriteGroupBTree writes the B-tree and symbol table nodes of a v1 group
+// holding entries, which must be sorted by name, and returns the address
+// of the root node. Symbol table nodes hold up to 2*leafK entries and
+// B-tree nodes up to 2*internalK children, and both are written at that
+// full size, as the HDF5 library reads them. Each key is the heap offset
+// of the last name below it; the first is offset 0, the empty name. An
+// empty group gets a root node without children.
+func WriteGroupBTree(w *binary.Writer, allocate func(size int64) uint64, entries []SymbolTableEntry, leafK, internalK int) (uint64, error) {
+	// Symbol table nodes: signature, version, reserved byte, number of
+	// symbols and the entries
+	entrySize := 2*w.OffsetSize() + 4 + 4 + 16
+	perNode := 2 * leafK
+	var level []groupNode
+	for start := 0; start < len(entries); start += perNode {
+		chunk := entries[start:min(start+perNode, len(entries))]
+		nodeSize := 8 + perNode*entrySize
+		addr := allocate(int64(nodeSize))
+		bw := w.At(int64(addr))
+		if err := bw.WriteBytes(snodSignature); err != nil {
+			return 0, err
+		}
+		if err := bw.WriteBytes([]byte{1, 0}); err != nil {
+			return 0, err
+		}
+		if err := bw.WriteUint16(uint16(len(chunk))); err != nil {
+			return 0, err
+		}
+		for _, e := range chunk {
+			if err := bw.WriteOffset(e.NameOffset); err != nil {
+				return 0, err
+			}
+			if err := bw.WriteOffset(e.ObjectAddress); err != nil {
+				return 0, err
+			}
+			if err := bw.WriteUint32(e.CacheType); err != nil {
+				return 0, err
+			}
+			if err := bw.WriteZeros(4); err != nil {
+				return 0, err
+			}
+			if err := bw.WriteBytes(e.Scratch[:]); err != nil {
+				return 0, err
+			}
+		}
+		// Unused entries are zero
+		if err := bw.WriteZeros(nodeSize - int(bw.Pos()-int64(addr))); err != nil {
+			return 0, fmt.Errorf("writing symbol table node: %w", err)
+		}
+		node := groupNode{addr: addr, rightKey: chunk[len(chunk)-1].NameOffset}
+		if len(level) > 0 {
+			node.leftKey = level[len(level)-1].rightKey
+		}
+		level = append(level, node)
+	}
+
+	// B-tree levels, from the leaves up, until a single root is left
+	for nodeLevel := 0; ; nodeLevel++ {
+		parents, err := writeGroupBTreeLevel(w, allocate, level, nodeLevel, 2*internalK)
+		if err != nil {
+			return 0, err
+		}
+		if len(parents) == 1 {
+			return parents[0].addr, nil
+		}
+		level = parents
+	}
+}
+
+// writeGroupBTreeLevel writes the B-tree nodes of one level over children,
+// linked to their siblings, and returns them.
+func writeGroupBTreeLevel(w *binary.Writer, allocate func(size int64) uint64, children []groupNode, nodeLevel, perNode int) ([]groupNode, error) {
+	// Signature, node type, level, entries used, sibling addresses, then
+	// the keys and children
+	nodeSize := 8 + 2*w.OffsetSize() + perNode*w.OffsetSize() + (perNode+1)*w.LengthSize()
+	numNodes := max(1, (len(children)+perNode-1)/perNode)
+	nodes := make([]groupNode, numNodes)
+	for i := range nodes {
+		nodes[i].addr = allocate(int64(nodeSize))
+	}
+
+	for i := range nodes {
+		chunk := children[min(i*perNode, len(children)):min((i+1)*perNode, len(children))]
+		if len(chunk) > 0 {
+			nodes[i].leftKey, nodes[i].rightKey = chunk[0].leftKey, chunk[len(chunk)-1].rightKey
+		}
+		left, right := w.UndefinedOffset(), w.UndefinedOffset()
+		if i > 0 {
+			left = nodes[i-1].addr
+		}
+		if i < len(nodes)-1 {
+			right = nodes[i+1].addr
+		}
+
+		bw := w.At(int64(nodes[i].addr))
+		if err := bw.WriteBytes(btreeSignature); err != nil {
+			return nil, err
+		}
+		if err := bw.WriteBytes([]byte{0, uint8(nodeLevel)}); err != nil {
+			return nil, err
+		}
+		if err := bw.WriteUint16(uint16(len(chunk))); err != nil {
+			return nil, err
+		}
+		if err := bw.WriteOffset(left); err != nil {
+			return nil, err
+		}
+		if err := bw.WriteOffset(right); err != nil {
+			return nil, err
+		}
+		if err := bw.WriteLength(nodes[i].leftKey); err != nil {
+			return nil, err
+		}
+		for _, child := range chunk {
+			if err := bw.WriteOffset(child.addr); err != nil {
+				return nil, err
+			}
+			if err := bw.WriteLength(child.rightKey); err != nil {
+				return nil, err
+			}
+		}
+		// Unused keys and children are zero
+		if err := bw.WriteZeros(nodeSize - int(bw.Pos()-int64(nodes[i].addr))); err != nil {
+			return nil, fmt.Errorf("writing B-tree node: %w", err)
+		}
+	}
+	return nodes, nil
+}
@@ -0,0 +1,201 @@
+package btree
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/robert-malhotra/go-hdf5/internal/binary"
+)
+
+// B-tree v2 record types used for dense attribute and link storage
+const (
+	// BTreeV2TypeLinkName is type 5: links indexed by name hash
+	BTreeV2TypeLinkName uint8 = 5
+	// BTreeV2TypeLinkCreationOrder is type 6: links indexed by creation order
+	BTreeV2TypeLinkCreationOrder uint8 = 6
+	// BTreeV2TypeAttrName is type 8: attributes indexed by name hash
+	BTreeV2TypeAttrName uint8 = 8
+	// BTreeV2TypeAttrCreationOrder is type 9: attributes indexed by creation order
+	BTreeV2TypeAttrCreationOrder uint8 = 9
+)
+
+// btreeV2PrefixSize is the size of the signature, version, type and
+// checksum every B-tree v2 node has.
+const btreeV2PrefixSize = 4 + 1 + 1 + 4
+
+// ReadRecordsV2 returns the raw records of a v2 B-tree in key order,
+// together with the record type from its header. Every node's checksum is
+// verified. Callers decode the records according to the type.
+func ReadRecordsV2(r *binary.Reader, btreeAddr uint64) (uint8, [][]byte, error) {
+	header, err := readBTreeV2Header(r, btreeAddr)
+	if err != nil {
+		return 0, nil, fmt.Errorf("reading B-tree v2 header: %w", err)
+	}
+	if header.TotalRecords == 0 || r.IsUndefinedOffset(header.RootAddr) {
+		return header.Type, nil, nil
+	}
+	if header.RecordSize == 0 || header.NodeSize <= btreeV2PrefixSize {
+		return 0, nil, fmt.Errorf("B-tree v2 has invalid node size %d or record size %d",
+			header.NodeSize, header.RecordSize)
+	}
+
+	w := &v2Walker{r: r, header: header}
+	w.computeNodeInfo()
+	if err := w.walk(header.RootAddr, uint64(header.NumRootRecords), int(header.Depth)); err != nil {
+		return 0, nil, err
+	}
+	return header.Type, w.records, nil
+}
+
+// v2NodeInfo holds the record limits of the nodes at one depth of a v2
+// B-tree, which determine the size of the child pointers above them.
+type v2NodeInfo struct {
+	maxRecords    uint64 // Records in a full node
+	cumMaxRecords uint64 // Records in a full subtree rooted at such a node
+	cumSize       int    // Bytes used to store a subtree's record count
+}
+
+// v2Walker collects the records of a v2 B-tree.
+type v2Walker struct {
+	r           *binary.Reader
+	header      *btreeV2Header
+	info        []v2NodeInfo // Indexed by depth, leaves at 0
+	maxNrecSize int          // Bytes used to store a child's record count
+	records     [][]byte
+	seen        map[uint64]bool   // Nodes walked, which a tree reaches once
+	visit       func(addr uint64) // If set, called with each node walked
+}
+
+// computeNodeInfo derives the node limits the way the HDF5 library does
+// when it opens a v2 B-tree.
+func (w *v2Walker) computeNodeInfo() {
+	h := w.header
+	leafMax := uint64(h.NodeSize-btreeV2PrefixSize) / uint64(h.RecordSize)
+	w.info = []v2NodeInfo{{maxRecords: leafMax, cumMaxRecords: leafMax}}
+	w.maxNrecSize = encodedSize(leafMax)
+	for d := 1; d <= int(h.Depth); d++ {
+		ptrSize := uint64(w.pointerSize(d))
+		maxRecords := uint64(0)
+		if avail := uint64(h.NodeSize - btreeV2PrefixSize); avail > ptrSize {
+			maxRecords = (avail - ptrSize) / (uint64(h.RecordSize) + ptrSize)
+		}
+		cum := (maxRecords+1)*w.info[d-1].cumMaxRecords + maxRecords
+		w.info = append(w.info, v2NodeInfo{maxRecords, cum, encodedSize(cum)})
+	}
+}
+
+// pointerSize returns the size of a child pointer in an internal node at
+// the given depth: the child's address, its record count, and below the
+// lowest internal level, the record count of its whole subtree.
+func (w *v2Walker) pointerSize(depth int) int {
+	size := w.r.OffsetSize() + w.maxNrecSize
+	if depth > 1 {
+		size += w.info[depth-1].cumSize
+	}
+	return size
+}
+
+// encodedSize returns the number of bytes the HDF5 library uses to store
+// counts up to n.
+func encodedSize(n uint64) int {
+	if n == 0 {
+		return 1
+	}
+	return (bits.Len64(n)-1)/8 + 1
+}
+
+// walk appends the records of the node at addr, which holds nrec records
+// and sits at the given depth, and of all nodes below it.
+func (w *v2Walker) walk(addr, nrec uint64, depth int) error {
+	if w.seen == nil {
+		w.seen = make(map[uint64]bool)
+	}
+	if w.seen[addr] {
+		return fmt.Errorf("B-tree v2 node at %d is reached twice", addr)
+	}
+	w.seen[addr] = true
+	records, children, err := w.readNode(addr, nrec, depth)
+	if err != nil {
+		return err
+	}
+	if w.visit != nil {
+		w.visit(addr)
+	}
+	if depth == 0 {
+		w.records = append(w.records, records...)
+		return nil
+	}
+
+	// Child i holds the keys before record i; the last child those after
+	// the last record
+	for i, child := range children {
+		if err := w.walk(child.addr, child.nrec, depth-1); err != nil {
+			return err
+		}
+		if i < len(records) {
+			w.records = append(w.records, records[i])
+		}
+	}
+	return nil
+}
+
+// v2Child is a child pointer of an internal v2 B-tree node.
+type v2Child struct {
+	addr uint64
+	nrec uint64
+}
+
+// readNode reads and verifies the node at addr, which holds nrec records
+// and sits at the given depth, and returns its records and, for internal
+// nodes, its nrec+1 child pointers.
+func (w *v2Walker) readNode(addr, nrec uint64, depth int) ([][]byte, []v2Child, error) {
+	if depth >= len(w.info) {
+		return nil, nil, fmt.Errorf("B-tree v2 node at %d is deeper than the tree", addr)
+	}
+	if nrec > w.info[depth].maxRecords {
+		return nil, nil, fmt.Errorf("B-tree v2 node at %d has %d records, at most %d fit",
+			addr, nrec, w.info[depth].maxRecords)
+	}
+
+	recSize := int(w.header.RecordSize)
+	sig := "BTLF"
+	size := 6 + int(nrec)*recSize + 4
+	if depth > 0 {
+		sig = "BTIN"
+		size += int(nrec+1) * w.pointerSize(depth)
+	}
+	data, err := w.r.At(int64(addr)).ReadBytes(size)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading B-tree v2 node at %d: %w", addr, err)
+	}
+	if string(data[:4]) != sig {
+		return nil, nil, fmt.Errorf("invalid B-tree v2 node signature at %d: %q (expected %s)", addr, data[:4], sig)
+	}
+	if data[4] != 0 {
+		return nil, nil, fmt.Errorf("unsupported B-tree v2 node version: %d", data[4])
+	}
+	if data[5] != w.header.Type {
+		return nil, nil, fmt.Errorf("B-tree v2 node at %d has type %d, header has %d", addr, data[5], w.header.Type)
+	}
+	if err := binary.CheckLookup3(data, sig, addr); err != nil {
+		return nil, nil, err
+	}
+
+	records := make([][]byte, nrec)
+	for i := range records {
+		records[i] = data[6+i*recSize : 6+(i+1)*recSize]
+	}
+	if depth == 0 {
+		return records, nil, nil
+	}
+
+	pointers := data[6+int(nrec)*recSize : size-4]
+	ptrSize := w.pointerSize(depth)
+	o := w.r.OffsetSize()
+	children := make([]v2Child, nrec+1)
+	for i := range children {
+		ptr := pointers[i*ptrSize : (i+1)*ptrSize]
+		children[i] = v2Child{addr: binary.DecodeUintLE(ptr[:o]), nrec: binary.DecodeUintLE(ptr[o : o+w.maxNrecSize])}
+	}
+	return records, children, nil
+}
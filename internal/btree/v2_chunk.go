@@ -1,7 +1,10 @@
 package btree
 
 import (
+	"bytes"
 	"fmt"
+	"slices"
+	"sort"
 
 	"github.com/robert-malhotra/go-hdf5/internal/binary"
 )
@@ -52,17 +55,20 @@ func ReadChunkIndexV2(r *binary.Reader, btreeAddr uint64, ndims int) (*ChunkInde
 		return index, nil // Empty index
 	}
 
-	hasFilter := header.Type == BTreeV2TypeChunkWithFilter
+	sizeLen, err := chunkSizeLen(header, ndims, r.OffsetSize())
+	if err != nil {
+		return nil, err
+	}
 
 	var entries []ChunkEntry
 	if header.Depth == 0 {
 		// Root is a leaf node
 		entries, err = readBTreeV2LeafRecords(r, header.RootAddr, int(header.NumRootRecords),
-			header.RecordSize, ndims, hasFilter, r.OffsetSize())
+			header.RecordSize, ndims, sizeLen, r.OffsetSize())
 	} else {
 		// Root is internal node
 		entries, err = readBTreeV2InternalNode(r, header.RootAddr, int(header.NumRootRecords),
-			header, ndims, int(header.Depth), hasFilter)
+			header, ndims, int(header.Depth), sizeLen, make(map[uint64]bool))
 	}
 
 	if err != nil {
@@ -73,9 +79,96 @@ func ReadChunkIndexV2(r *binary.Reader, btreeAddr uint64, ndims int) (*ChunkInde
 	return index, nil
 }
 
+// FindChunkV2 looks up the chunk at coords in a v2 B-tree chunk index,
+// reading only the nodes on the path to it rather than the whole tree.
+// ndims is the number of dataset dimensions, and coords has one offset per
+// dimension, in the form ReadChunkIndexV2 reports them. It reports false if
+// the chunk is not allocated.
+func FindChunkV2(r *binary.Reader, btreeAddr uint64, ndims int, coords []uint64) (ChunkEntry, bool, error) {
+	if len(coords) != ndims {
+		return ChunkEntry{}, false, fmt.Errorf("chunk coordinates have %d dimensions, expected %d", len(coords), ndims)
+	}
+
+	header, err := readBTreeV2Header(r, btreeAddr)
+	if err != nil {
+		return ChunkEntry{}, false, fmt.Errorf("reading B-tree v2 header: %w", err)
+	}
+	if header.Type != BTreeV2TypeChunkNoFilter && header.Type != BTreeV2TypeChunkWithFilter {
+		return ChunkEntry{}, false, fmt.Errorf("unexpected B-tree v2 type: %d (expected 10 or 11 for chunks)", header.Type)
+	}
+	if header.TotalRecords == 0 || r.IsUndefinedOffset(header.RootAddr) {
+		return ChunkEntry{}, false, nil
+	}
+	if header.RecordSize == 0 || header.NodeSize <= btreeV2PrefixSize {
+		return ChunkEntry{}, false, fmt.Errorf("B-tree v2 has invalid node size %d or record size %d",
+			header.NodeSize, header.RecordSize)
+	}
+
+	sizeLen, err := chunkSizeLen(header, ndims, r.OffsetSize())
+	if err != nil {
+		return ChunkEntry{}, false, err
+	}
+
+	w := &v2Walker{r: r, header: header}
+	w.computeNodeInfo()
+	cfg := binary.Config{ByteOrder: r.ByteOrder(), OffsetSize: r.OffsetSize(), LengthSize: r.LengthSize()}
+	decode := func(rec []byte) (ChunkEntry, error) {
+		return readChunkRecord(binary.NewReader(bytes.NewReader(rec), cfg), ndims, sizeLen, r.OffsetSize())
+	}
+
+	addr, nrec, depth := header.RootAddr, uint64(header.NumRootRecords), int(header.Depth)
+	for {
+		records, children, err := w.readNode(addr, nrec, depth)
+		if err != nil {
+			return ChunkEntry{}, false, err
+		}
+		entries := make([]ChunkEntry, len(records))
+		for i, rec := range records {
+			if entries[i], err = decode(rec); err != nil {
+				return ChunkEntry{}, false, fmt.Errorf("reading record in node at %d: %w", addr, err)
+			}
+		}
+
+		// Records are ordered by offset, compared one dimension at a time;
+		// child i holds those between records i-1 and i
+		i := sort.Search(len(entries), func(i int) bool {
+			return slices.Compare(entries[i].Offset, coords) >= 0
+		})
+		if i < len(entries) && slices.Equal(entries[i].Offset, coords) {
+			entry := entries[i]
+			if entry.Address == 0 || r.IsUndefinedOffset(entry.Address) {
+				return ChunkEntry{}, false, nil
+			}
+			return entry, true, nil
+		}
+		if depth == 0 {
+			return ChunkEntry{}, false, nil
+		}
+		addr, nrec, depth = children[i].addr, children[i].nrec, depth-1
+	}
+}
+
+// chunkSizeLen returns the width of the chunk size field in the records of
+// a chunk index of the given header, or 0 for type 10 records, which have
+// none. Writers size the field to hold the largest chunk the dataset can
+// have, so it is found from the record size: what is left of a type 11
+// record after its address, filter mask and one 8-byte scaled offset per
+// dimension.
+func chunkSizeLen(header *btreeV2Header, ndims, offsetSize int) (int, error) {
+	if header.Type != BTreeV2TypeChunkWithFilter {
+		return 0, nil
+	}
+	n := int(header.RecordSize) - offsetSize - 4 - 8*ndims
+	if n < 1 || n > 8 {
+		return 0, fmt.Errorf("B-tree v2 record size %d leaves %d bytes for the chunk size of %d-dimensional chunks, expected 1 to 8",
+			header.RecordSize, n, ndims)
+	}
+	return n, nil
+}
+
 // readBTreeV2Header reads the BTHD header.
 func readBTreeV2Header(r *binary.Reader, address uint64) (*btreeV2Header, error) {
-	nr := r.At(int64(address))
+	nr := r.AtSize(int64(address), 22+r.OffsetSize()+r.LengthSize())
 
 	// Check signature
 	sig, err := nr.ReadBytes(4)
@@ -151,16 +244,19 @@ func readBTreeV2Header(r *binary.Reader, address uint64) (*btreeV2Header, error)
 		return nil, err
 	}
 
-	// Skip checksum (4 bytes) - we could verify it but not required for reading
+	// Checksum (4 bytes)
+	if err := r.CheckLookup3At("BTHD", address, int(nr.Pos()-int64(address))+4); err != nil {
+		return nil, err
+	}
 
 	return header, nil
 }
 
 // readBTreeV2LeafRecords reads chunk records from a leaf node.
 func readBTreeV2LeafRecords(r *binary.Reader, address uint64, numRecords int,
-	recordSize uint16, ndims int, hasFilter bool, offsetSize int) ([]ChunkEntry, error) {
+	recordSize uint16, ndims int, sizeLen int, offsetSize int) ([]ChunkEntry, error) {
 
-	nr := r.At(int64(address))
+	nr := r.AtSize(int64(address), btreeV2PrefixSize+numRecords*int(recordSize))
 
 	// Check leaf signature
 	sig, err := nr.ReadBytes(4)
@@ -189,7 +285,7 @@ func readBTreeV2LeafRecords(r *binary.Reader, address uint64, numRecords int,
 	// Read records
 	entries := make([]ChunkEntry, 0, numRecords)
 	for i := 0; i < numRecords; i++ {
-		entry, err := readChunkRecord(nr, ndims, hasFilter, offsetSize)
+		entry, err := readChunkRecord(nr, ndims, sizeLen, offsetSize)
 		if err != nil {
 			return nil, fmt.Errorf("reading record %d: %w", i, err)
 		}
@@ -198,14 +294,24 @@ func readBTreeV2LeafRecords(r *binary.Reader, address uint64, numRecords int,
 		}
 	}
 
+	// Checksum (4 bytes)
+	if err := r.CheckLookup3At("BTLF", address, 6+numRecords*int(recordSize)+4); err != nil {
+		return nil, err
+	}
+
 	return entries, nil
 }
 
 // readBTreeV2InternalNode reads records from an internal node and recurses into children.
+// Internal nodes already in seen fail, so that corrupt trees cannot be read over and over.
 func readBTreeV2InternalNode(r *binary.Reader, address uint64, numRecords int,
-	header *btreeV2Header, ndims int, depth int, hasFilter bool) ([]ChunkEntry, error) {
+	header *btreeV2Header, ndims int, depth int, sizeLen int, seen map[uint64]bool) ([]ChunkEntry, error) {
+	if seen[address] {
+		return nil, fmt.Errorf("B-tree v2 node at %d is reached twice", address)
+	}
+	seen[address] = true
 
-	nr := r.At(int64(address))
+	nr := r.AtSize(int64(address), int(header.NodeSize))
 
 	// Check internal node signature
 	sig, err := nr.ReadBytes(4)
@@ -259,11 +365,11 @@ func readBTreeV2InternalNode(r *binary.Reader, address uint64, numRecords int,
 		if depth == 1 {
 			// Child is a leaf
 			childEntries, err = readBTreeV2LeafRecords(r, childAddr, int(childNumRecords),
-				header.RecordSize, ndims, hasFilter, offsetSize)
+				header.RecordSize, ndims, sizeLen, offsetSize)
 		} else {
 			// Child is another internal node
 			childEntries, err = readBTreeV2InternalNode(r, childAddr, int(childNumRecords),
-				header, ndims, depth-1, hasFilter)
+				header, ndims, depth-1, sizeLen, seen)
 		}
 		if err != nil {
 			return nil, fmt.Errorf("reading child node %d: %w", i, err)
@@ -281,14 +387,19 @@ func readBTreeV2InternalNode(r *binary.Reader, address uint64, numRecords int,
 		return nil, fmt.Errorf("reading last child record count: %w", err)
 	}
 
+	// Checksum (4 bytes)
+	if err := r.CheckLookup3At("BTIN", address, int(nr.Pos()-int64(address))+4); err != nil {
+		return nil, err
+	}
+
 	// Recurse into last child
 	var childEntries []ChunkEntry
 	if depth == 1 {
 		childEntries, err = readBTreeV2LeafRecords(r, childAddr, int(childNumRecords),
-			header.RecordSize, ndims, hasFilter, offsetSize)
+			header.RecordSize, ndims, sizeLen, offsetSize)
 	} else {
 		childEntries, err = readBTreeV2InternalNode(r, childAddr, int(childNumRecords),
-			header, ndims, depth-1, hasFilter)
+			header, ndims, depth-1, sizeLen, seen)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("reading last child node: %w", err)
@@ -301,11 +412,13 @@ func readBTreeV2InternalNode(r *binary.Reader, address uint64, numRecords int,
 // readChunkRecord reads a single chunk record.
 // For type 10 (no filter): scaled offsets + address
 // For type 11 (with filter): address + chunk size + filter mask + scaled offsets
-func readChunkRecord(nr *binary.Reader, ndims int, hasFilter bool, offsetSize int) (ChunkEntry, error) {
+// sizeLen is the width of the type 11 chunk size field, from chunkSizeLen,
+// or 0 for type 10.
+func readChunkRecord(nr *binary.Reader, ndims int, sizeLen int, offsetSize int) (ChunkEntry, error) {
 	var entry ChunkEntry
 	var err error
 
-	if hasFilter {
+	if sizeLen > 0 {
 		// Type 11: With filter info
 		// Address first
 		entry.Address, err = nr.ReadOffset()
@@ -313,25 +426,11 @@ func readChunkRecord(nr *binary.Reader, ndims int, hasFilter bool, offsetSize in
 			return entry, err
 		}
 
-		// Chunk size (variable-length encoded as 8-bit field showing # of bytes, then value)
-		// For simplicity, read as 4-byte uint (most common case)
-		// Actually the spec says it's encoded size - let me read it properly
-		chunkSizeLen, err := nr.ReadUint8()
+		// Chunk size, little-endian in sizeLen bytes
+		entry.Size, err = nr.ReadUintN(sizeLen)
 		if err != nil {
 			return entry, err
 		}
-		if chunkSizeLen > 0 {
-			sizeBytes, err := nr.ReadBytes(int(chunkSizeLen))
-			if err != nil {
-				return entry, err
-			}
-			// Decode little-endian
-			var size uint64
-			for i := 0; i < len(sizeBytes); i++ {
-				size |= uint64(sizeBytes[i]) << (8 * i)
-			}
-			entry.Size = uint32(size)
-		}
 
 		// Filter mask (4 bytes)
 		entry.FilterMask, err = nr.ReadUint32()
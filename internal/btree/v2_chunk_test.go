@@ -2,8 +2,11 @@ package btree
 
 import (
 	"bytes"
+	stdbinary "encoding/binary"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/robert-malhotra/go-hdf5/internal/binary"
@@ -426,3 +429,78 @@ func TestBTreeV2NonZeroOffset(t *testing.T) {
 		t.Error("expected non-nil index")
 	}
 }
+
+// buildFilteredChunkTreeV2 writes a type 11 B-tree v2 of one leaf holding
+// a record for each entry of a 2-D dataset, with a chunk size field of
+// sizeLen bytes. The header is at address 0.
+func buildFilteredChunkTreeV2(entries []ChunkEntry, sizeLen int) []byte {
+	recordSize := 8 + sizeLen + 4 + 2*8
+	le := stdbinary.LittleEndian
+
+	hdr := []byte("BTHD\x00\x0b")
+	hdr = le.AppendUint32(hdr, 4096)
+	hdr = le.AppendUint16(hdr, uint16(recordSize))
+	hdr = le.AppendUint16(hdr, 0) // Depth
+	hdr = append(hdr, 100, 40)
+	hdr = le.AppendUint64(hdr, 64) // Root address
+	hdr = le.AppendUint16(hdr, uint16(len(entries)))
+	hdr = le.AppendUint64(hdr, uint64(len(entries)))
+	hdr = le.AppendUint32(hdr, binary.ChecksumLookup3(hdr, 0))
+
+	leaf := []byte("BTLF\x00\x0b")
+	for _, e := range entries {
+		leaf = le.AppendUint64(leaf, e.Address)
+		leaf = le.AppendUint64(leaf, e.Size)[:len(leaf)+sizeLen]
+		leaf = le.AppendUint32(leaf, e.FilterMask)
+		leaf = le.AppendUint64(leaf, e.Offset[0])
+		leaf = le.AppendUint64(leaf, e.Offset[1])
+	}
+	leaf = le.AppendUint32(leaf, binary.ChecksumLookup3(leaf, 0))
+
+	data := make([]byte, 64)
+	copy(data, hdr)
+	return append(data, leaf...)
+}
+
+// TestReadChunkIndexV2FilteredSizeWidths reads type 11 records whose chunk
+// size fields are 1 to 8 bytes wide, including sizes past 4 GiB.
+func TestReadChunkIndexV2FilteredSizeWidths(t *testing.T) {
+	for _, sizeLen := range []int{1, 2, 3, 4, 5, 8} {
+		t.Run(fmt.Sprintf("%d_bytes", sizeLen), func(t *testing.T) {
+			maxSize := uint64(1)<<(8*sizeLen) - 1
+			entries := []ChunkEntry{
+				{Offset: []uint64{0, 0}, Size: 1, FilterMask: 0, Address: 1000},
+				{Offset: []uint64{0, 1}, Size: maxSize / 3, FilterMask: 2, Address: 2000},
+				{Offset: []uint64{1, 0}, Size: maxSize, FilterMask: 0, Address: 3000},
+			}
+			data := buildFilteredChunkTreeV2(entries, sizeLen)
+			r := binary.NewReader(bytes.NewReader(data), binary.Config{
+				ByteOrder: stdbinary.LittleEndian, OffsetSize: 8, LengthSize: 8, VerifyChecksums: true,
+			})
+
+			idx, err := ReadChunkIndexV2(r, 0, 2)
+			if err != nil {
+				t.Fatalf("ReadChunkIndexV2 failed: %v", err)
+			}
+			if !reflect.DeepEqual(idx.Entries, entries) {
+				t.Errorf("entries = %+v, want %+v", idx.Entries, entries)
+			}
+			for _, want := range entries {
+				got, ok, err := FindChunkV2(r, 0, 2, want.Offset)
+				if err != nil || !ok || !reflect.DeepEqual(got, want) {
+					t.Errorf("FindChunkV2(%v) = %+v, %v, %v, want %+v", want.Offset, got, ok, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadChunkIndexV2FilteredBadRecordSize(t *testing.T) {
+	data := buildFilteredChunkTreeV2([]ChunkEntry{{Offset: []uint64{0, 0}, Size: 1, Address: 1000}}, 2)
+	r := binary.NewReader(bytes.NewReader(data), binary.DefaultConfig())
+
+	// Read as 3-D, the record leaves no room for a chunk size
+	if _, err := ReadChunkIndexV2(r, 0, 3); err == nil {
+		t.Error("expected error for a record too small for its chunk size")
+	}
+}
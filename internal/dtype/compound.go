@@ -0,0 +1,426 @@
+package dtype
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// maxCompoundDepth is how deeply structs may nest inside a compound type:
+// a top-level struct may contain structs, but those may not contain more.
+const maxCompoundDepth = 1
+
+// structField maps a compound member to the Go struct field holding it.
+type structField struct {
+	name     string
+	index    int
+	optional bool // Tagged ",optional": may be absent when reading
+}
+
+// structFields returns the exported fields of t that map to compound members.
+// A field's member name is taken from its `hdf5:"name"` tag, or the field
+// name if untagged; fields tagged `hdf5:"-"` are skipped. The tag option
+// "optional", as in `hdf5:"name,optional"`, lets reads leave the field
+// unset when the compound type has no such member.
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		field := structField{name: f.Name, index: i}
+		if tag, ok := f.Tag.Lookup("hdf5"); ok {
+			name, opts, _ := strings.Cut(tag, ",")
+			if name == "-" {
+				continue
+			}
+			if name != "" {
+				field.name = name
+			}
+			for _, opt := range strings.Split(opts, ",") {
+				field.optional = field.optional || opt == "optional"
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// structPlan records which compound member fills each field of a struct
+// type, so that the matching is done once per read rather than per element.
+type structPlan struct {
+	typ    reflect.Type
+	fields []plannedField
+}
+
+type plannedField struct {
+	index  int
+	member message.CompoundMember
+	nested *structPlan // For struct fields holding a nested compound
+}
+
+// newStructPlan matches the fields of struct type t to the members of the
+// compound type dt. Members are matched by exact name first, then ignoring
+// case. Every field must find a member unless tagged optional; members
+// without a field are ignored.
+func newStructPlan(dt *message.Datatype, t reflect.Type) (*structPlan, error) {
+	plan := &structPlan{typ: t}
+	for _, f := range structFields(t) {
+		member, ok := findMember(dt, f.name)
+		if !ok {
+			if f.optional {
+				continue
+			}
+			return nil, fmt.Errorf("compound type has no member %q for field %v.%s",
+				f.name, t, t.Field(f.index).Name)
+		}
+		if member.Type == nil {
+			return nil, fmt.Errorf("compound member %q has no datatype", member.Name)
+		}
+		if int(member.ByteOffset)+int(member.Type.Size) > int(dt.Size) {
+			return nil, fmt.Errorf("compound member %q extends past element", member.Name)
+		}
+
+		pf := plannedField{index: f.index, member: member}
+		fieldType := t.Field(f.index).Type
+		if fieldType.Kind() == reflect.Struct {
+			if member.Type.Class != message.ClassCompound {
+				return nil, fmt.Errorf("field %v.%s is a struct but compound member %q is not compound",
+					t, t.Field(f.index).Name, member.Name)
+			}
+			nested, err := newStructPlan(member.Type, fieldType)
+			if err != nil {
+				return nil, fmt.Errorf("compound member %q: %w", member.Name, err)
+			}
+			pf.nested = nested
+		}
+		plan.fields = append(plan.fields, pf)
+	}
+	return plan, nil
+}
+
+// findMember looks up a compound member by name, falling back to a
+// case-insensitive match.
+func findMember(dt *message.Datatype, name string) (message.CompoundMember, bool) {
+	for _, m := range dt.Members {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	for _, m := range dt.Members {
+		if strings.EqualFold(m.Name, name) {
+			return m, true
+		}
+	}
+	return message.CompoundMember{}, false
+}
+
+// decode fills the struct v from the bytes of one compound element.
+func (p *structPlan) decode(data []byte, v reflect.Value, reader *binary.Reader) error {
+	for _, f := range p.fields {
+		start := int(f.member.ByteOffset)
+		memberData := data[start : start+int(f.member.Type.Size)]
+		field := v.Field(f.index)
+		if f.nested != nil {
+			if err := f.nested.decode(memberData, field, reader); err != nil {
+				return fmt.Errorf("compound member %q: %w", f.member.Name, err)
+			}
+			continue
+		}
+		val, err := convertMemberValue(f.member.Type, memberData, reader)
+		if err != nil {
+			return fmt.Errorf("compound member %q: %w", f.member.Name, err)
+		}
+		if err := assignValue(field, reflect.ValueOf(val)); err != nil {
+			return fmt.Errorf("compound member %q: %w", f.member.Name, err)
+		}
+	}
+	return nil
+}
+
+// assignValue stores a decoded member value in a struct field. Integers may
+// fill integer or float fields and floats float fields; array members fill
+// slices or (possibly nested) Go arrays with the same number of elements.
+func assignValue(dst, src reflect.Value) error {
+	switch {
+	case src.Type().AssignableTo(dst.Type()):
+		dst.Set(src)
+	case isIntKind(src.Kind()) && (isIntKind(dst.Kind()) || isFloatKind(dst.Kind())),
+		isFloatKind(src.Kind()) && isFloatKind(dst.Kind()):
+		dst.Set(src.Convert(dst.Type()))
+	case src.Kind() == reflect.String && dst.Kind() == reflect.String:
+		dst.SetString(src.String())
+	case src.Kind() == reflect.Slice && dst.Kind() == reflect.Slice:
+		out := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			if err := assignValue(out.Index(i), src.Index(i)); err != nil {
+				return err
+			}
+		}
+		dst.Set(out)
+	case src.Kind() == reflect.Slice && dst.Kind() == reflect.Array:
+		n := 1
+		for t := dst.Type(); t.Kind() == reflect.Array; t = t.Elem() {
+			n *= t.Len()
+		}
+		if src.Len() != n {
+			return fmt.Errorf("array of %d elements does not fit %v", src.Len(), dst.Type())
+		}
+		pos := 0
+		return fillArray(dst, src, &pos)
+	default:
+		return fmt.Errorf("cannot store %v in field of type %v", src.Type(), dst.Type())
+	}
+	return nil
+}
+
+// fillArray fills a (possibly nested) Go array in row-major order from the
+// flat slice src, starting at *pos.
+func fillArray(dst, src reflect.Value, pos *int) error {
+	for i := 0; i < dst.Len(); i++ {
+		elem := dst.Index(i)
+		if elem.Kind() == reflect.Array {
+			if err := fillArray(elem, src, pos); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := assignValue(elem, src.Index(*pos)); err != nil {
+			return err
+		}
+		*pos++
+	}
+	return nil
+}
+
+func isIntKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func isFloatKind(k reflect.Kind) bool {
+	return k == reflect.Float32 || k == reflect.Float64
+}
+
+// structDatatype derives a packed compound datatype from a Go struct type.
+func structDatatype(t reflect.Type, depth int) (*message.Datatype, error) {
+	fields := structFields(t)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("struct %v has no exported fields", t)
+	}
+
+	members := make([]message.CompoundMember, 0, len(fields))
+	offset := uint32(0)
+	for _, f := range fields {
+		memberType, err := memberDatatype(t.Field(f.index).Type, depth)
+		if err != nil {
+			return nil, fmt.Errorf("compound member %q: %w", f.name, err)
+		}
+		members = append(members, message.CompoundMember{Name: f.name, ByteOffset: offset, Type: memberType})
+		offset += memberType.Size
+	}
+	return message.NewCompoundDatatype(offset, members), nil
+}
+
+// MapDatatype derives a packed compound datatype from a map with string keys,
+// using the dynamic type of each value. Members are ordered by key.
+func MapDatatype(m reflect.Value) (*message.Datatype, error) {
+	if m.Kind() != reflect.Map || m.Type().Key().Kind() != reflect.String {
+		return nil, fmt.Errorf("compound values must be maps with string keys, got %v", m.Type())
+	}
+	if m.Len() == 0 {
+		return nil, fmt.Errorf("empty map has no compound members")
+	}
+
+	keys := make([]string, 0, m.Len())
+	for _, k := range m.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+
+	members := make([]message.CompoundMember, 0, len(keys))
+	offset := uint32(0)
+	for _, key := range keys {
+		v := m.MapIndex(reflect.ValueOf(key).Convert(m.Type().Key()))
+		if v.Kind() == reflect.Interface {
+			v = v.Elem()
+		}
+		if !v.IsValid() {
+			return nil, fmt.Errorf("compound member %q: nil value", key)
+		}
+		memberType, err := memberDatatype(v.Type(), 0)
+		if err != nil {
+			return nil, fmt.Errorf("compound member %q: %w", key, err)
+		}
+		members = append(members, message.CompoundMember{Name: key, ByteOffset: offset, Type: memberType})
+		offset += memberType.Size
+	}
+	return message.NewCompoundDatatype(offset, members), nil
+}
+
+// memberDatatype returns the datatype of a compound member of Go type t.
+// Fixed-size arrays become array types and structs nested compounds;
+// variable-length members (strings, slices) are not supported.
+func memberDatatype(t reflect.Type, depth int) (*message.Datatype, error) {
+	switch t.Kind() {
+	case reflect.Struct:
+		if depth >= maxCompoundDepth {
+			return nil, fmt.Errorf("structs nested more than %d level deep are not supported", maxCompoundDepth)
+		}
+		return structDatatype(t, depth+1)
+
+	case reflect.Array:
+		var dims []uint32
+		for t.Kind() == reflect.Array {
+			dims = append(dims, uint32(t.Len()))
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct || t.Kind() == reflect.String {
+			return nil, fmt.Errorf("arrays of %v are not supported", t)
+		}
+		base, err := GoTypeToDatatype(t)
+		if err != nil {
+			return nil, err
+		}
+		return message.NewArrayDatatype(dims, base), nil
+
+	case reflect.String, reflect.Slice, reflect.Map:
+		return nil, fmt.Errorf("variable-length %v members are not supported in compound types", t.Kind())
+
+	default:
+		return GoTypeToDatatype(t)
+	}
+}
+
+// encodeCompound encodes a struct, a map with string keys, or a slice or
+// array of either.
+func encodeCompound(dt *message.Datatype, srcVal reflect.Value) ([]byte, error) {
+	size := int(dt.Size)
+
+	var n int
+	switch srcVal.Kind() {
+	case reflect.Slice, reflect.Array:
+		n = srcVal.Len()
+	default:
+		n = 1
+		sliceVal := reflect.MakeSlice(reflect.SliceOf(srcVal.Type()), 1, 1)
+		sliceVal.Index(0).Set(srcVal)
+		srcVal = sliceVal
+	}
+
+	data := make([]byte, n*size)
+	for i := 0; i < n; i++ {
+		if err := encodeCompoundValue(dt, srcVal.Index(i), data[i*size:(i+1)*size]); err != nil {
+			return nil, fmt.Errorf("element %d: %w", i, err)
+		}
+	}
+	return data, nil
+}
+
+// encodeCompoundValue encodes one struct or map into buf.
+func encodeCompoundValue(dt *message.Datatype, v reflect.Value, buf []byte) error {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		fields := structFields(v.Type())
+		byName := make(map[string]int, len(fields))
+		for _, f := range fields {
+			byName[f.name] = f.index
+		}
+		for _, member := range dt.Members {
+			index, ok := byName[member.Name]
+			if !ok {
+				return fmt.Errorf("struct %v has no field for compound member %q", v.Type(), member.Name)
+			}
+			if err := encodeMemberValue(member, v.Field(index), buf); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		for _, member := range dt.Members {
+			field := v.MapIndex(reflect.ValueOf(member.Name).Convert(v.Type().Key()))
+			if !field.IsValid() {
+				return fmt.Errorf("map has no value for compound member %q", member.Name)
+			}
+			if err := encodeMemberValue(member, field, buf); err != nil {
+				return err
+			}
+		}
+
+	default:
+		return fmt.Errorf("cannot encode %v as compound", v.Kind())
+	}
+	return nil
+}
+
+// encodeMemberValue encodes v into the bytes of member within buf.
+func encodeMemberValue(member message.CompoundMember, v reflect.Value, buf []byte) error {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	start := int(member.ByteOffset)
+	end := start + int(member.Type.Size)
+	if end > len(buf) {
+		return fmt.Errorf("compound member %q extends past element", member.Name)
+	}
+	out := buf[start:end]
+
+	var data []byte
+	var err error
+	switch member.Type.Class {
+	case message.ClassCompound:
+		err = encodeCompoundValue(member.Type, v, out)
+	case message.ClassArray:
+		data, err = encodeArrayValue(member.Type, v)
+	default:
+		data, err = Encode(member.Type, v.Interface())
+	}
+	if err != nil {
+		return fmt.Errorf("compound member %q: %w", member.Name, err)
+	}
+	copy(out, data)
+	return nil
+}
+
+// encodeArrayValue encodes a (possibly nested) Go array as an array type value.
+func encodeArrayValue(dt *message.Datatype, v reflect.Value) ([]byte, error) {
+	if v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("cannot encode %v as array", v.Kind())
+	}
+
+	// Flatten nested arrays in row-major order
+	flat := reflect.MakeSlice(reflect.SliceOf(v.Type()), 1, 1)
+	flat.Index(0).Set(v)
+	for flat.Type().Elem().Kind() == reflect.Array {
+		inner := flat.Type().Elem()
+		next := reflect.MakeSlice(reflect.SliceOf(inner.Elem()), 0, flat.Len()*inner.Len())
+		for i := 0; i < flat.Len(); i++ {
+			for j := 0; j < inner.Len(); j++ {
+				next = reflect.Append(next, flat.Index(i).Index(j))
+			}
+		}
+		flat = next
+	}
+
+	data, err := Encode(dt.BaseType, flat.Interface())
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != int(dt.Size) {
+		return nil, fmt.Errorf("array has %d bytes, datatype expects %d", len(data), dt.Size)
+	}
+	return data, nil
+}
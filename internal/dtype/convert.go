@@ -14,11 +14,14 @@ package dtype
 //   - Float-point: Converts using IEEE 754 bit representations
 //   - String (fixed): Copies bytes, handles null/space padding
 //   - String (varlen): Resolves global heap references
+//   - Sequence (varlen): Resolves global heap references into slices
 //   - Compound: Recursively converts each member by offset
 //   - Array: Converts element sequences based on array dimensions
-//   - Enum: Converts as underlying integer type
-//   - Bitfield: Converts as unsigned integer
+//   - Enum: Converts as the base integer type, or to member names for strings
+//   - Bitfield: Converts as unsigned integer, keeping only the bits its
+//     offset and precision select
 //   - Opaque: Returns raw bytes
+//   - Reference: Converts object references to object header addresses
 //
 // # Fast Path Optimization
 //
@@ -27,10 +30,17 @@ package dtype
 // unsafe.Pointer. This is controlled by canDirectCopy() and directCopy().
 //
 // The fast path applies when:
-//   - Byte order is little-endian (matches x86/ARM platforms)
+//   - Byte order matches the host byte order (detected once at startup), or
+//     the element is a single byte
 //   - Element size matches the Go type size
 //   - Type class is fixed-point or float-point
 //
+// Data of the opposite byte order (big-endian files read on little-endian
+// hosts, and vice versa) takes a second fast path under the same size and
+// class rules: swapCopy decodes each 2, 4 or 8-byte word in a tight loop
+// straight into the destination slice, which avoids the reflect call per
+// element of the general conversion loop.
+//
 // # Variable-Length Data
 //
 // Variable-length strings and sequences store references to the global heap
@@ -52,6 +62,8 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"strconv"
+	"strings"
 	"unsafe"
 
 	"github.com/robert-malhotra/go-hdf5/internal/binary"
@@ -82,6 +94,8 @@ func ConvertWithReader(dt *message.Datatype, data []byte, numElements uint64, de
 	switch dt.Class {
 	case message.ClassFixedPoint:
 		return convertFixedPoint(dt, data, numElements, elemVal)
+	case message.ClassTime:
+		return convertFixedPoint(TimeInteger(dt), data, numElements, elemVal)
 	case message.ClassFloatPoint:
 		return convertFloatPoint(dt, data, numElements, elemVal)
 	case message.ClassString:
@@ -98,6 +112,8 @@ func ConvertWithReader(dt *message.Datatype, data []byte, numElements uint64, de
 		return convertBitfield(dt, data, numElements, elemVal)
 	case message.ClassOpaque:
 		return convertOpaque(dt, data, numElements, elemVal)
+	case message.ClassReference:
+		return convertReference(dt, data, numElements, elemVal)
 	default:
 		return fmt.Errorf("unsupported datatype class for conversion: %d", dt.Class)
 	}
@@ -118,11 +134,15 @@ func convertFixedPoint(dt *message.Datatype, data []byte, n uint64, dest reflect
 	size := int(dt.Size)
 	signed := dt.Signed
 
-	// Fast path: if dest is a compatible slice and endianness matches
+	// Fast path: if dest is a compatible slice, copy it in bulk, swapping
+	// bytes when the endianness differs
 	if dest.Kind() == reflect.Slice && dest.CanSet() {
 		if canDirectCopy(dt, dest.Type().Elem()) {
 			return directCopy(data, n, size, dest)
 		}
+		if canSwapCopy(dt, dest.Type().Elem()) {
+			return swapCopy(dt, data, n, size, dest)
+		}
 	}
 
 	// Slow path: element-by-element conversion
@@ -143,27 +163,28 @@ func convertFixedPoint(dt *message.Datatype, data []byte, n uint64, dest reflect
 
 		switch size {
 		case 1:
+			v := uint8(fixedPointBits(dt, uint64(elemData[0])))
 			if signed {
-				val = int8(elemData[0])
+				val = int8(v)
 			} else {
-				val = elemData[0]
+				val = v
 			}
 		case 2:
-			v := order.Uint16(elemData)
+			v := uint16(fixedPointBits(dt, uint64(order.Uint16(elemData))))
 			if signed {
 				val = int16(v)
 			} else {
 				val = v
 			}
 		case 4:
-			v := order.Uint32(elemData)
+			v := uint32(fixedPointBits(dt, uint64(order.Uint32(elemData))))
 			if signed {
 				val = int32(v)
 			} else {
 				val = v
 			}
 		case 8:
-			v := order.Uint64(elemData)
+			v := fixedPointBits(dt, order.Uint64(elemData))
 			if signed {
 				val = int64(v)
 			} else {
@@ -189,6 +210,9 @@ func convertFloatPoint(dt *message.Datatype, data []byte, n uint64, dest reflect
 	if dest.Kind() == reflect.Slice && canDirectCopy(dt, dest.Type().Elem()) {
 		return directCopy(data, n, size, dest)
 	}
+	if dest.Kind() == reflect.Slice && canSwapCopy(dt, dest.Type().Elem()) {
+		return swapCopy(dt, data, n, size, dest)
+	}
 
 	// Slow path
 	if dest.Kind() == reflect.Slice {
@@ -276,7 +300,90 @@ func convertVarLen(dt *message.Datatype, data []byte, n uint64, dest reflect.Val
 		return convertVarLenString(dt, data, n, dest, reader)
 	}
 
-	return fmt.Errorf("variable-length data type not fully supported (IsVarLenString=%v)", dt.IsVarLenString)
+	if dt.VarLenType == nil {
+		return fmt.Errorf("variable-length sequence has no base type")
+	}
+	return convertVarLenSequence(dt, data, n, dest, reader)
+}
+
+// convertVarLenSequence converts variable-length sequences into a slice of
+// slices, or into a slice of interface{} holding one slice of the base
+// type's Go type per element. Each element is stored as a reference to the
+// global heap object holding the sequence:
+//   - 4 bytes: number of base type elements
+//   - offsetSize bytes: heap collection address
+//   - 4 bytes: object index
+//
+// Empty sequences and null references become empty, non-nil slices.
+func convertVarLenSequence(dt *message.Datatype, data []byte, n uint64, dest reflect.Value, reader *binary.Reader) error {
+	if dest.Kind() != reflect.Slice {
+		return fmt.Errorf("variable-length sequences must be read into a slice of slices, got %v", dest.Type())
+	}
+	rowType := dest.Type().Elem()
+	switch rowType.Kind() {
+	case reflect.Slice:
+	case reflect.Interface:
+		baseType, err := GoType(dt.VarLenType)
+		if err != nil {
+			return fmt.Errorf("variable-length sequence base type: %w", err)
+		}
+		rowType = reflect.SliceOf(baseType)
+	default:
+		return fmt.Errorf("variable-length sequences must be read into a slice of slices, got %v", dest.Type())
+	}
+
+	offsetSize := 8
+	if reader != nil {
+		offsetSize = reader.OffsetSize()
+	}
+	refSize := 4 + offsetSize + 4
+	if len(data) < int(n)*refSize {
+		return fmt.Errorf("not enough data: need %d bytes, have %d", int(n)*refSize, len(data))
+	}
+	if dest.Len() < int(n) {
+		dest.Set(reflect.MakeSlice(dest.Type(), int(n), int(n)))
+	}
+
+	baseSize := uint64(dt.VarLenType.Size)
+	heapCache := make(map[uint64]*heap.GlobalHeap)
+	for i := 0; i < int(n); i++ {
+		refData := data[i*refSize : (i+1)*refSize]
+		length := uint64(refData[0]) | uint64(refData[1])<<8 | uint64(refData[2])<<16 | uint64(refData[3])<<24
+		heapID, err := heap.ParseGlobalHeapID(refData[4:], offsetSize)
+		if err != nil {
+			return fmt.Errorf("parsing global heap ID for element %d: %w", i, err)
+		}
+
+		row := reflect.New(rowType)
+		if length > 0 && heapID.CollectionAddress != 0 {
+			if reader == nil {
+				return fmt.Errorf("variable-length sequence reading requires file reader (global heap at 0x%x)", heapID.CollectionAddress)
+			}
+			gh, ok := heapCache[heapID.CollectionAddress]
+			if !ok {
+				gh, err = heap.ReadGlobalHeap(reader, heapID.CollectionAddress)
+				if err != nil {
+					return fmt.Errorf("reading global heap at 0x%x: %w", heapID.CollectionAddress, err)
+				}
+				heapCache[heapID.CollectionAddress] = gh
+			}
+			obj, err := gh.GetObject(uint16(heapID.ObjectIndex))
+			if err != nil {
+				return fmt.Errorf("getting sequence %d from heap: %w", i, err)
+			}
+			if uint64(len(obj)) < length*baseSize {
+				return fmt.Errorf("sequence %d has %d bytes, need %d for %d elements", i, len(obj), length*baseSize, length)
+			}
+			if err := ConvertWithReader(dt.VarLenType, obj, length, row.Interface(), reader); err != nil {
+				return fmt.Errorf("converting sequence %d: %w", i, err)
+			}
+		}
+		if row.Elem().IsNil() {
+			row.Elem().Set(reflect.MakeSlice(rowType, 0, 0))
+		}
+		dest.Index(i).Set(row.Elem())
+	}
+	return nil
 }
 
 func convertVarLenString(dt *message.Datatype, data []byte, n uint64, dest reflect.Value, reader *binary.Reader) error {
@@ -328,7 +435,7 @@ func convertVarLenString(dt *message.Datatype, data []byte, n uint64, dest refle
 
 		// We need the reader to access the global heap
 		if reader == nil {
-			return fmt.Errorf("variable-length string reading requires file reader (global heap at 0x%x)", heapID.CollectionAddress)
+			return fmt.Errorf("variable-length string %d reading requires file reader (global heap at 0x%x)", i, heapID.CollectionAddress)
 		}
 
 		// Get or read the global heap collection (cache for efficiency)
@@ -336,7 +443,7 @@ func convertVarLenString(dt *message.Datatype, data []byte, n uint64, dest refle
 		if !ok {
 			gh, err = heap.ReadGlobalHeap(reader, heapID.CollectionAddress)
 			if err != nil {
-				return fmt.Errorf("reading global heap at 0x%x: %w", heapID.CollectionAddress, err)
+				return fmt.Errorf("reading global heap at 0x%x for string %d: %w", heapID.CollectionAddress, i, err)
 			}
 			heapCache[heapID.CollectionAddress] = gh
 		}
@@ -344,7 +451,7 @@ func convertVarLenString(dt *message.Datatype, data []byte, n uint64, dest refle
 		// Get the string from the heap
 		str, err := gh.GetString(uint16(heapID.ObjectIndex))
 		if err != nil {
-			return fmt.Errorf("getting string from heap (index %d): %w", heapID.ObjectIndex, err)
+			return fmt.Errorf("getting string %d from heap: %w", i, err)
 		}
 
 		if dest.Kind() == reflect.Slice && dest.Type().Elem().Kind() == reflect.String {
@@ -361,10 +468,32 @@ func convertCompound(dt *message.Datatype, data []byte, n uint64, dest reflect.V
 	// Compound types are stored as contiguous bytes with members at specific offsets
 	size := int(dt.Size)
 
-	if dest.Kind() == reflect.Slice {
+	if dest.Kind() == reflect.Struct ||
+		(dest.Kind() == reflect.Slice && dest.Type().Elem().Kind() == reflect.Struct) {
+		return convertCompoundStructs(dt, data, n, dest, reader)
+	}
+
+	// Raw element bytes, one []byte each
+	if dest.Kind() == reflect.Slice && isBytes(dest.Type().Elem()) {
+		return convertRawElements(data, n, size, dest)
+	}
+
+	switch {
+	case dest.Kind() == reflect.Slice && (dest.Type().Elem() == mapType || dest.Type().Elem().Kind() == reflect.Interface):
 		if dest.Len() < int(n) {
 			dest.Set(reflect.MakeSlice(dest.Type(), int(n), int(n)))
 		}
+	case dest.Type() == mapType:
+		if n > 1 {
+			return fmt.Errorf("cannot decode %d compound elements into one map", n)
+		}
+		if dest.IsNil() {
+			dest.Set(reflect.MakeMap(mapType))
+		}
+	case dest.Kind() == reflect.Interface:
+		// Takes the first element
+	default:
+		return fmt.Errorf("cannot decode compound elements into %v; use structs, map[string]interface{} or [][]byte", dest.Type())
 	}
 
 	for i := uint64(0); i < n; i++ {
@@ -414,32 +543,184 @@ func convertCompound(dt *message.Datatype, data []byte, n uint64, dest reflect.V
 	return nil
 }
 
+// mapType is the type compound elements decode to without a struct.
+var mapType = reflect.TypeOf(map[string]interface{}{})
+
+// isBytes reports whether t is a byte slice.
+func isBytes(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+// convertRawElements copies the bytes of each element of size bytes into
+// dest, a slice of byte slices, or of the single element into dest, a byte
+// slice.
+func convertRawElements(data []byte, n uint64, size int, dest reflect.Value) error {
+	if len(data) < int(n)*size {
+		return fmt.Errorf("not enough data: need %d bytes, have %d", int(n)*size, len(data))
+	}
+	if isBytes(dest.Type()) {
+		if n != 1 {
+			return fmt.Errorf("cannot copy %d elements into one %v; pass a slice", n, dest.Type())
+		}
+		dest.SetBytes(append([]byte(nil), data[:size]...))
+		return nil
+	}
+	if dest.Len() < int(n) {
+		dest.Set(reflect.MakeSlice(dest.Type(), int(n), int(n)))
+	}
+	for i := 0; i < int(n); i++ {
+		dest.Index(i).SetBytes(append([]byte(nil), data[i*size:(i+1)*size]...))
+	}
+	return nil
+}
+
+// convertCompoundStructs decodes compound elements into a struct, or a
+// slice of structs, matching fields to members as described by
+// newStructPlan.
+func convertCompoundStructs(dt *message.Datatype, data []byte, n uint64, dest reflect.Value, reader *binary.Reader) error {
+	size := int(dt.Size)
+	structType := dest.Type()
+	if dest.Kind() == reflect.Slice {
+		structType = structType.Elem()
+		if dest.Len() < int(n) {
+			dest.Set(reflect.MakeSlice(dest.Type(), int(n), int(n)))
+		}
+	} else if n > 1 {
+		return fmt.Errorf("cannot decode %d compound elements into one %v; pass a slice", n, structType)
+	}
+
+	plan, err := newStructPlan(dt, structType)
+	if err != nil {
+		return err
+	}
+	if len(data) < int(n)*size {
+		return fmt.Errorf("not enough data: need %d bytes, have %d", int(n)*size, len(data))
+	}
+
+	for i := 0; i < int(n); i++ {
+		elem := dest
+		if dest.Kind() == reflect.Slice {
+			elem = dest.Index(i)
+		}
+		if err := plan.decode(data[i*size:(i+1)*size], elem, reader); err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// ConvertCompoundColumns converts only the named members of compound data,
+// returning the values of each member as a column. Bytes belonging to other
+// members are never decoded. Every name must be a member of dt.
+func ConvertCompoundColumns(dt *message.Datatype, data []byte, n uint64, names []string, reader *binary.Reader) (map[string][]interface{}, error) {
+	if dt == nil || dt.Class != message.ClassCompound {
+		return nil, fmt.Errorf("datatype is not compound")
+	}
+
+	members, err := ProjectMembers(dt, names)
+	if err != nil {
+		return nil, err
+	}
+
+	size := int(dt.Size)
+	columns := make(map[string][]interface{}, len(members))
+	for _, member := range members {
+		columns[member.Name] = make([]interface{}, n)
+	}
+
+	for i := uint64(0); i < n; i++ {
+		offset := int(i) * size
+		if offset+size > len(data) {
+			return nil, fmt.Errorf("not enough data: need %d bytes, have %d", int(n)*size, len(data))
+		}
+		elemData := data[offset : offset+size]
+
+		for _, member := range members {
+			memberOffset := int(member.ByteOffset)
+			memberSize := int(member.Type.Size)
+			if memberOffset+memberSize > len(elemData) {
+				return nil, fmt.Errorf("compound member %q extends past element", member.Name)
+			}
+			val, err := convertMemberValue(member.Type, elemData[memberOffset:memberOffset+memberSize], reader)
+			if err != nil {
+				return nil, fmt.Errorf("converting compound member %q: %w", member.Name, err)
+			}
+			columns[member.Name][i] = val
+		}
+	}
+
+	return columns, nil
+}
+
+// ProjectMembers returns the members of compound type dt with the given names,
+// in the order requested. All unknown names are reported in a single error.
+func ProjectMembers(dt *message.Datatype, names []string) ([]message.CompoundMember, error) {
+	byName := make(map[string]message.CompoundMember, len(dt.Members))
+	for _, member := range dt.Members {
+		if member.Type != nil {
+			byName[member.Name] = member
+		}
+	}
+
+	members := make([]message.CompoundMember, 0, len(names))
+	var unknown []string
+	for _, name := range names {
+		member, ok := byName[name]
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		members = append(members, member)
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown compound members: %s", strings.Join(unknown, ", "))
+	}
+	return members, nil
+}
+
+// ConvertValue converts the raw bytes of a single element to its natural Go
+// value: a number, a string, a map for compound types or a slice for
+// arrays.
+func ConvertValue(dt *message.Datatype, data []byte, reader *binary.Reader) (interface{}, error) {
+	if dt == nil {
+		return nil, fmt.Errorf("nil datatype")
+	}
+	if len(data) < int(dt.Size) {
+		return nil, fmt.Errorf("value has %d bytes, datatype has %d", len(data), dt.Size)
+	}
+	return convertMemberValue(dt, data, reader)
+}
+
 // convertMemberValue converts a single compound member value.
 func convertMemberValue(dt *message.Datatype, data []byte, reader *binary.Reader) (interface{}, error) {
+	if dt.Class == message.ClassTime {
+		dt = TimeInteger(dt)
+	}
 	switch dt.Class {
 	case message.ClassFixedPoint:
 		order := ByteOrder(dt)
 		size := int(dt.Size)
 		switch size {
 		case 1:
+			v := uint8(fixedPointBits(dt, uint64(data[0])))
 			if dt.Signed {
-				return int8(data[0]), nil
+				return int8(v), nil
 			}
-			return data[0], nil
+			return v, nil
 		case 2:
-			v := order.Uint16(data)
+			v := uint16(fixedPointBits(dt, uint64(order.Uint16(data))))
 			if dt.Signed {
 				return int16(v), nil
 			}
 			return v, nil
 		case 4:
-			v := order.Uint32(data)
+			v := uint32(fixedPointBits(dt, uint64(order.Uint32(data))))
 			if dt.Signed {
 				return int32(v), nil
 			}
 			return v, nil
 		case 8:
-			v := order.Uint64(data)
+			v := fixedPointBits(dt, order.Uint64(data))
 			if dt.Signed {
 				return int64(v), nil
 			}
@@ -467,6 +748,25 @@ func convertMemberValue(dt *message.Datatype, data []byte, reader *binary.Reader
 			}
 		}
 		return string(data[:end]), nil
+	case message.ClassVarLen:
+		if dt.IsVarLenString {
+			var result []string
+			if err := convertVarLenString(dt, data, 1, reflect.ValueOf(&result).Elem(), reader); err != nil {
+				return nil, err
+			}
+			if len(result) == 1 {
+				return result[0], nil
+			}
+		}
+	case message.ClassEnum:
+		if dt.BaseType != nil {
+			return convertMemberValue(dt.BaseType, data, reader)
+		}
+	case message.ClassReference:
+		if !dt.IsObjectReference() {
+			return nil, fmt.Errorf("region references are not supported")
+		}
+		return referenceAddress(data)
 	case message.ClassCompound:
 		result := make(map[string]interface{})
 		for _, member := range dt.Members {
@@ -486,6 +786,27 @@ func convertMemberValue(dt *message.Datatype, data []byte, reader *binary.Reader
 			result[member.Name] = val
 		}
 		return result, nil
+	case message.ClassArray:
+		if dt.BaseType == nil || dt.BaseType.Size == 0 {
+			return nil, fmt.Errorf("invalid array type: missing base type")
+		}
+		baseSize := int(dt.BaseType.Size)
+		count := len(data) / baseSize
+		var result reflect.Value
+		for j := 0; j < count; j++ {
+			val, err := convertMemberValue(dt.BaseType, data[j*baseSize:(j+1)*baseSize], reader)
+			if err != nil {
+				return nil, err
+			}
+			if j == 0 {
+				result = reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(val)), count, count)
+			}
+			result.Index(j).Set(reflect.ValueOf(val))
+		}
+		if count == 0 {
+			return []interface{}{}, nil
+		}
+		return result.Interface(), nil
 	}
 	return nil, fmt.Errorf("unsupported member type class: %d", dt.Class)
 }
@@ -595,9 +916,88 @@ func convertArray(dt *message.Datatype, data []byte, n uint64, dest reflect.Valu
 	return nil
 }
 
+// convertReference converts object references, each the address of the
+// referenced object header in offset-size little-endian bytes, to unsigned
+// integers. The undefined address reads as all ones whatever its size.
+func convertReference(dt *message.Datatype, data []byte, n uint64, dest reflect.Value) error {
+	if !dt.IsObjectReference() {
+		return fmt.Errorf("region references are not supported")
+	}
+	size := int(dt.Size)
+
+	if dest.Kind() == reflect.Slice {
+		if dest.Len() < int(n) {
+			dest.Set(reflect.MakeSlice(dest.Type(), int(n), int(n)))
+		}
+	}
+
+	for i := uint64(0); i < n; i++ {
+		offset := int(i) * size
+		if offset+size > len(data) {
+			return fmt.Errorf("not enough data: need %d bytes, have %d", int(n)*size, len(data))
+		}
+		addr, err := referenceAddress(data[offset : offset+size])
+		if err != nil {
+			return err
+		}
+
+		target := dest
+		if dest.Kind() == reflect.Slice {
+			target = dest.Index(int(i))
+		} else if i > 0 {
+			break
+		}
+		switch target.Kind() {
+		case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+			target.SetUint(addr)
+		case reflect.Interface:
+			target.Set(reflect.ValueOf(addr))
+		default:
+			return fmt.Errorf("cannot convert object reference to %v", target.Type())
+		}
+	}
+	return nil
+}
+
+// referenceAddress decodes one object reference.
+func referenceAddress(b []byte) (uint64, error) {
+	switch len(b) {
+	case 2, 4, 8:
+	default:
+		return 0, fmt.Errorf("unsupported object reference size: %d", len(b))
+	}
+	var addr uint64
+	for i := len(b) - 1; i >= 0; i-- {
+		addr = addr<<8 | uint64(b[i])
+	}
+	if len(b) < 8 && addr == uint64(1)<<(8*len(b))-1 {
+		addr = ^uint64(0)
+	}
+	return addr, nil
+}
+
 func convertEnum(dt *message.Datatype, data []byte, n uint64, dest reflect.Value) error {
+	// Strings receive member names
+	if dest.Kind() == reflect.Slice && dest.Type().Elem().Kind() == reflect.String {
+		names, err := EnumNames(dt, data, n)
+		if err != nil {
+			return err
+		}
+		if dest.Len() < len(names) {
+			dest.Set(reflect.MakeSlice(dest.Type(), len(names), len(names)))
+		}
+		for i, name := range names {
+			dest.Index(i).SetString(name)
+		}
+		return nil
+	}
+
 	// Enums are stored as their underlying integer type
-	// For now, convert to the base integer type
+	if dt.BaseType != nil && dt.BaseType.Class == message.ClassFixedPoint {
+		return convertFixedPoint(dt.BaseType, data, n, dest)
+	}
+
+	// Without a base type, assume signed little-endian values
 	order := ByteOrder(dt)
 	size := int(dt.Size)
 
@@ -637,8 +1037,51 @@ func convertEnum(dt *message.Datatype, data []byte, n uint64, dest reflect.Value
 	return nil
 }
 
+// EnumNames maps n stored enum values to the names of their members.
+// Values that match no member are rendered as decimal numbers.
+func EnumNames(dt *message.Datatype, data []byte, n uint64) ([]string, error) {
+	if dt.Class != message.ClassEnum {
+		return nil, fmt.Errorf("datatype class %d is not an enum", dt.Class)
+	}
+	size := int(dt.Size)
+	if needed := int(n) * size; needed > len(data) {
+		return nil, fmt.Errorf("not enough data: need %d bytes, have %d", needed, len(data))
+	}
+
+	byValue := make(map[string]string, len(dt.EnumValues))
+	for i, v := range dt.EnumValues {
+		if i < len(dt.EnumNames) {
+			if _, dup := byValue[string(v)]; !dup {
+				byValue[string(v)] = dt.EnumNames[i]
+			}
+		}
+	}
+
+	names := make([]string, n)
+	for i := range names {
+		elem := data[i*size : (i+1)*size]
+		if name, ok := byValue[string(elem)]; ok {
+			names[i] = name
+			continue
+		}
+		var v []int64
+		if err := convertEnum(dt, elem, 1, reflect.ValueOf(&v).Elem()); err != nil {
+			return nil, err
+		}
+		if dt.BaseType != nil && !dt.BaseType.Signed {
+			names[i] = strconv.FormatUint(uint64(v[0]), 10)
+		} else {
+			names[i] = strconv.FormatInt(v[0], 10)
+		}
+	}
+	return names, nil
+}
+
 func convertBitfield(dt *message.Datatype, data []byte, n uint64, dest reflect.Value) error {
-	// Bitfields are stored as unsigned integers
+	// Bitfields are stored as unsigned integers, of which only the bits
+	// from the bit offset up to the precision are significant
+	bits := *dt
+	bits.Signed = false
 	order := ByteOrder(dt)
 	size := int(dt.Size)
 
@@ -659,13 +1102,13 @@ func convertBitfield(dt *message.Datatype, data []byte, n uint64, dest reflect.V
 
 		switch size {
 		case 1:
-			val = elemData[0]
+			val = uint8(fixedPointBits(&bits, uint64(elemData[0])))
 		case 2:
-			val = order.Uint16(elemData)
+			val = uint16(fixedPointBits(&bits, uint64(order.Uint16(elemData))))
 		case 4:
-			val = order.Uint32(elemData)
+			val = uint32(fixedPointBits(&bits, uint64(order.Uint32(elemData))))
 		case 8:
-			val = order.Uint64(elemData)
+			val = fixedPointBits(&bits, order.Uint64(elemData))
 		default:
 			return fmt.Errorf("unsupported bitfield size: %d", size)
 		}
@@ -681,8 +1124,14 @@ func convertBitfield(dt *message.Datatype, data []byte, n uint64, dest reflect.V
 func convertOpaque(dt *message.Datatype, data []byte, n uint64, dest reflect.Value) error {
 	// Opaque types are returned as raw byte slices
 	size := int(dt.Size)
+	if isBytes(dest.Type()) || dest.Kind() == reflect.Slice && isBytes(dest.Type().Elem()) {
+		return convertRawElements(data, n, size, dest)
+	}
 
 	if dest.Kind() == reflect.Slice {
+		if dest.Type().Elem().Kind() != reflect.Interface {
+			return fmt.Errorf("cannot copy opaque elements into %v; use [][]byte", dest.Type())
+		}
 		if dest.Len() < int(n) {
 			dest.Set(reflect.MakeSlice(dest.Type(), int(n), int(n)))
 		}
@@ -705,13 +1154,43 @@ func convertOpaque(dt *message.Datatype, data []byte, n uint64, dest reflect.Val
 	return nil
 }
 
+// hostOrder is the byte order of the running platform. It is a variable
+// so that tests can simulate a big-endian host on little-endian hardware.
+var hostOrder = detectHostOrder()
+
+// detectHostOrder inspects the in-memory layout of a uint16.
+func detectHostOrder() message.ByteOrder {
+	x := uint16(1)
+	if *(*byte)(unsafe.Pointer(&x)) == 1 {
+		return message.OrderLE
+	}
+	return message.OrderBE
+}
+
 // canDirectCopy checks if we can do a direct memory copy.
 func canDirectCopy(dt *message.Datatype, elemType reflect.Type) bool {
-	// Must be little-endian (native for most systems)
-	if dt.ByteOrder != message.OrderLE {
+	// Multi-byte values must already be in host byte order
+	if dt.Size > 1 && dt.ByteOrder != hostOrder {
 		return false
 	}
+	return bulkCompatible(dt, elemType)
+}
 
+// canSwapCopy checks if we can decode multi-byte values in the opposite
+// byte order to the host straight into the destination's memory. VAX-ordered data is left to the element-by-element path.
+func canSwapCopy(dt *message.Datatype, elemType reflect.Type) bool {
+	if dt.Size == 1 || dt.ByteOrder == hostOrder {
+		return false
+	}
+	if dt.ByteOrder != message.OrderLE && dt.ByteOrder != message.OrderBE {
+		return false
+	}
+	return bulkCompatible(dt, elemType)
+}
+
+// bulkCompatible checks if elements of dt have the memory layout of
+// elemType apart from byte order.
+func bulkCompatible(dt *message.Datatype, elemType reflect.Type) bool {
 	// Size must match
 	if int(dt.Size) != int(elemType.Size()) {
 		return false
@@ -720,6 +1199,9 @@ func canDirectCopy(dt *message.Datatype, elemType reflect.Type) bool {
 	// Type must be compatible
 	switch dt.Class {
 	case message.ClassFixedPoint:
+		if partialPrecision(dt) {
+			return false
+		}
 		switch elemType.Kind() {
 		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 			return dt.Signed
@@ -736,6 +1218,30 @@ func canDirectCopy(dt *message.Datatype, elemType reflect.Type) bool {
 	return false
 }
 
+// partialPrecision reports whether a fixed-point type uses only some of
+// the bits of its storage, as data unpacked by the N-bit filter does.
+func partialPrecision(dt *message.Datatype) bool {
+	return dt.BitPrecision != 0 &&
+		(dt.BitOffset != 0 || int(dt.BitPrecision) < int(dt.Size)*8)
+}
+
+// fixedPointBits extracts the significant bits of a fixed-point value read
+// from storage, sign-extending them for signed types.
+func fixedPointBits(dt *message.Datatype, v uint64) uint64 {
+	if !partialPrecision(dt) || dt.BitOffset >= 64 {
+		return v
+	}
+	v >>= dt.BitOffset
+	if dt.BitPrecision >= 64 {
+		return v
+	}
+	v &= uint64(1)<<dt.BitPrecision - 1
+	if dt.Signed && v&(uint64(1)<<(dt.BitPrecision-1)) != 0 {
+		v |= ^uint64(0) << dt.BitPrecision
+	}
+	return v
+}
+
 // directCopy performs a direct memory copy for compatible types.
 func directCopy(data []byte, n uint64, size int, dest reflect.Value) error {
 	needed := int(n) * size
@@ -747,16 +1253,52 @@ func directCopy(data []byte, n uint64, size int, dest reflect.Value) error {
 		dest.Set(reflect.MakeSlice(dest.Type(), int(n), int(n)))
 	}
 
-	// Get pointer to slice data
-	sliceHeader := (*reflect.SliceHeader)(unsafe.Pointer(dest.UnsafeAddr()))
-	destPtr := unsafe.Pointer(sliceHeader.Data)
-
-	// Copy data directly
+	// Copy bytes into the slice's backing array. The destination is a Go
+	// slice of the element type and therefore correctly aligned; the copy
+	// itself is byte-wise, so the source alignment does not matter.
+	destPtr := dest.UnsafePointer()
 	copy(unsafe.Slice((*byte)(destPtr), needed), data[:needed])
 
 	return nil
 }
 
+// swapCopy decodes 2, 4 or 8-byte elements in the opposite byte order to
+// the host straight into the slice's backing array, avoiding the reflect
+// call per element of the general conversion loop.
+func swapCopy(dt *message.Datatype, data []byte, n uint64, size int, dest reflect.Value) error {
+	needed := int(n) * size
+	if needed > len(data) {
+		return fmt.Errorf("not enough data: need %d bytes, have %d", needed, len(data))
+	}
+
+	if dest.Len() < int(n) {
+		dest.Set(reflect.MakeSlice(dest.Type(), int(n), int(n)))
+	}
+
+	order := ByteOrder(dt)
+	destPtr := dest.UnsafePointer()
+	switch size {
+	case 2:
+		words := unsafe.Slice((*uint16)(destPtr), n)
+		for i := range words {
+			words[i] = order.Uint16(data[i*2:])
+		}
+	case 4:
+		words := unsafe.Slice((*uint32)(destPtr), n)
+		for i := range words {
+			words[i] = order.Uint32(data[i*4:])
+		}
+	case 8:
+		words := unsafe.Slice((*uint64)(destPtr), n)
+		for i := range words {
+			words[i] = order.Uint64(data[i*8:])
+		}
+	default:
+		return fmt.Errorf("unsupported element size for byte swapping: %d", size)
+	}
+	return nil
+}
+
 // ReadScalar reads a single scalar value from raw data.
 func ReadScalar[T any](dt *message.Datatype, data []byte) (T, error) {
 	var zero T
@@ -21,7 +21,7 @@
 //	Compound          | map[string]interface{} or struct
 //	Array             | slice of element type
 //	Enum              | underlying integer type
-//	Bitfield          | unsigned integer type
+//	Bitfield          | unsigned integer type, masked to its precision
 //	Opaque            | []byte
 //
 // # Reading Data
@@ -21,6 +21,8 @@ func GoType(dt *message.Datatype) (reflect.Type, error) {
 	switch dt.Class {
 	case message.ClassFixedPoint:
 		return goTypeFixedPoint(dt)
+	case message.ClassTime:
+		return goTypeFixedPoint(TimeInteger(dt))
 	case message.ClassFloatPoint:
 		return goTypeFloatPoint(dt)
 	case message.ClassString:
@@ -43,7 +45,17 @@ func GoType(dt *message.Datatype) (reflect.Type, error) {
 		return reflect.TypeOf([]byte{}), nil
 	case message.ClassEnum:
 		// Enums are stored as their base type (usually integer)
+		if dt.BaseType != nil {
+			return GoType(dt.BaseType)
+		}
 		return goTypeFixedPoint(dt)
+	case message.ClassBitfield:
+		return goTypeFixedPoint(&message.Datatype{Size: dt.Size})
+	case message.ClassOpaque:
+		return reflect.TypeOf([]byte{}), nil
+	case message.ClassReference:
+		// Object references are object header addresses
+		return reflect.TypeOf(uint64(0)), nil
 	default:
 		return nil, fmt.Errorf("unsupported datatype class: %d", dt.Class)
 	}
@@ -164,6 +176,20 @@ func ByteOrder(dt *message.Datatype) binary.ByteOrder {
 	return binary.LittleEndian
 }
 
+// TimeInteger returns the integer datatype the values of the time datatype
+// dt are stored as: signed seconds since the Unix epoch, of dt's size and
+// byte order.
+func TimeInteger(dt *message.Datatype) *message.Datatype {
+	it := *dt
+	it.Class = message.ClassFixedPoint
+	it.Signed = true
+	it.BitOffset = 0
+	if it.BitPrecision == 0 || it.BitPrecision > uint16(it.Size*8) {
+		it.BitPrecision = uint16(it.Size * 8)
+	}
+	return &it
+}
+
 // ElementSize returns the size of a single element in bytes.
 func ElementSize(dt *message.Datatype) int {
 	return int(dt.Size)
@@ -1,7 +1,11 @@
 package dtype
 
 import (
+	"encoding/binary"
+	"fmt"
+	"math"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/robert-malhotra/go-hdf5/internal/message"
@@ -137,6 +141,42 @@ func TestConvertInt32(t *testing.T) {
 	}
 }
 
+func TestConvertPartialPrecision(t *testing.T) {
+	// 12 significant bits at bit offset 2 of a little-endian int16
+	dt := &message.Datatype{
+		Class:        message.ClassFixedPoint,
+		Size:         2,
+		Signed:       true,
+		ByteOrder:    message.OrderLE,
+		BitOffset:    2,
+		BitPrecision: 12,
+	}
+	values := []int16{0, 1, -1, 2047, -2048, -5}
+	data := make([]byte, 0, 2*len(values))
+	for _, v := range values {
+		// Garbage in the padding bits must be ignored
+		raw := uint16(v)&0xFFF<<2 | 0xC001
+		data = append(data, byte(raw), byte(raw>>8))
+	}
+
+	var result []int16
+	if err := Convert(dt, data, uint64(len(values)), &result); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if !reflect.DeepEqual(result, values) {
+		t.Errorf("got %v, want %v", result, values)
+	}
+
+	dt.Signed = false
+	var unsigned []uint32
+	if err := Convert(dt, data, uint64(len(values)), &unsigned); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if unsigned[2] != 0xFFF || unsigned[4] != 0x800 {
+		t.Errorf("unsigned = %v, want 4095 and 2048 at 2 and 4", unsigned)
+	}
+}
+
 func TestConvertFloat64(t *testing.T) {
 	dt := &message.Datatype{
 		Class:     message.ClassFloatPoint,
@@ -249,3 +289,327 @@ func TestIsNumeric(t *testing.T) {
 		t.Error("string should not be numeric")
 	}
 }
+
+func TestCanDirectCopyHostOrder(t *testing.T) {
+	saved := hostOrder
+	defer func() { hostOrder = saved }()
+
+	le := &message.Datatype{Class: message.ClassFixedPoint, Size: 4, Signed: true, ByteOrder: message.OrderLE}
+	be := &message.Datatype{Class: message.ClassFixedPoint, Size: 4, Signed: true, ByteOrder: message.OrderBE}
+	byteLE := &message.Datatype{Class: message.ClassFixedPoint, Size: 1, Signed: false, ByteOrder: message.OrderLE}
+	int32Type := reflect.TypeOf(int32(0))
+	uint8Type := reflect.TypeOf(uint8(0))
+
+	tests := []struct {
+		name string
+		host message.ByteOrder
+		dt   *message.Datatype
+		elem reflect.Type
+		want bool
+	}{
+		{"LE data on LE host", message.OrderLE, le, int32Type, true},
+		{"BE data on LE host", message.OrderLE, be, int32Type, false},
+		{"LE data on BE host", message.OrderBE, le, int32Type, false},
+		{"BE data on BE host", message.OrderBE, be, int32Type, true},
+		{"bytes on BE host", message.OrderBE, byteLE, uint8Type, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hostOrder = tt.host
+			if got := canDirectCopy(tt.dt, tt.elem); got != tt.want {
+				t.Errorf("canDirectCopy = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertSimulatedBigEndianHost(t *testing.T) {
+	saved := hostOrder
+	defer func() { hostOrder = saved }()
+
+	// Little-endian int32 values 1, -2 and float64 value 1.5
+	intData := []byte{0x01, 0x00, 0x00, 0x00, 0xFE, 0xFF, 0xFF, 0xFF}
+	floatData := []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF8, 0x3F}
+	intType := &message.Datatype{Class: message.ClassFixedPoint, Size: 4, Signed: true, ByteOrder: message.OrderLE}
+	floatType := &message.Datatype{Class: message.ClassFloatPoint, Size: 8, ByteOrder: message.OrderLE}
+
+	for _, host := range []message.ByteOrder{message.OrderLE, message.OrderBE} {
+		hostOrder = host
+
+		var ints []int32
+		if err := Convert(intType, intData, 2, &ints); err != nil {
+			t.Fatalf("host order %d: Convert int32 failed: %v", host, err)
+		}
+		if len(ints) != 2 || ints[0] != 1 || ints[1] != -2 {
+			t.Errorf("host order %d: got %v, want [1 -2]", host, ints)
+		}
+
+		var floats []float64
+		if err := Convert(floatType, floatData, 1, &floats); err != nil {
+			t.Fatalf("host order %d: Convert float64 failed: %v", host, err)
+		}
+		if len(floats) != 1 || floats[0] != 1.5 {
+			t.Errorf("host order %d: got %v, want [1.5]", host, floats)
+		}
+	}
+}
+
+func TestConvertSwapped(t *testing.T) {
+	saved := hostOrder
+	defer func() { hostOrder = saved }()
+
+	fixed := func(size uint32, signed bool, order message.ByteOrder) *message.Datatype {
+		return &message.Datatype{Class: message.ClassFixedPoint, Size: size, Signed: signed, ByteOrder: order}
+	}
+	float := func(size uint32, order message.ByteOrder) *message.Datatype {
+		return &message.Datatype{Class: message.ClassFloatPoint, Size: size, ByteOrder: order}
+	}
+
+	// Each case encodes its values in the given byte order and converts
+	// them on a host of the other order, then of the same order
+	tests := []struct {
+		name   string
+		dt     func(message.ByteOrder) *message.Datatype
+		encode func(binary.AppendByteOrder) []byte
+		dest   func() any
+		want   any
+	}{
+		{"int16", func(o message.ByteOrder) *message.Datatype { return fixed(2, true, o) },
+			func(o binary.AppendByteOrder) []byte {
+				var b []byte
+				for _, v := range []int16{1, -2, math.MaxInt16, math.MinInt16} {
+					b = o.AppendUint16(b, uint16(v))
+				}
+				return b
+			}, func() any { return new([]int16) }, []int16{1, -2, math.MaxInt16, math.MinInt16}},
+		{"uint16", func(o message.ByteOrder) *message.Datatype { return fixed(2, false, o) },
+			func(o binary.AppendByteOrder) []byte { return o.AppendUint16(o.AppendUint16(nil, 0x0102), 0xFFFE) },
+			func() any { return new([]uint16) }, []uint16{0x0102, 0xFFFE}},
+		{"int32", func(o message.ByteOrder) *message.Datatype { return fixed(4, true, o) },
+			func(o binary.AppendByteOrder) []byte {
+				var b []byte
+				for _, v := range []int32{1, -2, 0x01020304, math.MinInt32} {
+					b = o.AppendUint32(b, uint32(v))
+				}
+				return b
+			}, func() any { return new([]int32) }, []int32{1, -2, 0x01020304, math.MinInt32}},
+		{"int64", func(o message.ByteOrder) *message.Datatype { return fixed(8, true, o) },
+			func(o binary.AppendByteOrder) []byte {
+				var b []byte
+				for _, v := range []int64{1, -2, 0x0102030405060708, math.MinInt64} {
+					b = o.AppendUint64(b, uint64(v))
+				}
+				return b
+			}, func() any { return new([]int64) }, []int64{1, -2, 0x0102030405060708, math.MinInt64}},
+		{"float32", func(o message.ByteOrder) *message.Datatype { return float(4, o) },
+			func(o binary.AppendByteOrder) []byte {
+				var b []byte
+				for _, v := range []float32{1.5, -0.25, math.MaxFloat32} {
+					b = o.AppendUint32(b, math.Float32bits(v))
+				}
+				return b
+			}, func() any { return new([]float32) }, []float32{1.5, -0.25, math.MaxFloat32}},
+		{"float64", func(o message.ByteOrder) *message.Datatype { return float(8, o) },
+			func(o binary.AppendByteOrder) []byte {
+				var b []byte
+				for _, v := range []float64{1.5, -0.25, math.Pi, math.SmallestNonzeroFloat64} {
+					b = o.AppendUint64(b, math.Float64bits(v))
+				}
+				return b
+			}, func() any { return new([]float64) }, []float64{1.5, -0.25, math.Pi, math.SmallestNonzeroFloat64}},
+	}
+
+	orders := []struct {
+		file message.ByteOrder
+		enc  binary.AppendByteOrder
+	}{{message.OrderBE, binary.BigEndian}, {message.OrderLE, binary.LittleEndian}}
+
+	for _, tt := range tests {
+		for _, o := range orders {
+			dt, data := tt.dt(o.file), tt.encode(o.enc)
+			n := uint64(reflect.ValueOf(tt.want).Len())
+			elem := reflect.TypeOf(tt.want).Elem()
+
+			// The path taken depends on the host order, which can be
+			// simulated; the values can only be checked on the real one
+			for _, host := range []message.ByteOrder{message.OrderLE, message.OrderBE} {
+				hostOrder = host
+				if swap := canSwapCopy(dt, elem); swap != (o.file != host) {
+					t.Errorf("%s order %d on host %d: canSwapCopy = %v", tt.name, o.file, host, swap)
+				}
+			}
+			hostOrder = saved
+
+			dest := tt.dest()
+			if err := Convert(dt, data, n, dest); err != nil {
+				t.Fatalf("%s order %d: Convert failed: %v", tt.name, o.file, err)
+			}
+			if got := reflect.ValueOf(dest).Elem().Interface(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s order %d: got %v, want %v", tt.name, o.file, got, tt.want)
+			}
+			if err := Convert(dt, data[:len(data)-1], n, tt.dest()); err == nil {
+				t.Errorf("%s order %d: expected error for short data", tt.name, o.file)
+			}
+		}
+	}
+
+	// Scalars and partial-precision or VAX-ordered types take the general path
+	if v, err := ReadScalar[float64](float(8, message.OrderBE), binary.BigEndian.AppendUint64(nil, math.Float64bits(2.5))); err != nil || v != 2.5 {
+		t.Errorf("ReadScalar = %v, %v, want 2.5", v, err)
+	}
+	partial := fixed(4, true, message.OrderBE)
+	partial.BitPrecision = 12
+	if canSwapCopy(partial, reflect.TypeOf(int32(0))) {
+		t.Error("canSwapCopy accepted a partial-precision type")
+	}
+	hostOrder = message.OrderLE
+	if canSwapCopy(fixed(4, true, message.OrderVAX), reflect.TypeOf(int32(0))) {
+		t.Error("canSwapCopy accepted a VAX-ordered type")
+	}
+	if canSwapCopy(fixed(4, true, message.OrderBE), reflect.TypeOf(int64(0))) {
+		t.Error("canSwapCopy accepted a size mismatch")
+	}
+}
+
+// Swapping bytes in place is much faster than converting each element
+// through reflect.
+func BenchmarkConvertSwapped(b *testing.B) {
+	const n = 100000
+	dt := &message.Datatype{Class: message.ClassFloatPoint, Size: 8, ByteOrder: message.OrderBE}
+	data := make([]byte, n*8)
+	for i := 0; i < n; i++ {
+		binary.BigEndian.PutUint64(data[i*8:], math.Float64bits(float64(i)))
+	}
+	if hostOrder == message.OrderBE {
+		dt.ByteOrder = message.OrderLE // Keep the data swapped; the values do not matter
+	}
+
+	b.Run("swap", func(b *testing.B) {
+		for b.Loop() {
+			var dest []float64
+			if err := Convert(dt, data, n, &dest); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("element", func(b *testing.B) {
+		// float64 into float32 converts element by element
+		for b.Loop() {
+			var dest []float32
+			if err := Convert(dt, data, n, &dest); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// wideCompound builds a compound type of n little-endian float64 members
+// named f00, f01, ... and rows elements of data where member j of row i
+// holds i*1000+j.
+func wideCompound(n, rows int) (*message.Datatype, []byte) {
+	f64 := &message.Datatype{Class: message.ClassFloatPoint, Size: 8, ByteOrder: message.OrderLE}
+	members := make([]message.CompoundMember, n)
+	for j := range members {
+		members[j] = message.CompoundMember{Name: fmt.Sprintf("f%02d", j), ByteOffset: uint32(j * 8), Type: f64}
+	}
+	dt := &message.Datatype{Class: message.ClassCompound, Size: uint32(n * 8), Members: members}
+
+	data := make([]byte, rows*n*8)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < n; j++ {
+			bits := math.Float64bits(float64(i*1000 + j))
+			binary.LittleEndian.PutUint64(data[(i*n+j)*8:], bits)
+		}
+	}
+	return dt, data
+}
+
+func TestConvertCompoundColumns(t *testing.T) {
+	dt, data := wideCompound(5, 3)
+
+	cols, err := ConvertCompoundColumns(dt, data, 3, []string{"f04", "f01"}, nil)
+	if err != nil {
+		t.Fatalf("ConvertCompoundColumns failed: %v", err)
+	}
+	if len(cols) != 2 {
+		t.Fatalf("got %d columns, want 2", len(cols))
+	}
+	for i := 0; i < 3; i++ {
+		if v := cols["f01"][i]; v != float64(i*1000+1) {
+			t.Errorf("f01[%d] = %v, want %d", i, v, i*1000+1)
+		}
+		if v := cols["f04"][i]; v != float64(i*1000+4) {
+			t.Errorf("f04[%d] = %v, want %d", i, v, i*1000+4)
+		}
+	}
+
+	if _, err := ConvertCompoundColumns(dt, data[:len(data)-1], 3, []string{"f00"}, nil); err == nil {
+		t.Error("expected error for short data")
+	}
+}
+
+func TestConvertCompoundDestinations(t *testing.T) {
+	dt, data := wideCompound(2, 3)
+
+	var rows []struct{ F00, F01 float64 }
+	if err := Convert(dt, data, 3, &rows); err != nil || len(rows) != 3 || rows[2].F01 != 2001 {
+		t.Errorf("Convert into structs = %+v, %v", rows, err)
+	}
+	var raw [][]byte
+	if err := Convert(dt, data, 3, &raw); err != nil || len(raw) != 3 || !reflect.DeepEqual(raw[1], data[16:32]) {
+		t.Errorf("Convert into [][]byte = %v, %v", raw, err)
+	}
+	var one map[string]interface{}
+	if err := Convert(dt, data, 1, &one); err != nil || one["f01"] != 1.0 {
+		t.Errorf("Convert one element into a map = %v, %v", one, err)
+	}
+
+	// Three elements do not fit one struct, map or byte slice, and no
+	// element fits a number or a string
+	var single struct{ F00, F01 float64 }
+	var bytes []byte
+	var floats []float64
+	var strs []string
+	for _, dest := range []interface{}{&single, &one, &bytes, &floats, &strs} {
+		if err := Convert(dt, data, 3, dest); err == nil {
+			t.Errorf("Convert into %T succeeded", dest)
+		}
+	}
+}
+
+func TestProjectMembersUnknown(t *testing.T) {
+	dt, _ := wideCompound(3, 0)
+
+	_, err := ProjectMembers(dt, []string{"f00", "nope", "f02", "missing"})
+	if err == nil {
+		t.Fatal("expected error for unknown members")
+	}
+	if !strings.Contains(err.Error(), "nope, missing") {
+		t.Errorf("error %q does not list every unknown member", err)
+	}
+}
+
+// Projecting 3 of 40 members decodes less than a tenth of the bytes a full
+// conversion touches and skips building a map per row.
+func BenchmarkCompoundProjection(b *testing.B) {
+	const rows = 1000000
+	dt, data := wideCompound(40, rows)
+
+	b.Run("Columns", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := ConvertCompoundColumns(dt, data, rows, []string{"f00", "f17", "f39"}, nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Full", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var result []map[string]interface{}
+			if err := Convert(dt, data, rows, &result); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
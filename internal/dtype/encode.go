@@ -30,6 +30,8 @@ func Encode(dt *message.Datatype, src interface{}) ([]byte, error) {
 		return encodeFloatPoint(dt, srcVal)
 	case message.ClassString:
 		return encodeString(dt, srcVal)
+	case message.ClassCompound:
+		return encodeCompound(dt, srcVal)
 	default:
 		return nil, fmt.Errorf("unsupported datatype class for encoding: %d", dt.Class)
 	}
@@ -229,6 +231,8 @@ func GoTypeToDatatype(t reflect.Type) (*message.Datatype, error) {
 	case reflect.String:
 		// Default to variable-length string
 		return message.NewVarLenStringDatatype(message.CharsetUTF8), nil
+	case reflect.Struct:
+		return structDatatype(t, 0)
 	default:
 		return nil, fmt.Errorf("unsupported Go type: %v", t)
 	}
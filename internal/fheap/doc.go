@@ -0,0 +1,26 @@
+// Package fheap implements reading of HDF5 fractal heaps.
+//
+// A fractal heap (signature "FRHP") stores variable-size objects addressed
+// by short heap IDs. Objects using dense storage keep their attributes and
+// links in a fractal heap, indexed by v2 B-trees whose records hold the
+// heap IDs.
+//
+// Fractal heap structure:
+//   - Header with the heap parameters and the address of the root block
+//   - Direct blocks (signature "FHDB") holding the objects
+//   - Indirect blocks (signature "FHIB") holding the addresses of direct
+//     blocks and of further indirect blocks, arranged as a doubling table
+//
+// Objects are found by their offset in the heap's address space, which the
+// doubling tables map to a direct block. Very small objects are stored in
+// their heap ID, and very large ones outside the blocks altogether.
+//
+// Usage:
+//
+//	h, err := fheap.ReadFractalHeap(reader, heapAddress)
+//	data, err := h.Object(heapID)
+//
+// # Key Types
+//
+//   - [FractalHeap]: A fractal heap header, used to look up objects
+package fheap
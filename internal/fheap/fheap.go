@@ -0,0 +1,449 @@
+package fheap
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/btree"
+)
+
+// Heap ID types, stored in bits 4-5 of the first byte of a heap ID
+const (
+	fractalIDManaged = 0
+	fractalIDHuge    = 1
+	fractalIDTiny    = 2
+)
+
+// B-tree v2 record type indexing huge objects stored without filters
+const btreeV2TypeHugeIndirect uint8 = 1
+
+// FractalHeap represents an HDF5 fractal heap (signature "FRHP").
+// Fractal heaps hold the attributes and links of objects using dense
+// storage, addressed by heap IDs kept in v2 B-tree records.
+//
+// Small ("managed") objects live in direct blocks, which are arranged in
+// a doubling table: each row has TableWidth blocks, the first two rows of
+// the starting block size and each later row twice the size of the one
+// before. Rows of blocks too large to be direct are indirect blocks
+// holding a smaller doubling table of their own. Objects too small to be
+// worth storing are kept in the heap ID itself ("tiny"), and those too
+// large for a direct block are stored separately ("huge").
+type FractalHeap struct {
+	r    *binary.Reader
+	addr uint64
+
+	IDLength          int    // Bytes in a heap ID
+	MaxManagedSize    uint32 // Largest object stored in a direct block
+	HugeBTreeAddr     uint64 // v2 B-tree indexing huge objects
+	TableWidth        int
+	StartBlockSize    uint64
+	MaxDirectSize     uint64 // Largest direct block
+	MaxHeapBits       int    // Bits in a heap offset
+	RootAddr          uint64 // Root direct or indirect block
+	RootRows          int    // Rows in the root indirect block, 0 if the root is a direct block
+	filterInfoLength  int
+	checksummedBlocks bool
+
+	offsetSize    int // Bytes of a heap offset in managed IDs and block headers
+	lengthSize    int // Bytes of an object length in managed IDs
+	maxDirectRows int // Rows of direct blocks in a full doubling table
+	hugeIDsDirect bool
+	hugeIDSize    int
+}
+
+// ReadFractalHeap reads the header of a fractal heap.
+func ReadFractalHeap(r *binary.Reader, address uint64) (*FractalHeap, error) {
+	if address == 0 || r.IsUndefinedOffset(address) {
+		return nil, fmt.Errorf("invalid fractal heap address")
+	}
+	o, l := r.OffsetSize(), r.LengthSize()
+	fixedSize := 4 + 1 + 2 + 2 + 1 + 4 + l + o + l + o + 8*l + 2 + l + l + 2 + 2 + o + 2
+	hr := r.At(int64(address))
+	data, err := hr.ReadBytes(fixedSize)
+	if err != nil {
+		return nil, fmt.Errorf("reading fractal heap header: %w", err)
+	}
+	if string(data[:4]) != "FRHP" {
+		return nil, fmt.Errorf("invalid fractal heap signature: %q", string(data[:4]))
+	}
+	if data[4] != 0 {
+		return nil, fmt.Errorf("unsupported fractal heap version: %d", data[4])
+	}
+
+	h := &FractalHeap{r: r, addr: address}
+	br := r.At(int64(address) + 5)
+	idLen, _ := br.ReadUint16()
+	filterLen, _ := br.ReadUint16()
+	flags, _ := br.ReadUint8()
+	h.MaxManagedSize, _ = br.ReadUint32()
+	br.Skip(int64(l)) // Next huge object ID
+	h.HugeBTreeAddr, _ = br.ReadOffset()
+	br.Skip(int64(l + o)) // Free space and its manager
+	br.Skip(int64(8 * l)) // Managed, allocated, iterator, and object counts and sizes
+	tableWidth, _ := br.ReadUint16()
+	h.StartBlockSize, _ = br.ReadLength()
+	h.MaxDirectSize, _ = br.ReadLength()
+	maxHeapBits, _ := br.ReadUint16()
+	br.Skip(2) // Starting rows of the root indirect block
+	h.RootAddr, _ = br.ReadOffset()
+	rootRows, err := br.ReadUint16()
+	if err != nil {
+		return nil, fmt.Errorf("reading fractal heap header: %w", err)
+	}
+
+	h.IDLength = int(idLen)
+	h.filterInfoLength = int(filterLen)
+	h.checksummedBlocks = flags&0x02 != 0
+	h.TableWidth = int(tableWidth)
+	h.MaxHeapBits = int(maxHeapBits)
+	h.RootRows = int(rootRows)
+
+	// Check the header checksum, which follows the filter information of
+	// the root direct block if the heap has filters
+	size := fixedSize
+	if h.filterInfoLength > 0 {
+		size += l + 4 + h.filterInfoLength
+	}
+	block, err := r.At(int64(address)).ReadBytes(size + 4)
+	if err != nil {
+		return nil, fmt.Errorf("reading fractal heap header: %w", err)
+	}
+	if err := binary.CheckLookup3(block, "FRHP", address); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case h.TableWidth == 0 || h.TableWidth&(h.TableWidth-1) != 0:
+		return nil, fmt.Errorf("fractal heap table width %d is not a power of two", h.TableWidth)
+	case h.StartBlockSize == 0 || h.StartBlockSize&(h.StartBlockSize-1) != 0:
+		return nil, fmt.Errorf("fractal heap starting block size %d is not a power of two", h.StartBlockSize)
+	case h.MaxDirectSize < h.StartBlockSize || h.MaxDirectSize&(h.MaxDirectSize-1) != 0:
+		return nil, fmt.Errorf("fractal heap maximum direct block size %d is invalid", h.MaxDirectSize)
+	case h.MaxHeapBits == 0 || h.MaxHeapBits > 64:
+		return nil, fmt.Errorf("fractal heap has invalid maximum size of %d bits", h.MaxHeapBits)
+	}
+
+	h.offsetSize = (h.MaxHeapBits + 7) / 8
+	h.lengthSize = min((log2(h.MaxDirectSize)+7)/8, encodedSize(uint64(h.MaxManagedSize)))
+	h.maxDirectRows = log2(h.MaxDirectSize) - log2(h.StartBlockSize) + 2
+	if h.filterInfoLength > 0 {
+		h.hugeIDsDirect = h.IDLength-1 >= o+l+4+l
+	} else {
+		h.hugeIDsDirect = h.IDLength-1 >= o+l
+	}
+	h.hugeIDSize = min(h.IDLength-1, 8)
+	return h, nil
+}
+
+// Object returns the object a heap ID refers to.
+func (h *FractalHeap) Object(id []byte) ([]byte, error) {
+	if len(id) == 0 {
+		return nil, fmt.Errorf("empty fractal heap ID")
+	}
+	if version := id[0] >> 6; version != 0 {
+		return nil, fmt.Errorf("unsupported fractal heap ID version: %d", version)
+	}
+
+	switch (id[0] >> 4) & 0x03 {
+	case fractalIDManaged:
+		if len(id) < 1+h.offsetSize+h.lengthSize {
+			return nil, fmt.Errorf("fractal heap ID too short: %d bytes", len(id))
+		}
+		offset := binary.DecodeUintLE(id[1 : 1+h.offsetSize])
+		length := binary.DecodeUintLE(id[1+h.offsetSize : 1+h.offsetSize+h.lengthSize])
+		return h.managedObject(offset, length)
+
+	case fractalIDHuge:
+		return h.hugeObject(id[1:])
+
+	case fractalIDTiny:
+		// The length is stored minus one, in 4 bits or, for heap IDs too
+		// long for that to cover, 12 bits
+		length, data := int(id[0]&0x0F)+1, id[1:]
+		if h.IDLength-1 > 16 {
+			if len(id) < 2 {
+				return nil, fmt.Errorf("fractal heap ID too short: %d bytes", len(id))
+			}
+			length, data = (int(id[0]&0x0F)<<8|int(id[1]))+1, id[2:]
+		}
+		if length > len(data) {
+			return nil, fmt.Errorf("tiny fractal heap object of %d bytes does not fit in its ID", length)
+		}
+		return data[:length], nil
+
+	default:
+		return nil, fmt.Errorf("invalid fractal heap ID type: %d", (id[0]>>4)&0x03)
+	}
+}
+
+// managedObject returns the object of length bytes at a heap offset,
+// finding the direct block that holds it through the doubling tables.
+func (h *FractalHeap) managedObject(offset, length uint64) ([]byte, error) {
+	if h.filterInfoLength > 0 {
+		return nil, fmt.Errorf("fractal heaps with filtered direct blocks are not supported")
+	}
+	if length == 0 || length > h.MaxDirectSize {
+		return nil, fmt.Errorf("fractal heap object has invalid length %d", length)
+	}
+	if h.RootAddr == 0 || h.r.IsUndefinedOffset(h.RootAddr) {
+		return nil, fmt.Errorf("fractal heap object at offset %d: heap is empty", offset)
+	}
+
+	blockAddr, blockOffset, blockSize := h.RootAddr, uint64(0), h.StartBlockSize
+	rows := h.RootRows
+	for depth := 0; rows > 0; depth++ {
+		if depth > 64 {
+			return nil, fmt.Errorf("fractal heap indirect blocks nest too deeply")
+		}
+		var err error
+		blockAddr, blockOffset, blockSize, rows, err = h.childBlock(blockAddr, blockOffset, rows, offset)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if offset < blockOffset || offset+length > blockOffset+blockSize {
+		return nil, fmt.Errorf("fractal heap object at offset %d (%d bytes) is outside its direct block", offset, length)
+	}
+	if err := h.checkBlockHeader(blockAddr, "FHDB", blockOffset); err != nil {
+		return nil, err
+	}
+	if err := h.checkDirectBlockChecksum(blockAddr, blockSize); err != nil {
+		return nil, err
+	}
+	return h.r.AtSize(int64(blockAddr+offset-blockOffset), int(length)).ReadBytes(int(length))
+}
+
+// childBlock finds the child of the indirect block at addr, which starts
+// at heap offset base and has rows rows, that holds the heap offset. It
+// returns the child's address, heap offset and size, and its rows if it
+// is itself an indirect block.
+func (h *FractalHeap) childBlock(addr, base uint64, rows int, offset uint64) (uint64, uint64, uint64, int, error) {
+	if err := h.checkBlockHeader(addr, "FHIB", base); err != nil {
+		return 0, 0, 0, 0, err
+	}
+	prefix := 4 + 1 + h.r.OffsetSize() + h.offsetSize
+	if err := h.r.CheckLookup3At("FHIB", addr, prefix+rows*h.TableWidth*h.r.OffsetSize()+4); err != nil {
+		return 0, 0, 0, 0, err
+	}
+
+	rowOffset := base
+	for row := 0; row < rows; row++ {
+		size := h.rowBlockSize(row)
+		rowSpan := size * uint64(h.TableWidth)
+		if offset >= rowOffset+rowSpan {
+			rowOffset += rowSpan
+			continue
+		}
+
+		col := (offset - rowOffset) / size
+		entry := uint64(row)*uint64(h.TableWidth) + col
+		childAddr, err := h.r.At(int64(addr) + int64(prefix) + int64(entry)*int64(h.r.OffsetSize())).ReadOffset()
+		if err != nil {
+			return 0, 0, 0, 0, fmt.Errorf("reading fractal heap indirect block at %d: %w", addr, err)
+		}
+		if childAddr == 0 || h.r.IsUndefinedOffset(childAddr) {
+			return 0, 0, 0, 0, fmt.Errorf("fractal heap offset %d is in a block that was never allocated", offset)
+		}
+
+		childRows := 0
+		if row >= h.maxDirectRows {
+			childRows = log2(size) - log2(h.StartBlockSize*uint64(h.TableWidth)) + 1
+		}
+		return childAddr, rowOffset + col*size, size, childRows, nil
+	}
+	return 0, 0, 0, 0, fmt.Errorf("fractal heap offset %d is past the end of the heap", offset)
+}
+
+// Space calls fn with the address and size of the heap's header, of each
+// direct and indirect block allocated for managed objects, and of the
+// nodes of the B-tree indexing huge objects. Huge objects themselves and
+// the heap's free-space manager are not included.
+func (h *FractalHeap) Space(fn func(addr, size uint64)) error {
+	o, l := h.r.OffsetSize(), h.r.LengthSize()
+	headerSize := 4 + 1 + 2 + 2 + 1 + 4 + l + o + l + o + 8*l + 2 + l + l + 2 + 2 + o + 2 + 4
+	if h.filterInfoLength > 0 {
+		headerSize += l + 4 + h.filterInfoLength
+	}
+	fn(h.addr, uint64(headerSize))
+
+	if h.HugeBTreeAddr != 0 && !h.r.IsUndefinedOffset(h.HugeBTreeAddr) {
+		if err := btree.SpaceV2(h.r, h.HugeBTreeAddr, fn); err != nil {
+			return fmt.Errorf("huge object B-tree: %w", err)
+		}
+	}
+	if h.RootAddr == 0 || h.r.IsUndefinedOffset(h.RootAddr) {
+		return nil
+	}
+	if h.RootRows == 0 {
+		fn(h.RootAddr, h.StartBlockSize)
+		return nil
+	}
+	return h.indirectSpace(h.RootAddr, h.RootRows, 0, fn)
+}
+
+// indirectSpace calls fn with the address and size of the indirect block
+// at addr, which has rows rows, and of each block allocated below it.
+func (h *FractalHeap) indirectSpace(addr uint64, rows, depth int, fn func(addr, size uint64)) error {
+	if depth > 64 {
+		return fmt.Errorf("fractal heap indirect blocks nest too deeply")
+	}
+	o := h.r.OffsetSize()
+	prefix := 4 + 1 + o + h.offsetSize
+	entries := rows * h.TableWidth
+	fn(addr, uint64(prefix+entries*o+4))
+
+	br := h.r.At(int64(addr) + int64(prefix))
+	for row := 0; row < rows; row++ {
+		size := h.rowBlockSize(row)
+		for col := 0; col < h.TableWidth; col++ {
+			child, err := br.ReadOffset()
+			if err != nil {
+				return fmt.Errorf("reading fractal heap indirect block at %d: %w", addr, err)
+			}
+			if child == 0 || h.r.IsUndefinedOffset(child) {
+				continue
+			}
+			if row < h.maxDirectRows {
+				fn(child, size)
+				continue
+			}
+			childRows := log2(size) - log2(h.StartBlockSize*uint64(h.TableWidth)) + 1
+			if err := h.indirectSpace(child, childRows, depth+1, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rowBlockSize returns the size of the blocks in a row of a doubling table.
+func (h *FractalHeap) rowBlockSize(row int) uint64 {
+	if row == 0 {
+		return h.StartBlockSize
+	}
+	return h.StartBlockSize << (row - 1)
+}
+
+// checkBlockHeader checks the signature, version, heap address and heap
+// offset at the start of a direct or indirect block.
+func (h *FractalHeap) checkBlockHeader(addr uint64, sig string, blockOffset uint64) error {
+	br := h.r.At(int64(addr))
+	data, err := br.ReadBytes(5)
+	if err != nil {
+		return fmt.Errorf("reading fractal heap block at %d: %w", addr, err)
+	}
+	if string(data[:4]) != sig {
+		return fmt.Errorf("invalid fractal heap block signature at %d: %q (expected %s)", addr, data[:4], sig)
+	}
+	if data[4] != 0 {
+		return fmt.Errorf("unsupported %s version: %d", sig, data[4])
+	}
+	heapAddr, err := br.ReadOffset()
+	if err != nil {
+		return err
+	}
+	if heapAddr != h.addr {
+		return fmt.Errorf("fractal heap block at %d belongs to heap %d, not %d", addr, heapAddr, h.addr)
+	}
+	raw, err := br.ReadBytes(h.offsetSize)
+	if err != nil {
+		return err
+	}
+	if got := binary.DecodeUintLE(raw); got != blockOffset {
+		return fmt.Errorf("fractal heap block at %d has offset %d, expected %d", addr, got, blockOffset)
+	}
+	return nil
+}
+
+// checkDirectBlockChecksum checks the checksum of the direct block of size
+// bytes at addr, if the heap checksums its direct blocks and the reader
+// verifies checksums. The checksum follows the block's header and covers
+// the whole block with the checksum itself zeroed.
+func (h *FractalHeap) checkDirectBlockChecksum(addr, size uint64) error {
+	if !h.checksummedBlocks || !h.r.VerifyChecksums() {
+		return nil
+	}
+	block, err := h.r.At(int64(addr)).ReadBytes(int(size))
+	if err != nil {
+		return fmt.Errorf("reading FHDB at %d: %w", addr, err)
+	}
+	at := 4 + 1 + h.r.OffsetSize() + h.offsetSize
+	if at+4 > len(block) {
+		return fmt.Errorf("FHDB at %d: %d bytes is too short for a checksum", addr, len(block))
+	}
+	stored := uint32(block[at]) | uint32(block[at+1])<<8 | uint32(block[at+2])<<16 | uint32(block[at+3])<<24
+	clear(block[at : at+4])
+	if computed := binary.ChecksumLookup3(block, 0); computed != stored {
+		return fmt.Errorf("FHDB at %d: %w: stored %#08x, computed %#08x", addr, binary.ErrChecksumMismatch, stored, computed)
+	}
+	return nil
+}
+
+// hugeObject returns a huge object, stored outside the heap's blocks.
+// Its ID holds either the object's address and length, or a key into the
+// heap's huge object B-tree.
+func (h *FractalHeap) hugeObject(id []byte) ([]byte, error) {
+	if h.filterInfoLength > 0 {
+		return nil, fmt.Errorf("fractal heaps with filtered huge objects are not supported")
+	}
+	o, l := h.r.OffsetSize(), h.r.LengthSize()
+
+	var addr, length uint64
+	if h.hugeIDsDirect {
+		if len(id) < o+l {
+			return nil, fmt.Errorf("huge fractal heap ID too short: %d bytes", len(id))
+		}
+		addr, length = binary.DecodeUintLE(id[:o]), binary.DecodeUintLE(id[o:o+l])
+	} else {
+		if len(id) < h.hugeIDSize {
+			return nil, fmt.Errorf("huge fractal heap ID too short: %d bytes", len(id))
+		}
+		key := binary.DecodeUintLE(id[:h.hugeIDSize])
+		var err error
+		if addr, length, err = h.lookupHuge(key); err != nil {
+			return nil, err
+		}
+	}
+	if length > 1<<31 {
+		return nil, fmt.Errorf("huge fractal heap object of %d bytes is too large", length)
+	}
+	return h.r.AtSize(int64(addr), int(length)).ReadBytes(int(length))
+}
+
+// lookupHuge finds the address and length of a huge object in the heap's
+// huge object B-tree.
+func (h *FractalHeap) lookupHuge(key uint64) (uint64, uint64, error) {
+	typ, records, err := btree.ReadRecordsV2(h.r, h.HugeBTreeAddr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("reading huge object index: %w", err)
+	}
+	if typ != btreeV2TypeHugeIndirect {
+		return 0, 0, fmt.Errorf("huge object index has B-tree type %d, expected %d", typ, btreeV2TypeHugeIndirect)
+	}
+	o, l := h.r.OffsetSize(), h.r.LengthSize()
+	for _, rec := range records {
+		if len(rec) < o+2*l {
+			return 0, 0, fmt.Errorf("huge object index record too short: %d bytes", len(rec))
+		}
+		if binary.DecodeUintLE(rec[o+l:o+2*l]) == key {
+			return binary.DecodeUintLE(rec[:o]), binary.DecodeUintLE(rec[o : o+l]), nil
+		}
+	}
+	return 0, 0, fmt.Errorf("huge fractal heap object %d not found", key)
+}
+
+// log2 returns the base 2 logarithm of a power of two.
+func log2(n uint64) int {
+	return bits.Len64(n) - 1
+}
+
+// encodedSize returns the number of bytes the HDF5 library uses to store
+// values up to n.
+func encodedSize(n uint64) int {
+	if n == 0 {
+		return 1
+	}
+	return log2(n)/8 + 1
+}
@@ -0,0 +1,44 @@
+package fheap
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTinyObjects(t *testing.T) {
+	tests := []struct {
+		name  string
+		idLen int
+		id    []byte
+		want  []byte
+	}{
+		{"short length", 8, []byte{0x22, 'a', 'b', 'c', 0, 0, 0, 0}, []byte("abc")},
+		{"extended length", 20, append([]byte{0x20, 0x11}, bytes.Repeat([]byte{'x'}, 18)...), bytes.Repeat([]byte{'x'}, 18)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &FractalHeap{IDLength: tt.idLen}
+			got, err := h.Object(tt.id)
+			if err != nil {
+				t.Fatalf("Object failed: %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("Object = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidIDs(t *testing.T) {
+	h := &FractalHeap{IDLength: 8}
+	for name, id := range map[string][]byte{
+		"empty":          nil,
+		"version 1":      {0x40, 0, 0, 0, 0, 0, 0, 0},
+		"reserved type":  {0x30, 0, 0, 0, 0, 0, 0, 0},
+		"tiny too large": {0x2F, 'a', 'b'},
+	} {
+		if _, err := h.Object(id); err == nil {
+			t.Errorf("%s: Object succeeded", name)
+		}
+	}
+}
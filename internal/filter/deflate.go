@@ -2,9 +2,15 @@ package filter
 
 import (
 	"bytes"
+	"compress/flate"
+	"compress/gzip"
 	"compress/zlib"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"hash/adler32"
 	"io"
+	"sync"
 
 	"github.com/robert-malhotra/go-hdf5/internal/message"
 )
@@ -28,17 +34,179 @@ func (f *Deflate) ID() uint16 {
 	return message.FilterDeflate
 }
 
+// Decode decompresses input; see DecodeInto.
 func (f *Deflate) Decode(input []byte) ([]byte, error) {
-	r, err := zlib.NewReader(bytes.NewReader(input))
-	if err != nil {
-		return nil, fmt.Errorf("zlib reader: %w", err)
+	return f.DecodeInto(nil, input, 0)
+}
+
+// inflater is a DEFLATE reader with the bytes.Reader it reads from, kept
+// in inflaters for reuse: a DEFLATE reader holds tens of kilobytes of
+// state.
+type inflater struct {
+	src bytes.Reader
+	fr  io.ReadCloser
+}
+
+var inflaters sync.Pool
+
+// DecodeInto decompresses input into dst. With a sizeHint of the
+// decompressed size, a dst of that capacity is filled without allocating.
+//
+// HDF5 writes zlib streams, but, as the HDF5 library does, gzip streams
+// and raw DEFLATE streams without a zlib header are read too, told apart
+// by their leading bytes. A stream with a zlib header that fails to
+// decompress is retried as raw DEFLATE, which can start with the same
+// bytes.
+func (f *Deflate) DecodeInto(dst, input []byte, sizeHint int) ([]byte, error) {
+	if cap(dst) < sizeHint {
+		dst = make([]byte, 0, sizeHint)
 	}
-	defer r.Close()
+	switch {
+	case len(input) >= 2 && input[0] == 0x1f && input[1] == 0x8b:
+		return decodeGzip(dst, input, sizeHint)
+	case isZlibHeader(input):
+		output, err := decodeZlib(dst, input, sizeHint)
+		if err != nil {
+			if raw, rawErr := inflate(dst, input, sizeHint); rawErr == nil {
+				return raw, nil
+			}
+		}
+		return output, err
+	default:
+		output, err := inflate(dst, input, sizeHint)
+		if err != nil {
+			return nil, fmt.Errorf("raw deflate: %w", err)
+		}
+		return output, nil
+	}
+}
 
-	output, err := io.ReadAll(r)
+// isZlibHeader reports whether b starts with a zlib header for DEFLATE
+// data: compression method 8, a window of at most 32KiB and a valid check.
+// Headers naming a preset dictionary are included, to be rejected.
+func isZlibHeader(b []byte) bool {
+	return len(b) >= 2 && b[0]&0x0f == 8 && b[0]>>4 <= 7 &&
+		(uint16(b[0])<<8|uint16(b[1]))%31 == 0
+}
+
+// decodeZlib decompresses the zlib stream input into dst. The framing is
+// read here rather than by compress/zlib, whose readers allocate a
+// checksum state each time they are reset.
+func decodeZlib(dst, input []byte, sizeHint int) ([]byte, error) {
+	if input[1]&0x20 != 0 {
+		return nil, fmt.Errorf("zlib reader: %w", zlib.ErrDictionary)
+	}
+	output, n, err := inflateN(dst, input[2:], sizeHint)
 	if err != nil {
 		return nil, fmt.Errorf("zlib decompress: %w", err)
 	}
 
+	// The Adler-32 checksum of the output follows the DEFLATE data
+	trailer := input[2+n:]
+	if len(trailer) < 4 {
+		return nil, fmt.Errorf("zlib decompress: %w", io.ErrUnexpectedEOF)
+	}
+	if binary.BigEndian.Uint32(trailer) != adler32.Checksum(output) {
+		return nil, fmt.Errorf("zlib decompress: %w", zlib.ErrChecksum)
+	}
 	return output, nil
 }
+
+// decodeGzip decompresses the gzip stream input into dst.
+func decodeGzip(dst, input []byte, sizeHint int) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(input))
+	if err != nil {
+		return nil, fmt.Errorf("gzip reader: %w", err)
+	}
+	output, err := readAllInto(dst[:0], zr)
+	if err != nil {
+		return nil, fmt.Errorf("gzip decompress: %w", truncated(err, len(output), sizeHint))
+	}
+	return output, nil
+}
+
+// inflate decompresses the raw DEFLATE stream input into dst.
+func inflate(dst, input []byte, sizeHint int) ([]byte, error) {
+	output, _, err := inflateN(dst, input, sizeHint)
+	return output, err
+}
+
+// inflateN is inflate also returning the length of the DEFLATE stream,
+// which may be followed by other data.
+func inflateN(dst, input []byte, sizeHint int) ([]byte, int, error) {
+	inf, _ := inflaters.Get().(*inflater)
+	if inf == nil {
+		inf = &inflater{}
+	}
+	defer func() {
+		inf.src.Reset(nil) // Do not keep input alive in the pool
+		inflaters.Put(inf)
+	}()
+	inf.src.Reset(input)
+	if inf.fr == nil {
+		inf.fr = flate.NewReader(&inf.src)
+	} else if err := inf.fr.(flate.Resetter).Reset(&inf.src, nil); err != nil {
+		return nil, 0, err
+	}
+
+	output, err := readAllInto(dst[:0], inf.fr)
+	if err != nil {
+		return nil, 0, truncated(err, len(output), sizeHint)
+	}
+	return output, len(input) - inf.src.Len(), nil
+}
+
+// truncated describes a stream that ended early after n bytes of
+// decompressed data, of expected if it is known.
+func truncated(err error, n, expected int) error {
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+	if expected > 0 {
+		return fmt.Errorf("truncated chunk: %w after %d of %d decompressed bytes", err, n, expected)
+	}
+	return fmt.Errorf("truncated chunk: %w after %d decompressed bytes", err, n)
+}
+
+// readAllInto is io.ReadAll appending to dst. Filling dst exactly does not
+// grow it to find that r has ended.
+func readAllInto(dst []byte, r io.Reader) ([]byte, error) {
+	for {
+		if len(dst) == cap(dst) {
+			var probe [1]byte
+			n, err := io.ReadFull(r, probe[:])
+			if err == io.EOF {
+				return dst, nil
+			}
+			dst = append(dst, probe[:n]...)
+			if err != nil {
+				return dst, err
+			}
+			continue
+		}
+		n, err := r.Read(dst[len(dst):cap(dst)])
+		dst = dst[:len(dst)+n]
+		if err == io.EOF {
+			return dst, nil
+		}
+		if err != nil {
+			return dst, err
+		}
+	}
+}
+
+func (f *Deflate) Encode(input []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := zlib.NewWriterLevel(&buf, f.level)
+	if err != nil {
+		return nil, fmt.Errorf("zlib writer: %w", err)
+	}
+	if _, err := w.Write(input); err != nil {
+		return nil, fmt.Errorf("zlib compress: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("zlib compress: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
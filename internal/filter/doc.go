@@ -6,7 +6,7 @@
 //
 // # Supported Filters
 //
-// This package implements the following standard HDF5 filters:
+// This package implements the following HDF5 filters:
 //
 //   - DEFLATE (ID 1): Zlib/gzip compression via [Deflate]. This is the most
 //     common compression filter, using Go's standard compress/zlib package.
@@ -19,16 +19,28 @@
 //     data integrity by checking a 32-bit Fletcher checksum appended to
 //     the data.
 //
-// # Unsupported Filters
+//   - SZIP (ID 4): CCSDS 121.0-B Rice decompression via [Szip], compatible
+//     with the szip library and libaec in both entropy coding (EC) and
+//     nearest neighbor (NN) modes.
 //
-// The following filters are recognized but not implemented:
+//   - N-bit (ID 5): Bit-level packing of atomic, array and compound types
+//     via [NBit].
 //
-//   - SZIP (ID 4): Proprietary compression algorithm
-//   - N-bit (ID 5): Bit-level packing
-//   - Scale-offset (ID 6): Integer scaling and offset
+//   - Scale-offset (ID 6): Integer packing and decimal (D-scale) float
+//     packing via [ScaleOffset].
 //
-// Datasets using unsupported filters cannot be read. However, optional filters
-// (marked in the filter pipeline) can be skipped if not available.
+//   - LZF (ID 32000): The third-party filter h5py writes for
+//     compression="lzf", via [Lzf].
+//
+//   - Zstandard (ID 32015): The third-party filter written by the HDF5
+//     zstd plugin and hdf5plugin, via [Zstd], using the pure Go decoder
+//     of github.com/klauspost/compress.
+//
+// Other filters can be added with [Register]; datasets using filters that
+// are neither built in nor registered cannot be read. However, optional
+// filters (marked in the filter pipeline) that are not available only fail
+// the chunks they were applied to: chunks whose filter mask skips them are
+// decoded.
 //
 // # Filter Pipeline
 //
@@ -45,13 +57,24 @@
 //
 // Each chunk can have a filter mask that indicates which filters to skip.
 // If bit i is set in the mask, filter i is skipped during decoding. This
-// allows individual chunks to use different filter combinations.
+// allows individual chunks to use different filter combinations. Bits
+// count the filters as the pipeline message lists them, unavailable ones
+// included.
+//
+// Errors of a filter are returned as a [FilterError] naming the filter and
+// its position, and a chunk whose expected size is given must decode to
+// exactly that size, or [ErrDecodedSize] is returned.
 //
 // # Key Types
 //
 //   - [Filter]: Interface implemented by all filters (ID and Decode methods)
 //   - [Pipeline]: Manages a sequence of filters for decoding
-//   - [Deflate]: DEFLATE/zlib decompression filter
+//   - [Deflate]: DEFLATE decompression filter, for zlib, gzip and raw streams
 //   - [Shuffle]: Byte shuffle/unshuffle filter
+//   - [Szip]: SZIP decompression filter
+//   - [NBit]: N-bit unpacking filter
+//   - [ScaleOffset]: Scale-offset decoding filter
+//   - [Lzf]: LZF decompression filter
+//   - [Zstd]: Zstandard decompression filter
 //   - [Fletcher32Filter]: Fletcher-32 checksum verification filter
 package filter
@@ -1,11 +1,14 @@
-// Package filter implements HDF5 filter decompression.
+// Package filter implements HDF5 filter decompression, and compression for
+// the filters that can be written.
 //
-// Filters are applied to chunked data in reverse order during reading.
-// Each filter transforms data from its encoded form to decoded form.
+// Filters are applied to chunked data in reverse order during reading, and
+// in forward order during writing.
 package filter
 
 import (
+	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/robert-malhotra/go-hdf5/internal/message"
 )
@@ -19,11 +22,76 @@ type Filter interface {
 	Decode(input []byte) ([]byte, error)
 }
 
+// IntoDecoder is implemented by filters that can decode into a buffer the
+// caller supplies, so that it can be reused from one chunk to the next.
+type IntoDecoder interface {
+	Filter
+
+	// DecodeInto decodes input into dst, reusing its capacity, and returns
+	// the decoded data. sizeHint is the expected decoded size, or 0 if it
+	// is not known. The result may be input itself.
+	DecodeInto(dst, input []byte, sizeHint int) ([]byte, error)
+}
+
+// Encoder is implemented by filters that can also encode data, for writing.
+type Encoder interface {
+	Filter
+
+	// Encode transforms decoded data to encoded form.
+	Encode(input []byte) ([]byte, error)
+}
+
 // Registry maps filter IDs to filter constructors.
 var Registry = map[uint16]func([]uint32) Filter{
-	message.FilterDeflate:    func(cd []uint32) Filter { return NewDeflate(cd) },
-	message.FilterShuffle:    func(cd []uint32) Filter { return NewShuffle(cd) },
-	message.FilterFletcher32: func(cd []uint32) Filter { return NewFletcher32(cd) },
+	message.FilterDeflate:     func(cd []uint32) Filter { return NewDeflate(cd) },
+	message.FilterShuffle:     func(cd []uint32) Filter { return NewShuffle(cd) },
+	message.FilterFletcher32:  func(cd []uint32) Filter { return NewFletcher32(cd) },
+	message.FilterSZIP:        func(cd []uint32) Filter { return NewSzip(cd) },
+	message.FilterNBit:        func(cd []uint32) Filter { return NewNBit(cd) },
+	message.FilterScaleOffset: func(cd []uint32) Filter { return NewScaleOffset(cd) },
+	message.FilterLZF:         func(cd []uint32) Filter { return NewLzf(cd) },
+	message.FilterZstd:        func(cd []uint32) Filter { return NewZstd(cd) },
+}
+
+// Factory creates a filter from the client data of its filter pipeline
+// entry.
+type Factory func(clientData []uint32) (Filter, error)
+
+// ErrFilterRegistered is returned by Register for a filter ID that already
+// has a filter.
+var ErrFilterRegistered = errors.New("filter already registered")
+
+var (
+	registeredMu sync.RWMutex
+	registered   = map[uint16]Factory{}
+)
+
+// Register makes the filter factory creates available for filter ID id,
+// for filters this package does not implement. It returns an error
+// wrapping ErrFilterRegistered if id is one of the filters in Registry or
+// was registered before. Register is safe to call concurrently with New.
+func Register(id uint16, factory Factory) error {
+	if factory == nil {
+		return fmt.Errorf("registering filter %d: nil factory", id)
+	}
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	if _, ok := Registry[id]; ok {
+		return fmt.Errorf("filter %d: %w", id, ErrFilterRegistered)
+	}
+	if _, ok := registered[id]; ok {
+		return fmt.Errorf("filter %d: %w", id, ErrFilterRegistered)
+	}
+	registered[id] = factory
+	return nil
+}
+
+// registeredFactory returns the factory registered for id, if any.
+func registeredFactory(id uint16) (Factory, bool) {
+	registeredMu.RLock()
+	defer registeredMu.RUnlock()
+	factory, ok := registered[id]
+	return factory, ok
 }
 
 // filterNames maps known filter IDs to their names for better error messages.
@@ -34,12 +102,26 @@ var filterNames = map[uint16]string{
 	message.FilterSZIP:        "SZIP",
 	message.FilterNBit:        "N-bit",
 	message.FilterScaleOffset: "scale-offset",
+	message.FilterLZF:         "LZF",
+	message.FilterZstd:        "Zstandard",
 }
 
-// New creates a filter from a FilterInfo.
+// New creates a filter from a FilterInfo, with the filters of Registry
+// and then those added with Register. An optional filter that neither
+// provides is skipped: New returns a nil Filter.
 func New(info message.FilterInfo) (Filter, error) {
 	constructor, ok := Registry[info.ID]
 	if !ok {
+		if factory, ok := registeredFactory(info.ID); ok {
+			f, err := factory(info.ClientData)
+			if err != nil {
+				return nil, err
+			}
+			if f == nil {
+				return nil, fmt.Errorf("registered filter %d returned no filter", info.ID)
+			}
+			return f, nil
+		}
 		if info.IsOptional() {
 			return nil, nil // Optional filter not available
 		}
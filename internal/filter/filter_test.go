@@ -2,7 +2,14 @@ package filter
 
 import (
 	"bytes"
+	"compress/flate"
+	"compress/gzip"
 	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/robert-malhotra/go-hdf5/internal/binary"
@@ -31,6 +38,123 @@ func TestDeflateRoundtrip(t *testing.T) {
 	}
 }
 
+func TestDeflateDecodeInto(t *testing.T) {
+	original := bytes.Repeat([]byte("0123456789abcdef"), 64)
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write(original)
+	w.Close()
+	compressed := buf.Bytes()
+
+	// A buffer of the decompressed size is filled in place
+	f := NewDeflate(nil)
+	dst := make([]byte, len(original))
+	got, err := f.DecodeInto(dst, compressed, len(original))
+	if err != nil {
+		t.Fatalf("DecodeInto failed: %v", err)
+	}
+	if !bytes.Equal(got, original) || &got[0] != &dst[0] {
+		t.Errorf("DecodeInto returned %d bytes, in dst %v", len(got), &got[0] == &dst[0])
+	}
+
+	// A short hint or buffer grows
+	if got, err := f.DecodeInto(make([]byte, 10), compressed, 0); err != nil || !bytes.Equal(got, original) {
+		t.Errorf("DecodeInto into a short buffer = %d bytes, %v", len(got), err)
+	}
+
+	corrupt := bytes.Clone(compressed)
+	corrupt[len(corrupt)-1] ^= 0xff
+	for _, tc := range []struct {
+		name  string
+		input []byte
+		want  error
+	}{
+		{"checksum", corrupt, zlib.ErrChecksum},
+		{"no checksum", compressed[:len(compressed)-4], io.ErrUnexpectedEOF},
+		{"dictionary", append([]byte{0x78, 0xbb}, compressed[2:]...), zlib.ErrDictionary},
+		{"empty", nil, io.ErrUnexpectedEOF},
+	} {
+		if _, err := f.DecodeInto(nil, tc.input, 0); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+// Chunks written by other libraries may hold raw DEFLATE or gzip streams
+// rather than zlib streams.
+func TestDeflateStreamFlavors(t *testing.T) {
+	flavors := map[string]func(io.Writer) io.WriteCloser{
+		"zlib": func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+		"gzip": func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		"raw": func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, flate.BestCompression)
+			return fw
+		},
+		"raw stored": func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, flate.NoCompression)
+			return fw
+		},
+	}
+	original := bytes.Repeat([]byte{0, 0, 0x80, 0x3f, 0, 0, 0, 0x40}, 200)
+	shuffled, _ := NewShuffle([]uint32{4}).Encode(original)
+	p, err := NewPipeline(&message.FilterPipeline{Filters: []message.FilterInfo{
+		{ID: message.FilterShuffle, ClientData: []uint32{4}},
+		{ID: message.FilterDeflate},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, newWriter := range flavors {
+		compress := func(data []byte) []byte {
+			var buf bytes.Buffer
+			w := newWriter(&buf)
+			w.Write(data)
+			w.Close()
+			return buf.Bytes()
+		}
+		if got, err := p.Decode(compress(shuffled), 0); err != nil || !bytes.Equal(got, original) {
+			t.Errorf("%s: Pipeline.Decode = %d bytes, %v", name, len(got), err)
+		}
+
+		// A truncated stream reports how much it held
+		compressed := compress(original)
+		_, err = NewDeflate(nil).DecodeInto(nil, compressed[:len(compressed)/2], len(original))
+		if !errors.Is(err, io.ErrUnexpectedEOF) || !strings.Contains(err.Error(), fmt.Sprintf("of %d decompressed bytes", len(original))) {
+			t.Errorf("%s: truncated stream: err = %v", name, err)
+		}
+	}
+}
+
+func TestPipelineDecodeInto(t *testing.T) {
+	p, err := NewPipeline(&message.FilterPipeline{Filters: []message.FilterInfo{
+		{ID: message.FilterShuffle, ClientData: []uint32{4}},
+		{ID: message.FilterDeflate},
+		{ID: message.FilterFletcher32},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bufs Buffers
+	for i := 0; i < 3; i++ {
+		original := bytes.Repeat([]byte{byte(i), 1, 2, 3}, 100)
+		encoded, err := p.Encode(original, 0)
+		if err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+		got, err := p.DecodeInto(&bufs, encoded, 0, len(original))
+		if err != nil {
+			t.Fatalf("chunk %d: DecodeInto failed: %v", i, err)
+		}
+		if !bytes.Equal(got, original) {
+			t.Errorf("chunk %d decoded wrongly", i)
+		}
+		if want, _ := p.Decode(encoded, 0); !bytes.Equal(got, want) {
+			t.Errorf("chunk %d: DecodeInto and Decode differ", i)
+		}
+	}
+}
+
 func TestDeflateID(t *testing.T) {
 	f := NewDeflate(nil)
 	if f.ID() != message.FilterDeflate {
@@ -83,6 +207,61 @@ func TestShuffleSingleByte(t *testing.T) {
 	}
 }
 
+// Bytes past the last whole element are left in place, as the HDF5
+// library leaves them.
+func TestShuffleOddLengths(t *testing.T) {
+	f := NewShuffle([]uint32{4})
+	for n := 0; n <= 19; n++ {
+		original := make([]byte, n)
+		for i := range original {
+			original[i] = byte(i + 1)
+		}
+		shuffled, err := f.Encode(original)
+		if err != nil {
+			t.Fatalf("%d bytes: Encode failed: %v", n, err)
+		}
+		tail := n / 4 * 4
+		if !bytes.Equal(shuffled[tail:], original[tail:]) {
+			t.Errorf("%d bytes: Encode moved the tail: %v", n, shuffled)
+		}
+		got, err := f.Decode(shuffled)
+		if err != nil {
+			t.Fatalf("%d bytes: Decode failed: %v", n, err)
+		}
+		if !bytes.Equal(got, original) {
+			t.Errorf("%d bytes: Decode = %v, want %v", n, got, original)
+		}
+	}
+
+	// Eleven bytes of two 4-byte elements, shuffled, and three more
+	got, err := f.Decode([]byte{1, 5, 2, 6, 3, 7, 4, 8, 9, 10, 11})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}; !bytes.Equal(got, want) {
+		t.Errorf("Decode = %v, want %v", got, want)
+	}
+}
+
+func TestPipelineCheckElementSize(t *testing.T) {
+	p, err := NewPipeline(&message.FilterPipeline{Filters: []message.FilterInfo{
+		{ID: message.FilterShuffle, ClientData: []uint32{4}},
+		{ID: message.FilterDeflate},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.CheckElementSize(4); err != nil {
+		t.Errorf("CheckElementSize(4) = %v, want nil", err)
+	}
+	err = p.CheckElementSize(8)
+	var sizeErr *ShuffleSizeError
+	if !errors.As(err, &sizeErr) || sizeErr.ElementSize != 4 || sizeErr.DatatypeSize != 8 ||
+		!errors.Is(err, ErrShuffleElementSize) {
+		t.Errorf("CheckElementSize(8) = %v, want a ShuffleSizeError of 4 and 8", err)
+	}
+}
+
 func TestShuffleID(t *testing.T) {
 	f := NewShuffle(nil)
 	if f.ID() != message.FilterShuffle {
@@ -126,8 +305,15 @@ func TestFletcher32Invalid(t *testing.T) {
 
 	f := NewFletcher32(nil)
 	_, err := f.Decode(input)
-	if err == nil {
-		t.Error("Expected error for invalid checksum")
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected ErrChecksumMismatch for invalid checksum, got %v", err)
+	}
+
+	// HDF5 1.6 stored some checksums with their bytes reversed
+	checksum := binary.Fletcher32(data)
+	input = append(data, byte(checksum>>24), byte(checksum>>16), byte(checksum>>8), byte(checksum))
+	if _, err := f.Decode(input); err != nil {
+		t.Errorf("Decode of a reversed checksum failed: %v", err)
 	}
 }
 
@@ -206,3 +392,388 @@ func TestPipelineFilterMask(t *testing.T) {
 		t.Error("Skipped filter should leave data unchanged")
 	}
 }
+
+func TestPipelineMasksWithUnavailableFilter(t *testing.T) {
+	// An optional filter in the middle that is not available keeps its
+	// place: mask bits still count filters as the message lists them
+	p, err := NewPipeline(&message.FilterPipeline{Filters: []message.FilterInfo{
+		{ID: message.FilterShuffle, ClientData: []uint32{4}},
+		{ID: 40000, Flags: 0x01},
+		{ID: message.FilterDeflate, ClientData: []uint32{6}},
+	}})
+	if err != nil {
+		t.Fatalf("NewPipeline failed: %v", err)
+	}
+	if p.Len() != 3 {
+		t.Fatalf("Len = %d, want 3", p.Len())
+	}
+
+	original := make([]byte, 1024)
+	for i := range original {
+		original[i] = byte(i / 16)
+	}
+	for _, mask := range []uint32{0x2, 0x3, 0x6, 0x7} {
+		encoded, err := p.Encode(original, mask)
+		if err != nil {
+			t.Fatalf("mask %#x: Encode failed: %v", mask, err)
+		}
+		compressed := mask&0x4 == 0
+		if compressed == (len(encoded) == len(original)) {
+			t.Errorf("mask %#x: encoded to %d bytes", mask, len(encoded))
+		}
+		if !compressed && bytes.Equal(encoded, original) != (mask&0x1 != 0) {
+			t.Errorf("mask %#x: shuffle applied wrongly", mask)
+		}
+		decoded, err := p.DecodeInto(nil, encoded, mask, len(original))
+		if err != nil {
+			t.Fatalf("mask %#x: Decode failed: %v", mask, err)
+		}
+		if !bytes.Equal(decoded, original) {
+			t.Errorf("mask %#x: round trip changed the data", mask)
+		}
+	}
+
+	// Chunks the missing filter was applied to cannot be decoded
+	encoded, _ := p.Encode(original, 0x2)
+	_, err = p.Decode(encoded, 0)
+	var ferr *FilterError
+	if !errors.Is(err, ErrFilterUnavailable) || !errors.As(err, &ferr) || ferr.Index != 1 || ferr.ID != 40000 {
+		t.Errorf("Decode with the missing filter applied: err = %v", err)
+	}
+	if _, err := p.Encode(original, 0); err == nil {
+		t.Error("Encode with the missing filter succeeded")
+	}
+}
+
+func TestPipelineErrors(t *testing.T) {
+	p, err := NewPipeline(&message.FilterPipeline{Filters: []message.FilterInfo{
+		{ID: message.FilterShuffle, ClientData: []uint32{4}},
+		{ID: message.FilterDeflate, ClientData: []uint32{6}},
+	}})
+	if err != nil {
+		t.Fatalf("NewPipeline failed: %v", err)
+	}
+	original := bytes.Repeat([]byte("0123456789abcdef"), 64)
+	encoded, err := p.Encode(original, 0)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	// Each filter's errors name it and its position
+	_, err = p.Decode(encoded[:len(encoded)/2], 0)
+	var ferr *FilterError
+	if !errors.As(err, &ferr) || ferr.Index != 1 || ferr.ID != message.FilterDeflate ||
+		!strings.HasPrefix(err.Error(), "pipeline filter 1 (deflate/gzip, ID 1): ") {
+		t.Errorf("Decode of a truncated chunk: err = %v", err)
+	}
+
+	// The decoded size is checked when it is known
+	for _, size := range []int{len(original) - 4, len(original) + 4} {
+		if _, err := p.DecodeInto(nil, encoded, 0, size); !errors.Is(err, ErrDecodedSize) {
+			t.Errorf("DecodeInto with size %d: err = %v, want ErrDecodedSize", size, err)
+		}
+	}
+	for _, size := range []int{0, len(original)} {
+		if got, err := p.DecodeInto(nil, encoded, 0, size); err != nil || !bytes.Equal(got, original) {
+			t.Errorf("DecodeInto with size %d = %d bytes, %v", size, len(got), err)
+		}
+	}
+	// Also when every filter is skipped
+	if _, err := p.DecodeInto(nil, original[:100], 0x3, len(original)); !errors.Is(err, ErrDecodedSize) {
+		t.Errorf("DecodeInto of a short unfiltered chunk: err = %v, want ErrDecodedSize", err)
+	}
+}
+
+func TestPipelineEncodeRoundtrip(t *testing.T) {
+	fp := &message.FilterPipeline{Filters: []message.FilterInfo{
+		{ID: message.FilterShuffle, ClientData: []uint32{4}},
+		{ID: message.FilterDeflate, ClientData: []uint32{9}},
+		{ID: message.FilterFletcher32},
+	}}
+	p, err := NewPipeline(fp)
+	if err != nil {
+		t.Fatalf("NewPipeline failed: %v", err)
+	}
+
+	original := make([]byte, 4096)
+	for i := range original {
+		original[i] = byte(i / 64)
+	}
+	for _, mask := range []uint32{0, 0x02} {
+		encoded, err := p.Encode(original, mask)
+		if err != nil {
+			t.Fatalf("mask %#x: Encode failed: %v", mask, err)
+		}
+		if mask == 0 && len(encoded) >= len(original) {
+			t.Errorf("encoded to %d bytes, not compressed", len(encoded))
+		}
+		decoded, err := p.Decode(encoded, mask)
+		if err != nil {
+			t.Fatalf("mask %#x: Decode failed: %v", mask, err)
+		}
+		if !bytes.Equal(decoded, original) {
+			t.Errorf("mask %#x: round trip changed the data", mask)
+		}
+	}
+
+	// Filters that only decode cannot be written
+	p, err = NewPipeline(&message.FilterPipeline{Filters: []message.FilterInfo{{ID: message.FilterNBit}}})
+	if err != nil {
+		t.Fatalf("NewPipeline failed: %v", err)
+	}
+	if _, err := p.Encode(original, 0); err == nil {
+		t.Error("Encode with the N-bit filter succeeded")
+	}
+}
+
+// xorFilter is a registered filter that XORs every byte with its client
+// data.
+type xorFilter struct {
+	id  uint16
+	key byte
+}
+
+func (f xorFilter) ID() uint16 { return f.id }
+
+func (f xorFilter) Decode(input []byte) ([]byte, error) {
+	out := make([]byte, len(input))
+	for i, b := range input {
+		out[i] = b ^ f.key
+	}
+	return out, nil
+}
+
+// unregister removes the filter registered for id.
+func unregister(id uint16) {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	delete(registered, id)
+}
+
+func TestRegister(t *testing.T) {
+	const id = 32100
+	info := message.FilterInfo{ID: id, ClientData: []uint32{0x5a}}
+	if _, err := New(info); err == nil || !strings.Contains(err.Error(), "unsupported filter ID: 32100") {
+		t.Fatalf("New before Register: got %v, expected unsupported filter", err)
+	}
+
+	err := Register(id, func(cd []uint32) (Filter, error) {
+		if len(cd) != 1 {
+			return nil, fmt.Errorf("xor filter needs 1 client data value, got %d", len(cd))
+		}
+		return xorFilter{id, byte(cd[0])}, nil
+	})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	t.Cleanup(func() { unregister(id) })
+
+	p, err := NewPipeline(&message.FilterPipeline{Filters: []message.FilterInfo{info}})
+	if err != nil {
+		t.Fatalf("NewPipeline failed: %v", err)
+	}
+	got, err := p.Decode([]byte{0x5a, 0x5b, 0xa5}, 0)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if !bytes.Equal(got, []byte{0, 1, 0xff}) {
+		t.Errorf("Decode = %x, expected 0001ff", got)
+	}
+	if _, err := New(message.FilterInfo{ID: id}); err == nil || !strings.Contains(err.Error(), "needs 1 client data") {
+		t.Errorf("New with bad client data: got %v, expected the factory's error", err)
+	}
+
+	// Neither built-in nor registered filters can be registered again
+	for _, dup := range []uint16{id, message.FilterDeflate} {
+		err := Register(dup, func([]uint32) (Filter, error) { return xorFilter{}, nil })
+		if !errors.Is(err, ErrFilterRegistered) {
+			t.Errorf("Register(%d) again: got %v, expected ErrFilterRegistered", dup, err)
+		}
+	}
+	if err := Register(id+1, nil); err == nil {
+		t.Error("Register with a nil factory succeeded")
+	}
+
+	// Unregistered optional filters are still skipped
+	f, err := New(message.FilterInfo{ID: id + 1, Flags: 0x01})
+	if f != nil || err != nil {
+		t.Errorf("New for an optional unknown filter = %v, %v; expected nil, nil", f, err)
+	}
+}
+
+func TestRegisterConcurrent(t *testing.T) {
+	const base = 32200
+	var wg sync.WaitGroup
+	for i := range uint16(8) {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := Register(base+i, func([]uint32) (Filter, error) { return xorFilter{base + i, 1}, nil }); err != nil {
+				t.Errorf("Register(%d) failed: %v", base+i, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			New(message.FilterInfo{ID: base + i, Flags: 0x01})
+		}()
+	}
+	wg.Wait()
+	for i := range uint16(8) {
+		f, err := New(message.FilterInfo{ID: base + i})
+		if err != nil || f.ID() != base+i {
+			t.Errorf("New(%d) = %v, %v", base+i, f, err)
+		}
+		unregister(base + i)
+	}
+}
+
+func TestZstdRoundtrip(t *testing.T) {
+	original := bytes.Repeat([]byte("0123456789abcdef"), 256)
+	// No client data, the default level, a "fast" negative level and a
+	// level above the maximum all decode alike
+	for _, cd := range [][]uint32{nil, {3}, {uint32(0xffffffff)}, {22}, {99, 1}} {
+		f := NewZstd(cd)
+		if f.ID() != message.FilterZstd {
+			t.Fatalf("ID = %d, want %d", f.ID(), message.FilterZstd)
+		}
+		compressed, err := f.Encode(original)
+		if err != nil {
+			t.Fatalf("client data %v: Encode failed: %v", cd, err)
+		}
+		if len(compressed) >= len(original) {
+			t.Errorf("client data %v: encoded to %d bytes, not compressed", cd, len(compressed))
+		}
+		got, err := NewZstd(nil).Decode(compressed)
+		if err != nil {
+			t.Fatalf("client data %v: Decode failed: %v", cd, err)
+		}
+		if !bytes.Equal(got, original) {
+			t.Errorf("client data %v: round trip changed the data", cd)
+		}
+	}
+}
+
+func TestZstdDecodeInto(t *testing.T) {
+	original := bytes.Repeat([]byte("0123456789abcdef"), 64)
+	f := NewZstd(nil)
+	compressed, err := f.Encode(original)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	// A buffer of the decompressed size is filled in place
+	dst := make([]byte, len(original))
+	got, err := f.DecodeInto(dst, compressed, len(original))
+	if err != nil {
+		t.Fatalf("DecodeInto failed: %v", err)
+	}
+	if !bytes.Equal(got, original) || &got[0] != &dst[0] {
+		t.Errorf("DecodeInto returned %d bytes, in dst %v", len(got), &got[0] == &dst[0])
+	}
+
+	// Through a pipeline with the filter registered by default
+	p, err := NewPipeline(&message.FilterPipeline{Filters: []message.FilterInfo{{ID: message.FilterZstd}}})
+	if err != nil {
+		t.Fatalf("NewPipeline failed: %v", err)
+	}
+	var bufs Buffers
+	if got, err := p.DecodeInto(&bufs, compressed, 0, len(original)); err != nil || !bytes.Equal(got, original) {
+		t.Errorf("Pipeline.DecodeInto = %d bytes, %v", len(got), err)
+	}
+
+	for _, input := range [][]byte{compressed[:len(compressed)/2], []byte("not a zstd frame")} {
+		if _, err := f.Decode(input); err == nil || !strings.HasPrefix(err.Error(), "zstd: ") {
+			t.Errorf("Decode of %d bad bytes: err = %v, want a zstd error", len(input), err)
+		}
+	}
+}
+
+func TestLzfDecode(t *testing.T) {
+	// "abc", then 9 bytes from 3 back, a length 7 reference with its
+	// extension byte, then 3 bytes from 5 back
+	input := []byte{2, 'a', 'b', 'c', 7 << 5, 0, 2, 1 << 5, 4}
+	want := []byte("abcabcabcabcbca")
+	for _, cd := range [][]uint32{nil, {4, 0x0105, uint32(len(want))}} {
+		got, err := NewLzf(cd).Decode(input)
+		if err != nil {
+			t.Fatalf("client data %v: Decode failed: %v", cd, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("client data %v: Decode = %q, want %q", cd, got, want)
+		}
+	}
+
+	// Input of the chunk size is decoded like any other: "a" and a
+	// reference copying it 3 times is 4 bytes either way, and raw bytes of
+	// the chunk size are not LZF
+	if got, err := NewLzf([]uint32{4, 0x0105, 4}).Decode([]byte{0, 'a', 1 << 5, 0}); err != nil || string(got) != "aaaa" {
+		t.Errorf("Decode of 4 bytes to 4 = %q, %v, want \"aaaa\"", got, err)
+	}
+	if got, err := NewLzf([]uint32{4, 0x0105, 16}).Decode([]byte("0123456789abcdef")); !errors.Is(err, errLzfCorrupt) {
+		t.Errorf("Decode of raw bytes = %q, %v, want errLzfCorrupt", got, err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		cd    []uint32
+		input []byte
+	}{
+		{"literal past end", nil, []byte{5, 'a', 'b'}},
+		{"reference past end", nil, []byte{0, 'a', 7 << 5}},
+		{"reference before start", nil, []byte{0, 'a', 1 << 5, 4}},
+		{"wrong size", []uint32{4, 0x0105, 20}, input},
+	} {
+		if _, err := NewLzf(tc.cd).Decode(tc.input); !errors.Is(err, errLzfCorrupt) {
+			t.Errorf("%s: err = %v, want errLzfCorrupt", tc.name, err)
+		}
+	}
+}
+
+func TestLzfRoundtrip(t *testing.T) {
+	compressible := make([]byte, 10000)
+	for i := range compressible {
+		compressible[i] = byte(i / 100 * 7)
+	}
+	noise := make([]byte, 4096)
+	x := uint32(1)
+	for i := range noise {
+		x ^= x << 13
+		x ^= x >> 17
+		x ^= x << 5
+		noise[i] = byte(x)
+	}
+	long := bytes.Repeat([]byte{'z'}, 1000) // References longer than 264 bytes
+
+	for _, tc := range []struct {
+		name     string
+		input    []byte
+		compress bool
+	}{
+		{"compressible", compressible, true},
+		{"noise", noise, false},
+		{"long runs", long, true},
+		{"short", []byte("ab"), false},
+	} {
+		f := NewLzf([]uint32{4, 0x0105, uint32(len(tc.input))})
+		encoded, err := f.Encode(tc.input)
+		if !tc.compress {
+			if !errors.Is(err, ErrIncompressible) {
+				t.Errorf("%s: Encode = %d bytes, %v, want ErrIncompressible", tc.name, len(encoded), err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: Encode failed: %v", tc.name, err)
+		}
+		if len(encoded) >= len(tc.input) {
+			t.Errorf("%s: encoded %d bytes to %d", tc.name, len(tc.input), len(encoded))
+		}
+		decoded, err := f.DecodeInto(nil, encoded, len(tc.input))
+		if err != nil {
+			t.Fatalf("%s: Decode failed: %v", tc.name, err)
+		}
+		if !bytes.Equal(decoded, tc.input) {
+			t.Errorf("%s: round trip changed the data", tc.name)
+		}
+	}
+}
@@ -3,15 +3,22 @@ package filter
 import (
 	"encoding/binary"
 	"fmt"
+	"math/bits"
 
 	binpkg "github.com/robert-malhotra/go-hdf5/internal/binary"
 	"github.com/robert-malhotra/go-hdf5/internal/message"
 )
 
+// ErrChecksumMismatch is returned by Decode when the stored checksum does
+// not match the data. It is the error metadata checksums wrap too.
+var ErrChecksumMismatch = binpkg.ErrChecksumMismatch
+
 // Fletcher32Filter implements the Fletcher-32 checksum filter.
 // This filter validates data integrity by checking a checksum
 // appended to the data.
-type Fletcher32Filter struct{}
+type Fletcher32Filter struct {
+	skipVerify bool // Strip the checksum without checking it
+}
 
 // NewFletcher32 creates a new Fletcher-32 filter.
 func NewFletcher32(clientData []uint32) *Fletcher32Filter {
@@ -23,7 +30,9 @@ func (f *Fletcher32Filter) ID() uint16 {
 }
 
 // Decode verifies the Fletcher-32 checksum and returns the data without it.
-// The checksum is stored as the last 4 bytes of the input.
+// The checksum is stored as the last 4 bytes of the input. As in the HDF5
+// library, a checksum stored with its bytes reversed, as HDF5 1.6 wrote
+// them on some platforms, is accepted too.
 func (f *Fletcher32Filter) Decode(input []byte) ([]byte, error) {
 	if len(input) < 4 {
 		return nil, fmt.Errorf("fletcher32: input too short for checksum")
@@ -36,13 +45,24 @@ func (f *Fletcher32Filter) Decode(input []byte) ([]byte, error) {
 	// Stored checksum (little-endian)
 	storedChecksum := binary.LittleEndian.Uint32(checksumBytes)
 
+	if f.skipVerify {
+		return data, nil
+	}
+
 	// Compute checksum of data
 	computedChecksum := binpkg.Fletcher32(data)
 
-	if storedChecksum != computedChecksum {
-		return nil, fmt.Errorf("fletcher32: checksum mismatch (stored=0x%08x, computed=0x%08x)",
-			storedChecksum, computedChecksum)
+	if storedChecksum != computedChecksum && storedChecksum != bits.ReverseBytes32(computedChecksum) {
+		return nil, fmt.Errorf("fletcher32: %w (stored=0x%08x, computed=0x%08x)",
+			ErrChecksumMismatch, storedChecksum, computedChecksum)
 	}
 
 	return data, nil
 }
+
+// Encode returns the data with its Fletcher-32 checksum appended.
+func (f *Fletcher32Filter) Encode(input []byte) ([]byte, error) {
+	output := make([]byte, len(input), len(input)+4)
+	copy(output, input)
+	return binary.LittleEndian.AppendUint32(output, binpkg.Fletcher32(input)), nil
+}
@@ -0,0 +1,163 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// errLzfCorrupt is returned for LZF data that does not decode to the chunk.
+var errLzfCorrupt = errors.New("lzf: corrupt compressed data")
+
+// ErrIncompressible is returned by Lzf.Encode for data that does not
+// shrink. h5py's filter fails for such a chunk, and as the filter is
+// optional the HDF5 library stores the chunk unfiltered, with the filter's
+// bit set in the chunk's filter mask.
+var ErrIncompressible = errors.New("lzf: data does not shrink")
+
+// Lzf implements the LZF filter (ID 32000), as written by h5py for
+// compression="lzf".
+type Lzf struct {
+	chunkSize int // Uncompressed chunk size in bytes, 0 if unknown
+}
+
+// NewLzf creates a new LZF filter.
+// Client data: [0] = filter revision, [1] = LZF version, [2] = chunk size
+// in bytes. h5py fills in the chunk size; without it, the decoded size
+// comes from the size hint or the buffer grows.
+func NewLzf(clientData []uint32) *Lzf {
+	f := &Lzf{}
+	if len(clientData) > 2 {
+		f.chunkSize = int(clientData[2])
+	}
+	return f
+}
+
+func (f *Lzf) ID() uint16 {
+	return message.FilterLZF
+}
+
+// Decode decompresses input; see DecodeInto.
+func (f *Lzf) Decode(input []byte) ([]byte, error) {
+	return f.DecodeInto(nil, input, 0)
+}
+
+// DecodeInto decompresses input into dst, which is preallocated to the
+// chunk size. Chunks that h5py could not shrink are stored unfiltered, and
+// the filter mask keeps them from reaching the filter.
+func (f *Lzf) DecodeInto(dst, input []byte, sizeHint int) ([]byte, error) {
+	size := f.chunkSize
+	if size == 0 {
+		size = sizeHint
+	}
+	if size == 0 {
+		size = 2 * len(input)
+	}
+	if cap(dst) < size {
+		dst = make([]byte, 0, size)
+	}
+	out := dst[:0]
+
+	for ip := 0; ip < len(input); {
+		ctrl := int(input[ip])
+		ip++
+		if ctrl < 1<<5 {
+			// Literal run of ctrl+1 bytes
+			n := ctrl + 1
+			if ip+n > len(input) {
+				return nil, fmt.Errorf("%w: literal run past end of input", errLzfCorrupt)
+			}
+			out = append(out, input[ip:ip+n]...)
+			ip += n
+			continue
+		}
+
+		// Back reference: length in the top 3 bits, extended by a byte
+		// if they are all set, and a 13-bit distance
+		n := ctrl >> 5
+		if n == 7 {
+			if ip >= len(input) {
+				return nil, fmt.Errorf("%w: back reference past end of input", errLzfCorrupt)
+			}
+			n += int(input[ip])
+			ip++
+		}
+		if ip >= len(input) {
+			return nil, fmt.Errorf("%w: back reference past end of input", errLzfCorrupt)
+		}
+		ref := len(out) - (ctrl&0x1f)<<8 - int(input[ip]) - 1
+		ip++
+		n += 2
+		if ref < 0 {
+			return nil, fmt.Errorf("%w: back reference before start of output", errLzfCorrupt)
+		}
+		// Byte by byte, as the copy may overlap the bytes it appends
+		for i := range n {
+			out = append(out, out[ref+i])
+		}
+	}
+
+	if f.chunkSize > 0 && len(out) != f.chunkSize {
+		return nil, fmt.Errorf("%w: decoded %d bytes, expected %d", errLzfCorrupt, len(out), f.chunkSize)
+	}
+	return out, nil
+}
+
+const (
+	lzfMaxLiteral = 1 << 5
+	lzfMaxOffset  = 1 << 13
+	lzfMaxRef     = 7 + 255 + 2
+	lzfHashBits   = 14
+)
+
+// Encode compresses input with LZF. As h5py's filter does, it fails for
+// data that does not shrink, with ErrIncompressible.
+func (f *Lzf) Encode(input []byte) ([]byte, error) {
+	out := make([]byte, 0, len(input))
+	var table [1 << lzfHashBits]int32 // Position+1 of the last 3 bytes with each hash
+	lit := 0                          // Start of the pending literal run
+
+	flush := func(end int) {
+		for lit < end {
+			n := min(end-lit, lzfMaxLiteral)
+			out = append(out, byte(n-1))
+			out = append(out, input[lit:lit+n]...)
+			lit += n
+		}
+	}
+
+	for ip := 0; ip+2 < len(input); {
+		v := uint32(input[ip])<<16 | uint32(input[ip+1])<<8 | uint32(input[ip+2])
+		h := (v * 2654435761) >> (32 - lzfHashBits)
+		ref := int(table[h]) - 1
+		table[h] = int32(ip + 1)
+
+		off := ip - ref - 1
+		if ref < 0 || off >= lzfMaxOffset || input[ref] != input[ip] ||
+			input[ref+1] != input[ip+1] || input[ref+2] != input[ip+2] {
+			ip++
+			continue
+		}
+
+		n := 3
+		for n < lzfMaxRef && ip+n < len(input) && input[ref+n] == input[ip+n] {
+			n++
+		}
+		flush(ip)
+		if n-2 < 7 {
+			out = append(out, byte((n-2)<<5|off>>8))
+		} else {
+			out = append(out, byte(7<<5|off>>8), byte(n-2-7))
+		}
+		out = append(out, byte(off))
+		ip += n
+		lit = ip
+	}
+	flush(len(input))
+
+	if len(out) >= len(input) {
+		return nil, ErrIncompressible
+	}
+	return out, nil
+}
@@ -0,0 +1,240 @@
+package filter
+
+import (
+	"fmt"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// Datatype class codes used in N-bit client data.
+const (
+	NBitAtomic   = 1 // Integer or float: size, order, precision, offset
+	NBitArray    = 2 // Array: size, then the base type
+	NBitCompound = 3 // Compound: size, member count, then offset and type per member
+	NBitNoop     = 4 // Any other type, stored as whole bytes: size
+)
+
+// Indexes of the leading client data values written by libhdf5's
+// set_local callback.
+const (
+	nbitParmCount = iota
+	nbitParmNoCompress
+	nbitParmNumElements
+	nbitParmType
+)
+
+// NBit implements the N-bit filter.
+//
+// Only the significant bits of each value (its precision, starting at its
+// bit offset) are stored, packed most significant bit first with no padding
+// between values. Decoding restores each value to its full storage size
+// with the unused bits cleared; sign extension is left to datatype
+// conversion, which knows the precision from the datatype message.
+type NBit struct {
+	clientData []uint32
+}
+
+// NewNBit creates a new N-bit filter.
+// Client data: [0] = number of values, [1] = nonzero when the data was
+// stored unpacked, [2] = elements per chunk, [3:] = datatype description
+// (see the NBit* class codes)
+func NewNBit(clientData []uint32) *NBit {
+	return &NBit{clientData: clientData}
+}
+
+func (f *NBit) ID() uint16 {
+	return message.FilterNBit
+}
+
+// nbitType is a parsed datatype description.
+type nbitType struct {
+	class     uint32
+	size      int
+	bigEndian bool
+	precision int
+	offset    int
+	base      *nbitType    // Array element type
+	members   []nbitMember // Compound members
+}
+
+type nbitMember struct {
+	offset int
+	typ    *nbitType
+}
+
+// Decode unpacks one chunk written by the N-bit filter.
+func (f *NBit) Decode(input []byte) ([]byte, error) {
+	cd := f.clientData
+	if len(cd) <= nbitParmType {
+		return nil, fmt.Errorf("nbit: need at least %d client data values, got %d", nbitParmType+1, len(cd))
+	}
+	// Types where packing would gain nothing are stored as is
+	if cd[nbitParmNoCompress] != 0 {
+		return input, nil
+	}
+
+	rest := cd[nbitParmType:]
+	typ, err := parseNBitType(&rest, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	n := int(cd[nbitParmNumElements])
+	output := make([]byte, n*typ.size)
+	br := &bitReader{data: input}
+	for i := 0; i < n; i++ {
+		if err := typ.decode(br, output[i*typ.size:(i+1)*typ.size]); err != nil {
+			return nil, fmt.Errorf("nbit: element %d: %w", i, err)
+		}
+	}
+	return output, nil
+}
+
+// parseNBitType consumes one datatype description from the front of cd.
+func parseNBitType(cd *[]uint32, depth int) (*nbitType, error) {
+	if depth > 32 {
+		return nil, fmt.Errorf("nbit: datatype nested too deeply")
+	}
+	next := func() (uint32, error) {
+		if len(*cd) == 0 {
+			return 0, fmt.Errorf("nbit: client data ends inside datatype description")
+		}
+		v := (*cd)[0]
+		*cd = (*cd)[1:]
+		return v, nil
+	}
+
+	class, err := next()
+	if err != nil {
+		return nil, err
+	}
+	size, err := next()
+	if err != nil {
+		return nil, err
+	}
+	t := &nbitType{class: class, size: int(size)}
+	if t.size == 0 {
+		return nil, fmt.Errorf("nbit: datatype of class %d has zero size", class)
+	}
+
+	switch class {
+	case NBitAtomic:
+		var vals [3]uint32
+		for i := range vals {
+			if vals[i], err = next(); err != nil {
+				return nil, err
+			}
+		}
+		if vals[0] > 1 {
+			return nil, fmt.Errorf("nbit: unknown byte order %d", vals[0])
+		}
+		t.bigEndian = vals[0] == 1
+		t.precision, t.offset = int(vals[1]), int(vals[2])
+		switch {
+		case t.precision == 0 || t.precision > 64:
+			return nil, fmt.Errorf("nbit: unsupported precision %d", t.precision)
+		case t.precision+t.offset > t.size*8:
+			return nil, fmt.Errorf("nbit: precision %d at offset %d exceeds %d-byte type",
+				t.precision, t.offset, t.size)
+		}
+
+	case NBitArray:
+		if t.base, err = parseNBitType(cd, depth+1); err != nil {
+			return nil, err
+		}
+		if t.size%t.base.size != 0 {
+			return nil, fmt.Errorf("nbit: array of %d bytes is not a multiple of its %d-byte base type",
+				t.size, t.base.size)
+		}
+
+	case NBitCompound:
+		count, err := next()
+		if err != nil {
+			return nil, err
+		}
+		for i := uint32(0); i < count; i++ {
+			offset, err := next()
+			if err != nil {
+				return nil, err
+			}
+			member, err := parseNBitType(cd, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			if int(offset)+member.size > t.size {
+				return nil, fmt.Errorf("nbit: compound member %d extends past the %d-byte type", i, t.size)
+			}
+			t.members = append(t.members, nbitMember{offset: int(offset), typ: member})
+		}
+
+	case NBitNoop:
+
+	default:
+		return nil, fmt.Errorf("nbit: unknown datatype class %d", class)
+	}
+	return t, nil
+}
+
+// decode unpacks one value of t into out, which is t.size bytes long.
+func (t *nbitType) decode(br *bitReader, out []byte) error {
+	switch t.class {
+	case NBitAtomic:
+		var v uint64
+		if t.precision > 32 {
+			hi, err := br.read(t.precision - 32)
+			if err != nil {
+				return err
+			}
+			lo, err := br.read(32)
+			if err != nil {
+				return err
+			}
+			v = uint64(hi)<<32 | uint64(lo)
+		} else {
+			lo, err := br.read(t.precision)
+			if err != nil {
+				return err
+			}
+			v = uint64(lo)
+		}
+		// Place the value at its bit offset, byte by byte from the least
+		// significant
+		for k := t.offset / 8; k <= (t.offset+t.precision-1)/8; k++ {
+			var b byte
+			if shift := k*8 - t.offset; shift >= 0 {
+				b = byte(v >> shift)
+			} else {
+				b = byte(v << -shift)
+			}
+			if t.bigEndian {
+				out[t.size-1-k] = b
+			} else {
+				out[k] = b
+			}
+		}
+
+	case NBitArray:
+		for i := 0; i < t.size; i += t.base.size {
+			if err := t.base.decode(br, out[i:i+t.base.size]); err != nil {
+				return err
+			}
+		}
+
+	case NBitCompound:
+		for _, m := range t.members {
+			if err := m.typ.decode(br, out[m.offset:m.offset+m.typ.size]); err != nil {
+				return err
+			}
+		}
+
+	case NBitNoop:
+		for i := range out {
+			b, err := br.read(8)
+			if err != nil {
+				return err
+			}
+			out[i] = byte(b)
+		}
+	}
+	return nil
+}
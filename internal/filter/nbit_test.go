@@ -0,0 +1,123 @@
+package filter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// packNBit packs each value into the given number of bits.
+func packNBit(precision int, values ...uint32) []byte {
+	w := &bitWriter{}
+	for _, v := range values {
+		w.write(v, precision)
+	}
+	return w.buf
+}
+
+func TestNBitID(t *testing.T) {
+	f := NewNBit(nil)
+	if f.ID() != message.FilterNBit {
+		t.Errorf("expected ID %d, got %d", message.FilterNBit, f.ID())
+	}
+}
+
+func TestNBitDecode(t *testing.T) {
+	// A compound of a 12-bit big-endian int16 at byte 0, an opaque byte at
+	// byte 2 and a two-element array of 3-bit bytes at byte 3
+	compound := []uint32{16, 0, 2, NBitCompound, 5, 3,
+		0, NBitAtomic, 2, 1, 12, 0,
+		2, NBitNoop, 1,
+		3, NBitArray, 2, NBitAtomic, 1, 0, 3, 0}
+	w := &bitWriter{}
+	for _, e := range [][4]uint32{{0xABC, 0x7F, 5, 2}, {0x001, 0x80, 7, 0}} {
+		w.write(e[0], 12)
+		w.write(e[1], 8)
+		w.write(e[2], 3)
+		w.write(e[3], 3)
+	}
+
+	tests := []struct {
+		name  string
+		cd    []uint32
+		input []byte
+		want  []byte
+	}{
+		{
+			name:  "12-bit little endian",
+			cd:    []uint32{8, 0, 3, NBitAtomic, 2, 0, 12, 0},
+			input: packNBit(12, 0xFFF, 0x800, 0x123),
+			want:  []byte{0xFF, 0x0F, 0x00, 0x08, 0x23, 0x01},
+		},
+		{
+			name:  "12-bit big endian",
+			cd:    []uint32{8, 0, 2, NBitAtomic, 2, 1, 12, 0},
+			input: packNBit(12, 0xFFF, 0x123),
+			want:  []byte{0x0F, 0xFF, 0x01, 0x23},
+		},
+		{
+			name:  "bit offset",
+			cd:    []uint32{8, 0, 2, NBitAtomic, 4, 0, 10, 7},
+			input: packNBit(10, 0x3FF, 0x201),
+			want:  []byte{0x80, 0xFF, 0x01, 0x00, 0x80, 0x00, 0x01, 0x00},
+		},
+		{
+			name:  "full precision",
+			cd:    []uint32{8, 0, 2, NBitAtomic, 1, 0, 8, 0},
+			input: []byte{0x12, 0xFE},
+			want:  []byte{0x12, 0xFE},
+		},
+		{
+			name:  "stored unpacked",
+			cd:    []uint32{8, 1, 2, NBitAtomic, 4, 0, 32, 0},
+			input: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			want:  []byte{1, 2, 3, 4, 5, 6, 7, 8},
+		},
+		{
+			name:  "compound",
+			cd:    compound,
+			input: w.buf,
+			want:  []byte{0x0A, 0xBC, 0x7F, 5, 2, 0x00, 0x01, 0x80, 7, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewNBit(tt.cd).Decode(tt.input)
+			if err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("got %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNBitErrors(t *testing.T) {
+	chunk := packNBit(12, 1, 2, 3, 4)
+
+	tests := []struct {
+		name  string
+		cd    []uint32
+		input []byte
+	}{
+		{"missing parameters", []uint32{3, 0, 4}, chunk},
+		{"short type", []uint32{8, 0, 4, NBitAtomic, 2, 0}, chunk},
+		{"unknown class", []uint32{5, 0, 4, 9, 2}, chunk},
+		{"bad order", []uint32{8, 0, 4, NBitAtomic, 2, 2, 12, 0}, chunk},
+		{"precision too large", []uint32{8, 0, 4, NBitAtomic, 2, 0, 12, 8}, chunk},
+		{"zero precision", []uint32{8, 0, 4, NBitAtomic, 2, 0, 0, 0}, chunk},
+		{"truncated", []uint32{8, 0, 5, NBitAtomic, 2, 0, 12, 0}, chunk},
+		{"member past end", []uint32{9, 0, 1, NBitCompound, 2, 1, 1, NBitNoop, 2}, chunk},
+		{"ragged array", []uint32{9, 0, 1, NBitArray, 3, NBitAtomic, 2, 0, 12, 0}, chunk},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewNBit(tt.cd).Decode(tt.input); err == nil {
+				t.Error("Decode succeeded")
+			}
+		})
+	}
+}
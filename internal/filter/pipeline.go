@@ -1,17 +1,62 @@
 package filter
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/robert-malhotra/go-hdf5/internal/message"
 )
 
+// ErrFilterUnavailable is returned when decoding a chunk that was encoded
+// with an optional filter that is neither built in nor registered.
+var ErrFilterUnavailable = errors.New("filter not available")
+
+// ErrDecodedSize is returned when a chunk does not decode to its expected
+// size.
+var ErrDecodedSize = errors.New("decoded chunk has the wrong size")
+
+// FilterError reports an error of one filter of a pipeline.
+type FilterError struct {
+	Index int    // Position in the pipeline, and bit of the filter mask
+	ID    uint16 // Filter ID
+	Err   error
+}
+
+func (e *FilterError) Error() string {
+	name := fmt.Sprintf("ID %d", e.ID)
+	if n, ok := filterNames[e.ID]; ok {
+		name = fmt.Sprintf("%s, ID %d", n, e.ID)
+	}
+	return fmt.Sprintf("pipeline filter %d (%s): %v", e.Index, name, e.Err)
+}
+
+func (e *FilterError) Unwrap() error {
+	return e.Err
+}
+
+// unavailable stands in for an optional filter that is not available, so
+// that the pipeline's filters keep their positions in the filter mask.
+type unavailable uint16
+
+func (f unavailable) ID() uint16 {
+	return uint16(f)
+}
+
+func (f unavailable) Decode(input []byte) ([]byte, error) {
+	return nil, ErrFilterUnavailable
+}
+
 // Pipeline represents a filter pipeline that can decode chunk data.
 type Pipeline struct {
 	filters []Filter
 }
 
 // NewPipeline creates a filter pipeline from a FilterPipeline message.
+//
+// Optional filters that are not available do not fail the pipeline: as in
+// the HDF5 library, chunks whose filter mask skips them, such as those the
+// filter could not shrink, can still be decoded. Decoding any other chunk
+// returns an error wrapping ErrFilterUnavailable.
 func NewPipeline(fp *message.FilterPipeline) (*Pipeline, error) {
 	if fp == nil || len(fp.Filters) == 0 {
 		return &Pipeline{}, nil
@@ -26,22 +71,39 @@ func NewPipeline(fp *message.FilterPipeline) (*Pipeline, error) {
 		if err != nil {
 			return nil, fmt.Errorf("creating filter %d: %w", info.ID, err)
 		}
-		if f != nil {
-			p.filters = append(p.filters, f)
+		if f == nil {
+			f = unavailable(info.ID)
 		}
+		p.filters = append(p.filters, f)
 	}
 
 	return p, nil
 }
 
+// Buffers holds the buffers Pipeline.DecodeInto decodes into, to be reused
+// from one chunk to the next. The zero value is ready to use.
+type Buffers struct {
+	bufs [2][]byte
+	next int // Buffer the next filter decodes into
+}
+
 // Decode applies the filter pipeline to encoded data.
 // The filterMask specifies which filters to skip (bit i = skip filter i).
 // Filters are applied in reverse order (last filter first).
 func (p *Pipeline) Decode(input []byte, filterMask uint32) ([]byte, error) {
-	if len(p.filters) == 0 {
-		return input, nil
-	}
+	return p.DecodeInto(nil, input, filterMask, 0)
+}
 
+// DecodeInto is like Decode, but filters that implement IntoDecoder decode
+// into bufs, alternating between its two buffers, so that decoding chunk
+// after chunk does not allocate once they have grown to size. The result
+// is only valid until bufs is next used. A nil bufs allocates as Decode
+// does.
+//
+// size is the decoded chunk size, or 0 if it is not known. A chunk that
+// decodes to another size returns an error wrapping ErrDecodedSize.
+// Errors of a filter are returned as a *FilterError.
+func (p *Pipeline) DecodeInto(bufs *Buffers, input []byte, filterMask uint32, size int) ([]byte, error) {
 	data := input
 
 	// Apply filters in reverse order
@@ -52,15 +114,77 @@ func (p *Pipeline) Decode(input []byte, filterMask uint32) ([]byte, error) {
 		}
 
 		var err error
-		data, err = p.filters[i].Decode(data)
+		if into, ok := p.filters[i].(IntoDecoder); ok && bufs != nil {
+			// data is the input or lies in the other buffer, so decoding
+			// into this one cannot overwrite it
+			var out []byte
+			out, err = into.DecodeInto(bufs.bufs[bufs.next], data, size)
+			if err == nil && len(out) > 0 && len(data) > 0 && &out[0] != &data[0] {
+				bufs.bufs[bufs.next] = out
+				bufs.next ^= 1
+			}
+			data = out
+		} else {
+			data, err = p.filters[i].Decode(data)
+		}
 		if err != nil {
-			return nil, fmt.Errorf("filter %d decode: %w", p.filters[i].ID(), err)
+			return nil, &FilterError{Index: i, ID: p.filters[i].ID(), Err: err}
 		}
 	}
 
+	if size > 0 && len(data) != size {
+		return nil, fmt.Errorf("%w: decoded %d bytes, expected %d", ErrDecodedSize, len(data), size)
+	}
 	return data, nil
 }
 
+// Encode applies the filter pipeline to data for writing.
+// The filterMask specifies which filters to skip, as for Decode.
+// Filters are applied in forward order (first filter first).
+func (p *Pipeline) Encode(input []byte, filterMask uint32) ([]byte, error) {
+	data := input
+
+	for i, f := range p.filters {
+		if filterMask&(1<<uint(i)) != 0 {
+			continue
+		}
+
+		enc, ok := f.(Encoder)
+		if !ok {
+			return nil, fmt.Errorf("filter %d cannot be used for writing", f.ID())
+		}
+		var err error
+		data, err = enc.Encode(data)
+		if err != nil {
+			return nil, fmt.Errorf("filter %d encode: %w", f.ID(), err)
+		}
+	}
+
+	return data, nil
+}
+
+// SetVerifyChecksums sets whether Decode checks the checksums of checksum
+// filters, which is the default, or only strips them.
+func (p *Pipeline) SetVerifyChecksums(verify bool) {
+	for _, f := range p.filters {
+		if fl, ok := f.(*Fletcher32Filter); ok {
+			fl.skipVerify = !verify
+		}
+	}
+}
+
+// CheckElementSize returns a *ShuffleSizeError if a shuffle filter of the
+// pipeline shuffles elements of other than datatypeSize bytes. Decoding
+// uses the filter's own size, as the HDF5 library does.
+func (p *Pipeline) CheckElementSize(datatypeSize int) error {
+	for _, f := range p.filters {
+		if s, ok := f.(*Shuffle); ok && s.elemSize != datatypeSize {
+			return &ShuffleSizeError{ElementSize: s.elemSize, DatatypeSize: datatypeSize}
+		}
+	}
+	return nil
+}
+
 // Empty returns true if the pipeline has no filters.
 func (p *Pipeline) Empty() bool {
 	return len(p.filters) == 0
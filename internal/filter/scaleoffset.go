@@ -0,0 +1,195 @@
+package filter
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// Scale types, as stored in the first client data value.
+const (
+	ScaleOffsetFloatDScale = 0 // Decimal scaling of floating-point data
+	ScaleOffsetFloatEScale = 1 // Exponent scaling (never implemented by libhdf5)
+	ScaleOffsetInt         = 2 // Integer data
+)
+
+// Indexes of the client data values written by libhdf5's set_local callback.
+const (
+	scaleOffsetParmScaleType = iota
+	scaleOffsetParmScaleFactor
+	scaleOffsetParmNumElements
+	scaleOffsetParmClass
+	scaleOffsetParmSize
+	scaleOffsetParmSign
+	scaleOffsetParmOrder
+	scaleOffsetParmFillAvail
+	scaleOffsetParmFillValue
+)
+
+// scaleOffsetHeaderSize is the size of the header the filter prepends to
+// each chunk: minbits (4 bytes), the size of minval (1 byte), minval (8
+// bytes) and 8 unused bytes.
+const scaleOffsetHeaderSize = 21
+
+// ScaleOffset implements the scale-offset filter.
+//
+// Each chunk stores the minimum value and the number of bits needed for the
+// largest offset from it; every element is packed into that many bits.
+// Integers are stored as offsets from the minimum. Floats are first
+// rounded to integers after multiplying by 10^scaleFactor (D-scaling), so
+// reading them back is exact only to that many decimal places.
+type ScaleOffset struct {
+	clientData []uint32
+}
+
+// NewScaleOffset creates a new scale-offset filter.
+// Client data: [0] = scale type, [1] = scale factor, [2] = elements per
+// chunk, [3] = class (0 integer, 1 float), [4] = element size,
+// [5] = signed, [6] = byte order (0 little, 1 big endian),
+// [7] = fill value defined, [8:] = fill value bytes
+func NewScaleOffset(clientData []uint32) *ScaleOffset {
+	return &ScaleOffset{clientData: clientData}
+}
+
+func (f *ScaleOffset) ID() uint16 {
+	return message.FilterScaleOffset
+}
+
+// Decode unpacks one chunk written by the scale-offset filter.
+func (f *ScaleOffset) Decode(input []byte) ([]byte, error) {
+	cd := f.clientData
+	if len(cd) < scaleOffsetParmFillValue {
+		return nil, fmt.Errorf("scaleoffset: need at least %d client data values, got %d",
+			scaleOffsetParmFillValue, len(cd))
+	}
+	scaleType := cd[scaleOffsetParmScaleType]
+	isFloat := cd[scaleOffsetParmClass] == 1
+	size := int(cd[scaleOffsetParmSize])
+	var order binary.ByteOrder = binary.LittleEndian
+	if cd[scaleOffsetParmOrder] == 1 {
+		order = binary.BigEndian
+	}
+
+	switch {
+	case cd[scaleOffsetParmClass] > 1:
+		return nil, fmt.Errorf("scaleoffset: unknown datatype class %d", cd[scaleOffsetParmClass])
+	case isFloat && scaleType == ScaleOffsetFloatEScale:
+		return nil, fmt.Errorf("scaleoffset: E-scaling is not supported")
+	case isFloat && scaleType != ScaleOffsetFloatDScale:
+		return nil, fmt.Errorf("scaleoffset: scale type %d is not valid for floating-point data", scaleType)
+	case !isFloat && scaleType != ScaleOffsetInt:
+		return nil, fmt.Errorf("scaleoffset: scale type %d is not valid for integer data", scaleType)
+	case isFloat && size != 4 && size != 8:
+		return nil, fmt.Errorf("scaleoffset: unsupported float size %d", size)
+	case size != 1 && size != 2 && size != 4 && size != 8:
+		return nil, fmt.Errorf("scaleoffset: unsupported integer size %d", size)
+	}
+
+	if len(input) < scaleOffsetHeaderSize {
+		return nil, fmt.Errorf("scaleoffset: chunk of %d bytes is shorter than its header", len(input))
+	}
+	minbits := int(binary.LittleEndian.Uint32(input))
+	if minbits > size*8 {
+		return nil, fmt.Errorf("scaleoffset: minbits %d exceeds element size %d", minbits, size)
+	}
+	var minval uint64
+	for i := 0; i < min(int(input[4]), 8); i++ {
+		minval |= uint64(input[5+i]) << (8 * i)
+	}
+
+	n := int(cd[scaleOffsetParmNumElements])
+	payload := input[scaleOffsetHeaderSize:]
+	output := make([]byte, n*size)
+
+	// Full precision: the elements were stored unchanged, in the writer's
+	// (little-endian) memory order
+	if minbits == size*8 {
+		if len(payload) < len(output) {
+			return nil, fmt.Errorf("scaleoffset: chunk truncated")
+		}
+		for i := 0; i < n; i++ {
+			putElement(output[i*size:], order, size, element(payload[i*size:], binary.LittleEndian, size))
+		}
+		return output, nil
+	}
+
+	if need := (n*minbits + 7) / 8; len(payload) < need {
+		return nil, fmt.Errorf("scaleoffset: chunk has %d bytes of packed data, need %d", len(payload), need)
+	}
+
+	fillDefined := cd[scaleOffsetParmFillAvail] == 1
+	var fill uint64
+	if fillDefined {
+		fillBytes := make([]byte, 0, 4*(len(cd)-scaleOffsetParmFillValue))
+		for _, v := range cd[scaleOffsetParmFillValue:] {
+			fillBytes = binary.LittleEndian.AppendUint32(fillBytes, v)
+		}
+		if len(fillBytes) < size {
+			return nil, fmt.Errorf("scaleoffset: fill value needs %d bytes, client data has %d", size, len(fillBytes))
+		}
+		fill = element(fillBytes, order, size)
+	}
+	// With a fill value, the all-ones pattern marks fill elements
+	fillMarker := uint64(1)<<minbits - 1
+	scale := math.Pow(10, float64(int32(cd[scaleOffsetParmScaleFactor])))
+
+	br := &bitReader{data: payload}
+	for i := 0; i < n; i++ {
+		var raw uint64
+		if minbits > 32 {
+			hi, _ := br.read(minbits - 32)
+			lo, _ := br.read(32)
+			raw = uint64(hi)<<32 | uint64(lo)
+		} else {
+			v, _ := br.read(minbits)
+			raw = uint64(v)
+		}
+
+		var value uint64
+		switch {
+		case fillDefined && raw == fillMarker:
+			value = fill
+		case !isFloat:
+			// Signed or not, the sum wraps modulo the element size as in C
+			value = raw + minval
+		case size == 4:
+			// Single precision throughout, as libhdf5 does with powf
+			v := float32(int32(raw))/float32(scale) + math.Float32frombits(uint32(minval))
+			value = uint64(math.Float32bits(v))
+		default:
+			value = math.Float64bits(float64(int64(raw))/scale + math.Float64frombits(minval))
+		}
+		putElement(output[i*size:], order, size, value)
+	}
+	return output, nil
+}
+
+// element reads an unsigned element of size bytes.
+func element(b []byte, order binary.ByteOrder, size int) uint64 {
+	switch size {
+	case 1:
+		return uint64(b[0])
+	case 2:
+		return uint64(order.Uint16(b))
+	case 4:
+		return uint64(order.Uint32(b))
+	default:
+		return order.Uint64(b)
+	}
+}
+
+// putElement writes the low size bytes of v.
+func putElement(b []byte, order binary.ByteOrder, size int, v uint64) {
+	switch size {
+	case 1:
+		b[0] = byte(v)
+	case 2:
+		order.PutUint16(b, uint16(v))
+	case 4:
+		order.PutUint32(b, uint32(v))
+	default:
+		order.PutUint64(b, v)
+	}
+}
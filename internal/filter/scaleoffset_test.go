@@ -0,0 +1,159 @@
+package filter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// packScaleOffset builds a scale-offset chunk: the 21-byte header followed
+// by each raw offset packed into minbits bits.
+func packScaleOffset(minbits int, minval uint64, raws []uint64) []byte {
+	out := binary.LittleEndian.AppendUint32(nil, uint32(minbits))
+	out = append(out, 8)
+	out = binary.LittleEndian.AppendUint64(out, minval)
+	out = append(out, make([]byte, 8)...)
+
+	w := &bitWriter{}
+	for _, v := range raws {
+		if minbits > 32 {
+			w.write(uint32(v>>32), minbits-32)
+			w.write(uint32(v), 32)
+		} else {
+			w.write(uint32(v), minbits)
+		}
+	}
+	return append(out, w.buf...)
+}
+
+func elementBytes(order binary.ByteOrder, size int, values ...uint64) []byte {
+	out := make([]byte, size*len(values))
+	for i, v := range values {
+		putElement(out[i*size:], order, size, v)
+	}
+	return out
+}
+
+func TestScaleOffsetID(t *testing.T) {
+	f := NewScaleOffset(nil)
+	if f.ID() != message.FilterScaleOffset {
+		t.Errorf("expected ID %d, got %d", message.FilterScaleOffset, f.ID())
+	}
+}
+
+func TestScaleOffsetDecode(t *testing.T) {
+	neg := func(v int64) uint64 { return uint64(v) }
+	f64 := func(v float64) uint64 { return math.Float64bits(v) }
+	f32 := func(v float32) uint64 { return uint64(math.Float32bits(v)) }
+
+	tests := []struct {
+		name  string
+		cd    []uint32
+		input []byte
+		want  []byte
+	}{
+		{
+			name:  "uint16",
+			cd:    []uint32{ScaleOffsetInt, 0, 4, 0, 2, 0, 0, 0},
+			input: packScaleOffset(4, 1000, []uint64{0, 5, 15, 3}),
+			want:  elementBytes(binary.LittleEndian, 2, 1000, 1005, 1015, 1003),
+		},
+		{
+			name:  "int32 negative minimum",
+			cd:    []uint32{ScaleOffsetInt, 0, 3, 0, 4, 1, 0, 0},
+			input: packScaleOffset(7, neg(-50), []uint64{0, 100, 50}),
+			want:  elementBytes(binary.LittleEndian, 4, neg(-50), 50, 0),
+		},
+		{
+			name:  "int32 big endian",
+			cd:    []uint32{ScaleOffsetInt, 0, 2, 0, 4, 1, 1, 0},
+			input: packScaleOffset(9, 70000, []uint64{1, 511}),
+			want:  elementBytes(binary.BigEndian, 4, 70001, 70511),
+		},
+		{
+			name:  "int8 fill value",
+			cd:    []uint32{ScaleOffsetInt, 0, 3, 0, 1, 1, 0, 1, 0xFF},
+			input: packScaleOffset(3, 10, []uint64{7, 1, 2}),
+			want:  []byte{0xFF, 11, 12},
+		},
+		{
+			name:  "int64 wide offsets",
+			cd:    []uint32{ScaleOffsetInt, 0, 2, 0, 8, 0, 0, 0},
+			input: packScaleOffset(40, 5, []uint64{1 << 39, 12345678901}),
+			want:  elementBytes(binary.LittleEndian, 8, 1<<39+5, 12345678906),
+		},
+		{
+			name:  "all equal",
+			cd:    []uint32{ScaleOffsetInt, 0, 3, 0, 4, 1, 0, 0},
+			input: packScaleOffset(0, 42, nil),
+			want:  elementBytes(binary.LittleEndian, 4, 42, 42, 42),
+		},
+		{
+			name: "full precision",
+			cd:   []uint32{ScaleOffsetInt, 0, 2, 0, 4, 1, 1, 0},
+			input: append(packScaleOffset(32, 0, nil),
+				elementBytes(binary.LittleEndian, 4, neg(-7), 1<<31)...),
+			want: elementBytes(binary.BigEndian, 4, neg(-7), 1<<31),
+		},
+		{
+			name:  "float64 D-scale",
+			cd:    []uint32{ScaleOffsetFloatDScale, 2, 3, 1, 8, 1, 0, 0},
+			input: packScaleOffset(8, f64(1.5), []uint64{0, 25, 250}),
+			want:  elementBytes(binary.LittleEndian, 8, f64(1.5), f64(1.75), f64(4)),
+		},
+		{
+			name:  "float32 D-scale with fill value",
+			cd:    []uint32{ScaleOffsetFloatDScale, 1, 4, 1, 4, 1, 0, 1, math.Float32bits(-999)},
+			input: packScaleOffset(6, f32(-2.5), []uint64{0, 5, 30, 63}),
+			want:  elementBytes(binary.LittleEndian, 4, f32(-2.5), f32(-2), f32(0.5), f32(-999)),
+		},
+		{
+			name:  "float64 negative scale factor",
+			cd:    []uint32{ScaleOffsetFloatDScale, uint32(0xFFFFFFFE), 2, 1, 8, 1, 0, 0},
+			input: packScaleOffset(4, f64(100), []uint64{0, 3}),
+			want:  elementBytes(binary.LittleEndian, 8, f64(100), f64(400)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewScaleOffset(tt.cd).Decode(tt.input)
+			if err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScaleOffsetErrors(t *testing.T) {
+	chunk := packScaleOffset(4, 0, []uint64{1, 2, 3, 4})
+
+	tests := []struct {
+		name  string
+		cd    []uint32
+		input []byte
+	}{
+		{"missing parameters", []uint32{ScaleOffsetInt, 0, 4}, chunk},
+		{"E-scale", []uint32{ScaleOffsetFloatEScale, 2, 4, 1, 4, 1, 0, 0}, chunk},
+		{"scale type mismatch", []uint32{ScaleOffsetFloatDScale, 0, 4, 0, 4, 1, 0, 0}, chunk},
+		{"bad size", []uint32{ScaleOffsetInt, 0, 4, 0, 3, 1, 0, 0}, chunk},
+		{"short header", []uint32{ScaleOffsetInt, 0, 4, 0, 4, 1, 0, 0}, chunk[:20]},
+		{"truncated", []uint32{ScaleOffsetInt, 0, 40, 0, 4, 1, 0, 0}, chunk},
+		{"minbits too large", []uint32{ScaleOffsetInt, 0, 4, 0, 1, 1, 0, 0},
+			packScaleOffset(9, 0, []uint64{1, 2, 3, 4})},
+		{"missing fill value", []uint32{ScaleOffsetInt, 0, 4, 0, 4, 1, 0, 1}, chunk},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewScaleOffset(tt.cd).Decode(tt.input); err == nil {
+				t.Error("Decode succeeded")
+			}
+		})
+	}
+}
@@ -1,9 +1,32 @@
 package filter
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/robert-malhotra/go-hdf5/internal/message"
 )
 
+// ErrShuffleElementSize is wrapped by a *ShuffleSizeError.
+var ErrShuffleElementSize = errors.New("shuffle element size differs from datatype size")
+
+// ShuffleSizeError reports a shuffle filter whose element size, from its
+// client data, is not the size of the dataset's datatype. The HDF5 library
+// always writes the datatype size, so the chunks of such a dataset were
+// written by something else and may not unshuffle into its elements.
+type ShuffleSizeError struct {
+	ElementSize  int // From the filter's client data
+	DatatypeSize int
+}
+
+func (e *ShuffleSizeError) Error() string {
+	return fmt.Sprintf("shuffle element size %d differs from datatype size %d", e.ElementSize, e.DatatypeSize)
+}
+
+func (e *ShuffleSizeError) Unwrap() error {
+	return ErrShuffleElementSize
+}
+
 // Shuffle implements the byte shuffle filter.
 // This filter rearranges bytes to improve compression by grouping
 // similar byte positions together (e.g., all MSBs, then all next bytes, etc.).
@@ -28,7 +51,18 @@ func (f *Shuffle) ID() uint16 {
 // Decode reverses the shuffle transformation.
 // Input is organized as: [all byte 0s][all byte 1s]...[all byte N-1s]
 // Output is organized as: [elem0][elem1]...[elemM]
+//
+// As in the HDF5 library, input whose length is not a multiple of the
+// element size is not an error: the bytes past the last whole element were
+// never shuffled, and are left in place at the end. Input shorter than two
+// elements is returned as is.
 func (f *Shuffle) Decode(input []byte) ([]byte, error) {
+	return f.DecodeInto(nil, input, 0)
+}
+
+// DecodeInto reverses the shuffle transformation into dst, which must not
+// overlap input.
+func (f *Shuffle) DecodeInto(dst, input []byte, sizeHint int) ([]byte, error) {
 	if f.elemSize <= 1 {
 		// No shuffling for single-byte elements
 		return input, nil
@@ -41,7 +75,11 @@ func (f *Shuffle) Decode(input []byte) ([]byte, error) {
 		return input, nil
 	}
 
-	output := make([]byte, numBytes)
+	output := dst[:0]
+	if cap(output) < numBytes {
+		output = make([]byte, numBytes)
+	}
+	output = output[:numBytes]
 
 	// Unshuffle: gather bytes from grouped positions into elements
 	for i := 0; i < numElems; i++ {
@@ -50,10 +88,39 @@ func (f *Shuffle) Decode(input []byte) ([]byte, error) {
 			output[i*f.elemSize+j] = input[j*numElems+i]
 		}
 	}
+	copy(output[numElems*f.elemSize:], input[numElems*f.elemSize:])
 
 	return output, nil
 }
 
+// Encode applies the shuffle transformation, the inverse of Decode. Bytes
+// past the last whole element are left in place at the end.
+func (f *Shuffle) Encode(input []byte) ([]byte, error) {
+	if f.elemSize <= 1 {
+		return input, nil
+	}
+
+	numElems := len(input) / f.elemSize
+	if numElems == 0 {
+		return input, nil
+	}
+
+	output := make([]byte, len(input))
+	for i := 0; i < numElems; i++ {
+		for j := 0; j < f.elemSize; j++ {
+			output[j*numElems+i] = input[i*f.elemSize+j]
+		}
+	}
+	copy(output[numElems*f.elemSize:], input[numElems*f.elemSize:])
+
+	return output, nil
+}
+
+// ElementSize returns the size of the elements the filter shuffles.
+func (f *Shuffle) ElementSize() int {
+	return f.elemSize
+}
+
 // SetElementSize sets the element size for the shuffle filter.
 // This is used when the element size is determined after filter creation.
 func (f *Shuffle) SetElementSize(size int) {
@@ -0,0 +1,383 @@
+package filter
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// SZIP option mask bits, as stored in the first client data value.
+const (
+	SzipAllowK13 = 1
+	SzipChip     = 2
+	SzipEC       = 4 // Entropy coding without preprocessing
+	SzipLSB      = 8
+	SzipMSB      = 16
+	SzipNN       = 32 // Nearest neighbor preprocessing
+	SzipRaw      = 128
+)
+
+// errSzipTruncated is returned when the coded stream ends before the
+// expected number of samples has been decoded.
+var errSzipTruncated = errors.New("szip: compressed data truncated")
+
+// Szip implements SZIP decompression: the CCSDS 121.0-B adaptive Rice coder
+// as written by the szip library and by libaec, its free replacement.
+//
+// The chunk starts with the uncompressed size as a little-endian uint32,
+// followed by the coded stream without an SZIP header (HDF5 always sets
+// SzipRaw). 32- and 64-bit pixels are coded as interleaved bytes.
+type Szip struct {
+	optionsMask       uint32
+	pixelsPerBlock    uint32
+	bitsPerPixel      uint32
+	pixelsPerScanline uint32
+	params            int // Number of client data values supplied
+}
+
+// NewSzip creates a new SZIP filter.
+// Client data: [0] = options mask, [1] = pixels per block,
+// [2] = bits per pixel, [3] = pixels per scanline
+func NewSzip(clientData []uint32) *Szip {
+	f := &Szip{params: len(clientData)}
+	if len(clientData) >= 4 {
+		f.optionsMask = clientData[0]
+		f.pixelsPerBlock = clientData[1]
+		f.bitsPerPixel = clientData[2]
+		f.pixelsPerScanline = clientData[3]
+	}
+	return f
+}
+
+func (f *Szip) ID() uint16 {
+	return message.FilterSZIP
+}
+
+// Decode decompresses one SZIP-coded chunk.
+func (f *Szip) Decode(input []byte) ([]byte, error) {
+	if f.params < 4 {
+		return nil, fmt.Errorf("szip: need 4 client data values, got %d", f.params)
+	}
+	switch f.pixelsPerBlock {
+	case 8, 16, 32, 64:
+	default:
+		return nil, fmt.Errorf("szip: unsupported pixels per block %d", f.pixelsPerBlock)
+	}
+	bpp := f.bitsPerPixel
+	if bpp == 0 || (bpp > 24 && bpp != 32 && bpp != 64) {
+		return nil, fmt.Errorf("szip: unsupported bits per pixel %d", bpp)
+	}
+	if f.pixelsPerScanline == 0 {
+		return nil, fmt.Errorf("szip: pixels per scanline is zero")
+	}
+	if len(input) < 4 {
+		return nil, errSzipTruncated
+	}
+	size := int(binary.LittleEndian.Uint32(input))
+
+	// Wide pixels are coded as bytes, interleaved so that byte i of every
+	// pixel is grouped together (the same layout as the shuffle filter)
+	width := int(bpp)
+	interleave := bpp == 32 || bpp == 64
+	if interleave {
+		width = 8
+	}
+	sampleBytes := szipSampleBytes(width)
+
+	blockSize := int(f.pixelsPerBlock)
+	scanline := int(f.pixelsPerScanline)
+	rsi := (scanline + blockSize - 1) / blockSize
+	numSamples := size / sampleBytes
+
+	// Scanlines that are not a whole number of blocks are padded to one
+	// reference sample interval each
+	pad := scanline%blockSize != 0
+	total := numSamples
+	if pad {
+		scanlines := (numSamples + scanline - 1) / scanline
+		total = scanlines * rsi * blockSize
+	}
+
+	preprocess := f.optionsMask&SzipNN != 0
+	samples, err := decodeRice(input[4:], width, blockSize, rsi, preprocess, total)
+	if err != nil {
+		return nil, err
+	}
+	if preprocess {
+		unmapResiduals(samples, width, rsi*blockSize)
+	}
+	if pad {
+		samples = removeScanlinePadding(samples, scanline, rsi*blockSize)
+	}
+
+	output := make([]byte, size)
+	bigEndian := f.optionsMask&SzipMSB != 0
+	for i := 0; i < numSamples; i++ {
+		b := output[i*sampleBytes : (i+1)*sampleBytes]
+		switch {
+		case sampleBytes == 1:
+			b[0] = byte(samples[i])
+		case sampleBytes == 2 && bigEndian:
+			binary.BigEndian.PutUint16(b, uint16(samples[i]))
+		case sampleBytes == 2:
+			binary.LittleEndian.PutUint16(b, uint16(samples[i]))
+		case bigEndian:
+			binary.BigEndian.PutUint32(b, samples[i])
+		default:
+			binary.LittleEndian.PutUint32(b, samples[i])
+		}
+	}
+
+	if interleave {
+		return NewShuffle([]uint32{bpp / 8}).Decode(output)
+	}
+	return output, nil
+}
+
+// szipSampleBytes returns the storage size of a sample of the given width.
+// Without the 3-byte option, which HDF5 never sets, 17-24 bit samples
+// occupy four bytes.
+func szipSampleBytes(width int) int {
+	switch {
+	case width > 16:
+		return 4
+	case width > 8:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// szipROS is the zero block count that stands for "to the end of the
+// segment or reference sample interval".
+const szipROS = 5
+
+// decodeRice decodes at least n samples from a CCSDS 121.0-B coded stream
+// and returns the first n. With preprocessing the samples are mapped
+// residuals, except the first of each reference sample interval, which is
+// the raw reference value.
+func decodeRice(input []byte, width, blockSize, rsi int, preprocess bool, n int) ([]uint32, error) {
+	idLen := 3
+	switch {
+	case width > 16:
+		idLen = 5
+	case width > 8:
+		idLen = 4
+	}
+	uncompressed := uint32(1)<<idLen - 1
+	rsiSamples := rsi * blockSize
+
+	br := &bitReader{data: input}
+	out := make([]uint32, 0, n+rsiSamples)
+	for len(out) < n {
+		used := len(out) % rsiSamples
+		ref := 0
+		if preprocess && used == 0 {
+			ref = 1
+		}
+
+		id, err := br.read(idLen)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case id == 0:
+			// Low entropy: one bit selects zero blocks or second extension
+			secondExt, err := br.read(1)
+			if err != nil {
+				return nil, err
+			}
+			if ref == 1 {
+				s, err := br.read(width)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, s)
+			}
+
+			if secondExt == 1 {
+				for i := ref; i < blockSize; {
+					m, err := br.readFS()
+					if err != nil {
+						return nil, err
+					}
+					if m > 90 {
+						return nil, fmt.Errorf("szip: invalid second extension code %d", m)
+					}
+					// m = s(s+1)/2 + second, with s the sum of the pair
+					s := uint32(0)
+					for (s+1)*(s+2)/2 <= m {
+						s++
+					}
+					second := m - s*(s+1)/2
+					if i%2 == 0 {
+						out = append(out, s-second)
+						i++
+					}
+					out = append(out, second)
+					i++
+				}
+				continue
+			}
+
+			fs, err := br.readFS()
+			if err != nil {
+				return nil, err
+			}
+			blocks := int(fs) + 1
+			b := used / blockSize
+			if blocks == szipROS {
+				blocks = min(rsi-b, 64-b%64)
+			} else if blocks > szipROS {
+				blocks--
+			}
+			if b+blocks > rsi {
+				return nil, fmt.Errorf("szip: zero block run of %d overflows reference sample interval", blocks)
+			}
+			for i := blocks*blockSize - ref; i > 0; i-- {
+				out = append(out, 0)
+			}
+
+		case id == uncompressed:
+			for i := 0; i < blockSize; i++ {
+				s, err := br.read(width)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, s)
+			}
+
+		default:
+			// Split sample: all fundamental sequence codes for the high
+			// bits, then the k low bits of every sample
+			k := int(id) - 1
+			if ref == 1 {
+				s, err := br.read(width)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, s)
+			}
+			start := len(out)
+			for i := ref; i < blockSize; i++ {
+				fs, err := br.readFS()
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, fs<<k)
+			}
+			if k > 0 {
+				for i := start; i < len(out); i++ {
+					low, err := br.read(k)
+					if err != nil {
+						return nil, err
+					}
+					out[i] |= low
+				}
+			}
+		}
+	}
+	return out[:n], nil
+}
+
+// unmapResiduals reverses nearest neighbor preprocessing in place. Each
+// reference sample interval starts with a raw value that predicts the next.
+func unmapResiduals(samples []uint32, width, rsiSamples int) {
+	xmax := uint32(1)<<width - 1
+	med := xmax/2 + 1
+
+	var x uint32
+	for i, d := range samples {
+		if i%rsiSamples == 0 {
+			x = d
+			continue
+		}
+		// theta is the distance from x to the nearer end of the range
+		mask := uint32(0)
+		if x&med != 0 {
+			mask = xmax
+		}
+		if (d+1)/2 <= mask^x {
+			if d&1 == 0 {
+				x += d / 2
+			} else {
+				x -= (d + 1) / 2
+			}
+		} else {
+			x = mask ^ d
+		}
+		samples[i] = x
+	}
+}
+
+// removeScanlinePadding keeps the first scanline samples of every padded
+// line of lineSamples samples.
+func removeScanlinePadding(samples []uint32, scanline, lineSamples int) []uint32 {
+	out := samples[:0]
+	for i := 0; i < len(samples); i += lineSamples {
+		out = append(out, samples[i:min(i+scanline, len(samples))]...)
+	}
+	return out
+}
+
+// bitReader reads a most-significant-bit-first bit stream.
+type bitReader struct {
+	data  []byte
+	pos   int    // Next byte of data to load
+	acc   uint64 // Unread bits, left-aligned
+	nbits int    // Number of valid bits in acc
+}
+
+// fill loads whole bytes into the accumulator.
+func (br *bitReader) fill() {
+	for br.nbits <= 56 && br.pos < len(br.data) {
+		br.acc |= uint64(br.data[br.pos]) << (56 - br.nbits)
+		br.pos++
+		br.nbits += 8
+	}
+}
+
+// read returns the next n bits, 0 <= n <= 32.
+func (br *bitReader) read(n int) (uint32, error) {
+	if n == 0 {
+		return 0, nil
+	}
+	if br.nbits < n {
+		br.fill()
+		if br.nbits < n {
+			return 0, errSzipTruncated
+		}
+	}
+	v := uint32(br.acc >> (64 - n))
+	br.acc <<= n
+	br.nbits -= n
+	return v, nil
+}
+
+// readFS reads a fundamental sequence code: the number of zero bits before
+// the next one bit.
+func (br *bitReader) readFS() (uint32, error) {
+	var zeros uint32
+	for {
+		if br.nbits == 0 {
+			br.fill()
+			if br.nbits == 0 {
+				return 0, errSzipTruncated
+			}
+		}
+		if br.acc == 0 {
+			zeros += uint32(br.nbits)
+			br.nbits = 0
+			continue
+		}
+		lz := bits.LeadingZeros64(br.acc)
+		zeros += uint32(lz)
+		br.acc <<= lz + 1
+		br.nbits -= lz + 1
+		return zeros, nil
+	}
+}
@@ -0,0 +1,400 @@
+package filter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// bitWriter writes a most-significant-bit-first bit stream.
+type bitWriter struct {
+	buf   []byte
+	nbits int
+}
+
+func (w *bitWriter) write(v uint32, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if w.nbits%8 == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		if v>>i&1 != 0 {
+			w.buf[len(w.buf)-1] |= 0x80 >> (w.nbits % 8)
+		}
+		w.nbits++
+	}
+}
+
+func (w *bitWriter) writeFS(v uint32) {
+	for ; v > 0; v-- {
+		w.write(0, 1)
+	}
+	w.write(1, 1)
+}
+
+// szipEncode is a reference encoder for the tests. It follows libaec's
+// SZ_BufftoBuffCompress, choosing the cheapest coding option per block, and
+// pads incomplete intervals with the last sample as libaec does.
+func szipEncode(data []byte, mask, ppb, bpp, pps uint32) []byte {
+	width := int(bpp)
+	if bpp == 32 || bpp == 64 {
+		width = 8
+		interleaved := make([]byte, len(data))
+		words := len(data) / int(bpp/8)
+		for i := 0; i < words; i++ {
+			for j := 0; j < int(bpp/8); j++ {
+				interleaved[j*words+i] = data[i*int(bpp/8)+j]
+			}
+		}
+		data = interleaved
+	}
+	sampleBytes := szipSampleBytes(width)
+	samples := make([]uint32, len(data)/sampleBytes)
+	for i := range samples {
+		b := data[i*sampleBytes:]
+		switch {
+		case sampleBytes == 1:
+			samples[i] = uint32(b[0])
+		case sampleBytes == 2 && mask&SzipMSB != 0:
+			samples[i] = uint32(binary.BigEndian.Uint16(b))
+		case sampleBytes == 2:
+			samples[i] = uint32(binary.LittleEndian.Uint16(b))
+		case mask&SzipMSB != 0:
+			samples[i] = binary.BigEndian.Uint32(b)
+		default:
+			samples[i] = binary.LittleEndian.Uint32(b)
+		}
+	}
+
+	blockSize := int(ppb)
+	rsi := (int(pps) + blockSize - 1) / blockSize
+	rsiSamples := rsi * blockSize
+	padTo := func(s []uint32, n int) []uint32 {
+		for len(s)%n != 0 {
+			s = append(s, s[len(s)-1])
+		}
+		return s
+	}
+	if int(pps)%blockSize != 0 {
+		var padded []uint32
+		for i := 0; i < len(samples); i += int(pps) {
+			line := append([]uint32(nil), samples[i:min(i+int(pps), len(samples))]...)
+			padded = append(padded, padTo(line, rsiSamples)...)
+		}
+		samples = padded
+	} else {
+		samples = padTo(samples, rsiSamples)
+	}
+
+	idLen := 3
+	switch {
+	case width > 16:
+		idLen = 5
+	case width > 8:
+		idLen = 4
+	}
+	uncompressed := uint32(1)<<idLen - 1
+	xmax := uint32(1)<<width - 1
+
+	w := &bitWriter{}
+	for r := 0; r < len(samples); r += rsiSamples {
+		values := append([]uint32(nil), samples[r:r+rsiSamples]...)
+		preprocess := mask&SzipNN != 0
+		if preprocess {
+			for i := len(values) - 1; i > 0; i-- {
+				x, prev := values[i], values[i-1]
+				theta := min(prev, xmax-prev)
+				switch {
+				case x >= prev && x-prev <= theta:
+					values[i] = 2 * (x - prev)
+				case x < prev && prev-x <= theta:
+					values[i] = 2*(prev-x) - 1
+				case x >= prev:
+					values[i] = theta + x - prev
+				default:
+					values[i] = theta + prev - x
+				}
+			}
+		}
+
+		for b := 0; b < rsi; {
+			block := values[b*blockSize : (b+1)*blockSize]
+			ref := 0
+			if preprocess && b == 0 {
+				ref = 1
+			}
+			rest := block[ref:]
+
+			if allZero(rest) {
+				// Zero run, ending at the interval or the 64-block segment
+				end := min(rsi, (b/64+1)*64)
+				run := 1
+				for b+run < end && allZero(values[(b+run)*blockSize:(b+run+1)*blockSize]) {
+					run++
+				}
+				w.write(0, idLen)
+				w.write(0, 1)
+				if ref == 1 {
+					w.write(block[0], width)
+				}
+				switch {
+				case b+run == end && run >= szipROS:
+					w.writeFS(szipROS - 1)
+				case run >= szipROS:
+					w.writeFS(uint32(run))
+				default:
+					w.writeFS(uint32(run - 1))
+				}
+				b += run
+				continue
+			}
+
+			// Second extension pairs, with a zero standing in for the
+			// reference sample
+			pairs := append([]uint32(nil), block...)
+			if ref == 1 {
+				pairs[0] = 0
+			}
+			seBits, seOK := 0, true
+			for i := 0; i < blockSize; i += 2 {
+				s := pairs[i] + pairs[i+1]
+				m := s*(s+1)/2 + pairs[i+1]
+				if s > 12 || m > 90 {
+					seOK = false
+					break
+				}
+				seBits += int(m) + 1
+			}
+
+			bestK, bestBits := -1, blockSize*width
+			for k := 0; k <= int(uncompressed)-2; k++ {
+				n := 0
+				for _, v := range rest {
+					n += int(v>>k) + 1 + k
+				}
+				if n < bestBits {
+					bestK, bestBits = k, n
+				}
+			}
+
+			switch {
+			case seOK && seBits < bestBits:
+				w.write(0, idLen)
+				w.write(1, 1)
+				if ref == 1 {
+					w.write(block[0], width)
+				}
+				for i := 0; i < blockSize; i += 2 {
+					s := pairs[i] + pairs[i+1]
+					w.writeFS(s*(s+1)/2 + pairs[i+1])
+				}
+			case bestK >= 0:
+				w.write(uint32(bestK+1), idLen)
+				if ref == 1 {
+					w.write(block[0], width)
+				}
+				for _, v := range rest {
+					w.writeFS(v >> bestK)
+				}
+				for _, v := range rest {
+					w.write(v&(1<<bestK-1), bestK)
+				}
+			default:
+				w.write(uncompressed, idLen)
+				for _, v := range block {
+					w.write(v, width)
+				}
+			}
+			b++
+		}
+	}
+
+	out := binary.LittleEndian.AppendUint32(nil, uint32(len(data)))
+	return append(out, w.buf...)
+}
+
+func allZero(values []uint32) bool {
+	for _, v := range values {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSzipID(t *testing.T) {
+	f := NewSzip(nil)
+	if f.ID() != message.FilterSZIP {
+		t.Errorf("expected ID %d, got %d", message.FilterSZIP, f.ID())
+	}
+}
+
+// Hand-coded streams, one per coding option, with a four byte size prefix
+func TestSzipDecodeVectors(t *testing.T) {
+	tests := []struct {
+		name  string
+		cd    []uint32
+		input []byte
+		want  []byte
+	}{
+		{
+			// id 010 (k=1), reference 00001010, seven FS codes 01, seven low bits 0
+			name:  "split with reference",
+			cd:    []uint32{SzipNN, 8, 8, 8},
+			input: []byte{8, 0, 0, 0, 0x41, 0x4A, 0xAA, 0x80},
+			want:  []byte{10, 11, 12, 13, 14, 15, 16, 17},
+		},
+		{
+			// id 000, zero block selector 0, FS 00001 (to end of interval)
+			name:  "zero blocks",
+			cd:    []uint32{SzipEC, 8, 8, 32},
+			input: []byte{32, 0, 0, 0, 0x00, 0x80},
+			want:  make([]byte, 32),
+		},
+		{
+			// id 000, second extension selector 1, codes 2, 0, 4, 0
+			name:  "second extension",
+			cd:    []uint32{SzipEC, 8, 8, 8},
+			input: []byte{8, 0, 0, 0, 0x13, 0x0C},
+			want:  []byte{0, 1, 0, 0, 1, 1, 0, 0},
+		},
+		{
+			// id 111, eight raw samples
+			name: "uncompressed",
+			cd:   []uint32{SzipEC, 8, 8, 8},
+			input: []byte{8, 0, 0, 0,
+				0xE0, 0x20, 0x40, 0x60, 0x80, 0xA0, 0xC0, 0xE1, 0x00},
+			want: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewSzip(tt.cd).Decode(tt.input)
+			if err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSzipRoundtrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	// Zero runs, small residuals, smooth ramps and noise in one buffer
+	smooth := func(n, size int, order binary.ByteOrder) []byte {
+		data := make([]byte, n*size)
+		for i := 0; i < n; i++ {
+			var v uint64
+			switch {
+			case i < n/4:
+				v = 0
+			case i < n/2:
+				v = uint64(rng.Intn(2))
+			case i < 3*n/4:
+				v = uint64(i * 3)
+			default:
+				v = rng.Uint64()
+			}
+			switch size {
+			case 1:
+				data[i] = byte(v)
+			case 2:
+				order.PutUint16(data[i*2:], uint16(v))
+			case 4:
+				order.PutUint32(data[i*4:], uint32(v))
+			case 8:
+				order.PutUint64(data[i*8:], v)
+			}
+		}
+		return data
+	}
+	floats := make([]byte, 4*1000)
+	for i := 0; i < 1000; i++ {
+		v := float32(math.Sin(float64(i) / 50))
+		binary.LittleEndian.PutUint32(floats[i*4:], math.Float32bits(v))
+	}
+	masked := func(data []byte, bits uint) []byte {
+		for i := 0; i+1 < len(data); i += 2 {
+			v := binary.LittleEndian.Uint16(data[i:]) & (1<<bits - 1)
+			binary.LittleEndian.PutUint16(data[i:], v)
+		}
+		return data
+	}
+	masked24 := smooth(700, 4, binary.LittleEndian)
+	for i := 0; i < len(masked24); i += 4 {
+		masked24[i+3] = 0
+	}
+
+	tests := []struct {
+		name               string
+		data               []byte
+		mask, ppb, bpp, ps uint32
+	}{
+		{"uint8 NN", smooth(1000, 1, nil), SzipNN, 8, 8, 64},
+		{"uint8 EC", smooth(1000, 1, nil), SzipEC, 16, 8, 1024},
+		{"uint16 NN padded", smooth(1000, 2, binary.LittleEndian), SzipNN | SzipLSB, 32, 16, 100},
+		{"uint16 EC big endian", smooth(1000, 2, binary.BigEndian), SzipEC | SzipMSB, 16, 16, 160},
+		{"12 bit", masked(smooth(500, 2, binary.LittleEndian), 12), SzipNN | SzipLSB, 8, 12, 40},
+		{"24 bit", masked24, SzipNN | SzipLSB, 32, 24, 128},
+		{"float32 NN", floats, SzipNN | SzipLSB, 32, 32, 100},
+		{"uint64 EC", smooth(300, 8, binary.LittleEndian), SzipEC | SzipLSB, 16, 64, 16},
+		{"long zero run", make([]byte, 10000), SzipNN, 8, 8, 8000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := szipEncode(tt.data, tt.mask, tt.ppb, tt.bpp, tt.ps)
+			got, err := NewSzip([]uint32{tt.mask, tt.ppb, tt.bpp, tt.ps}).Decode(encoded)
+			if err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			if !bytes.Equal(got, tt.data) {
+				for i := range got {
+					if got[i] != tt.data[i] {
+						t.Fatalf("first difference at byte %d: got %d, want %d", i, got[i], tt.data[i])
+					}
+				}
+				t.Fatalf("got %d bytes, want %d", len(got), len(tt.data))
+			}
+		})
+	}
+}
+
+func TestSzipErrors(t *testing.T) {
+	data := make([]byte, 256)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	encoded := szipEncode(data, SzipNN, 16, 8, 64)
+
+	tests := []struct {
+		name  string
+		cd    []uint32
+		input []byte
+	}{
+		{"missing parameters", []uint32{SzipNN, 16}, encoded},
+		{"bad block size", []uint32{SzipNN, 10, 8, 64}, encoded},
+		{"bad pixel width", []uint32{SzipNN, 16, 48, 64}, encoded},
+		{"no size", []uint32{SzipNN, 16, 8, 64}, encoded[:3]},
+		{"truncated", []uint32{SzipNN, 16, 8, 64}, encoded[:len(encoded)/2]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSzip(tt.cd).Decode(tt.input); err == nil {
+				t.Error("Decode succeeded")
+			}
+		})
+	}
+
+	if _, err := NewSzip([]uint32{SzipNN, 16, 8, 64}).Decode(encoded[:6]); !errors.Is(err, errSzipTruncated) {
+		t.Errorf("err = %v, want errSzipTruncated", err)
+	}
+}
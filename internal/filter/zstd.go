@@ -0,0 +1,81 @@
+package filter
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// Zstd implements the Zstandard filter (ID 32015), as written by the HDF5
+// plugin of that name and by hdf5plugin for Python.
+type Zstd struct {
+	level int
+}
+
+// NewZstd creates a new Zstandard filter.
+// Client data: [0] = compression level (optional, default 3). Decoding
+// does not use it, so other or missing client data still decodes.
+func NewZstd(clientData []uint32) *Zstd {
+	level := 3
+	if len(clientData) > 0 {
+		level = int(int32(clientData[0])) // Negative levels are "fast" modes
+	}
+	return &Zstd{level: level}
+}
+
+func (f *Zstd) ID() uint16 {
+	return message.FilterZstd
+}
+
+// zstdDecoder is shared by all Zstd filters: DecodeAll can be called from
+// many goroutines at once.
+var zstdDecoder = sync.OnceValues(func() (*zstd.Decoder, error) {
+	return zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+})
+
+// Decode decompresses input; see DecodeInto.
+func (f *Zstd) Decode(input []byte) ([]byte, error) {
+	return f.DecodeInto(nil, input, 0)
+}
+
+// DecodeInto decompresses the Zstandard frames of input into dst. With a
+// sizeHint of the decompressed size, a dst of that capacity is filled
+// without allocating.
+func (f *Zstd) DecodeInto(dst, input []byte, sizeHint int) ([]byte, error) {
+	dec, err := zstdDecoder()
+	if err != nil {
+		return nil, fmt.Errorf("zstd: %w", err)
+	}
+	if cap(dst) < sizeHint {
+		dst = make([]byte, 0, sizeHint)
+	}
+	out, err := dec.DecodeAll(input, dst[:0])
+	if err != nil {
+		return nil, fmt.Errorf("zstd: %w", err)
+	}
+	return out, nil
+}
+
+// zstdEncoders holds an encoder for each of the speeds levels map to,
+// created when first used.
+var zstdEncoders [zstd.SpeedBestCompression + 1]struct {
+	once sync.Once
+	enc  *zstd.Encoder
+	err  error
+}
+
+// Encode compresses input into one Zstandard frame recording its size.
+// Levels are mapped to the nearest speed the pure Go encoder offers.
+func (f *Zstd) Encode(input []byte) ([]byte, error) {
+	speed := zstd.EncoderLevelFromZstd(f.level)
+	e := &zstdEncoders[speed]
+	e.once.Do(func() {
+		e.enc, e.err = zstd.NewWriter(nil, zstd.WithEncoderLevel(speed))
+	})
+	if e.err != nil {
+		return nil, fmt.Errorf("zstd: %w", e.err)
+	}
+	return e.enc.EncodeAll(input, nil), nil
+}
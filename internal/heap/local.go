@@ -62,6 +62,12 @@ func ReadLocalHeap(r *binary.Reader, address uint64) (*LocalHeap, error) {
 		return nil, err
 	}
 
+	// With checksum verification, which stands in for the checksum local
+	// heaps lack, the free list must start in the data segment or be empty
+	if r.VerifyChecksums() && freeOffset >= dataSize && freeOffset != freeListNull && !r.IsUndefinedLength(freeOffset) {
+		return nil, fmt.Errorf("HEAP at %d: free list offset %d is outside the %d-byte data segment", address, freeOffset, dataSize)
+	}
+
 	heap := &LocalHeap{
 		DataSize:    dataSize,
 		FreeOffset:  freeOffset,
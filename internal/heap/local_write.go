@@ -0,0 +1,51 @@
+package heap
+
+import (
+	"github.com/robert-malhotra/go-hdf5/internal/binary"
+)
+
+// freeListNull is the offset to the head of the free list of a local heap
+// without free space, as the HDF5 library writes it.
+const freeListNull = 1
+
+// WriteLocalHeap writes a local heap holding the given null-terminated
+// strings, as v1 groups keep the names of their members, and returns its
+// address and the offset of each string in its data segment. The data
+// segment starts with an empty string at offset 0, the name of the first
+// key of a group B-tree, and follows the header; strings are padded to 8
+// bytes and the heap has no free space.
+func WriteLocalHeap(w *binary.Writer, allocate func(size int64) uint64, strings []string) (uint64, []uint64, error) {
+	data := make([]byte, 8)
+	offsets := make([]uint64, len(strings))
+	for i, s := range strings {
+		offsets[i] = uint64(len(data))
+		padded := make([]byte, (len(s)+8)&^7)
+		copy(padded, s)
+		data = append(data, padded...)
+	}
+
+	// Signature, version, reserved bytes, data segment size, offset to the
+	// head of the free list and data segment address
+	headerSize := 8 + 2*w.LengthSize() + w.OffsetSize()
+	addr := allocate(int64(headerSize + len(data)))
+	hw := w.At(int64(addr))
+	if err := hw.WriteBytes(localHeapSignature); err != nil {
+		return 0, nil, err
+	}
+	if err := hw.WriteZeros(4); err != nil {
+		return 0, nil, err
+	}
+	if err := hw.WriteLength(uint64(len(data))); err != nil {
+		return 0, nil, err
+	}
+	if err := hw.WriteLength(freeListNull); err != nil {
+		return 0, nil, err
+	}
+	if err := hw.WriteOffset(addr + uint64(headerSize)); err != nil {
+		return 0, nil, err
+	}
+	if err := hw.WriteBytes(data); err != nil {
+		return 0, nil, err
+	}
+	return addr, offsets, nil
+}
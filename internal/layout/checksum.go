@@ -0,0 +1,65 @@
+package layout
+
+import (
+	"fmt"
+)
+
+// ChecksumPolicy says how reads treat chunks whose checksum filter finds
+// that the stored checksum does not match the data.
+type ChecksumPolicy int
+
+const (
+	// ChecksumStrict fails the read with the chunk's *ChunkError.
+	ChecksumStrict ChecksumPolicy = iota
+
+	// ChecksumSkip reads the chunk as the fill value and records it, for
+	// SkippedChunks.
+	ChecksumSkip
+
+	// ChecksumIgnore uses the chunk's data without checking the checksum.
+	ChecksumIgnore
+)
+
+// ChunkError reports a chunk that could not be decoded.
+type ChunkError struct {
+	Offset  []uint64 // Coordinates of the chunk's first element
+	Address uint64   // File address of the chunk
+	Err     error    // The filter's error
+}
+
+func (e *ChunkError) Error() string {
+	return fmt.Sprintf("decoding chunk at offset %v (address %d): %v", e.Offset, e.Address, e.Err)
+}
+
+func (e *ChunkError) Unwrap() error {
+	return e.Err
+}
+
+// SetChecksumPolicy sets how reads treat chunks failing their checksum. It
+// must be called before the first read; the default is ChecksumStrict.
+func (c *Chunked) SetChecksumPolicy(policy ChecksumPolicy) {
+	c.checksumPolicy = policy
+	if c.pipeline != nil {
+		c.pipeline.SetVerifyChecksums(policy != ChecksumIgnore)
+	}
+}
+
+// SkippedChunks returns the chunks reads have taken as the fill value under
+// ChecksumSkip, each once, in the order they were found.
+func (c *Chunked) SkippedChunks() []*ChunkError {
+	c.skippedMu.Lock()
+	defer c.skippedMu.Unlock()
+	return append([]*ChunkError(nil), c.skipped...)
+}
+
+// skip records a chunk read as the fill value.
+func (c *Chunked) skip(chunkErr *ChunkError) {
+	c.skippedMu.Lock()
+	defer c.skippedMu.Unlock()
+	for _, e := range c.skipped {
+		if e.Address == chunkErr.Address {
+			return
+		}
+	}
+	c.skipped = append(c.skipped, chunkErr)
+}
@@ -1,7 +1,11 @@
 package layout
 
 import (
+	"fmt"
+	"math/bits"
+
 	"github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/filter"
 )
 
 // ChunkWriter handles writing chunked dataset data and indices.
@@ -10,6 +14,7 @@ type ChunkWriter struct {
 	chunkDims    []uint32
 	elementSize  uint32
 	filterMask   uint32 // 0 = all filters applied
+	pipeline     *filter.Pipeline
 	allocator    func(size int64) uint64
 }
 
@@ -24,6 +29,30 @@ func NewChunkWriter(w *binary.Writer, chunkDims []uint32, elementSize uint32, al
 	}
 }
 
+// SetPipeline sets the filters that WriteChunks applies to each chunk. The
+// chunk indexes written afterwards record the stored size of each chunk.
+func (cw *ChunkWriter) SetPipeline(p *filter.Pipeline) {
+	cw.pipeline = p
+}
+
+// Filtered reports whether chunks are written through a filter pipeline.
+func (cw *ChunkWriter) Filtered() bool {
+	return cw.pipeline != nil && !cw.pipeline.Empty()
+}
+
+// FilterMask returns the filter mask recorded for each written chunk.
+func (cw *ChunkWriter) FilterMask() uint32 {
+	return cw.filterMask
+}
+
+// SetFilterMask sets the filter mask recorded for each written chunk, and
+// the filters WriteChunks skips: bit i set skips filter i of the pipeline.
+// Chunks copied as stored from another dataset keep the mask they were
+// written with.
+func (cw *ChunkWriter) SetFilterMask(mask uint32) {
+	cw.filterMask = mask
+}
+
 // ChunkSize returns the size in bytes of one chunk.
 func (cw *ChunkWriter) ChunkSize() uint64 {
 	size := uint64(cw.elementSize)
@@ -50,7 +79,7 @@ func (cw *ChunkWriter) WriteSingleChunk(data []byte) (uint64, error) {
 
 // WriteSingleChunkIndex writes a single chunk index structure.
 // Returns the address of the index.
-func (cw *ChunkWriter) WriteSingleChunkIndex(chunkAddr uint64, chunkSize uint32) (uint64, error) {
+func (cw *ChunkWriter) WriteSingleChunkIndex(chunkAddr uint64, chunkSize uint64) (uint64, error) {
 	// Single Chunk Index format (for layout version 4, chunk index type 0):
 	// - Filtered chunk size (if filters present): Length size bytes
 	// - Filter mask (if filters present): 4 bytes
@@ -80,15 +109,26 @@ type FixedArrayHeader struct {
 }
 
 // WriteFixedArrayIndex writes a fixed array chunk index.
-// chunkAddrs contains the address of each chunk in storage order.
-func (cw *ChunkWriter) WriteFixedArrayIndex(chunkAddrs []uint64, chunkSizes []uint32) (uint64, error) {
+// chunkAddrs contains the address of each chunk in storage order, and
+// chunkSizes their stored sizes, which are recorded for filtered chunks.
+func (cw *ChunkWriter) WriteFixedArrayIndex(chunkAddrs []uint64, chunkSizes []uint64) (uint64, error) {
 	numChunks := len(chunkAddrs)
 	if numChunks == 0 {
 		return 0, nil
 	}
+	filtered := cw.Filtered()
+	if filtered && len(chunkSizes) != numChunks {
+		return 0, fmt.Errorf("%d chunk sizes for %d filtered chunks", len(chunkSizes), numChunks)
+	}
 
-	// For non-filtered chunks, entry size = offset size
+	// For non-filtered chunks, entry size = offset size. Filtered chunks
+	// add the chunk size and filter mask.
 	entrySize := cw.w.OffsetSize()
+	clientID := uint8(0)
+	if filtered {
+		entrySize += cw.chunkSizeLen() + 4
+		clientID = 1
+	}
 	offsetSize := cw.w.OffsetSize()
 	lengthSize := cw.w.LengthSize()
 
@@ -122,8 +162,8 @@ func (cw *ChunkWriter) WriteFixedArrayIndex(chunkAddrs []uint64, chunkSizes []ui
 	fadbData[idx] = 0
 	idx++
 
-	// Client ID (0 = non-filtered chunks)
-	fadbData[idx] = 0
+	// Client ID (0 = non-filtered chunks, 1 = filtered chunks)
+	fadbData[idx] = clientID
 	idx++
 
 	// Header address
@@ -131,13 +171,19 @@ func (cw *ChunkWriter) WriteFixedArrayIndex(chunkAddrs []uint64, chunkSizes []ui
 	idx += offsetSize
 
 	// Write each chunk address (the element entries)
-	for _, addr := range chunkAddrs {
+	for i, addr := range chunkAddrs {
 		putUint64LE(fadbData[idx:], addr, offsetSize)
 		idx += offsetSize
+		if filtered {
+			putUint64LE(fadbData[idx:], uint64(chunkSizes[i]), cw.chunkSizeLen())
+			idx += cw.chunkSizeLen()
+			putUint32LE(fadbData[idx:], cw.filterMask)
+			idx += 4
+		}
 	}
 
 	// Compute and add checksum
-	fadbChecksum := binary.Lookup3Checksum(fadbData[:idx])
+	fadbChecksum := binary.ChecksumLookup3(fadbData[:idx], 0)
 	putUint32LE(fadbData[idx:], fadbChecksum)
 	idx += 4
 
@@ -159,8 +205,8 @@ func (cw *ChunkWriter) WriteFixedArrayIndex(chunkAddrs []uint64, chunkSizes []ui
 	fahdData[idx] = 0
 	idx++
 
-	// Client ID (0 = non-filtered chunks)
-	fahdData[idx] = 0
+	// Client ID (0 = non-filtered chunks, 1 = filtered chunks)
+	fahdData[idx] = clientID
 	idx++
 
 	// Entry size
@@ -180,7 +226,7 @@ func (cw *ChunkWriter) WriteFixedArrayIndex(chunkAddrs []uint64, chunkSizes []ui
 	idx += offsetSize
 
 	// Compute and add checksum
-	fahdChecksum := binary.Lookup3Checksum(fahdData[:idx])
+	fahdChecksum := binary.ChecksumLookup3(fahdData[:idx], 0)
 	putUint32LE(fahdData[idx:], fahdChecksum)
 	idx += 4
 
@@ -193,187 +239,43 @@ func (cw *ChunkWriter) WriteFixedArrayIndex(chunkAddrs []uint64, chunkSizes []ui
 	return headerAddr, nil
 }
 
-// WriteChunks writes multiple chunks and returns their addresses.
-func (cw *ChunkWriter) WriteChunks(chunks [][]byte) ([]uint64, error) {
+// WriteChunks writes multiple chunks, encoding each through the filter
+// pipeline if one is set, and returns their addresses and stored sizes.
+func (cw *ChunkWriter) WriteChunks(chunks [][]byte) ([]uint64, []uint64, error) {
 	addrs := make([]uint64, len(chunks))
+	sizes := make([]uint64, len(chunks))
 
 	for i, chunk := range chunks {
+		if cw.Filtered() {
+			encoded, err := cw.pipeline.Encode(chunk, cw.filterMask)
+			if err != nil {
+				return nil, nil, fmt.Errorf("encoding chunk %d: %w", i, err)
+			}
+			if n := cw.chunkSizeLen(); n < 8 && uint64(len(encoded)) >= 1<<(8*n) {
+				return nil, nil, fmt.Errorf("chunk %d is %d bytes encoded, too large for a %d-byte index field", i, len(encoded), n)
+			}
+			chunk = encoded
+		}
 		addr, err := cw.WriteSingleChunk(chunk)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		addrs[i] = addr
+		sizes[i] = uint64(len(chunk))
 	}
 
-	return addrs, nil
+	return addrs, sizes, nil
 }
 
-// WriteExtensibleArrayIndex writes an extensible array chunk index.
-// This is the format that h5py uses for multi-chunk datasets.
-// chunkAddrs contains the address of each chunk in storage order.
-func (cw *ChunkWriter) WriteExtensibleArrayIndex(chunkAddrs []uint64) (uint64, error) {
-	numChunks := len(chunkAddrs)
-	if numChunks == 0 {
-		return 0, nil
-	}
-
-	// For non-filtered chunks, element size = offset size
-	elemSize := cw.w.OffsetSize()
-	offsetSize := cw.w.OffsetSize()
-	lengthSize := cw.w.LengthSize()
-
-	// Calculate bits for number of elements in index block
-	// We'll store all elements directly in the index block for simplicity
-	idxBlkElmtsBits := uint8(0)
-	for (1 << idxBlkElmtsBits) < numChunks {
-		idxBlkElmtsBits++
-	}
-	// h5py commonly uses 2 bits for small arrays
-	if idxBlkElmtsBits < 2 {
-		idxBlkElmtsBits = 2
-	}
-
-	// Max elements bits - enough to address all chunks
-	maxElmtsBits := idxBlkElmtsBits
-	for (1 << maxElmtsBits) < numChunks {
-		maxElmtsBits++
-	}
-	if maxElmtsBits < 4 {
-		maxElmtsBits = 4 // Minimum for reasonable addressability
-	}
-
-	// Calculate sizes
-	numIdxElmts := 1 << idxBlkElmtsBits
-	idxBlockSize := 4 + 1 + 1 + offsetSize + numIdxElmts*elemSize + 4
-	headerSize := 4 + 1 + 1 + 1 + 1 + 1 + 1 + 1 + 1 + 6*lengthSize + offsetSize + 4
-
-	// Allocate space for both structures
-	idxBlockAddr := cw.allocator(int64(idxBlockSize))
-	headerAddr := cw.allocator(int64(headerSize))
-
-	// Build index block in memory
-	idxData := make([]byte, idxBlockSize)
-	idx := 0
-
-	// Signature "EAIB"
-	copy(idxData[idx:], []byte("EAIB"))
-	idx += 4
-
-	// Version
-	idxData[idx] = 0
-	idx++
-
-	// Client ID (0 = non-filtered chunks)
-	idxData[idx] = 0
-	idx++
-
-	// Header address
-	putUint64LE(idxData[idx:], headerAddr, offsetSize)
-	idx += offsetSize
-
-	// Chunk addresses (elements)
-	for _, addr := range chunkAddrs {
-		putUint64LE(idxData[idx:], addr, offsetSize)
-		idx += offsetSize
-	}
-
-	// Pad remaining slots with undefined address
-	for i := numChunks; i < numIdxElmts; i++ {
-		putUint64LE(idxData[idx:], 0xFFFFFFFFFFFFFFFF, offsetSize)
-		idx += offsetSize
-	}
-
-	// Compute and add checksum
-	idxChecksum := binary.Lookup3Checksum(idxData[:idx])
-	putUint32LE(idxData[idx:], idxChecksum)
-	idx += 4
-
-	// Write index block
-	iw := cw.w.At(int64(idxBlockAddr))
-	if err := iw.WriteBytes(idxData); err != nil {
-		return 0, err
-	}
-
-	// Build header in memory
-	hdrData := make([]byte, headerSize)
-	idx = 0
-
-	// Signature "EAHD"
-	copy(hdrData[idx:], []byte("EAHD"))
-	idx += 4
-
-	// Version
-	hdrData[idx] = 0
-	idx++
-
-	// Client ID (0 = non-filtered chunks)
-	hdrData[idx] = 0
-	idx++
-
-	// Element size
-	hdrData[idx] = uint8(elemSize)
-	idx++
-
-	// Max number of elements bits
-	hdrData[idx] = maxElmtsBits
-	idx++
-
-	// Index block element count bits
-	hdrData[idx] = idxBlkElmtsBits
-	idx++
-
-	// Data block min element count bits
-	hdrData[idx] = 1
-	idx++
-
-	// Super block min element count bits
-	hdrData[idx] = 0
-	idx++
-
-	// Data block page max element count bits
-	hdrData[idx] = 0
-	idx++
-
-	// Number of secondary blocks (0)
-	putUint64LE(hdrData[idx:], 0, lengthSize)
-	idx += lengthSize
-
-	// Secondary block size (0)
-	putUint64LE(hdrData[idx:], 0, lengthSize)
-	idx += lengthSize
-
-	// Number of data blocks (0)
-	putUint64LE(hdrData[idx:], 0, lengthSize)
-	idx += lengthSize
-
-	// Data block size (0)
-	putUint64LE(hdrData[idx:], 0, lengthSize)
-	idx += lengthSize
-
-	// Max index set
-	putUint64LE(hdrData[idx:], uint64(numChunks-1), lengthSize)
-	idx += lengthSize
-
-	// Number of elements
-	putUint64LE(hdrData[idx:], uint64(numChunks), lengthSize)
-	idx += lengthSize
-
-	// Index block address
-	putUint64LE(hdrData[idx:], idxBlockAddr, offsetSize)
-	idx += offsetSize
-
-	// Compute and add checksum
-	hdrChecksum := binary.Lookup3Checksum(hdrData[:idx])
-	putUint32LE(hdrData[idx:], hdrChecksum)
-	idx += 4
-
-	// Write header
-	hw := cw.w.At(int64(headerAddr))
-	if err := hw.WriteBytes(hdrData); err != nil {
-		return 0, err
+// chunkSizeLen returns the number of bytes in which index entries of
+// filtered chunks store the chunk size: enough for one more byte than the
+// unfiltered chunk size needs, as the HDF5 library sizes them, up to 8.
+func (cw *ChunkWriter) chunkSizeLen() int {
+	n := 1 + (bits.Len64(cw.ChunkSize())-1+8)/8
+	if n > 8 {
+		n = 8
 	}
-
-	return headerAddr, nil
+	return n
 }
 
 // Helper functions for building byte arrays
@@ -400,7 +302,7 @@ func SplitIntoChunks(data []byte, dataDims []uint64, chunkDims []uint32, element
 		totalChunks *= numChunksPerDim[i]
 	}
 
-	// For 1D case, simple splitting
+	// For 1D case, simple splitting; the last chunk is padded to full size
 	if len(dataDims) == 1 {
 		chunkSize := uint64(chunkDims[0]) * uint64(elementSize)
 		chunks := make([][]byte, 0, totalChunks)
@@ -408,14 +310,118 @@ func SplitIntoChunks(data []byte, dataDims []uint64, chunkDims []uint32, element
 		for offset := uint64(0); offset < uint64(len(data)); offset += chunkSize {
 			end := offset + chunkSize
 			if end > uint64(len(data)) {
-				end = uint64(len(data))
+				chunk := make([]byte, chunkSize)
+				copy(chunk, data[offset:])
+				chunks = append(chunks, chunk)
+				break
 			}
 			chunks = append(chunks, data[offset:end])
 		}
 		return chunks
 	}
 
-	// For multi-dimensional, more complex logic needed
-	// For now, return single chunk for simplicity
-	return [][]byte{data}
+	// Extract the chunks in row-major order of their coordinates, as the
+	// fixed array index numbers them
+	chunks := make([][]byte, 0, totalChunks)
+	offset := make([]uint64, len(dataDims))
+	for {
+		chunks = append(chunks, ExtractChunk(data, dataDims, offset, chunkDims, elementSize))
+
+		d := len(dataDims) - 1
+		for ; d >= 0; d-- {
+			offset[d] += uint64(chunkDims[d])
+			if offset[d] < dataDims[d] {
+				break
+			}
+			offset[d] = 0
+		}
+		if d < 0 {
+			return chunks
+		}
+	}
+}
+
+// PadChunk lays out a dataset that fits in a single chunk as a full-size
+// chunk: each row is placed at its position in the chunk and the rest is
+// left zero. Chunks are always stored at full size, even past the edge of
+// the dataset.
+func PadChunk(data []byte, dataDims []uint64, chunkDims []uint32, elementSize uint32) []byte {
+	ndims := len(dataDims)
+	chunkBytes := uint64(elementSize)
+	for _, d := range chunkDims {
+		chunkBytes *= uint64(d)
+	}
+	if ndims == 0 || uint64(len(data)) == chunkBytes {
+		return data
+	}
+
+	output := make([]byte, chunkBytes)
+	rowBytes := dataDims[ndims-1] * uint64(elementSize)
+	idx := make([]uint64, ndims)
+	for pos := uint64(0); pos+rowBytes <= uint64(len(data)) && rowBytes > 0; pos += rowBytes {
+		dst := uint64(0)
+		for d := 0; d < ndims; d++ {
+			dst = dst*uint64(chunkDims[d]) + idx[d]
+		}
+		dst *= uint64(elementSize)
+		copy(output[dst:dst+rowBytes], data[pos:pos+rowBytes])
+
+		for d := ndims - 2; d >= 0; d-- {
+			idx[d]++
+			if idx[d] < dataDims[d] {
+				break
+			}
+			idx[d] = 0
+		}
+	}
+	return output
+}
+
+// ExtractChunk returns the full-size chunk at offset (in dataset
+// coordinates) of row-major data with dimensions dataDims. Parts of the
+// chunk past the edge of the data are left zero.
+func ExtractChunk(data []byte, dataDims, offset []uint64, chunkDims []uint32, elementSize uint32) []byte {
+	ndims := len(dataDims)
+	chunkBytes := uint64(elementSize)
+	for _, d := range chunkDims[:ndims] {
+		chunkBytes *= uint64(d)
+	}
+	output := make([]byte, chunkBytes)
+	if ndims == 0 {
+		copy(output, data)
+		return output
+	}
+	for d := 0; d < ndims; d++ {
+		if offset[d] >= dataDims[d] {
+			return output
+		}
+	}
+
+	// Copy the chunk row by row along the last dimension
+	last := ndims - 1
+	rowElems := min(uint64(chunkDims[last]), dataDims[last]-offset[last])
+	rowBytes := rowElems * uint64(elementSize)
+	idx := make([]uint64, ndims) // Position within the chunk
+	for {
+		src, dst := uint64(0), uint64(0)
+		for d := 0; d < ndims; d++ {
+			src = src*dataDims[d] + offset[d] + idx[d]
+			dst = dst*uint64(chunkDims[d]) + idx[d]
+		}
+		src *= uint64(elementSize)
+		dst *= uint64(elementSize)
+		copy(output[dst:dst+rowBytes], data[src:src+rowBytes])
+
+		d := last - 1
+		for ; d >= 0; d-- {
+			idx[d]++
+			if idx[d] < uint64(chunkDims[d]) && offset[d]+idx[d] < dataDims[d] {
+				break
+			}
+			idx[d] = 0
+		}
+		if d < 0 {
+			return output
+		}
+	}
 }
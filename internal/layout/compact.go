@@ -27,14 +27,36 @@ func (c *Compact) Class() message.LayoutClass {
 	return message.LayoutCompact
 }
 
+// checkSize reports an error if the data in the object header is too
+// short to hold every element of the dataset.
+func (c *Compact) checkSize() error {
+	if need := calculateDataSize(c.dataspace, c.datatype); uint64(len(c.data)) < need {
+		return fmt.Errorf("compact data has %d bytes, dataset needs %d", len(c.data), need)
+	}
+	return nil
+}
+
 // Read returns the compact data stored in the object header.
 func (c *Compact) Read() ([]byte, error) {
+	data, err := c.Data()
+	if err != nil {
+		return nil, err
+	}
 	// Data is already available - just return a copy
-	result := make([]byte, len(c.data))
-	copy(result, c.data)
+	result := make([]byte, len(data))
+	copy(result, data)
 	return result, nil
 }
 
+// Data returns the compact data stored in the object header without
+// copying it. The caller must not modify it.
+func (c *Compact) Data() ([]byte, error) {
+	if err := c.checkSize(); err != nil {
+		return nil, err
+	}
+	return c.data, nil
+}
+
 // Size returns the size of the compact data.
 func (c *Compact) Size() int {
 	return len(c.data)
@@ -46,9 +68,7 @@ func (c *Compact) ReadSlice(start, count []uint64) ([]byte, error) {
 	if len(dims) == 0 {
 		// Scalar dataset
 		if len(start) == 0 && len(count) == 0 {
-			result := make([]byte, len(c.data))
-			copy(result, c.data)
-			return result, nil
+			return c.Read()
 		}
 		return nil, fmt.Errorf("cannot slice scalar dataset with non-empty start/count")
 	}
@@ -66,6 +86,9 @@ func (c *Compact) ReadSlice(start, count []uint64) ([]byte, error) {
 		}
 	}
 
+	if err := c.checkSize(); err != nil {
+		return nil, err
+	}
 	elementSize := uint64(c.datatype.Size)
 	return extractHyperslab(c.data, dims, start, count, elementSize)
 }
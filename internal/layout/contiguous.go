@@ -1,12 +1,19 @@
 package layout
 
 import (
+	"errors"
 	"fmt"
+	"math"
 
 	"github.com/robert-malhotra/go-hdf5/internal/binary"
 	"github.com/robert-malhotra/go-hdf5/internal/message"
 )
 
+// ErrNoStorage is returned when reading contiguous data that was never
+// allocated in the file, as HDF5 libraries leave it until it is written,
+// from a dataset without a fill value to read it as.
+var ErrNoStorage = errors.New("dataset storage not allocated")
+
 // Contiguous represents contiguous storage layout.
 // Data is stored in a single contiguous block in the file.
 type Contiguous struct {
@@ -15,6 +22,7 @@ type Contiguous struct {
 	dataspace *message.Dataspace
 	datatype  *message.Datatype
 	reader    *binary.Reader
+	fillValue []byte // One element; nil fails reads of unallocated data
 }
 
 // NewContiguous creates a new contiguous layout handler.
@@ -43,19 +51,46 @@ func (c *Contiguous) Class() message.LayoutClass {
 	return message.LayoutContiguous
 }
 
+// SetFillValue sets the bytes of one element that the dataset reads as
+// while its data is not allocated. It must be called before the first
+// read. With a nil value, the default, such reads fail with ErrNoStorage.
+func (c *Contiguous) SetFillValue(value []byte) {
+	c.fillValue = value
+}
+
+// Allocated reports whether the data has been allocated in the file. Data
+// that was never written reads as the fill value.
+func (c *Contiguous) Allocated() bool {
+	return !c.reader.IsUndefinedOffset(c.address)
+}
+
+// unallocated returns size bytes of the fill value in place of data that
+// was never allocated, or ErrNoStorage without a fill value to use.
+func (c *Contiguous) unallocated(size uint64) ([]byte, error) {
+	if size == 0 {
+		return []byte{}, nil
+	}
+	if c.fillValue == nil {
+		return nil, ErrNoStorage
+	}
+	return filled(c.fillValue, size), nil
+}
+
 // Read reads all data from contiguous storage.
 func (c *Contiguous) Read() ([]byte, error) {
-	// Check for undefined address (no data allocated)
-	if c.reader.IsUndefinedOffset(c.address) {
-		return nil, fmt.Errorf("contiguous data not allocated")
+	if !c.Allocated() {
+		return c.unallocated(calculateDataSize(c.dataspace, c.datatype))
 	}
 
 	if c.size == 0 {
 		return []byte{}, nil
 	}
+	if c.size > math.MaxInt {
+		return nil, fmt.Errorf("contiguous data of %d bytes is too large", c.size)
+	}
 
 	// Read data directly from the file
-	r := c.reader.At(int64(c.address))
+	r := c.reader.AtSize(int64(c.address), int(c.size))
 	data, err := r.ReadBytes(int(c.size))
 	if err != nil {
 		return nil, fmt.Errorf("reading contiguous data: %w", err)
@@ -64,6 +99,18 @@ func (c *Contiguous) Read() ([]byte, error) {
 	return data, nil
 }
 
+// ReadMapped returns the data as a view of mapped, a read-only memory map
+// of the whole file, without copying it. It reports false if the data is
+// not allocated or lies past the end of the mapping; Read then reports why.
+func (c *Contiguous) ReadMapped(mapped []byte) ([]byte, bool) {
+	if !c.Allocated() || c.address > uint64(len(mapped)) ||
+		c.size > uint64(len(mapped))-c.address {
+		return nil, false
+	}
+	end := c.address + c.size
+	return mapped[c.address:end:end], true
+}
+
 // Address returns the data address.
 func (c *Contiguous) Address() uint64 {
 	return c.address
@@ -101,12 +148,20 @@ func (c *Contiguous) ReadSlice(start, count []uint64) ([]byte, error) {
 	elementSize := uint64(c.datatype.Size)
 	ndims := len(dims)
 
+	if !c.Allocated() {
+		size := elementSize
+		for _, n := range count {
+			size *= n
+		}
+		return c.unallocated(size)
+	}
+
 	// For 1D arrays or when selecting a contiguous region, we can optimize
 	if ndims == 1 {
 		// Simple case: read only the needed portion
 		startByte := start[0] * elementSize
 		numBytes := count[0] * elementSize
-		r := c.reader.At(int64(c.address + startByte))
+		r := c.reader.AtSize(int64(c.address+startByte), int(numBytes))
 		return r.ReadBytes(int(numBytes))
 	}
 
@@ -128,7 +183,7 @@ func (c *Contiguous) ReadSlice(start, count []uint64) ([]byte, error) {
 		}
 		startByte := start[0] * rowSize
 		numBytes := count[0] * rowSize
-		r := c.reader.At(int64(c.address + startByte))
+		r := c.reader.AtSize(int64(c.address+startByte), int(numBytes))
 		return r.ReadBytes(int(numBytes))
 	}
 
@@ -31,9 +31,11 @@
 //
 // # Chunked Storage Details
 //
-// Chunked storage supports multiple index formats, automatically detected:
+// Chunked storage supports multiple index formats. Layout messages before
+// version 4 always use a v1 B-tree; version 4 names the index type:
 //
 //   - Single chunk: Small datasets with one chunk, no index structure
+//   - Implicit: Full-size unfiltered chunks stored back to back, no index structure
 //   - B-tree v1 ("TREE"): Traditional B-tree index for chunks
 //   - B-tree v2 ("BTHD"): Modern B-tree with better performance
 //   - Fixed array ("FAHD"): Fixed-size array for known chunk counts
@@ -41,7 +43,10 @@
 //
 // The [Chunked] type handles decompression through the filter pipeline and
 // correctly assembles chunks into the final dataset array, handling edge
-// chunks that may be smaller than the chunk dimensions.
+// chunks that may be smaller than the chunk dimensions. Parts of the dataset
+// with no allocated chunk read as the fill value. [Chunked.ForEachChunk]
+// streams the decoded chunks one at a time instead, for datasets too large
+// to assemble in memory.
 //
 // # Multi-dimensional Chunk Copying
 //
@@ -0,0 +1,448 @@
+package layout
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/btree"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// Extensible array chunk indexes are used for datasets with one unlimited
+// dimension. The array is a tree of blocks, each guarded by a checksum:
+//
+//   - The header (EAHD) holds the creation parameters and the number of
+//     elements set.
+//   - The index block (EAIB) holds the first few elements directly, then
+//     the addresses of the data blocks of the first super blocks, then the
+//     addresses of the remaining super blocks.
+//   - A super block (EASB) holds the addresses of its data blocks and, when
+//     those are paged, a bitmap of the pages that have been written.
+//   - A data block (EADB) holds elements, or when larger than a page, is
+//     followed by pages (with their own checksums) holding them.
+//
+// Super block u has 2^(u/2) data blocks of 2^((u+1)/2) times the minimum
+// data block size elements each. Blocks and pages never written have the
+// undefined address (or a clear page bit), and their elements read as
+// unallocated chunks.
+
+// eaHeader holds the parameters of an extensible array.
+type eaHeader struct {
+	elemSize          int    // Bytes per element
+	idxBlkElmts       uint64 // Elements stored in the index block
+	supBlkMinDataPtrs uint64 // Data block pointers in the smallest super blocks
+	dblkPageNelmts    uint64 // Elements per data block page
+	arrOffSize        int    // Bytes of the block offset field in super and data blocks
+	maxIdxSet         uint64 // One past the highest element index set
+	idxBlockAddr      uint64
+	sblks             []eaSuperBlockInfo
+}
+
+// eaSuperBlockInfo describes the data blocks of one super block.
+type eaSuperBlockInfo struct {
+	ndblks     uint64 // Number of data blocks
+	dblkNelmts uint64 // Elements per data block
+	startDblk  uint64 // Index of its first data block, counting from super block 0
+}
+
+// readExtensibleArrayIndex reads chunk entries from an extensible array index.
+func (c *Chunked) readExtensibleArrayIndex(dims []uint64, chunkDims []uint32) ([]btree.ChunkEntry, error) {
+	var entries []btree.ChunkEntry
+	err := c.walkExtensibleArray(chunkDims, func(idx uint64, entry btree.ChunkEntry) {
+		entry.Offset = c.extensibleArrayChunkOffset(idx, dims, chunkDims)
+		entries = append(entries, entry)
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ExtensibleArrayChunks returns the address and stored size of each chunk
+// of an extensible array chunk index, by index in the array, with the
+// undefined address for chunks never written. It is the input
+// WriteExtensibleArrayIndex takes to write the index again.
+func (c *Chunked) ExtensibleArrayChunks() ([]uint64, []uint64, error) {
+	if c.layout.ChunkIndexType != message.ChunkIndexExtensibleArray {
+		return nil, nil, fmt.Errorf("chunk index is not an extensible array")
+	}
+	chunkDims := c.layout.ChunkDims
+	if len(chunkDims) > len(c.dataspace.Dimensions) {
+		chunkDims = chunkDims[:len(c.dataspace.Dimensions)]
+	}
+
+	var addrs []uint64
+	var sizes []uint64
+	err := c.walkExtensibleArray(chunkDims, func(idx uint64, entry btree.ChunkEntry) {
+		for uint64(len(addrs)) <= idx {
+			addrs = append(addrs, ^uint64(0))
+			sizes = append(sizes, 0)
+		}
+		addrs[idx], sizes[idx] = entry.Address, entry.Size
+	}, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return addrs, sizes, nil
+}
+
+// walkExtensibleArray calls fn with the index and entry of each chunk
+// written in an extensible array index, in index order. If block is not
+// nil, it is called with the address and size of each block of the array
+// read on the way, pages included.
+func (c *Chunked) walkExtensibleArray(chunkDims []uint32, fn func(idx uint64, entry btree.ChunkEntry),
+	block func(addr, size uint64)) error {
+	if block == nil {
+		block = func(addr, size uint64) {}
+	}
+	hdr, err := c.readExtensibleArrayHeader(c.layout.ChunkIndexAddr)
+	if err != nil {
+		return err
+	}
+	block(c.layout.ChunkIndexAddr, uint64(c.extensibleArrayHeaderSize()))
+	if hdr.maxIdxSet == 0 {
+		return nil
+	}
+
+	o := c.reader.OffsetSize()
+	iblkNsblks := 2 * uint64(bits.Len64(hdr.supBlkMinDataPtrs)-1)
+	if iblkNsblks > uint64(len(hdr.sblks)) {
+		iblkNsblks = uint64(len(hdr.sblks))
+	}
+	ndblkAddrs := 2 * (hdr.supBlkMinDataPtrs - 1)
+	nsblkAddrs := uint64(len(hdr.sblks)) - iblkNsblks
+
+	size := 4 + 1 + 1 + o + int(hdr.idxBlkElmts)*hdr.elemSize + int(ndblkAddrs+nsblkAddrs)*o + 4
+	br, err := c.readChecksummedBlock(hdr.idxBlockAddr, size, "EAIB")
+	if err != nil {
+		return fmt.Errorf("extensible array index block: %w", err)
+	}
+	block(hdr.idxBlockAddr, uint64(size))
+	if _, err := br.ReadOffset(); err != nil { // Header address
+		return err
+	}
+
+	add := func(idx uint64, raw []byte) error {
+		entry, err := c.decodeChunkIndexEntry(raw, chunkDims)
+		if err != nil {
+			return fmt.Errorf("extensible array element %d: %w", idx, err)
+		}
+		if entry.Address == 0 || c.reader.IsUndefinedOffset(entry.Address) {
+			return nil
+		}
+		fn(idx, entry)
+		return nil
+	}
+
+	// Elements in the index block itself
+	for i := uint64(0); i < hdr.idxBlkElmts; i++ {
+		raw, err := br.ReadBytes(hdr.elemSize)
+		if err != nil {
+			return err
+		}
+		if i < hdr.maxIdxSet {
+			if err := add(i, raw); err != nil {
+				return err
+			}
+		}
+	}
+	dblkAddrs := make([]uint64, ndblkAddrs)
+	for i := range dblkAddrs {
+		if dblkAddrs[i], err = br.ReadOffset(); err != nil {
+			return err
+		}
+	}
+	sblkAddrs := make([]uint64, nsblkAddrs)
+	for i := range sblkAddrs {
+		if sblkAddrs[i], err = br.ReadOffset(); err != nil {
+			return err
+		}
+	}
+
+	// Then the data blocks of each super block, in element order
+	idx := hdr.idxBlkElmts
+	for s := uint64(0); s < uint64(len(hdr.sblks)) && idx < hdr.maxIdxSet; s++ {
+		info := hdr.sblks[s]
+		blockElmts := info.ndblks * info.dblkNelmts
+
+		var addrs []uint64
+		var pageInit []byte
+		if s < iblkNsblks {
+			addrs = dblkAddrs[info.startDblk : info.startDblk+info.ndblks]
+		} else {
+			addr := sblkAddrs[s-iblkNsblks]
+			if c.reader.IsUndefinedOffset(addr) {
+				idx += blockElmts
+				continue
+			}
+			addrs, pageInit, err = c.readExtensibleArraySuperBlock(hdr, addr, info)
+			if err != nil {
+				return fmt.Errorf("extensible array super block %d: %w", s, err)
+			}
+			block(addr, uint64(extensibleArraySuperBlockSize(hdr, info, c.reader.OffsetSize())))
+		}
+
+		for d, addr := range addrs {
+			if !c.reader.IsUndefinedOffset(addr) {
+				var init []byte
+				if pageInit != nil {
+					pageInitSize := (info.dblkNelmts/hdr.dblkPageNelmts + 7) / 8
+					init = pageInit[uint64(d)*pageInitSize : uint64(d+1)*pageInitSize]
+				}
+				n := min(info.dblkNelmts, hdr.maxIdxSet-idx)
+				err := c.readExtensibleArrayDataBlock(hdr, addr, info.dblkNelmts, n, init, func(i uint64, raw []byte) error {
+					return add(idx+i, raw)
+				})
+				if err != nil {
+					return fmt.Errorf("extensible array data block at %d: %w", addr, err)
+				}
+				block(addr, c.extensibleArrayDataBlockSize(hdr, info.dblkNelmts))
+			}
+			idx += info.dblkNelmts
+			if idx >= hdr.maxIdxSet {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// readExtensibleArrayHeader reads and checks the header of an extensible
+// array.
+func (c *Chunked) readExtensibleArrayHeader(addr uint64) (*eaHeader, error) {
+	br, err := c.readChecksummedBlock(addr, c.extensibleArrayHeaderSize(), "EAHD")
+	if err != nil {
+		return nil, fmt.Errorf("extensible array header: %w", err)
+	}
+
+	// Element size, then the creation parameters
+	params, err := br.ReadBytes(6)
+	if err != nil {
+		return nil, err
+	}
+	hdr := &eaHeader{
+		elemSize:          int(params[0]),
+		idxBlkElmts:       uint64(params[2]),
+		supBlkMinDataPtrs: uint64(params[4]),
+	}
+	maxNelmtsBits := int(params[1])
+	dataBlkMinElmts := uint64(params[3])
+	pageBits := int(params[5])
+
+	switch {
+	case hdr.elemSize < c.reader.OffsetSize():
+		return nil, fmt.Errorf("extensible array element size %d is smaller than an address", hdr.elemSize)
+	case maxNelmtsBits == 0 || maxNelmtsBits > 64 || pageBits >= 64:
+		return nil, fmt.Errorf("extensible array has invalid size bits %d and %d", maxNelmtsBits, pageBits)
+	case dataBlkMinElmts == 0 || dataBlkMinElmts&(dataBlkMinElmts-1) != 0:
+		return nil, fmt.Errorf("extensible array data block minimum %d is not a power of two", dataBlkMinElmts)
+	case hdr.supBlkMinDataPtrs < 2 || hdr.supBlkMinDataPtrs&(hdr.supBlkMinDataPtrs-1) != 0:
+		return nil, fmt.Errorf("extensible array super block minimum %d is not a power of two", hdr.supBlkMinDataPtrs)
+	}
+	hdr.dblkPageNelmts = 1 << pageBits
+	// Pages, with their prefix and checksum, are read into a single slice
+	if hi, lo := bits.Mul64(hdr.dblkPageNelmts, uint64(hdr.elemSize)); hi != 0 || lo > math.MaxInt/2 {
+		return nil, fmt.Errorf("extensible array pages of 2^%d %d-byte elements are too large", pageBits, hdr.elemSize)
+	}
+	hdr.arrOffSize = (maxNelmtsBits + 7) / 8
+
+	// Super block, data block and element counts and sizes; only the
+	// highest index set is needed
+	for i := 0; i < 4; i++ {
+		if _, err := br.ReadLength(); err != nil {
+			return nil, err
+		}
+	}
+	if hdr.maxIdxSet, err = br.ReadLength(); err != nil {
+		return nil, err
+	}
+	if _, err := br.ReadLength(); err != nil {
+		return nil, err
+	}
+	if hdr.idxBlockAddr, err = br.ReadOffset(); err != nil {
+		return nil, err
+	}
+
+	nsblks := 1 + maxNelmtsBits - (bits.Len64(dataBlkMinElmts) - 1)
+	if nsblks < 1 {
+		return nil, fmt.Errorf("extensible array data block minimum %d exceeds %d-bit element count",
+			dataBlkMinElmts, maxNelmtsBits)
+	}
+	startDblk := uint64(0)
+	for u := 0; u < nsblks; u++ {
+		info := eaSuperBlockInfo{
+			ndblks:     1 << (u / 2),
+			dblkNelmts: dataBlkMinElmts << ((u + 1) / 2),
+			startDblk:  startDblk,
+		}
+		hdr.sblks = append(hdr.sblks, info)
+		startDblk += info.ndblks
+	}
+	return hdr, nil
+}
+
+// extensibleArrayHeaderSize returns the size of an extensible array
+// header.
+func (c *Chunked) extensibleArrayHeaderSize() int {
+	return 4 + 1 + 1 + 6 + 6*c.reader.LengthSize() + c.reader.OffsetSize() + 4
+}
+
+// extensibleArraySuperBlockSize returns the size of a super block with
+// info's data blocks, in a file with addresses of o bytes.
+func extensibleArraySuperBlockSize(hdr *eaHeader, info eaSuperBlockInfo, o int) int {
+	var pageInitSize uint64
+	if info.dblkNelmts > hdr.dblkPageNelmts {
+		pageInitSize = (info.dblkNelmts/hdr.dblkPageNelmts + 7) / 8
+	}
+	return 4 + 1 + 1 + o + hdr.arrOffSize + int(info.ndblks*pageInitSize) + int(info.ndblks)*o + 4
+}
+
+// extensibleArrayDataBlockSize returns the size of a data block of nelmts
+// elements, with the pages that follow it if it is paged.
+func (c *Chunked) extensibleArrayDataBlockSize(hdr *eaHeader, nelmts uint64) uint64 {
+	prefix := uint64(4 + 1 + 1 + c.reader.OffsetSize() + hdr.arrOffSize)
+	if nelmts <= hdr.dblkPageNelmts {
+		return prefix + nelmts*uint64(hdr.elemSize) + 4
+	}
+	pages := nelmts / hdr.dblkPageNelmts
+	return prefix + 4 + pages*(hdr.dblkPageNelmts*uint64(hdr.elemSize)+4)
+}
+
+// readExtensibleArraySuperBlock returns the data block addresses of a super
+// block and, if its data blocks are paged, their page init bitmaps.
+func (c *Chunked) readExtensibleArraySuperBlock(hdr *eaHeader, addr uint64, info eaSuperBlockInfo) ([]uint64, []byte, error) {
+	o := c.reader.OffsetSize()
+	var pageInitSize uint64
+	if info.dblkNelmts > hdr.dblkPageNelmts {
+		pageInitSize = (info.dblkNelmts/hdr.dblkPageNelmts + 7) / 8
+	}
+
+	size := extensibleArraySuperBlockSize(hdr, info, o)
+	br, err := c.readChecksummedBlock(addr, size, "EASB")
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := br.ReadBytes(o + hdr.arrOffSize); err != nil { // Header address, block offset
+		return nil, nil, err
+	}
+
+	var pageInit []byte
+	if pageInitSize > 0 {
+		if pageInit, err = br.ReadBytes(int(info.ndblks * pageInitSize)); err != nil {
+			return nil, nil, err
+		}
+	}
+	addrs := make([]uint64, info.ndblks)
+	for i := range addrs {
+		if addrs[i], err = br.ReadOffset(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return addrs, pageInit, nil
+}
+
+// readExtensibleArrayDataBlock calls fn with the first n of the nelmts
+// elements of a data block. A data block with more elements than fit in a
+// page holds none itself; they follow it in pages, each checksummed, and
+// pageInit marks which pages have been written.
+func (c *Chunked) readExtensibleArrayDataBlock(hdr *eaHeader, addr, nelmts, n uint64, pageInit []byte, fn func(i uint64, raw []byte) error) error {
+	prefix := 4 + 1 + 1 + c.reader.OffsetSize() + hdr.arrOffSize
+	elemSize := uint64(hdr.elemSize)
+
+	if nelmts <= hdr.dblkPageNelmts {
+		br, err := c.readChecksummedBlock(addr, prefix+int(nelmts*elemSize)+4, "EADB")
+		if err != nil {
+			return err
+		}
+		if _, err := br.ReadBytes(prefix - 6); err != nil { // Header address, block offset
+			return err
+		}
+		for i := uint64(0); i < n; i++ {
+			raw, err := br.ReadBytes(hdr.elemSize)
+			if err != nil {
+				return err
+			}
+			if err := fn(i, raw); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if _, err := c.readChecksummedBlock(addr, prefix+4, "EADB"); err != nil {
+		return err
+	}
+	pageSize := hdr.dblkPageNelmts*elemSize + 4
+	for p := uint64(0); p*hdr.dblkPageNelmts < n; p++ {
+		if pageInit != nil && pageInit[p/8]&(0x80>>(p%8)) == 0 {
+			continue // Never written
+		}
+		pageAddr := addr + uint64(prefix+4) + p*pageSize
+		page, err := c.readChecksummedBytes(pageAddr, int(pageSize), "EADB page")
+		if err != nil {
+			return fmt.Errorf("page %d: %w", p, err)
+		}
+		for i := uint64(0); i < hdr.dblkPageNelmts && p*hdr.dblkPageNelmts+i < n; i++ {
+			if err := fn(p*hdr.dblkPageNelmts+i, page[i*elemSize:(i+1)*elemSize]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// extensibleArrayChunkOffset returns the dataset coordinates of the chunk
+// at index idx. Chunks are numbered in row-major order of their chunk
+// coordinates, with the unlimited dimension moved first.
+func (c *Chunked) extensibleArrayChunkOffset(idx uint64, dims []uint64, chunkDims []uint32) []uint64 {
+	order := extensibleArrayOrder(c.dataspace.MaxDims, len(dims))
+	offset := make([]uint64, len(dims))
+	for k := len(order) - 1; k > 0; k-- {
+		d := order[k]
+		n := extensibleArrayExtent(d, dims, c.dataspace.MaxDims, chunkDims)
+		offset[d] = (idx % n) * uint64(chunkDims[d])
+		idx /= n
+	}
+	offset[order[0]] = idx * uint64(chunkDims[order[0]])
+	return offset
+}
+
+// readChecksummedBlock reads a metadata block of size bytes that starts
+// with the signature sig and ends in its checksum, and returns a reader
+// over the block positioned after the signature, version and client ID.
+func (c *Chunked) readChecksummedBlock(addr uint64, size int, sig string) (*binary.Reader, error) {
+	data, err := c.readChecksummedBytes(addr, size, sig)
+	if err != nil {
+		return nil, err
+	}
+	if string(data[:4]) != sig {
+		return nil, fmt.Errorf("invalid signature at %d: got %q, expected %q", addr, data[:4], sig)
+	}
+	if data[4] != 0 {
+		return nil, fmt.Errorf("unsupported %s version: %d", sig, data[4])
+	}
+	br := binary.NewReader(bytes.NewReader(data), binary.Config{
+		ByteOrder:  c.reader.ByteOrder(),
+		OffsetSize: c.reader.OffsetSize(),
+		LengthSize: c.reader.LengthSize(),
+	})
+	br.Skip(6)
+	return br, nil
+}
+
+// readChecksummedBytes reads size bytes ending in a lookup3 checksum of
+// the bytes before it. structure names the block in errors.
+func (c *Chunked) readChecksummedBytes(addr uint64, size int, structure string) ([]byte, error) {
+	data, err := c.reader.At(int64(addr)).ReadBytes(size)
+	if err != nil {
+		return nil, err
+	}
+	if err := binary.CheckLookup3(data, structure, addr); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,307 @@
+package layout
+
+import (
+	"fmt"
+
+	"github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// Creation parameters of the extensible arrays written for chunk indexes,
+// the C library's defaults. The data layout message repeats them.
+const (
+	eaMaxNelmtsBits     = 32
+	eaIdxBlkElmts       = 4
+	eaDataBlkMinElmts   = 16
+	eaSupBlkMinDataPtrs = 4
+	eaPageBits          = 10
+)
+
+// WriteExtensibleArrayIndex writes an extensible array chunk index and
+// returns the address of its header. chunkAddrs holds the address of each
+// chunk by its index in the array (see ExtensibleArrayChunkIndex), with
+// the undefined address for chunks never written, and chunkSizes their
+// stored sizes, which are recorded for filtered chunks.
+//
+// The whole array is written anew: the first elements in the index block,
+// the rest in data blocks, which past the first few are reached through
+// super blocks. Blocks holding only unwritten chunks are left unallocated.
+func (cw *ChunkWriter) WriteExtensibleArrayIndex(chunkAddrs []uint64, chunkSizes []uint64) (uint64, error) {
+	filtered := cw.Filtered()
+	if filtered && len(chunkSizes) != len(chunkAddrs) {
+		return 0, fmt.Errorf("%d chunk sizes for %d filtered chunks", len(chunkSizes), len(chunkAddrs))
+	}
+
+	offsetSize := cw.w.OffsetSize()
+	lengthSize := cw.w.LengthSize()
+	undefined := ^uint64(0)
+
+	elemSize := offsetSize
+	clientID := uint8(0)
+	if filtered {
+		elemSize += cw.chunkSizeLen() + 4
+		clientID = 1
+	}
+	putElement := func(b []byte, i uint64) {
+		addr, size := undefined, uint64(0)
+		if i < uint64(len(chunkAddrs)) {
+			addr = chunkAddrs[i]
+			if filtered && !isUndefined(addr) {
+				size = chunkSizes[i]
+			}
+		}
+		putUint64LE(b, addr, offsetSize)
+		if filtered {
+			putUint64LE(b[offsetSize:], uint64(size), cw.chunkSizeLen())
+			putUint32LE(b[offsetSize+cw.chunkSizeLen():], cw.filterMask)
+		}
+	}
+	// written reports whether any chunk in [start, start+n) was written
+	written := func(start, n uint64) bool {
+		for i := start; i < start+n && i < uint64(len(chunkAddrs)); i++ {
+			if !isUndefined(chunkAddrs[i]) {
+				return true
+			}
+		}
+		return false
+	}
+	// block starts a metadata block with its signature, version, client
+	// ID and the header address
+	headerSize := 4 + 2 + 6 + 6*lengthSize + offsetSize + 4
+	headerAddr := cw.allocator(int64(headerSize))
+	block := func(sig string, size int) []byte {
+		b := make([]byte, 0, size)
+		b = append(b, sig...)
+		b = append(b, 0, clientID)
+		b = b[:len(b)+offsetSize]
+		putUint64LE(b[len(b)-offsetSize:], headerAddr, offsetSize)
+		return b
+	}
+	write := func(addr uint64, data []byte) error {
+		return cw.w.At(int64(addr)).WriteBytes(data)
+	}
+	withChecksum := func(b []byte) []byte {
+		b = b[:len(b)+4]
+		putUint32LE(b[len(b)-4:], binary.ChecksumLookup3(b[:len(b)-4], 0))
+		return b
+	}
+
+	arrOffSize := (eaMaxNelmtsBits + 7) / 8
+	pageNelmts := uint64(1) << eaPageBits
+	var nsblks, sblkBytes, ndblks, dblkBytes, realized uint64
+
+	// writeDataBlock writes the data block holding elements [start,
+	// start+n) and returns its address, or the undefined address if none
+	// of them was written. Paged blocks also set their bits in pageInit.
+	writeDataBlock := func(start, n, blockOff uint64, pageInit []byte) (uint64, error) {
+		if !written(start, n) {
+			return undefined, nil
+		}
+		prefix := 4 + 2 + offsetSize + arrOffSize
+		var data []byte
+		if n <= pageNelmts {
+			data = block("EADB", prefix+int(n)*elemSize+4)
+			data = data[:prefix]
+			putUint64LE(data[prefix-arrOffSize:], blockOff, arrOffSize)
+			for i := uint64(0); i < n; i++ {
+				data = data[:len(data)+elemSize]
+				putElement(data[len(data)-elemSize:], start+i)
+			}
+			data = withChecksum(data)
+		} else {
+			npages := n / pageNelmts
+			pageSize := int(pageNelmts)*elemSize + 4
+			data = block("EADB", prefix+4+int(npages)*pageSize)
+			data = data[:prefix]
+			putUint64LE(data[prefix-arrOffSize:], blockOff, arrOffSize)
+			data = withChecksum(data)
+			for p := uint64(0); p < npages; p++ {
+				page := make([]byte, 0, pageSize)
+				for i := uint64(0); i < pageNelmts; i++ {
+					page = page[:len(page)+elemSize]
+					putElement(page[len(page)-elemSize:], start+p*pageNelmts+i)
+				}
+				data = append(data, withChecksum(page)...)
+				if written(start+p*pageNelmts, pageNelmts) {
+					pageInit[p/8] |= 0x80 >> (p % 8)
+				}
+			}
+		}
+		addr := cw.allocator(int64(len(data)))
+		if err := write(addr, data); err != nil {
+			return 0, err
+		}
+		ndblks++
+		dblkBytes += uint64(len(data))
+		realized += n
+		return addr, nil
+	}
+
+	// Data block and super block addresses kept in the index block
+	var dblkAddrs, sblkAddrs []uint64
+	sblkCount := 1 + eaMaxNelmtsBits - log2(eaDataBlkMinElmts)
+	iblkNsblks := 2 * log2(eaSupBlkMinDataPtrs)
+	start := uint64(eaIdxBlkElmts)
+	blockOff := uint64(0)
+	for u := 0; u < sblkCount; u++ {
+		sblkNdblks := uint64(1) << (u / 2)
+		dblkNelmts := uint64(eaDataBlkMinElmts) << ((u + 1) / 2)
+		if u < iblkNsblks {
+			for d := uint64(0); d < sblkNdblks; d++ {
+				addr, err := writeDataBlock(start, dblkNelmts, blockOff, nil)
+				if err != nil {
+					return 0, err
+				}
+				dblkAddrs = append(dblkAddrs, addr)
+				start += dblkNelmts
+				blockOff += dblkNelmts
+			}
+			continue
+		}
+
+		if !written(start, sblkNdblks*dblkNelmts) {
+			sblkAddrs = append(sblkAddrs, undefined)
+			start += sblkNdblks * dblkNelmts
+			blockOff += sblkNdblks * dblkNelmts
+			continue
+		}
+		var pageInitSize uint64
+		if dblkNelmts > pageNelmts {
+			pageInitSize = (dblkNelmts/pageNelmts + 7) / 8
+		}
+		pageInit := make([]byte, sblkNdblks*pageInitSize)
+		sblkOff := blockOff
+		addrs := make([]uint64, sblkNdblks)
+		for d := range addrs {
+			var init []byte
+			if pageInitSize > 0 {
+				init = pageInit[uint64(d)*pageInitSize : uint64(d+1)*pageInitSize]
+			}
+			addr, err := writeDataBlock(start, dblkNelmts, blockOff, init)
+			if err != nil {
+				return 0, err
+			}
+			addrs[d] = addr
+			start += dblkNelmts
+			blockOff += dblkNelmts
+		}
+
+		size := 4 + 2 + offsetSize + arrOffSize + len(pageInit) + len(addrs)*offsetSize + 4
+		data := block("EASB", size)
+		data = data[:len(data)+arrOffSize]
+		putUint64LE(data[len(data)-arrOffSize:], sblkOff, arrOffSize)
+		data = append(data, pageInit...)
+		for _, addr := range addrs {
+			data = data[:len(data)+offsetSize]
+			putUint64LE(data[len(data)-offsetSize:], addr, offsetSize)
+		}
+		data = withChecksum(data)
+		addr := cw.allocator(int64(len(data)))
+		if err := write(addr, data); err != nil {
+			return 0, err
+		}
+		sblkAddrs = append(sblkAddrs, addr)
+		nsblks++
+		sblkBytes += uint64(len(data))
+	}
+
+	// The index block, unless no chunk was written at all
+	idxBlockAddr := undefined
+	if written(0, uint64(len(chunkAddrs))) {
+		size := 4 + 2 + offsetSize + eaIdxBlkElmts*elemSize + (len(dblkAddrs)+len(sblkAddrs))*offsetSize + 4
+		data := block("EAIB", size)
+		for i := uint64(0); i < eaIdxBlkElmts; i++ {
+			data = data[:len(data)+elemSize]
+			putElement(data[len(data)-elemSize:], i)
+		}
+		for _, addr := range append(dblkAddrs, sblkAddrs...) {
+			data = data[:len(data)+offsetSize]
+			putUint64LE(data[len(data)-offsetSize:], addr, offsetSize)
+		}
+		data = withChecksum(data)
+		idxBlockAddr = cw.allocator(int64(len(data)))
+		if err := write(idxBlockAddr, data); err != nil {
+			return 0, err
+		}
+		realized += eaIdxBlkElmts
+	}
+
+	// The header, with the creation parameters and statistics
+	data := make([]byte, 0, headerSize)
+	data = append(data, "EAHD"...)
+	data = append(data, 0, clientID, uint8(elemSize), eaMaxNelmtsBits, eaIdxBlkElmts,
+		eaDataBlkMinElmts, eaSupBlkMinDataPtrs, eaPageBits)
+	maxIdxSet := uint64(0)
+	for i, addr := range chunkAddrs {
+		if !isUndefined(addr) {
+			maxIdxSet = uint64(i) + 1
+		}
+	}
+	for _, v := range []uint64{nsblks, sblkBytes, ndblks, dblkBytes, maxIdxSet, realized} {
+		data = data[:len(data)+lengthSize]
+		putUint64LE(data[len(data)-lengthSize:], v, lengthSize)
+	}
+	data = data[:len(data)+offsetSize]
+	putUint64LE(data[len(data)-offsetSize:], idxBlockAddr, offsetSize)
+	data = withChecksum(data)
+	if err := write(headerAddr, data); err != nil {
+		return 0, err
+	}
+
+	return headerAddr, nil
+}
+
+// ExtensibleArrayChunkIndex returns the index in an extensible array chunk
+// index of the chunk at offset: chunks are numbered in row-major order of
+// their chunk coordinates, with the unlimited dimension moved first, and
+// the other dimensions taken at their maximum size.
+func ExtensibleArrayChunkIndex(offset, dims, maxDims []uint64, chunkDims []uint32) uint64 {
+	order := extensibleArrayOrder(maxDims, len(dims))
+	var idx uint64
+	for k, d := range order {
+		if k > 0 {
+			idx *= extensibleArrayExtent(d, dims, maxDims, chunkDims)
+		}
+		idx += offset[d] / uint64(chunkDims[d])
+	}
+	return idx
+}
+
+// extensibleArrayOrder returns the dimensions in the order chunks are
+// numbered, the unlimited one first.
+func extensibleArrayOrder(maxDims []uint64, ndims int) []int {
+	order := make([]int, 0, ndims)
+	for d := 0; d < ndims; d++ {
+		if d < len(maxDims) && maxDims[d] == message.Unlimited {
+			order = append([]int{d}, order...)
+		} else {
+			order = append(order, d)
+		}
+	}
+	return order
+}
+
+// extensibleArrayExtent returns the number of chunks along a dimension
+// other than the unlimited one.
+func extensibleArrayExtent(d int, dims, maxDims []uint64, chunkDims []uint32) uint64 {
+	extent := dims[d]
+	if d < len(maxDims) && maxDims[d] > extent {
+		extent = maxDims[d]
+	}
+	return (extent + uint64(chunkDims[d]) - 1) / uint64(chunkDims[d])
+}
+
+// isUndefined reports whether addr is the undefined address.
+func isUndefined(addr uint64) bool {
+	return addr == ^uint64(0)
+}
+
+// log2 returns the base-2 logarithm of a power of two.
+func log2(n int) int {
+	k := 0
+	for n > 1 {
+		n >>= 1
+		k++
+	}
+	return k
+}
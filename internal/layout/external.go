@@ -0,0 +1,223 @@
+package layout
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// ExternalSegment is one piece of a dataset's raw data stored in a file
+// outside the HDF5 file. A dataset's data is the concatenation of its
+// segments, in order.
+type ExternalSegment struct {
+	Name   string // External file name, as recorded in the file
+	Offset uint64 // Byte offset of the segment within the external file
+	Size   uint64 // Segment size in bytes, or message.ExternalSizeUnlimited
+}
+
+// ExternalFile is an open external file holding segments of raw data.
+type ExternalFile interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// ExternalOpener opens the external file of a segment, given its name as
+// recorded in the file.
+type ExternalOpener func(name string) (ExternalFile, error)
+
+// ExternalContiguous represents contiguous storage kept in external files,
+// as described by an external data files message. Each read opens the
+// files it needs once and closes them before returning.
+type ExternalContiguous struct {
+	segments  []ExternalSegment
+	dataspace *message.Dataspace
+	datatype  *message.Datatype
+	open      ExternalOpener
+}
+
+// NewExternalContiguous creates a layout handler for contiguous data held
+// in the given segments, opening their files with open.
+func NewExternalContiguous(
+	segments []ExternalSegment,
+	dataspace *message.Dataspace,
+	datatype *message.Datatype,
+	open ExternalOpener,
+) *ExternalContiguous {
+	return &ExternalContiguous{
+		segments:  segments,
+		dataspace: dataspace,
+		datatype:  datatype,
+		open:      open,
+	}
+}
+
+func (c *ExternalContiguous) Class() message.LayoutClass {
+	return message.LayoutContiguous
+}
+
+// Read reads all data from the external files.
+func (c *ExternalContiguous) Read() ([]byte, error) {
+	r := c.newReader()
+	defer r.close()
+	output := make([]byte, calculateDataSize(c.dataspace, c.datatype))
+	if err := r.readAt(output, 0); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// ReadSlice reads a hyperslab from the external files, one run of the
+// fastest-varying dimension at a time.
+func (c *ExternalContiguous) ReadSlice(start, count []uint64) ([]byte, error) {
+	dims := c.dataspace.Dimensions
+	if len(dims) == 0 {
+		// Scalar dataset
+		if len(start) == 0 && len(count) == 0 {
+			return c.Read()
+		}
+		return nil, fmt.Errorf("cannot slice scalar dataset with non-empty start/count")
+	}
+
+	if len(start) != len(dims) || len(count) != len(dims) {
+		return nil, fmt.Errorf("start and count must have %d dimensions, got %d and %d",
+			len(dims), len(start), len(count))
+	}
+
+	// Validate bounds
+	for d := 0; d < len(dims); d++ {
+		if start[d]+count[d] > dims[d] {
+			return nil, fmt.Errorf("slice out of bounds: dimension %d, start=%d, count=%d, size=%d",
+				d, start[d], count[d], dims[d])
+		}
+	}
+
+	elementSize := uint64(c.datatype.Size)
+	ndims := len(dims)
+	totalElements := uint64(1)
+	for _, n := range count {
+		totalElements *= n
+	}
+	output := make([]byte, totalElements*elementSize)
+	if totalElements == 0 {
+		return output, nil
+	}
+
+	r := c.newReader()
+	defer r.close()
+
+	// Step through the runs in row-major order, keeping the coordinates of
+	// the start of the current one in pos
+	runBytes := count[ndims-1] * elementSize
+	pos := make([]uint64, ndims)
+	for dst := uint64(0); dst < uint64(len(output)); dst += runBytes {
+		var index uint64
+		for d := range dims {
+			index = index*dims[d] + start[d] + pos[d]
+		}
+		if err := r.readAt(output[dst:dst+runBytes], index*elementSize); err != nil {
+			return nil, err
+		}
+		for d := ndims - 2; d >= 0; d-- {
+			if pos[d]++; pos[d] < count[d] {
+				break
+			}
+			pos[d] = 0
+		}
+	}
+	return output, nil
+}
+
+// ReadPoints reads the elements at the given points from the external
+// files.
+func (c *ExternalContiguous) ReadPoints(points [][]uint64) ([]byte, error) {
+	indexes, err := pointIndexes(c.dataspace.Dimensions, points)
+	if err != nil {
+		return nil, err
+	}
+	r := c.newReader()
+	defer r.close()
+
+	elementSize := uint64(c.datatype.Size)
+	output := make([]byte, uint64(len(points))*elementSize)
+	for i, index := range indexes {
+		elem := output[uint64(i)*elementSize : uint64(i+1)*elementSize]
+		if err := r.readAt(elem, index*elementSize); err != nil {
+			return nil, fmt.Errorf("reading point %d: %w", i, err)
+		}
+	}
+	return output, nil
+}
+
+// externalReader reads the data of an ExternalContiguous layout, keeping
+// the files it opens until close.
+type externalReader struct {
+	c     *ExternalContiguous
+	files map[string]ExternalFile
+}
+
+func (c *ExternalContiguous) newReader() *externalReader {
+	return &externalReader{c: c, files: make(map[string]ExternalFile)}
+}
+
+// file returns the open file of name, opening it on first use.
+func (r *externalReader) file(name string) (ExternalFile, error) {
+	if f, ok := r.files[name]; ok {
+		return f, nil
+	}
+	f, err := r.c.open(name)
+	if err != nil {
+		return nil, err
+	}
+	r.files[name] = f
+	return f, nil
+}
+
+func (r *externalReader) close() {
+	for _, f := range r.files {
+		f.Close()
+	}
+}
+
+// readAt fills buf with the data starting offset bytes into the
+// concatenation of the segments. Parts of a segment past the end of its
+// file read as zeros, as in the HDF5 library.
+func (r *externalReader) readAt(buf []byte, offset uint64) error {
+	var base uint64 // Offset of the current segment in the data
+	for _, seg := range r.c.segments {
+		if len(buf) == 0 {
+			return nil
+		}
+		end := uint64(math.MaxUint64)
+		if seg.Size != message.ExternalSizeUnlimited && seg.Size <= math.MaxUint64-base {
+			end = base + seg.Size
+		}
+		if offset < end {
+			n := min(uint64(len(buf)), end-offset)
+			fileOffset := seg.Offset + (offset - base)
+			if fileOffset < seg.Offset || fileOffset > math.MaxInt64 {
+				return fmt.Errorf("external file %q: offset %d too large", seg.Name, seg.Offset)
+			}
+			f, err := r.file(seg.Name)
+			if err != nil {
+				return err
+			}
+			read, err := f.ReadAt(buf[:n], int64(fileOffset))
+			if err != nil && err != io.EOF {
+				return fmt.Errorf("reading external file %q: %w", seg.Name, err)
+			}
+			clear(buf[read:n])
+			buf = buf[n:]
+			offset += n
+		}
+		if end == math.MaxUint64 {
+			break
+		}
+		base = end
+	}
+	if len(buf) > 0 {
+		return fmt.Errorf("external files hold %d bytes of data, read needs %d", base, offset+uint64(len(buf)))
+	}
+	return nil
+}
@@ -2,7 +2,14 @@
 package layout
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"math"
+	"math/bits"
+	"slices"
+	"sync"
+	"sync/atomic"
 
 	"github.com/robert-malhotra/go-hdf5/internal/binary"
 	"github.com/robert-malhotra/go-hdf5/internal/btree"
@@ -20,6 +27,11 @@ type Layout interface {
 	// Returns the raw bytes for the selected region in row-major order.
 	ReadSlice(start, count []uint64) ([]byte, error)
 
+	// ReadPoints reads the elements at the given coordinates, one entry per
+	// dimension each. Returns the raw bytes of the elements in the order of
+	// points.
+	ReadPoints(points [][]uint64) ([]byte, error)
+
 	// Class returns the layout class.
 	Class() message.LayoutClass
 }
@@ -35,6 +47,14 @@ func New(
 	if layout == nil {
 		return nil, fmt.Errorf("nil layout message")
 	}
+	if _, ok := dataSize(dataspace, datatype); !ok {
+		return nil, fmt.Errorf("dataset of dimensions %v and %d-byte elements is too large", dataspace.Dimensions, datatype.Size)
+	}
+	if layout.Class == message.LayoutChunked {
+		if err := checkChunkSize(layout.ChunkDims, dataspace, datatype); err != nil {
+			return nil, err
+		}
+	}
 
 	switch layout.Class {
 	case message.LayoutCompact:
@@ -51,12 +71,46 @@ func New(
 	}
 }
 
-// calculateDataSize calculates the total size of data in bytes.
+// maxChunkSize is the largest chunk in bytes, uncompressed, the HDF5
+// library allows, or that fits in an int if that is smaller.
+const maxChunkSize = min(1<<32-1, math.MaxInt)
+
+// calculateDataSize calculates the total size of data in bytes. New
+// rejects datasets whose size does not fit in an int, so it cannot wrap
+// for the layouts it returns.
 func calculateDataSize(dataspace *message.Dataspace, datatype *message.Datatype) uint64 {
+	size, _ := dataSize(dataspace, datatype)
+	return size
+}
+
+// dataSize returns the total size of data in bytes, and false if it does
+// not fit in an int.
+func dataSize(dataspace *message.Dataspace, datatype *message.Datatype) (uint64, bool) {
 	if dataspace == nil || datatype == nil {
-		return 0
+		return 0, true
+	}
+	size, ok := dataspace.DataSize(uint64(datatype.Size))
+	return size, ok && size <= math.MaxInt
+}
+
+// checkChunkSize checks that chunks of the given dimensions, trimmed to
+// the dataset's rank, are no larger than the HDF5 library allows.
+func checkChunkSize(chunkDims []uint32, dataspace *message.Dataspace, datatype *message.Datatype) error {
+	if dataspace == nil || datatype == nil {
+		return nil
+	}
+	if rank := len(dataspace.Dimensions); len(chunkDims) > rank && rank > 0 {
+		chunkDims = chunkDims[:rank]
+	}
+	size := uint64(datatype.Size)
+	for _, d := range chunkDims {
+		hi, lo := bits.Mul64(size, uint64(d))
+		if hi != 0 || lo > maxChunkSize {
+			return fmt.Errorf("chunks of %v elements of %d bytes are larger than %d bytes", chunkDims, datatype.Size, uint64(maxChunkSize))
+		}
+		size = lo
 	}
-	return dataspace.NumElements() * uint64(datatype.Size)
+	return nil
 }
 
 // extractHyperslab extracts a rectangular region from data stored in row-major order.
@@ -138,6 +192,20 @@ type Chunked struct {
 	datatype  *message.Datatype
 	pipeline  *filter.Pipeline
 	reader    *binary.Reader
+	fillValue []byte // One element; nil reads unallocated chunks as zeros
+
+	checksumPolicy ChecksumPolicy
+	skippedMu      sync.Mutex
+	skipped        []*ChunkError // Chunks read as the fill value by ChecksumSkip
+
+	// The chunk index is loaded on first use and then reused by every read.
+	// indexOnce makes the load safe for concurrent readers.
+	indexOnce  sync.Once
+	indexReady atomic.Bool // Set once the index has been loaded
+	indexType  string
+	entries    []btree.ChunkEntry
+	index      *btree.ChunkIndex // The entries, for finding chunks by offset
+	indexErr   error
 }
 
 // NewChunked creates a new chunked layout handler.
@@ -170,6 +238,50 @@ func (c *Chunked) Class() message.LayoutClass {
 	return message.LayoutChunked
 }
 
+// Allocated reports whether any chunk has been allocated in the file, that
+// is whether the dataset has a chunk index. Chunks that were never written
+// read as the fill value.
+func (c *Chunked) Allocated() bool {
+	addr := c.layout.ChunkIndexAddr
+	return addr != 0 && !c.reader.IsUndefinedOffset(addr)
+}
+
+// CheckShuffle returns a *filter.ShuffleSizeError if the dataset's shuffle
+// filter shuffles elements of other than its datatype's size.
+func (c *Chunked) CheckShuffle() error {
+	if c.pipeline == nil {
+		return nil
+	}
+	return c.pipeline.CheckElementSize(int(c.datatype.Size))
+}
+
+// SetFillValue sets the bytes of one element that parts of the dataset
+// with no allocated chunk read as. It must be called before the first
+// read. A nil value, the default, reads them as zeros.
+func (c *Chunked) SetFillValue(value []byte) {
+	c.fillValue = value
+}
+
+// newOutput allocates an output buffer of size bytes, filled with the fill
+// value.
+func (c *Chunked) newOutput(size uint64) []byte {
+	return filled(c.fillValue, size)
+}
+
+// filled allocates a buffer of size bytes holding repeats of the one
+// element fill, or zeros if fill is nil.
+func filled(fill []byte, size uint64) []byte {
+	output := make([]byte, size)
+	if len(fill) == 0 || bytes.Count(fill, []byte{0}) == len(fill) {
+		return output
+	}
+	n := copy(output, fill)
+	for n < len(output) {
+		n += copy(output[n:], output[:n])
+	}
+	return output
+}
+
 func (c *Chunked) Read() ([]byte, error) {
 	// Get dataset dimensions
 	dims := c.dataspace.Dimensions
@@ -197,8 +309,9 @@ func (c *Chunked) Read() ([]byte, error) {
 		return nil, nil
 	}
 
-	// Allocate output buffer
-	output := make([]byte, totalSize)
+	// Allocate output buffer; chunks that were never written keep the fill
+	// value
+	output := c.newOutput(totalSize)
 
 	// Calculate chunk size in bytes (uncompressed)
 	chunkElements := uint64(1)
@@ -207,87 +320,160 @@ func (c *Chunked) Read() ([]byte, error) {
 	}
 	chunkSizeBytes := chunkElements * elementSize
 
-	// Detect chunk index type by reading the signature at the index address
-	indexType, err := c.detectChunkIndexType()
+	_, entries, err := c.chunkIndex(dims, chunkDims)
+	if err != nil {
+		return nil, err
+	}
+
+	// Process each chunk, decoding each into the buffers of the last
+	var scratch chunkScratch
+	for _, entry := range entries {
+		if entry.Address == 0 || entry.Address == 0xFFFFFFFFFFFFFFFF {
+			continue // Skip empty/undefined chunks
+		}
+
+		offset, inside, err := chunkOffset(entry, dims)
+		if err != nil {
+			return nil, err
+		}
+		if !inside {
+			continue
+		}
+
+		chunkData, err := c.decodeChunk(entry, chunkSizeBytes, &scratch)
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(chunkData)) < chunkSizeBytes {
+			return nil, fmt.Errorf("chunk at offset %v decoded to %d bytes, expected %d",
+				offset, len(chunkData), chunkSizeBytes)
+		}
+
+		// Copy chunk data to the correct position in output buffer
+		err = c.copyChunkToOutput(output, chunkData, offset, dims, chunkDims, elementSize, chunkSizeBytes)
+		if err != nil {
+			return nil, fmt.Errorf("copying chunk at offset %v: %w", offset, err)
+		}
+	}
+
+	return output, nil
+}
+
+// chunkIndex returns the index type and chunk entries of the dataset, reading
+// the index from the file on the first call only. Single-chunk datasets have
+// no entries.
+func (c *Chunked) chunkIndex(dims []uint64, chunkDims []uint32) (string, []btree.ChunkEntry, error) {
+	c.indexOnce.Do(func() {
+		c.indexType, c.entries, c.indexErr = c.loadChunkIndex(dims, chunkDims)
+		c.index = &btree.ChunkIndex{NDims: len(dims), Entries: c.entries}
+		if len(chunkDims) == len(dims) && !slices.Contains(chunkDims, 0) {
+			c.index.Grid = make([]uint64, len(dims))
+			for d := range dims {
+				c.index.Grid[d] = (dims[d] + uint64(chunkDims[d]) - 1) / uint64(chunkDims[d])
+			}
+		}
+		c.indexReady.Store(true)
+	})
+	return c.indexType, c.entries, c.indexErr
+}
+
+// findChunks returns the entries of the allocated chunks at offsets, found
+// in the loaded index.
+func (c *Chunked) findChunks(dims []uint64, chunkDims []uint32, offsets [][]uint64) ([]btree.ChunkEntry, error) {
+	if _, _, err := c.chunkIndex(dims, chunkDims); err != nil {
+		return nil, err
+	}
+	var entries []btree.ChunkEntry
+	for _, offset := range offsets {
+		if entry := c.index.FindChunk(offset, chunkDims); entry != nil {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries, nil
+}
+
+// loadChunkIndex reads the chunk index from the file.
+func (c *Chunked) loadChunkIndex(dims []uint64, chunkDims []uint32) (string, []btree.ChunkEntry, error) {
+	indexType, err := c.indexTypeName()
 	if err != nil {
-		return nil, fmt.Errorf("detecting chunk index type: %w", err)
+		return "", nil, err
 	}
 
+	var entries []btree.ChunkEntry
 	switch indexType {
 	case "single":
-		return c.readSingleChunk(totalSize)
+		// The index address is the chunk itself. Its size is recorded only
+		// when it is filtered; decodeChunk reads a full chunk otherwise.
+		entries = []btree.ChunkEntry{{
+			Offset:     make([]uint64, len(dims)),
+			Address:    c.layout.ChunkIndexAddr,
+			Size:       c.layout.FilteredChunkSize,
+			FilterMask: c.layout.FilterMask,
+		}}
+
+	case "implicit":
+		entries = c.readImplicitIndex(dims, chunkDims)
 
 	case "btree_v1":
-		return c.readBTreeV1Chunks(dims, chunkDims, elementSize, chunkSizeBytes, output)
+		chunkIndex, err := btree.ReadChunkIndex(c.reader, c.layout.ChunkIndexAddr, len(dims))
+		if err != nil {
+			return "", nil, fmt.Errorf("reading chunk index: %w", err)
+		}
+		entries = chunkIndex.Entries
 
 	case "fixed_array":
-		return c.readFixedArrayChunks(dims, chunkDims, elementSize, chunkSizeBytes, output)
+		entries, err = c.readFixedArrayIndex(dims, chunkDims)
+		if err != nil {
+			return "", nil, fmt.Errorf("reading fixed array index: %w", err)
+		}
 
 	case "extensible_array":
-		return c.readExtensibleArrayChunks(dims, chunkDims, elementSize, chunkSizeBytes, output)
+		entries, err = c.readExtensibleArrayIndex(dims, chunkDims)
+		if err != nil {
+			return "", nil, fmt.Errorf("reading extensible array index: %w", err)
+		}
 
 	case "btree_v2":
-		return c.readBTreeV2Chunks(dims, chunkDims, elementSize, chunkSizeBytes, output)
-
-	default:
-		return nil, fmt.Errorf("unsupported chunk index type: %s", indexType)
+		chunkIndex, err := btree.ReadChunkIndexV2(c.reader, c.layout.ChunkIndexAddr, len(dims))
+		if err != nil {
+			return "", nil, fmt.Errorf("reading B-tree v2 chunk index: %w", err)
+		}
+		entries = chunkIndex.Entries
 	}
+
+	return indexType, entries, nil
 }
 
-// detectChunkIndexType reads the signature at ChunkIndexAddr to determine the index type.
-func (c *Chunked) detectChunkIndexType() (string, error) {
+// indexTypeName names the type of the chunk index, or returns
+// "unallocated" if no chunk has been written. Layout messages before
+// version 4 always index chunks with a version 1 B-tree; version 4 names
+// the index type explicitly.
+func (c *Chunked) indexTypeName() (string, error) {
 	if c.layout.ChunkIndexAddr == 0 || c.layout.ChunkIndexAddr == 0xFFFFFFFFFFFFFFFF {
-		return "single", nil // Assume single chunk if no valid address
-	}
-
-	nr := c.reader.At(int64(c.layout.ChunkIndexAddr))
-	sig, err := nr.ReadBytes(4)
-	if err != nil {
-		// If we can't read, assume single chunk
-		return "single", nil
+		return "unallocated", nil
 	}
-
-	sigStr := string(sig)
-	switch sigStr {
-	case "TREE":
+	if c.layout.Version < 4 {
 		return "btree_v1", nil
-	case "FARY", "FAHD":
+	}
+	switch c.layout.ChunkIndexType {
+	case message.ChunkIndexSingleChunk:
+		return "single", nil
+	case message.ChunkIndexImplicit:
+		return "implicit", nil
+	case message.ChunkIndexFixedArray:
 		return "fixed_array", nil
-	case "EAHD":
+	case message.ChunkIndexExtensibleArray:
 		return "extensible_array", nil
-	case "BTHD":
+	case message.ChunkIndexBTreeV2:
 		return "btree_v2", nil
-	default:
-		// Not a known index signature - might be raw chunk data (single chunk)
-		return "single", nil
 	}
+	return "", fmt.Errorf("unsupported chunk index type: %d", c.layout.ChunkIndexType)
 }
 
-// readSingleChunk reads a dataset stored as a single chunk.
-func (c *Chunked) readSingleChunk(totalSize uint64) ([]byte, error) {
-	nr := c.reader.At(int64(c.layout.ChunkIndexAddr))
-	data, err := nr.ReadBytes(int(totalSize))
-	if err != nil {
-		return nil, fmt.Errorf("reading single chunk: %w", err)
-	}
-
-	// Apply filter pipeline if present
-	if c.pipeline != nil && !c.pipeline.Empty() {
-		data, err = c.pipeline.Decode(data, 0)
-		if err != nil {
-			return nil, fmt.Errorf("decoding single chunk: %w", err)
-		}
-	}
-
-	return data, nil
-}
-
-// readImplicitChunks reads chunks stored contiguously without an explicit index.
-func (c *Chunked) readImplicitChunks(dims []uint64, chunkDims []uint32, elementSize uint64) ([]byte, error) {
-	totalSize := calculateDataSize(c.dataspace, c.datatype)
-	output := make([]byte, totalSize)
-
-	// Calculate number of chunks in each dimension
+// readImplicitIndex lists the chunks of an implicit index. Every chunk is
+// allocated at full size, unfiltered, one after another in row-major chunk
+// order from the index address.
+func (c *Chunked) readImplicitIndex(dims []uint64, chunkDims []uint32) []btree.ChunkEntry {
 	ndims := len(dims)
 	numChunks := make([]uint64, ndims)
 	totalChunks := uint64(1)
@@ -296,206 +482,429 @@ func (c *Chunked) readImplicitChunks(dims []uint64, chunkDims []uint32, elementS
 		totalChunks *= numChunks[d]
 	}
 
-	// Calculate uncompressed chunk size
-	chunkBytes := elementSize
+	chunkBytes := uint64(c.datatype.Size)
 	for _, cd := range chunkDims {
 		chunkBytes *= uint64(cd)
 	}
 
-	// Read chunks in row-major order
-	nr := c.reader.At(int64(c.layout.ChunkIndexAddr))
-	chunkOffset := make([]uint64, ndims)
-
+	entries := make([]btree.ChunkEntry, totalChunks)
 	for chunkIdx := uint64(0); chunkIdx < totalChunks; chunkIdx++ {
-		// Calculate chunk coordinates
+		offset := make([]uint64, ndims)
 		remaining := chunkIdx
 		for d := ndims - 1; d >= 0; d-- {
-			chunkOffset[d] = (remaining % numChunks[d]) * uint64(chunkDims[d])
+			offset[d] = (remaining % numChunks[d]) * uint64(chunkDims[d])
 			remaining /= numChunks[d]
 		}
-
-		// Read chunk data
-		chunkData, err := nr.ReadBytes(int(chunkBytes))
-		if err != nil {
-			return nil, fmt.Errorf("reading implicit chunk %d: %w", chunkIdx, err)
-		}
-
-		// Apply filter pipeline if present
-		if c.pipeline != nil && !c.pipeline.Empty() {
-			chunkData, err = c.pipeline.Decode(chunkData, 0)
-			if err != nil {
-				return nil, fmt.Errorf("decoding implicit chunk %d: %w", chunkIdx, err)
-			}
+		entries[chunkIdx] = btree.ChunkEntry{
+			Offset:  offset,
+			Address: c.layout.ChunkIndexAddr + chunkIdx*chunkBytes,
+			Size:    chunkBytes,
 		}
+	}
+	return entries
+}
 
-		// Copy to output
-		err = c.copyChunkToOutput(output, chunkData, chunkOffset, dims, chunkDims, elementSize, chunkBytes)
-		if err != nil {
-			return nil, fmt.Errorf("copying implicit chunk %d: %w", chunkIdx, err)
-		}
+// ForEachChunk reads and decodes the allocated chunks one at a time, in
+// chunk index order, and calls fn with each chunk's starting coordinates in
+// dataset space and its data. The whole dataset is never assembled in
+// memory.
+//
+// Chunks at the upper edge of the dataset are trimmed to the part that lies
+// inside the dataset's shape, so data always holds, in row-major order, the
+// elements from offset up to min(offset+chunk dims, dataset dims).
+// Unallocated chunks, which read as the fill value, are skipped. fn may
+// keep data. An error returned by fn stops the iteration and is returned
+// as is.
+func (c *Chunked) ForEachChunk(fn func(offset []uint64, data []byte) error) error {
+	dims := c.dataspace.Dimensions
+	if len(dims) == 0 {
+		dims = []uint64{1}
+	}
+	chunkDims := c.layout.ChunkDims
+	if len(chunkDims) == 0 {
+		return fmt.Errorf("chunked layout has no chunk dimensions")
+	}
+	if len(chunkDims) > len(dims) {
+		chunkDims = chunkDims[:len(dims)]
 	}
 
-	return output, nil
-}
+	elementSize := uint64(c.datatype.Size)
+	totalSize := calculateDataSize(c.dataspace, c.datatype)
+	if totalSize == 0 {
+		return nil
+	}
+	chunkSizeBytes := elementSize
+	for _, d := range chunkDims {
+		chunkSizeBytes *= uint64(d)
+	}
 
-// readFixedArrayChunks reads chunks indexed by a fixed array.
-func (c *Chunked) readFixedArrayChunks(dims []uint64, chunkDims []uint32, elementSize, chunkSizeBytes uint64, output []byte) ([]byte, error) {
-	// Read fixed array header
-	entries, err := c.readFixedArrayIndex(dims, chunkDims)
+	_, entries, err := c.chunkIndex(dims, chunkDims)
 	if err != nil {
-		return nil, fmt.Errorf("reading fixed array index: %w", err)
+		return err
 	}
 
-	// Process each chunk
 	for _, entry := range entries {
 		if entry.Address == 0 || entry.Address == 0xFFFFFFFFFFFFFFFF {
-			continue // Skip empty/undefined chunks
+			continue
 		}
-
-		// Read chunk data
-		chunkData, err := c.readChunkData(entry)
+		offset, inside, err := chunkOffset(entry, dims)
 		if err != nil {
-			return nil, fmt.Errorf("reading chunk at offset %v: %w", entry.Offset, err)
+			return err
 		}
-
-		// Apply filter pipeline
-		if c.pipeline != nil && !c.pipeline.Empty() {
-			chunkData, err = c.pipeline.Decode(chunkData, entry.FilterMask)
-			if err != nil {
-				return nil, fmt.Errorf("decoding chunk at offset %v: %w", entry.Offset, err)
-			}
+		if !inside {
+			continue
 		}
 
-		// Copy to output
-		err = c.copyChunkToOutput(output, chunkData, entry.Offset, dims, chunkDims, elementSize, chunkSizeBytes)
+		chunkData, err := c.decodeChunk(entry, chunkSizeBytes, nil)
+		if err != nil {
+			return err
+		}
+		chunkData, err = trimChunk(chunkData, offset, dims, chunkDims, elementSize)
 		if err != nil {
-			return nil, fmt.Errorf("copying chunk at offset %v: %w", entry.Offset, err)
+			return fmt.Errorf("trimming chunk at offset %v: %w", offset, err)
+		}
+		if err := fn(offset, chunkData); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	return output, nil
+// RawChunk is a chunk as stored in the file, before its filters are
+// undone.
+type RawChunk struct {
+	Offset     []uint64 // Coordinates of the chunk's first element
+	FilterMask uint32   // Filters skipped when the chunk was written
+	Data       []byte   // Stored bytes, possibly compressed
 }
 
-// readExtensibleArrayChunks reads chunks indexed by an extensible array.
-func (c *Chunked) readExtensibleArrayChunks(dims []uint64, chunkDims []uint32, elementSize, chunkSizeBytes uint64, output []byte) ([]byte, error) {
-	// Read extensible array header
-	entries, err := c.readExtensibleArrayIndex(dims, chunkDims)
-	if err != nil {
-		return nil, fmt.Errorf("reading extensible array index: %w", err)
+// ForEachRawChunk reads the allocated chunks inside the dataset's extent
+// one at a time, in chunk index order, and calls fn with each as stored in
+// the file: neither decoded by the filter pipeline nor trimmed to the
+// extent. Chunks stored without filters hold a full chunk. An error
+// returned by fn stops the iteration and is returned as is.
+func (c *Chunked) ForEachRawChunk(fn func(chunk RawChunk) error) error {
+	dims := c.dataspace.Dimensions
+	if len(dims) == 0 {
+		dims = []uint64{1}
+	}
+	chunkDims := c.layout.ChunkDims
+	if len(chunkDims) == 0 {
+		return fmt.Errorf("chunked layout has no chunk dimensions")
+	}
+	if len(chunkDims) > len(dims) {
+		chunkDims = chunkDims[:len(dims)]
+	}
+	if calculateDataSize(c.dataspace, c.datatype) == 0 {
+		return nil
+	}
+	chunkSizeBytes := uint64(c.datatype.Size)
+	for _, d := range chunkDims {
+		chunkSizeBytes *= uint64(d)
 	}
 
-	// Process each chunk
+	_, entries, err := c.chunkIndex(dims, chunkDims)
+	if err != nil {
+		return err
+	}
 	for _, entry := range entries {
 		if entry.Address == 0 || entry.Address == 0xFFFFFFFFFFFFFFFF {
-			continue // Skip empty/undefined chunks
+			continue
 		}
-
-		// Read chunk data
-		chunkData, err := c.readChunkData(entry)
+		offset, inside, err := chunkOffset(entry, dims)
 		if err != nil {
-			return nil, fmt.Errorf("reading chunk at offset %v: %w", entry.Offset, err)
+			return err
 		}
-
-		// Apply filter pipeline
-		if c.pipeline != nil && !c.pipeline.Empty() {
-			chunkData, err = c.pipeline.Decode(chunkData, entry.FilterMask)
-			if err != nil {
-				return nil, fmt.Errorf("decoding chunk at offset %v: %w", entry.Offset, err)
-			}
+		if !inside {
+			continue
 		}
-
-		// Copy to output
-		err = c.copyChunkToOutput(output, chunkData, entry.Offset, dims, chunkDims, elementSize, chunkSizeBytes)
+		if entry.Size == 0 {
+			entry.Size = chunkSizeBytes
+		}
+		data, err := c.readChunkData(entry, nil)
 		if err != nil {
-			return nil, fmt.Errorf("copying chunk at offset %v: %w", entry.Offset, err)
+			return fmt.Errorf("reading chunk at offset %v: %w", offset, err)
+		}
+		if err := fn(RawChunk{Offset: offset, FilterMask: entry.FilterMask, Data: data}); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	return output, nil
+// Storage returns the number of chunks allocated inside the dataset's
+// extent, the bytes they occupy in the file and the size of one chunk
+// uncompressed. It reads the chunk index but no chunk data: stored sizes
+// come from the index entries, and chunks stored without filters, whose
+// entries may not record one, take the uncompressed size.
+func (c *Chunked) Storage() (chunks int, stored, chunkBytes uint64, err error) {
+	dims := c.dataspace.Dimensions
+	if len(dims) == 0 {
+		dims = []uint64{1}
+	}
+	chunkDims := c.layout.ChunkDims
+	if len(chunkDims) == 0 {
+		return 0, 0, 0, fmt.Errorf("chunked layout has no chunk dimensions")
+	}
+	if len(chunkDims) > len(dims) {
+		chunkDims = chunkDims[:len(dims)]
+	}
+	chunkBytes = uint64(c.datatype.Size)
+	for _, d := range chunkDims {
+		chunkBytes *= uint64(d)
+	}
+	if calculateDataSize(c.dataspace, c.datatype) == 0 {
+		return 0, 0, chunkBytes, nil
+	}
+
+	_, entries, err := c.chunkIndex(dims, chunkDims)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	for _, entry := range entries {
+		if entry.Address == 0 || entry.Address == 0xFFFFFFFFFFFFFFFF {
+			continue
+		}
+		if _, inside, err := chunkOffset(entry, dims); err != nil {
+			return 0, 0, 0, err
+		} else if !inside {
+			continue
+		}
+		chunks++
+		if entry.Size == 0 {
+			stored += chunkBytes
+		} else {
+			stored += uint64(entry.Size)
+		}
+	}
+	return chunks, stored, chunkBytes, nil
 }
 
-// readBTreeV1Chunks reads chunks indexed by a v1 B-tree.
-func (c *Chunked) readBTreeV1Chunks(dims []uint64, chunkDims []uint32, elementSize, chunkSizeBytes uint64, output []byte) ([]byte, error) {
-	ndims := len(dims)
-	chunkIndex, err := btree.ReadChunkIndex(c.reader, c.layout.ChunkIndexAddr, ndims)
+// Space calls fn with the address and size of each block of the chunk
+// index, with chunk false, and of each allocated chunk, with chunk true.
+// Chunks outside the current extent are included, since they still take
+// space in the file. k is the superblock's indexed storage K value, which
+// sizes the nodes of version 1 B-tree indexes.
+func (c *Chunked) Space(k int, fn func(addr, size uint64, chunk bool)) error {
+	dims := c.dataspace.Dimensions
+	if len(dims) == 0 {
+		dims = []uint64{1}
+	}
+	chunkDims := c.layout.ChunkDims
+	if len(chunkDims) == 0 {
+		return fmt.Errorf("chunked layout has no chunk dimensions")
+	}
+	if len(chunkDims) > len(dims) {
+		chunkDims = chunkDims[:len(dims)]
+	}
+	chunkBytes := uint64(c.datatype.Size)
+	for _, d := range chunkDims {
+		chunkBytes *= uint64(d)
+	}
+
+	indexType, err := c.indexTypeName()
+	if err != nil {
+		return err
+	}
+	index := func(addr, size uint64) { fn(addr, size, false) }
+	addr := c.layout.ChunkIndexAddr
+	switch indexType {
+	case "unallocated":
+		return nil
+	case "btree_v1":
+		err = btree.ChunkSpace(c.reader, addr, len(dims), k, index)
+	case "btree_v2":
+		err = btree.SpaceV2(c.reader, addr, index)
+	case "fixed_array":
+		err = c.fixedArraySpace(index)
+	case "extensible_array":
+		err = c.walkExtensibleArray(chunkDims, func(uint64, btree.ChunkEntry) {}, index)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("reading chunk index: %w", err)
+		return fmt.Errorf("reading %s chunk index: %w", indexType, err)
 	}
 
-	// Process each chunk
-	for _, entry := range chunkIndex.Entries {
-		// Read raw chunk data from disk
-		chunkData, err := c.readChunkData(entry)
-		if err != nil {
-			return nil, fmt.Errorf("reading chunk at offset %v: %w", entry.Offset, err)
+	_, entries, err := c.chunkIndex(dims, chunkDims)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Address == 0 || c.reader.IsUndefinedOffset(entry.Address) {
+			continue
 		}
+		size := uint64(entry.Size)
+		if size == 0 {
+			size = chunkBytes
+		}
+		fn(entry.Address, size, true)
+	}
+	return nil
+}
 
-		// Apply filter pipeline (decompress)
-		if c.pipeline != nil && !c.pipeline.Empty() {
-			chunkData, err = c.pipeline.Decode(chunkData, entry.FilterMask)
-			if err != nil {
-				return nil, fmt.Errorf("decoding chunk at offset %v: %w", entry.Offset, err)
-			}
+// fixedArraySpace calls fn with the address and size of the header and the
+// data block of a fixed array chunk index. A data block with more entries
+// than fit in a page is followed by its pages, each with a checksum, and
+// starts with a bitmap of the pages written.
+func (c *Chunked) fixedArraySpace(fn func(addr, size uint64)) error {
+	o, l := c.reader.OffsetSize(), c.reader.LengthSize()
+	hr := c.reader.At(int64(c.layout.ChunkIndexAddr))
+	header, err := hr.ReadBytes(8)
+	if err != nil {
+		return err
+	}
+	if string(header[:4]) != "FAHD" {
+		return fmt.Errorf("invalid fixed array signature: got %q, expected \"FAHD\"", string(header[:4]))
+	}
+	entrySize, pageBits := uint64(header[6]), header[7]
+	numEntries, err := hr.ReadLength()
+	if err != nil {
+		return err
+	}
+	dataBlockAddr, err := hr.ReadOffset()
+	if err != nil {
+		return err
+	}
+	fn(c.layout.ChunkIndexAddr, uint64(12+l+o))
+	if c.reader.IsUndefinedOffset(dataBlockAddr) {
+		return nil
+	}
+
+	size := uint64(4+1+1+o) + numEntries*entrySize + 4
+	if pageBits < 64 && numEntries > 1<<pageBits {
+		pageEntries := uint64(1) << pageBits
+		pages := (numEntries + pageEntries - 1) / pageEntries
+		size += (pages+7)/8 + pages*4
+	}
+	fn(dataBlockAddr, size)
+	return nil
+}
+
+// chunkOffset returns the coordinates of a chunk, trimmed to the rank of
+// the dataset, and whether the chunk starts inside the current extent.
+// Chunks past it are left behind by datasets that have since shrunk and
+// are not part of the data.
+func chunkOffset(entry btree.ChunkEntry, dims []uint64) ([]uint64, bool, error) {
+	if len(entry.Offset) < len(dims) {
+		return nil, false, fmt.Errorf("chunk at address %d has %d coordinates, dataset has rank %d",
+			entry.Address, len(entry.Offset), len(dims))
+	}
+	offset := entry.Offset[:len(dims)]
+	for d := range dims {
+		if offset[d] >= dims[d] {
+			return offset, false, nil
 		}
+	}
+	return offset, true, nil
+}
 
-		// Copy chunk data to the correct position in output buffer
-		err = c.copyChunkToOutput(output, chunkData, entry.Offset, dims, chunkDims, elementSize, chunkSizeBytes)
+// chunkScratch holds the buffers a read reuses from one chunk to the next:
+// chunks decoded with it are only valid until the next is decoded.
+type chunkScratch struct {
+	raw     []byte
+	filters filter.Buffers
+}
+
+// decodeChunk reads a chunk from disk and runs it through the filter
+// pipeline, into the buffers of scratch unless it is nil. Entries without
+// a size, as in unfiltered B-tree v2 indexes, are read as a full
+// uncompressed chunk. Filter errors are returned as a *ChunkError; under
+// ChecksumSkip, a chunk failing its checksum is read as the fill value
+// instead.
+func (c *Chunked) decodeChunk(entry btree.ChunkEntry, chunkSizeBytes uint64, scratch *chunkScratch) ([]byte, error) {
+	if entry.Size == 0 {
+		entry.Size = chunkSizeBytes
+	}
+	var raw []byte
+	var bufs *filter.Buffers
+	if scratch != nil {
+		raw, bufs = scratch.raw, &scratch.filters
+	}
+	chunkData, err := c.readChunkData(entry, raw)
+	if err != nil {
+		return nil, fmt.Errorf("reading chunk at offset %v: %w", entry.Offset, err)
+	}
+	if scratch != nil {
+		scratch.raw = chunkData
+	}
+	if c.pipeline != nil && !c.pipeline.Empty() {
+		chunkData, err = c.pipeline.DecodeInto(bufs, chunkData, entry.FilterMask, int(min(chunkSizeBytes, math.MaxInt)))
 		if err != nil {
-			return nil, fmt.Errorf("copying chunk at offset %v: %w", entry.Offset, err)
+			chunkErr := &ChunkError{Offset: entry.Offset, Address: entry.Address, Err: err}
+			if c.checksumPolicy == ChecksumSkip && errors.Is(err, filter.ErrChecksumMismatch) {
+				c.skip(chunkErr)
+				return c.newOutput(chunkSizeBytes), nil
+			}
+			return nil, chunkErr
 		}
 	}
-
-	return output, nil
+	return chunkData, nil
 }
 
-// readBTreeV2Chunks reads chunks indexed by a v2 B-tree.
-func (c *Chunked) readBTreeV2Chunks(dims []uint64, chunkDims []uint32, elementSize, chunkSizeBytes uint64, output []byte) ([]byte, error) {
+// trimChunk returns the part of a decoded chunk that lies inside the
+// dataset, in row-major order. Chunks entirely inside are returned without
+// copying.
+func trimChunk(data []byte, offset, dims []uint64, chunkDims []uint32, elementSize uint64) ([]byte, error) {
 	ndims := len(dims)
-	chunkIndex, err := btree.ReadChunkIndexV2(c.reader, c.layout.ChunkIndexAddr, ndims)
-	if err != nil {
-		return nil, fmt.Errorf("reading B-tree v2 chunk index: %w", err)
+	extent := make([]uint64, ndims)
+	elements := uint64(1)
+	chunkBytes := elementSize
+	trimmed := false
+	for d := 0; d < ndims; d++ {
+		extent[d] = min(uint64(chunkDims[d]), dims[d]-offset[d])
+		elements *= extent[d]
+		chunkBytes *= uint64(chunkDims[d])
+		trimmed = trimmed || extent[d] < uint64(chunkDims[d])
+	}
+	if uint64(len(data)) < chunkBytes {
+		return nil, fmt.Errorf("decoded chunk has %d bytes, expected %d", len(data), chunkBytes)
+	}
+	if !trimmed {
+		return data[:chunkBytes], nil
 	}
 
-	// Process each chunk
-	for _, entry := range chunkIndex.Entries {
-		// For B-tree v2 type 10 (no filter), Size may be 0 - calculate from chunk dims
-		chunkEntry := entry
-		if chunkEntry.Size == 0 {
-			chunkEntry.Size = uint32(chunkSizeBytes)
-		}
-
-		// Read raw chunk data from disk
-		chunkData, err := c.readChunkData(chunkEntry)
-		if err != nil {
-			return nil, fmt.Errorf("reading chunk at offset %v: %w", entry.Offset, err)
+	// Copy one row of the innermost dimension at a time. idx walks the
+	// outer dimensions of the kept region; its last entry stays zero.
+	output := make([]byte, elements*elementSize)
+	rowBytes := extent[ndims-1] * elementSize
+	idx := make([]uint64, ndims)
+	for pos := uint64(0); pos < uint64(len(output)); pos += rowBytes {
+		src := uint64(0)
+		for d := 0; d < ndims; d++ {
+			src = src*uint64(chunkDims[d]) + idx[d]
 		}
+		src *= elementSize
+		copy(output[pos:pos+rowBytes], data[src:src+rowBytes])
 
-		// Apply filter pipeline (decompress)
-		if c.pipeline != nil && !c.pipeline.Empty() {
-			chunkData, err = c.pipeline.Decode(chunkData, entry.FilterMask)
-			if err != nil {
-				return nil, fmt.Errorf("decoding chunk at offset %v: %w", entry.Offset, err)
+		for d := ndims - 2; d >= 0; d-- {
+			idx[d]++
+			if idx[d] < extent[d] {
+				break
 			}
-		}
-
-		// Copy chunk data to the correct position in output buffer
-		err = c.copyChunkToOutput(output, chunkData, entry.Offset, dims, chunkDims, elementSize, chunkSizeBytes)
-		if err != nil {
-			return nil, fmt.Errorf("copying chunk at offset %v: %w", entry.Offset, err)
+			idx[d] = 0
 		}
 	}
-
 	return output, nil
 }
 
-// readChunkData reads the raw (possibly compressed) chunk data from disk.
-func (c *Chunked) readChunkData(entry btree.ChunkEntry) ([]byte, error) {
+// readChunkData reads the raw (possibly compressed) chunk data from disk,
+// into buf if it has room.
+func (c *Chunked) readChunkData(entry btree.ChunkEntry, buf []byte) ([]byte, error) {
 	if entry.Address == 0 || entry.Address == 0xFFFFFFFFFFFFFFFF {
 		return nil, fmt.Errorf("invalid chunk address")
 	}
 
-	nr := c.reader.At(int64(entry.Address))
-	return nr.ReadBytes(int(entry.Size))
+	if entry.Size > math.MaxInt {
+		return nil, fmt.Errorf("chunk of %d bytes is too large to read", entry.Size)
+	}
+	nr := c.reader.AtSize(int64(entry.Address), int(entry.Size))
+	if uint64(cap(buf)) < entry.Size {
+		return nr.ReadBytes(int(entry.Size))
+	}
+	buf = buf[:entry.Size]
+	if err := nr.ReadInto(buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
 }
 
 // copyChunkToOutput copies decompressed chunk data to the correct position in the output buffer.
@@ -665,7 +1074,7 @@ func (c *Chunked) copyChunkRecursive(
 
 // readFixedArrayIndex reads chunk entries from a fixed array index.
 func (c *Chunked) readFixedArrayIndex(dims []uint64, chunkDims []uint32) ([]btree.ChunkEntry, error) {
-	nr := c.reader.At(int64(c.layout.ChunkIndexAddr))
+	nr := c.reader.AtSize(int64(c.layout.ChunkIndexAddr), 12+c.reader.LengthSize()+c.reader.OffsetSize())
 
 	// Read fixed array header signature
 	sig, err := nr.ReadBytes(4)
@@ -715,13 +1124,21 @@ func (c *Chunked) readFixedArrayIndex(dims []uint64, chunkDims []uint32) ([]btre
 		return nil, err
 	}
 
+	// Checksum (4 bytes)
+	if err := c.reader.CheckLookup3At("FAHD", c.layout.ChunkIndexAddr, int(nr.Pos()-int64(c.layout.ChunkIndexAddr))+4); err != nil {
+		return nil, err
+	}
+
 	// Now read the data block
+	if numEntries > math.MaxInt/uint64(max(entrySize, 1)) {
+		return nil, fmt.Errorf("fixed array of %d %d-byte entries is too large", numEntries, entrySize)
+	}
 	return c.readFixedArrayDataBlock(dataBlockAddr, int(numEntries), int(entrySize), dims, chunkDims)
 }
 
 // readFixedArrayDataBlock reads chunk entries from a fixed array data block.
 func (c *Chunked) readFixedArrayDataBlock(addr uint64, numEntries, entrySize int, dims []uint64, chunkDims []uint32) ([]btree.ChunkEntry, error) {
-	nr := c.reader.At(int64(addr))
+	nr := c.reader.AtSize(int64(addr), 6+c.reader.OffsetSize()+numEntries*entrySize+4)
 
 	// Read data block signature
 	sig, err := nr.ReadBytes(4)
@@ -752,6 +1169,10 @@ func (c *Chunked) readFixedArrayDataBlock(addr uint64, numEntries, entrySize int
 
 	// Page bitmap (optional, not always present for small arrays)
 	// For now, assume no page bitmap and read entries directly
+	prefixSize := int(nr.Pos() - int64(addr))
+	if err := c.reader.CheckLookup3At("FADB", addr, prefixSize+numEntries*entrySize+4); err != nil {
+		return nil, err
+	}
 
 	ndims := len(dims)
 	numChunksPerDim := make([]uint64, ndims)
@@ -770,281 +1191,51 @@ func (c *Chunked) readFixedArrayDataBlock(addr uint64, numEntries, entrySize int
 			remaining /= numChunksPerDim[d]
 		}
 
-		// Read entry based on entry size
-		// Entry format depends on whether filters are used
-		var chunkAddr uint64
-		var chunkSize uint32
-		var filterMask uint32
-
-		if entrySize <= 8 {
-			// Just address (no filters)
-			chunkAddr, err = nr.ReadOffset()
-			if err != nil {
-				return nil, fmt.Errorf("reading chunk address: %w", err)
-			}
-			// Use full chunk size
-			chunkSize = 1
-			for _, cd := range chunkDims {
-				chunkSize *= cd
-			}
-			chunkSize *= uint32(c.datatype.Size)
-		} else {
-			// Address + filter info
-			// Entry format for filtered chunks: addr (offsetSize) + size (variable) + mask (4 bytes)
-			chunkAddr, err = nr.ReadOffset()
-			if err != nil {
-				return nil, fmt.Errorf("reading chunk address: %w", err)
-			}
-
-			// Size bytes = entrySize - offsetSize - 4 (mask is always 4 bytes)
-			sizeBytes := entrySize - c.reader.OffsetSize() - 4
-			if sizeBytes > 0 {
-				sizeData, err := nr.ReadBytes(sizeBytes)
-				if err != nil {
-					return nil, fmt.Errorf("reading chunk size: %w", err)
-				}
-				// Read as little-endian variable-length integer
-				for j := 0; j < sizeBytes; j++ {
-					chunkSize |= uint32(sizeData[j]) << (8 * j)
-				}
-			}
-
-			// Filter mask is always 4 bytes
-			filterMask, err = nr.ReadUint32()
-			if err != nil {
-				return nil, fmt.Errorf("reading filter mask: %w", err)
-			}
+		raw, err := nr.ReadBytes(entrySize)
+		if err != nil {
+			return nil, fmt.Errorf("reading fixed array element %d: %w", i, err)
+		}
+		entry, err := c.decodeChunkIndexEntry(raw, chunkDims)
+		if err != nil {
+			return nil, fmt.Errorf("fixed array element %d: %w", i, err)
 		}
 
-		if chunkAddr != 0 && chunkAddr != 0xFFFFFFFFFFFFFFFF {
-			entries = append(entries, btree.ChunkEntry{
-				Offset:     offset,
-				FilterMask: filterMask,
-				Size:       chunkSize,
-				Address:    chunkAddr,
-			})
+		if entry.Address != 0 && !c.reader.IsUndefinedOffset(entry.Address) {
+			entry.Offset = offset
+			entries = append(entries, entry)
 		}
 	}
 
 	return entries, nil
 }
 
-// readExtensibleArrayIndex reads chunk entries from an extensible array index.
-func (c *Chunked) readExtensibleArrayIndex(dims []uint64, chunkDims []uint32) ([]btree.ChunkEntry, error) {
-	nr := c.reader.At(int64(c.layout.ChunkIndexAddr))
-
-	// Read extensible array header signature
-	sig, err := nr.ReadBytes(4)
-	if err != nil {
-		return nil, fmt.Errorf("reading extensible array signature: %w", err)
-	}
-	if string(sig) != "EAHD" {
-		return nil, fmt.Errorf("invalid extensible array signature: got %q, expected \"EAHD\"", string(sig))
-	}
-
-	// Version (1 byte)
-	version, err := nr.ReadUint8()
-	if err != nil {
-		return nil, err
-	}
-	if version != 0 {
-		return nil, fmt.Errorf("unsupported extensible array version: %d", version)
-	}
-
-	// Client ID (1 byte)
-	_, err = nr.ReadUint8()
-	if err != nil {
-		return nil, err
-	}
-
-	// Element size (1 byte)
-	elemSize, err := nr.ReadUint8()
-	if err != nil {
-		return nil, err
-	}
-
-	// Max number of elements bits (1 byte)
-	_, err = nr.ReadUint8()
-	if err != nil {
-		return nil, err
-	}
-
-	// Index block element count bits (1 byte)
-	idxBlkElmts, err := nr.ReadUint8()
-	if err != nil {
-		return nil, err
-	}
-
-	// Data block min element count bits (1 byte)
-	_, err = nr.ReadUint8()
-	if err != nil {
-		return nil, err
-	}
-
-	// Super block min element count bits (1 byte)
-	_, err = nr.ReadUint8()
-	if err != nil {
-		return nil, err
-	}
-
-	// Data block page max element count bits (1 byte)
-	_, err = nr.ReadUint8()
-	if err != nil {
-		return nil, err
-	}
-
-	// Number of secondary blocks (length-sized)
-	_, err = nr.ReadLength()
-	if err != nil {
-		return nil, err
-	}
-
-	// Secondary block size (length-sized)
-	_, err = nr.ReadLength()
-	if err != nil {
-		return nil, err
-	}
-
-	// Number of data blocks (length-sized)
-	_, err = nr.ReadLength()
-	if err != nil {
-		return nil, err
-	}
-
-	// Data block size (length-sized)
-	_, err = nr.ReadLength()
-	if err != nil {
-		return nil, err
-	}
-
-	// Max index set (length-sized)
-	maxIdx, err := nr.ReadLength()
-	if err != nil {
-		return nil, err
-	}
-
-	// Number of elements (length-sized)
-	numElements, err := nr.ReadLength()
-	if err != nil {
-		return nil, err
-	}
-
-	// Index block address (offset-sized)
-	idxBlockAddr, err := nr.ReadOffset()
-	if err != nil {
-		return nil, err
-	}
-
-	// Read from index block
-	return c.readExtensibleArrayIndexBlock(idxBlockAddr, int(idxBlkElmts), int(elemSize), int(numElements), int(maxIdx), dims, chunkDims)
-}
-
-// readExtensibleArrayIndexBlock reads the index block of an extensible array.
-func (c *Chunked) readExtensibleArrayIndexBlock(addr uint64, idxBlkElmts, elemSize, numElements, maxIdx int, dims []uint64, chunkDims []uint32) ([]btree.ChunkEntry, error) {
-	nr := c.reader.At(int64(addr))
-
-	// Read index block signature
-	sig, err := nr.ReadBytes(4)
-	if err != nil {
-		return nil, fmt.Errorf("reading extensible array index block signature: %w", err)
-	}
-	if string(sig) != "EAIB" {
-		return nil, fmt.Errorf("invalid extensible array index block signature: got %q, expected \"EAIB\"", string(sig))
-	}
-
-	// Version (1 byte)
-	_, err = nr.ReadUint8()
-	if err != nil {
-		return nil, err
-	}
-
-	// Client ID (1 byte)
-	_, err = nr.ReadUint8()
-	if err != nil {
-		return nil, err
-	}
-
-	// Header address (offset-sized)
-	_, err = nr.ReadOffset()
-	if err != nil {
-		return nil, err
-	}
-
-	ndims := len(dims)
-	numChunksPerDim := make([]uint64, ndims)
-	for d := 0; d < ndims; d++ {
-		numChunksPerDim[d] = (dims[d] + uint64(chunkDims[d]) - 1) / uint64(chunkDims[d])
-	}
-
-	var entries []btree.ChunkEntry
-
-	// Read elements directly stored in index block
-	numIdxElmts := 1 << idxBlkElmts
-	if numIdxElmts > numElements {
-		numIdxElmts = numElements
-	}
-
-	for i := 0; i < numIdxElmts; i++ {
-		// Calculate chunk offset from linear index
-		offset := make([]uint64, ndims)
-		remaining := uint64(i)
-		for d := ndims - 1; d >= 0; d-- {
-			offset[d] = (remaining % numChunksPerDim[d]) * uint64(chunkDims[d])
-			remaining /= numChunksPerDim[d]
-		}
-
-		// Read element
-		var chunkAddr uint64
-		var chunkSize uint32
-		var filterMask uint32
-
-		if elemSize <= 8 {
-			chunkAddr, err = nr.ReadOffset()
-			if err != nil {
-				return nil, err
-			}
-			chunkSize = 1
-			for _, cd := range chunkDims {
-				chunkSize *= cd
-			}
-			chunkSize *= uint32(c.datatype.Size)
-		} else {
-			chunkAddr, err = nr.ReadOffset()
-			if err != nil {
-				return nil, err
-			}
-			remaining := elemSize - c.reader.OffsetSize()
-			if remaining >= 4 {
-				chunkSize, err = nr.ReadUint32()
-				if err != nil {
-					return nil, err
-				}
-				remaining -= 4
-			}
-			if remaining >= 4 {
-				filterMask, err = nr.ReadUint32()
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
-
-		if chunkAddr != 0 && chunkAddr != 0xFFFFFFFFFFFFFFFF {
-			entries = append(entries, btree.ChunkEntry{
-				Offset:     offset,
-				FilterMask: filterMask,
-				Size:       chunkSize,
-				Address:    chunkAddr,
-			})
+// decodeChunkIndexEntry decodes a fixed or extensible array element. For
+// unfiltered chunks it is just the chunk address; filtered chunks add the
+// stored chunk size, in the bytes between the address and a trailing
+// 4-byte filter mask.
+func (c *Chunked) decodeChunkIndexEntry(raw []byte, chunkDims []uint32) (btree.ChunkEntry, error) {
+	o := c.reader.OffsetSize()
+	if len(raw) < o {
+		return btree.ChunkEntry{}, fmt.Errorf("%d-byte element is smaller than an address", len(raw))
+	}
+	entry := btree.ChunkEntry{Address: binary.DecodeUintLE(raw[:o])}
+
+	if len(raw) == o {
+		// Unfiltered chunks are stored at full size
+		entry.Size = uint64(c.datatype.Size)
+		for _, cd := range chunkDims {
+			entry.Size *= uint64(cd)
 		}
+		return entry, nil
 	}
 
-	// Check if there are more elements in data blocks
-	if numElements > numIdxElmts {
-		return nil, fmt.Errorf("extensible array has %d elements but only %d fit in index block; data block reading not yet implemented", numElements, numIdxElmts)
+	sizeBytes := len(raw) - o - 4
+	if sizeBytes < 1 || sizeBytes > 8 {
+		return btree.ChunkEntry{}, fmt.Errorf("invalid %d-byte filtered chunk element", len(raw))
 	}
-
-	return entries, nil
+	entry.Size = binary.DecodeUintLE(raw[o : o+sizeBytes])
+	entry.FilterMask = uint32(binary.DecodeUintLE(raw[o+sizeBytes:]))
+	return entry, nil
 }
 
 // ReadSlice reads a hyperslab from chunked storage.
@@ -1085,7 +1276,7 @@ func (c *Chunked) ReadSlice(start, count []uint64) ([]byte, error) {
 	for _, cnt := range count {
 		totalElements *= cnt
 	}
-	output := make([]byte, totalElements*elementSize)
+	output := c.newOutput(totalElements * elementSize)
 
 	// Calculate chunk size in bytes (uncompressed)
 	chunkElements := uint64(1)
@@ -1094,51 +1285,9 @@ func (c *Chunked) ReadSlice(start, count []uint64) ([]byte, error) {
 	}
 	chunkSizeBytes := chunkElements * elementSize
 
-	// Detect chunk index type and get all chunks
-	indexType, err := c.detectChunkIndexType()
+	entries, err := c.sliceEntries(dims, chunkDims, start, count)
 	if err != nil {
-		return nil, fmt.Errorf("detecting chunk index type: %w", err)
-	}
-
-	// Get all chunk entries
-	var entries []btree.ChunkEntry
-	switch indexType {
-	case "single":
-		// Single chunk - read and extract
-		data, err := c.readSingleChunk(calculateDataSize(c.dataspace, c.datatype))
-		if err != nil {
-			return nil, err
-		}
-		return extractHyperslab(data, dims, start, count, elementSize)
-
-	case "btree_v1":
-		chunkIndex, err := btree.ReadChunkIndex(c.reader, c.layout.ChunkIndexAddr, ndims)
-		if err != nil {
-			return nil, err
-		}
-		entries = chunkIndex.Entries
-
-	case "fixed_array":
-		entries, err = c.readFixedArrayIndex(dims, chunkDims)
-		if err != nil {
-			return nil, err
-		}
-
-	case "extensible_array":
-		entries, err = c.readExtensibleArrayIndex(dims, chunkDims)
-		if err != nil {
-			return nil, err
-		}
-
-	case "btree_v2":
-		chunkIndex, err := btree.ReadChunkIndexV2(c.reader, c.layout.ChunkIndexAddr, ndims)
-		if err != nil {
-			return nil, err
-		}
-		entries = chunkIndex.Entries
-
-	default:
-		return nil, fmt.Errorf("unsupported chunk index type: %s", indexType)
+		return nil, err
 	}
 
 	// Calculate the end of the selection
@@ -1148,13 +1297,21 @@ func (c *Chunked) ReadSlice(start, count []uint64) ([]byte, error) {
 	}
 
 	// Process each chunk that overlaps with the selection
+	var scratch chunkScratch
 	for _, entry := range entries {
 		if entry.Address == 0 || entry.Address == 0xFFFFFFFFFFFFFFFF {
 			continue
 		}
 
+		chunkStart, inside, err := chunkOffset(entry, dims)
+		if err != nil {
+			return nil, err
+		}
+		if !inside {
+			continue
+		}
+
 		// Calculate chunk boundaries
-		chunkStart := entry.Offset
 		chunkEnd := make([]uint64, ndims)
 		for d := 0; d < ndims; d++ {
 			chunkEnd[d] = chunkStart[d] + uint64(chunkDims[d])
@@ -1176,33 +1333,125 @@ func (c *Chunked) ReadSlice(start, count []uint64) ([]byte, error) {
 		}
 
 		// Read and decompress chunk
-		chunkEntry := entry
-		if chunkEntry.Size == 0 {
-			chunkEntry.Size = uint32(chunkSizeBytes)
-		}
-		chunkData, err := c.readChunkData(chunkEntry)
+		chunkData, err := c.decodeChunk(entry, chunkSizeBytes, &scratch)
 		if err != nil {
-			return nil, fmt.Errorf("reading chunk at offset %v: %w", entry.Offset, err)
+			return nil, err
 		}
-
-		if c.pipeline != nil && !c.pipeline.Empty() {
-			chunkData, err = c.pipeline.Decode(chunkData, entry.FilterMask)
-			if err != nil {
-				return nil, fmt.Errorf("decoding chunk at offset %v: %w", entry.Offset, err)
-			}
+		if uint64(len(chunkData)) < chunkSizeBytes {
+			return nil, fmt.Errorf("chunk at offset %v decoded to %d bytes, expected %d",
+				chunkStart, len(chunkData), chunkSizeBytes)
 		}
 
 		// Copy the overlapping portion to output
-		err = c.copyChunkToSlice(output, chunkData, entry.Offset, dims, chunkDims,
+		err = c.copyChunkToSlice(output, chunkData, chunkStart, dims, chunkDims,
 			start, count, elementSize)
 		if err != nil {
-			return nil, fmt.Errorf("copying chunk at offset %v: %w", entry.Offset, err)
+			return nil, fmt.Errorf("copying chunk at offset %v: %w", chunkStart, err)
 		}
 	}
 
 	return output, nil
 }
 
+// chunkLookupLimit is the most chunks a ReadSlice selection may overlap
+// for them to be looked up one at a time in a B-tree chunk index. Larger
+// selections read the whole index.
+const chunkLookupLimit = 64
+
+// sliceEntries returns the entries of the chunks a ReadSlice selection may
+// overlap. Until the whole index has been loaded, small selections in
+// B-tree indexes look up just their chunks, descending the tree once for
+// each, so that reading a few chunks of a dataset with millions costs a
+// few node reads. Other selections use the full index.
+func (c *Chunked) sliceEntries(dims []uint64, chunkDims []uint32, start, count []uint64) ([]btree.ChunkEntry, error) {
+	find, err := c.chunkLookup()
+	if err != nil {
+		return nil, err
+	}
+	offsets, ok := selectedChunks(start, count, chunkDims, chunkLookupLimit)
+	if !ok {
+		_, entries, err := c.chunkIndex(dims, chunkDims)
+		return entries, err
+	}
+	if find == nil {
+		return c.findChunks(dims, chunkDims, offsets)
+	}
+
+	var entries []btree.ChunkEntry
+	for _, offset := range offsets {
+		entry, found, err := find(c.reader, c.layout.ChunkIndexAddr, len(dims), offset)
+		if err != nil {
+			return nil, fmt.Errorf("looking up chunk at offset %v: %w", offset, err)
+		}
+		if found {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// chunkLookup returns the function that looks up a single chunk in a
+// B-tree chunk index, or nil if chunks are found in the full index instead:
+// because it has been loaded already or is not a B-tree.
+func (c *Chunked) chunkLookup() (func(*binary.Reader, uint64, int, []uint64) (btree.ChunkEntry, bool, error), error) {
+	if c.indexReady.Load() {
+		return nil, nil
+	}
+	indexType, err := c.indexTypeName()
+	if err != nil {
+		return nil, err
+	}
+	switch indexType {
+	case "btree_v1":
+		return btree.FindChunkV1, nil
+	case "btree_v2":
+		return btree.FindChunkV2, nil
+	}
+	return nil, nil
+}
+
+// selectedChunks returns the offsets of the chunks a selection overlaps, in
+// row-major order, or false if there are more than limit of them.
+func selectedChunks(start, count []uint64, chunkDims []uint32, limit uint64) ([][]uint64, bool) {
+	ndims := len(start)
+	first := make([]uint64, ndims)
+	last := make([]uint64, ndims)
+	total := uint64(1)
+	for d := 0; d < ndims; d++ {
+		if count[d] == 0 {
+			return nil, true
+		}
+		first[d] = start[d] / uint64(chunkDims[d])
+		last[d] = (start[d] + count[d] - 1) / uint64(chunkDims[d])
+		total *= last[d] - first[d] + 1
+		if total > limit {
+			return nil, false
+		}
+	}
+
+	offsets := make([][]uint64, 0, total)
+	idx := slices.Clone(first)
+	for {
+		offset := make([]uint64, ndims)
+		for d := range idx {
+			offset[d] = idx[d] * uint64(chunkDims[d])
+		}
+		offsets = append(offsets, offset)
+
+		d := ndims - 1
+		for ; d >= 0; d-- {
+			if idx[d] < last[d] {
+				idx[d]++
+				break
+			}
+			idx[d] = first[d]
+		}
+		if d < 0 {
+			return offsets, true
+		}
+	}
+}
+
 // copyChunkToSlice copies the overlapping portion of a chunk to the output slice.
 func (c *Chunked) copyChunkToSlice(
 	output []byte,
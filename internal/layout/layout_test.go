@@ -2,9 +2,17 @@ package layout
 
 import (
 	"bytes"
+	"compress/zlib"
+	stdbinary "encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/filter"
 	"github.com/robert-malhotra/go-hdf5/internal/message"
 )
 
@@ -63,6 +71,61 @@ func TestCompactRead(t *testing.T) {
 	}
 }
 
+func TestCompactReadSlice(t *testing.T) {
+	// A 3x4 int32 dataset holding 0 through 11 in row-major order
+	var data []byte
+	for v := range uint32(12) {
+		data = stdbinary.LittleEndian.AppendUint32(data, v)
+	}
+	layoutMsg := &message.DataLayout{Class: message.LayoutCompact, CompactData: data}
+	dataspace := &message.Dataspace{SpaceType: message.DataspaceSimple, Rank: 2, Dimensions: []uint64{3, 4}}
+	datatype := &message.Datatype{Class: message.ClassFixedPoint, Size: 4}
+	compact := NewCompact(layoutMsg, dataspace, datatype)
+
+	tests := []struct {
+		name         string
+		start, count []uint64
+		want         []uint32
+	}{
+		{"interior", []uint64{1, 1}, []uint64{1, 2}, []uint32{5, 6}},
+		{"interior block", []uint64{0, 1}, []uint64{2, 2}, []uint32{1, 2, 5, 6}},
+		{"first row", []uint64{0, 0}, []uint64{1, 4}, []uint32{0, 1, 2, 3}},
+		{"last column", []uint64{0, 3}, []uint64{3, 1}, []uint32{3, 7, 11}},
+		{"bottom right corner", []uint64{1, 2}, []uint64{2, 2}, []uint32{6, 7, 10, 11}},
+		{"last element", []uint64{2, 3}, []uint64{1, 1}, []uint32{11}},
+		{"whole dataset", []uint64{0, 0}, []uint64{3, 4}, []uint32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}},
+	}
+	for _, tt := range tests {
+		result, err := compact.ReadSlice(tt.start, tt.count)
+		if err != nil {
+			t.Errorf("%s: ReadSlice failed: %v", tt.name, err)
+			continue
+		}
+		got := make([]uint32, len(result)/4)
+		for i := range got {
+			got[i] = stdbinary.LittleEndian.Uint32(result[4*i:])
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ReadSlice(%v, %v) = %v, want %v", tt.name, tt.start, tt.count, got, tt.want)
+		}
+	}
+
+	for _, sel := range [][2][]uint64{{{0, 3}, {1, 2}}, {{3, 0}, {1, 1}}, {{0}, {1}}} {
+		if _, err := compact.ReadSlice(sel[0], sel[1]); err == nil {
+			t.Errorf("ReadSlice(%v, %v) succeeded, want an error", sel[0], sel[1])
+		}
+	}
+
+	// Data too short for the dataspace is reported, not read as zeros
+	short := NewCompact(&message.DataLayout{Class: message.LayoutCompact, CompactData: data[:40]}, dataspace, datatype)
+	if _, err := short.ReadSlice([]uint64{2, 0}, []uint64{1, 4}); err == nil {
+		t.Error("ReadSlice of truncated compact data succeeded")
+	}
+	if _, err := short.Read(); err == nil {
+		t.Error("Read of truncated compact data succeeded")
+	}
+}
+
 func TestContiguousRead(t *testing.T) {
 	// Create fake file data with contiguous storage
 	fileData := make(bytesReaderAt, 1024)
@@ -118,6 +181,47 @@ func TestContiguousRead(t *testing.T) {
 	}
 }
 
+func TestContiguousUnallocated(t *testing.T) {
+	reader := binary.NewReader(make(bytesReaderAt, 64), binary.Config{OffsetSize: 8, LengthSize: 8})
+	layoutMsg := &message.DataLayout{Class: message.LayoutContiguous, Address: 0xFFFFFFFFFFFFFFFF}
+	dataspace := &message.Dataspace{SpaceType: message.DataspaceSimple, Rank: 2, Dimensions: []uint64{2, 3}}
+	datatype := &message.Datatype{Class: message.ClassFixedPoint, Size: 2}
+
+	contiguous := NewContiguous(layoutMsg, dataspace, datatype, reader)
+	contiguous.SetFillValue([]byte{7, 0})
+	fill := func(n int) []byte {
+		return bytes.Repeat([]byte{7, 0}, n)
+	}
+
+	result, err := contiguous.Read()
+	if err != nil || !bytes.Equal(result, fill(6)) {
+		t.Errorf("Read = %v, %v; want the fill value", result, err)
+	}
+	result, err = contiguous.ReadSlice([]uint64{1, 1}, []uint64{1, 2})
+	if err != nil || !bytes.Equal(result, fill(2)) {
+		t.Errorf("ReadSlice = %v, %v; want the fill value", result, err)
+	}
+	result, err = contiguous.ReadPoints([][]uint64{{0, 0}, {1, 2}})
+	if err != nil || !bytes.Equal(result, fill(2)) {
+		t.Errorf("ReadPoints = %v, %v; want the fill value", result, err)
+	}
+
+	// Without a fill value there is nothing to read the data as
+	noFill := NewContiguous(layoutMsg, dataspace, datatype, reader)
+	if _, err := noFill.Read(); !errors.Is(err, ErrNoStorage) {
+		t.Errorf("Read without a fill value: got %v, want ErrNoStorage", err)
+	}
+	if _, err := noFill.ReadSlice([]uint64{0, 0}, []uint64{1, 1}); !errors.Is(err, ErrNoStorage) {
+		t.Errorf("ReadSlice without a fill value: got %v, want ErrNoStorage", err)
+	}
+
+	// Without elements there is nothing to read
+	empty := NewContiguous(layoutMsg, &message.Dataspace{SpaceType: message.DataspaceNull}, datatype, reader)
+	if result, err := empty.Read(); err != nil || len(result) != 0 {
+		t.Errorf("null dataspace Read = %v, %v; want no bytes", result, err)
+	}
+}
+
 func TestContiguousSizeFromDataspace(t *testing.T) {
 	fileData := make(bytesReaderAt, 1024)
 
@@ -200,3 +304,957 @@ func TestCalculateDataSize(t *testing.T) {
 		})
 	}
 }
+
+func TestNewTooLarge(t *testing.T) {
+	datatype := &message.Datatype{Size: 8}
+	tests := []struct {
+		name   string
+		layout *message.DataLayout
+		dims   []uint64
+	}{
+		{
+			name:   "contiguous",
+			layout: &message.DataLayout{Class: message.LayoutContiguous},
+			dims:   []uint64{1 << 40, 1 << 30},
+		},
+		{
+			name:   "chunk",
+			layout: &message.DataLayout{Class: message.LayoutChunked, ChunkDims: []uint32{1 << 20, 1 << 20, 8}},
+			dims:   []uint64{1 << 20, 1 << 20},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dataspace := &message.Dataspace{SpaceType: message.DataspaceSimple, Dimensions: tt.dims}
+			if _, err := New(tt.layout, dataspace, datatype, nil, nil); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestTrimChunk(t *testing.T) {
+	// A 2x3 chunk of bytes at (1, 2) in a 2x4 dataset keeps one row of two
+	chunk := []byte{1, 2, 3, 4, 5, 6}
+	got, err := trimChunk(chunk, []uint64{1, 2}, []uint64{2, 4}, []uint32{2, 3}, 1)
+	if err != nil {
+		t.Fatalf("trimChunk failed: %v", err)
+	}
+	if !bytes.Equal(got, []byte{1, 2}) {
+		t.Errorf("got %v, want [1 2]", got)
+	}
+
+	// Interior chunks come back whole
+	got, err = trimChunk(chunk, []uint64{0, 0}, []uint64{2, 4}, []uint32{2, 3}, 1)
+	if err != nil || !bytes.Equal(got, chunk) {
+		t.Errorf("interior chunk = %v, %v; want %v", got, err, chunk)
+	}
+
+	// 2-byte elements, trimmed in the outer dimension only
+	got, err = trimChunk([]byte{1, 0, 2, 0, 3, 0, 4, 0}, []uint64{4, 0}, []uint64{5, 2}, []uint32{2, 2}, 2)
+	if err != nil || !bytes.Equal(got, []byte{1, 0, 2, 0}) {
+		t.Errorf("outer trim = %v, %v; want [1 0 2 0]", got, err)
+	}
+
+	if _, err := trimChunk(chunk[:5], []uint64{0, 0}, []uint64{2, 4}, []uint32{2, 3}, 1); err == nil {
+		t.Error("short chunk accepted")
+	}
+}
+
+func TestImplicitIndex(t *testing.T) {
+	// A 3x5 byte dataset in 2x2 chunks: 2x3 chunks stored back to back at
+	// offset 64, each at full size even where it overhangs the dataset
+	want := []byte{
+		0, 1, 2, 3, 4,
+		10, 11, 12, 13, 14,
+		20, 21, 22, 23, 24,
+	}
+	dims := []uint64{3, 5}
+	fileData := make(bytesReaderAt, 64, 128)
+	for ci := uint64(0); ci < 2; ci++ {
+		for cj := uint64(0); cj < 3; cj++ {
+			for i := uint64(0); i < 2; i++ {
+				for j := uint64(0); j < 2; j++ {
+					r, c := 2*ci+i, 2*cj+j
+					var v byte = 0xEE // Padding outside the dataset
+					if r < dims[0] && c < dims[1] {
+						v = want[r*5+c]
+					}
+					fileData = append(fileData, v)
+				}
+			}
+		}
+	}
+	reader := binary.NewReader(fileData, binary.Config{OffsetSize: 8, LengthSize: 8})
+
+	layoutMsg := &message.DataLayout{
+		Version:        4,
+		Class:          message.LayoutChunked,
+		ChunkDims:      []uint32{2, 2, 1},
+		ChunkIndexType: message.ChunkIndexImplicit,
+		ChunkIndexAddr: 64,
+	}
+	dataspace := &message.Dataspace{SpaceType: message.DataspaceSimple, Rank: 2, Dimensions: dims}
+	datatype := &message.Datatype{Class: message.ClassFixedPoint, Size: 1}
+	chunked, err := NewChunked(layoutMsg, dataspace, datatype, nil, reader)
+	if err != nil {
+		t.Fatalf("NewChunked failed: %v", err)
+	}
+
+	got, err := chunked.Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Read = %v, want %v", got, want)
+	}
+
+	got, err = chunked.ReadSlice([]uint64{1, 1}, []uint64{2, 4})
+	if err != nil {
+		t.Fatalf("ReadSlice failed: %v", err)
+	}
+	if wantSlice := []byte{11, 12, 13, 14, 21, 22, 23, 24}; !bytes.Equal(got, wantSlice) {
+		t.Errorf("ReadSlice = %v, want %v", got, wantSlice)
+	}
+
+	chunks := 0
+	err = chunked.ForEachChunk(func(offset []uint64, data []byte) error {
+		chunks++
+		if offset[0] == 2 && offset[1] == 4 && !bytes.Equal(data, []byte{24}) {
+			t.Errorf("corner chunk = %v, want [24]", data)
+		}
+		return nil
+	})
+	if err != nil || chunks != 6 {
+		t.Errorf("ForEachChunk visited %d chunks, err %v; want 6", chunks, err)
+	}
+}
+
+func TestPadChunk(t *testing.T) {
+	// A 2x2 dataset in a 3x3 chunk
+	got := PadChunk([]byte{1, 2, 3, 4}, []uint64{2, 2}, []uint32{3, 3}, 1)
+	if want := []byte{1, 2, 0, 3, 4, 0, 0, 0, 0}; !bytes.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	full := []byte{1, 0, 2, 0}
+	if got := PadChunk(full, []uint64{2}, []uint32{2}, 2); !bytes.Equal(got, full) {
+		t.Errorf("full chunk changed to %v", got)
+	}
+}
+
+// eaParams are the creation parameters of an extensible array.
+type eaParams struct {
+	maxNelmtsBits, idxBlkElmts, dataBlkMinElmts, supBlkMinDataPtrs, pageBits int
+}
+
+// h5Checksummed appends the lookup3 checksum of block to it.
+func h5Checksummed(block []byte) []byte {
+	return le32(block, binary.ChecksumLookup3(block, 0))
+}
+
+func le32(b []byte, v uint32) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func leN(b []byte, v uint64, n int) []byte {
+	for i := 0; i < n; i++ {
+		b = append(b, byte(v>>(8*i)))
+	}
+	return b
+}
+
+// buildExtensibleArray appends an extensible array holding elems to file,
+// placing each element the way libhdf5 looks it up, and returns the header
+// address. Nil elements are never written; blocks and pages holding only
+// such elements are not created.
+func buildExtensibleArray(file []byte, elems [][]byte, elemSize int, p eaParams) ([]byte, uint64) {
+	const o = 8
+	undef := bytes.Repeat([]byte{0xFF}, elemSize)
+	arrOff := (p.maxNelmtsBits + 7) / 8
+	pageNelmts := 1 << p.pageBits
+
+	type sblkInfo struct{ ndblks, nelmts, startIdx, startDblk int }
+	nsblks := 1 + p.maxNelmtsBits - (bits.Len(uint(p.dataBlkMinElmts)) - 1)
+	sblks := make([]sblkInfo, nsblks)
+	startIdx, startDblk := 0, 0
+	for u := range sblks {
+		sblks[u] = sblkInfo{1 << (u / 2), p.dataBlkMinElmts << ((u + 1) / 2), startIdx, startDblk}
+		startIdx += sblks[u].ndblks * sblks[u].nelmts
+		startDblk += sblks[u].ndblks
+	}
+	iblkNsblks := 2 * (bits.Len(uint(p.supBlkMinDataPtrs)) - 1)
+
+	// Element storage of each data block that holds a set element
+	dblocks := map[[2]int][]byte{}
+	iblkElems := bytes.Repeat(undef, p.idxBlkElmts)
+	maxIdxSet := 0
+	for idx, e := range elems {
+		if e == nil {
+			continue
+		}
+		maxIdxSet = idx + 1
+		if idx < p.idxBlkElmts {
+			copy(iblkElems[idx*elemSize:], e)
+			continue
+		}
+		s := bits.Len(uint((idx-p.idxBlkElmts)/p.dataBlkMinElmts+1)) - 1
+		elmt := idx - p.idxBlkElmts - sblks[s].startIdx
+		key := [2]int{s, elmt / sblks[s].nelmts}
+		if dblocks[key] == nil {
+			dblocks[key] = bytes.Repeat(undef, sblks[s].nelmts)
+		}
+		copy(dblocks[key][(elmt%sblks[s].nelmts)*elemSize:], e)
+	}
+
+	hdrAddr := uint64(len(file))
+	hdrSize := 4 + 1 + 1 + 6 + 6*8 + o + 4
+	file = append(file, make([]byte, hdrSize)...)
+
+	// Data blocks, recording which pages of paged blocks are written
+	dblkAddrs := map[[2]int]uint64{}
+	pageInit := map[[2]int][]byte{}
+	for s, info := range sblks {
+		for d := 0; d < info.ndblks; d++ {
+			key := [2]int{s, d}
+			data := dblocks[key]
+			if data == nil {
+				continue
+			}
+			dblkAddrs[key] = uint64(len(file))
+			block := append([]byte("EADB\x00\x00"), leN(nil, hdrAddr, o)...)
+			block = leN(block, uint64(info.startIdx+d*info.nelmts), arrOff)
+			if info.nelmts <= pageNelmts {
+				file = append(file, h5Checksummed(append(block, data...))...)
+				continue
+			}
+			file = append(file, h5Checksummed(block)...)
+			npages := info.nelmts / pageNelmts
+			init := make([]byte, (npages+7)/8)
+			for pg := 0; pg < npages; pg++ {
+				page := data[pg*pageNelmts*elemSize : (pg+1)*pageNelmts*elemSize]
+				if bytes.Equal(page, bytes.Repeat(undef, pageNelmts)) {
+					file = append(file, make([]byte, len(page)+4)...)
+					continue
+				}
+				init[pg/8] |= 0x80 >> (pg % 8)
+				file = append(file, h5Checksummed(append([]byte(nil), page...))...)
+			}
+			pageInit[key] = init
+		}
+	}
+
+	// Super blocks past those the index block points into directly
+	sblkAddrs := make([]uint64, nsblks-iblkNsblks)
+	for s := iblkNsblks; s < nsblks; s++ {
+		info := sblks[s]
+		sblkAddrs[s-iblkNsblks] = ^uint64(0)
+		var addrs, inits []byte
+		used := false
+		for d := 0; d < info.ndblks; d++ {
+			addr, ok := dblkAddrs[[2]int{s, d}]
+			if !ok {
+				addr = ^uint64(0)
+			}
+			used = used || ok
+			addrs = leN(addrs, addr, o)
+			if info.nelmts > pageNelmts {
+				init := pageInit[[2]int{s, d}]
+				if init == nil {
+					init = make([]byte, (info.nelmts/pageNelmts+7)/8)
+				}
+				inits = append(inits, init...)
+			}
+		}
+		if !used {
+			continue
+		}
+		sblkAddrs[s-iblkNsblks] = uint64(len(file))
+		block := append([]byte("EASB\x00\x00"), leN(nil, hdrAddr, o)...)
+		block = leN(block, uint64(info.startIdx), arrOff)
+		file = append(file, h5Checksummed(append(append(block, inits...), addrs...))...)
+	}
+
+	// The index block
+	iblkAddr := uint64(len(file))
+	block := append([]byte("EAIB\x00\x00"), leN(nil, hdrAddr, o)...)
+	block = append(block, iblkElems...)
+	for s := 0; s < iblkNsblks; s++ {
+		for d := 0; d < sblks[s].ndblks; d++ {
+			addr, ok := dblkAddrs[[2]int{s, d}]
+			if !ok {
+				addr = ^uint64(0)
+			}
+			block = leN(block, addr, o)
+		}
+	}
+	for _, addr := range sblkAddrs {
+		block = leN(block, addr, o)
+	}
+	file = append(file, h5Checksummed(block)...)
+
+	hdr := []byte("EAHD\x00\x00")
+	hdr = append(hdr, byte(elemSize), byte(p.maxNelmtsBits), byte(p.idxBlkElmts),
+		byte(p.dataBlkMinElmts), byte(p.supBlkMinDataPtrs), byte(p.pageBits))
+	for _, v := range []int{0, 0, 0, 0, maxIdxSet, maxIdxSet} {
+		hdr = leN(hdr, uint64(v), 8)
+	}
+	hdr = h5Checksummed(leN(hdr, iblkAddr, o))
+	copy(file[hdrAddr:], hdr)
+	return file, hdrAddr
+}
+
+func TestExtensibleArrayIndex(t *testing.T) {
+	const nchunks = 300
+	chunkData := func(i int) []byte {
+		return []byte{byte(i), byte(i >> 8), 1, 2}
+	}
+	written := func(i int) bool {
+		// A single missing chunk and a whole missing data block
+		return i != 5 && (i < 100 || i >= 132)
+	}
+	deflate := func(b []byte) []byte {
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		zw.Write(b)
+		zw.Close()
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name     string
+		params   eaParams
+		filtered bool
+	}{
+		{"libhdf5 defaults", eaParams{32, 4, 16, 4, 10}, false},
+		{"gzip", eaParams{32, 4, 16, 4, 10}, true},
+		{"paged data blocks", eaParams{16, 2, 2, 2, 2}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := make([]byte, 64)
+			elemSize := 8
+			if tt.filtered {
+				elemSize = 8 + 1 + 4
+			}
+			elems := make([][]byte, nchunks)
+			for i := range elems {
+				if !written(i) {
+					continue
+				}
+				stored := chunkData(i)
+				if tt.filtered {
+					stored = deflate(stored)
+				}
+				elems[i] = leN(nil, uint64(len(file)), 8)
+				if tt.filtered {
+					elems[i] = le32(append(elems[i], byte(len(stored))), 0)
+				}
+				file = append(file, stored...)
+			}
+			file, hdrAddr := buildExtensibleArray(file, elems, elemSize, tt.params)
+
+			var pipeline *message.FilterPipeline
+			if tt.filtered {
+				pipeline = &message.FilterPipeline{Version: 2, Filters: []message.FilterInfo{
+					{ID: message.FilterDeflate, ClientData: []uint32{6}},
+				}}
+			}
+			reader := binary.NewReader(bytesReaderAt(file), binary.DefaultConfig())
+			chunked, err := NewChunked(&message.DataLayout{
+				Version:        4,
+				Class:          message.LayoutChunked,
+				ChunkDims:      []uint32{4, 1},
+				ChunkIndexType: message.ChunkIndexExtensibleArray,
+				ChunkIndexAddr: hdrAddr,
+			}, &message.Dataspace{
+				SpaceType:  message.DataspaceSimple,
+				Rank:       1,
+				Dimensions: []uint64{4 * nchunks},
+				MaxDims:    []uint64{message.Unlimited},
+			}, &message.Datatype{Class: message.ClassFixedPoint, Size: 1}, pipeline, reader)
+			if err != nil {
+				t.Fatalf("NewChunked failed: %v", err)
+			}
+
+			got, err := chunked.Read()
+			if err != nil {
+				t.Fatalf("Read failed: %v", err)
+			}
+			for i := 0; i < nchunks; i++ {
+				want := make([]byte, 4)
+				if written(i) {
+					want = chunkData(i)
+				}
+				if !bytes.Equal(got[4*i:4*i+4], want) {
+					t.Fatalf("chunk %d = %v, want %v", i, got[4*i:4*i+4], want)
+				}
+			}
+		})
+	}
+}
+
+func TestExtensibleArrayIndexChecksum(t *testing.T) {
+	elems := make([][]byte, 40)
+	file := make([]byte, 64)
+	for i := range elems {
+		elems[i] = leN(nil, uint64(len(file)), 8)
+		file = append(file, byte(i))
+	}
+	file, hdrAddr := buildExtensibleArray(file, elems, 8, eaParams{32, 4, 16, 4, 10})
+	file[hdrAddr+72+20] ^= 0xFF // An element of the first data block, just past the header
+
+	reader := binary.NewReader(bytesReaderAt(file), binary.DefaultConfig())
+	chunked, err := NewChunked(&message.DataLayout{
+		Version:        4,
+		Class:          message.LayoutChunked,
+		ChunkDims:      []uint32{1, 1},
+		ChunkIndexType: message.ChunkIndexExtensibleArray,
+		ChunkIndexAddr: hdrAddr,
+	}, &message.Dataspace{SpaceType: message.DataspaceSimple, Rank: 1, Dimensions: []uint64{40}},
+		&message.Datatype{Class: message.ClassFixedPoint, Size: 1}, nil, reader)
+	if err != nil {
+		t.Fatalf("NewChunked failed: %v", err)
+	}
+	if _, err := chunked.Read(); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("corrupt data block: err = %v, want checksum mismatch", err)
+	}
+}
+
+func TestExtensibleArrayChunkOffset(t *testing.T) {
+	// The unlimited dimension comes first in the chunk numbering
+	c := &Chunked{dataspace: &message.Dataspace{
+		Dimensions: []uint64{3, 10},
+		MaxDims:    []uint64{3, message.Unlimited},
+	}}
+	got := c.extensibleArrayChunkOffset(5, []uint64{3, 10}, []uint32{2, 4})
+	if want := []uint64{2, 8}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestChunksBeyondExtent(t *testing.T) {
+	// A 2D dataset with an unlimited first dimension, written as 6x4 in
+	// 2x2 chunks and then shrunk to 3x3: the last row of chunks lies past
+	// the extent and the others are clipped
+	file := make([]byte, 64)
+	elems := make([][]byte, 6)
+	for i := range elems {
+		elems[i] = leN(nil, uint64(len(file)), 8)
+		for e := 0; e < 4; e++ {
+			row, col := 2*(i/2)+e/2, 2*(i%2)+e%2
+			file = append(file, byte(10*row+col))
+		}
+	}
+	file, hdrAddr := buildExtensibleArray(file, elems, 8, eaParams{32, 4, 16, 4, 10})
+
+	reader := binary.NewReader(bytesReaderAt(file), binary.DefaultConfig())
+	chunked, err := NewChunked(&message.DataLayout{
+		Version:        4,
+		Class:          message.LayoutChunked,
+		ChunkDims:      []uint32{2, 2, 1},
+		ChunkIndexType: message.ChunkIndexExtensibleArray,
+		ChunkIndexAddr: hdrAddr,
+	}, &message.Dataspace{
+		SpaceType:  message.DataspaceSimple,
+		Rank:       2,
+		Dimensions: []uint64{3, 3},
+		MaxDims:    []uint64{message.Unlimited, 4},
+	}, &message.Datatype{Class: message.ClassFixedPoint, Size: 1}, nil, reader)
+	if err != nil {
+		t.Fatalf("NewChunked failed: %v", err)
+	}
+
+	want := []byte{0, 1, 2, 10, 11, 12, 20, 21, 22}
+	got, err := chunked.Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Read = %v, want %v", got, want)
+	}
+
+	got, err = chunked.ReadSlice([]uint64{1, 1}, []uint64{2, 2})
+	if err != nil {
+		t.Fatalf("ReadSlice failed: %v", err)
+	}
+	if want := []byte{11, 12, 21, 22}; !bytes.Equal(got, want) {
+		t.Errorf("ReadSlice = %v, want %v", got, want)
+	}
+
+	var visited int
+	err = chunked.ForEachChunk(func(offset []uint64, data []byte) error {
+		visited++
+		if offset[0] >= 3 || offset[1] >= 3 {
+			t.Errorf("ForEachChunk visited chunk at %v past the extent", offset)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEachChunk failed: %v", err)
+	}
+	if visited != 4 {
+		t.Errorf("ForEachChunk visited %d chunks, want 4", visited)
+	}
+}
+
+func TestChunksBeyondExtent1D(t *testing.T) {
+	// Written as 12 elements in chunks of 4, then shrunk to 5
+	file := make([]byte, 64)
+	elems := make([][]byte, 3)
+	for i := range elems {
+		elems[i] = leN(nil, uint64(len(file)), 8)
+		file = append(file, byte(4*i), byte(4*i+1), byte(4*i+2), byte(4*i+3))
+	}
+	file, hdrAddr := buildExtensibleArray(file, elems, 8, eaParams{32, 4, 16, 4, 10})
+
+	reader := binary.NewReader(bytesReaderAt(file), binary.DefaultConfig())
+	chunked, err := NewChunked(&message.DataLayout{
+		Version:        4,
+		Class:          message.LayoutChunked,
+		ChunkDims:      []uint32{4, 1},
+		ChunkIndexType: message.ChunkIndexExtensibleArray,
+		ChunkIndexAddr: hdrAddr,
+	}, &message.Dataspace{
+		SpaceType:  message.DataspaceSimple,
+		Rank:       1,
+		Dimensions: []uint64{5},
+		MaxDims:    []uint64{message.Unlimited},
+	}, &message.Datatype{Class: message.ClassFixedPoint, Size: 1}, nil, reader)
+	if err != nil {
+		t.Fatalf("NewChunked failed: %v", err)
+	}
+	got, err := chunked.Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if want := []byte{0, 1, 2, 3, 4}; !bytes.Equal(got, want) {
+		t.Errorf("Read = %v, want %v", got, want)
+	}
+}
+
+// memWriterAt is a growable in-memory io.WriterAt.
+type memWriterAt struct{ buf []byte }
+
+func (m *memWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	if end := int(off) + len(p); end > len(m.buf) {
+		m.buf = append(m.buf, make([]byte, end-len(m.buf))...)
+	}
+	return copy(m.buf[off:], p), nil
+}
+
+func TestWriteExtensibleArrayIndex(t *testing.T) {
+	// Enough chunks to reach super blocks with paged data blocks; most
+	// are never written, leaving whole blocks and pages unallocated
+	const nchunks = 140000
+	written := func(i int) bool { return i%997 == 0 || i == nchunks-1 }
+
+	for _, filtered := range []bool{false, true} {
+		mem := &memWriterAt{}
+		next := uint64(64)
+		allocate := func(size int64) uint64 {
+			addr := next
+			next += uint64(size)
+			return addr
+		}
+		w := binary.NewWriter(mem, binary.DefaultConfig())
+		cw := NewChunkWriter(w, []uint32{1}, 4, allocate)
+		var pipeline *message.FilterPipeline
+		if filtered {
+			pipeline = &message.FilterPipeline{Version: 2, Filters: []message.FilterInfo{{ID: message.FilterShuffle}}}
+			p, err := filter.NewPipeline(pipeline)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cw.SetPipeline(p)
+		}
+
+		addrs := make([]uint64, nchunks)
+		sizes := make([]uint64, nchunks)
+		for i := range addrs {
+			addrs[i] = ^uint64(0)
+			if written(i) {
+				addrs[i], sizes[i] = uint64(1000+i), uint64(i%4+1)
+			}
+		}
+		hdrAddr, err := cw.WriteExtensibleArrayIndex(addrs, sizes)
+		if err != nil {
+			t.Fatalf("WriteExtensibleArrayIndex failed: %v", err)
+		}
+
+		reader := binary.NewReader(bytesReaderAt(mem.buf), binary.DefaultConfig())
+		chunked, err := NewChunked(&message.DataLayout{
+			Version:        4,
+			Class:          message.LayoutChunked,
+			ChunkDims:      []uint32{1, 4},
+			ChunkIndexType: message.ChunkIndexExtensibleArray,
+			ChunkIndexAddr: hdrAddr,
+		}, &message.Dataspace{
+			SpaceType:  message.DataspaceSimple,
+			Rank:       1,
+			Dimensions: []uint64{nchunks},
+			MaxDims:    []uint64{message.Unlimited},
+		}, &message.Datatype{Class: message.ClassFixedPoint, Size: 4}, pipeline, reader)
+		if err != nil {
+			t.Fatalf("NewChunked failed: %v", err)
+		}
+		gotAddrs, gotSizes, err := chunked.ExtensibleArrayChunks()
+		if err != nil {
+			t.Fatalf("filtered=%v: ExtensibleArrayChunks failed: %v", filtered, err)
+		}
+		if !reflect.DeepEqual(gotAddrs, addrs) {
+			t.Errorf("filtered=%v: chunk addresses read back differ", filtered)
+		}
+		if filtered {
+			for i := range sizes {
+				if written(i) && gotSizes[i] != sizes[i] {
+					t.Fatalf("chunk %d size %d, want %d", i, gotSizes[i], sizes[i])
+				}
+			}
+		}
+	}
+}
+
+func TestFixedArrayIndexLargeChunkSizes(t *testing.T) {
+	// 32GiB chunks: the index stores their sizes in 6 bytes. Nothing is
+	// written at the chunk addresses, as only the index is read back.
+	chunkDims := []uint32{1 << 16, 1 << 16}
+	mem := &memWriterAt{}
+	next := uint64(64)
+	allocate := func(size int64) uint64 {
+		addr := next
+		next += uint64(size)
+		return addr
+	}
+	cw := NewChunkWriter(binary.NewWriter(mem, binary.DefaultConfig()), chunkDims, 8, allocate)
+	pipeline := &message.FilterPipeline{Version: 2, Filters: []message.FilterInfo{{ID: message.FilterShuffle}}}
+	p, err := filter.NewPipeline(pipeline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cw.SetPipeline(p)
+
+	addrs := []uint64{1 << 40, ^uint64(0), 3 << 40}
+	sizes := []uint64{5 << 30, 0, 7 << 32}
+	hdrAddr, err := cw.WriteFixedArrayIndex(addrs, sizes)
+	if err != nil {
+		t.Fatalf("WriteFixedArrayIndex failed: %v", err)
+	}
+
+	reader := binary.NewReader(bytesReaderAt(mem.buf), binary.DefaultConfig())
+	dims := []uint64{3 << 16, 1 << 16}
+	chunked, err := NewChunked(&message.DataLayout{
+		Version:        4,
+		Class:          message.LayoutChunked,
+		ChunkDims:      []uint32{1 << 16, 1 << 16, 8},
+		ChunkIndexType: message.ChunkIndexFixedArray,
+		ChunkIndexAddr: hdrAddr,
+	}, &message.Dataspace{
+		SpaceType:  message.DataspaceSimple,
+		Rank:       2,
+		Dimensions: dims,
+	}, &message.Datatype{Class: message.ClassFloatPoint, Size: 8}, pipeline, reader)
+	if err != nil {
+		t.Fatalf("NewChunked failed: %v", err)
+	}
+	_, entries, err := chunked.chunkIndex(dims, chunkDims)
+	if err != nil {
+		t.Fatalf("reading chunk index: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d chunks, want 2", len(entries))
+	}
+	for i, want := range []struct{ addr, size uint64 }{{1 << 40, 5 << 30}, {3 << 40, 7 << 32}} {
+		if entries[i].Address != want.addr || entries[i].Size != want.size {
+			t.Errorf("chunk %d at %d with size %d, want %d with size %d",
+				i, entries[i].Address, entries[i].Size, want.addr, want.size)
+		}
+	}
+}
+
+func TestExtensibleArrayChunkIndex(t *testing.T) {
+	c := &Chunked{dataspace: &message.Dataspace{
+		Dimensions: []uint64{3, 10},
+		MaxDims:    []uint64{3, message.Unlimited},
+	}}
+	for idx := uint64(0); idx < 10; idx++ {
+		offset := c.extensibleArrayChunkOffset(idx, []uint64{3, 10}, []uint32{2, 4})
+		if got := ExtensibleArrayChunkIndex(offset, []uint64{3, 10}, c.dataspace.MaxDims, []uint32{2, 4}); got != idx {
+			t.Errorf("chunk at %v has index %d, want %d", offset, got, idx)
+		}
+	}
+}
+
+// nopCloser is an in-memory ExternalFile.
+type nopCloser struct{ bytesReaderAt }
+
+func (nopCloser) Close() error { return nil }
+
+func TestExternalContiguousSegments(t *testing.T) {
+	files := map[string][]byte{"a": {0, 1, 2, 3}, "b": {9, 9, 4, 5}}
+	open := func(name string) (ExternalFile, error) {
+		data, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("no file %q", name)
+		}
+		return nopCloser{bytesReaderAt(data)}, nil
+	}
+	dataspace := &message.Dataspace{SpaceType: message.DataspaceSimple, Rank: 1, Dimensions: []uint64{6}}
+	datatype := &message.Datatype{Class: message.ClassFixedPoint, Size: 1}
+
+	// Two bytes of a, the two after the header of b, then the rest of a
+	segments := []ExternalSegment{{Name: "a", Size: 2}, {Name: "b", Offset: 2, Size: 2}, {Name: "a", Offset: 2, Size: 2}}
+	ext := NewExternalContiguous(segments, dataspace, datatype, open)
+	if result, err := ext.Read(); err != nil || !bytes.Equal(result, []byte{0, 1, 4, 5, 2, 3}) {
+		t.Errorf("Read = %v, %v; want [0 1 4 5 2 3]", result, err)
+	}
+	if result, err := ext.ReadSlice([]uint64{1}, []uint64{4}); err != nil || !bytes.Equal(result, []byte{1, 4, 5, 2}) {
+		t.Errorf("ReadSlice = %v, %v; want [1 4 5 2]", result, err)
+	}
+
+	// Segments holding less than the dataset are an error
+	short := NewExternalContiguous(segments[:2], dataspace, datatype, open)
+	if _, err := short.Read(); err == nil {
+		t.Error("Read past the last segment succeeded")
+	}
+	if result, err := short.ReadPoints([][]uint64{{3}}); err != nil || !bytes.Equal(result, []byte{5}) {
+		t.Errorf("ReadPoints = %v, %v; want [5]", result, err)
+	}
+}
+
+// newFilteredChunked writes a one-dimensional float64 dataset of nchunks
+// shuffled and deflated chunks of 256 elements, with a fixed array index,
+// and returns its layout with the index loaded.
+func newFilteredChunked(tb testing.TB, nchunks int) *Chunked {
+	tb.Helper()
+	mem := &memWriterAt{}
+	next := uint64(64)
+	allocate := func(size int64) uint64 {
+		addr := next
+		next += uint64(size)
+		return addr
+	}
+	cw := NewChunkWriter(binary.NewWriter(mem, binary.DefaultConfig()), []uint32{256}, 8, allocate)
+	pipeline := &message.FilterPipeline{Version: 2, Filters: []message.FilterInfo{
+		{ID: message.FilterShuffle, ClientData: []uint32{8}},
+		{ID: message.FilterDeflate, ClientData: []uint32{6}},
+	}}
+	p, err := filter.NewPipeline(pipeline)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	cw.SetPipeline(p)
+
+	chunks := make([][]byte, nchunks)
+	for i := range chunks {
+		chunks[i] = make([]byte, 256*8)
+		for j := 0; j < 256; j++ {
+			stdbinary.LittleEndian.PutUint64(chunks[i][8*j:], uint64(i*256+j))
+		}
+	}
+	addrs, sizes, err := cw.WriteChunks(chunks)
+	if err != nil {
+		tb.Fatalf("WriteChunks failed: %v", err)
+	}
+	indexAddr, err := cw.WriteFixedArrayIndex(addrs, sizes)
+	if err != nil {
+		tb.Fatalf("WriteFixedArrayIndex failed: %v", err)
+	}
+
+	reader := binary.NewReader(bytesReaderAt(mem.buf), binary.DefaultConfig())
+	chunked, err := NewChunked(&message.DataLayout{
+		Version:        4,
+		Class:          message.LayoutChunked,
+		ChunkDims:      []uint32{256, 8},
+		ChunkIndexType: message.ChunkIndexFixedArray,
+		ChunkIndexAddr: indexAddr,
+	}, &message.Dataspace{
+		SpaceType:  message.DataspaceSimple,
+		Rank:       1,
+		Dimensions: []uint64{uint64(nchunks) * 256},
+	}, &message.Datatype{Class: message.ClassFixedPoint, Size: 8}, pipeline, reader)
+	if err != nil {
+		tb.Fatalf("NewChunked failed: %v", err)
+	}
+	data, err := chunked.Read()
+	if err != nil {
+		tb.Fatalf("Read failed: %v", err)
+	}
+	for i := 0; i < nchunks*256; i++ {
+		if v := stdbinary.LittleEndian.Uint64(data[8*i:]); v != uint64(i) {
+			tb.Fatalf("element %d = %d", i, v)
+		}
+	}
+	return chunked
+}
+
+// A chunk that decodes to other than the chunk size fails its read,
+// rather than leaving part of the output unfilled.
+func TestChunkedReadWrongDecodedSize(t *testing.T) {
+	written := newFilteredChunked(t, 2)
+
+	// The same chunks, read as chunks of 512 elements
+	pipeline := &message.FilterPipeline{Version: 2, Filters: []message.FilterInfo{
+		{ID: message.FilterShuffle, ClientData: []uint32{8}},
+		{ID: message.FilterDeflate, ClientData: []uint32{6}},
+	}}
+	chunked, err := NewChunked(&message.DataLayout{
+		Version:        4,
+		Class:          message.LayoutChunked,
+		ChunkDims:      []uint32{512, 8},
+		ChunkIndexType: message.ChunkIndexFixedArray,
+		ChunkIndexAddr: written.layout.ChunkIndexAddr,
+	}, &message.Dataspace{
+		SpaceType:  message.DataspaceSimple,
+		Rank:       1,
+		Dimensions: []uint64{1024},
+	}, &message.Datatype{Class: message.ClassFixedPoint, Size: 8}, pipeline, written.reader)
+	if err != nil {
+		t.Fatalf("NewChunked failed: %v", err)
+	}
+
+	_, err = chunked.Read()
+	var chunkErr *ChunkError
+	if !errors.Is(err, filter.ErrDecodedSize) || !errors.As(err, &chunkErr) {
+		t.Fatalf("Read: err = %v, want a ChunkError wrapping ErrDecodedSize", err)
+	}
+	if !strings.Contains(err.Error(), "decoded 2048 bytes, expected 4096") {
+		t.Errorf("error %q does not give both sizes", err)
+	}
+}
+
+// Reading reuses its decode buffers from one chunk to the next, so the
+// allocations of a read do not grow with its number of chunks.
+func TestChunkedReadAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector drops pooled decode buffers")
+	}
+	allocs := func(nchunks int) float64 {
+		chunked := newFilteredChunked(t, nchunks)
+		return testing.AllocsPerRun(5, func() {
+			if _, err := chunked.Read(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+	few, many := allocs(16), allocs(1024)
+	if many > few+16 {
+		t.Errorf("reading 1024 chunks made %v allocations, 16 chunks %v", many, few)
+	}
+}
+
+func BenchmarkChunkedRead(b *testing.B) {
+	const nchunks = 4096
+	chunked := newFilteredChunked(b, nchunks)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := chunked.Read(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(testing.AllocsPerRun(1, func() { chunked.Read() }))/nchunks, "allocs/chunk")
+}
+
+func TestVirtual(t *testing.T) {
+	datatype := &message.Datatype{Class: message.ClassFixedPoint, Size: 4}
+	compact := func(dims []uint64, values ...uint32) *Compact {
+		var data []byte
+		for _, v := range values {
+			data = stdbinary.LittleEndian.AppendUint32(data, v)
+		}
+		dataspace := &message.Dataspace{SpaceType: message.DataspaceSimple, Rank: len(dims), Dimensions: dims}
+		return NewCompact(&message.DataLayout{Class: message.LayoutCompact, CompactData: data}, dataspace, datatype)
+	}
+	sources := map[string]*Compact{
+		"a": compact([]uint64{4}, 1, 2, 3, 4),
+		"b": compact([]uint64{2, 2}, 5, 6, 7, 8),
+		"c": compact([]uint64{3, 2}, 10, 11, 12, 13, 14, 15),
+	}
+	opened := map[string]int{}
+	open := func(file, dataset string) (Layout, []uint64, error) {
+		opened[dataset]++
+		src, ok := sources[dataset]
+		if !ok {
+			return nil, nil, fmt.Errorf("no dataset %s in %s", dataset, file)
+		}
+		return src, src.dataspace.Dimensions, nil
+	}
+
+	// A 4x4 dataset: row 0 is all of a, row 1 all of b taken row by row,
+	// row 2 the middle column of c, and row 3 is not mapped
+	mappings := []VirtualMapping{
+		{File: "f.h5", Dataset: "a", Start: []uint64{0, 0}, Count: []uint64{1, 4}},
+		{File: "f.h5", Dataset: "b", Start: []uint64{1, 0}, Count: []uint64{1, 4}},
+		{File: "f.h5", Dataset: "c", Start: []uint64{2, 1}, Count: []uint64{1, 3},
+			SourceStart: []uint64{0, 1}, SourceCount: []uint64{3, 1}},
+	}
+	dataspace := &message.Dataspace{SpaceType: message.DataspaceSimple, Rank: 2, Dimensions: []uint64{4, 4}}
+	v := NewVirtual(mappings, dataspace, datatype, open)
+	v.SetFillValue([]byte{0xff, 0xff, 0xff, 0xff})
+	const fill = 0xffffffff
+
+	decode := func(data []byte) []uint32 {
+		values := make([]uint32, len(data)/4)
+		for i := range values {
+			values[i] = stdbinary.LittleEndian.Uint32(data[4*i:])
+		}
+		return values
+	}
+	tests := []struct {
+		name         string
+		start, count []uint64
+		want         []uint32
+	}{
+		{"whole", []uint64{0, 0}, []uint64{4, 4}, []uint32{
+			1, 2, 3, 4,
+			5, 6, 7, 8,
+			fill, 11, 13, 15,
+			fill, fill, fill, fill,
+		}},
+		{"across rows", []uint64{0, 1}, []uint64{3, 2}, []uint32{2, 3, 6, 7, 11, 13}},
+		{"part of c", []uint64{2, 2}, []uint64{2, 2}, []uint32{13, 15, fill, fill}},
+		{"unmapped", []uint64{3, 0}, []uint64{1, 4}, []uint32{fill, fill, fill, fill}},
+	}
+	for _, tt := range tests {
+		data, err := v.ReadSlice(tt.start, tt.count)
+		if err != nil {
+			t.Fatalf("%s: ReadSlice failed: %v", tt.name, err)
+		}
+		if got := decode(data); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if data, err := v.Read(); err != nil || len(data) != 64 {
+		t.Errorf("Read() = %d bytes, %v", len(data), err)
+	}
+	data, err := v.ReadPoints([][]uint64{{1, 3}, {3, 0}, {2, 1}})
+	if err != nil {
+		t.Fatalf("ReadPoints failed: %v", err)
+	}
+	if got := decode(data); !reflect.DeepEqual(got, []uint32{8, fill, 11}) {
+		t.Errorf("ReadPoints() = %v", got)
+	}
+	for name, n := range opened {
+		if n != 1 {
+			t.Errorf("source %s opened %d times", name, n)
+		}
+	}
+
+	// Sources that cannot be opened, or whose boxes do not match, fail
+	// the reads that need them
+	bad := NewVirtual([]VirtualMapping{
+		{File: "f.h5", Dataset: "missing", Start: []uint64{0, 0}, Count: []uint64{1, 4}},
+		{File: "f.h5", Dataset: "a", Start: []uint64{1, 0}, Count: []uint64{1, 3}},
+	}, dataspace, datatype, open)
+	if _, err := bad.ReadSlice([]uint64{0, 0}, []uint64{1, 1}); err == nil || !strings.Contains(err.Error(), "no dataset missing") {
+		t.Errorf("missing source: got %v", err)
+	}
+	if _, err := bad.ReadSlice([]uint64{1, 0}, []uint64{1, 1}); err == nil {
+		t.Error("expected error mapping 3 elements to 4")
+	}
+	if _, err := bad.ReadSlice([]uint64{2, 0}, []uint64{1, 4}); err != nil {
+		t.Errorf("reading unmapped row failed: %v", err)
+	}
+}
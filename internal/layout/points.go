@@ -0,0 +1,209 @@
+package layout
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/robert-malhotra/go-hdf5/internal/btree"
+)
+
+// pointReadSpan is the number of bytes of contiguous storage a point
+// selection may cover per point and still be read one element at a time.
+// Denser selections read the whole dataset once instead of issuing a small
+// read for each point.
+const pointReadSpan = 4096
+
+// pointIndexes returns the row-major element index of each point, checking
+// that it has one coordinate per dimension and lies within dims. A scalar
+// dataset, with no dimensions, has the single point with no coordinates.
+func pointIndexes(dims []uint64, points [][]uint64) ([]uint64, error) {
+	indexes := make([]uint64, len(points))
+	for i, p := range points {
+		if len(p) != len(dims) {
+			return nil, fmt.Errorf("point %d has %d coordinates, dataset has rank %d", i, len(p), len(dims))
+		}
+		var index uint64
+		for d, size := range dims {
+			if p[d] >= size {
+				return nil, fmt.Errorf("point %d %v out of bounds: dimension %d has size %d", i, p, d, size)
+			}
+			index = index*size + p[d]
+		}
+		indexes[i] = index
+	}
+	return indexes, nil
+}
+
+// ReadPoints reads the elements at the given points from compact storage.
+func (c *Compact) ReadPoints(points [][]uint64) ([]byte, error) {
+	indexes, err := pointIndexes(c.dataspace.Dimensions, points)
+	if err != nil {
+		return nil, err
+	}
+	elementSize := uint64(c.datatype.Size)
+	output := make([]byte, uint64(len(points))*elementSize)
+	for i, index := range indexes {
+		offset := index * elementSize
+		if offset+elementSize > uint64(len(c.data)) {
+			return nil, fmt.Errorf("point %d lies past the %d bytes of compact data", i, len(c.data))
+		}
+		copy(output[uint64(i)*elementSize:], c.data[offset:offset+elementSize])
+	}
+	return output, nil
+}
+
+// ReadPoints reads the elements at the given points from contiguous
+// storage. Sparse selections read each element on its own; dense ones read
+// the whole dataset once.
+func (c *Contiguous) ReadPoints(points [][]uint64) ([]byte, error) {
+	indexes, err := pointIndexes(c.dataspace.Dimensions, points)
+	if err != nil {
+		return nil, err
+	}
+	if len(points) == 0 {
+		return []byte{}, nil
+	}
+	elementSize := uint64(c.datatype.Size)
+	if !c.Allocated() {
+		return c.unallocated(uint64(len(points)) * elementSize)
+	}
+
+	output := make([]byte, uint64(len(points))*elementSize)
+	if uint64(len(points))*pointReadSpan >= c.size {
+		data, err := c.Read()
+		if err != nil {
+			return nil, err
+		}
+		for i, index := range indexes {
+			offset := index * elementSize
+			if offset+elementSize > uint64(len(data)) {
+				return nil, fmt.Errorf("point %d lies past the %d bytes of contiguous data", i, len(data))
+			}
+			copy(output[uint64(i)*elementSize:], data[offset:offset+elementSize])
+		}
+		return output, nil
+	}
+
+	for i, index := range indexes {
+		r := c.reader.AtSize(int64(c.address+index*elementSize), int(elementSize))
+		elem, err := r.ReadBytes(int(elementSize))
+		if err != nil {
+			return nil, fmt.Errorf("reading point %d: %w", i, err)
+		}
+		copy(output[uint64(i)*elementSize:], elem)
+	}
+	return output, nil
+}
+
+// ReadPoints reads the elements at the given points from chunked storage.
+// The points are grouped by the chunk containing them, and each of those
+// chunks is read and decoded once. As with ReadSlice, a few chunks are
+// looked up one at a time in B-tree indexes instead of loading the whole
+// index. Points in chunks that were never written read as the fill value.
+func (c *Chunked) ReadPoints(points [][]uint64) ([]byte, error) {
+	dims := c.dataspace.Dimensions
+	if len(dims) == 0 {
+		// Scalar dataset - shouldn't be chunked normally
+		dims = []uint64{1}
+	}
+	if _, err := pointIndexes(dims, points); err != nil {
+		return nil, err
+	}
+
+	chunkDims := c.layout.ChunkDims
+	if len(chunkDims) == 0 {
+		return nil, fmt.Errorf("chunked layout has no chunk dimensions")
+	}
+	if len(chunkDims) > len(dims) {
+		chunkDims = chunkDims[:len(dims)]
+	}
+
+	elementSize := uint64(c.datatype.Size)
+	chunkElements := uint64(1)
+	for _, d := range chunkDims {
+		chunkElements *= uint64(d)
+	}
+	chunkSizeBytes := chunkElements * elementSize
+	output := c.newOutput(uint64(len(points)) * elementSize)
+
+	// Group the points by the chunk containing them, numbering chunks in
+	// row-major order over the grid of chunks
+	grid := make([]uint64, len(dims))
+	for d := range dims {
+		grid[d] = (dims[d] + uint64(chunkDims[d]) - 1) / uint64(chunkDims[d])
+	}
+	chunkNumber := func(coords []uint64) uint64 {
+		var n uint64
+		for d := range dims {
+			n = n*grid[d] + coords[d]/uint64(chunkDims[d])
+		}
+		return n
+	}
+	groups := make(map[uint64][]int)
+	for i, p := range points {
+		n := chunkNumber(p)
+		groups[n] = append(groups[n], i)
+	}
+	numbers := make([]uint64, 0, len(groups))
+	for n := range groups {
+		numbers = append(numbers, n)
+	}
+	slices.Sort(numbers)
+
+	// Find the chunks' entries, one lookup each for a few chunks of a
+	// B-tree index, otherwise from the full index
+	find, err := c.chunkLookup()
+	if err != nil {
+		return nil, err
+	}
+	if find == nil || len(numbers) > chunkLookupLimit {
+		find = nil
+		if _, _, err := c.chunkIndex(dims, chunkDims); err != nil {
+			return nil, err
+		}
+	}
+
+	offset := make([]uint64, len(dims))
+	var scratch chunkScratch
+	for _, n := range numbers {
+		rest := n
+		for d := len(dims) - 1; d >= 0; d-- {
+			offset[d] = rest % grid[d] * uint64(chunkDims[d])
+			rest /= grid[d]
+		}
+
+		var entry btree.ChunkEntry
+		var found bool
+		if find != nil {
+			entry, found, err = find(c.reader, c.layout.ChunkIndexAddr, len(dims), offset)
+			if err != nil {
+				return nil, fmt.Errorf("looking up chunk at offset %v: %w", offset, err)
+			}
+		} else if e := c.index.FindChunk(offset, chunkDims); e != nil {
+			entry, found = *e, true
+		}
+		if !found || entry.Address == 0 || entry.Address == 0xFFFFFFFFFFFFFFFF {
+			continue // Unallocated chunks keep the fill value
+		}
+
+		chunkData, err := c.decodeChunk(entry, chunkSizeBytes, &scratch)
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(chunkData)) < chunkSizeBytes {
+			return nil, fmt.Errorf("chunk at offset %v decoded to %d bytes, expected %d",
+				offset, len(chunkData), chunkSizeBytes)
+		}
+
+		// Copy each point from its position within the chunk
+		for _, i := range groups[n] {
+			var index uint64
+			for d := range dims {
+				index = index*uint64(chunkDims[d]) + points[i][d] - offset[d]
+			}
+			src := chunkData[index*elementSize : (index+1)*elementSize]
+			copy(output[uint64(i)*elementSize:], src)
+		}
+	}
+	return output, nil
+}
@@ -0,0 +1,7 @@
+//go:build race
+
+package layout
+
+// raceEnabled is set in builds with the race detector, which drops
+// sync.Pool items at random.
+const raceEnabled = true
@@ -0,0 +1,371 @@
+package layout
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/btree"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// ErrIndexNotWritable is returned by Chunked.WriteSlice when chunks must be
+// added to or moved in a chunk index that cannot be written, such as a
+// B-tree.
+var ErrIndexNotWritable = errors.New("chunk index cannot be rewritten")
+
+// forEachRun calls fn for each contiguous run of elements of the hyperslab
+// (start, count) of a row-major array with dimensions dims, with the
+// element offset of the run in the array, its element offset in the
+// hyperslab's own row-major data, and its length in elements. Trailing
+// dimensions selected whole are merged into longer runs.
+func forEachRun(dims, start, count []uint64, fn func(offset, selOffset, n uint64) error) error {
+	ndims := len(dims)
+	for _, c := range count {
+		if c == 0 {
+			return nil
+		}
+	}
+
+	// Runs cover dimension k and every dimension after it
+	k := ndims - 1
+	for k > 0 && start[k] == 0 && count[k] == dims[k] {
+		k--
+	}
+	inner := uint64(1)
+	for d := k + 1; d < ndims; d++ {
+		inner *= dims[d]
+	}
+	n := count[k] * inner
+
+	idx := make([]uint64, k) // Position within the selection in dimensions before k
+	for selOffset := uint64(0); ; selOffset += n {
+		offset := uint64(0)
+		for d := 0; d <= k; d++ {
+			pos := start[d]
+			if d < k {
+				pos += idx[d]
+			}
+			offset = offset*dims[d] + pos
+		}
+		if err := fn(offset*inner, selOffset, n); err != nil {
+			return err
+		}
+
+		d := k - 1
+		for ; d >= 0; d-- {
+			idx[d]++
+			if idx[d] < count[d] {
+				break
+			}
+			idx[d] = 0
+		}
+		if d < 0 {
+			return nil
+		}
+	}
+}
+
+// copyRegion copies a box of count elements per dimension from src, a
+// row-major array with dimensions srcDims, starting at srcStart, into dst,
+// with dimensions dstDims, at dstStart.
+func copyRegion(dst []byte, dstDims, dstStart []uint64, src []byte, srcDims, srcStart, count []uint64, elementSize uint64) {
+	ndims := len(count)
+	for _, c := range count {
+		if c == 0 {
+			return
+		}
+	}
+	rowBytes := count[ndims-1] * elementSize
+	idx := make([]uint64, ndims)
+	for {
+		s, t := uint64(0), uint64(0)
+		for d := 0; d < ndims; d++ {
+			s = s*srcDims[d] + srcStart[d] + idx[d]
+			t = t*dstDims[d] + dstStart[d] + idx[d]
+		}
+		copy(dst[t*elementSize:t*elementSize+rowBytes], src[s*elementSize:s*elementSize+rowBytes])
+
+		d := ndims - 2
+		for ; d >= 0; d-- {
+			idx[d]++
+			if idx[d] < count[d] {
+				break
+			}
+			idx[d] = 0
+		}
+		if d < 0 {
+			return
+		}
+	}
+}
+
+// checkSlice checks that a hyperslab lies within dims and that data holds
+// exactly its elements.
+func checkSlice(dims, start, count []uint64, data []byte, elementSize uint64) error {
+	if len(start) != len(dims) || len(count) != len(dims) {
+		return fmt.Errorf("start and count must have %d dimensions, got %d and %d",
+			len(dims), len(start), len(count))
+	}
+	elements := uint64(1)
+	for d := range dims {
+		if count[d] > dims[d] || start[d] > dims[d]-count[d] {
+			return fmt.Errorf("slice out of bounds: dimension %d, start=%d, count=%d, size=%d",
+				d, start[d], count[d], dims[d])
+		}
+		elements *= count[d]
+	}
+	if uint64(len(data)) != elements*elementSize {
+		return fmt.Errorf("%d bytes of data for %d elements of %d bytes", len(data), elements, elementSize)
+	}
+	return nil
+}
+
+// WriteSlice writes data, the row-major elements of the hyperslab (start,
+// count), into contiguous storage through w, one run of adjacent elements
+// at a time.
+func (c *Contiguous) WriteSlice(w *binary.Writer, start, count []uint64, data []byte) error {
+	dims := c.dataspace.Dimensions
+	elementSize := uint64(c.datatype.Size)
+	if err := checkSlice(dims, start, count, data, elementSize); err != nil {
+		return err
+	}
+	if c.reader.IsUndefinedOffset(c.address) {
+		return fmt.Errorf("contiguous data not allocated")
+	}
+	if len(dims) == 0 {
+		return w.At(int64(c.address)).WriteBytes(data)
+	}
+
+	return forEachRun(dims, start, count, func(offset, selOffset, n uint64) error {
+		run := data[selOffset*elementSize : (selOffset+n)*elementSize]
+		return w.At(int64(c.address + offset*elementSize)).WriteBytes(run)
+	})
+}
+
+// WriteSlice returns the compact data with the hyperslab (start, count)
+// replaced by data, the row-major elements of the hyperslab. The caller
+// writes it back into the layout message.
+func (c *Compact) WriteSlice(start, count []uint64, data []byte) ([]byte, error) {
+	dims := c.dataspace.Dimensions
+	elementSize := uint64(c.datatype.Size)
+	if err := checkSlice(dims, start, count, data, elementSize); err != nil {
+		return nil, err
+	}
+	if uint64(len(c.data)) < c.dataspace.NumElements()*elementSize {
+		return nil, fmt.Errorf("compact data has %d bytes, expected %d", len(c.data), c.dataspace.NumElements()*elementSize)
+	}
+
+	output := make([]byte, len(c.data))
+	copy(output, c.data)
+	if len(dims) == 0 {
+		copy(output, data)
+		return output, nil
+	}
+	err := forEachRun(dims, start, count, func(offset, selOffset, n uint64) error {
+		copy(output[offset*elementSize:], data[selOffset*elementSize:(selOffset+n)*elementSize])
+		return nil
+	})
+	return output, err
+}
+
+// WriteSlice writes data, the row-major elements of the hyperslab (start,
+// count), into chunked storage. Chunks the hyperslab covers only in part
+// are read and decoded first; chunks never written start from the fill
+// value.
+//
+// Unfiltered chunks already allocated are overwritten in place. Others are
+// written anew through cw, which must have the dataset's filters, and the
+// chunk index is then written again: WriteSlice returns a copy of the
+// layout message pointing at the new index (or, for a single chunk, at the
+// chunk), for the caller to write into the object header, or nil if the
+// index did not change. Fixed array, extensible array and single chunk
+// indexes can be rewritten; others return an error wrapping
+// ErrIndexNotWritable.
+func (c *Chunked) WriteSlice(cw *ChunkWriter, start, count []uint64, data []byte) (*message.DataLayout, error) {
+	dims := c.dataspace.Dimensions
+	elementSize := uint64(c.datatype.Size)
+	if len(dims) == 0 {
+		return nil, fmt.Errorf("cannot slice scalar dataset")
+	}
+	if err := checkSlice(dims, start, count, data, elementSize); err != nil {
+		return nil, err
+	}
+	for _, n := range count {
+		if n == 0 {
+			return nil, nil
+		}
+	}
+	chunkDims := c.layout.ChunkDims
+	if len(chunkDims) < len(dims) {
+		return nil, fmt.Errorf("chunked layout has %d chunk dimensions for rank %d", len(chunkDims), len(dims))
+	}
+	chunkDims = chunkDims[:len(dims)]
+	chunkDims64 := make([]uint64, len(dims))
+	chunkBytes := elementSize
+	for d, n := range chunkDims {
+		chunkDims64[d] = uint64(n)
+		chunkBytes *= uint64(n)
+	}
+
+	indexType, entries, err := c.chunkIndex(dims, chunkDims)
+	if err != nil {
+		return nil, err
+	}
+	key := func(offset []uint64) string { return fmt.Sprint(offset) }
+	existing := make(map[string]btree.ChunkEntry, len(entries))
+	for _, entry := range entries {
+		if entry.Address == 0 || c.reader.IsUndefinedOffset(entry.Address) {
+			continue
+		}
+		if offset, inside, err := chunkOffset(entry, dims); err == nil && inside {
+			existing[key(offset)] = entry
+		}
+	}
+
+	// Visit the chunks overlapping the hyperslab
+	first := make([]uint64, len(dims))
+	last := make([]uint64, len(dims))
+	for d := range dims {
+		first[d] = start[d] / chunkDims64[d] * chunkDims64[d]
+		last[d] = (start[d] + count[d] - 1) / chunkDims64[d] * chunkDims64[d]
+	}
+	type moved struct {
+		offset []uint64
+		addr   uint64
+		size   uint64
+	}
+	var written []moved
+	offset := append([]uint64{}, first...)
+	for {
+		// The overlap of the chunk and the hyperslab
+		overlap := make([]uint64, len(dims))
+		inChunk := make([]uint64, len(dims))
+		inSel := make([]uint64, len(dims))
+		whole := true
+		for d := range dims {
+			lo, hi := max(offset[d], start[d]), min(offset[d]+chunkDims64[d], start[d]+count[d])
+			overlap[d] = hi - lo
+			inChunk[d] = lo - offset[d]
+			inSel[d] = lo - start[d]
+			whole = whole && overlap[d] == chunkDims64[d]
+		}
+
+		entry, found := existing[key(offset)]
+		var chunk []byte
+		switch {
+		case whole:
+			chunk = make([]byte, chunkBytes)
+		case found:
+			if chunk, err = c.decodeChunk(entry, chunkBytes, nil); err != nil {
+				return nil, err
+			}
+			if uint64(len(chunk)) < chunkBytes {
+				return nil, fmt.Errorf("chunk at offset %v decoded to %d bytes, expected %d", offset, len(chunk), chunkBytes)
+			}
+			chunk = chunk[:chunkBytes]
+		default:
+			chunk = c.newOutput(chunkBytes)
+		}
+		copyRegion(chunk, chunkDims64, inChunk, data, count, inSel, overlap, elementSize)
+
+		if found && !cw.Filtered() {
+			if err := cw.w.At(int64(entry.Address)).WriteBytes(chunk); err != nil {
+				return nil, fmt.Errorf("writing chunk at offset %v: %w", offset, err)
+			}
+		} else {
+			addrs, sizes, err := cw.WriteChunks([][]byte{chunk})
+			if err != nil {
+				return nil, fmt.Errorf("writing chunk at offset %v: %w", offset, err)
+			}
+			written = append(written, moved{append([]uint64{}, offset...), addrs[0], sizes[0]})
+		}
+
+		d := len(dims) - 1
+		for ; d >= 0; d-- {
+			offset[d] += chunkDims64[d]
+			if offset[d] <= last[d] {
+				break
+			}
+			offset[d] = first[d]
+		}
+		if d < 0 {
+			break
+		}
+	}
+	if len(written) == 0 {
+		return nil, nil
+	}
+
+	// Write the chunk index again with the moved chunks. A layout with no
+	// chunk written yet still names the index to write.
+	updated := *c.layout
+	if indexType == "unallocated" && c.layout.Version >= 4 {
+		switch c.layout.ChunkIndexType {
+		case message.ChunkIndexSingleChunk:
+			indexType = "single"
+		case message.ChunkIndexFixedArray:
+			indexType = "fixed_array"
+		case message.ChunkIndexExtensibleArray:
+			indexType = "extensible_array"
+		}
+	}
+	switch indexType {
+	case "single":
+		updated.ChunkIndexAddr = written[0].addr
+		updated.FilteredChunkSize = written[0].size
+		updated.FilterMask = cw.FilterMask()
+
+	case "fixed_array":
+		grid := make([]uint64, len(dims))
+		nchunks := uint64(1)
+		for d := range dims {
+			grid[d] = (dims[d] + chunkDims64[d] - 1) / chunkDims64[d]
+			nchunks *= grid[d]
+		}
+		number := func(offset []uint64) uint64 {
+			var n uint64
+			for d := range dims {
+				n = n*grid[d] + offset[d]/chunkDims64[d]
+			}
+			return n
+		}
+		addrs := make([]uint64, nchunks)
+		sizes := make([]uint64, nchunks)
+		for i := range addrs {
+			addrs[i] = ^uint64(0)
+		}
+		for _, entry := range existing {
+			n := number(entry.Offset[:len(dims)])
+			addrs[n], sizes[n] = entry.Address, entry.Size
+		}
+		for _, m := range written {
+			n := number(m.offset)
+			addrs[n], sizes[n] = m.addr, m.size
+		}
+		if updated.ChunkIndexAddr, err = cw.WriteFixedArrayIndex(addrs, sizes); err != nil {
+			return nil, fmt.Errorf("writing chunk index: %w", err)
+		}
+
+	case "extensible_array":
+		addrs, sizes, err := c.ExtensibleArrayChunks()
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range written {
+			idx := ExtensibleArrayChunkIndex(m.offset, dims, c.dataspace.MaxDims, chunkDims)
+			for uint64(len(addrs)) <= idx {
+				addrs = append(addrs, ^uint64(0))
+				sizes = append(sizes, 0)
+			}
+			addrs[idx], sizes[idx] = m.addr, m.size
+		}
+		if updated.ChunkIndexAddr, err = cw.WriteExtensibleArrayIndex(addrs, sizes); err != nil {
+			return nil, fmt.Errorf("writing chunk index: %w", err)
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: %s index", ErrIndexNotWritable, indexType)
+	}
+	return &updated, nil
+}
@@ -0,0 +1,284 @@
+package layout
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// VirtualMapping maps a box of a virtual dataset's elements to a box of a
+// source dataset's with as many elements, taking both in row-major order.
+type VirtualMapping struct {
+	File    string // Source file name, as recorded in the file
+	Dataset string // Source dataset path, as recorded in the file
+
+	Start, Count []uint64 // Box of the virtual dataset
+
+	// Box of the source dataset; nil for the whole source
+	SourceStart, SourceCount []uint64
+}
+
+// SourceOpener opens the source dataset of a mapping, given its file and
+// dataset names as recorded in the file, and returns its layout and
+// dimensions.
+type SourceOpener func(file, dataset string) (Layout, []uint64, error)
+
+// Virtual represents the layout of a virtual dataset, whose elements are
+// read from the source datasets of its mappings. Elements no mapping
+// covers read as the fill value. Sources are opened when first read and
+// kept for later reads.
+type Virtual struct {
+	mappings  []VirtualMapping
+	dataspace *message.Dataspace
+	datatype  *message.Datatype
+	open      SourceOpener
+	fillValue []byte // One element; nil reads unmapped elements as zeros
+
+	mu      sync.Mutex
+	sources map[[2]string]*virtualSource // By file and dataset name
+}
+
+// virtualSource is an open source dataset.
+type virtualSource struct {
+	layout Layout
+	dims   []uint64
+}
+
+// NewVirtual creates a layout handler for a virtual dataset with the given
+// mappings, opening their sources with open.
+func NewVirtual(
+	mappings []VirtualMapping,
+	dataspace *message.Dataspace,
+	datatype *message.Datatype,
+	open SourceOpener,
+) *Virtual {
+	return &Virtual{
+		mappings:  mappings,
+		dataspace: dataspace,
+		datatype:  datatype,
+		open:      open,
+		sources:   make(map[[2]string]*virtualSource),
+	}
+}
+
+func (v *Virtual) Class() message.LayoutClass {
+	return message.LayoutVirtual
+}
+
+// SetFillValue sets the bytes of one element that elements no mapping
+// covers read as. It must be called before the first read. A nil value,
+// the default, reads them as zeros.
+func (v *Virtual) SetFillValue(value []byte) {
+	v.fillValue = value
+}
+
+// Read reads all data of the virtual dataset.
+func (v *Virtual) Read() ([]byte, error) {
+	dims := v.dataspace.Dimensions
+	return v.ReadSlice(make([]uint64, len(dims)), dims)
+}
+
+// ReadSlice reads a hyperslab of the virtual dataset, mapping by mapping.
+// Each mapping overlapping the hyperslab reads the part it covers from its
+// source: just that part if the virtual and source boxes have the same
+// shape once dimensions of size 1 are left out, as when sources are
+// stacked along a new dimension, and otherwise the whole source box.
+func (v *Virtual) ReadSlice(start, count []uint64) ([]byte, error) {
+	dims := v.dataspace.Dimensions
+	if len(dims) == 0 {
+		return nil, fmt.Errorf("scalar virtual datasets are not supported")
+	}
+	if len(start) != len(dims) || len(count) != len(dims) {
+		return nil, fmt.Errorf("start and count must have %d dimensions, got %d and %d",
+			len(dims), len(start), len(count))
+	}
+	elements := uint64(1)
+	for d := range dims {
+		if count[d] > dims[d] || start[d] > dims[d]-count[d] {
+			return nil, fmt.Errorf("slice out of bounds: dimension %d, start=%d, count=%d, size=%d",
+				d, start[d], count[d], dims[d])
+		}
+		elements *= count[d]
+	}
+
+	elementSize := uint64(v.datatype.Size)
+	output := filled(v.fillValue, elements*elementSize)
+	if elements == 0 {
+		return output, nil
+	}
+	for i := range v.mappings {
+		m := &v.mappings[i]
+		partStart, partCount, ok := overlap(start, count, m.Start, m.Count)
+		if !ok {
+			continue
+		}
+		part, err := v.readMapping(m, partStart, partCount)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s in %s: %w", m.Dataset, m.File, err)
+		}
+		dstStart := make([]uint64, len(dims))
+		for d := range dims {
+			dstStart[d] = partStart[d] - start[d]
+		}
+		copyRegion(output, count, dstStart, part, partCount, make([]uint64, len(dims)), partCount, elementSize)
+	}
+	return output, nil
+}
+
+// ReadPoints reads the elements at the given points, one at a time.
+func (v *Virtual) ReadPoints(points [][]uint64) ([]byte, error) {
+	dims := v.dataspace.Dimensions
+	if _, err := pointIndexes(dims, points); err != nil {
+		return nil, err
+	}
+	ones := make([]uint64, len(dims))
+	for d := range ones {
+		ones[d] = 1
+	}
+
+	elementSize := uint64(v.datatype.Size)
+	output := make([]byte, 0, uint64(len(points))*elementSize)
+	for i, p := range points {
+		elem, err := v.ReadSlice(p, ones)
+		if err != nil {
+			return nil, fmt.Errorf("reading point %d: %w", i, err)
+		}
+		output = append(output, elem...)
+	}
+	return output, nil
+}
+
+// readMapping reads the elements of the box (start, count) of the virtual
+// dataset, which lies within m's box, from m's source.
+func (v *Virtual) readMapping(m *VirtualMapping, start, count []uint64) ([]byte, error) {
+	src, err := v.source(m.File, m.Dataset)
+	if err != nil {
+		return nil, err
+	}
+	srcStart, srcCount := m.SourceStart, m.SourceCount
+	if srcCount == nil {
+		srcStart, srcCount = make([]uint64, len(src.dims)), src.dims
+	}
+	if len(srcStart) != len(src.dims) {
+		return nil, fmt.Errorf("source selection of rank %d in a dataset of rank %d", len(srcStart), len(src.dims))
+	}
+	for d, size := range src.dims {
+		if srcCount[d] > size || srcStart[d] > size-srcCount[d] {
+			return nil, fmt.Errorf("source selection out of bounds: dimension %d, start=%d, count=%d, size=%d",
+				d, srcStart[d], srcCount[d], size)
+		}
+	}
+	if n, srcN := product(m.Count), product(srcCount); n != srcN {
+		return nil, fmt.Errorf("mapping of %d elements to %d source elements", n, srcN)
+	}
+
+	if partStart, partCount, ok := sourcePart(m.Start, m.Count, srcStart, srcCount, start, count); ok {
+		return src.layout.ReadSlice(partStart, partCount)
+	}
+
+	// Pick the part's elements out of the whole box by their row-major
+	// index, which is the same in both boxes
+	data, err := src.layout.ReadSlice(srcStart, srcCount)
+	if err != nil {
+		return nil, err
+	}
+	elementSize := uint64(v.datatype.Size)
+	rel := make([]uint64, len(start))
+	for d := range start {
+		rel[d] = start[d] - m.Start[d]
+	}
+	part := make([]byte, product(count)*elementSize)
+	err = forEachRun(m.Count, rel, count, func(offset, selOffset, n uint64) error {
+		copy(part[selOffset*elementSize:], data[offset*elementSize:(offset+n)*elementSize])
+		return nil
+	})
+	return part, err
+}
+
+// source returns the open source dataset of a mapping, opening it on first
+// use.
+func (v *Virtual) source(file, dataset string) (*virtualSource, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	key := [2]string{file, dataset}
+	if src, ok := v.sources[key]; ok {
+		return src, nil
+	}
+	l, dims, err := v.open(file, dataset)
+	if err != nil {
+		return nil, err
+	}
+	if l == Layout(v) {
+		return nil, fmt.Errorf("virtual dataset is its own source")
+	}
+	src := &virtualSource{layout: l, dims: dims}
+	v.sources[key] = src
+	return src, nil
+}
+
+// overlap returns the box where the boxes (aStart, aCount) and (bStart,
+// bCount) overlap, and false if they do not.
+func overlap(aStart, aCount, bStart, bCount []uint64) ([]uint64, []uint64, bool) {
+	if len(bStart) != len(aStart) {
+		return nil, nil, false
+	}
+	start := make([]uint64, len(aStart))
+	count := make([]uint64, len(aStart))
+	for d := range aStart {
+		lo := max(aStart[d], bStart[d])
+		hi := min(aStart[d]+aCount[d], bStart[d]+bCount[d])
+		if hi <= lo {
+			return nil, nil, false
+		}
+		start[d], count[d] = lo, hi-lo
+	}
+	return start, count, true
+}
+
+// sourcePart returns the box of the source a part (start, count) of a
+// mapping's virtual box maps to, if the virtual and source boxes have the
+// same shape once dimensions of size 1 are left out. Otherwise the part is
+// not a box of the source in general, and sourcePart returns false.
+func sourcePart(vStart, vCount, srcStart, srcCount, start, count []uint64) ([]uint64, []uint64, bool) {
+	var vDims, srcDims []int
+	for d, n := range vCount {
+		if n != 1 {
+			vDims = append(vDims, d)
+		}
+	}
+	for d, n := range srcCount {
+		if n != 1 {
+			srcDims = append(srcDims, d)
+		}
+	}
+	if len(vDims) != len(srcDims) {
+		return nil, nil, false
+	}
+	for k := range vDims {
+		if vCount[vDims[k]] != srcCount[srcDims[k]] {
+			return nil, nil, false
+		}
+	}
+
+	partStart := slices.Clone(srcStart)
+	partCount := make([]uint64, len(srcCount))
+	for d := range partCount {
+		partCount[d] = 1
+	}
+	for k, d := range vDims {
+		partStart[srcDims[k]] += start[d] - vStart[d]
+		partCount[srcDims[k]] = count[d]
+	}
+	return partStart, partCount, true
+}
+
+// product returns the number of elements in a box of the given counts.
+func product(count []uint64) uint64 {
+	n := uint64(1)
+	for _, c := range count {
+		n *= c
+	}
+	return n
+}
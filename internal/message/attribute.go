@@ -12,17 +12,33 @@ type Attribute struct {
 	Version      uint8
 	Name         string
 	DatatypeSize uint16
+
+	// NameCharset is the character set of Name, CharsetASCII or
+	// CharsetUTF8. Only version 3 messages record it; Name holds the bytes
+	// of the name as stored either way.
+	NameCharset CharacterSet
+
 	DataspaceSize uint16
 	Datatype     *Datatype
 	Dataspace    *Dataspace
 	Data         []byte
+
+	// DatatypeRef and DataspaceRef are set in place of Datatype and
+	// Dataspace when the attribute shares them, as attributes of committed
+	// datatypes do; object.ResolveShared looks them up.
+	DatatypeRef  *SharedRef
+	DataspaceRef *SharedRef
+
+	// CreationOrder is set from the object header or dense storage index
+	// when the object tracks attribute creation order, and zero otherwise.
+	CreationOrder uint32
 }
 
 func (m *Attribute) Type() Type { return TypeAttribute }
 
-func parseAttribute(data []byte, r *binpkg.Reader) (*Attribute, error) {
+func parseAttribute(data []byte, r *binpkg.Reader) (*Attribute, int, error) {
 	if len(data) < 6 {
-		return nil, fmt.Errorf("attribute message too short")
+		return nil, 0, fmt.Errorf("attribute message too short")
 	}
 
 	attr := &Attribute{
@@ -37,13 +53,13 @@ func parseAttribute(data []byte, r *binpkg.Reader) (*Attribute, error) {
 	case 3:
 		return parseAttributeV3(data, r, attr)
 	default:
-		return nil, fmt.Errorf("unsupported attribute version: %d", attr.Version)
+		return nil, 0, fmt.Errorf("unsupported attribute version: %d", attr.Version)
 	}
 }
 
-func parseAttributeV1(data []byte, r *binpkg.Reader, attr *Attribute) (*Attribute, error) {
+func parseAttributeV1(data []byte, r *binpkg.Reader, attr *Attribute) (*Attribute, int, error) {
 	if len(data) < 8 {
-		return nil, fmt.Errorf("attribute v1 too short")
+		return nil, 0, fmt.Errorf("attribute v1 too short")
 	}
 
 	nameSize := binary.LittleEndian.Uint16(data[2:4])
@@ -54,7 +70,7 @@ func parseAttributeV1(data []byte, r *binpkg.Reader, attr *Attribute) (*Attribut
 
 	// Parse name (null-padded to 8-byte boundary)
 	if offset+int(nameSize) > len(data) {
-		return nil, fmt.Errorf("attribute name truncated")
+		return nil, 0, fmt.Errorf("attribute name truncated")
 	}
 	nameEnd := offset
 	for nameEnd < offset+int(nameSize) && data[nameEnd] != 0 {
@@ -70,7 +86,7 @@ func parseAttributeV1(data []byte, r *binpkg.Reader, attr *Attribute) (*Attribut
 
 	// Parse datatype
 	if offset+int(attr.DatatypeSize) > len(data) {
-		return nil, fmt.Errorf("attribute datatype truncated")
+		return nil, 0, fmt.Errorf("attribute datatype truncated")
 	}
 	dt, err := parseDatatype(data[offset:offset+int(attr.DatatypeSize)], r)
 	if err == nil {
@@ -85,9 +101,9 @@ func parseAttributeV1(data []byte, r *binpkg.Reader, attr *Attribute) (*Attribut
 
 	// Parse dataspace
 	if offset+int(attr.DataspaceSize) > len(data) {
-		return nil, fmt.Errorf("attribute dataspace truncated")
+		return nil, 0, fmt.Errorf("attribute dataspace truncated")
 	}
-	ds, err := parseDataspace(data[offset:offset+int(attr.DataspaceSize)], r)
+	ds, _, err := parseDataspace(data[offset:offset+int(attr.DataspaceSize)], r)
 	if err == nil {
 		attr.Dataspace = ds
 	}
@@ -98,21 +114,15 @@ func parseAttributeV1(data []byte, r *binpkg.Reader, attr *Attribute) (*Attribut
 		offset += 8 - (offset % 8)
 	}
 
-	// Remaining data is the attribute value
-	if offset < len(data) {
-		attr.Data = make([]byte, len(data)-offset)
-		copy(attr.Data, data[offset:])
-	}
-
-	return attr, nil
+	return attr.parseValue(data, offset)
 }
 
-func parseAttributeV2(data []byte, r *binpkg.Reader, attr *Attribute) (*Attribute, error) {
+func parseAttributeV2(data []byte, r *binpkg.Reader, attr *Attribute) (*Attribute, int, error) {
 	if len(data) < 6 {
-		return nil, fmt.Errorf("attribute v2 too short")
+		return nil, 0, fmt.Errorf("attribute v2 too short")
 	}
 
-	// flags := data[1]
+	flags := data[1]
 	nameSize := binary.LittleEndian.Uint16(data[2:4])
 	attr.DatatypeSize = binary.LittleEndian.Uint16(data[4:6])
 	attr.DataspaceSize = binary.LittleEndian.Uint16(data[6:8])
@@ -121,7 +131,7 @@ func parseAttributeV2(data []byte, r *binpkg.Reader, attr *Attribute) (*Attribut
 
 	// Parse name (NOT padded in v2)
 	if offset+int(nameSize) > len(data) {
-		return nil, fmt.Errorf("attribute name truncated")
+		return nil, 0, fmt.Errorf("attribute name truncated")
 	}
 	nameEnd := offset
 	for nameEnd < offset+int(nameSize) && data[nameEnd] != 0 {
@@ -132,50 +142,42 @@ func parseAttributeV2(data []byte, r *binpkg.Reader, attr *Attribute) (*Attribut
 
 	// Parse datatype
 	if offset+int(attr.DatatypeSize) > len(data) {
-		return nil, fmt.Errorf("attribute datatype truncated")
+		return nil, 0, fmt.Errorf("attribute datatype truncated")
 	}
-	dt, err := parseDatatype(data[offset:offset+int(attr.DatatypeSize)], r)
-	if err == nil {
-		attr.Datatype = dt
+	if err := attr.parseDatatype(data[offset:offset+int(attr.DatatypeSize)], flags, r); err != nil {
+		return nil, 0, err
 	}
 	offset += int(attr.DatatypeSize)
 
 	// Parse dataspace
 	if offset+int(attr.DataspaceSize) > len(data) {
-		return nil, fmt.Errorf("attribute dataspace truncated")
+		return nil, 0, fmt.Errorf("attribute dataspace truncated")
 	}
-	ds, err := parseDataspace(data[offset:offset+int(attr.DataspaceSize)], r)
-	if err == nil {
-		attr.Dataspace = ds
+	if err := attr.parseDataspace(data[offset:offset+int(attr.DataspaceSize)], flags, r); err != nil {
+		return nil, 0, err
 	}
 	offset += int(attr.DataspaceSize)
 
-	// Remaining data is the attribute value
-	if offset < len(data) {
-		attr.Data = make([]byte, len(data)-offset)
-		copy(attr.Data, data[offset:])
-	}
-
-	return attr, nil
+	return attr.parseValue(data, offset)
 }
 
-func parseAttributeV3(data []byte, r *binpkg.Reader, attr *Attribute) (*Attribute, error) {
+func parseAttributeV3(data []byte, r *binpkg.Reader, attr *Attribute) (*Attribute, int, error) {
 	// V3 is similar to V2 but with encoding field
 	if len(data) < 9 {
-		return nil, fmt.Errorf("attribute v3 too short")
+		return nil, 0, fmt.Errorf("attribute v3 too short")
 	}
 
-	// flags := data[1]
+	flags := data[1]
 	nameSize := binary.LittleEndian.Uint16(data[2:4])
 	attr.DatatypeSize = binary.LittleEndian.Uint16(data[4:6])
 	attr.DataspaceSize = binary.LittleEndian.Uint16(data[6:8])
-	// encoding := data[8]
+	attr.NameCharset = CharacterSet(data[8])
 
 	offset := 9
 
 	// Parse name
 	if offset+int(nameSize) > len(data) {
-		return nil, fmt.Errorf("attribute name truncated")
+		return nil, 0, fmt.Errorf("attribute name truncated")
 	}
 	nameEnd := offset
 	for nameEnd < offset+int(nameSize) && data[nameEnd] != 0 {
@@ -186,29 +188,86 @@ func parseAttributeV3(data []byte, r *binpkg.Reader, attr *Attribute) (*Attribut
 
 	// Parse datatype
 	if offset+int(attr.DatatypeSize) > len(data) {
-		return nil, fmt.Errorf("attribute datatype truncated")
+		return nil, 0, fmt.Errorf("attribute datatype truncated")
 	}
-	dt, err := parseDatatype(data[offset:offset+int(attr.DatatypeSize)], r)
-	if err == nil {
-		attr.Datatype = dt
+	if err := attr.parseDatatype(data[offset:offset+int(attr.DatatypeSize)], flags, r); err != nil {
+		return nil, 0, err
 	}
 	offset += int(attr.DatatypeSize)
 
 	// Parse dataspace
 	if offset+int(attr.DataspaceSize) > len(data) {
-		return nil, fmt.Errorf("attribute dataspace truncated")
+		return nil, 0, fmt.Errorf("attribute dataspace truncated")
 	}
-	ds, err := parseDataspace(data[offset:offset+int(attr.DataspaceSize)], r)
-	if err == nil {
-		attr.Dataspace = ds
+	if err := attr.parseDataspace(data[offset:offset+int(attr.DataspaceSize)], flags, r); err != nil {
+		return nil, 0, err
 	}
 	offset += int(attr.DataspaceSize)
 
-	// Remaining data is the attribute value
-	if offset < len(data) {
-		attr.Data = make([]byte, len(data)-offset)
-		copy(attr.Data, data[offset:])
+	return attr.parseValue(data, offset)
+}
+
+// Attribute flags of versions 2 and 3, marking a shared datatype or
+// dataspace
+const (
+	attrFlagSharedDatatype  = 0x01
+	attrFlagSharedDataspace = 0x02
+)
+
+// parseDatatype parses the datatype of an attribute, or the reference to
+// it if the flags mark it shared. A datatype that cannot be parsed is left
+// nil, as for version 1 attributes.
+func (attr *Attribute) parseDatatype(data []byte, flags uint8, r *binpkg.Reader) error {
+	if flags&attrFlagSharedDatatype != 0 {
+		ref, _, err := parseSharedRef(TypeDatatype, data, r)
+		if err != nil {
+			return fmt.Errorf("attribute %q: %w", attr.Name, err)
+		}
+		attr.DatatypeRef = ref
+		return nil
+	}
+	if dt, err := parseDatatype(data, r); err == nil {
+		attr.Datatype = dt
+	}
+	return nil
+}
+
+// parseDataspace parses the dataspace of an attribute like parseDatatype.
+func (attr *Attribute) parseDataspace(data []byte, flags uint8, r *binpkg.Reader) error {
+	if flags&attrFlagSharedDataspace != 0 {
+		ref, _, err := parseSharedRef(TypeDataspace, data, r)
+		if err != nil {
+			return fmt.Errorf("attribute %q: %w", attr.Name, err)
+		}
+		attr.DataspaceRef = ref
+		return nil
+	}
+	if ds, _, err := parseDataspace(data, r); err == nil {
+		attr.Dataspace = ds
+	}
+	return nil
+}
+
+// parseValue copies the attribute value starting at offset. Its size is
+// fixed by the dataspace and datatype; if either could not be parsed, the
+// rest of the message is taken as the value.
+func (attr *Attribute) parseValue(data []byte, offset int) (*Attribute, int, error) {
+	// Version 1 padding after the dataspace may be cut off when there is
+	// no value
+	offset = min(offset, len(data))
+
+	end := len(data)
+	if attr.Datatype != nil && attr.Dataspace != nil {
+		size, ok := attr.Dataspace.DataSize(uint64(attr.Datatype.Size))
+		if !ok || size > uint64(len(data)-offset) {
+			return nil, 0, fmt.Errorf("attribute data truncated: need %d bytes", size)
+		}
+		end = offset + int(size)
 	}
 
-	return attr, nil
+	if offset < end {
+		attr.Data = make([]byte, end-offset)
+		copy(attr.Data, data[offset:end])
+	}
+	return attr, end, nil
 }
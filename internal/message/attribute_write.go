@@ -5,25 +5,28 @@ import (
 )
 
 // NewAttribute creates a new attribute message.
-// Uses version 3 format for modern compatibility.
+// Uses version 3 format for modern compatibility, which records whether the
+// name is UTF-8.
 func NewAttribute(name string, datatype *Datatype, dataspace *Dataspace, data []byte) *Attribute {
 	return &Attribute{
-		Version:   3,
-		Name:      name,
-		Datatype:  datatype,
-		Dataspace: dataspace,
-		Data:      data,
+		Version:     3,
+		Name:        name,
+		NameCharset: CharacterSet(nameCharset(name)),
+		Datatype:    datatype,
+		Dataspace:   dataspace,
+		Data:        data,
 	}
 }
 
 // NewScalarAttribute creates a new scalar attribute (no dimensions).
 func NewScalarAttribute(name string, datatype *Datatype, data []byte) *Attribute {
 	return &Attribute{
-		Version:   3,
-		Name:      name,
-		Datatype:  datatype,
-		Dataspace: NewScalarDataspace(),
-		Data:      data,
+		Version:     3,
+		Name:        name,
+		NameCharset: CharacterSet(nameCharset(name)),
+		Datatype:    datatype,
+		Dataspace:   NewScalarDataspace(),
+		Data:        data,
 	}
 }
 
@@ -59,8 +62,8 @@ func (m *Attribute) Serialize(w *binary.Writer) error {
 		return err
 	}
 
-	// Encoding (0 = ASCII)
-	if err := w.WriteUint8(0); err != nil {
+	// Name character set
+	if err := w.WriteUint8(uint8(m.NameCharset)); err != nil {
 		return err
 	}
 
@@ -0,0 +1,72 @@
+package message
+
+import (
+	"fmt"
+
+	binpkg "github.com/robert-malhotra/go-hdf5/internal/binary"
+)
+
+// AttributeInfo represents an attribute info message (type 0x0015).
+// Objects whose attributes are in dense storage keep them in a fractal heap
+// indexed by name, and optionally by creation order, with v2 B-trees.
+type AttributeInfo struct {
+	Version                uint8
+	Flags                  uint8
+	MaxCreationIndex       uint16 // Present if flag bit 0 set
+	FractalHeapAddr        uint64 // Undefined if attributes are not dense
+	NameIndexBTreeAddr     uint64
+	CreationOrderBTreeAddr uint64 // Present if flag bit 1 set, else undefined
+}
+
+func (m *AttributeInfo) Type() Type { return TypeAttributeInfo }
+
+// TracksCreationOrder reports whether attribute creation order is tracked.
+func (m *AttributeInfo) TracksCreationOrder() bool { return m.Flags&0x01 != 0 }
+
+// IndexesCreationOrder reports whether attributes are indexed by creation
+// order, in which case CreationOrderBTreeAddr is set.
+func (m *AttributeInfo) IndexesCreationOrder() bool { return m.Flags&0x02 != 0 }
+
+func parseAttributeInfo(data []byte, r *binpkg.Reader) (*AttributeInfo, int, error) {
+	if len(data) < 2 {
+		return nil, 0, fmt.Errorf("attribute info message too short")
+	}
+	m := &AttributeInfo{
+		Version:                data[0],
+		Flags:                  data[1],
+		CreationOrderBTreeAddr: UndefinedAddress,
+	}
+	if m.Version != 0 {
+		return nil, 0, fmt.Errorf("unsupported attribute info version: %d", m.Version)
+	}
+	if m.Flags&^0x03 != 0 {
+		return nil, 0, fmt.Errorf("attribute info message has unknown flags %#02x", m.Flags)
+	}
+
+	offsetSize := r.OffsetSize()
+	offset := 2
+	size := offset + 2*offsetSize
+	if m.TracksCreationOrder() {
+		size += 2
+	}
+	if m.IndexesCreationOrder() {
+		size += offsetSize
+	}
+	if len(data) < size {
+		return nil, 0, fmt.Errorf("attribute info message truncated")
+	}
+
+	if m.TracksCreationOrder() {
+		m.MaxCreationIndex = uint16(decodeUint(data[offset:], 2, r.ByteOrder()))
+		offset += 2
+	}
+	m.FractalHeapAddr = decodeUint(data[offset:], offsetSize, r.ByteOrder())
+	offset += offsetSize
+	m.NameIndexBTreeAddr = decodeUint(data[offset:], offsetSize, r.ByteOrder())
+	offset += offsetSize
+	if m.IndexesCreationOrder() {
+		m.CreationOrderBTreeAddr = decodeUint(data[offset:], offsetSize, r.ByteOrder())
+		offset += offsetSize
+	}
+	return m, offset, nil
+}
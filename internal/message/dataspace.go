@@ -3,6 +3,8 @@ package message
 import (
 	"encoding/binary"
 	"fmt"
+	"math/bits"
+	"slices"
 
 	binpkg "github.com/robert-malhotra/go-hdf5/internal/binary"
 )
@@ -25,6 +27,10 @@ type Dataspace struct {
 	MaxDims    []uint64 // nil if not present (means same as Dimensions)
 }
 
+// Unlimited is the maximum size of a dimension that can grow without
+// bound. It is decoded from all ones at any length size.
+const Unlimited = ^uint64(0)
+
 func (m *Dataspace) Type() Type { return TypeDataspace }
 
 // NumElements returns the total number of elements in the dataspace.
@@ -48,6 +54,26 @@ func (m *Dataspace) NumElements() uint64 {
 	}
 }
 
+// DataSize returns the size in bytes of the dataspace's elements, each of
+// elementSize bytes, and false if it overflows 64 bits.
+func (m *Dataspace) DataSize(elementSize uint64) (uint64, bool) {
+	if m.SpaceType != DataspaceSimple {
+		return m.NumElements() * elementSize, true
+	}
+	if len(m.Dimensions) == 0 || slices.Contains(m.Dimensions, 0) {
+		return 0, true
+	}
+	size := elementSize
+	for _, d := range m.Dimensions {
+		hi, lo := bits.Mul64(size, d)
+		if hi != 0 {
+			return 0, false
+		}
+		size = lo
+	}
+	return size, true
+}
+
 // IsScalar returns true if this is a scalar dataspace.
 func (m *Dataspace) IsScalar() bool {
 	return m.SpaceType == DataspaceScalar
@@ -58,9 +84,9 @@ func (m *Dataspace) IsNull() bool {
 	return m.SpaceType == DataspaceNull
 }
 
-func parseDataspace(data []byte, r *binpkg.Reader) (*Dataspace, error) {
+func parseDataspace(data []byte, r *binpkg.Reader) (*Dataspace, int, error) {
 	if len(data) < 4 {
-		return nil, fmt.Errorf("dataspace message too short")
+		return nil, 0, fmt.Errorf("dataspace message too short")
 	}
 
 	ds := &Dataspace{
@@ -70,6 +96,7 @@ func parseDataspace(data []byte, r *binpkg.Reader) (*Dataspace, error) {
 
 	flags := data[2]
 	hasMaxDims := flags&0x01 != 0
+	hasPermutation := flags&0x02 != 0
 
 	// Version 2 has explicit type field
 	if ds.Version >= 2 {
@@ -83,17 +110,20 @@ func parseDataspace(data []byte, r *binpkg.Reader) (*Dataspace, error) {
 		}
 	}
 
-	// No dimensions for scalar or null
-	if ds.SpaceType != DataspaceSimple || ds.Rank == 0 {
-		return ds, nil
-	}
-
 	// Calculate offset to dimensions
 	offset := 4
 	if ds.Version == 1 {
+		if len(data) < 8 {
+			return nil, 0, fmt.Errorf("dataspace message too short")
+		}
 		offset = 8 // Version 1 has 4 reserved bytes
 	}
 
+	// No dimensions for scalar or null
+	if ds.SpaceType != DataspaceSimple || ds.Rank == 0 {
+		return ds, offset, nil
+	}
+
 	// Use the reader's length size for dimension values
 	lengthSize := r.LengthSize()
 	if lengthSize == 0 {
@@ -104,25 +134,47 @@ func parseDataspace(data []byte, r *binpkg.Reader) (*Dataspace, error) {
 	ds.Dimensions = make([]uint64, ds.Rank)
 	for i := 0; i < ds.Rank; i++ {
 		if offset+lengthSize > len(data) {
-			return nil, fmt.Errorf("dataspace message truncated reading dimensions")
+			return nil, 0, fmt.Errorf("dataspace message truncated reading dimensions")
 		}
 		ds.Dimensions[i] = decodeUint(data[offset:], lengthSize, r.ByteOrder())
 		offset += lengthSize
 	}
 
+	// The number of elements must fit in 64 bits, so that NumElements
+	// cannot wrap
+	n := uint64(1)
+	for _, d := range ds.Dimensions {
+		hi, lo := bits.Mul64(n, d)
+		if hi != 0 {
+			return nil, 0, fmt.Errorf("dataspace dimensions %v have more than 2^64 elements", ds.Dimensions)
+		}
+		n = lo
+	}
+
 	// Parse max dimensions if present
 	if hasMaxDims {
 		ds.MaxDims = make([]uint64, ds.Rank)
 		for i := 0; i < ds.Rank; i++ {
 			if offset+lengthSize > len(data) {
-				return nil, fmt.Errorf("dataspace message truncated reading max dimensions")
+				return nil, 0, fmt.Errorf("dataspace message truncated reading max dimensions")
 			}
 			ds.MaxDims[i] = decodeUint(data[offset:], lengthSize, r.ByteOrder())
+			if lengthSize < 8 && ds.MaxDims[i] == 1<<(8*lengthSize)-1 {
+				ds.MaxDims[i] = Unlimited
+			}
 			offset += lengthSize
 		}
 	}
 
-	return ds, nil
+	// Version 1 may store permutation indices, which are never used
+	if ds.Version == 1 && hasPermutation {
+		if offset+ds.Rank*lengthSize > len(data) {
+			return nil, 0, fmt.Errorf("dataspace message truncated reading permutation indices")
+		}
+		offset += ds.Rank * lengthSize
+	}
+
+	return ds, offset, nil
 }
 
 // decodeUint decodes a variable-width unsigned integer.
@@ -1,6 +1,7 @@
 package message
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 
@@ -60,7 +61,7 @@ type Datatype struct {
 	// Class-specific properties
 	ByteOrder ByteOrder
 
-	// Fixed-point specific
+	// Fixed-point and bitfield specific
 	BitOffset    uint16
 	BitPrecision uint16
 	Signed       bool
@@ -71,12 +72,20 @@ type Datatype struct {
 	StringPadding StringPadding
 	CharSet       CharacterSet
 
+	// Opaque specific: ASCII tag describing the contents
+	OpaqueTag string
+
 	// Compound specific
 	Members []CompoundMember
 
 	// Array specific
 	ArrayDims []uint32
-	BaseType  *Datatype
+	BaseType  *Datatype // Also the integer type of an enum
+
+	// Enum specific: member names and their values, each encoded in the
+	// base type
+	EnumNames  []string
+	EnumValues [][]byte
 
 	// VarLen specific
 	VarLenType    *Datatype
@@ -120,6 +129,12 @@ func (m *Datatype) IsArray() bool {
 	return m.Class == ClassArray
 }
 
+// IsObjectReference returns true if this is an object reference type, as
+// opposed to a dataset region reference.
+func (m *Datatype) IsObjectReference() bool {
+	return m.Class == ClassReference && m.ClassBits&0x0F == 0
+}
+
 // IsVarLen returns true if this is a variable-length type.
 func (m *Datatype) IsVarLen() bool {
 	return m.Class == ClassVarLen
@@ -138,23 +153,21 @@ func parseDatatypeWithSize(data []byte, r *binpkg.Reader) (*Datatype, int, error
 
 	classAndVersion := data[0]
 	class := DatatypeClass(classAndVersion & 0x0F)
-	// version := classAndVersion >> 4
+	version := int(classAndVersion >> 4)
 
 	classBits := uint32(data[1]) | uint32(data[2])<<8 | uint32(data[3])<<16
 	size := binary.LittleEndian.Uint32(data[4:8])
 
-	// Calculate properties size based on class
-	propsSize := calcPropertiesSize(class, data[8:], classBits, size)
-
 	dt := &Datatype{
-		Class:      class,
-		ClassBits:  classBits,
-		Size:       size,
-		Properties: data[8 : 8+propsSize],
+		Class:     class,
+		ClassBits: classBits,
+		Size:      size,
 	}
 
-	// Parse class-specific properties
+	// Parse class-specific properties, tracking exactly how many bytes
+	// they occupy so that nested types and padding are never misread
 	props := data[8:]
+	var propsSize int
 
 	switch class {
 	case ClassFixedPoint:
@@ -162,6 +175,7 @@ func parseDatatypeWithSize(data []byte, r *binpkg.Reader) (*Datatype, int, error
 		if classBits&0x08 != 0 {
 			dt.Signed = true
 		}
+		propsSize = 4 // bit offset (2) + bit precision (2)
 		if len(props) >= 4 {
 			dt.BitOffset = binary.LittleEndian.Uint16(props[0:2])
 			dt.BitPrecision = binary.LittleEndian.Uint16(props[2:4])
@@ -171,14 +185,38 @@ func parseDatatypeWithSize(data []byte, r *binpkg.Reader) (*Datatype, int, error
 		dt.ByteOrder = ByteOrder(classBits & 0x01)
 		// Float properties contain bit positions for sign, exponent, mantissa
 		// We store them in Properties for later use
+		propsSize = 12
+
+	case ClassTime:
+		dt.ByteOrder = ByteOrder(classBits & 0x01)
+		propsSize = 2 // bit precision
+		if len(props) >= 2 {
+			dt.BitPrecision = binary.LittleEndian.Uint16(props[0:2])
+		}
 
 	case ClassString:
 		dt.StringPadding = StringPadding(classBits & 0x0F)
 		dt.CharSet = CharacterSet((classBits >> 4) & 0x0F)
 
+	case ClassBitfield:
+		dt.ByteOrder = ByteOrder(classBits & 0x01)
+		propsSize = 4 // bit offset (2) + bit precision (2)
+		if len(props) >= 4 {
+			dt.BitOffset = binary.LittleEndian.Uint16(props[0:2])
+			dt.BitPrecision = binary.LittleEndian.Uint16(props[2:4])
+		}
+
+	case ClassOpaque:
+		// The tag's length, including its NUL padding, is in the class bits
+		propsSize = int(classBits & 0xFF)
+		tag := props[:min(propsSize, len(props))]
+		if i := bytes.IndexByte(tag, 0); i >= 0 {
+			tag = tag[:i]
+		}
+		dt.OpaqueTag = string(tag)
+
 	case ClassCompound:
 		numMembers := int(classBits & 0xFFFF)
-		version := int(classAndVersion >> 4)
 		dt.Members = make([]CompoundMember, 0, numMembers)
 		offset := 0
 		for i := 0; i < numMembers && offset < len(props); i++ {
@@ -189,21 +227,40 @@ func parseDatatypeWithSize(data []byte, r *binpkg.Reader) (*Datatype, int, error
 			dt.Members = append(dt.Members, member)
 			offset += consumed
 		}
+		// Nested compounds must report only their own bytes as consumed
+		propsSize = offset
+
+	case ClassEnum:
+		n, err := parseEnumProperties(dt, props, r, version, classBits)
+		if err != nil {
+			return nil, 0, err
+		}
+		propsSize = n
 
 	case ClassArray:
 		if len(props) >= 1 {
+			// Version 2 has 3 reserved bytes after the dimensionality and
+			// permutation indices after the sizes; version 3 has neither.
 			ndims := int(props[0])
 			dt.ArrayDims = make([]uint32, ndims)
-			offset := 4 // Skip version + reserved bytes
+			offset := 1
+			if version < 3 {
+				offset = 4
+			}
 			for i := 0; i < ndims && offset+4 <= len(props); i++ {
 				dt.ArrayDims[i] = binary.LittleEndian.Uint32(props[offset:])
 				offset += 4
 			}
+			if version < 3 {
+				offset += 4 * ndims
+			}
+			propsSize = min(offset, len(props))
 			// Parse base type
 			if offset < len(props) {
-				baseType, err := parseDatatype(props[offset:], r)
+				baseType, consumed, err := parseDatatypeWithSize(props[offset:], r)
 				if err == nil {
 					dt.BaseType = baseType
+					propsSize = offset + consumed
 				}
 			}
 		}
@@ -212,68 +269,63 @@ func parseDatatypeWithSize(data []byte, r *binpkg.Reader) (*Datatype, int, error
 		// Type: 0 = sequence, 1 = string
 		dt.IsVarLenString = (classBits & 0x0F) == 1
 		if len(props) > 0 {
-			varLenType, err := parseDatatype(props, r)
+			varLenType, consumed, err := parseDatatypeWithSize(props, r)
 			if err == nil {
 				dt.VarLenType = varLenType
+				propsSize = consumed
 			}
 		}
 	}
 
+	if propsSize > len(props) {
+		return nil, 0, fmt.Errorf("datatype properties truncated: need %d bytes, have %d", propsSize, len(props))
+	}
+	dt.Properties = props[:propsSize]
 	return dt, 8 + propsSize, nil
 }
 
-// calcPropertiesSize calculates the size of properties for a given datatype class.
-func calcPropertiesSize(class DatatypeClass, props []byte, classBits uint32, size uint32) int {
-	switch class {
-	case ClassFixedPoint:
-		return 4 // bit offset (2) + bit precision (2)
-	case ClassFloatPoint:
-		return 12 // bit offset (2) + bit precision (2) + exp location (1) + exp size (1) + mant location (1) + mant size (2) + exp bias (4)
-	case ClassString:
-		return 0 // no properties
-	case ClassBitfield:
-		return 4 // bit offset (2) + bit precision (2)
-	case ClassOpaque:
-		// Opaque has a tag (null-terminated string)
-		end := 0
-		for end < len(props) && props[end] != 0 {
-			end++
+// parseEnumProperties reads the base type, member names and member values
+// of an enum into dt and returns the size of the properties. Names are
+// padded to a multiple of 8 bytes before version 3; all names come before
+// all values.
+func parseEnumProperties(dt *Datatype, props []byte, r *binpkg.Reader, version int, classBits uint32) (int, error) {
+	base, offset, err := parseDatatypeWithSize(props, r)
+	if err != nil {
+		return 0, fmt.Errorf("enum base type: %w", err)
+	}
+
+	numMembers := int(classBits & 0xFFFF)
+	names := make([]string, numMembers)
+	for i := 0; i < numMembers; i++ {
+		nameEnd := offset
+		for nameEnd < len(props) && props[nameEnd] != 0 {
+			nameEnd++
 		}
-		return end + 1 // include null
-	case ClassCompound:
-		// Compound properties include all member definitions
-		// The size depends on member count and their types
-		// For now, use all remaining data (will be trimmed by the calling context)
-		return len(props)
-	case ClassReference:
-		return 0 // no properties
-	case ClassEnum:
-		// Enum has base type + name-value pairs
-		// For simplicity, use remaining data
-		return len(props)
-	case ClassVarLen:
-		// VarLen has a base type
-		if len(props) >= 8 {
-			baseClass := DatatypeClass(props[0] & 0x0F)
-			baseProps := calcPropertiesSize(baseClass, props[8:], 0, 0)
-			return 8 + baseProps
+		if nameEnd >= len(props) {
+			return 0, fmt.Errorf("enum member name not terminated")
 		}
-		return len(props)
-	case ClassArray:
-		// Array has dimensions + base type
-		if len(props) >= 4 {
-			ndims := int(props[0])
-			offset := 4 + ndims*4 // version(1) + reserved(3) + dims(4*ndims)
-			if offset < len(props) {
-				baseClass := DatatypeClass(props[offset] & 0x0F)
-				baseProps := calcPropertiesSize(baseClass, props[offset+8:], 0, 0)
-				return offset + 8 + baseProps
-			}
+		names[i] = string(props[offset:nameEnd])
+		nameLen := nameEnd + 1 - offset
+		if version < 3 && nameLen%8 != 0 {
+			nameLen += 8 - nameLen%8
 		}
-		return len(props)
-	default:
-		return len(props)
+		offset += nameLen
 	}
+
+	valueSize := int(base.Size)
+	end := offset + numMembers*valueSize
+	if end > len(props) {
+		return 0, fmt.Errorf("enum values truncated: need %d bytes, have %d", end, len(props))
+	}
+	values := make([][]byte, numMembers)
+	for i := range values {
+		values[i] = props[offset+i*valueSize : offset+(i+1)*valueSize]
+	}
+
+	dt.BaseType = base
+	dt.EnumNames = names
+	dt.EnumValues = values
+	return end, nil
 }
 
 func parseCompoundMember(data []byte, r *binpkg.Reader, version int, compoundSize uint32) (CompoundMember, int, error) {
@@ -332,6 +384,23 @@ func parseCompoundMember(data []byte, r *binpkg.Reader, version int, compoundSiz
 	}
 	offset += offsetSize
 
+	// Version 1 describes array members inline: dimensionality (1),
+	// reserved (3), permutation (4), reserved (4), then four dimension sizes
+	var arrayDims []uint32
+	if version == 1 {
+		if offset+28 > len(data) {
+			return member, 0, fmt.Errorf("compound member dimensions truncated")
+		}
+		ndims := int(data[offset])
+		if ndims > 4 {
+			return member, 0, fmt.Errorf("compound member has %d dimensions, at most 4 allowed", ndims)
+		}
+		for i := 0; i < ndims; i++ {
+			arrayDims = append(arrayDims, binary.LittleEndian.Uint32(data[offset+12+4*i:]))
+		}
+		offset += 28
+	}
+
 	// Parse member datatype
 	if offset < len(data) {
 		memberType, typeSize, err := parseDatatypeWithSize(data[offset:], r)
@@ -340,6 +409,19 @@ func parseCompoundMember(data []byte, r *binpkg.Reader, version int, compoundSiz
 		}
 		member.Type = memberType
 		offset += typeSize
+
+		if len(arrayDims) > 0 {
+			n := uint32(1)
+			for _, d := range arrayDims {
+				n *= d
+			}
+			member.Type = &Datatype{
+				Class:     ClassArray,
+				Size:      n * memberType.Size,
+				ArrayDims: arrayDims,
+				BaseType:  memberType,
+			}
+		}
 	}
 
 	return member, offset, nil
@@ -12,9 +12,9 @@ func (m *Datatype) Serialize(w *binary.Writer) error {
 	// Bytes 4-7: Size (32 bits)
 	// Bytes 8+: Class-specific properties
 
-	// Use version 1 for most types, version 3 for compound
+	// Use version 1 for most types, version 3 for compound, array and enum
 	version := uint8(1)
-	if m.Class == ClassCompound {
+	if m.Class == ClassCompound || m.Class == ClassArray || m.Class == ClassEnum {
 		version = 3
 	}
 
@@ -41,7 +41,7 @@ func (m *Datatype) Serialize(w *binary.Writer) error {
 
 	// Write class-specific properties
 	switch m.Class {
-	case ClassFixedPoint:
+	case ClassFixedPoint, ClassBitfield:
 		// Bit offset (2 bytes)
 		if err := w.WriteUint16(m.BitOffset); err != nil {
 			return err
@@ -67,6 +67,14 @@ func (m *Datatype) Serialize(w *binary.Writer) error {
 	case ClassString:
 		// No properties for strings
 
+	case ClassOpaque:
+		// The tag, NUL-padded to the length in the class bits
+		tag := make([]byte, m.ClassBits&0xFF)
+		copy(tag, m.OpaqueTag)
+		if err := w.WriteBytes(tag); err != nil {
+			return err
+		}
+
 	case ClassCompound:
 		// Write member definitions
 		for _, member := range m.Members {
@@ -76,18 +84,10 @@ func (m *Datatype) Serialize(w *binary.Writer) error {
 		}
 
 	case ClassArray:
-		// Version
-		if err := w.WriteUint8(2); err != nil {
-			return err
-		}
-		// Number of dimensions
+		// Number of dimensions (version 3: no reserved bytes or permutation)
 		if err := w.WriteUint8(uint8(len(m.ArrayDims))); err != nil {
 			return err
 		}
-		// Reserved (2 bytes)
-		if err := w.WriteUint16(0); err != nil {
-			return err
-		}
 		// Dimensions
 		for _, dim := range m.ArrayDims {
 			if err := w.WriteUint32(dim); err != nil {
@@ -108,6 +108,24 @@ func (m *Datatype) Serialize(w *binary.Writer) error {
 				return err
 			}
 		}
+
+	case ClassEnum:
+		// Base type, then the names (version 3: unpadded), then the values
+		if m.BaseType != nil {
+			if err := m.BaseType.Serialize(w); err != nil {
+				return err
+			}
+		}
+		for _, name := range m.EnumNames {
+			if err := w.WriteBytes(append([]byte(name), 0)); err != nil {
+				return err
+			}
+		}
+		for _, value := range m.EnumValues {
+			if err := w.WriteBytes(value); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -119,18 +137,20 @@ func (m *Datatype) SerializedSize(w *binary.Writer) int {
 	size := 8
 
 	switch m.Class {
-	case ClassFixedPoint:
+	case ClassFixedPoint, ClassBitfield:
 		size += 4 // bit offset + bit precision
 	case ClassFloatPoint:
 		size += 12 // float properties
 	case ClassString:
 		// no properties
+	case ClassOpaque:
+		size += int(m.ClassBits & 0xFF) // padded tag
 	case ClassCompound:
 		for _, member := range m.Members {
 			size += compoundMemberSize(&member, m.Size)
 		}
 	case ClassArray:
-		size += 4 + len(m.ArrayDims)*4 // version + ndims + reserved + dims
+		size += 1 + len(m.ArrayDims)*4 // ndims + dims
 		if m.BaseType != nil {
 			size += m.BaseType.SerializedSize(w)
 		}
@@ -138,6 +158,16 @@ func (m *Datatype) SerializedSize(w *binary.Writer) int {
 		if m.VarLenType != nil {
 			size += m.VarLenType.SerializedSize(w)
 		}
+	case ClassEnum:
+		if m.BaseType != nil {
+			size += m.BaseType.SerializedSize(w)
+		}
+		for _, name := range m.EnumNames {
+			size += len(name) + 1
+		}
+		for _, value := range m.EnumValues {
+			size += len(value)
+		}
 	}
 
 	return size
@@ -278,6 +308,29 @@ func NewFixedPointDatatype(size uint32, signed bool, byteOrder ByteOrder) *Datat
 	}
 }
 
+// NewBitfieldDatatype creates a new bitfield datatype using all the bits
+// of its size.
+func NewBitfieldDatatype(size uint32, byteOrder ByteOrder) *Datatype {
+	return &Datatype{
+		Class:        ClassBitfield,
+		ClassBits:    uint32(byteOrder),
+		Size:         size,
+		ByteOrder:    byteOrder,
+		BitPrecision: uint16(size * 8),
+	}
+}
+
+// NewOpaqueDatatype creates a new opaque datatype of the given size,
+// described by tag. The tag is stored NUL-padded to a multiple of 8 bytes.
+func NewOpaqueDatatype(size uint32, tag string) *Datatype {
+	return &Datatype{
+		Class:     ClassOpaque,
+		ClassBits: uint32((len(tag) + 8) &^ 7),
+		Size:      size,
+		OpaqueTag: tag,
+	}
+}
+
 // NewFloatDatatype creates a new floating-point datatype.
 func NewFloatDatatype(size uint32, byteOrder ByteOrder) *Datatype {
 	// ClassBits for floating-point (matches h5py encoding):
@@ -344,8 +397,9 @@ func NewStringDatatype(size uint32, padding StringPadding, charset CharacterSet)
 
 // NewVarLenStringDatatype creates a new variable-length string datatype.
 func NewVarLenStringDatatype(charset CharacterSet) *Datatype {
-	// VarLen string: type=1 (string), padding=nullterm, charset
-	classBits := uint32(1) | (uint32(charset) << 4)
+	// VarLen string: type=1 (string) in bits 0-3, padding=nullterm in
+	// bits 4-7, charset in bits 8-11
+	classBits := uint32(1) | (uint32(charset) << 8)
 
 	// Base type for var-len string is a 1-byte fixed string
 	baseType := &Datatype{
@@ -365,6 +419,25 @@ func NewVarLenStringDatatype(charset CharacterSet) *Datatype {
 	}
 }
 
+// NewVarLenSequenceDatatype creates a new variable-length sequence datatype
+// whose elements have the given base type.
+func NewVarLenSequenceDatatype(baseType *Datatype) *Datatype {
+	return &Datatype{
+		Class:      ClassVarLen,
+		Size:       16, // hvl_t structure size (typically 16 bytes)
+		VarLenType: baseType,
+	}
+}
+
+// NewObjectReferenceDatatype creates a new object reference datatype. Each
+// reference is an object header address of the file's offset size.
+func NewObjectReferenceDatatype(offsetSize uint32) *Datatype {
+	return &Datatype{
+		Class: ClassReference,
+		Size:  offsetSize,
+	}
+}
+
 // NewCompoundDatatype creates a new compound datatype.
 func NewCompoundDatatype(size uint32, members []CompoundMember) *Datatype {
 	return &Datatype{
@@ -391,3 +464,29 @@ func NewArrayDatatype(dims []uint32, baseType *Datatype) *Datatype {
 		BaseType:  baseType,
 	}
 }
+
+// NewEnumDatatype creates a new enumeration datatype over an integer base
+// type, with one member per name taking the value at the same index.
+func NewEnumDatatype(baseType *Datatype, names []string, values []int64) *Datatype {
+	encoded := make([][]byte, len(values))
+	for i, v := range values {
+		b := make([]byte, baseType.Size)
+		for j := range b {
+			shift := 8 * j
+			if baseType.ByteOrder == OrderBE {
+				shift = 8 * (len(b) - 1 - j)
+			}
+			b[j] = byte(uint64(v) >> shift)
+		}
+		encoded[i] = b
+	}
+
+	return &Datatype{
+		Class:      ClassEnum,
+		ClassBits:  uint32(len(names)),
+		Size:       baseType.Size,
+		BaseType:   baseType,
+		EnumNames:  names,
+		EnumValues: encoded,
+	}
+}
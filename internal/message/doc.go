@@ -10,13 +10,16 @@
 // parsing for the following:
 //
 //   - Dataspace (0x0001): Describes the dimensions of a dataset. See [Dataspace].
+//   - Link Info (0x0002): Locates links in dense storage. See [LinkInfo].
 //   - Datatype (0x0003): Describes the data type of elements. See [Datatype].
-//   - Fill Value (0x0005): Specifies the fill value for unwritten data.
+//   - Fill Value (0x0004, 0x0005): Specifies the fill value for unwritten data. See [FillValue] and [FillValueOld].
 //   - Link (0x0006): Describes a link to another object. See [Link].
+//   - External Data Files (0x0007): Lists raw data segments stored outside the file. See [ExternalFiles].
 //   - Data Layout (0x0008): Describes how dataset data is stored. See [DataLayout].
 //   - Filter Pipeline (0x000B): Lists filters applied to chunks. See [FilterPipeline].
 //   - Attribute (0x000C): Stores an attribute name, datatype, and value. See [Attribute].
 //   - Symbol Table (0x0011): Points to v1 group B-tree and heap. See [SymbolTable].
+//   - Attribute Info (0x0015): Locates attributes in dense storage. See [AttributeInfo].
 //   - Continuation (0x0010): Points to additional header data. See [Continuation].
 //
 // Unrecognized message types are wrapped in [Unknown] for forward compatibility.
@@ -53,6 +56,9 @@
 // The returned [Message] interface can be type-asserted to the specific
 // message type based on its Type() method.
 //
+// Parsers read exactly the fields the message's version defines, so padding
+// after them is ignored. [ParseWithSize] also reports where those fields end.
+//
 // # Writing
 //
 // Message serialization functions are available for writing HDF5 files:
@@ -0,0 +1,36 @@
+package message
+
+import (
+	"fmt"
+
+	binpkg "github.com/robert-malhotra/go-hdf5/internal/binary"
+)
+
+// DriverInfo represents a driver info message (type 0x0014), kept in the
+// superblock extension of files written with a file driver that records
+// its settings, such as the multi and family drivers.
+type DriverInfo struct {
+	Version uint8
+	Driver  string // Eight-character driver identification, such as "NCSAmult"
+	Info    []byte // Driver-specific information
+}
+
+func (m *DriverInfo) Type() Type { return TypeDriverInfo }
+
+func parseDriverInfo(data []byte, r *binpkg.Reader) (*DriverInfo, int, error) {
+	if len(data) < 11 {
+		return nil, 0, fmt.Errorf("driver info message too short")
+	}
+	if data[0] != 0 {
+		return nil, 0, fmt.Errorf("unsupported driver info message version: %d", data[0])
+	}
+	n := int(decodeUint(data[9:], 2, r.ByteOrder()))
+	if len(data) < 11+n {
+		return nil, 0, fmt.Errorf("driver info message of %d bytes truncated", n)
+	}
+	return &DriverInfo{
+		Version: data[0],
+		Driver:  string(data[1:9]),
+		Info:    append([]byte(nil), data[11:11+n]...),
+	}, 11 + n, nil
+}
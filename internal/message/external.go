@@ -0,0 +1,76 @@
+package message
+
+import (
+	"fmt"
+	"math"
+
+	binpkg "github.com/robert-malhotra/go-hdf5/internal/binary"
+)
+
+// ExternalFileSlot describes one segment of a dataset's raw data stored in
+// an external file.
+type ExternalFileSlot struct {
+	NameOffset uint64 // Offset of the file name in the local heap
+	Offset     uint64 // Byte offset of the segment within the external file
+	Size       uint64 // Segment size in bytes, or ExternalSizeUnlimited
+}
+
+// ExternalSizeUnlimited is the size of a final external segment that extends
+// to the end of its file.
+const ExternalSizeUnlimited = math.MaxUint64
+
+// ExternalFiles represents an external data files message (type 0x0007).
+// The dataset's raw data is stored outside the HDF5 file, as the
+// concatenation of the listed segments.
+type ExternalFiles struct {
+	Version     uint8
+	HeapAddress uint64 // Local heap holding the file names
+	Slots       []ExternalFileSlot
+}
+
+func (m *ExternalFiles) Type() Type { return TypeExternalDataFiles }
+
+func parseExternalFiles(data []byte, r *binpkg.Reader) (*ExternalFiles, int, error) {
+	offsetSize := r.OffsetSize()
+	lengthSize := r.LengthSize()
+	order := r.ByteOrder()
+
+	if len(data) < 8+offsetSize {
+		return nil, 0, fmt.Errorf("external files message too short")
+	}
+
+	ef := &ExternalFiles{Version: data[0]}
+	if ef.Version != 1 {
+		return nil, 0, fmt.Errorf("unsupported external files message version: %d", ef.Version)
+	}
+	// Bytes 1-3 are reserved, 4-5 hold the allocated slot count
+	allocated := int(order.Uint16(data[4:6]))
+	used := int(order.Uint16(data[6:8]))
+	ef.HeapAddress = decodeUint(data[8:8+offsetSize], offsetSize, order)
+
+	pos := 8 + offsetSize
+	slotSize := 3 * lengthSize
+	if len(data) < pos+used*slotSize {
+		return nil, 0, fmt.Errorf("external files message too short for %d slots", used)
+	}
+
+	ef.Slots = make([]ExternalFileSlot, used)
+	for i := range ef.Slots {
+		ef.Slots[i] = ExternalFileSlot{
+			NameOffset: decodeUint(data[pos:pos+lengthSize], lengthSize, order),
+			Offset:     decodeUint(data[pos+lengthSize:pos+2*lengthSize], lengthSize, order),
+			Size:       decodeUint(data[pos+2*lengthSize:pos+3*lengthSize], lengthSize, order),
+		}
+		if lengthSize < 8 && ef.Slots[i].Size == 1<<(8*lengthSize)-1 {
+			ef.Slots[i].Size = ExternalSizeUnlimited
+		}
+		pos += slotSize
+	}
+
+	// Allocated but unused slots belong to the message too
+	if allocated > used {
+		pos = min(pos+(allocated-used)*slotSize, len(data))
+	}
+
+	return ef, pos, nil
+}
@@ -0,0 +1,120 @@
+package message
+
+import (
+	"fmt"
+
+	binpkg "github.com/robert-malhotra/go-hdf5/internal/binary"
+)
+
+// FileSpaceStrategy is how the library that wrote a file allocates space
+// in it, as recorded by version 1 of the file space info message.
+type FileSpaceStrategy uint8
+
+const (
+	// FileSpaceFSMAggr uses free-space managers, with aggregators for
+	// small metadata and raw data allocations; the library default
+	FileSpaceFSMAggr FileSpaceStrategy = 0
+	// FileSpacePage uses free-space managers with paged aggregation
+	FileSpacePage FileSpaceStrategy = 1
+	// FileSpaceAggr uses aggregators only, without tracking free space
+	FileSpaceAggr FileSpaceStrategy = 2
+	// FileSpaceNone allocates from the end of the file only
+	FileSpaceNone FileSpaceStrategy = 3
+)
+
+// fileSpaceManagers is the number of free-space managers whose addresses
+// a file space info message lists when free space persists: one per kind
+// of allocation in version 0, and one for small and one for large
+// allocations of each kind in version 1.
+var fileSpaceManagers = [...]int{0: 6, 1: 12}
+
+// FileSpaceInfo represents a file space info message (type 0x0017), kept
+// in the superblock extension of files created with a non-default file
+// space strategy, such as with persistent free-space tracking.
+type FileSpaceInfo struct {
+	Version  uint8
+	Strategy FileSpaceStrategy // Version 0 strategies are mapped to these
+
+	// Persist is set when free space is tracked across opens of the file,
+	// in the free-space managers at Managers.
+	Persist bool
+
+	Threshold        uint64 // Smallest free-space section tracked
+	PageSize         uint64 // File space page size; version 1 only
+	PageEndThreshold uint16 // Smallest free space at page ends kept; version 1 only
+	EOA              uint64 // End of allocated space; version 1 only, undefined if unknown
+
+	// Managers holds the addresses of the persistent free-space managers,
+	// undefined for managers not in use; nil unless Persist is set.
+	Managers []uint64
+}
+
+func (m *FileSpaceInfo) Type() Type { return TypeFileSpaceInfo }
+
+func parseFileSpaceInfo(data []byte, r *binpkg.Reader) (*FileSpaceInfo, int, error) {
+	if len(data) < 2 {
+		return nil, 0, fmt.Errorf("file space info message too short")
+	}
+	m := &FileSpaceInfo{Version: data[0], EOA: UndefinedAddress}
+	offsetSize, lengthSize := r.OffsetSize(), r.LengthSize()
+	order := r.ByteOrder()
+
+	var size int
+	switch m.Version {
+	case 0:
+		// Strategies of version 0: all persist, all, aggregators and VFD,
+		// and VFD only
+		switch data[1] {
+		case 1:
+			m.Strategy, m.Persist = FileSpaceFSMAggr, true
+		case 2:
+			m.Strategy = FileSpaceFSMAggr
+		case 3:
+			m.Strategy = FileSpaceAggr
+		case 4:
+			m.Strategy = FileSpaceNone
+		default:
+			return nil, 0, fmt.Errorf("unknown file space strategy %d", data[1])
+		}
+		size = 2 + lengthSize
+		if len(data) < size {
+			return nil, 0, fmt.Errorf("file space info message truncated")
+		}
+		m.Threshold = decodeUint(data[2:], lengthSize, order)
+
+	case 1:
+		m.Strategy = FileSpaceStrategy(data[1])
+		if m.Strategy > FileSpaceNone {
+			return nil, 0, fmt.Errorf("unknown file space strategy %d", data[1])
+		}
+		size = 3 + 2*lengthSize + 2 + offsetSize
+		if len(data) < size {
+			return nil, 0, fmt.Errorf("file space info message truncated")
+		}
+		m.Persist = data[2] != 0
+		offset := 3
+		m.Threshold = decodeUint(data[offset:], lengthSize, order)
+		offset += lengthSize
+		m.PageSize = decodeUint(data[offset:], lengthSize, order)
+		offset += lengthSize
+		m.PageEndThreshold = uint16(decodeUint(data[offset:], 2, order))
+		offset += 2
+		m.EOA = decodeUint(data[offset:], offsetSize, order)
+
+	default:
+		return nil, 0, fmt.Errorf("unsupported file space info message version: %d", m.Version)
+	}
+
+	if m.Persist {
+		n := fileSpaceManagers[m.Version]
+		if len(data) < size+n*offsetSize {
+			return nil, 0, fmt.Errorf("file space info message truncated")
+		}
+		m.Managers = make([]uint64, n)
+		for i := range m.Managers {
+			m.Managers[i] = decodeUint(data[size:], offsetSize, order)
+			size += offsetSize
+		}
+	}
+	return m, size, nil
+}
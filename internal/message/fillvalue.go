@@ -1,6 +1,7 @@
 package message
 
 import (
+	"encoding/binary"
 	"fmt"
 
 	binpkg "github.com/robert-malhotra/go-hdf5/internal/binary"
@@ -16,6 +17,10 @@ const (
 )
 
 // FillValue represents a fill value message (type 0x0005).
+//
+// Value holds one element's worth of bytes in the dataset's datatype. It
+// is nil when the fill value is undefined or is the library default,
+// which is all zero bytes.
 type FillValue struct {
 	Version      uint8
 	SpaceAllocTime uint8
@@ -27,9 +32,34 @@ type FillValue struct {
 
 func (m *FillValue) Type() Type { return TypeFillValue }
 
-func parseFillValue(data []byte, r *binpkg.Reader) (*FillValue, error) {
+// FillValueOld represents the old fill value message (type 0x0004),
+// written by HDF5 1.4 and earlier. Newer files may carry it alongside a
+// FillValue message, which takes precedence.
+type FillValueOld struct {
+	Size  uint32
+	Value []byte
+}
+
+func (m *FillValueOld) Type() Type { return TypeFillValueOld }
+
+func parseFillValueOld(data []byte, r *binpkg.Reader) (*FillValueOld, int, error) {
+	if len(data) < 4 {
+		return nil, 0, fmt.Errorf("old fill value message too short")
+	}
+	fv := &FillValueOld{Size: binary.LittleEndian.Uint32(data)}
+	if 4+uint64(fv.Size) > uint64(len(data)) {
+		return nil, 0, fmt.Errorf("old fill value data truncated")
+	}
+	if fv.Size > 0 {
+		fv.Value = make([]byte, fv.Size)
+		copy(fv.Value, data[4:])
+	}
+	return fv, 4 + int(fv.Size), nil
+}
+
+func parseFillValue(data []byte, r *binpkg.Reader) (*FillValue, int, error) {
 	if len(data) < 2 {
-		return nil, fmt.Errorf("fill value message too short")
+		return nil, 0, fmt.Errorf("fill value message too short")
 	}
 
 	fv := &FillValue{
@@ -42,36 +72,45 @@ func parseFillValue(data []byte, r *binpkg.Reader) (*FillValue, error) {
 	case 3:
 		return parseFillValueV3(data, fv)
 	default:
-		return nil, fmt.Errorf("unsupported fill value version: %d", fv.Version)
+		return nil, 0, fmt.Errorf("unsupported fill value version: %d", fv.Version)
 	}
 }
 
-func parseFillValueV1V2(data []byte, fv *FillValue) (*FillValue, error) {
+func parseFillValueV1V2(data []byte, fv *FillValue) (*FillValue, int, error) {
 	if len(data) < 4 {
-		return nil, fmt.Errorf("fill value v1/v2 too short")
+		return nil, 0, fmt.Errorf("fill value v1/v2 too short")
 	}
 
 	fv.SpaceAllocTime = data[1]
 	fv.FillWriteTime = data[2]
 	fv.IsDefined = data[3] != 0
 
-	if fv.IsDefined && len(data) >= 8 {
-		fv.Size = uint32(data[4]) | uint32(data[5])<<8 |
-			uint32(data[6])<<16 | uint32(data[7])<<24
-		if len(data) >= 8+int(fv.Size) {
-			fv.Value = make([]byte, fv.Size)
-			copy(fv.Value, data[8:8+fv.Size])
-		}
+	// Version 1 always stores the size and value; version 2 only when a
+	// fill value is defined
+	offset := 4
+	if !fv.IsDefined && fv.Version != 1 {
+		return fv, offset, nil
+	}
+	if offset+4 > len(data) {
+		return nil, 0, fmt.Errorf("fill value v%d size truncated", fv.Version)
+	}
+	size := uint32(data[4]) | uint32(data[5])<<8 |
+		uint32(data[6])<<16 | uint32(data[7])<<24
+	offset += 4
+	if offset+int(size) > len(data) {
+		return nil, 0, fmt.Errorf("fill value v%d data truncated", fv.Version)
 	}
+	fv.Size = size
+	if fv.IsDefined && size > 0 {
+		fv.Value = make([]byte, size)
+		copy(fv.Value, data[offset:offset+int(size)])
+	}
+	offset += int(size)
 
-	return fv, nil
+	return fv, offset, nil
 }
 
-func parseFillValueV3(data []byte, fv *FillValue) (*FillValue, error) {
-	if len(data) < 2 {
-		return nil, fmt.Errorf("fill value v3 too short")
-	}
-
+func parseFillValueV3(data []byte, fv *FillValue) (*FillValue, int, error) {
 	flags := data[1]
 	fv.SpaceAllocTime = flags & 0x03
 	fv.FillWriteTime = (flags >> 2) & 0x03
@@ -81,18 +120,21 @@ func parseFillValueV3(data []byte, fv *FillValue) (*FillValue, error) {
 	if fv.IsDefined && (flags>>5)&0x01 != 0 {
 		// Fill value is present
 		if offset+4 > len(data) {
-			return nil, fmt.Errorf("fill value v3 size truncated")
+			return nil, 0, fmt.Errorf("fill value v3 size truncated")
 		}
 		fv.Size = uint32(data[offset]) | uint32(data[offset+1])<<8 |
 			uint32(data[offset+2])<<16 | uint32(data[offset+3])<<24
 		offset += 4
 
 		if offset+int(fv.Size) > len(data) {
-			return nil, fmt.Errorf("fill value v3 data truncated")
+			return nil, 0, fmt.Errorf("fill value v3 data truncated")
 		}
-		fv.Value = make([]byte, fv.Size)
-		copy(fv.Value, data[offset:offset+int(fv.Size)])
+		if fv.Size > 0 {
+			fv.Value = make([]byte, fv.Size)
+			copy(fv.Value, data[offset:offset+int(fv.Size)])
+		}
+		offset += int(fv.Size)
 	}
 
-	return fv, nil
+	return fv, offset, nil
 }
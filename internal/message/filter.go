@@ -15,6 +15,10 @@ const (
 	FilterSZIP        uint16 = 4 // SZIP compression
 	FilterNBit        uint16 = 5 // N-bit packing
 	FilterScaleOffset uint16 = 6 // Scale + offset
+
+	// Third-party filters registered with The HDF Group
+	FilterLZF  uint16 = 32000 // LZF, h5py's compression="lzf"
+	FilterZstd uint16 = 32015 // Zstandard
 )
 
 // FilterInfo describes a single filter in the pipeline.
@@ -59,9 +63,9 @@ func (m *FilterPipeline) HasCompression() bool {
 	return false
 }
 
-func parseFilterPipeline(data []byte, r *binpkg.Reader) (*FilterPipeline, error) {
+func parseFilterPipeline(data []byte, r *binpkg.Reader) (*FilterPipeline, int, error) {
 	if len(data) < 2 {
-		return nil, fmt.Errorf("filter pipeline message too short")
+		return nil, 0, fmt.Errorf("filter pipeline message too short")
 	}
 
 	fp := &FilterPipeline{
@@ -74,18 +78,23 @@ func parseFilterPipeline(data []byte, r *binpkg.Reader) (*FilterPipeline, error)
 	// Version 1 has 6 reserved bytes
 	if fp.Version == 1 {
 		offset = 8
+		if len(data) < offset {
+			return nil, 0, fmt.Errorf("filter pipeline message too short")
+		}
 	}
 
+	// Exactly as many filters as the header declares; anything after the
+	// last one is padding
 	for i := range fp.Filters {
 		filter, consumed, err := parseFilterInfo(data[offset:], fp.Version)
 		if err != nil {
-			return nil, fmt.Errorf("parsing filter %d: %w", i, err)
+			return nil, 0, fmt.Errorf("parsing filter %d: %w", i, err)
 		}
 		fp.Filters[i] = filter
 		offset += consumed
 	}
 
-	return fp, nil
+	return fp, offset, nil
 }
 
 func parseFilterInfo(data []byte, version uint8) (FilterInfo, int, error) {
@@ -101,6 +110,9 @@ func parseFilterInfo(data []byte, version uint8) (FilterInfo, int, error) {
 	// Name length field only present in v1 or for custom filters (ID >= 256)
 	var nameLen uint16
 	if version == 1 || f.ID >= 256 {
+		if len(data) < 8 {
+			return f, 0, fmt.Errorf("filter info too short")
+		}
 		nameLen = binary.LittleEndian.Uint16(data[offset:])
 		offset += 2
 	}
@@ -130,8 +142,11 @@ func parseFilterInfo(data []byte, version uint8) (FilterInfo, int, error) {
 	}
 
 	// Parse client data
+	if offset+4*int(numCD) > len(data) {
+		return f, 0, fmt.Errorf("filter client data truncated")
+	}
 	f.ClientData = make([]uint32, numCD)
-	for j := 0; j < int(numCD) && offset+4 <= len(data); j++ {
+	for j := range f.ClientData {
 		f.ClientData[j] = binary.LittleEndian.Uint32(data[offset:])
 		offset += 4
 	}
@@ -140,6 +155,9 @@ func parseFilterInfo(data []byte, version uint8) (FilterInfo, int, error) {
 	if version == 1 && numCD%2 != 0 {
 		offset += 4
 	}
+	if offset > len(data) {
+		return f, 0, fmt.Errorf("filter info truncated")
+	}
 
 	return f, offset, nil
 }
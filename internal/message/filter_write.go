@@ -0,0 +1,83 @@
+package message
+
+import (
+	"github.com/robert-malhotra/go-hdf5/internal/binary"
+)
+
+// Serialize writes the FilterPipeline to the writer.
+// Always uses the version 2 format, which omits the names of the predefined
+// filters and the padding of version 1.
+func (m *FilterPipeline) Serialize(w *binary.Writer) error {
+	if err := w.WriteUint8(2); err != nil {
+		return err
+	}
+	if err := w.WriteUint8(uint8(len(m.Filters))); err != nil {
+		return err
+	}
+
+	for _, f := range m.Filters {
+		if err := w.WriteUint16(f.ID); err != nil {
+			return err
+		}
+		// Filters outside the predefined range carry a name
+		name := filterName(f)
+		if f.ID >= 256 {
+			if err := w.WriteUint16(uint16(len(name))); err != nil {
+				return err
+			}
+		}
+		if err := w.WriteUint16(f.Flags); err != nil {
+			return err
+		}
+		if err := w.WriteUint16(uint16(len(f.ClientData))); err != nil {
+			return err
+		}
+		if f.ID >= 256 {
+			if err := w.WriteBytes(name); err != nil {
+				return err
+			}
+		}
+		for _, cd := range f.ClientData {
+			if err := w.WriteUint32(cd); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// SerializedSize returns the size in bytes when serialized.
+func (m *FilterPipeline) SerializedSize(w *binary.Writer) int {
+	// Version + number of filters
+	size := 2
+
+	for _, f := range m.Filters {
+		// ID + flags + number of client data values
+		size += 6
+		if f.ID >= 256 {
+			size += 2 + len(filterName(f))
+		}
+		size += 4 * len(f.ClientData)
+	}
+
+	return size
+}
+
+// filterName returns the null-terminated name of a filter, or nothing for
+// an unnamed one.
+func filterName(f FilterInfo) []byte {
+	if f.Name == "" {
+		return nil
+	}
+	return append([]byte(f.Name), 0)
+}
+
+// NewFilterPipeline creates a filter pipeline message applying filters in
+// the given order when writing.
+func NewFilterPipeline(filters ...FilterInfo) *FilterPipeline {
+	return &FilterPipeline{
+		Version: 2,
+		Filters: filters,
+	}
+}
@@ -0,0 +1,53 @@
+package message
+
+import (
+	"bytes"
+	"testing"
+
+	binpkg "github.com/robert-malhotra/go-hdf5/internal/binary"
+)
+
+// FuzzParse checks that parsing arbitrary message bodies returns an error
+// rather than panicking or allocating without bound. It is seeded with
+// messages written by this package.
+func FuzzParse(f *testing.F) {
+	f64 := NewFloatDatatype(8, OrderLE)
+	i32 := NewFixedPointDatatype(4, true, OrderLE)
+	seeds := []Serializable{
+		NewDataspace([]uint64{10, 20}, []uint64{Unlimited, 20}),
+		NewScalarDataspace(),
+		i32,
+		NewVarLenStringDatatype(CharsetUTF8),
+		NewCompoundDatatype(8+24, []CompoundMember{
+			{Name: "gain", ByteOffset: 0, Type: f64},
+			{Name: "offset", ByteOffset: 8, Type: NewArrayDatatype([]uint32{3}, f64)},
+		}),
+		NewEnumDatatype(i32, []string{"off", "on"}, []int64{0, 1}),
+		NewContiguousLayout(0x1000, 1024),
+		NewCompactLayout([]byte{1, 2, 3, 4}),
+		NewChunkedLayout([]uint32{4, 4}, 8, ChunkIndexFixedArray),
+		NewFilterPipeline(
+			FilterInfo{ID: FilterShuffle, Flags: 0x01, ClientData: []uint32{8}},
+			FilterInfo{ID: FilterDeflate, Flags: 0x01, ClientData: []uint32{6}},
+		),
+		NewHardLink("data", 0x1234),
+		NewSoftLink("soft", "/data"),
+		NewExternalLink("ext", "other.h5", "/data"),
+		NewLinkInfo(),
+		NewGroupInfo(),
+		NewAttribute("scale", f64, NewDataspace([]uint64{2}, nil), make([]byte, 16)),
+	}
+	for _, msg := range seeds {
+		buf := &bytesWriterAt{}
+		w := binpkg.NewWriter(buf, binpkg.DefaultConfig())
+		if err := msg.Serialize(w); err != nil {
+			f.Fatalf("serializing %T: %v", msg, err)
+		}
+		f.Add(uint8(msg.Type()), uint8(0), buf.Bytes())
+	}
+
+	f.Fuzz(func(t *testing.T, typ, flags uint8, data []byte) {
+		r := binpkg.NewReader(bytes.NewReader(data), binpkg.DefaultConfig())
+		Parse(Type(typ), data, flags, r)
+	})
+}
@@ -50,8 +50,13 @@ type DataLayout struct {
 	ChunkFlags         uint8
 	DimensionSizeBytes uint8 // Size of each dimension entry
 
-	// Filtered chunk info (v4)
-	FilteredChunkSize uint32
+	// Filtered single chunk info (v4)
+	FilteredChunkSize uint64
+	FilterMask        uint32
+
+	// Virtual layout: the global heap object holding the mapping list
+	VirtualHeapAddress uint64
+	VirtualHeapIndex   uint32
 }
 
 func (m *DataLayout) Type() Type { return TypeDataLayout }
@@ -71,9 +76,9 @@ func (m *DataLayout) IsChunked() bool {
 	return m.Class == LayoutChunked
 }
 
-func parseDataLayout(data []byte, r *binpkg.Reader) (*DataLayout, error) {
+func parseDataLayout(data []byte, r *binpkg.Reader) (*DataLayout, int, error) {
 	if len(data) < 2 {
-		return nil, fmt.Errorf("data layout message too short")
+		return nil, 0, fmt.Errorf("data layout message too short")
 	}
 
 	layout := &DataLayout{
@@ -83,136 +88,211 @@ func parseDataLayout(data []byte, r *binpkg.Reader) (*DataLayout, error) {
 	switch layout.Version {
 	case 1, 2:
 		return parseDataLayoutV1V2(data, r, layout)
-	case 3:
-		return parseDataLayoutV3(data, r, layout)
-	case 4:
-		return parseDataLayoutV4(data, r, layout)
+	case 3, 4:
+		return parseDataLayoutV3V4(data, r, layout)
 	default:
-		return nil, fmt.Errorf("unsupported data layout version: %d", layout.Version)
+		return nil, 0, fmt.Errorf("unsupported data layout version: %d", layout.Version)
 	}
 }
 
-func parseDataLayoutV1V2(data []byte, r *binpkg.Reader, layout *DataLayout) (*DataLayout, error) {
-	if len(data) < 4 {
-		return nil, fmt.Errorf("data layout v1/v2 message too short")
+// parseDataLayoutV1V2 parses layout versions 1 and 2. After the version,
+// dimensionality, class and five reserved bytes come the address (contiguous
+// and chunked only), one 4-byte size per dimension, and for compact storage
+// the data size and data. Chunked dimensions include the trailing element
+// size; contiguous dimensions are not needed, since the storage size follows
+// from the dataspace and datatype.
+func parseDataLayoutV1V2(data []byte, r *binpkg.Reader, layout *DataLayout) (*DataLayout, int, error) {
+	if len(data) < 8 {
+		return nil, 0, fmt.Errorf("data layout v%d message too short", layout.Version)
 	}
 
 	ndims := int(data[1])
 	layout.Class = LayoutClass(data[2])
-	// data[3] is reserved
+	offset := 8 // data[3:8] is reserved
 
-	offset := 4
+	offsetSize := r.OffsetSize()
+	var address uint64
+	switch layout.Class {
+	case LayoutContiguous, LayoutChunked:
+		if offset+offsetSize > len(data) {
+			return nil, 0, fmt.Errorf("data layout v%d address truncated", layout.Version)
+		}
+		address = decodeUint(data[offset:], offsetSize, r.ByteOrder())
+		offset += offsetSize
+	case LayoutCompact:
+	default:
+		return nil, 0, fmt.Errorf("unsupported data layout v%d class: %d", layout.Version, layout.Class)
+	}
+
+	if offset+4*ndims > len(data) {
+		return nil, 0, fmt.Errorf("data layout v%d dimensions truncated", layout.Version)
+	}
+	dims := make([]uint32, ndims)
+	for i := range dims {
+		dims[i] = binary.LittleEndian.Uint32(data[offset:])
+		offset += 4
+	}
 
 	switch layout.Class {
 	case LayoutCompact:
 		if offset+4 > len(data) {
-			return nil, fmt.Errorf("compact layout truncated")
+			return nil, 0, fmt.Errorf("compact layout truncated")
 		}
 		size := binary.LittleEndian.Uint32(data[offset:])
 		offset += 4
-		if offset+int(size) > len(data) {
-			return nil, fmt.Errorf("compact data truncated")
+		if uint64(offset)+uint64(size) > uint64(len(data)) {
+			return nil, 0, fmt.Errorf("compact data truncated")
 		}
 		layout.CompactData = make([]byte, size)
 		copy(layout.CompactData, data[offset:offset+int(size)])
+		offset += int(size)
 
 	case LayoutContiguous:
-		offsetSize := r.OffsetSize()
-		lengthSize := r.LengthSize()
-		if offset+offsetSize+lengthSize > len(data) {
-			return nil, fmt.Errorf("contiguous layout truncated")
-		}
-		layout.Address = decodeUint(data[offset:], offsetSize, r.ByteOrder())
-		offset += offsetSize
-		layout.Size = decodeUint(data[offset:], lengthSize, r.ByteOrder())
+		layout.Address = address
 
 	case LayoutChunked:
-		offsetSize := r.OffsetSize()
-		if offset+offsetSize > len(data) {
-			return nil, fmt.Errorf("chunked layout truncated")
-		}
-		layout.ChunkIndexAddr = decodeUint(data[offset:], offsetSize, r.ByteOrder())
-		offset += offsetSize
-
-		// Parse chunk dimensions (ndims * 4 bytes each)
-		layout.ChunkDims = make([]uint32, ndims)
-		for i := 0; i < ndims && offset+4 <= len(data); i++ {
-			layout.ChunkDims[i] = binary.LittleEndian.Uint32(data[offset:])
-			offset += 4
-		}
+		layout.ChunkIndexAddr = address
+		layout.ChunkDims = dims
+		layout.DimensionSizeBytes = 4
 	}
 
-	return layout, nil
+	return layout, offset, nil
 }
 
-func parseDataLayoutV3(data []byte, r *binpkg.Reader, layout *DataLayout) (*DataLayout, error) {
-	if len(data) < 2 {
-		return nil, fmt.Errorf("data layout v3 message too short")
-	}
-
+// parseDataLayoutV3V4 parses layout versions 3 and 4, which differ only in
+// how chunked storage is described: version 3 always uses a version 1
+// B-tree, while version 4 names its chunk index type explicitly.
+func parseDataLayoutV3V4(data []byte, r *binpkg.Reader, layout *DataLayout) (*DataLayout, int, error) {
 	layout.Class = LayoutClass(data[1])
 	offset := 2
 
+	offsetSize := r.OffsetSize()
+	lengthSize := r.LengthSize()
+
 	switch layout.Class {
 	case LayoutCompact:
 		if offset+2 > len(data) {
-			return nil, fmt.Errorf("compact layout v3 truncated")
+			return nil, 0, fmt.Errorf("compact layout v%d truncated", layout.Version)
 		}
 		size := binary.LittleEndian.Uint16(data[offset:])
 		offset += 2
 		if offset+int(size) > len(data) {
-			return nil, fmt.Errorf("compact data v3 truncated")
+			return nil, 0, fmt.Errorf("compact data v%d truncated", layout.Version)
 		}
 		layout.CompactData = make([]byte, size)
 		copy(layout.CompactData, data[offset:offset+int(size)])
+		offset += int(size)
 
 	case LayoutContiguous:
-		offsetSize := r.OffsetSize()
-		lengthSize := r.LengthSize()
 		if offset+offsetSize+lengthSize > len(data) {
-			return nil, fmt.Errorf("contiguous layout v3 truncated")
+			return nil, 0, fmt.Errorf("contiguous layout v%d truncated", layout.Version)
 		}
 		layout.Address = decodeUint(data[offset:], offsetSize, r.ByteOrder())
 		offset += offsetSize
 		layout.Size = decodeUint(data[offset:], lengthSize, r.ByteOrder())
+		offset += lengthSize
 
 	case LayoutChunked:
-		if offset+2 > len(data) {
-			return nil, fmt.Errorf("chunked layout v3 truncated")
-		}
-		layout.ChunkFlags = data[offset]
-		layout.ChunkIndexType = ChunkIndexType(layout.ChunkFlags & 0x0F) // Lower 4 bits
-		offset++
-		ndims := int(data[offset])
-		offset++
-		layout.DimensionSizeBytes = data[offset]
-		offset++
-
-		// Parse chunk dimensions
-		dimSize := int(layout.DimensionSizeBytes)
-		layout.ChunkDims = make([]uint32, ndims)
-		for i := 0; i < ndims && offset+dimSize <= len(data); i++ {
-			layout.ChunkDims[i] = uint32(decodeUint(data[offset:], dimSize, r.ByteOrder()))
-			offset += dimSize
+		if layout.Version == 3 {
+			return parseChunkedLayoutV3(data, offset, r, layout)
 		}
+		return parseChunkedLayoutV4(data, offset, r, layout)
 
-		// For v4, there may be additional bytes before the address
-		// (element size encoding or chunk indexing type parameters)
-		// The address is typically at the end of the message, so we calculate its position
-		offsetSize := r.OffsetSize()
-		addrOffset := len(data) - offsetSize
-		if addrOffset >= offset {
-			layout.ChunkIndexAddr = decodeUint(data[addrOffset:], offsetSize, r.ByteOrder())
-		} else if offset+offsetSize <= len(data) {
-			layout.ChunkIndexAddr = decodeUint(data[offset:], offsetSize, r.ByteOrder())
+	case LayoutVirtual:
+		// Global heap address and index of the mapping list
+		if layout.Version < 4 || offset+offsetSize+4 > len(data) {
+			return nil, 0, fmt.Errorf("virtual layout v%d truncated", layout.Version)
 		}
+		layout.VirtualHeapAddress = decodeUint(data[offset:], offsetSize, r.ByteOrder())
+		offset += offsetSize
+		layout.VirtualHeapIndex = binary.LittleEndian.Uint32(data[offset:])
+		offset += 4
+	}
+
+	return layout, offset, nil
+}
+
+// parseChunkedLayoutV3 parses the dimensionality, the B-tree address and
+// the chunk dimensions (4 bytes each, the last being the element size).
+func parseChunkedLayoutV3(data []byte, offset int, r *binpkg.Reader, layout *DataLayout) (*DataLayout, int, error) {
+	offsetSize := r.OffsetSize()
+	if offset+1+offsetSize > len(data) {
+		return nil, 0, fmt.Errorf("chunked layout v3 truncated")
+	}
+	ndims := int(data[offset])
+	offset++
+	layout.ChunkIndexAddr = decodeUint(data[offset:], offsetSize, r.ByteOrder())
+	offset += offsetSize
+
+	if offset+4*ndims > len(data) {
+		return nil, 0, fmt.Errorf("chunked layout v3 dimensions truncated")
+	}
+	layout.DimensionSizeBytes = 4
+	layout.ChunkDims = make([]uint32, ndims)
+	for i := range layout.ChunkDims {
+		layout.ChunkDims[i] = binary.LittleEndian.Uint32(data[offset:])
+		offset += 4
 	}
 
-	return layout, nil
+	return layout, offset, nil
 }
 
-func parseDataLayoutV4(data []byte, r *binpkg.Reader, layout *DataLayout) (*DataLayout, error) {
-	// V4 is similar to V3 but supports virtual datasets
-	// For now, parse like V3
-	return parseDataLayoutV3(data, r, layout)
+// parseChunkedLayoutV4 parses the flags, the chunk dimensions, the chunk
+// index type with its index-specific parameters, and the index address.
+func parseChunkedLayoutV4(data []byte, offset int, r *binpkg.Reader, layout *DataLayout) (*DataLayout, int, error) {
+	if offset+3 > len(data) {
+		return nil, 0, fmt.Errorf("chunked layout v4 truncated")
+	}
+	layout.ChunkFlags = data[offset]
+	ndims := int(data[offset+1])
+	layout.DimensionSizeBytes = data[offset+2]
+	offset += 3
+
+	dimSize := int(layout.DimensionSizeBytes)
+	if dimSize < 1 || dimSize > 8 {
+		return nil, 0, fmt.Errorf("chunked layout v4: invalid dimension size %d", dimSize)
+	}
+	if offset+ndims*dimSize+1 > len(data) {
+		return nil, 0, fmt.Errorf("chunked layout v4 dimensions truncated")
+	}
+	layout.ChunkDims = make([]uint32, ndims)
+	for i := range layout.ChunkDims {
+		layout.ChunkDims[i] = uint32(decodeUint(data[offset:], dimSize, r.ByteOrder()))
+		offset += dimSize
+	}
+
+	layout.ChunkIndexType = ChunkIndexType(data[offset])
+	offset++
+
+	// Index-specific parameters
+	var paramSize int
+	switch layout.ChunkIndexType {
+	case ChunkIndexSingleChunk:
+		if layout.ChunkFlags&0x02 != 0 {
+			// Filtered chunk size and filter mask
+			paramSize = r.LengthSize() + 4
+		}
+	case ChunkIndexImplicit:
+	case ChunkIndexFixedArray:
+		paramSize = 1 // Page bits
+	case ChunkIndexExtensibleArray:
+		paramSize = 5 // Max bits, index elements, min pointers, min elements, page bits
+	case ChunkIndexBTreeV2:
+		paramSize = 6 // Node size (4), split percent, merge percent
+	default:
+		return nil, 0, fmt.Errorf("chunked layout v4: unknown chunk index type %d", layout.ChunkIndexType)
+	}
+	if offset+paramSize+r.OffsetSize() > len(data) {
+		return nil, 0, fmt.Errorf("chunked layout v4 index truncated")
+	}
+	if paramSize > 0 && layout.ChunkIndexType == ChunkIndexSingleChunk {
+		layout.FilteredChunkSize = decodeUint(data[offset:], r.LengthSize(), r.ByteOrder())
+		layout.FilterMask = binary.LittleEndian.Uint32(data[offset+r.LengthSize():])
+	}
+	offset += paramSize
+
+	layout.ChunkIndexAddr = decodeUint(data[offset:], r.OffsetSize(), r.ByteOrder())
+	offset += r.OffsetSize()
+
+	return layout, offset, nil
 }
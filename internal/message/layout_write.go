@@ -7,14 +7,13 @@ import (
 // Serialize writes the DataLayout to the writer.
 // Uses version 3/4 format for modern compatibility.
 func (m *DataLayout) Serialize(w *binary.Writer) error {
-	// We use version 4 for chunked with index type, version 3 otherwise
+	// Chunked layouts are always written in the version 4 format, which
+	// carries the index type; other classes default to version 3
 	version := m.Version
-	if version == 0 {
-		if m.Class == LayoutChunked {
-			version = 4
-		} else {
-			version = 3
-		}
+	if m.Class == LayoutChunked {
+		version = 4
+	} else if version == 0 {
+		version = 3
 	}
 
 	if err := w.WriteUint8(version); err != nil {
@@ -45,10 +44,10 @@ func (m *DataLayout) Serialize(w *binary.Writer) error {
 		}
 
 	case LayoutChunked:
-		// Flags byte (bit 4 = DONT_FILTER_PARTIAL_BOUND_CHUNKS)
+		// Flags byte (bit 0 = DONT_FILTER_PARTIAL_BOUND_CHUNKS, bit 1 =
+		// SINGLE_INDEX_WITH_FILTER)
 		// Note: chunk index type is written as a separate byte after dimensions
-		flags := uint8(0)
-		if err := w.WriteUint8(flags); err != nil {
+		if err := w.WriteUint8(m.ChunkFlags); err != nil {
 			return err
 		}
 
@@ -81,6 +80,16 @@ func (m *DataLayout) Serialize(w *binary.Writer) error {
 
 		// Indexing-type-specific info (required for Fixed Array and Extensible Array)
 		switch m.ChunkIndexType {
+		case ChunkIndexSingleChunk:
+			// Size and filter mask of a filtered chunk
+			if m.ChunkFlags&0x02 != 0 {
+				if err := w.WriteLength(m.FilteredChunkSize); err != nil {
+					return err
+				}
+				if err := w.WriteUint32(m.FilterMask); err != nil {
+					return err
+				}
+			}
 		case ChunkIndexFixedArray:
 			// Page Bits: log2 of entries per data block page
 			// Must match the value used in WriteFixedArrayIndex
@@ -89,10 +98,9 @@ func (m *DataLayout) Serialize(w *binary.Writer) error {
 				return err
 			}
 		case ChunkIndexExtensibleArray:
-			// Max Bits: max number of bits for storing # of elements in data block page
-			// h5py uses 10 for small datasets
-			maxBits := uint8(10)
-			if err := w.WriteUint8(maxBits); err != nil {
+			// Max bits, index elements, min pointers, min elements and
+			// page bits, as the C library's defaults
+			if err := w.WriteBytes([]byte{32, 4, 4, 16, 10}); err != nil {
 				return err
 			}
 		}
@@ -127,9 +135,16 @@ func (m *DataLayout) SerializedSize(w *binary.Writer) int {
 		size += 3
 		size += len(m.ChunkDims) * dimSizeBytes
 		size += 1 // chunk index type (separate byte)
-		// Indexing-type-specific info byte (Fixed Array and Extensible Array need this)
-		if m.ChunkIndexType == ChunkIndexFixedArray || m.ChunkIndexType == ChunkIndexExtensibleArray {
+		// Indexing-type-specific info
+		switch m.ChunkIndexType {
+		case ChunkIndexSingleChunk:
+			if m.ChunkFlags&0x02 != 0 {
+				size += w.LengthSize() + 4
+			}
+		case ChunkIndexFixedArray:
 			size += 1
+		case ChunkIndexExtensibleArray:
+			size += 5
 		}
 		size += w.OffsetSize() // chunk index address
 	}
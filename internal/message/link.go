@@ -52,9 +52,9 @@ func (m *Link) IsExternal() bool {
 	return m.LinkType == LinkTypeExternal
 }
 
-func parseLink(data []byte, r *binpkg.Reader) (*Link, error) {
+func parseLink(data []byte, r *binpkg.Reader) (*Link, int, error) {
 	if len(data) < 2 {
-		return nil, fmt.Errorf("link message too short")
+		return nil, 0, fmt.Errorf("link message too short")
 	}
 
 	link := &Link{
@@ -70,7 +70,7 @@ func parseLink(data []byte, r *binpkg.Reader) (*Link, error) {
 	// Link type present (flag bit 3)
 	if flags&0x08 != 0 {
 		if offset >= len(data) {
-			return nil, fmt.Errorf("link type truncated")
+			return nil, 0, fmt.Errorf("link type truncated")
 		}
 		link.LinkType = LinkType(data[offset])
 		offset++
@@ -79,7 +79,7 @@ func parseLink(data []byte, r *binpkg.Reader) (*Link, error) {
 	// Creation order present (flag bit 2)
 	if flags&0x04 != 0 {
 		if offset+8 > len(data) {
-			return nil, fmt.Errorf("link creation order truncated")
+			return nil, 0, fmt.Errorf("link creation order truncated")
 		}
 		link.CreationOrder = binary.LittleEndian.Uint64(data[offset:])
 		offset += 8
@@ -88,7 +88,7 @@ func parseLink(data []byte, r *binpkg.Reader) (*Link, error) {
 	// Link name charset (flag bit 4)
 	if flags&0x10 != 0 {
 		if offset >= len(data) {
-			return nil, fmt.Errorf("link charset truncated")
+			return nil, 0, fmt.Errorf("link charset truncated")
 		}
 		link.Charset = data[offset]
 		offset++
@@ -96,7 +96,7 @@ func parseLink(data []byte, r *binpkg.Reader) (*Link, error) {
 
 	// Parse link name length
 	if offset+nameLenSize > len(data) {
-		return nil, fmt.Errorf("link name length truncated")
+		return nil, 0, fmt.Errorf("link name length truncated")
 	}
 	var nameLen uint64
 	switch nameLenSize {
@@ -112,8 +112,8 @@ func parseLink(data []byte, r *binpkg.Reader) (*Link, error) {
 	offset += nameLenSize
 
 	// Parse link name
-	if offset+int(nameLen) > len(data) {
-		return nil, fmt.Errorf("link name truncated")
+	if nameLen > uint64(len(data)-offset) {
+		return nil, 0, fmt.Errorf("link name truncated")
 	}
 	link.Name = string(data[offset : offset+int(nameLen)])
 	offset += int(nameLen)
@@ -123,34 +123,37 @@ func parseLink(data []byte, r *binpkg.Reader) (*Link, error) {
 	case LinkTypeHard:
 		offsetSize := r.OffsetSize()
 		if offset+offsetSize > len(data) {
-			return nil, fmt.Errorf("hard link address truncated")
+			return nil, 0, fmt.Errorf("hard link address truncated")
 		}
 		link.ObjectAddress = decodeUint(data[offset:], offsetSize, r.ByteOrder())
+		offset += offsetSize
 
 	case LinkTypeSoft:
 		if offset+2 > len(data) {
-			return nil, fmt.Errorf("soft link length truncated")
+			return nil, 0, fmt.Errorf("soft link length truncated")
 		}
 		softLen := binary.LittleEndian.Uint16(data[offset:])
 		offset += 2
 		if offset+int(softLen) > len(data) {
-			return nil, fmt.Errorf("soft link value truncated")
+			return nil, 0, fmt.Errorf("soft link value truncated")
 		}
 		link.SoftLinkValue = string(data[offset : offset+int(softLen)])
+		offset += int(softLen)
 
 	case LinkTypeExternal:
 		if offset+2 > len(data) {
-			return nil, fmt.Errorf("external link length truncated")
+			return nil, 0, fmt.Errorf("external link length truncated")
 		}
 		extLen := binary.LittleEndian.Uint16(data[offset:])
 		offset += 2
 		if offset+int(extLen) > len(data) {
-			return nil, fmt.Errorf("external link value truncated")
+			return nil, 0, fmt.Errorf("external link value truncated")
 		}
 		// External link format: flags (1) + file (null-term) + path (null-term)
 		extData := data[offset : offset+int(extLen)]
+		offset += int(extLen)
 		if len(extData) < 2 {
-			return nil, fmt.Errorf("external link data too short")
+			return nil, 0, fmt.Errorf("external link data too short")
 		}
 		// Skip flags byte
 		extData = extData[1:]
@@ -167,7 +170,19 @@ func parseLink(data []byte, r *binpkg.Reader) (*Link, error) {
 				link.ExternalPath = link.ExternalPath[:len(link.ExternalPath)-1]
 			}
 		}
+
+	default:
+		// User-defined links carry a length-prefixed value
+		if offset+2 > len(data) {
+			return nil, 0, fmt.Errorf("link value length truncated")
+		}
+		valueLen := binary.LittleEndian.Uint16(data[offset:])
+		offset += 2
+		if offset+int(valueLen) > len(data) {
+			return nil, 0, fmt.Errorf("link value truncated")
+		}
+		offset += int(valueLen)
 	}
 
-	return link, nil
+	return link, offset, nil
 }
@@ -48,6 +48,9 @@ func (m *Link) Serialize(w *binary.Writer) error {
 	if m.LinkType != LinkTypeHard {
 		flags |= 0x08 // Link type present
 	}
+	if m.Charset != 0 {
+		flags |= 0x10 // Charset present; ASCII otherwise
+	}
 
 	if err := w.WriteUint8(flags); err != nil {
 		return err
@@ -60,6 +63,13 @@ func (m *Link) Serialize(w *binary.Writer) error {
 		}
 	}
 
+	// Write charset if not ASCII
+	if m.Charset != 0 {
+		if err := w.WriteUint8(m.Charset); err != nil {
+			return err
+		}
+	}
+
 	// Write name length
 	if err := w.WriteUintN(uint64(nameLen), nameLenSize); err != nil {
 		return err
@@ -125,6 +135,11 @@ func (m *Link) SerializedSize(w *binary.Writer) int {
 		size += 1
 	}
 
+	// Charset if not ASCII
+	if m.Charset != 0 {
+		size += 1
+	}
+
 	// Name length field size
 	nameLen := len(m.Name)
 	if nameLen <= 0xFF {
@@ -153,12 +168,24 @@ func (m *Link) SerializedSize(w *binary.Writer) int {
 	return size
 }
 
+// nameCharset returns the character set of a link or attribute name: UTF-8
+// for a name outside ASCII.
+func nameCharset(name string) uint8 {
+	for i := 0; i < len(name); i++ {
+		if name[i] >= 0x80 {
+			return uint8(CharsetUTF8)
+		}
+	}
+	return uint8(CharsetASCII)
+}
+
 // NewHardLink creates a new hard link message.
 func NewHardLink(name string, objectAddress uint64) *Link {
 	return &Link{
 		Version:       1,
 		LinkType:      LinkTypeHard,
 		Name:          name,
+		Charset:       nameCharset(name),
 		ObjectAddress: objectAddress,
 	}
 }
@@ -169,6 +196,7 @@ func NewSoftLink(name string, targetPath string) *Link {
 		Version:       1,
 		LinkType:      LinkTypeSoft,
 		Name:          name,
+		Charset:       nameCharset(name),
 		SoftLinkValue: targetPath,
 	}
 }
@@ -179,6 +207,7 @@ func NewExternalLink(name string, externalFile, externalPath string) *Link {
 		Version:      1,
 		LinkType:     LinkTypeExternal,
 		Name:         name,
+		Charset:      nameCharset(name),
 		ExternalFile: externalFile,
 		ExternalPath: externalPath,
 	}
@@ -0,0 +1,73 @@
+package message
+
+import (
+	"fmt"
+
+	binpkg "github.com/robert-malhotra/go-hdf5/internal/binary"
+)
+
+// LinkInfo represents a link info message (type 0x0002).
+// This message provides metadata about links in a group. Groups whose links
+// are in dense storage keep them in a fractal heap indexed by name, and
+// optionally by creation order, with v2 B-trees.
+type LinkInfo struct {
+	Version                uint8
+	Flags                  uint8
+	MaxCreationIndex       uint64 // Present if flag bit 0 set
+	FractalHeapAddr        uint64 // Undefined if links are not dense
+	NameIndexBTreeAddr     uint64
+	CreationOrderBTreeAddr uint64 // Present if flag bit 1 set, else undefined
+}
+
+func (m *LinkInfo) Type() Type { return TypeLinkInfo }
+
+// TracksCreationOrder reports whether link creation order is tracked.
+func (m *LinkInfo) TracksCreationOrder() bool { return m.Flags&0x01 != 0 }
+
+// IndexesCreationOrder reports whether links are indexed by creation order,
+// in which case CreationOrderBTreeAddr is set.
+func (m *LinkInfo) IndexesCreationOrder() bool { return m.Flags&0x02 != 0 }
+
+func parseLinkInfo(data []byte, r *binpkg.Reader) (*LinkInfo, int, error) {
+	if len(data) < 2 {
+		return nil, 0, fmt.Errorf("link info message too short")
+	}
+	m := &LinkInfo{
+		Version:                data[0],
+		Flags:                  data[1],
+		CreationOrderBTreeAddr: UndefinedAddress,
+	}
+	if m.Version != 0 {
+		return nil, 0, fmt.Errorf("unsupported link info version: %d", m.Version)
+	}
+	if m.Flags&^0x03 != 0 {
+		return nil, 0, fmt.Errorf("link info message has unknown flags %#02x", m.Flags)
+	}
+
+	offsetSize := r.OffsetSize()
+	offset := 2
+	size := offset + 2*offsetSize
+	if m.TracksCreationOrder() {
+		size += 8
+	}
+	if m.IndexesCreationOrder() {
+		size += offsetSize
+	}
+	if len(data) < size {
+		return nil, 0, fmt.Errorf("link info message truncated")
+	}
+
+	if m.TracksCreationOrder() {
+		m.MaxCreationIndex = decodeUint(data[offset:], 8, r.ByteOrder())
+		offset += 8
+	}
+	m.FractalHeapAddr = decodeUint(data[offset:], offsetSize, r.ByteOrder())
+	offset += offsetSize
+	m.NameIndexBTreeAddr = decodeUint(data[offset:], offsetSize, r.ByteOrder())
+	offset += offsetSize
+	if m.IndexesCreationOrder() {
+		m.CreationOrderBTreeAddr = decodeUint(data[offset:], offsetSize, r.ByteOrder())
+		offset += offsetSize
+	}
+	return m, offset, nil
+}
@@ -4,19 +4,6 @@ import (
 	"github.com/robert-malhotra/go-hdf5/internal/binary"
 )
 
-// LinkInfo represents a link info message (type 0x0002).
-// This message provides metadata about links in a group.
-type LinkInfo struct {
-	Version               uint8
-	Flags                 uint8
-	MaxCreationIndex      uint64 // Present if flag bit 0 set
-	FractalHeapAddr       uint64 // Present if flag bit 1 set
-	NameIndexBTreeAddr    uint64 // Present if flag bit 1 set
-	CreationOrderBTreeAddr uint64 // Present if both bits 0 and 1 set
-}
-
-func (m *LinkInfo) Type() Type { return TypeLinkInfo }
-
 // Serialize writes the LinkInfo to the writer.
 // Note: The HDF5 library expects fractal heap and B-tree addresses to always
 // be present for groups using Link messages, even when undefined.
@@ -45,8 +32,8 @@ func (m *LinkInfo) Serialize(w *binary.Writer) error {
 		return err
 	}
 
-	// Creation order B-tree address (if both bits 0 and 1 set)
-	if m.Flags&0x03 == 0x03 {
+	// Creation order B-tree address (if flag bit 1 set)
+	if m.IndexesCreationOrder() {
 		if err := w.WriteOffset(m.CreationOrderBTreeAddr); err != nil {
 			return err
 		}
@@ -69,7 +56,7 @@ func (m *LinkInfo) SerializedSize(w *binary.Writer) int {
 	size += 2 * w.OffsetSize()
 
 	// Creation order B-tree address
-	if m.Flags&0x03 == 0x03 {
+	if m.IndexesCreationOrder() {
 		size += w.OffsetSize()
 	}
 
@@ -95,7 +82,7 @@ func NewLinkInfo() *LinkInfo {
 func NewLinkInfoWithHeap(heapAddr, nameIndexAddr uint64) *LinkInfo {
 	return &LinkInfo{
 		Version:            0,
-		Flags:              0x02, // Use fractal heap
+		Flags:              0,
 		FractalHeapAddr:    heapAddr,
 		NameIndexBTreeAddr: nameIndexAddr,
 	}
@@ -38,37 +38,107 @@ const (
 	TypeDriverInfo               Type = 0x0014
 	TypeAttributeInfo            Type = 0x0015
 	TypeObjectRefCount           Type = 0x0016
+	TypeFileSpaceInfo            Type = 0x0017
 )
 
+// typeNames maps message types to their names in the HDF5 specification.
+var typeNames = map[Type]string{
+	TypeNIL:                      "NIL",
+	TypeDataspace:                "Dataspace",
+	TypeLinkInfo:                 "Link Info",
+	TypeDatatype:                 "Datatype",
+	TypeFillValueOld:             "Fill Value (old)",
+	TypeFillValue:                "Fill Value",
+	TypeLink:                     "Link",
+	TypeExternalDataFiles:        "External Data Files",
+	TypeDataLayout:               "Data Layout",
+	TypeBogus:                    "Bogus",
+	TypeGroupInfo:                "Group Info",
+	TypeFilterPipeline:           "Filter Pipeline",
+	TypeAttribute:                "Attribute",
+	TypeObjectComment:            "Object Comment",
+	TypeObjectModTime:            "Object Modification Time",
+	TypeSharedMessageTable:       "Shared Message Table",
+	TypeObjectHeaderContinuation: "Object Header Continuation",
+	TypeSymbolTable:              "Symbol Table",
+	TypeObjectModTimeOld:         "Object Modification Time (old)",
+	TypeBTreeKValues:             "B-tree 'K' Values",
+	TypeDriverInfo:               "Driver Info",
+	TypeAttributeInfo:            "Attribute Info",
+	TypeObjectRefCount:           "Object Reference Count",
+	TypeFileSpaceInfo:            "File Space Info",
+}
+
+// String returns the specification name of the message type.
+func (t Type) String() string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown(0x%04x)", uint16(t))
+}
+
 // Message is the interface implemented by all header messages.
 type Message interface {
 	Type() Type
 }
 
-// Parse parses a header message from raw bytes.
+// Parse parses a header message from raw bytes. Bytes after the fields
+// defined by the message's version are ignored.
 func Parse(typ Type, data []byte, flags uint8, r *binary.Reader) (Message, error) {
+	msg, _, err := ParseWithSize(typ, data, flags, r)
+	return msg, err
+}
+
+// ParseWithSize parses a header message like Parse and also returns the
+// number of bytes its fields occupy. Writers may pad a message body past
+// that point, so data[size:] is padding that carries no information.
+//
+// A message flagged FlagShared is returned as the SharedRef its body holds;
+// object.Read resolves those to the messages they refer to.
+func ParseWithSize(typ Type, data []byte, flags uint8, r *binary.Reader) (Message, int, error) {
+	if flags&FlagShared != 0 && sharable(typ) {
+		return parseSharedRef(typ, data, r)
+	}
 	switch typ {
 	case TypeDataspace:
 		return parseDataspace(data, r)
 	case TypeDatatype:
-		return parseDatatype(data, r)
+		return parseDatatypeWithSize(data, r)
 	case TypeDataLayout:
 		return parseDataLayout(data, r)
 	case TypeFilterPipeline:
 		return parseFilterPipeline(data, r)
 	case TypeFillValue:
 		return parseFillValue(data, r)
+	case TypeFillValueOld:
+		return parseFillValueOld(data, r)
+	case TypeExternalDataFiles:
+		return parseExternalFiles(data, r)
 	case TypeAttribute:
 		return parseAttribute(data, r)
+	case TypeAttributeInfo:
+		return parseAttributeInfo(data, r)
+	case TypeLinkInfo:
+		return parseLinkInfo(data, r)
 	case TypeLink:
 		return parseLink(data, r)
 	case TypeSymbolTable:
 		return parseSymbolTable(data, r)
+	case TypeSharedMessageTable:
+		return parseSharedMessageTable(data, r)
+	case TypeDriverInfo:
+		return parseDriverInfo(data, r)
+	case TypeFileSpaceInfo:
+		return parseFileSpaceInfo(data, r)
 	case TypeObjectHeaderContinuation:
-		return ParseContinuation(data, r)
+		msg, err := ParseContinuation(data, r)
+		if err != nil {
+			return nil, 0, err
+		}
+		return msg, r.OffsetSize() + r.LengthSize(), nil
 	default:
 		// Return an unknown message wrapper for unhandled types
-		return &Unknown{typ: typ, data: data}, nil
+		return &Unknown{typ: typ, data: data}, len(data), nil
 	}
 }
 
@@ -91,14 +161,14 @@ func (m *Continuation) Type() Type { return TypeObjectHeaderContinuation }
 
 // ParseContinuation parses a continuation message.
 func ParseContinuation(data []byte, r *binary.Reader) (*Continuation, error) {
-	if len(data) < 2*r.OffsetSize() {
+	offsetSize := r.OffsetSize()
+	lengthSize := r.LengthSize()
+	if len(data) < offsetSize+lengthSize {
 		return nil, fmt.Errorf("continuation message too short")
 	}
 
-	offsetSize := r.OffsetSize()
-
 	offset := decodeUint(data[0:offsetSize], offsetSize, r.ByteOrder())
-	length := decodeUint(data[offsetSize:2*offsetSize], offsetSize, r.ByteOrder())
+	length := decodeUint(data[offsetSize:offsetSize+lengthSize], lengthSize, r.ByteOrder())
 
 	return &Continuation{
 		Offset: offset,
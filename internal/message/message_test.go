@@ -36,7 +36,7 @@ func TestDataspaceScalar(t *testing.T) {
 		0,    // Type = scalar
 	}
 
-	ds, err := parseDataspace(data, mockReader())
+	ds, _, err := parseDataspace(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseDataspace failed: %v", err)
 	}
@@ -68,7 +68,7 @@ func TestDataspaceSimple1D(t *testing.T) {
 
 	binary.LittleEndian.PutUint64(data[4:], 10) // Dimension 0 = 10
 
-	ds, err := parseDataspace(data, mockReader())
+	ds, _, err := parseDataspace(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseDataspace failed: %v", err)
 	}
@@ -98,7 +98,7 @@ func TestDataspaceSimple2D(t *testing.T) {
 	binary.LittleEndian.PutUint64(data[4:], 3)  // Dimension 0 = 3
 	binary.LittleEndian.PutUint64(data[12:], 4) // Dimension 1 = 4
 
-	ds, err := parseDataspace(data, mockReader())
+	ds, _, err := parseDataspace(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseDataspace failed: %v", err)
 	}
@@ -114,7 +114,7 @@ func TestDataspaceSimple2D(t *testing.T) {
 func TestDataspaceNull(t *testing.T) {
 	data := []byte{2, 0, 0, 2} // Version 2, rank 0, flags 0, type = null
 
-	ds, err := parseDataspace(data, mockReader())
+	ds, _, err := parseDataspace(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseDataspace failed: %v", err)
 	}
@@ -217,7 +217,7 @@ func TestDataLayoutContiguous(t *testing.T) {
 	binary.LittleEndian.PutUint64(data[2:], 1024)  // Address
 	binary.LittleEndian.PutUint64(data[10:], 4096) // Size
 
-	layout, err := parseDataLayout(data, mockReader())
+	layout, _, err := parseDataLayout(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseDataLayout failed: %v", err)
 	}
@@ -248,7 +248,7 @@ func TestDataLayoutCompact(t *testing.T) {
 	binary.LittleEndian.PutUint16(data[2:], uint16(len(compactData)))
 	copy(data[4:], compactData)
 
-	layout, err := parseDataLayout(data, mockReader())
+	layout, _, err := parseDataLayout(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseDataLayout failed: %v", err)
 	}
@@ -274,7 +274,7 @@ func TestFilterPipeline(t *testing.T) {
 		0x06, 0x00, 0x00, 0x00, // Client data: level 6
 	}
 
-	fp, err := parseFilterPipeline(data, mockReader())
+	fp, _, err := parseFilterPipeline(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseFilterPipeline failed: %v", err)
 	}
@@ -305,7 +305,7 @@ func TestLinkHard(t *testing.T) {
 	copy(data[4:], name)
 	binary.LittleEndian.PutUint64(data[4+len(name):], 0x1234) // Object address
 
-	link, err := parseLink(data, mockReader())
+	link, _, err := parseLink(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseLink failed: %v", err)
 	}
@@ -329,7 +329,7 @@ func TestSymbolTable(t *testing.T) {
 	binary.LittleEndian.PutUint64(data[0:], 0x1000) // B-tree address
 	binary.LittleEndian.PutUint64(data[8:], 0x2000) // Local heap address
 
-	st, err := parseSymbolTable(data, mockReader())
+	st, _, err := parseSymbolTable(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseSymbolTable failed: %v", err)
 	}
@@ -358,3 +358,48 @@ func TestUnknownMessage(t *testing.T) {
 		t.Errorf("expected type 0x99, got 0x%x", unknown.Type())
 	}
 }
+
+func TestSharedRef(t *testing.T) {
+	addr := binary.LittleEndian.AppendUint64(nil, 0x1234)
+	tests := []struct {
+		name string
+		data []byte
+		want SharedRef
+	}{
+		{"v1", append([]byte{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, addr...),
+			SharedRef{MsgType: TypeDatatype, Version: 1, Location: SharedInHeader, Address: 0x1234}},
+		{"v2", append([]byte{2, 0}, addr...),
+			SharedRef{MsgType: TypeDatatype, Version: 2, Location: SharedInHeader, Address: 0x1234}},
+		{"v3 header", append([]byte{3, SharedInHeader}, addr...),
+			SharedRef{MsgType: TypeDatatype, Version: 3, Location: SharedInHeader, Address: 0x1234}},
+		{"v3 heap", []byte{3, SharedInHeap, 1, 2, 3, 4, 5, 6, 7, 8},
+			SharedRef{MsgType: TypeDatatype, Version: 3, Location: SharedInHeap, HeapID: []byte{1, 2, 3, 4, 5, 6, 7, 8}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := Parse(TypeDatatype, tt.data, FlagShared, mockReader())
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			ref, ok := msg.(*SharedRef)
+			if !ok {
+				t.Fatalf("expected *SharedRef, got %T", msg)
+			}
+			if ref.Type() != TypeDatatype || ref.Version != tt.want.Version || ref.Location != tt.want.Location ||
+				ref.Address != tt.want.Address || string(ref.HeapID) != string(tt.want.HeapID) {
+				t.Errorf("got %+v, want %+v", ref, tt.want)
+			}
+		})
+	}
+
+	if _, err := Parse(TypeDatatype, []byte{3, 9}, FlagShared, mockReader()); err == nil {
+		t.Error("expected an error for an unknown shared message location")
+	}
+
+	// The shared flag of a message that cannot be shared is ignored
+	if msg, err := Parse(Type(0x99), []byte{1}, FlagShared, mockReader()); err != nil {
+		t.Errorf("Parse failed: %v", err)
+	} else if _, ok := msg.(*Unknown); !ok {
+		t.Errorf("expected *Unknown message, got %T", msg)
+	}
+}
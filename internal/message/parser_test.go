@@ -1,10 +1,16 @@
 package message
 
 import (
+	"bytes"
 	"encoding/binary"
+	"errors"
+	"math/rand"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+
+	binpkg "github.com/robert-malhotra/go-hdf5/internal/binary"
 )
 
 // Helper to get testdata path
@@ -23,7 +29,7 @@ func TestDataspaceScalarParsing(t *testing.T) {
 		0, // Type = scalar
 	}
 
-	ds, err := parseDataspace(data, mockReader())
+	ds, _, err := parseDataspace(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseDataspace failed: %v", err)
 	}
@@ -55,7 +61,7 @@ func TestDataspaceSimple1DParsing(t *testing.T) {
 
 	binary.LittleEndian.PutUint64(data[4:], 10) // Dimension 0 = 10
 
-	ds, err := parseDataspace(data, mockReader())
+	ds, _, err := parseDataspace(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseDataspace failed: %v", err)
 	}
@@ -85,7 +91,7 @@ func TestDataspaceSimple2DParsing(t *testing.T) {
 	binary.LittleEndian.PutUint64(data[4:], 3)  // Dimension 0 = 3
 	binary.LittleEndian.PutUint64(data[12:], 4) // Dimension 1 = 4
 
-	ds, err := parseDataspace(data, mockReader())
+	ds, _, err := parseDataspace(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseDataspace failed: %v", err)
 	}
@@ -110,7 +116,7 @@ func TestDataspaceSimple3DParsing(t *testing.T) {
 	binary.LittleEndian.PutUint64(data[12:], 3) // Dimension 1 = 3
 	binary.LittleEndian.PutUint64(data[20:], 4) // Dimension 2 = 4
 
-	ds, err := parseDataspace(data, mockReader())
+	ds, _, err := parseDataspace(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseDataspace failed: %v", err)
 	}
@@ -137,7 +143,7 @@ func TestDataspaceWithMaxDims(t *testing.T) {
 	binary.LittleEndian.PutUint64(data[4:], 10)                // Dimension 0 = 10
 	binary.LittleEndian.PutUint64(data[12:], 0xFFFFFFFFFFFFFFFF) // Max dimension = unlimited
 
-	ds, err := parseDataspace(data, mockReader())
+	ds, _, err := parseDataspace(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseDataspace failed: %v", err)
 	}
@@ -153,7 +159,7 @@ func TestDataspaceWithMaxDims(t *testing.T) {
 func TestDataspaceNullParsing(t *testing.T) {
 	data := []byte{2, 0, 0, 2} // Version 2, rank 0, flags 0, type = null
 
-	ds, err := parseDataspace(data, mockReader())
+	ds, _, err := parseDataspace(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseDataspace failed: %v", err)
 	}
@@ -177,7 +183,7 @@ func TestDataspaceVersion1(t *testing.T) {
 
 	binary.LittleEndian.PutUint64(data[8:], 5) // Dimension 0 = 5
 
-	ds, err := parseDataspace(data, mockReader())
+	ds, _, err := parseDataspace(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseDataspace failed: %v", err)
 	}
@@ -196,12 +202,24 @@ func TestDataspaceVersion1(t *testing.T) {
 func TestDataspaceTooShort(t *testing.T) {
 	data := []byte{2, 0} // Too short
 
-	_, err := parseDataspace(data, mockReader())
+	_, _, err := parseDataspace(data, mockReader())
 	if err == nil {
 		t.Error("expected error for too short data")
 	}
 }
 
+func TestDataspaceTooManyElements(t *testing.T) {
+	// Version 2 simple 2D dataspace whose element count overflows 64 bits
+	data := make([]byte, 4+16)
+	data[0], data[1], data[3] = 2, 2, 1
+	binary.LittleEndian.PutUint64(data[4:], 1<<33)
+	binary.LittleEndian.PutUint64(data[12:], 1<<31)
+
+	if _, _, err := parseDataspace(data, mockReader()); err == nil {
+		t.Error("expected error for a dataspace of 2^64 elements")
+	}
+}
+
 // === DATATYPE TESTS ===
 
 func TestDatatypeInt8(t *testing.T) {
@@ -452,7 +470,7 @@ func TestLayoutContiguousV3(t *testing.T) {
 	binary.LittleEndian.PutUint64(data[2:], 0x1000)       // Address
 	binary.LittleEndian.PutUint64(data[10:], 0x2000)      // Size
 
-	layout, err := parseDataLayout(data, mockReader())
+	layout, _, err := parseDataLayout(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseDataLayout failed: %v", err)
 	}
@@ -482,7 +500,7 @@ func TestLayoutCompactV3(t *testing.T) {
 	binary.LittleEndian.PutUint16(data[2:], uint16(len(compactData)))
 	copy(data[4:], compactData)
 
-	layout, err := parseDataLayout(data, mockReader())
+	layout, _, err := parseDataLayout(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseDataLayout failed: %v", err)
 	}
@@ -501,19 +519,16 @@ func TestLayoutCompactV3(t *testing.T) {
 }
 
 func TestLayoutChunkedV3(t *testing.T) {
-	data := make([]byte, 20)
-	data[0] = 3                                           // Version 3
-	data[1] = byte(LayoutChunked)                         // Chunked
-	data[2] = byte(ChunkIndexBTreeV2)                     // Chunk index type (in flags)
-	data[3] = 2                                           // 2 dimensions
-	data[4] = 4                                           // 4 bytes per dimension size
-	// Chunk dimensions (4 bytes each)
-	binary.LittleEndian.PutUint32(data[5:], 10)           // Chunk dim 0 = 10
-	binary.LittleEndian.PutUint32(data[9:], 10)           // Chunk dim 1 = 10
-	// B-tree address at end
-	binary.LittleEndian.PutUint64(data[12:], 0x3000)
-
-	layout, err := parseDataLayout(data, mockReader())
+	data := make([]byte, 23)
+	data[0] = 3                                     // Version 3
+	data[1] = byte(LayoutChunked)                   // Chunked
+	data[2] = 3                                     // 2 dimensions + element size
+	binary.LittleEndian.PutUint64(data[3:], 0x3000) // B-tree address
+	binary.LittleEndian.PutUint32(data[11:], 10)    // Chunk dim 0 = 10
+	binary.LittleEndian.PutUint32(data[15:], 10)    // Chunk dim 1 = 10
+	binary.LittleEndian.PutUint32(data[19:], 8)     // Element size
+
+	layout, _, err := parseDataLayout(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseDataLayout failed: %v", err)
 	}
@@ -521,37 +536,141 @@ func TestLayoutChunkedV3(t *testing.T) {
 	if !layout.IsChunked() {
 		t.Error("IsChunked should return true")
 	}
-	if len(layout.ChunkDims) != 2 {
-		t.Errorf("expected 2 chunk dims, got %d", len(layout.ChunkDims))
+	if len(layout.ChunkDims) != 3 || layout.ChunkDims[0] != 10 || layout.ChunkDims[2] != 8 {
+		t.Errorf("expected chunk dims [10 10 8], got %v", layout.ChunkDims)
+	}
+	if layout.ChunkIndexAddr != 0x3000 {
+		t.Errorf("expected B-tree address 0x3000, got 0x%x", layout.ChunkIndexAddr)
 	}
 }
 
-func TestLayoutV1V2(t *testing.T) {
-	data := make([]byte, 20)
-	data[0] = 1                                           // Version 1
-	data[1] = 2                                           // 2 dimensions
-	data[2] = byte(LayoutContiguous)                      // Contiguous
-	// Offset and size
-	binary.LittleEndian.PutUint64(data[4:], 0x5000)       // Address
-	binary.LittleEndian.PutUint64(data[12:], 0x1000)      // Size
-
-	layout, err := parseDataLayout(data, mockReader())
+func TestLayoutChunkedV4(t *testing.T) {
+	data := []byte{
+		4,                   // Version 4
+		byte(LayoutChunked), // Chunked
+		0,                   // Flags
+		3,                   // 2 dimensions + element size
+		1,                   // 1 byte per dimension size
+		10, 10, 8,           // Chunk dims
+		byte(ChunkIndexBTreeV2), // Chunk index type
+		0, 2, 0, 0, 100, 40,     // Node size, split and merge percent
+		0, 0x30, 0, 0, 0, 0, 0, 0, // Index address
+	}
+
+	layout, n, err := parseDataLayout(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseDataLayout failed: %v", err)
 	}
+	if n != len(data) {
+		t.Errorf("consumed %d bytes, want %d", n, len(data))
+	}
+	if layout.ChunkIndexType != ChunkIndexBTreeV2 {
+		t.Errorf("expected B-tree v2 index, got %d", layout.ChunkIndexType)
+	}
+	if len(layout.ChunkDims) != 3 || layout.ChunkDims[1] != 10 || layout.ChunkDims[2] != 8 {
+		t.Errorf("expected chunk dims [10 10 8], got %v", layout.ChunkDims)
+	}
+	if layout.ChunkIndexAddr != 0x3000 {
+		t.Errorf("expected index address 0x3000, got 0x%x", layout.ChunkIndexAddr)
+	}
+}
 
+// Layout messages in the version 1 and 2 encoding of HDF5 1.4 and earlier,
+// annotated field by field.
+var (
+	layoutV1Contiguous = []byte{
+		0x01,             // Version 1
+		0x03,             // Dimensionality (rank 2 + element size)
+		0x01,             // Layout class: contiguous
+		0x00, 0, 0, 0, 0, // Reserved
+		0x00, 0x50, 0, 0, 0, 0, 0, 0, // Data address 0x5000
+		0x0a, 0, 0, 0, // Dimension 0: 10
+		0x14, 0, 0, 0, // Dimension 1: 20
+		0x08, 0, 0, 0, // Element size: 8
+	}
+	layoutV1Chunked = []byte{
+		0x01,             // Version 1
+		0x03,             // Dimensionality (rank 2 + element size)
+		0x02,             // Layout class: chunked
+		0x00, 0, 0, 0, 0, // Reserved
+		0x78, 0x05, 0, 0, 0, 0, 0, 0, // B-tree address 0x578
+		0x05, 0, 0, 0, // Chunk dimension 0: 5
+		0x05, 0, 0, 0, // Chunk dimension 1: 5
+		0x08, 0, 0, 0, // Element size: 8
+	}
+	layoutV2Compact = []byte{
+		0x02,             // Version 2
+		0x02,             // Dimensionality (rank 1 + element size)
+		0x00,             // Layout class: compact
+		0x00, 0, 0, 0, 0, // Reserved
+		0x04, 0, 0, 0, // Dimension 0: 4
+		0x02, 0, 0, 0, // Element size: 2
+		0x08, 0, 0, 0, // Compact data size: 8
+		1, 0, 2, 0, 3, 0, 4, 0, // Compact data
+	}
+)
+
+func TestLayoutV1V2(t *testing.T) {
+	layout, n, err := parseDataLayout(layoutV1Contiguous, mockReader())
+	if err != nil {
+		t.Fatalf("parseDataLayout failed: %v", err)
+	}
+	if n != len(layoutV1Contiguous) {
+		t.Errorf("consumed %d bytes, want %d", n, len(layoutV1Contiguous))
+	}
 	if layout.Version != 1 {
 		t.Errorf("expected version 1, got %d", layout.Version)
 	}
-	if layout.Address != 0x5000 {
-		t.Errorf("expected address 0x5000, got 0x%x", layout.Address)
+	if !layout.IsContiguous() || layout.Address != 0x5000 {
+		t.Errorf("expected contiguous at 0x5000, got class %d at 0x%x", layout.Class, layout.Address)
+	}
+	// The size follows from the dataspace and datatype
+	if layout.Size != 0 {
+		t.Errorf("expected no stored size, got %d", layout.Size)
+	}
+}
+
+func TestLayoutV1Chunked(t *testing.T) {
+	layout, n, err := parseDataLayout(layoutV1Chunked, mockReader())
+	if err != nil {
+		t.Fatalf("parseDataLayout failed: %v", err)
+	}
+	if n != len(layoutV1Chunked) {
+		t.Errorf("consumed %d bytes, want %d", n, len(layoutV1Chunked))
+	}
+	if !layout.IsChunked() {
+		t.Fatalf("expected chunked class, got %d", layout.Class)
+	}
+	if layout.ChunkIndexAddr != 0x578 {
+		t.Errorf("expected B-tree address 0x578, got 0x%x", layout.ChunkIndexAddr)
+	}
+	if len(layout.ChunkDims) != 3 || layout.ChunkDims[0] != 5 || layout.ChunkDims[1] != 5 || layout.ChunkDims[2] != 8 {
+		t.Errorf("expected chunk dims [5 5 8], got %v", layout.ChunkDims)
+	}
+}
+
+func TestLayoutV2Compact(t *testing.T) {
+	layout, n, err := parseDataLayout(layoutV2Compact, mockReader())
+	if err != nil {
+		t.Fatalf("parseDataLayout failed: %v", err)
+	}
+	if n != len(layoutV2Compact) {
+		t.Errorf("consumed %d bytes, want %d", n, len(layoutV2Compact))
+	}
+	if !layout.IsCompact() || !bytes.Equal(layout.CompactData, []byte{1, 0, 2, 0, 3, 0, 4, 0}) {
+		t.Errorf("expected compact data [1 0 2 0 3 0 4 0], got class %d with %v", layout.Class, layout.CompactData)
+	}
+
+	// Truncating the data must fail rather than misread the fields
+	if _, _, err := parseDataLayout(layoutV2Compact[:len(layoutV2Compact)-1], mockReader()); err == nil {
+		t.Error("expected error for truncated compact data")
 	}
 }
 
 func TestLayoutTooShort(t *testing.T) {
 	data := []byte{3} // Too short
 
-	_, err := parseDataLayout(data, mockReader())
+	_, _, err := parseDataLayout(data, mockReader())
 	if err == nil {
 		t.Error("expected error for too short data")
 	}
@@ -560,12 +679,154 @@ func TestLayoutTooShort(t *testing.T) {
 func TestLayoutUnsupportedVersion(t *testing.T) {
 	data := []byte{99, 0} // Unsupported version
 
-	_, err := parseDataLayout(data, mockReader())
+	_, _, err := parseDataLayout(data, mockReader())
 	if err == nil {
 		t.Error("expected error for unsupported version")
 	}
 }
 
+func TestLayoutVirtual(t *testing.T) {
+	data := make([]byte, 14)
+	data[0] = 4                                     // Version 4
+	data[1] = byte(LayoutVirtual)                   // Virtual
+	binary.LittleEndian.PutUint64(data[2:], 0x3000) // Global heap collection
+	binary.LittleEndian.PutUint32(data[10:], 2)     // Object index
+
+	layout, n, err := parseDataLayout(data, mockReader())
+	if err != nil {
+		t.Fatalf("parseDataLayout failed: %v", err)
+	}
+	if n != len(data) {
+		t.Errorf("parsed %d bytes, want %d", n, len(data))
+	}
+	if layout.Class != LayoutVirtual || layout.VirtualHeapAddress != 0x3000 || layout.VirtualHeapIndex != 2 {
+		t.Errorf("got class %d, heap %#x index %d", layout.Class, layout.VirtualHeapAddress, layout.VirtualHeapIndex)
+	}
+}
+
+// selectionAll encodes a selection of the whole dataspace.
+func selectionAll() []byte {
+	return binary.LittleEndian.AppendUint32(binary.LittleEndian.AppendUint32(
+		binary.LittleEndian.AppendUint32(binary.LittleEndian.AppendUint32(nil, uint32(SelectAll)), 1), 0), 0)
+}
+
+// selectionBlocks encodes a version 1 hyperslab selection of the blocks
+// given by their first and last elements, alternately.
+func selectionBlocks(corners ...[]uint32) []byte {
+	le := binary.LittleEndian
+	b := le.AppendUint32(le.AppendUint32(nil, uint32(SelectHyperslabs)), 1)
+	b = le.AppendUint32(le.AppendUint32(b, 0), 0) // Reserved and length
+	b = le.AppendUint32(b, uint32(len(corners[0])))
+	b = le.AppendUint32(b, uint32(len(corners)/2))
+	for _, c := range corners {
+		for _, v := range c {
+			b = le.AppendUint32(b, v)
+		}
+	}
+	return b
+}
+
+// virtualMappingList encodes a version 0 mapping list of entries, each the
+// encoded fields of one mapping, with its checksum.
+func virtualMappingList(entries ...[]byte) []byte {
+	b := binary.LittleEndian.AppendUint64([]byte{0}, uint64(len(entries)))
+	for _, e := range entries {
+		b = append(b, e...)
+	}
+	return binary.LittleEndian.AppendUint32(b, binpkg.ChecksumLookup3(b, 0))
+}
+
+func TestParseVirtualMappings(t *testing.T) {
+	le := binary.LittleEndian
+	entry := func(file, dataset string, selections ...[]byte) []byte {
+		b := append([]byte(file+"\x00"), dataset+"\x00"...)
+		for _, s := range selections {
+			b = append(b, s...)
+		}
+		return b
+	}
+
+	// Version 3 regular hyperslab with 2-byte values: start 2, stride 4,
+	// count 3, block 4 in one dimension
+	regularV3 := le.AppendUint32(le.AppendUint32(nil, uint32(SelectHyperslabs)), 3)
+	regularV3 = append(regularV3, 0x01, 2)
+	regularV3 = le.AppendUint32(regularV3, 1)
+	for _, v := range []uint16{2, 4, 3, 4} {
+		regularV3 = le.AppendUint16(regularV3, v)
+	}
+
+	// Version 2 regular hyperslab of unlimited count
+	regularV2 := le.AppendUint32(le.AppendUint32(nil, uint32(SelectHyperslabs)), 2)
+	regularV2 = append(regularV2, 0x01)
+	regularV2 = le.AppendUint32(le.AppendUint32(regularV2, 0), 1)
+	for _, v := range []uint64{0, 5, Unlimited, 5} {
+		regularV2 = le.AppendUint64(regularV2, v)
+	}
+
+	// Version 1 point selection of one point
+	point := le.AppendUint32(le.AppendUint32(nil, uint32(SelectPoints)), 1)
+	point = le.AppendUint32(le.AppendUint32(point, 0), 0)
+	point = le.AppendUint32(le.AppendUint32(point, 2), 1)
+	point = le.AppendUint32(le.AppendUint32(point, 7), 8)
+
+	data := virtualMappingList(
+		entry("a.h5", "/data", selectionAll(), selectionBlocks([]uint32{1, 0}, []uint32{1, 3})),
+		entry(".", "/stack", regularV3, regularV2),
+		entry("b.h5", "/x", point, selectionBlocks([]uint32{0}, []uint32{1}, []uint32{4}, []uint32{5})),
+	)
+	mappings, err := ParseVirtualMappings(data, mockReader())
+	if err != nil {
+		t.Fatalf("ParseVirtualMappings failed: %v", err)
+	}
+	if len(mappings) != 3 {
+		t.Fatalf("got %d mappings, want 3", len(mappings))
+	}
+
+	type box struct {
+		start, count []uint64
+		ok           bool
+	}
+	boxOf := func(s *Selection) box {
+		start, count, err := s.Box()
+		return box{start, count, err == nil}
+	}
+	tests := []struct {
+		file, dataset   string
+		source, virtual box
+	}{
+		{"a.h5", "/data", box{nil, nil, true}, box{[]uint64{1, 0}, []uint64{1, 4}, true}},
+		{".", "/stack", box{[]uint64{2}, []uint64{12}, true}, box{nil, nil, false}},
+		{"b.h5", "/x", box{[]uint64{7, 8}, []uint64{1, 1}, true}, box{nil, nil, false}},
+	}
+	for i, tt := range tests {
+		m := mappings[i]
+		if m.SourceFile != tt.file || m.SourceDataset != tt.dataset {
+			t.Errorf("mapping %d: source %q %q, want %q %q", i, m.SourceFile, m.SourceDataset, tt.file, tt.dataset)
+		}
+		if got := boxOf(m.SourceSelection); !reflect.DeepEqual(got, tt.source) {
+			t.Errorf("mapping %d: source box %+v, want %+v", i, got, tt.source)
+		}
+		if got := boxOf(m.VirtualSelection); !reflect.DeepEqual(got, tt.virtual) {
+			t.Errorf("mapping %d: virtual box %+v, want %+v", i, got, tt.virtual)
+		}
+	}
+	if c := mappings[1].VirtualSelection.Count; len(c) != 1 || c[0] != Unlimited {
+		t.Errorf("unlimited count decoded as %v", c)
+	}
+
+	// A damaged list fails its checksum when checksums are verified
+	data[len(data)-5] ^= 0xff
+	verifying := binpkg.NewReader(bytesReaderAt(make([]byte, 256)), binpkg.Config{
+		ByteOrder: binary.LittleEndian, OffsetSize: 8, LengthSize: 8, VerifyChecksums: true,
+	})
+	if _, err := ParseVirtualMappings(data, verifying); !errors.Is(err, binpkg.ErrChecksumMismatch) {
+		t.Errorf("damaged list: got %v, want checksum mismatch", err)
+	}
+	if _, err := ParseVirtualMappings(data[:20], mockReader()); err == nil {
+		t.Error("expected error for truncated list")
+	}
+}
+
 // === LINK TESTS ===
 
 func TestLinkHardParsing(t *testing.T) {
@@ -578,7 +839,7 @@ func TestLinkHardParsing(t *testing.T) {
 	copy(data[4:], name)
 	binary.LittleEndian.PutUint64(data[4+len(name):], 0x1234)
 
-	link, err := parseLink(data, mockReader())
+	link, _, err := parseLink(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseLink failed: %v", err)
 	}
@@ -610,7 +871,7 @@ func TestLinkSoftParsing(t *testing.T) {
 	binary.LittleEndian.PutUint16(data[offset:], uint16(len(target)))
 	copy(data[offset+2:], target)
 
-	link, err := parseLink(data, mockReader())
+	link, _, err := parseLink(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseLink failed: %v", err)
 	}
@@ -634,7 +895,7 @@ func TestLinkWithCreationOrder(t *testing.T) {
 	copy(data[12:], name)
 	binary.LittleEndian.PutUint64(data[12+len(name):], 0x5678)
 
-	link, err := parseLink(data, mockReader())
+	link, _, err := parseLink(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseLink failed: %v", err)
 	}
@@ -647,7 +908,7 @@ func TestLinkWithCreationOrder(t *testing.T) {
 func TestLinkTooShort(t *testing.T) {
 	data := []byte{1} // Too short
 
-	_, err := parseLink(data, mockReader())
+	_, _, err := parseLink(data, mockReader())
 	if err == nil {
 		t.Error("expected error for too short data")
 	}
@@ -666,7 +927,7 @@ func TestFilterPipelineSingleDeflate(t *testing.T) {
 		0x06, 0x00, 0x00, 0x00, // Client data: level 6
 	}
 
-	fp, err := parseFilterPipeline(data, mockReader())
+	fp, _, err := parseFilterPipeline(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseFilterPipeline failed: %v", err)
 	}
@@ -704,7 +965,7 @@ func TestFilterPipelineShuffleAndDeflate(t *testing.T) {
 		0x09, 0x00, 0x00, 0x00, // Level 9
 	}
 
-	fp, err := parseFilterPipeline(data, mockReader())
+	fp, _, err := parseFilterPipeline(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseFilterPipeline failed: %v", err)
 	}
@@ -733,7 +994,7 @@ func TestFilterPipelineFletcher32(t *testing.T) {
 		0x00, 0x00, // Num client data = 0
 	}
 
-	fp, err := parseFilterPipeline(data, mockReader())
+	fp, _, err := parseFilterPipeline(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseFilterPipeline failed: %v", err)
 	}
@@ -760,7 +1021,7 @@ func TestFilterPipelineOptionalFilter(t *testing.T) {
 		0x00, 0x00, // Num client data = 0
 	}
 
-	fp, err := parseFilterPipeline(data, mockReader())
+	fp, _, err := parseFilterPipeline(data, mockReader())
 	if err != nil {
 		t.Fatalf("parseFilterPipeline failed: %v", err)
 	}
@@ -773,7 +1034,7 @@ func TestFilterPipelineOptionalFilter(t *testing.T) {
 func TestFilterPipelineTooShort(t *testing.T) {
 	data := []byte{2} // Too short
 
-	_, err := parseFilterPipeline(data, mockReader())
+	_, _, err := parseFilterPipeline(data, mockReader())
 	if err == nil {
 		t.Error("expected error for too short data")
 	}
@@ -951,3 +1212,521 @@ func TestMockReader(t *testing.T) {
 		t.Errorf("expected length size 8, got %d", r.LengthSize())
 	}
 }
+
+// === EXTERNAL DATA FILES TESTS ===
+
+func TestExternalFilesParsing(t *testing.T) {
+	data := make([]byte, 16+2*24)
+	data[0] = 1                                    // Version
+	binary.LittleEndian.PutUint16(data[4:], 4)     // Allocated slots
+	binary.LittleEndian.PutUint16(data[6:], 2)     // Used slots
+	binary.LittleEndian.PutUint64(data[8:], 0x400) // Heap address
+	binary.LittleEndian.PutUint64(data[16:], 8)    // Slot 0 name offset
+	binary.LittleEndian.PutUint64(data[24:], 0)    // Slot 0 file offset
+	binary.LittleEndian.PutUint64(data[32:], 100)  // Slot 0 size
+	binary.LittleEndian.PutUint64(data[40:], 24)   // Slot 1 name offset
+	binary.LittleEndian.PutUint64(data[48:], 512)  // Slot 1 file offset
+	binary.LittleEndian.PutUint64(data[56:], 0xFFFFFFFFFFFFFFFF)
+
+	ef, _, err := parseExternalFiles(data, mockReader())
+	if err != nil {
+		t.Fatalf("parseExternalFiles failed: %v", err)
+	}
+	if ef.HeapAddress != 0x400 {
+		t.Errorf("expected heap address 0x400, got 0x%x", ef.HeapAddress)
+	}
+	if len(ef.Slots) != 2 {
+		t.Fatalf("expected 2 slots, got %d", len(ef.Slots))
+	}
+	if ef.Slots[0] != (ExternalFileSlot{NameOffset: 8, Offset: 0, Size: 100}) {
+		t.Errorf("slot 0: got %+v", ef.Slots[0])
+	}
+	if ef.Slots[1].Size != ExternalSizeUnlimited || ef.Slots[1].Offset != 512 {
+		t.Errorf("slot 1: got %+v", ef.Slots[1])
+	}
+
+	if _, _, err := parseExternalFiles(data[:40], mockReader()); err == nil {
+		t.Error("expected error for truncated slot list")
+	}
+}
+
+// === FILL VALUE TESTS ===
+
+func TestFillValueParsing(t *testing.T) {
+	u32 := func(v uint32) []byte { return binary.LittleEndian.AppendUint32(nil, v) }
+	nan := binary.LittleEndian.AppendUint64(nil, 0x7FF8000000000000)
+
+	tests := []struct {
+		name    string
+		typ     Type
+		data    []byte
+		defined bool
+		value   []byte
+	}{
+		{"v1 defined", TypeFillValue, append(append([]byte{1, 2, 2, 1}, u32(8)...), nan...), true, nan},
+		{"v1 undefined keeps size", TypeFillValue, append(append([]byte{1, 2, 2, 0}, u32(8)...), nan...), false, nil},
+		{"v2 defined", TypeFillValue, append(append([]byte{2, 2, 2, 1}, u32(8)...), nan...), true, nan},
+		{"v2 default", TypeFillValue, append([]byte{2, 2, 2, 1}, u32(0)...), true, nil},
+		{"v2 undefined", TypeFillValue, []byte{2, 2, 2, 0}, false, nil},
+		{"v3 user defined", TypeFillValue, append(append([]byte{3, 0x2A}, u32(8)...), nan...), true, nan},
+		{"v3 default", TypeFillValue, []byte{3, 0x0A}, true, nil},
+		{"v3 undefined", TypeFillValue, []byte{3, 0x1A}, false, nil},
+		{"old", TypeFillValueOld, append(u32(8), nan...), true, nan},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, size, err := ParseWithSize(tt.typ, tt.data, 0, mockReader())
+			if err != nil {
+				t.Fatalf("ParseWithSize failed: %v", err)
+			}
+			if size != len(tt.data) {
+				t.Errorf("parsed %d bytes, want %d", size, len(tt.data))
+			}
+			var defined bool
+			var value []byte
+			switch fv := msg.(type) {
+			case *FillValue:
+				defined, value = fv.IsDefined, fv.Value
+			case *FillValueOld:
+				defined, value = true, fv.Value
+			default:
+				t.Fatalf("parsed as %T", msg)
+			}
+			if defined != tt.defined || !bytes.Equal(value, tt.value) {
+				t.Errorf("got defined=%v value=%x, want defined=%v value=%x", defined, value, tt.defined, tt.value)
+			}
+		})
+	}
+
+	if _, err := Parse(TypeFillValueOld, append(u32(8), 1, 2), 0, mockReader()); err == nil {
+		t.Error("expected error for truncated old fill value")
+	}
+}
+
+// === TRAILING BYTES TESTS ===
+
+// serializeMessage returns the body a message serializes to.
+func serializeMessage(t *testing.T, m interface {
+	Serialize(w *binpkg.Writer) error
+}) []byte {
+	t.Helper()
+	buf := newBytesWriterAt(0)
+	w := binpkg.NewWriter(buf, binpkg.DefaultConfig())
+	if err := m.Serialize(w); err != nil {
+		t.Fatalf("Serialize failed: %v", err)
+	}
+	return buf.Bytes()[:w.Pos()]
+}
+
+// trailingBytesBodies returns valid message bodies covering every parser
+// and the version-specific fields each one has to account for.
+func trailingBytesBodies(t *testing.T) []struct {
+	name string
+	typ  Type
+	body []byte
+} {
+	i32 := NewFixedPointDatatype(4, true, OrderLE)
+	f64 := NewFloatDatatype(8, OrderLE)
+	nested := NewCompoundDatatype(8+24+8, []CompoundMember{
+		{Name: "gain", ByteOffset: 0, Type: f64},
+		{Name: "offset", ByteOffset: 8, Type: NewArrayDatatype([]uint32{3}, f64)},
+		{Name: "window", ByteOffset: 32, Type: NewCompoundDatatype(8, []CompoundMember{
+			{Name: "start", ByteOffset: 0, Type: i32},
+			{Name: "stop", ByteOffset: 4, Type: i32},
+		})},
+	})
+
+	int8Type := []byte{0x10, 0x08, 0, 0, 1, 0, 0, 0, 0, 0, 8, 0}
+	int32Type := []byte{0x10, 0x08, 0, 0, 4, 0, 0, 0, 0, 0, 32, 0}
+	join := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+	u16 := func(v uint16) []byte { return binary.LittleEndian.AppendUint16(nil, v) }
+	u32 := func(v uint32) []byte { return binary.LittleEndian.AppendUint32(nil, v) }
+	u64 := func(v uint64) []byte { return binary.LittleEndian.AppendUint64(nil, v) }
+
+	return []struct {
+		name string
+		typ  Type
+		body []byte
+	}{
+		{"dataspace v2", TypeDataspace, serializeMessage(t, NewDataspace([]uint64{3, 4}, []uint64{10, 0xFFFFFFFFFFFFFFFF}))},
+		{"dataspace v2 scalar", TypeDataspace, serializeMessage(t, NewScalarDataspace())},
+		{"dataspace v1", TypeDataspace, join([]byte{1, 2, 1, 0, 0, 0, 0, 0}, u64(3), u64(4), u64(3), u64(8))},
+		{"dataspace v1 scalar", TypeDataspace, []byte{1, 0, 0, 0, 0, 0, 0, 0}},
+		{"datatype int32", TypeDatatype, int32Type},
+		{"datatype float64", TypeDatatype, serializeMessage(t, f64)},
+		{"datatype string", TypeDatatype, serializeMessage(t, NewStringDatatype(16, PadNullPad, CharsetUTF8))},
+		{"datatype varlen string", TypeDatatype, serializeMessage(t, NewVarLenStringDatatype(CharsetASCII))},
+		{"datatype compound v3", TypeDatatype, serializeMessage(t, nested)},
+		{"datatype compound v1", TypeDatatype, join(
+			[]byte{0x16, 2, 0, 0}, u32(16),
+			[]byte("x\x00\x00\x00\x00\x00\x00\x00"), u32(0), make([]byte, 28), int32Type,
+			[]byte("v\x00\x00\x00\x00\x00\x00\x00"), u32(4), []byte{1, 0, 0, 0}, make([]byte, 8), u32(3), make([]byte, 12), int32Type)},
+		{"datatype enum v1", TypeDatatype, join(
+			[]byte{0x18, 2, 0, 0}, u32(1), int8Type,
+			[]byte("RED\x00\x00\x00\x00\x00GREEN\x00\x00\x00"), []byte{0, 1})},
+		{"datatype enum v3", TypeDatatype, serializeMessage(t, NewEnumDatatype(NewFixedPointDatatype(2, false, OrderBE),
+			[]string{"OFF", "ON", "AUTO"}, []int64{0, 1, 0x100}))},
+		{"datatype opaque", TypeDatatype, join([]byte{0x15, 8, 0, 0}, u32(4), []byte("tag\x00\x00\x00\x00\x00"))},
+		{"layout v3 contiguous", TypeDataLayout, serializeMessage(t, NewContiguousLayout(0x800, 96))},
+		{"layout v3 compact", TypeDataLayout, serializeMessage(t, NewCompactLayout([]byte{1, 2, 3, 4, 5}))},
+		{"layout v1 contiguous", TypeDataLayout, layoutV1Contiguous},
+		{"layout v1 chunked", TypeDataLayout, layoutV1Chunked},
+		{"layout v2 compact", TypeDataLayout, layoutV2Compact},
+		{"layout v3 chunked", TypeDataLayout, join([]byte{3, 2, 3}, u64(0x3000), u32(10), u32(10), u32(8))},
+		{"layout v4 fixed array", TypeDataLayout, serializeMessage(t, NewChunkedLayout([]uint32{10, 10}, 8, ChunkIndexFixedArray))},
+		{"layout v4 extensible array", TypeDataLayout, serializeMessage(t, NewChunkedLayout([]uint32{300}, 4, ChunkIndexExtensibleArray))},
+		{"layout v4 filtered single chunk", TypeDataLayout, join(
+			[]byte{4, 2, 0x02, 2, 1, 10, 8, byte(ChunkIndexSingleChunk)}, u64(421), u32(0), u64(0x3000))},
+		{"filter pipeline v1", TypeFilterPipeline, join(
+			[]byte{1, 2, 0, 0, 0, 0, 0, 0},
+			u16(FilterShuffle), u16(0), u16(0), u16(1), u32(8), make([]byte, 4),
+			u16(FilterDeflate), u16(8), u16(1), u16(1), []byte("deflate\x00"), u32(6), make([]byte, 4))},
+		{"filter pipeline v2", TypeFilterPipeline, join(
+			[]byte{2, 3},
+			u16(FilterShuffle), u16(0), u16(1), u32(8),
+			u16(FilterDeflate), u16(0), u16(1), u32(6),
+			u16(32001), u16(5), u16(1), u16(0), []byte("blosc"))},
+		{"fill value v2", TypeFillValue, join([]byte{2, 2, 2, 1}, u32(8), u64(0x7FF8000000000000))},
+		{"fill value v2 undefined", TypeFillValue, []byte{2, 2, 2, 0}},
+		{"fill value v3", TypeFillValue, join([]byte{3, 0x2A}, u32(4), u32(0xFFFFFFFF))},
+		{"fill value v1 undefined", TypeFillValue, join([]byte{1, 2, 2, 0}, u32(2), u16(7))},
+		{"fill value old", TypeFillValueOld, join(u32(4), u32(0xC61C3C00))},
+		{"external files", TypeExternalDataFiles, join(
+			[]byte{1, 0, 0, 0}, u16(2), u16(1), u64(0x400), u64(8), u64(0), u64(100), make([]byte, 24))},
+		{"attribute v3", TypeAttribute, serializeMessage(t, NewScalarAttribute("units", NewStringDatatype(4, PadNullPad, CharsetASCII), []byte("degC")))},
+		{"attribute v1", TypeAttribute, join(
+			[]byte{1, 0}, u16(6), u16(12), u16(8),
+			[]byte("scale\x00\x00\x00"), int32Type, make([]byte, 4), []byte{1, 0, 0, 0, 0, 0, 0, 0}, u32(42))},
+		{"link hard", TypeLink, serializeMessage(t, NewHardLink("data", 0x800))},
+		{"link soft", TypeLink, serializeMessage(t, NewSoftLink("alias", "/group/data"))},
+		{"link external", TypeLink, serializeMessage(t, NewExternalLink("ext", "other.h5", "/data"))},
+		{"symbol table", TypeSymbolTable, join(u64(0x200), u64(0x300))},
+		{"shared message table", TypeSharedMessageTable, join([]byte{0}, u64(0x180), []byte{2})},
+		{"driver info", TypeDriverInfo, join([]byte{0}, []byte("NCSAmult"), u16(4), []byte{1, 2, 3, 4})},
+		{"file space info v0", TypeFileSpaceInfo, join([]byte{0, 1}, u64(1), u64(0x400), u64(0x500), u64(0x600), u64(0x700), u64(0x800), u64(0x900))},
+		{"file space info v1", TypeFileSpaceInfo, join([]byte{1, 1, 0}, u64(1), u64(4096), u16(0), u64(0x10000))},
+		{"continuation", TypeObjectHeaderContinuation, join(u64(0x1000), u64(0x200))},
+	}
+}
+
+func TestParseIgnoresTrailingBytes(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for _, tt := range trailingBytesBodies(t) {
+		t.Run(tt.name, func(t *testing.T) {
+			want, size, err := ParseWithSize(tt.typ, tt.body, 0, mockReader())
+			if err != nil {
+				t.Fatalf("ParseWithSize failed: %v", err)
+			}
+			if size != len(tt.body) {
+				t.Errorf("consumed %d bytes of a %d-byte body", size, len(tt.body))
+			}
+
+			for n := 1; n <= 7; n++ {
+				junk := make([]byte, n)
+				for i := range junk {
+					junk[i] = byte(rng.Intn(255) + 1)
+				}
+				padded := append(bytes.Clone(tt.body), junk...)
+
+				got, paddedSize, err := ParseWithSize(tt.typ, padded, 0, mockReader())
+				if err != nil {
+					t.Fatalf("%d trailing bytes: ParseWithSize failed: %v", n, err)
+				}
+				if paddedSize != size {
+					t.Errorf("%d trailing bytes: consumed %d bytes, want %d", n, paddedSize, size)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("%d trailing bytes: parsed %+v, want %+v", n, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseCompoundV1ArrayMember(t *testing.T) {
+	var body []byte
+	for _, tt := range trailingBytesBodies(t) {
+		if tt.name == "datatype compound v1" {
+			body = tt.body
+		}
+	}
+
+	dt, err := parseDatatype(body, mockReader())
+	if err != nil {
+		t.Fatalf("parseDatatype failed: %v", err)
+	}
+	if len(dt.Members) != 2 {
+		t.Fatalf("expected 2 members, got %d", len(dt.Members))
+	}
+	if m := dt.Members[0]; m.Name != "x" || m.Type.Class != ClassFixedPoint {
+		t.Errorf("member 0 parsed as %q of class %d", m.Name, m.Type.Class)
+	}
+	v := dt.Members[1].Type
+	if v.Class != ClassArray || v.Size != 12 || len(v.ArrayDims) != 1 || v.ArrayDims[0] != 3 || v.BaseType.Size != 4 {
+		t.Errorf("array member parsed as %+v", v)
+	}
+}
+
+func TestParseEnumMembers(t *testing.T) {
+	bodies := make(map[string][]byte)
+	for _, tt := range trailingBytesBodies(t) {
+		bodies[tt.name] = tt.body
+	}
+
+	// Version 1 pads names to 8 bytes
+	dt, err := parseDatatype(bodies["datatype enum v1"], mockReader())
+	if err != nil {
+		t.Fatalf("parseDatatype failed: %v", err)
+	}
+	if dt.BaseType == nil || dt.BaseType.Size != 1 || !dt.BaseType.Signed {
+		t.Errorf("enum base type parsed as %+v", dt.BaseType)
+	}
+	if !reflect.DeepEqual(dt.EnumNames, []string{"RED", "GREEN"}) ||
+		!reflect.DeepEqual(dt.EnumValues, [][]byte{{0}, {1}}) {
+		t.Errorf("enum members parsed as %q = %v", dt.EnumNames, dt.EnumValues)
+	}
+
+	// Version 3 round-trips through Serialize
+	dt, err = parseDatatype(bodies["datatype enum v3"], mockReader())
+	if err != nil {
+		t.Fatalf("parseDatatype failed: %v", err)
+	}
+	if !reflect.DeepEqual(dt.EnumNames, []string{"OFF", "ON", "AUTO"}) ||
+		!reflect.DeepEqual(dt.EnumValues, [][]byte{{0, 0}, {0, 1}, {1, 0}}) || dt.BaseType.ByteOrder != OrderBE {
+		t.Errorf("enum members parsed as %q = %v", dt.EnumNames, dt.EnumValues)
+	}
+
+	if _, err := parseDatatype(bodies["datatype enum v3"][:len(bodies["datatype enum v3"])-1], mockReader()); err == nil {
+		t.Error("parseDatatype accepted truncated enum values")
+	}
+}
+
+// Attributes of rank 2 and 3 parse in every attribute message version:
+// version 1 pads the name, datatype and dataspace to multiples of 8 bytes,
+// versions 2 and 3 do not, and the dataspace may be version 1 or 2.
+func TestParseAttributeMultiDim(t *testing.T) {
+	join := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+	u16 := func(v int) []byte { return binary.LittleEndian.AppendUint16(nil, uint16(v)) }
+	pad8 := func(b []byte) []byte { return append(b, make([]byte, (8-len(b)%8)%8)...) }
+	f64Type := serializeMessage(t, NewFloatDatatype(8, OrderLE))
+	int32Type := []byte{0x10, 0x08, 0, 0, 4, 0, 0, 0, 0, 0, 32, 0}
+	spaceV1 := func(dims ...uint64) []byte {
+		b := []byte{1, byte(len(dims)), 0, 0, 0, 0, 0, 0}
+		for _, d := range dims {
+			b = binary.LittleEndian.AppendUint64(b, d)
+		}
+		return b
+	}
+	spaceV2 := func(dims ...uint64) []byte {
+		b := []byte{2, byte(len(dims)), 0, byte(DataspaceSimple)}
+		for _, d := range dims {
+			b = binary.LittleEndian.AppendUint64(b, d)
+		}
+		return b
+	}
+	values := func(n, size int) []byte {
+		b := make([]byte, n*size)
+		for i := range b {
+			b[i] = byte(i)
+		}
+		return b
+	}
+	name := func(s string) []byte { return append([]byte(s), 0) }
+	attrV1 := func(n string, dt, space, data []byte) []byte {
+		return join([]byte{1, 0}, u16(len(n)+1), u16(len(dt)), u16(len(space)),
+			pad8(name(n)), pad8(dt), pad8(space), data)
+	}
+	attrV2 := func(n string, dt, space, data []byte) []byte {
+		return join([]byte{2, 0}, u16(len(n)+1), u16(len(dt)), u16(len(space)), name(n), dt, space, data)
+	}
+	attrV3 := func(n string, dt, space, data []byte) []byte {
+		return join([]byte{3, 0}, u16(len(n)+1), u16(len(dt)), u16(len(space)), []byte{0}, name(n), dt, space, data)
+	}
+
+	tests := []struct {
+		name string
+		body []byte
+		dims []uint64
+		size int
+	}{
+		{"v1 2-D", attrV1("matrix", f64Type, spaceV1(2, 3), values(6, 8)), []uint64{2, 3}, 8},
+		{"v1 3-D", attrV1("cube", int32Type, spaceV1(2, 2, 2), values(8, 4)), []uint64{2, 2, 2}, 4},
+		{"v2 2-D", attrV2("matrix", f64Type, spaceV1(2, 3), values(6, 8)), []uint64{2, 3}, 8},
+		{"v2 3-D", attrV2("cube", int32Type, spaceV2(4, 3, 2), values(24, 4)), []uint64{4, 3, 2}, 4},
+		{"v3 2-D", attrV3("matrix", f64Type, spaceV2(3, 2), values(6, 8)), []uint64{3, 2}, 8},
+		{"v3 3-D", attrV3("cube", int32Type, spaceV1(2, 3, 4), values(24, 4)), []uint64{2, 3, 4}, 4},
+		{"v3 serialized", serializeMessage(t, NewAttribute("grid", NewFloatDatatype(8, OrderLE),
+			NewDataspace([]uint64{2, 1, 3}, nil), values(6, 8))), []uint64{2, 1, 3}, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, size, err := ParseWithSize(TypeAttribute, tt.body, 0, mockReader())
+			if err != nil {
+				t.Fatalf("ParseWithSize failed: %v", err)
+			}
+			attr := msg.(*Attribute)
+			if size != len(tt.body) {
+				t.Errorf("consumed %d bytes of a %d-byte body", size, len(tt.body))
+			}
+			if attr.Dataspace == nil || attr.Dataspace.Rank != len(tt.dims) ||
+				!reflect.DeepEqual(attr.Dataspace.Dimensions, tt.dims) {
+				t.Fatalf("dataspace = %+v, want dimensions %v", attr.Dataspace, tt.dims)
+			}
+			if attr.Datatype == nil || int(attr.Datatype.Size) != tt.size {
+				t.Fatalf("datatype = %+v, want size %d", attr.Datatype, tt.size)
+			}
+			n := int(attr.Dataspace.NumElements())
+			if want := values(n, tt.size); !bytes.Equal(attr.Data, want) {
+				t.Errorf("data = %v, want %v", attr.Data, want)
+			}
+		})
+	}
+}
+
+func TestParseAttributeUTF8Name(t *testing.T) {
+	dt := NewFloatDatatype(8, OrderLE)
+	for _, name := range []string{"température", "привет", "温度", "units"} {
+		t.Run(name, func(t *testing.T) {
+			want := CharsetUTF8
+			if name == "units" {
+				want = CharsetASCII
+			}
+			orig := NewScalarAttribute(name, dt, make([]byte, 8))
+			if orig.NameCharset != want {
+				t.Errorf("NewScalarAttribute charset = %d, want %d", orig.NameCharset, want)
+			}
+			body := serializeMessage(t, orig)
+			if body[8] != byte(want) {
+				t.Errorf("encoding byte = %d, want %d", body[8], want)
+			}
+
+			msg, size, err := ParseWithSize(TypeAttribute, body, 0, mockReader())
+			if err != nil {
+				t.Fatalf("ParseWithSize failed: %v", err)
+			}
+			attr := msg.(*Attribute)
+			if attr.Name != name || attr.NameCharset != want || size != len(body) {
+				t.Errorf("parsed name %q, charset %d, size %d; want %q, %d, %d",
+					attr.Name, attr.NameCharset, size, name, want, len(body))
+			}
+		})
+	}
+
+	// Version 1 and 2 messages carry no character set, but UTF-8 bytes in
+	// their names are kept as they are
+	name := "привет"
+	dtBytes := serializeMessage(t, dt)
+	space := []byte{1, 0, 0, 0, 0, 0, 0, 0}
+	nameBytes := append([]byte(name), 0)
+	v2 := append([]byte{2, 0, byte(len(nameBytes)), 0, byte(len(dtBytes)), 0, byte(len(space)), 0}, nameBytes...)
+	v2 = append(append(append(v2, dtBytes...), space...), make([]byte, 8)...)
+	msg, err := Parse(TypeAttribute, v2, 0, mockReader())
+	if err != nil {
+		t.Fatalf("Parse v2 failed: %v", err)
+	}
+	if attr := msg.(*Attribute); attr.Name != name || attr.NameCharset != CharsetASCII {
+		t.Errorf("v2 name %q, charset %d", attr.Name, attr.NameCharset)
+	}
+}
+
+func TestParseDriverInfo(t *testing.T) {
+	data := append([]byte{0}, "NCSAfami"...)
+	data = binary.LittleEndian.AppendUint16(data, 8)
+	data = binary.LittleEndian.AppendUint64(data, 1<<30)
+
+	msg, err := Parse(TypeDriverInfo, data, 0, mockReader())
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	info, ok := msg.(*DriverInfo)
+	if !ok {
+		t.Fatalf("expected *DriverInfo message, got %T", msg)
+	}
+	if info.Driver != "NCSAfami" || !bytes.Equal(info.Info, data[11:]) {
+		t.Errorf("driver info = %+v", info)
+	}
+
+	if _, err := Parse(TypeDriverInfo, data[:15], 0, mockReader()); err == nil {
+		t.Error("Parse succeeded on truncated driver info")
+	}
+	data[0] = 1
+	if _, err := Parse(TypeDriverInfo, data, 0, mockReader()); err == nil {
+		t.Error("Parse succeeded on driver info version 1")
+	}
+}
+
+func TestParseFileSpaceInfo(t *testing.T) {
+	le := binary.LittleEndian
+	managers := func(data []byte, n int) []byte {
+		for i := range n {
+			data = le.AppendUint64(data, 0x1000*uint64(i+1))
+		}
+		return data
+	}
+
+	// Version 1 with persistent free space: 12 managers
+	v1 := []byte{1, byte(FileSpacePage), 1}
+	v1 = le.AppendUint64(v1, 1)
+	v1 = le.AppendUint64(v1, 4096)
+	v1 = le.AppendUint16(v1, 64)
+	v1 = managers(le.AppendUint64(v1, 0x20000), 12)
+	msg, err := Parse(TypeFileSpaceInfo, v1, 0, mockReader())
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	info, ok := msg.(*FileSpaceInfo)
+	if !ok {
+		t.Fatalf("expected *FileSpaceInfo message, got %T", msg)
+	}
+	if info.Strategy != FileSpacePage || !info.Persist || info.Threshold != 1 || info.PageSize != 4096 ||
+		info.PageEndThreshold != 64 || info.EOA != 0x20000 {
+		t.Errorf("file space info = %+v", info)
+	}
+	if len(info.Managers) != 12 || info.Managers[0] != 0x1000 || info.Managers[11] != 0xC000 {
+		t.Errorf("managers = %#x, want 12", info.Managers)
+	}
+	if _, err := Parse(TypeFileSpaceInfo, v1[:len(v1)-8], 0, mockReader()); err == nil {
+		t.Error("Parse succeeded without the last manager address")
+	}
+
+	// Version 0 strategies map to those of version 1
+	for _, tt := range []struct {
+		strategy byte
+		want     FileSpaceStrategy
+		persist  bool
+	}{
+		{1, FileSpaceFSMAggr, true},
+		{2, FileSpaceFSMAggr, false},
+		{3, FileSpaceAggr, false},
+		{4, FileSpaceNone, false},
+	} {
+		v0 := le.AppendUint64([]byte{0, tt.strategy}, 1)
+		if tt.persist {
+			v0 = managers(v0, 6)
+		}
+		msg, err := Parse(TypeFileSpaceInfo, v0, 0, mockReader())
+		if err != nil {
+			t.Fatalf("strategy %d: Parse failed: %v", tt.strategy, err)
+		}
+		info := msg.(*FileSpaceInfo)
+		if info.Strategy != tt.want || info.Persist != tt.persist || info.EOA != UndefinedAddress {
+			t.Errorf("strategy %d: file space info = %+v", tt.strategy, info)
+		}
+		if want := map[bool]int{true: 6}[tt.persist]; len(info.Managers) != want {
+			t.Errorf("strategy %d: %d managers, want %d", tt.strategy, len(info.Managers), want)
+		}
+	}
+
+	for _, data := range [][]byte{
+		le.AppendUint64([]byte{0, 5}, 1),    // Unknown version 0 strategy
+		{1, 4, 0},                           // Unknown strategy
+		{2, 0},                              // Unknown version
+		le.AppendUint64([]byte{1, 0, 0}, 1), // Truncated
+	} {
+		if _, err := Parse(TypeFileSpaceInfo, data, 0, mockReader()); err == nil {
+			t.Errorf("Parse(%v) succeeded", data)
+		}
+	}
+}
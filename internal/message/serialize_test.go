@@ -84,7 +84,7 @@ func TestDataspaceSerialize(t *testing.T) {
 
 			// Verify round-trip by parsing
 			r := binpkg.NewReader(bytes.NewReader(buf.Bytes()), cfg)
-			parsed, err := parseDataspace(buf.Bytes()[:w.Pos()], r)
+			parsed, _, err := parseDataspace(buf.Bytes()[:w.Pos()], r)
 			if err != nil {
 				t.Fatalf("parseDataspace failed: %v", err)
 			}
@@ -121,7 +121,7 @@ func TestLinkSerialize(t *testing.T) {
 
 	// Verify round-trip
 	r := binpkg.NewReader(bytes.NewReader(buf.Bytes()), cfg)
-	parsed, err := parseLink(buf.Bytes()[:w.Pos()], r)
+	parsed, _, err := parseLink(buf.Bytes()[:w.Pos()], r)
 	if err != nil {
 		t.Fatalf("parseLink failed: %v", err)
 	}
@@ -150,7 +150,7 @@ func TestSoftLinkSerialize(t *testing.T) {
 
 	// Verify round-trip
 	r := binpkg.NewReader(bytes.NewReader(buf.Bytes()), cfg)
-	parsed, err := parseLink(buf.Bytes()[:w.Pos()], r)
+	parsed, _, err := parseLink(buf.Bytes()[:w.Pos()], r)
 	if err != nil {
 		t.Fatalf("parseLink failed: %v", err)
 	}
@@ -191,6 +191,14 @@ func TestDatatypeSerialize(t *testing.T) {
 			name:     "fixed string",
 			datatype: NewStringDatatype(16, PadNullTerm, CharsetUTF8),
 		},
+		{
+			name:     "bitfield",
+			datatype: NewBitfieldDatatype(2, OrderBE),
+		},
+		{
+			name:     "opaque",
+			datatype: NewOpaqueDatatype(12, "image/png"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -217,10 +225,63 @@ func TestDatatypeSerialize(t *testing.T) {
 			if parsed.Size != tt.datatype.Size {
 				t.Errorf("expected size %d, got %d", tt.datatype.Size, parsed.Size)
 			}
+			if parsed.ByteOrder != tt.datatype.ByteOrder || parsed.BitPrecision != tt.datatype.BitPrecision {
+				t.Errorf("expected order %d and precision %d, got %d and %d",
+					tt.datatype.ByteOrder, tt.datatype.BitPrecision, parsed.ByteOrder, parsed.BitPrecision)
+			}
+			if parsed.OpaqueTag != tt.datatype.OpaqueTag {
+				t.Errorf("expected tag %q, got %q", tt.datatype.OpaqueTag, parsed.OpaqueTag)
+			}
 		})
 	}
 }
 
+func TestCompoundDatatypeSerializeNested(t *testing.T) {
+	f64 := NewFloatDatatype(8, OrderLE)
+	i32 := NewFixedPointDatatype(4, true, OrderLE)
+	inner := NewCompoundDatatype(8, []CompoundMember{
+		{Name: "start", ByteOffset: 0, Type: i32},
+		{Name: "stop", ByteOffset: 4, Type: i32},
+	})
+	dt := NewCompoundDatatype(8+24+8+4, []CompoundMember{
+		{Name: "gain", ByteOffset: 0, Type: f64},
+		{Name: "offset", ByteOffset: 8, Type: NewArrayDatatype([]uint32{3}, f64)},
+		{Name: "window", ByteOffset: 32, Type: inner},
+		{Name: "id", ByteOffset: 40, Type: i32},
+	})
+
+	buf := newBytesWriterAt(256)
+	cfg := binpkg.DefaultConfig()
+	w := binpkg.NewWriter(buf, cfg)
+	if err := dt.Serialize(w); err != nil {
+		t.Fatalf("Serialize failed: %v", err)
+	}
+	if int(w.Pos()) != dt.SerializedSize(w) {
+		t.Errorf("wrote %d bytes, SerializedSize reports %d", w.Pos(), dt.SerializedSize(w))
+	}
+
+	r := binpkg.NewReader(bytes.NewReader(buf.Bytes()), cfg)
+	parsed, err := parseDatatype(buf.Bytes()[:w.Pos()], r)
+	if err != nil {
+		t.Fatalf("parseDatatype failed: %v", err)
+	}
+
+	// Members after the nested compound are only found if its size is exact
+	if len(parsed.Members) != 4 {
+		t.Fatalf("expected 4 members, got %d", len(parsed.Members))
+	}
+	arr := parsed.Members[1].Type
+	if arr.Class != ClassArray || len(arr.ArrayDims) != 1 || arr.ArrayDims[0] != 3 || arr.BaseType == nil || arr.BaseType.Size != 8 {
+		t.Errorf("array member parsed as %+v", arr)
+	}
+	if n := len(parsed.Members[2].Type.Members); n != 2 {
+		t.Errorf("nested compound has %d members, want 2", n)
+	}
+	if m := parsed.Members[3]; m.Name != "id" || m.ByteOffset != 40 {
+		t.Errorf("last member parsed as %q at %d", m.Name, m.ByteOffset)
+	}
+}
+
 func TestLayoutSerializeContiguous(t *testing.T) {
 	buf := newBytesWriterAt(256)
 	cfg := binpkg.DefaultConfig()
@@ -240,7 +301,7 @@ func TestLayoutSerializeContiguous(t *testing.T) {
 
 	// Verify round-trip
 	r := binpkg.NewReader(bytes.NewReader(buf.Bytes()), cfg)
-	parsed, err := parseDataLayout(buf.Bytes()[:w.Pos()], r)
+	parsed, _, err := parseDataLayout(buf.Bytes()[:w.Pos()], r)
 	if err != nil {
 		t.Fatalf("parseDataLayout failed: %v", err)
 	}
@@ -256,6 +317,42 @@ func TestLayoutSerializeContiguous(t *testing.T) {
 	}
 }
 
+func TestFilterPipelineSerialize(t *testing.T) {
+	buf := newBytesWriterAt(256)
+	cfg := binpkg.DefaultConfig()
+	w := binpkg.NewWriter(buf, cfg)
+
+	fp := NewFilterPipeline(
+		FilterInfo{ID: FilterShuffle, Flags: 0x01, ClientData: []uint32{8}},
+		FilterInfo{ID: FilterDeflate, Flags: 0x01, ClientData: []uint32{6}},
+		FilterInfo{ID: 32004, Name: "lz4", ClientData: []uint32{0, 0}},
+	)
+	if err := fp.Serialize(w); err != nil {
+		t.Fatalf("Serialize failed: %v", err)
+	}
+	if int(w.Pos()) != fp.SerializedSize(w) {
+		t.Errorf("wrote %d bytes, SerializedSize is %d", w.Pos(), fp.SerializedSize(w))
+	}
+
+	r := binpkg.NewReader(bytes.NewReader(buf.Bytes()), cfg)
+	parsed, n, err := parseFilterPipeline(buf.Bytes()[:w.Pos()], r)
+	if err != nil {
+		t.Fatalf("parseFilterPipeline failed: %v", err)
+	}
+	if n != int(w.Pos()) {
+		t.Errorf("parsed %d bytes, wrote %d", n, w.Pos())
+	}
+	if parsed.Version != 2 || len(parsed.Filters) != 3 {
+		t.Fatalf("parsed %+v", parsed)
+	}
+	for i, f := range fp.Filters {
+		got := parsed.Filters[i]
+		if got.ID != f.ID || got.Flags != f.Flags || got.Name != f.Name || len(got.ClientData) != len(f.ClientData) {
+			t.Errorf("filter %d: got %+v, want %+v", i, got, f)
+		}
+	}
+}
+
 func TestLayoutSerializeCompact(t *testing.T) {
 	buf := newBytesWriterAt(256)
 	cfg := binpkg.DefaultConfig()
@@ -270,7 +367,7 @@ func TestLayoutSerializeCompact(t *testing.T) {
 
 	// Verify round-trip
 	r := binpkg.NewReader(bytes.NewReader(buf.Bytes()), cfg)
-	parsed, err := parseDataLayout(buf.Bytes()[:w.Pos()], r)
+	parsed, _, err := parseDataLayout(buf.Bytes()[:w.Pos()], r)
 	if err != nil {
 		t.Fatalf("parseDataLayout failed: %v", err)
 	}
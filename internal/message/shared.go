@@ -0,0 +1,127 @@
+package message
+
+import (
+	"fmt"
+
+	binpkg "github.com/robert-malhotra/go-hdf5/internal/binary"
+)
+
+// FlagShared is the header message flag marking a message stored elsewhere:
+// its body is then a SharedRef to the message.
+const FlagShared = 0x02
+
+// Where a version 3 shared message is stored
+const (
+	SharedInHeap   = 1 // The shared object header message heap
+	SharedInHeader = 2 // Another object's header, such as a committed datatype
+)
+
+// SharedRef is the body of a header message that is stored elsewhere, in
+// place of the message itself. Messages stored in another object's header
+// are found by its address; those in the shared object header message heap
+// by their heap ID.
+type SharedRef struct {
+	MsgType  Type // Type of the message referred to
+	Version  uint8
+	Location uint8  // SharedInHeap or SharedInHeader
+	Address  uint64 // Object header holding the message (SharedInHeader)
+	HeapID   []byte // Heap ID of the message (SharedInHeap)
+}
+
+// Type returns the type of the message referred to, so that a reference
+// is found where the message would be.
+func (m *SharedRef) Type() Type { return m.MsgType }
+
+// sharable reports whether messages of type typ may be shared.
+func sharable(typ Type) bool {
+	switch typ {
+	case TypeDataspace, TypeDatatype, TypeFillValueOld, TypeFillValue, TypeFilterPipeline, TypeAttribute:
+		return true
+	}
+	return false
+}
+
+// parseSharedRef parses the body of a shared message of type typ. Versions
+// 1 and 2 always refer to another object's header; version 1 stores its
+// address after reserved bytes and a length-sized field, as the HDF5
+// library decodes it.
+func parseSharedRef(typ Type, data []byte, r *binpkg.Reader) (*SharedRef, int, error) {
+	if len(data) < 2 {
+		return nil, 0, fmt.Errorf("shared %s message too short", typ)
+	}
+	ref := &SharedRef{MsgType: typ, Version: data[0], Location: SharedInHeader}
+	offsetSize := r.OffsetSize()
+	offset := 2
+	switch ref.Version {
+	case 1:
+		offset = 8 + r.LengthSize()
+	case 2:
+	case 3:
+		ref.Location = data[1]
+		if ref.Location == SharedInHeap {
+			if len(data) < offset+8 {
+				return nil, 0, fmt.Errorf("shared %s message heap ID truncated", typ)
+			}
+			ref.HeapID = append([]byte(nil), data[offset:offset+8]...)
+			return ref, offset + 8, nil
+		}
+		if ref.Location != SharedInHeader {
+			return nil, 0, fmt.Errorf("unsupported shared %s message location: %d", typ, ref.Location)
+		}
+	default:
+		return nil, 0, fmt.Errorf("unsupported shared message version: %d", ref.Version)
+	}
+	if len(data) < offset+offsetSize {
+		return nil, 0, fmt.Errorf("shared %s message address truncated", typ)
+	}
+	ref.Address = decodeUint(data[offset:], offsetSize, r.ByteOrder())
+	return ref, offset + offsetSize, nil
+}
+
+// Serialize writes a version 3 reference to the message.
+func (m *SharedRef) Serialize(w *binpkg.Writer) error {
+	if err := w.WriteUint8(3); err != nil {
+		return err
+	}
+	if err := w.WriteUint8(m.Location); err != nil {
+		return err
+	}
+	if m.Location == SharedInHeap {
+		return w.WriteBytes(m.HeapID)
+	}
+	return w.WriteOffset(m.Address)
+}
+
+// SerializedSize returns the size of a version 3 reference to the message.
+func (m *SharedRef) SerializedSize(w *binpkg.Writer) int {
+	if m.Location == SharedInHeap {
+		return 2 + len(m.HeapID)
+	}
+	return 2 + w.OffsetSize()
+}
+
+// SharedMessageTable represents a shared message table message (type
+// 0x000F), kept in the superblock extension of files that share header
+// messages through the shared object header message heap.
+type SharedMessageTable struct {
+	Version      uint8
+	TableAddress uint64 // Address of the table of indexes ("SMTB")
+	NumIndexes   int
+}
+
+func (m *SharedMessageTable) Type() Type { return TypeSharedMessageTable }
+
+func parseSharedMessageTable(data []byte, r *binpkg.Reader) (*SharedMessageTable, int, error) {
+	offsetSize := r.OffsetSize()
+	if len(data) < 2+offsetSize {
+		return nil, 0, fmt.Errorf("shared message table message too short")
+	}
+	if data[0] != 0 {
+		return nil, 0, fmt.Errorf("unsupported shared message table message version: %d", data[0])
+	}
+	return &SharedMessageTable{
+		Version:      data[0],
+		TableAddress: decodeUint(data[1:], offsetSize, r.ByteOrder()),
+		NumIndexes:   int(data[1+offsetSize]),
+	}, 2 + offsetSize, nil
+}
@@ -16,15 +16,15 @@ type SymbolTable struct {
 
 func (m *SymbolTable) Type() Type { return TypeSymbolTable }
 
-func parseSymbolTable(data []byte, r *binpkg.Reader) (*SymbolTable, error) {
+func parseSymbolTable(data []byte, r *binpkg.Reader) (*SymbolTable, int, error) {
 	offsetSize := r.OffsetSize()
 
 	if len(data) < 2*offsetSize {
-		return nil, fmt.Errorf("symbol table message too short")
+		return nil, 0, fmt.Errorf("symbol table message too short")
 	}
 
 	return &SymbolTable{
 		BTreeAddress:     decodeUint(data[0:offsetSize], offsetSize, r.ByteOrder()),
 		LocalHeapAddress: decodeUint(data[offsetSize:2*offsetSize], offsetSize, r.ByteOrder()),
-	}, nil
+	}, 2 * offsetSize, nil
 }
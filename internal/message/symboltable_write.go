@@ -0,0 +1,19 @@
+package message
+
+import (
+	"github.com/robert-malhotra/go-hdf5/internal/binary"
+)
+
+// Serialize writes the SymbolTable to the writer.
+func (m *SymbolTable) Serialize(w *binary.Writer) error {
+	if err := w.WriteOffset(m.BTreeAddress); err != nil {
+		return err
+	}
+	return w.WriteOffset(m.LocalHeapAddress)
+}
+
+// SerializedSize returns the size in bytes when serialized.
+func (m *SymbolTable) SerializedSize(w *binary.Writer) int {
+	// B-tree address + local heap address
+	return 2 * w.OffsetSize()
+}
@@ -0,0 +1,345 @@
+package message
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	binpkg "github.com/robert-malhotra/go-hdf5/internal/binary"
+)
+
+// SelectionType is the kind of a serialized dataspace selection.
+type SelectionType uint32
+
+const (
+	SelectNone       SelectionType = 0 // No elements
+	SelectPoints     SelectionType = 1 // A list of points
+	SelectHyperslabs SelectionType = 2 // A union of blocks
+	SelectAll        SelectionType = 3 // The whole dataspace
+)
+
+// Selection is a dataspace selection as serialized in the mapping list of
+// a virtual dataset.
+type Selection struct {
+	Type SelectionType
+	Rank int
+
+	// Regular hyperslabs: the start, stride, count and block of each
+	// dimension. Counts and blocks may be Unlimited.
+	Start, Stride, Count, Block []uint64
+
+	// Other hyperslabs: the blocks, by their first and last elements
+	Blocks []SelectionBlock
+
+	// Point selections: the coordinates of each point
+	Points [][]uint64
+}
+
+// SelectionBlock is one block of a hyperslab selection, from Start to End
+// inclusive in each dimension.
+type SelectionBlock struct {
+	Start, End []uint64
+}
+
+// Box returns the start and count in each dimension of the single box of
+// elements the selection covers, or nil slices for SelectAll, whose box is
+// the whole extent. Selections of no elements, of several boxes, and of
+// unlimited extent are reported as errors.
+func (s *Selection) Box() (start, count []uint64, err error) {
+	switch s.Type {
+	case SelectAll:
+		return nil, nil, nil
+
+	case SelectPoints:
+		if len(s.Points) != 1 {
+			return nil, nil, fmt.Errorf("selection of %d points", len(s.Points))
+		}
+		count = make([]uint64, s.Rank)
+		for d := range count {
+			count[d] = 1
+		}
+		return s.Points[0], count, nil
+
+	case SelectHyperslabs:
+		if s.Blocks != nil {
+			if len(s.Blocks) != 1 {
+				return nil, nil, fmt.Errorf("hyperslab selection of %d blocks", len(s.Blocks))
+			}
+			b := s.Blocks[0]
+			count = make([]uint64, s.Rank)
+			for d := range count {
+				if b.End[d] < b.Start[d] {
+					return nil, nil, fmt.Errorf("hyperslab block ends before it starts in dimension %d", d)
+				}
+				count[d] = b.End[d] - b.Start[d] + 1
+			}
+			return b.Start, count, nil
+		}
+
+		// Blocks placed end to end, or just one, make up one box
+		count = make([]uint64, s.Rank)
+		for d := range count {
+			if s.Count[d] == Unlimited || s.Block[d] == Unlimited {
+				return nil, nil, fmt.Errorf("unlimited hyperslab selection")
+			}
+			if s.Count[d] > 1 && s.Stride[d] != s.Block[d] {
+				return nil, nil, fmt.Errorf("hyperslab selection of %d separate blocks in dimension %d", s.Count[d], d)
+			}
+			hi, lo := bits.Mul64(s.Count[d], s.Block[d])
+			if hi != 0 {
+				return nil, nil, fmt.Errorf("hyperslab selection too large in dimension %d", d)
+			}
+			count[d] = lo
+		}
+		return s.Start, count, nil
+	}
+	return nil, nil, fmt.Errorf("empty selection")
+}
+
+// VirtualMapping is one entry of a virtual dataset's mapping list: the
+// elements of VirtualSelection are those of SourceSelection in the source
+// dataset, both taken in row-major order.
+type VirtualMapping struct {
+	SourceFile       string // "." for the file of the virtual dataset
+	SourceDataset    string
+	SourceSelection  *Selection
+	VirtualSelection *Selection
+}
+
+// ParseVirtualMappings parses the mapping list of a virtual dataset, held
+// in the global heap object its layout message names. Version 0 of the
+// encoding is supported. The trailing checksum is verified if r was
+// configured to verify checksums.
+func ParseVirtualMappings(data []byte, r *binpkg.Reader) ([]VirtualMapping, error) {
+	lengthSize := r.LengthSize()
+	if len(data) < 1+lengthSize+4 {
+		return nil, fmt.Errorf("virtual dataset mapping list of %d bytes truncated", len(data))
+	}
+	if data[0] != 0 {
+		return nil, fmt.Errorf("unsupported virtual dataset mapping list version %d", data[0])
+	}
+	if r.VerifyChecksums() {
+		stored := binary.LittleEndian.Uint32(data[len(data)-4:])
+		if computed := binpkg.ChecksumLookup3(data[:len(data)-4], 0); computed != stored {
+			return nil, fmt.Errorf("virtual dataset mapping list: %w: stored %#08x, computed %#08x",
+				binpkg.ErrChecksumMismatch, stored, computed)
+		}
+	}
+	n := decodeUint(data[1:], lengthSize, binary.LittleEndian)
+	p := &selectionParser{data: data[:len(data)-4], offset: 1 + lengthSize}
+	if n > uint64(len(p.data)) {
+		return nil, fmt.Errorf("virtual dataset mapping list of %d entries in %d bytes", n, len(data))
+	}
+
+	mappings := make([]VirtualMapping, n)
+	for i := range mappings {
+		m := &mappings[i]
+		var err error
+		if m.SourceFile, err = p.string(); err != nil {
+			return nil, fmt.Errorf("virtual dataset mapping %d source file: %w", i, err)
+		}
+		if m.SourceDataset, err = p.string(); err != nil {
+			return nil, fmt.Errorf("virtual dataset mapping %d source dataset: %w", i, err)
+		}
+		if m.SourceSelection, err = p.selection(); err != nil {
+			return nil, fmt.Errorf("virtual dataset mapping %d source selection: %w", i, err)
+		}
+		if m.VirtualSelection, err = p.selection(); err != nil {
+			return nil, fmt.Errorf("virtual dataset mapping %d virtual selection: %w", i, err)
+		}
+	}
+	return mappings, nil
+}
+
+// selectionParser reads the little-endian fields of a mapping list.
+type selectionParser struct {
+	data   []byte
+	offset int
+}
+
+func (p *selectionParser) uint(size int) (uint64, error) {
+	if p.offset+size > len(p.data) {
+		return 0, fmt.Errorf("truncated at byte %d", p.offset)
+	}
+	v := decodeUint(p.data[p.offset:], size, binary.LittleEndian)
+	p.offset += size
+	return v, nil
+}
+
+// string reads a null-terminated string.
+func (p *selectionParser) string() (string, error) {
+	end := bytes.IndexByte(p.data[p.offset:], 0)
+	if end < 0 {
+		return "", fmt.Errorf("unterminated string at byte %d", p.offset)
+	}
+	s := string(p.data[p.offset : p.offset+end])
+	p.offset += end + 1
+	return s, nil
+}
+
+// coordinates reads rank values of size bytes each. Values of all ones
+// are Unlimited, whatever their size.
+func (p *selectionParser) coordinates(rank, size int) ([]uint64, error) {
+	values := make([]uint64, rank)
+	for d := range values {
+		v, err := p.uint(size)
+		if err != nil {
+			return nil, err
+		}
+		if size < 8 && v == 1<<(8*size)-1 {
+			v = Unlimited
+		}
+		values[d] = v
+	}
+	return values, nil
+}
+
+// selection reads a serialized selection: its type and version, then the
+// fields of that version.
+func (p *selectionParser) selection() (*Selection, error) {
+	typ, err := p.uint(4)
+	if err != nil {
+		return nil, err
+	}
+	version, err := p.uint(4)
+	if err != nil {
+		return nil, err
+	}
+	s := &Selection{Type: SelectionType(typ)}
+
+	switch s.Type {
+	case SelectNone, SelectAll:
+		// Reserved and length fields, both zero
+		if _, err := p.uint(8); err != nil {
+			return nil, err
+		}
+		return s, nil
+
+	case SelectPoints:
+		size := 4
+		switch version {
+		case 1:
+			_, err = p.uint(8) // Reserved and length
+		case 2:
+			var encSize uint64
+			encSize, err = p.uint(1)
+			size = int(encSize)
+		default:
+			return nil, fmt.Errorf("unsupported point selection version %d", version)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := p.rank(s); err != nil {
+			return nil, err
+		}
+		if size != 2 && size != 4 && size != 8 {
+			return nil, fmt.Errorf("invalid point selection encoding size %d", size)
+		}
+		n, err := p.uint(size)
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(p.data)) {
+			return nil, fmt.Errorf("point selection of %d points in %d bytes", n, len(p.data))
+		}
+		s.Points = make([][]uint64, n)
+		for i := range s.Points {
+			if s.Points[i], err = p.coordinates(s.Rank, size); err != nil {
+				return nil, err
+			}
+		}
+		return s, nil
+
+	case SelectHyperslabs:
+		return p.hyperslab(s, version)
+	}
+	return nil, fmt.Errorf("unknown selection type %d", typ)
+}
+
+// rank reads the rank of a selection.
+func (p *selectionParser) rank(s *Selection) error {
+	rank, err := p.uint(4)
+	if err != nil {
+		return err
+	}
+	if rank == 0 || rank > 32 {
+		return fmt.Errorf("invalid selection rank %d", rank)
+	}
+	s.Rank = int(rank)
+	return nil
+}
+
+// hyperslab reads the fields of a hyperslab selection. Version 1 lists
+// blocks with 4-byte coordinates; version 2 adds a flag for regular
+// hyperslabs, given by start, stride, count and block, with 8-byte values;
+// version 3 also gives the size of the values.
+func (p *selectionParser) hyperslab(s *Selection, version uint64) (*Selection, error) {
+	const regular = 0x01
+	var flags uint64
+	size := 4
+	var err error
+	switch version {
+	case 1:
+		_, err = p.uint(8) // Reserved and length
+	case 2:
+		if flags, err = p.uint(1); err == nil {
+			_, err = p.uint(4) // Length
+		}
+		size = 8
+	case 3:
+		var encSize uint64
+		if flags, err = p.uint(1); err == nil {
+			encSize, err = p.uint(1)
+		}
+		size = int(encSize)
+	default:
+		return nil, fmt.Errorf("unsupported hyperslab selection version %d", version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if size != 2 && size != 4 && size != 8 {
+		return nil, fmt.Errorf("invalid hyperslab selection encoding size %d", size)
+	}
+	if err := p.rank(s); err != nil {
+		return nil, err
+	}
+
+	if flags&regular != 0 {
+		s.Start = make([]uint64, s.Rank)
+		s.Stride = make([]uint64, s.Rank)
+		s.Count = make([]uint64, s.Rank)
+		s.Block = make([]uint64, s.Rank)
+		for d := 0; d < s.Rank; d++ {
+			for _, field := range []*uint64{&s.Start[d], &s.Stride[d], &s.Count[d], &s.Block[d]} {
+				values, err := p.coordinates(1, size)
+				if err != nil {
+					return nil, err
+				}
+				*field = values[0]
+			}
+		}
+		return s, nil
+	}
+
+	n, err := p.uint(size)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(p.data)) {
+		return nil, fmt.Errorf("hyperslab selection of %d blocks in %d bytes", n, len(p.data))
+	}
+	s.Blocks = make([]SelectionBlock, n)
+	for i := range s.Blocks {
+		b := &s.Blocks[i]
+		if b.Start, err = p.coordinates(s.Rank, size); err != nil {
+			return nil, err
+		}
+		if b.End, err = p.coordinates(s.Rank, size); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
@@ -28,6 +28,11 @@
 //   - Sequence of header messages (dataspace, datatype, layout, etc.)
 //   - Optional continuation blocks for overflow messages
 //
+// Messages are listed chunk by chunk, as the HDF5 library lists them, so
+// those in a continuation block follow every message of the chunk pointing
+// to it. In v2 headers tracking attribute creation order, each attribute's
+// creation order is recorded on the parsed message.
+//
 // # Usage
 //
 // Read an object header at a known address:
@@ -0,0 +1,350 @@
+package object
+
+import (
+	stdbinary "encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// ErrHeaderFull is returned when a message cannot be added to an object
+// header because no message in it is large enough to give up its place to
+// a continuation message.
+var ErrHeaderFull = errors.New("no room in object header")
+
+// SetAttribute writes attr into the object header at address, replacing the
+// attribute of the same name if there is one. The header stays at address,
+// so links and references to the object remain valid:
+//
+//   - A replacement no larger than the old attribute overwrites it in place.
+//   - Otherwise the old attribute becomes a NIL message and the new one takes
+//     the first NIL message large enough to hold it.
+//   - Failing that, it goes in a new continuation block from allocate. The
+//     continuation message pointing there takes a NIL message or, if none is
+//     large enough, the place of an existing message, which moves to the new
+//     block as well.
+//
+// Version 1 and 2 headers are supported; checksums of v2 header chunks and
+// the message count of v1 headers are updated.
+func SetAttribute(r *binary.Reader, w *binary.Writer, address uint64, attr *message.Attribute, allocate func(size int64) uint64) error {
+	body, err := serializeMessage(w, attr)
+	if err != nil {
+		return fmt.Errorf("serializing attribute: %w", err)
+	}
+	if len(body) > 0xFFFF {
+		return fmt.Errorf("attribute %q is %d bytes, more than a header message can hold", attr.Name, len(body))
+	}
+
+	return editHeader(r, w, address, allocate, message.TypeAttribute, body, func(msg RawMessage) bool {
+		parsed, err := message.Parse(msg.Type, msg.Data, msg.Flags, r)
+		a, ok := parsed.(*message.Attribute)
+		return err == nil && ok && a.Name == attr.Name
+	})
+}
+
+// ReplaceMessage writes msg into the object header at address in place of
+// the first message of the same type, or adds it if there is none, as
+// SetAttribute does for attributes. It is used to update messages such as
+// the dataspace and data layout of a dataset as it grows.
+func ReplaceMessage(r *binary.Reader, w *binary.Writer, address uint64, msg message.Serializable, allocate func(size int64) uint64) error {
+	body, err := serializeMessage(w, msg)
+	if err != nil {
+		return fmt.Errorf("serializing %s message: %w", msg.Type(), err)
+	}
+	if len(body) > 0xFFFF {
+		return fmt.Errorf("%s message is %d bytes, more than a header message can hold", msg.Type(), len(body))
+	}
+	return editHeader(r, w, address, allocate, msg.Type(), body, func(RawMessage) bool { return true })
+}
+
+// editHeader writes a message of type typ with the given body into the
+// object header at address, replacing the first message of that type for
+// which match returns true.
+func editHeader(r *binary.Reader, w *binary.Writer, address uint64, allocate func(size int64) uint64,
+	typ message.Type, body []byte, match func(RawMessage) bool) error {
+	raw, err := ReadRaw(r, address)
+	if err != nil {
+		return err
+	}
+	if raw.FramingError != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidHeader, raw.FramingError)
+	}
+	e := &headerEditor{r: r, w: w, raw: raw, allocate: allocate, dirty: make(map[int]bool)}
+
+	old := -1
+	for i, msg := range raw.Messages {
+		if msg.Type == typ && match(msg) {
+			old = i
+			break
+		}
+	}
+
+	switch {
+	case old >= 0 && len(body) <= int(raw.Messages[old].Size):
+		msg := raw.Messages[old]
+		err = e.write(msg.Offset, e.framing(typ, msg.Size, msg.Flags, msg.CreationOrder, body), msg.Chunk)
+	case old >= 0:
+		msg := raw.Messages[old]
+		if err = e.free(old); err == nil {
+			err = e.add(typ, body, msg.Flags, msg.CreationOrder)
+		}
+	default:
+		err = e.add(typ, body, 0, e.nextCreationOrder())
+	}
+	if err != nil {
+		return err
+	}
+	return e.finish()
+}
+
+// serializeMessage returns the body of msg.
+func serializeMessage(w *binary.Writer, msg message.Serializable) ([]byte, error) {
+	buf := make([]byte, msg.SerializedSize(w))
+	if err := msg.Serialize(bufferWriter(w, buf)); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// bufferWriter returns a writer into buf with the sizes of w.
+func bufferWriter(w *binary.Writer, buf []byte) *binary.Writer {
+	return binary.NewWriter(&bufferWriterAt{buf: buf}, binary.Config{
+		ByteOrder:  w.ByteOrder(),
+		OffsetSize: w.OffsetSize(),
+		LengthSize: w.LengthSize(),
+	})
+}
+
+// headerEditor changes the messages of an existing object header in place.
+// raw.Messages is kept up to date with the edits.
+type headerEditor struct {
+	r        *binary.Reader
+	w        *binary.Writer
+	raw      *RawHeader
+	allocate func(size int64) uint64
+	dirty    map[int]bool // Chunks whose v2 checksum must be recomputed
+}
+
+// prefixSize is the size of the framing before each message body.
+func (e *headerEditor) prefixSize() uint32 {
+	if e.raw.Version == 1 {
+		return 8
+	}
+	if e.raw.Flags&0x04 != 0 {
+		return 6 // Includes the creation order
+	}
+	return 4
+}
+
+// bodySize returns the size a message body of n bytes occupies: v1 headers
+// align messages to 8 bytes.
+func (e *headerEditor) bodySize(n int) uint32 {
+	if e.raw.Version == 1 {
+		return uint32(n+7) &^ 7
+	}
+	return uint32(n)
+}
+
+// nextCreationOrder returns the creation order for a new message in headers
+// that track it.
+func (e *headerEditor) nextCreationOrder() uint16 {
+	var next uint16
+	for _, msg := range e.raw.Messages {
+		if msg.Type == message.TypeAttribute && msg.CreationOrder >= next {
+			next = msg.CreationOrder + 1
+		}
+	}
+	return next
+}
+
+// framing returns a message with its framing, the body zero-padded to size.
+// Header fields are always little-endian.
+func (e *headerEditor) framing(typ message.Type, size uint32, flags uint8, crtOrder uint16, body []byte) []byte {
+	order := stdbinary.LittleEndian
+	var b []byte
+	if e.raw.Version == 1 {
+		b = order.AppendUint16(b, uint16(typ))
+		b = order.AppendUint16(b, uint16(size))
+		b = append(b, flags, 0, 0, 0)
+	} else {
+		b = append(b, uint8(typ))
+		b = order.AppendUint16(b, uint16(size))
+		b = append(b, flags)
+		if e.raw.Flags&0x04 != 0 {
+			b = order.AppendUint16(b, crtOrder)
+		}
+	}
+	padded := make([]byte, size)
+	copy(padded, body)
+	return append(b, padded...)
+}
+
+// write writes data at offset, in the given header chunk.
+func (e *headerEditor) write(offset uint64, data []byte, chunk int) error {
+	if err := e.w.At(int64(offset)).WriteBytes(data); err != nil {
+		return fmt.Errorf("writing object header: %w", err)
+	}
+	e.dirty[chunk] = true
+	return nil
+}
+
+// free turns message i into a NIL message.
+func (e *headerEditor) free(i int) error {
+	msg := &e.raw.Messages[i]
+	if err := e.write(msg.Offset, e.framing(message.TypeNIL, msg.Size, 0, 0, nil), msg.Chunk); err != nil {
+		return err
+	}
+	msg.Type, msg.Flags, msg.CreationOrder, msg.Data = message.TypeNIL, 0, 0, make([]byte, msg.Size)
+	return nil
+}
+
+// place writes a message over NIL message i, which must be large enough,
+// leaving what is left of it as a smaller NIL message.
+func (e *headerEditor) place(i int, typ message.Type, flags uint8, crtOrder uint16, body []byte) error {
+	slot := e.raw.Messages[i]
+	size := e.bodySize(len(body))
+	prefix := e.prefixSize()
+	rest := slot.Size - size
+	if rest < prefix {
+		size, rest = slot.Size, 0
+	}
+
+	data := e.framing(typ, size, flags, crtOrder, body)
+	placed := RawMessage{
+		Offset: slot.Offset, DataOffset: slot.Offset + uint64(prefix), Type: typ, Size: size,
+		Flags: flags, CreationOrder: crtOrder, Chunk: slot.Chunk, Data: data[prefix:],
+	}
+	messages := []RawMessage{placed}
+	if rest > 0 {
+		nilOffset := slot.Offset + uint64(prefix+size)
+		data = append(data, e.framing(message.TypeNIL, rest-prefix, 0, 0, nil)...)
+		messages = append(messages, RawMessage{
+			Offset: nilOffset, DataOffset: nilOffset + uint64(prefix), Type: message.TypeNIL,
+			Size: rest - prefix, Chunk: slot.Chunk, Data: make([]byte, rest-prefix),
+		})
+	}
+	if err := e.write(slot.Offset, data, slot.Chunk); err != nil {
+		return err
+	}
+	e.raw.Messages = append(e.raw.Messages[:i], append(messages, e.raw.Messages[i+1:]...)...)
+	return nil
+}
+
+// findNIL returns the index of the first NIL message that can hold a body
+// of n bytes, or -1.
+func (e *headerEditor) findNIL(n int) int {
+	for i, msg := range e.raw.Messages {
+		if msg.Type == message.TypeNIL && msg.Size >= e.bodySize(n) {
+			return i
+		}
+	}
+	return -1
+}
+
+// add adds a message with the given body and flags to the header.
+func (e *headerEditor) add(typ message.Type, body []byte, flags uint8, crtOrder uint16) error {
+	if i := e.findNIL(len(body)); i >= 0 {
+		return e.place(i, typ, flags, crtOrder, body)
+	}
+
+	// The continuation message needs a NIL message to take, or else the
+	// smallest message large enough to make room for it by moving out
+	contSize := e.w.OffsetSize() + e.w.LengthSize()
+	slot := e.findNIL(contSize)
+	var moved []RawMessage
+	if slot < 0 {
+		for i, msg := range e.raw.Messages {
+			if msg.Type == message.TypeNIL || msg.Type == message.TypeObjectHeaderContinuation ||
+				msg.Size < e.bodySize(contSize) {
+				continue
+			}
+			if slot < 0 || msg.Size < e.raw.Messages[slot].Size {
+				slot = i
+			}
+		}
+		if slot < 0 {
+			return ErrHeaderFull
+		}
+		moved = append(moved, e.raw.Messages[slot])
+	}
+
+	// Build the continuation block
+	var block []byte
+	if e.raw.Version != 1 {
+		block = append(block, "OCHK"...)
+	}
+	for _, msg := range moved {
+		block = append(block, e.framing(msg.Type, msg.Size, msg.Flags, msg.CreationOrder, msg.Data)...)
+	}
+	block = append(block, e.framing(typ, e.bodySize(len(body)), flags, crtOrder, body)...)
+	var blockAddr uint64
+	var slack int
+	if e.raw.Version == 1 {
+		// Messages in v1 headers are aligned to 8 bytes. The slack after
+		// the block is written too, so the file reaches the allocation's end.
+		start := e.allocate(int64(len(block)) + 7)
+		blockAddr = (start + 7) &^ 7
+		slack = int(start + 7 - blockAddr)
+	} else {
+		blockAddr = e.allocate(int64(len(block)) + 4)
+		block = stdbinary.LittleEndian.AppendUint32(block, binary.ChecksumLookup3(block, 0))
+	}
+	if err := e.w.At(int64(blockAddr)).WriteBytes(append(block, make([]byte, slack)...)); err != nil {
+		return fmt.Errorf("writing continuation block: %w", err)
+	}
+
+	if len(moved) > 0 {
+		if err := e.free(slot); err != nil {
+			return err
+		}
+	}
+	cont := make([]byte, contSize)
+	cw := bufferWriter(e.w, cont)
+	if err := cw.WriteOffset(blockAddr); err != nil {
+		return err
+	}
+	if err := cw.WriteLength(uint64(len(block))); err != nil {
+		return err
+	}
+	return e.place(slot, message.TypeObjectHeaderContinuation, 0, 0, cont)
+}
+
+// finish recomputes the checksums of changed v2 header chunks, or the
+// message count of a v1 header.
+func (e *headerEditor) finish() error {
+	if e.raw.Version == 1 {
+		raw, err := ReadRaw(e.r, e.raw.Address)
+		if err != nil {
+			return err
+		}
+		if err := e.w.At(int64(e.raw.Address) + 2).WriteUint16(uint16(len(raw.Messages))); err != nil {
+			return fmt.Errorf("writing message count: %w", err)
+		}
+		return nil
+	}
+
+	for i, chunk := range e.raw.Chunks {
+		if !e.dirty[i] {
+			continue
+		}
+		// Chunk 0 is checksummed from the start of the header; its length
+		// covers only the messages. Continuation blocks include their
+		// signature and checksum.
+		start, end := chunk.Address, chunk.Address+chunk.Length
+		if i == 0 {
+			start = e.raw.Address
+		} else {
+			end -= 4
+		}
+		data, err := e.r.At(int64(start)).ReadBytes(int(end - start))
+		if err != nil {
+			return fmt.Errorf("reading object header chunk: %w", err)
+		}
+		if err := e.w.At(int64(end)).WriteUint32(binary.ChecksumLookup3(data, 0)); err != nil {
+			return fmt.Errorf("writing object header checksum: %w", err)
+		}
+	}
+	return nil
+}
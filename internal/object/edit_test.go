@@ -0,0 +1,104 @@
+package object
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// memFile is an in-memory file that grows as it is written.
+type memFile struct {
+	bufferWriterAt
+}
+
+func (m *memFile) ReadAt(p []byte, off int64) (int, error) {
+	if off >= int64(len(m.buf)) {
+		return 0, io.EOF
+	}
+	n := copy(p, m.buf[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// checkChecksums verifies the checksum of every chunk of the v2 header at
+// address.
+func checkChecksums(t *testing.T, m *memFile, r *binary.Reader, address uint64) {
+	t.Helper()
+	raw, err := ReadRaw(r, address)
+	if err != nil || raw.FramingError != nil {
+		t.Fatalf("ReadRaw = %v, %v", err, raw.FramingError)
+	}
+	for i, chunk := range raw.Chunks {
+		start, end := chunk.Address, chunk.Address+chunk.Length
+		if i == 0 {
+			start = address
+		} else {
+			end -= 4
+		}
+		want := binary.ChecksumLookup3(m.buf[start:end], 0)
+		if got := r.ByteOrder().Uint32(m.buf[end : end+4]); got != want {
+			t.Errorf("chunk %d checksum = %#x, want %#x", i, got, want)
+		}
+	}
+}
+
+func TestSetAttribute(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		minChunk int // Leaves NIL padding in the header
+	}{{"full", 0}, {"padded", 200}} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &memFile{}
+			w := binary.NewWriter(m, binary.DefaultConfig())
+			r := binary.NewReader(m, binary.DefaultConfig())
+			msgs := NewDatasetHeader(message.NewDataspace([]uint64{4}, nil),
+				message.NewFixedPointDatatype(4, true, message.OrderLE), message.NewContiguousLayout(4096, 16))
+			if _, err := WriteHeaderWithMinChunk(w, msgs, tc.minChunk); err != nil {
+				t.Fatalf("WriteHeader failed: %v", err)
+			}
+			allocate := func(size int64) uint64 {
+				addr := uint64(len(m.buf))
+				m.buf = append(m.buf, make([]byte, size)...)
+				return addr
+			}
+
+			set := func(name string, value []byte) {
+				t.Helper()
+				attr := message.NewScalarAttribute(name, message.NewFixedPointDatatype(uint32(len(value)), false, message.OrderLE), value)
+				if err := SetAttribute(r, w, 0, attr, allocate); err != nil {
+					t.Fatalf("SetAttribute %s failed: %v", name, err)
+				}
+				checkChecksums(t, m, r, 0)
+			}
+			set("a", []byte{1})
+			set("b", []byte{2, 0})
+			size := len(m.buf)
+			set("a", []byte{3}) // In place
+			if len(m.buf) != size {
+				t.Errorf("same-size replacement grew the file from %d to %d bytes", size, len(m.buf))
+			}
+			set("b", []byte{4, 0, 0, 0, 0, 0, 0, 0}) // Larger
+
+			h, err := Read(r, 0)
+			if err != nil {
+				t.Fatalf("Read failed: %v", err)
+			}
+			if h.Dataspace() == nil || h.Datatype() == nil || h.DataLayout() == nil {
+				t.Error("dataset messages lost")
+			}
+			values := make(map[string][]byte)
+			for _, msg := range h.GetMessages(message.TypeAttribute) {
+				attr := msg.(*message.Attribute)
+				values[attr.Name] = attr.Data
+			}
+			if len(values) != 2 || !bytes.Equal(values["a"], []byte{3}) || !bytes.Equal(values["b"], []byte{4, 0, 0, 0, 0, 0, 0, 0}) {
+				t.Errorf("attributes = %v", values)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package object
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+	"github.com/robert-malhotra/go-hdf5/internal/superblock"
+)
+
+// FuzzRead checks that reading an object header at an arbitrary address
+// of arbitrary data returns an error rather than panicking or allocating
+// without bound. It is seeded with the small test files, read at their
+// root group, and with headers of both versions written by this package.
+func FuzzRead(f *testing.F) {
+	paths, _ := filepath.Glob(filepath.Join("..", "..", "testdata", "*.h5"))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		sb, err := superblock.Read(bytes.NewReader(data))
+		if err != nil || len(data) > 8192 {
+			continue
+		}
+		f.Add(data, sb.RootGroupAddress)
+	}
+	messages := []message.Message{
+		message.NewDataspace([]uint64{4}, nil),
+		message.NewFixedPointDatatype(4, true, message.OrderLE),
+		message.NewContiguousLayout(0x1000, 16),
+		message.NewHardLink("data", 0x800),
+	}
+	for _, v1 := range []bool{false, true} {
+		buf := &bufferWriterAt{}
+		w := binary.NewWriter(buf, binary.DefaultConfig())
+		var err error
+		if v1 {
+			_, err = WriteHeaderV1(w, messages, 64)
+		} else {
+			_, err = WriteHeader(w, messages)
+		}
+		if err != nil {
+			f.Fatalf("writing header: %v", err)
+		}
+		f.Add(buf.buf, uint64(0))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte, addr uint64) {
+		cfg := binary.DefaultConfig()
+		if sb, err := superblock.Read(bytes.NewReader(data)); err == nil {
+			cfg = sb.ReaderConfig()
+		}
+		Read(binary.NewReader(bytes.NewReader(data), cfg), addr)
+	})
+}
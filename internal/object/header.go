@@ -17,11 +17,15 @@ var (
 	SignatureV2 = []byte{'O', 'H', 'D', 'R'}
 )
 
+// maxContinuationBlocks bounds the continuation blocks of one header, so
+// that a corrupt chain of them cannot be followed without end.
+const maxContinuationBlocks = 1 << 16
+
 // Errors
 var (
-	ErrInvalidHeader        = errors.New("invalid object header")
-	ErrUnsupportedVersion   = errors.New("unsupported object header version")
-	ErrChecksumMismatch     = errors.New("object header checksum mismatch")
+	ErrInvalidHeader      = errors.New("invalid object header")
+	ErrUnsupportedVersion = errors.New("unsupported object header version")
+	ErrChecksumMismatch   = binary.ErrChecksumMismatch
 )
 
 // Header represents a parsed HDF5 object header.
@@ -41,6 +45,11 @@ type Header struct {
 	// Messages contains all parsed header messages
 	Messages []message.Message
 
+	// TrailingBytes lists the messages whose bodies end in nonzero bytes
+	// after the fields their version defines. Those bytes are ignored;
+	// zero padding, which aligns v1 messages, is not recorded.
+	TrailingBytes []TrailingBytes
+
 	// Timestamps (v2 only, if flag 0x04 is set)
 	AccessTime uint32
 	ModTime    uint32
@@ -48,8 +57,23 @@ type Header struct {
 	BirthTime  uint32
 }
 
-// Read parses an object header at the given address.
+// Read parses an object header at the given address. Messages stored
+// elsewhere and flagged shared, such as committed datatypes, are replaced
+// by the messages they refer to; see ResolveShared.
 func Read(r *binary.Reader, address uint64) (*Header, error) {
+	hdr, err := readHeader(r, address)
+	if err != nil {
+		return nil, err
+	}
+	if err := hdr.resolveShared(r); err != nil {
+		return nil, err
+	}
+	return hdr, nil
+}
+
+// readHeader parses an object header at the given address, leaving shared
+// messages unresolved.
+func readHeader(r *binary.Reader, address uint64) (*Header, error) {
 	hr := r.At(int64(address))
 
 	// Peek first byte to determine version
@@ -71,6 +95,27 @@ func Read(r *binary.Reader, address uint64) (*Header, error) {
 	return nil, fmt.Errorf("%w: unknown format at address %d", ErrInvalidHeader, address)
 }
 
+// TrailingBytes describes junk after the fields of a message body.
+type TrailingBytes struct {
+	Type  message.Type
+	Count int // Number of bytes after the message's fields
+}
+
+// parseMessage parses a message body, recording nonzero trailing bytes in h.
+func (h *Header) parseMessage(typ message.Type, data []byte, flags uint8, r *binary.Reader) (message.Message, error) {
+	msg, size, err := message.ParseWithSize(typ, data, flags, r)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range data[size:] {
+		if b != 0 {
+			h.TrailingBytes = append(h.TrailingBytes, TrailingBytes{Type: typ, Count: len(data) - size})
+			break
+		}
+	}
+	return msg, nil
+}
+
 // GetMessage returns the first message of the given type, or nil if not found.
 func (h *Header) GetMessage(typ message.Type) message.Message {
 	for _, msg := range h.Messages {
@@ -127,3 +172,60 @@ func (h *Header) FilterPipeline() *message.FilterPipeline {
 	}
 	return msg.(*message.FilterPipeline)
 }
+
+// ExternalFiles returns the external data files message if present.
+func (h *Header) ExternalFiles() *message.ExternalFiles {
+	msg := h.GetMessage(message.TypeExternalDataFiles)
+	if msg == nil {
+		return nil
+	}
+	return msg.(*message.ExternalFiles)
+}
+
+// FillValue returns the fill value message if present.
+func (h *Header) FillValue() *message.FillValue {
+	msg := h.GetMessage(message.TypeFillValue)
+	if msg == nil {
+		return nil
+	}
+	return msg.(*message.FillValue)
+}
+
+// FillValueOld returns the old fill value message if present.
+func (h *Header) FillValueOld() *message.FillValueOld {
+	msg := h.GetMessage(message.TypeFillValueOld)
+	if msg == nil {
+		return nil
+	}
+	return msg.(*message.FillValueOld)
+}
+
+// AttributeInfo returns the attribute info message if present.
+func (h *Header) AttributeInfo() *message.AttributeInfo {
+	msg := h.GetMessage(message.TypeAttributeInfo)
+	if msg == nil {
+		return nil
+	}
+	return msg.(*message.AttributeInfo)
+}
+
+// LinkInfo returns the link info message if present.
+func (h *Header) LinkInfo() *message.LinkInfo {
+	msg := h.GetMessage(message.TypeLinkInfo)
+	if msg == nil {
+		return nil
+	}
+	return msg.(*message.LinkInfo)
+}
+
+// TracksAttrCreationOrder reports whether the header records the creation
+// order of its attribute messages (v2 headers with flag bit 2 set).
+func (h *Header) TracksAttrCreationOrder() bool {
+	return h.Version == 2 && h.Flags&0x04 != 0
+}
+
+// HasTimes reports whether the header stores the object's access,
+// modification, change and birth times (v2 headers with flag bit 5 set).
+func (h *Header) HasTimes() bool {
+	return h.Version == 2 && h.Flags&0x20 != 0
+}
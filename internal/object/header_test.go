@@ -2,6 +2,8 @@ package object
 
 import (
 	"bytes"
+	stdbinary "encoding/binary"
+	"fmt"
 	"testing"
 
 	"github.com/robert-malhotra/go-hdf5/internal/binary"
@@ -205,3 +207,182 @@ func TestSignatureV2(t *testing.T) {
 		t.Errorf("expected SignatureV2 to be %q, got %q", expected, SignatureV2)
 	}
 }
+
+// attributeBody returns the serialized body of a scalar attribute.
+func attributeBody(t *testing.T, name string) []byte {
+	t.Helper()
+	attr := message.NewScalarAttribute(name, message.NewFixedPointDatatype(1, false, message.OrderLE), []byte{7})
+	b := &bufferWriterAt{}
+	if err := attr.Serialize(binary.NewWriter(b, binary.DefaultConfig())); err != nil {
+		t.Fatalf("serializing attribute: %v", err)
+	}
+	return b.buf
+}
+
+// attrNames lists the names and creation orders of h's attributes.
+func attrNames(h *Header) ([]string, []uint32) {
+	var names []string
+	var orders []uint32
+	for _, msg := range h.GetMessages(message.TypeAttribute) {
+		attr := msg.(*message.Attribute)
+		names = append(names, attr.Name)
+		orders = append(orders, attr.CreationOrder)
+	}
+	return names, orders
+}
+
+func TestReadV2ChunkOrder(t *testing.T) {
+	// Chunk 0 holds a continuation message followed by attribute "b"; the
+	// continuation block holds attribute "a". Creation order is tracked.
+	v2Message := func(typ uint8, order uint16, body []byte) []byte {
+		msg := []byte{typ, byte(len(body)), byte(len(body) >> 8), 0, byte(order), byte(order >> 8)}
+		return append(msg, body...)
+	}
+	a, b := attributeBody(t, "a"), attributeBody(t, "b")
+
+	chunk1 := append([]byte("OCHK"), v2Message(0x0C, 0, a)...)
+	chunk1 = append(chunk1, 0, 0, 0, 0) // Checksum, not verified
+
+	contBody := make([]byte, 16)
+	messages := v2Message(0x10, 0, contBody)
+	messages = append(messages, v2Message(0x0C, 1, b)...)
+	chunk0 := append([]byte("OHDR"), 2, 0x04, byte(len(messages)))
+	chunk0 = append(chunk0, messages...)
+	chunk0 = append(chunk0, 0, 0, 0, 0)
+
+	// Point the continuation message at chunk 1, placed after chunk 0
+	contAt := 7 + 6
+	chunk0[contAt] = byte(len(chunk0))
+	chunk0[contAt+8] = byte(len(chunk1))
+
+	r := binary.NewReader(bytes.NewReader(append(chunk0, chunk1...)), binary.DefaultConfig())
+	h, err := Read(r, 0)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	names, orders := attrNames(h)
+	if len(names) != 2 || names[0] != "b" || names[1] != "a" {
+		t.Errorf("attributes = %v, want [b a]", names)
+	}
+	if len(orders) != 2 || orders[0] != 1 || orders[1] != 0 {
+		t.Errorf("creation orders = %v, want [1 0]", orders)
+	}
+	if !h.TracksAttrCreationOrder() {
+		t.Error("TracksAttrCreationOrder() = false")
+	}
+}
+
+func TestReadV1ChunkOrder(t *testing.T) {
+	// Like TestReadV2ChunkOrder, with a v1 header whose continuation block
+	// loops back to itself
+	v1Message := func(typ uint16, body []byte) []byte {
+		for len(body)%8 != 0 {
+			body = append(body, 0)
+		}
+		msg := []byte{byte(typ), byte(typ >> 8), byte(len(body)), byte(len(body) >> 8), 0, 0, 0, 0}
+		return append(msg, body...)
+	}
+	a, b := attributeBody(t, "a"), attributeBody(t, "b")
+
+	messages := v1Message(0x10, make([]byte, 16))
+	messages = append(messages, v1Message(0x0C, b)...)
+	chunk0 := []byte{1, 0, 3, 0, 1, 0, 0, 0, byte(len(messages)), 0, 0, 0, 0, 0, 0, 0}
+	chunk0 = append(chunk0, messages...)
+
+	chunk1 := append(v1Message(0x0C, a), v1Message(0x10, make([]byte, 16))...)
+	cont := func(msgs []byte, at int) {
+		msgs[at+8] = byte(len(chunk0))
+		msgs[at+16] = byte(len(chunk1))
+	}
+	cont(chunk0, 16)
+	cont(chunk1, len(chunk1)-24)
+
+	r := binary.NewReader(bytes.NewReader(append(chunk0, chunk1...)), binary.DefaultConfig())
+	h, err := Read(r, 0)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if names, _ := attrNames(h); len(names) != 2 || names[0] != "b" || names[1] != "a" {
+		t.Errorf("attributes = %v, want [b a]", names)
+	}
+	if h.TracksAttrCreationOrder() {
+		t.Error("TracksAttrCreationOrder() = true for a v1 header")
+	}
+}
+
+// TestWriteHeaderSmallPadding writes headers whose minimum chunk size
+// leaves less room than a NIL message header takes.
+func TestWriteHeaderSmallPadding(t *testing.T) {
+	msgs := NewGroupHeader([]*message.Link{message.NewHardLink("température", 1024)})
+	messagesSize := HeaderSize(binary.NewWriter(&memFile{}, binary.DefaultConfig()), msgs) - 4 - 1 - 1 - 1 - 4
+	for gap := 1; gap <= 5; gap++ {
+		m := &memFile{}
+		w := binary.NewWriter(m, binary.DefaultConfig())
+		r := binary.NewReader(m, binary.DefaultConfig())
+		n, err := WriteHeaderWithMinChunk(w, msgs, messagesSize+gap)
+		if err != nil {
+			t.Fatalf("gap %d: WriteHeader failed: %v", gap, err)
+		}
+		if want := HeaderSizeWithMinChunk(w, msgs, messagesSize+gap); n != int64(want) || len(m.buf) != want {
+			t.Errorf("gap %d: wrote %d bytes (buffer %d), HeaderSize says %d", gap, n, len(m.buf), want)
+		}
+		checkChecksums(t, m, r, 0)
+		h, err := Read(r, 0)
+		if err != nil {
+			t.Fatalf("gap %d: Read failed: %v", gap, err)
+		}
+		if links := h.GetMessages(message.TypeLink); len(links) != 1 || links[0].(*message.Link).Name != "température" {
+			t.Errorf("gap %d: links = %v", gap, links)
+		}
+	}
+}
+
+// TestReadV2MessageAtChunkEnd reads a v2 header whose last message, a
+// 2-byte group info message, ends exactly where chunk 0 does.
+func TestReadV2MessageAtChunkEnd(t *testing.T) {
+	a := attributeBody(t, "a")
+	messages := append([]byte{0x0C, byte(len(a)), byte(len(a) >> 8), 0}, a...)
+	messages = append(messages, 0x0A, 2, 0, 0, 0, 0)
+	hdr := append([]byte("OHDR"), 2, 0, byte(len(messages)))
+	hdr = append(hdr, messages...)
+	hdr = stdbinary.LittleEndian.AppendUint32(hdr, binary.ChecksumLookup3(hdr, 0))
+
+	cfg := binary.DefaultConfig()
+	cfg.VerifyChecksums = true
+	h, err := Read(binary.NewReader(bytes.NewReader(hdr), cfg), 0)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if names, _ := attrNames(h); len(names) != 1 || names[0] != "a" {
+		t.Errorf("attributes = %v, want [a]", names)
+	}
+	if h.GetMessage(message.TypeGroupInfo) == nil {
+		t.Error("group info message at the end of chunk 0 was not read")
+	}
+}
+
+// TestWriteHeaderLargeChunk writes a header whose chunk size needs a
+// 4-byte size field.
+func TestWriteHeaderLargeChunk(t *testing.T) {
+	var links []*message.Link
+	for i := 0; i < 5000; i++ {
+		links = append(links, message.NewHardLink(fmt.Sprintf("ds%05d", i), uint64(i)))
+	}
+	m := &memFile{}
+	w := binary.NewWriter(m, binary.DefaultConfig())
+	r := binary.NewReader(m, binary.DefaultConfig())
+	if _, err := WriteHeader(w, NewGroupHeader(links)); err != nil {
+		t.Fatalf("WriteHeader failed: %v", err)
+	}
+	if flags := m.buf[5]; flags&0x03 != 2 {
+		t.Errorf("chunk size field flags = %d, want 2 (4 bytes)", flags&0x03)
+	}
+	checkChecksums(t, m, r, 0)
+	h, err := Read(r, 0)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if got := h.GetMessages(message.TypeLink); len(got) != len(links) {
+		t.Errorf("read %d links, want %d", len(got), len(links))
+	}
+}
@@ -59,13 +59,39 @@ func readV1(r *binary.Reader, address uint64) (*Header, error) {
 		Messages: make([]message.Message, 0, numMessages),
 	}
 
-	// Align to 8-byte boundary before reading messages
-	r.Align(8)
+	// Messages start 16 bytes into the header, after reserved padding
+	r.Skip(4)
 
 	messagesStart := r.Pos()
 	messagesEnd := messagesStart + int64(headerSize)
 
-	for r.Pos() < messagesEnd {
+	// Parse messages chunk by chunk, as the HDF5 library lists them: the
+	// messages of a continuation block follow every message of the chunk
+	// pointing to it, wherever the continuation message sits
+	conts := readV1Chunk(r, hdr, messagesStart, messagesEnd)
+	seen := make(map[uint64]bool)
+	for i := 0; i < len(conts); i++ {
+		if seen[conts[i].Offset] {
+			continue
+		}
+		if len(seen) == maxContinuationBlocks {
+			return nil, fmt.Errorf("%w: more than %d continuation blocks", ErrInvalidHeader, maxContinuationBlocks)
+		}
+		seen[conts[i].Offset] = true
+		cr := r.At(int64(conts[i].Offset))
+		conts = append(conts, readV1Chunk(cr, hdr, int64(conts[i].Offset), int64(conts[i].Offset+conts[i].Length))...)
+	}
+
+	return hdr, nil
+}
+
+// readV1Chunk appends the messages of the chunk from startPos to endPos to
+// hdr and returns the continuation messages among them. Messages are
+// aligned to 8 bytes from the start of the chunk, and a gap at its end too
+// small for a message is padding.
+func readV1Chunk(r *binary.Reader, hdr *Header, startPos, endPos int64) []*message.Continuation {
+	var conts []*message.Continuation
+	for endPos-r.Pos() >= 8 {
 		msgType, err := r.ReadUint16()
 		if err != nil {
 			break
@@ -89,29 +115,27 @@ func readV1(r *binary.Reader, address uint64) (*Header, error) {
 		}
 
 		// Align to 8-byte boundary
-		r.Align(8)
+		if pad := (r.Pos() - startPos) % 8; pad != 0 {
+			r.Skip(8 - pad)
+		}
 
 		// Skip NIL messages (type 0)
 		if msgType == 0 {
 			continue
 		}
 
-		// Handle continuation message
+		// Continuation blocks are read once this chunk is done
 		if message.Type(msgType) == message.TypeObjectHeaderContinuation {
 			contMsg, err := message.ParseContinuation(data, r)
 			if err != nil {
 				continue
 			}
-			// Read messages from continuation block
-			contMsgs, err := readV1Continuation(r, contMsg.Offset, contMsg.Length)
-			if err == nil {
-				hdr.Messages = append(hdr.Messages, contMsgs...)
-			}
+			conts = append(conts, contMsg)
 			continue
 		}
 
 		// Parse the message
-		msg, err := message.Parse(message.Type(msgType), data, flags, r)
+		msg, err := hdr.parseMessage(message.Type(msgType), data, flags, r)
 		if err != nil {
 			// Skip unknown message types
 			continue
@@ -119,64 +143,5 @@ func readV1(r *binary.Reader, address uint64) (*Header, error) {
 
 		hdr.Messages = append(hdr.Messages, msg)
 	}
-
-	return hdr, nil
-}
-
-func readV1Continuation(r *binary.Reader, offset, length uint64) ([]message.Message, error) {
-	cr := r.At(int64(offset))
-	var messages []message.Message
-
-	endPos := int64(offset + length)
-	for cr.Pos() < endPos {
-		msgType, err := cr.ReadUint16()
-		if err != nil {
-			break
-		}
-
-		dataSize, err := cr.ReadUint16()
-		if err != nil {
-			break
-		}
-
-		flags, err := cr.ReadUint8()
-		if err != nil {
-			break
-		}
-
-		cr.Skip(3) // Reserved
-
-		data, err := cr.ReadBytes(int(dataSize))
-		if err != nil {
-			break
-		}
-
-		cr.Align(8)
-
-		if msgType == 0 {
-			continue
-		}
-
-		// Handle nested continuation messages recursively
-		if message.Type(msgType) == message.TypeObjectHeaderContinuation {
-			contMsg, err := message.ParseContinuation(data, cr)
-			if err != nil {
-				continue
-			}
-			nestedMsgs, err := readV1Continuation(cr, contMsg.Offset, contMsg.Length)
-			if err == nil {
-				messages = append(messages, nestedMsgs...)
-			}
-			continue
-		}
-
-		msg, err := message.Parse(message.Type(msgType), data, flags, cr)
-		if err != nil {
-			continue
-		}
-
-		messages = append(messages, msg)
-	}
-
-	return messages, nil
+	return conts
 }
@@ -90,39 +90,64 @@ func readV2(r *binary.Reader, address uint64) (*Header, error) {
 	// Track creation order flag (bit 2)
 	trackCreationOrder := flags&0x04 != 0
 
-	// Calculate where messages end (before checksum)
-	chunkEnd := r.Pos() + int64(chunk0Size) - 4
+	// Chunk 0 size covers the messages only; the checksum follows the chunk
+	chunkEnd := r.Pos() + int64(chunk0Size)
+	if err := r.CheckLookup3At("OHDR", address, int(chunkEnd+4-int64(address))); err != nil {
+		return nil, err
+	}
+
+	// Parse messages chunk by chunk, as the HDF5 library lists them: the
+	// messages of a continuation block follow every message of the chunk
+	// pointing to it, wherever the continuation message sits
+	conts := readV2Chunk(r, hdr, chunkEnd, trackCreationOrder)
+	seen := make(map[uint64]bool)
+	for i := 0; i < len(conts); i++ {
+		if seen[conts[i].Offset] {
+			continue
+		}
+		if len(seen) == maxContinuationBlocks {
+			return nil, fmt.Errorf("%w: more than %d continuation blocks", ErrInvalidHeader, maxContinuationBlocks)
+		}
+		seen[conts[i].Offset] = true
+		if err := r.CheckLookup3At("OCHK", conts[i].Offset, int(conts[i].Length)); err != nil {
+			return nil, err
+		}
+		more, err := readV2Continuation(r, hdr, conts[i].Offset, conts[i].Length, trackCreationOrder)
+		if err == nil {
+			conts = append(conts, more...)
+		}
+	}
+
+	return hdr, nil
+}
 
-	// Parse messages
-	for r.Pos() < chunkEnd {
-		msg, err := readV2Message(r, trackCreationOrder)
+// readV2Chunk appends the messages before chunkEnd to hdr and returns the
+// continuation messages among them.
+func readV2Chunk(r *binary.Reader, hdr *Header, chunkEnd int64, trackCreationOrder bool) []*message.Continuation {
+	var conts []*message.Continuation
+	// A gap smaller than a message prefix at the end of a chunk is padding
+	prefixSize := int64(4)
+	if trackCreationOrder {
+		prefixSize += 2
+	}
+	for chunkEnd-r.Pos() >= prefixSize {
+		msg, err := readV2Message(r, hdr, trackCreationOrder)
 		if err != nil {
 			break
 		}
-		if msg != nil {
-			// Handle continuation message
-			if cont, ok := msg.(*message.Continuation); ok {
-				contMsgs, err := readV2Continuation(r, cont.Offset, cont.Length, trackCreationOrder)
-				if err == nil {
-					hdr.Messages = append(hdr.Messages, contMsgs...)
-				}
-				continue
-			}
+		if cont, ok := msg.(*message.Continuation); ok {
+			conts = append(conts, cont)
+		} else if msg != nil {
 			hdr.Messages = append(hdr.Messages, msg)
 		}
 	}
-
-	// Skip to checksum and verify
-	// For now, we skip checksum verification in v2 message reading
-	// (it's validated at a higher level if needed)
-
-	return hdr, nil
+	return conts
 }
 
-// readV2Continuation reads messages from a v2 continuation block.
-func readV2Continuation(r *binary.Reader, offset, length uint64, trackCreationOrder bool) ([]message.Message, error) {
+// readV2Continuation appends the messages of a v2 continuation block to hdr
+// and returns the continuation messages among them.
+func readV2Continuation(r *binary.Reader, hdr *Header, offset, length uint64, trackCreationOrder bool) ([]*message.Continuation, error) {
 	cr := r.At(int64(offset))
-	var messages []message.Message
 
 	// V2 continuation blocks have: signature "OCHK" (4 bytes) + messages + checksum (4 bytes)
 	sig, err := cr.ReadBytes(4)
@@ -135,29 +160,10 @@ func readV2Continuation(r *binary.Reader, offset, length uint64, trackCreationOr
 
 	// Calculate where messages end (before checksum)
 	chunkEnd := int64(offset) + int64(length) - 4
-
-	for cr.Pos() < chunkEnd {
-		msg, err := readV2Message(cr, trackCreationOrder)
-		if err != nil {
-			break
-		}
-		if msg != nil {
-			// Handle nested continuation (unlikely but possible)
-			if cont, ok := msg.(*message.Continuation); ok {
-				nestedMsgs, err := readV2Continuation(r, cont.Offset, cont.Length, trackCreationOrder)
-				if err == nil {
-					messages = append(messages, nestedMsgs...)
-				}
-				continue
-			}
-			messages = append(messages, msg)
-		}
-	}
-
-	return messages, nil
+	return readV2Chunk(cr, hdr, chunkEnd, trackCreationOrder), nil
 }
 
-func readV2Message(r *binary.Reader, trackCreationOrder bool) (message.Message, error) {
+func readV2Message(r *binary.Reader, hdr *Header, trackCreationOrder bool) (message.Message, error) {
 	firstByte, err := r.ReadUint8()
 	if err != nil {
 		return nil, err
@@ -192,8 +198,11 @@ func readV2Message(r *binary.Reader, trackCreationOrder bool) (message.Message,
 	}
 
 	// Optional creation order
+	var creationOrder uint16
 	if trackCreationOrder {
-		r.Skip(2)
+		if creationOrder, err = r.ReadUint16(); err != nil {
+			return nil, err
+		}
 	}
 
 	// Read message data
@@ -208,5 +217,12 @@ func readV2Message(r *binary.Reader, trackCreationOrder bool) (message.Message,
 	}
 
 	// Parse the message
-	return message.Parse(message.Type(msgType), data, flags, r)
+	msg, err := hdr.parseMessage(message.Type(msgType), data, flags, r)
+	if err != nil {
+		return nil, err
+	}
+	if attr, ok := msg.(*message.Attribute); ok {
+		attr.CreationOrder = uint32(creationOrder)
+	}
+	return msg, nil
 }
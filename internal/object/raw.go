@@ -0,0 +1,301 @@
+package object
+
+import (
+	"fmt"
+
+	"github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// RawMessage describes the framing of a single header message.
+// The message body is returned as raw bytes and is never interpreted.
+type RawMessage struct {
+	// Offset is the file address of the message's framing (its type field)
+	Offset uint64
+
+	// DataOffset is the file address of the message body
+	DataOffset uint64
+
+	// Type is the declared message type
+	Type message.Type
+
+	// Size is the declared body size
+	Size uint32
+
+	// Flags are the message flags
+	Flags uint8
+
+	// CreationOrder is the message creation order (v2 headers that track it)
+	CreationOrder uint16
+
+	// Chunk is the index of the header chunk containing the message
+	// (0 for the header itself, then continuation blocks in discovery order)
+	Chunk int
+
+	// Data is the raw message body
+	Data []byte
+}
+
+// RawChunk describes one contiguous block of header messages.
+type RawChunk struct {
+	Address uint64 // File address of the chunk's first message
+	Length  uint64 // Declared length of the message area
+}
+
+// RawHeader is the framing-level view of an object header.
+type RawHeader struct {
+	Version  uint8
+	Address  uint64
+	Flags    uint8
+	Chunks   []RawChunk
+	Messages []RawMessage
+
+	// FramingError is set when the framing itself became unreadable;
+	// FramingErrorOffset is the file address where that happened.
+	// Messages framed before that point are still returned.
+	FramingError       error
+	FramingErrorOffset uint64
+}
+
+// ReadRaw walks the message framing of the object header at address without
+// parsing any message bodies. Continuation messages are followed so that every
+// chunk is covered. An error is returned only if the header prefix cannot be
+// read; problems further in are recorded in FramingError.
+func ReadRaw(r *binary.Reader, address uint64) (*RawHeader, error) {
+	hr := r.At(int64(address))
+
+	peek, err := hr.Peek(4)
+	if err != nil {
+		return nil, fmt.Errorf("reading object header: %w", err)
+	}
+
+	raw := &RawHeader{Address: address}
+	if string(peek) == "OHDR" {
+		err = readRawV2(hr, raw)
+	} else if peek[0] == 1 {
+		err = readRawV1(hr, raw)
+	} else {
+		err = fmt.Errorf("%w: unknown format at address %d", ErrInvalidHeader, address)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// fail records the first framing error.
+func (raw *RawHeader) fail(offset int64, err error) {
+	if raw.FramingError == nil {
+		raw.FramingError = err
+		raw.FramingErrorOffset = uint64(offset)
+	}
+}
+
+func readRawV1(r *binary.Reader, raw *RawHeader) error {
+	version, err := r.ReadUint8()
+	if err != nil {
+		return err
+	}
+	r.Skip(1)                                 // Reserved
+	if _, err := r.ReadUint16(); err != nil { // Number of messages
+		return err
+	}
+	if _, err := r.ReadUint32(); err != nil { // Reference count
+		return err
+	}
+	headerSize, err := r.ReadUint32()
+	if err != nil {
+		return err
+	}
+	raw.Version = version
+
+	r.Skip(4) // Reserved
+
+	pending := []RawChunk{{Address: uint64(r.Pos()), Length: uint64(headerSize)}}
+	seen := make(map[uint64]bool)
+
+	for len(pending) > 0 {
+		chunk := pending[0]
+		pending = pending[1:]
+		if seen[chunk.Address] {
+			raw.fail(int64(chunk.Address), fmt.Errorf("continuation loop at address %d", chunk.Address))
+			continue
+		}
+		seen[chunk.Address] = true
+		index := len(raw.Chunks)
+		raw.Chunks = append(raw.Chunks, chunk)
+
+		cr := r.At(int64(chunk.Address))
+		end := int64(chunk.Address + chunk.Length)
+		for end-cr.Pos() >= 8 {
+			start := cr.Pos()
+			msgType, err := cr.ReadUint16()
+			if err != nil {
+				raw.fail(start, fmt.Errorf("reading message type: %w", err))
+				break
+			}
+			dataSize, err := cr.ReadUint16()
+			if err != nil {
+				raw.fail(start, fmt.Errorf("reading message size: %w", err))
+				break
+			}
+			flags, err := cr.ReadUint8()
+			if err != nil {
+				raw.fail(start, fmt.Errorf("reading message flags: %w", err))
+				break
+			}
+			cr.Skip(3) // Reserved
+
+			msg := RawMessage{
+				Offset:     uint64(start),
+				DataOffset: uint64(cr.Pos()),
+				Type:       message.Type(msgType),
+				Size:       uint32(dataSize),
+				Flags:      flags,
+				Chunk:      index,
+			}
+			if cr.Pos()+int64(dataSize) > end {
+				raw.fail(start, fmt.Errorf("message size %d overruns header chunk", dataSize))
+				break
+			}
+			msg.Data, err = cr.ReadBytes(int(dataSize))
+			if err != nil {
+				raw.fail(start, fmt.Errorf("reading message body: %w", err))
+				break
+			}
+			if pad := (cr.Pos() - int64(chunk.Address)) % 8; pad != 0 {
+				cr.Skip(8 - pad)
+			}
+
+			raw.Messages = append(raw.Messages, msg)
+			if msg.Type == message.TypeObjectHeaderContinuation {
+				if cont, err := message.ParseContinuation(msg.Data, cr); err == nil {
+					pending = append(pending, RawChunk{Address: cont.Offset, Length: cont.Length})
+				} else {
+					raw.fail(start, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func readRawV2(r *binary.Reader, raw *RawHeader) error {
+	r.Skip(4) // Signature
+	version, err := r.ReadUint8()
+	if err != nil {
+		return err
+	}
+	flags, err := r.ReadUint8()
+	if err != nil {
+		return err
+	}
+	raw.Version = version
+	raw.Flags = flags
+
+	if flags&0x20 != 0 {
+		r.Skip(16) // Timestamps
+	}
+	if flags&0x10 != 0 {
+		r.Skip(4) // Attribute phase change values
+	}
+	chunk0Size, err := r.ReadUintN(1 << (flags & 0x03))
+	if err != nil {
+		return err
+	}
+	trackCreationOrder := flags&0x04 != 0
+
+	// The chunk #0 size covers only its messages; continuation block lengths
+	// also include the "OCHK" signature and the trailing checksum.
+	pending := []RawChunk{{Address: uint64(r.Pos()), Length: chunk0Size}}
+	seen := make(map[uint64]bool)
+
+	for len(pending) > 0 {
+		chunk := pending[0]
+		pending = pending[1:]
+		if seen[chunk.Address] {
+			raw.fail(int64(chunk.Address), fmt.Errorf("continuation loop at address %d", chunk.Address))
+			continue
+		}
+		seen[chunk.Address] = true
+		index := len(raw.Chunks)
+
+		cr := r.At(int64(chunk.Address))
+		end := int64(chunk.Address + chunk.Length)
+		if index > 0 {
+			end -= 4
+			sig, err := cr.ReadBytes(4)
+			if err != nil || string(sig) != "OCHK" {
+				raw.fail(int64(chunk.Address), fmt.Errorf("invalid continuation block signature at address %d", chunk.Address))
+				continue
+			}
+		}
+		raw.Chunks = append(raw.Chunks, chunk)
+
+		// A gap smaller than a message prefix at the end of a chunk is padding
+		for cr.Pos()+4 <= end {
+			start := cr.Pos()
+			msg := RawMessage{Offset: uint64(start), Chunk: index}
+
+			first, err := cr.ReadUint8()
+			if err != nil {
+				raw.fail(start, fmt.Errorf("reading message type: %w", err))
+				break
+			}
+			if first == 0xFF {
+				typ, err := cr.ReadUint8()
+				if err != nil {
+					raw.fail(start, fmt.Errorf("reading message type: %w", err))
+					break
+				}
+				size, err := cr.ReadUint32()
+				if err != nil {
+					raw.fail(start, fmt.Errorf("reading message size: %w", err))
+					break
+				}
+				msg.Type = message.Type(typ)
+				msg.Size = size
+			} else {
+				size, err := cr.ReadUint16()
+				if err != nil {
+					raw.fail(start, fmt.Errorf("reading message size: %w", err))
+					break
+				}
+				msg.Type = message.Type(first)
+				msg.Size = uint32(size)
+			}
+			if msg.Flags, err = cr.ReadUint8(); err != nil {
+				raw.fail(start, fmt.Errorf("reading message flags: %w", err))
+				break
+			}
+			if trackCreationOrder {
+				if msg.CreationOrder, err = cr.ReadUint16(); err != nil {
+					raw.fail(start, fmt.Errorf("reading creation order: %w", err))
+					break
+				}
+			}
+
+			msg.DataOffset = uint64(cr.Pos())
+			if cr.Pos()+int64(msg.Size) > end {
+				raw.fail(start, fmt.Errorf("message size %d overruns header chunk", msg.Size))
+				break
+			}
+			if msg.Data, err = cr.ReadBytes(int(msg.Size)); err != nil {
+				raw.fail(start, fmt.Errorf("reading message body: %w", err))
+				break
+			}
+
+			raw.Messages = append(raw.Messages, msg)
+			if msg.Type == message.TypeObjectHeaderContinuation {
+				if cont, err := message.ParseContinuation(msg.Data, cr); err == nil {
+					pending = append(pending, RawChunk{Address: cont.Offset, Length: cont.Length})
+				} else {
+					raw.fail(start, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package object
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/fheap"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// resolveShared replaces the shared messages of h, and the shared datatypes
+// and dataspaces of its attributes, with the messages they refer to.
+func (h *Header) resolveShared(r *binary.Reader) error {
+	for i, msg := range h.Messages {
+		resolved, err := ResolveShared(r, msg)
+		if err != nil {
+			return err
+		}
+		h.Messages[i] = resolved
+	}
+	return nil
+}
+
+// ResolveShared returns the message msg refers to if it is a
+// message.SharedRef, and msg itself otherwise. The shared datatype and
+// dataspace of an attribute are looked up and set in place, and its value
+// trimmed to the size they give it.
+func ResolveShared(r *binary.Reader, msg message.Message) (message.Message, error) {
+	switch m := msg.(type) {
+	case *message.SharedRef:
+		return lookupShared(r, m)
+
+	case *message.Attribute:
+		if m.DatatypeRef == nil && m.DataspaceRef == nil {
+			break
+		}
+		if m.DatatypeRef != nil {
+			msg, err := lookupShared(r, m.DatatypeRef)
+			if err != nil {
+				return nil, fmt.Errorf("attribute %q: %w", m.Name, err)
+			}
+			dt, ok := msg.(*message.Datatype)
+			if !ok {
+				return nil, fmt.Errorf("attribute %q: shared datatype could not be parsed", m.Name)
+			}
+			m.Datatype, m.DatatypeRef = dt, nil
+		}
+		if m.DataspaceRef != nil {
+			msg, err := lookupShared(r, m.DataspaceRef)
+			if err != nil {
+				return nil, fmt.Errorf("attribute %q: %w", m.Name, err)
+			}
+			ds, ok := msg.(*message.Dataspace)
+			if !ok {
+				return nil, fmt.Errorf("attribute %q: shared dataspace could not be parsed", m.Name)
+			}
+			m.Dataspace, m.DataspaceRef = ds, nil
+		}
+		// The value was taken to run to the end of the message
+		if m.Datatype != nil && m.Dataspace != nil {
+			size, ok := m.Dataspace.DataSize(uint64(m.Datatype.Size))
+			if !ok || size > uint64(len(m.Data)) {
+				return nil, fmt.Errorf("attribute %q: data truncated: need %d bytes", m.Name, size)
+			}
+			m.Data = m.Data[:size]
+		}
+	}
+	return msg, nil
+}
+
+// lookupShared reads the message a shared message refers to, from the
+// header of another object or from the shared object header message heap.
+// The message found must not itself be shared.
+func lookupShared(r *binary.Reader, ref *message.SharedRef) (message.Message, error) {
+	var msg message.Message
+	switch ref.Location {
+	case message.SharedInHeader:
+		hdr, err := readHeader(r, ref.Address)
+		if err != nil {
+			return nil, fmt.Errorf("reading shared %s message at %d: %w", ref.MsgType, ref.Address, err)
+		}
+		msg = hdr.GetMessage(ref.MsgType)
+		if msg == nil {
+			return nil, fmt.Errorf("%w: object at %d has no %s message to share", ErrInvalidHeader, ref.Address, ref.MsgType)
+		}
+
+	case message.SharedInHeap:
+		data, err := r.SharedMessage(uint16(ref.MsgType), ref.HeapID)
+		if err != nil {
+			return nil, fmt.Errorf("reading shared %s message: %w", ref.MsgType, err)
+		}
+		if msg, err = message.Parse(ref.MsgType, data, 0, r); err != nil {
+			return nil, fmt.Errorf("parsing shared %s message: %w", ref.MsgType, err)
+		}
+	}
+
+	if attr, ok := msg.(*message.Attribute); ok && (attr.DatatypeRef != nil || attr.DataspaceRef != nil) {
+		return ResolveShared(r, msg)
+	}
+	if _, ok := msg.(*message.SharedRef); ok {
+		return nil, fmt.Errorf("%w: shared %s message refers to another shared message", ErrInvalidHeader, ref.MsgType)
+	}
+	return msg, nil
+}
+
+// smtbSignature starts the table of shared object header message indexes.
+var smtbSignature = []byte("SMTB")
+
+// SharedMessageTable is the table of indexes of a file's shared object
+// header messages. Each index keeps the messages of some types in a
+// fractal heap, where messages flagged shared are found by their heap ID.
+type SharedMessageTable struct {
+	indexes []sharedIndex
+
+	mu    sync.Mutex
+	heaps map[uint64]*fheap.FractalHeap // Loaded heaps by address
+}
+
+// sharedIndex is one index of a SharedMessageTable.
+type sharedIndex struct {
+	typeFlags uint16 // Bit 1<<t set for each message type t the index holds
+	heapAddr  uint64
+}
+
+// ReadSharedMessageTable reads the table of numIndexes shared object header
+// message indexes at address, as a shared message table message locates it.
+func ReadSharedMessageTable(r *binary.Reader, address uint64, numIndexes int) (*SharedMessageTable, error) {
+	tr := r.At(int64(address))
+	sig, err := tr.ReadBytes(4)
+	if err != nil {
+		return nil, fmt.Errorf("reading shared message table: %w", err)
+	}
+	if string(sig) != string(smtbSignature) {
+		return nil, fmt.Errorf("invalid shared message table signature: got %q, expected \"SMTB\"", string(sig))
+	}
+
+	// Version, index type, message type flags, minimum message size, list
+	// and B-tree cutoffs, number of messages, then the index and heap
+	// addresses
+	entrySize := 14 + 2*r.OffsetSize()
+	table := &SharedMessageTable{
+		indexes: make([]sharedIndex, numIndexes),
+		heaps:   make(map[uint64]*fheap.FractalHeap),
+	}
+	for i := range table.indexes {
+		tr.Skip(2)
+		flags, err := tr.ReadUint16()
+		if err != nil {
+			return nil, fmt.Errorf("reading shared message index %d: %w", i, err)
+		}
+		tr.Skip(10 + int64(r.OffsetSize()))
+		heapAddr, err := tr.ReadOffset()
+		if err != nil {
+			return nil, fmt.Errorf("reading shared message index %d: %w", i, err)
+		}
+		table.indexes[i] = sharedIndex{typeFlags: flags, heapAddr: heapAddr}
+	}
+	if err := r.CheckLookup3At("SMTB", address, 4+numIndexes*entrySize+4); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// Message returns the body of the message of type typ stored under the
+// heap ID id, from the heap of the index holding messages of that type.
+func (t *SharedMessageTable) Message(r *binary.Reader, typ message.Type, id []byte) ([]byte, error) {
+	for _, index := range t.indexes {
+		if typ >= 16 || index.typeFlags&(1<<typ) == 0 {
+			continue
+		}
+		heap, err := t.heap(r, index.heapAddr)
+		if err != nil {
+			return nil, err
+		}
+		return heap.Object(id)
+	}
+	return nil, fmt.Errorf("no shared message index holds %s messages", typ)
+}
+
+// heap returns the fractal heap at addr, reading it on first use.
+func (t *SharedMessageTable) heap(r *binary.Reader, addr uint64) (*fheap.FractalHeap, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if heap, ok := t.heaps[addr]; ok {
+		return heap, nil
+	}
+	heap, err := fheap.ReadFractalHeap(r, addr)
+	if err != nil {
+		return nil, fmt.Errorf("reading shared message heap: %w", err)
+	}
+	t.heaps[addr] = heap
+	return heap, nil
+}
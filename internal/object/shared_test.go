@@ -0,0 +1,149 @@
+package object
+
+import (
+	"bytes"
+	stdbinary "encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// serialize returns the body of msg.
+func serialize(t *testing.T, msg message.Serializable) []byte {
+	t.Helper()
+	b := &bufferWriterAt{}
+	if err := msg.Serialize(binary.NewWriter(b, binary.DefaultConfig())); err != nil {
+		t.Fatalf("serializing %s message: %v", msg.Type(), err)
+	}
+	return b.buf
+}
+
+// v2Header returns a single-chunk v2 header holding messages, each given
+// as its type, flags and body, followed by gap bytes of padding.
+func v2Header(gap int, messages ...any) []byte {
+	var chunk []byte
+	for i := 0; i < len(messages); i += 3 {
+		body := messages[i+2].([]byte)
+		chunk = append(chunk, byte(messages[i].(message.Type)))
+		chunk = stdbinary.LittleEndian.AppendUint16(chunk, uint16(len(body)))
+		chunk = append(chunk, messages[i+1].(byte))
+		chunk = append(chunk, body...)
+	}
+	chunk = append(chunk, make([]byte, gap)...)
+	hdr := append([]byte("OHDR"), 2, 0x01)
+	hdr = stdbinary.LittleEndian.AppendUint16(hdr, uint16(len(chunk)))
+	hdr = append(hdr, chunk...)
+	return append(hdr, 0, 0, 0, 0) // Checksum, not verified
+}
+
+// sharedRefV3 returns the body of a version 3 shared message stored in the
+// header at address.
+func sharedRefV3(address uint64) []byte {
+	return stdbinary.LittleEndian.AppendUint64([]byte{3, message.SharedInHeader}, address)
+}
+
+func TestReadSharedDatatype(t *testing.T) {
+	// A committed datatype at address 0, used by the datatype message of a
+	// dataset and by the datatype of its attribute
+	i32 := message.NewFixedPointDatatype(4, true, message.OrderLE)
+	committed := v2Header(0, message.TypeDatatype, byte(0), serialize(t, i32))
+	addr := uint64(len(committed))
+
+	ref := sharedRefV3(0)
+	space := serialize(t, message.NewScalarDataspace())
+	attr := []byte{3, 0x01, 2, 0, byte(len(ref)), 0, byte(len(space)), 0, 0, 'a', 0}
+	attr = append(attr, ref...)
+	attr = append(attr, space...)
+	attr = append(attr, 42, 0, 0, 0, 0xEE) // Value, then padding
+
+	dataset := v2Header(0,
+		message.TypeDataspace, byte(0), serialize(t, message.NewDataspace([]uint64{3}, nil)),
+		message.TypeDatatype, byte(message.FlagShared), ref,
+		message.TypeAttribute, byte(0), attr,
+	)
+	r := binary.NewReader(bytes.NewReader(append(committed, dataset...)), binary.DefaultConfig())
+	h, err := Read(r, addr)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if dt := h.Datatype(); dt == nil || dt.Class != message.ClassFixedPoint || dt.Size != 4 {
+		t.Errorf("datatype = %+v, want the committed 4-byte integer", h.Datatype())
+	}
+	a, ok := h.GetMessage(message.TypeAttribute).(*message.Attribute)
+	if !ok {
+		t.Fatal("attribute not found")
+	}
+	if a.DatatypeRef != nil || a.Datatype == nil || a.Datatype.Size != 4 {
+		t.Errorf("attribute datatype = %+v, want the committed 4-byte integer", a.Datatype)
+	}
+	if !bytes.Equal(a.Data, []byte{42, 0, 0, 0}) {
+		t.Errorf("attribute data = %v, want [42 0 0 0]", a.Data)
+	}
+
+	// The committed datatype must hold the message referred to
+	r = binary.NewReader(bytes.NewReader(append(committed, v2Header(0,
+		message.TypeDataspace, byte(message.FlagShared), ref)...)), binary.DefaultConfig())
+	if _, err := Read(r, addr); err == nil {
+		t.Error("Read succeeded with a shared dataspace missing from its header")
+	}
+}
+
+func TestReadSharedHeapMessage(t *testing.T) {
+	space := serialize(t, message.NewDataspace([]uint64{5, 6}, nil))
+	id := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	ref := append([]byte{3, message.SharedInHeap}, id...)
+	data := v2Header(0, message.TypeDataspace, byte(message.FlagShared), ref)
+
+	cfg := binary.DefaultConfig()
+	cfg.SharedMessages = func(typ uint16, got []byte) ([]byte, error) {
+		if message.Type(typ) != message.TypeDataspace || !bytes.Equal(got, id) {
+			return nil, fmt.Errorf("no %d message under heap ID %v", typ, got)
+		}
+		return space, nil
+	}
+	h, err := Read(binary.NewReader(bytes.NewReader(data), cfg), 0)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if ds := h.Dataspace(); ds == nil || len(ds.Dimensions) != 2 || ds.Dimensions[1] != 6 {
+		t.Errorf("dataspace = %+v, want 5x6", h.Dataspace())
+	}
+
+	// Without a shared message table the reference cannot be followed
+	if _, err := Read(binary.NewReader(bytes.NewReader(data), binary.DefaultConfig()), 0); err == nil {
+		t.Error("Read succeeded without shared messages")
+	}
+}
+
+func TestReadChunkGaps(t *testing.T) {
+	a := attributeBody(t, "a")
+
+	// A v2 chunk ending in a gap too small for a message
+	data := v2Header(3, message.TypeAttribute, byte(0), a)
+	h, err := Read(binary.NewReader(bytes.NewReader(data), binary.DefaultConfig()), 0)
+	if err != nil {
+		t.Fatalf("Read v2 failed: %v", err)
+	}
+	if names, _ := attrNames(h); len(names) != 1 || names[0] != "a" {
+		t.Errorf("v2 attributes = %v, want [a]", names)
+	}
+
+	// A v1 header at an address that is not a multiple of 8, whose chunk
+	// ends in a 4-byte gap
+	for len(a)%8 != 0 {
+		a = append(a, 0)
+	}
+	msg := append([]byte{0x0C, 0, byte(len(a)), 0, 0, 0, 0, 0}, a...)
+	chunk := []byte{1, 0, 1, 0, 1, 0, 0, 0, byte(len(msg) + 4), 0, 0, 0, 0, 0, 0, 0}
+	chunk = append(append(chunk, msg...), 0xFF, 0xFF, 0xFF, 0xFF)
+	data = append([]byte{0, 0, 0}, chunk...)
+	h, err = Read(binary.NewReader(bytes.NewReader(data), binary.DefaultConfig()), 3)
+	if err != nil {
+		t.Fatalf("Read v1 failed: %v", err)
+	}
+	if names, _ := attrNames(h); len(names) != 1 || names[0] != "a" {
+		t.Errorf("v1 attributes = %v, want [a]", names)
+	}
+}
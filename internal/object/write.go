@@ -1,6 +1,8 @@
 package object
 
 import (
+	"math/bits"
+
 	"github.com/robert-malhotra/go-hdf5/internal/binary"
 	"github.com/robert-malhotra/go-hdf5/internal/message"
 )
@@ -31,24 +33,11 @@ func WriteHeaderWithMinChunk(w *binary.Writer, messages []message.Message, minCh
 		}
 	}
 
-	// Chunk size = messages only (checksum is written separately after)
-	chunkSize := messagesSize
-
-	// Apply minimum chunk size (for compatibility with h5py)
-	if minChunkSize > 0 && chunkSize < minChunkSize {
-		chunkSize = minChunkSize
-	}
-
-	// Calculate padding needed (NIL message)
-	// ChunkSize = messages + padding (checksum is separate)
-	paddingSize := chunkSize - messagesSize
-	if paddingSize < 0 {
-		paddingSize = 0
-	}
+	chunkSize, paddingSize := chunkPadding(messagesSize, minChunkSize)
 
 	// Determine chunk size field size
 	chunkSizeFieldSize := chunkSizeFieldBytes(int64(chunkSize))
-	flags := uint8(chunkSizeFieldSize - 1)
+	flags := uint8(bits.TrailingZeros(uint(chunkSizeFieldSize))) // Bits 0-1: log2 of the field size
 
 	// Calculate total header size for buffering
 	// signature(4) + version(1) + flags(1) + chunkSize(var) + messages + padding + checksum(4)
@@ -118,7 +107,7 @@ func WriteHeaderWithMinChunk(w *binary.Writer, messages []message.Message, minCh
 
 	// Calculate checksum (over entire header except the checksum itself)
 	checksumData := buf[:bw.Pos()]
-	checksum := binary.Lookup3Checksum(checksumData)
+	checksum := binary.ChecksumLookup3(checksumData, 0)
 
 	// Write checksum
 	if err := bw.WriteUint32(checksum); err != nil {
@@ -165,8 +154,12 @@ func writeV2Message(w *binary.Writer, msg message.Message) error {
 		}
 	}
 
-	// Flags (0 = no special flags)
-	if err := w.WriteUint8(0); err != nil {
+	// Flags: references to shared messages are marked shared
+	flags := uint8(0)
+	if _, ok := msg.(*message.SharedRef); ok {
+		flags = message.FlagShared
+	}
+	if err := w.WriteUint8(flags); err != nil {
 		return err
 	}
 
@@ -220,6 +213,22 @@ func (b *bufferWriterAt) WriteAt(p []byte, off int64) (n int, err error) {
 	return len(p), nil
 }
 
+// chunkPadding returns the chunk size of a V2 object header with messages
+// of messagesSize bytes, which is the messages only (the checksum is
+// written separately after) raised to minChunkSize for compatibility with
+// h5py, and the size of the NIL message padding the messages to it. The
+// chunk grows further if the padding would be too small for the 4-byte NIL
+// message header.
+func chunkPadding(messagesSize, minChunkSize int) (chunkSize, paddingSize int) {
+	chunkSize = max(messagesSize, minChunkSize)
+	paddingSize = chunkSize - messagesSize
+	if paddingSize > 0 && paddingSize < 4 {
+		chunkSize += 4 - paddingSize
+		paddingSize = 4
+	}
+	return chunkSize, paddingSize
+}
+
 // HeaderSize calculates the total size of a V2 object header with the given messages.
 func HeaderSize(w *binary.Writer, messages []message.Message) int {
 	return HeaderSizeWithMinChunk(w, messages, 0)
@@ -236,18 +245,7 @@ func HeaderSizeWithMinChunk(w *binary.Writer, messages []message.Message, minChu
 		}
 	}
 
-	// Chunk size = messages only (checksum is separate)
-	chunkSize := messagesSize
-	if minChunkSize > 0 && chunkSize < minChunkSize {
-		chunkSize = minChunkSize
-	}
-
-	// Calculate padding (NIL message to reach minChunkSize)
-	paddingSize := chunkSize - messagesSize
-	if paddingSize < 0 {
-		paddingSize = 0
-	}
-
+	chunkSize, paddingSize := chunkPadding(messagesSize, minChunkSize)
 	chunkSizeFieldSize := chunkSizeFieldBytes(int64(chunkSize))
 
 	// signature(4) + version(1) + flags(1) + chunkSize(var) + messages + padding + checksum(4)
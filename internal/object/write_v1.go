@@ -0,0 +1,125 @@
+package object
+
+import (
+	"github.com/robert-malhotra/go-hdf5/internal/binary"
+	"github.com/robert-malhotra/go-hdf5/internal/message"
+)
+
+// v1PrefixSize is the size of a V1 object header prefix, including the
+// padding that aligns the first message to 8 bytes.
+const v1PrefixSize = 16
+
+// WriteHeaderV1 writes a V1 object header, as files with a version 0 or 1
+// superblock use, at the current writer position. Messages are padded to
+// 8 bytes, and a NIL message fills the header to at least minSize bytes of
+// messages. The writer position should be 8-byte aligned, as message
+// alignment is relative to the file. Returns the total bytes written.
+func WriteHeaderV1(w *binary.Writer, messages []message.Message, minSize int) (int64, error) {
+	serializable := v1Messages(messages)
+	messagesSize, paddingSize := v1BodySizes(w, serializable, minSize)
+
+	buf := make([]byte, v1PrefixSize+messagesSize+paddingSize)
+	bw := binary.NewWriter(&bufferWriterAt{buf: buf}, binary.Config{
+		ByteOrder:  w.ByteOrder(),
+		OffsetSize: w.OffsetSize(),
+		LengthSize: w.LengthSize(),
+	})
+
+	// Version, reserved byte, number of messages (NIL included), reference
+	// count and size of the messages, then padding to 8 bytes
+	numMessages := len(serializable)
+	if paddingSize > 0 {
+		numMessages++
+	}
+	if err := bw.WriteUint8(1); err != nil {
+		return 0, err
+	}
+	if err := bw.WriteUint8(0); err != nil {
+		return 0, err
+	}
+	if err := bw.WriteUint16(uint16(numMessages)); err != nil {
+		return 0, err
+	}
+	if err := bw.WriteUint32(1); err != nil {
+		return 0, err
+	}
+	if err := bw.WriteUint32(uint32(messagesSize + paddingSize)); err != nil {
+		return 0, err
+	}
+	if err := bw.WriteZeros(4); err != nil {
+		return 0, err
+	}
+
+	for _, s := range serializable {
+		size := s.SerializedSize(w)
+		if err := writeV1MessagePrefix(bw, s.Type(), alignV1(size)); err != nil {
+			return 0, err
+		}
+		start := bw.Pos()
+		if err := s.Serialize(bw); err != nil {
+			return 0, err
+		}
+		// The buffer is zeroed, so skipping to the next message pads
+		bw.Skip(start + int64(alignV1(size)) - bw.Pos())
+	}
+	if paddingSize > 0 {
+		if err := writeV1MessagePrefix(bw, message.TypeNIL, paddingSize-8); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := w.WriteBytes(buf); err != nil {
+		return 0, err
+	}
+	return int64(len(buf)), nil
+}
+
+// HeaderSizeV1 calculates the total size of a V1 object header with the
+// given messages and minimum size of messages.
+func HeaderSizeV1(w *binary.Writer, messages []message.Message, minSize int) int {
+	messagesSize, paddingSize := v1BodySizes(w, v1Messages(messages), minSize)
+	return v1PrefixSize + messagesSize + paddingSize
+}
+
+// v1Messages returns the messages that can be written.
+func v1Messages(messages []message.Message) []message.Serializable {
+	var serializable []message.Serializable
+	for _, msg := range messages {
+		if s, ok := msg.(message.Serializable); ok {
+			serializable = append(serializable, s)
+		}
+	}
+	return serializable
+}
+
+// v1BodySizes returns the size of the messages of a V1 header and of the
+// NIL message padding them to minSize. Padding too small for a NIL
+// message's prefix is not added.
+func v1BodySizes(w *binary.Writer, messages []message.Serializable, minSize int) (int, int) {
+	var messagesSize int
+	for _, s := range messages {
+		messagesSize += 8 + alignV1(s.SerializedSize(w))
+	}
+	paddingSize := alignV1(minSize) - messagesSize
+	if paddingSize < 8 {
+		paddingSize = 0
+	}
+	return messagesSize, paddingSize
+}
+
+// writeV1MessagePrefix writes the type, size, flags and reserved bytes
+// before a V1 message body.
+func writeV1MessagePrefix(w *binary.Writer, typ message.Type, size int) error {
+	if err := w.WriteUint16(uint16(typ)); err != nil {
+		return err
+	}
+	if err := w.WriteUint16(uint16(size)); err != nil {
+		return err
+	}
+	return w.WriteZeros(4)
+}
+
+// alignV1 rounds n up to the 8-byte alignment of V1 messages.
+func alignV1(n int) int {
+	return (n + 7) &^ 7
+}
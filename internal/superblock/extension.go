@@ -0,0 +1,50 @@
+package superblock
+
+import "github.com/robert-malhotra/go-hdf5/internal/message"
+
+// Extension holds the messages of a superblock extension, the object
+// header a v2 or v3 superblock points to for file-wide settings that do
+// not fit in the superblock itself.
+type Extension struct {
+	// Address is the address of the extension's object header
+	Address uint64
+
+	// SharedMessageTable locates the shared object header message indexes
+	// (nil if messages are not shared)
+	SharedMessageTable *message.SharedMessageTable
+
+	// DriverInfo holds the settings of the file driver the file was
+	// written with (nil if the driver records none)
+	DriverInfo *message.DriverInfo
+
+	// FileSpaceInfo holds the file space strategy and the addresses of any
+	// persistent free-space managers (nil for the default strategy)
+	FileSpaceInfo *message.FileSpaceInfo
+
+	// Messages holds every message of the extension in header order,
+	// including those above; unrecognized ones are *message.Unknown
+	Messages []message.Message
+}
+
+// NewExtension sorts the messages of the superblock extension header at
+// address by type.
+func NewExtension(address uint64, messages []message.Message) *Extension {
+	ext := &Extension{Address: address, Messages: messages}
+	for _, msg := range messages {
+		switch msg := msg.(type) {
+		case *message.SharedMessageTable:
+			if ext.SharedMessageTable == nil {
+				ext.SharedMessageTable = msg
+			}
+		case *message.DriverInfo:
+			if ext.DriverInfo == nil {
+				ext.DriverInfo = msg
+			}
+		case *message.FileSpaceInfo:
+			if ext.FileSpaceInfo == nil {
+				ext.FileSpaceInfo = msg
+			}
+		}
+	}
+	return ext
+}
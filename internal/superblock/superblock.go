@@ -47,6 +47,10 @@ type Superblock struct {
 	// (v2/v3 only, undefined if not present)
 	SuperblockExtensionAddress uint64
 
+	// Extension holds the messages of the superblock extension, once the
+	// caller has read its object header (nil if not present or not read)
+	Extension *Extension
+
 	// EOFAddress is the end-of-file address (logical EOF)
 	EOFAddress uint64
 
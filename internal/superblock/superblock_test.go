@@ -81,7 +81,7 @@ func TestReadV2SuperblockMinimal(t *testing.T) {
 
 	// Calculate and append checksum
 	data := buf.Bytes()
-	checksum := binpkg.Lookup3Checksum(data)
+	checksum := binpkg.ChecksumLookup3(data, 0)
 	binary.Write(&buf, binary.LittleEndian, checksum)
 
 	// Pad to reasonable size
@@ -134,7 +134,7 @@ func TestReadV2SuperblockWithOffset(t *testing.T) {
 
 	// Checksum
 	data := buf.Bytes()
-	checksum := binpkg.Lookup3Checksum(data)
+	checksum := binpkg.ChecksumLookup3(data, 0)
 	binary.Write(&buf, binary.LittleEndian, checksum)
 
 	// Place at offset 512
@@ -294,3 +294,42 @@ func TestBytesEqual(t *testing.T) {
 		}
 	}
 }
+
+func TestWriteV0V1Roundtrip(t *testing.T) {
+	for _, version := range []uint8{0, 1} {
+		sb := &Superblock{
+			Version:                   version,
+			OffsetSize:                8,
+			LengthSize:                8,
+			GroupLeafNodeK:            4,
+			GroupInternalNodeK:        16,
+			IndexedStorageK:           32,
+			EOFAddress:                4096,
+			RootGroupAddress:          96,
+			RootGroupBTreeAddress:     136,
+			RootGroupLocalHeapAddress: 680,
+		}
+		buf := &bufferWriterAt{}
+		w := binpkg.NewWriter(buf, binpkg.Config{ByteOrder: binary.LittleEndian, OffsetSize: 8, LengthSize: 8})
+		n, err := sb.Write(w)
+		if err != nil {
+			t.Fatalf("version %d: Write failed: %v", version, err)
+		}
+		if int(n) != sb.Size() {
+			t.Errorf("version %d: wrote %d bytes, Size = %d", version, n, sb.Size())
+		}
+
+		got, err := Read(bytesReaderAt(buf.buf))
+		if err != nil {
+			t.Fatalf("version %d: Read failed: %v", version, err)
+		}
+		if got.Version != version || got.EOFAddress != sb.EOFAddress || got.RootGroupAddress != sb.RootGroupAddress ||
+			got.RootGroupBTreeAddress != sb.RootGroupBTreeAddress || got.RootGroupLocalHeapAddress != sb.RootGroupLocalHeapAddress ||
+			got.GroupLeafNodeK != 4 || got.GroupInternalNodeK != 16 {
+			t.Errorf("version %d: read back %+v", version, got)
+		}
+		if version == 1 && got.IndexedStorageK != 32 {
+			t.Errorf("IndexedStorageK = %d, want 32", got.IndexedStorageK)
+		}
+	}
+}
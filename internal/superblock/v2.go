@@ -100,7 +100,7 @@ func readV2V3(r io.ReaderAt, offset int64, version uint8) (*Superblock, error) {
 	storedChecksum := uint32(checksumBuf[0]) | uint32(checksumBuf[1])<<8 |
 		uint32(checksumBuf[2])<<16 | uint32(checksumBuf[3])<<24
 
-	computedChecksum := binpkg.Lookup3Checksum(checksumData)
+	computedChecksum := binpkg.ChecksumLookup3(checksumData, 0)
 	if storedChecksum != computedChecksum {
 		return nil, ErrInvalidSuperblock
 	}
@@ -4,9 +4,12 @@ import (
 	binpkg "github.com/robert-malhotra/go-hdf5/internal/binary"
 )
 
-// Write writes a V2/V3 superblock at the current writer position.
-// Returns the total bytes written.
+// Write writes the superblock in the format of its version at the current
+// writer position. Returns the total bytes written.
 func (sb *Superblock) Write(w *binpkg.Writer) (int64, error) {
+	if sb.Version == 0 || sb.Version == 1 {
+		return sb.writeV0V1(w)
+	}
 	startPos := w.Pos()
 
 	// Calculate header size for checksum
@@ -77,7 +80,7 @@ func (sb *Superblock) Write(w *binpkg.Writer) (int64, error) {
 
 	// Calculate checksum over header (before checksum field)
 	checksumData := buf[:bw.Pos()]
-	checksum := binpkg.Lookup3Checksum(checksumData)
+	checksum := binpkg.ChecksumLookup3(checksumData, 0)
 
 	// Write checksum
 	if err := bw.WriteUint32(checksum); err != nil {
@@ -92,15 +95,115 @@ func (sb *Superblock) Write(w *binpkg.Writer) (int64, error) {
 	return w.Pos() - startPos, nil
 }
 
-// Size returns the size in bytes of a V2/V3 superblock.
+// writeV0V1 writes a version 0 or 1 superblock, which ends with the root
+// group's symbol table entry. The entry caches the root group's B-tree and
+// local heap addresses when they are known.
+func (sb *Superblock) writeV0V1(w *binpkg.Writer) (int64, error) {
+	buf := make([]byte, sb.Size())
+	bw := binpkg.NewWriter(&bufferWriterAt{buf: buf}, binpkg.Config{
+		ByteOrder:  w.ByteOrder(),
+		OffsetSize: w.OffsetSize(),
+		LengthSize: w.LengthSize(),
+	})
+
+	// Signature, versions of the superblock, free-space storage, root
+	// group symbol table entry and shared header messages, then the sizes
+	fields := []uint8{sb.Version, sb.FreeSpaceManagerVersion, 0, 0, 0, sb.OffsetSize, sb.LengthSize, 0}
+	if err := bw.WriteBytes(Signature); err != nil {
+		return 0, err
+	}
+	if err := bw.WriteBytes(fields); err != nil {
+		return 0, err
+	}
+	if err := bw.WriteUint16(sb.GroupLeafNodeK); err != nil {
+		return 0, err
+	}
+	if err := bw.WriteUint16(sb.GroupInternalNodeK); err != nil {
+		return 0, err
+	}
+	if err := bw.WriteUint32(uint32(sb.FileConsistencyFlags)); err != nil {
+		return 0, err
+	}
+	if sb.Version == 1 {
+		if err := bw.WriteUint16(sb.IndexedStorageK); err != nil {
+			return 0, err
+		}
+		if err := bw.WriteZeros(2); err != nil {
+			return 0, err
+		}
+	}
+
+	// Base, free-space info, end of file and driver info addresses
+	if err := bw.WriteOffset(sb.BaseAddress); err != nil {
+		return 0, err
+	}
+	if err := bw.WriteUndefinedOffset(); err != nil {
+		return 0, err
+	}
+	if err := bw.WriteOffset(sb.EOFAddress); err != nil {
+		return 0, err
+	}
+	if err := bw.WriteUndefinedOffset(); err != nil {
+		return 0, err
+	}
+
+	// Root group symbol table entry: link name offset, object header
+	// address, cache type, reserved and scratch-pad
+	if err := bw.WriteOffset(0); err != nil {
+		return 0, err
+	}
+	if err := bw.WriteOffset(sb.RootGroupAddress); err != nil {
+		return 0, err
+	}
+	cached := sb.RootGroupBTreeAddress != 0
+	cacheType := uint32(0)
+	if cached {
+		cacheType = 1
+	}
+	if err := bw.WriteUint32(cacheType); err != nil {
+		return 0, err
+	}
+	if err := bw.WriteZeros(4); err != nil {
+		return 0, err
+	}
+	if cached {
+		if err := bw.WriteOffset(sb.RootGroupBTreeAddress); err != nil {
+			return 0, err
+		}
+		if err := bw.WriteOffset(sb.RootGroupLocalHeapAddress); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := w.WriteBytes(buf); err != nil {
+		return 0, err
+	}
+	return int64(len(buf)), nil
+}
+
+// Size returns the size in bytes of the superblock in the format of its
+// version.
 func (sb *Superblock) Size() int {
+	if sb.Version == 0 || sb.Version == 1 {
+		// Fixed fields, indexed storage K for version 1, four addresses
+		// and the root group symbol table entry
+		size := 24 + 4*sb.offsetSize() + 2*sb.offsetSize() + 24
+		if sb.Version == 1 {
+			size += 4
+		}
+		return size
+	}
 	// Signature(8) + Version(1) + OffsetSize(1) + LengthSize(1) + Flags(1) +
 	// BaseAddr(O) + ExtAddr(O) + EOFAddr(O) + RootAddr(O) + Checksum(4)
-	offsetSize := int(sb.OffsetSize)
-	if offsetSize == 0 {
-		offsetSize = 8
+	return 12 + 4*sb.offsetSize() + 4
+}
+
+// offsetSize returns the size of file addresses, 8 bytes if unset.
+func (sb *Superblock) offsetSize() int {
+	if sb.OffsetSize == 0 {
+		return 8
 	}
-	return 12 + 4*offsetSize + 4
+	return int(sb.OffsetSize)
 }
 
 // NewSuperblock creates a new V3 superblock with default settings.
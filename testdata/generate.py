@@ -1,6 +1,11 @@
 #!/usr/bin/env python3
 """Generate HDF5 test files for go-hdf5 testing."""
 
+import os
+import shutil
+import sys
+import tempfile
+
 import numpy as np
 
 try:
@@ -9,14 +14,28 @@ except ImportError:
     print("h5py not installed. Install with: pip install h5py numpy")
     exit(1)
 
+# Files named on the command line are generated alone, leaving the other
+# checked-in fixtures as they are: python3 generate.py lzf.h5 zstd.h5
+only = set(sys.argv[1:])
+scratch = tempfile.mkdtemp()
+
+def fixture_path(name):
+    """Path to write fixture name to, a scratch file if it was not asked for."""
+    if only and name not in only:
+        return os.path.join(scratch, name)
+    return name
+
+# Random data is seeded so regenerated fixtures hold the same values
+np.random.seed(20240601)
+
 # Use latest file format to get Link messages instead of symbol tables
 # track_order ensures creation order is preserved
 def create_file(name, libver='latest'):
-    return h5py.File(name, 'w', libver=libver, track_order=True)
+    return h5py.File(fixture_path(name), 'w', libver=libver, track_order=True)
 
 def create_file_v0(name):
     """Create file with v0 superblock (earliest format)."""
-    return h5py.File(name, 'w', libver='earliest')
+    return h5py.File(fixture_path(name), 'w', libver='earliest')
 
 # Minimal test file - simplest possible HDF5 file
 with create_file('minimal.h5') as f:
@@ -193,6 +212,23 @@ with create_file('array_attrs.h5') as f:
     # 1D array of float64
     ds.attrs['vector'] = np.array([1.0, 2.0, 3.0], dtype=np.float64)
 
+# 2-D and 3-D attributes, in version 3 attribute messages and, in the v0
+# file, version 1 ones with their 8-byte padding
+for name, create in [('nd_attrs.h5', create_file), ('v0_nd_attrs.h5', create_file_v0)]:
+    with create(name) as f:
+        ds = f.create_dataset('data', data=np.array([1, 2, 3]))
+        ds.attrs['matrix'] = np.arange(6, dtype=np.float64).reshape(2, 3)
+        ds.attrs['cube'] = np.arange(24, dtype=np.int32).reshape(2, 3, 4)
+
+# Attributes with UTF-8 names, which h5py writes in version 3 attribute
+# messages, one of them of a committed datatype
+with create_file('utf8_attrs.h5') as f:
+    ds = f.create_dataset('data', data=np.array([1, 2, 3]))
+    ds.attrs['température'] = 21.5
+    ds.attrs['привет'] = np.int32(7)
+    f['celsius'] = np.dtype('<f4')
+    ds.attrs.create('温度', data=np.float32(-3.5), dtype=f['celsius'])
+
 # Soft links test file
 with create_file('softlink.h5') as f:
     # Direct target dataset
@@ -297,7 +333,7 @@ with create_file('mixed_chain.h5') as f:
 
 # B-tree v2 chunked dataset (force v2 with latest libver)
 # This creates a file that uses B-tree v2 for chunk indexing
-with h5py.File('btree_v2.h5', 'w', libver='latest') as f:
+with h5py.File(fixture_path('btree_v2.h5'), 'w', libver='latest') as f:
     # Create a chunked dataset that will use B-tree v2
     data = np.arange(10000).reshape(100, 100).astype(np.float64)
     f.create_dataset('chunked', data=data, chunks=(10, 10))
@@ -306,10 +342,118 @@ with h5py.File('btree_v2.h5', 'w', libver='latest') as f:
     f.create_dataset('small', data=small_data, chunks=(5, 5))
 
 # B-tree v2 with compression (type 11 - with filter info)
-with h5py.File('btree_v2_compressed.h5', 'w', libver='latest') as f:
+with h5py.File(fixture_path('btree_v2_compressed.h5'), 'w', libver='latest') as f:
     data = np.arange(10000).reshape(100, 100).astype(np.float64)
     f.create_dataset('compressed', data=data, chunks=(10, 10), compression='gzip', compression_opts=6)
 
+# SZIP filter (ID 4), in libhdf5 builds with libaec. Float32 values coded
+# with entropy coding (EC) and nearest neighbour (NN) preprocessing, next
+# to their uncompressed twin. Smooth, constant, small-stepped and noisy
+# rows leave the coder to choose each of its block options
+if h5py.h5z.filter_avail(h5py.h5z.FILTER_SZIP):
+    with create_file('szip.h5') as f:
+        data = (np.sin(np.arange(10000) / 50) * 1000).reshape(100, 100).astype(np.float32)
+        data[20:30] = 0
+        data[40:50] = np.arange(1000).reshape(10, 100) % 3
+        data[60:70] = np.random.rand(10, 100) * 1e30
+        f.create_dataset('float32', data=data, chunks=(10, 100))
+        f.create_dataset('szip_ec', data=data, chunks=(10, 100), compression='szip', compression_opts=('ec', 32))
+        f.create_dataset('szip_nn', data=data, chunks=(10, 100), compression='szip', compression_opts=('nn', 16))
+else:
+    print("libhdf5 was built without SZIP, skipping szip.h5")
+
+# Scale-offset filter (ID 6), next to uncompressed twins: integers in the
+# fewest bits libhdf5 finds, with fill elements coded as the all-ones
+# offset when there is a fill value, and floats D-scaled to a number of
+# decimal digits, float32 ones in single precision
+with create_file('scaleoffset.h5') as f:
+    ints = (np.arange(10000).reshape(100, 100) * 37 % 5000 - 1200).astype(np.int32)
+    f.create_dataset('int32', data=ints, chunks=(10, 10))
+    f.create_dataset('scaleoffset_int32', data=ints, chunks=(10, 10), scaleoffset=0)
+    holes = ints.copy()
+    holes[::7, ::3] = -9999
+    f.create_dataset('int32_fill', data=holes, chunks=(10, 10))
+    f.create_dataset('scaleoffset_int32_fill', data=holes, chunks=(10, 10), scaleoffset=0, fillvalue=-9999)
+    floats = np.sin(np.arange(10000) / 100).reshape(100, 100) * 50
+    f.create_dataset('float64', data=floats, chunks=(10, 10))
+    f.create_dataset('scaleoffset_float64', data=floats, chunks=(10, 10), scaleoffset=3)
+    f.create_dataset('float32', data=floats.astype(np.float32), chunks=(10, 10))
+    f.create_dataset('scaleoffset_float32', data=floats.astype(np.float32), chunks=(10, 10), scaleoffset=2)
+
+# N-bit filter (ID 5), which h5py sets only through its low-level API:
+# signed integers of fewer significant bits than their size, next to
+# uncompressed twins, so negative values need their sign bit extended
+def create_nbit(f, name, base, precision, data):
+    tid = base.copy()
+    tid.set_precision(precision)
+    dcpl = h5py.h5p.create(h5py.h5p.DATASET_CREATE)
+    dcpl.set_chunk((10, 10))
+    dcpl.set_nbit()
+    space = h5py.h5s.create_simple(data.shape)
+    dsid = h5py.h5d.create(f.id, name.encode(), tid, space, dcpl=dcpl)
+    dsid.write(h5py.h5s.ALL, h5py.h5s.ALL, data)
+
+with create_file('nbit.h5') as f:
+    int16s = (np.arange(10000).reshape(100, 100) * 13 % 4096 - 2048).astype(np.int16)
+    f.create_dataset('int16', data=int16s, chunks=(10, 10))
+    create_nbit(f, 'nbit_int16', h5py.h5t.STD_I16LE, 12, int16s)
+    int32s = (np.arange(10000).reshape(100, 100) * 997 % (1 << 20) - (1 << 19)).astype(np.int32)
+    f.create_dataset('int32', data=int32s, chunks=(10, 10))
+    create_nbit(f, 'nbit_int32', h5py.h5t.STD_I32LE, 20, int32s)
+
+# LZF filter (ID 32000), built into h5py. Random values do not shrink, so
+# their chunks are stored uncompressed with the filter skipped in the mask;
+# random holds the same values unfiltered
+with create_file('lzf.h5') as f:
+    data = (np.arange(10000).reshape(100, 100) / 7).astype(np.float64)
+    f.create_dataset('lzf', data=data, chunks=(10, 10), compression='lzf')
+    f.create_dataset('shuffle_lzf', data=data, chunks=(10, 10), compression='lzf', shuffle=True)
+    noise = np.random.rand(100, 100)
+    f.create_dataset('random', data=noise, chunks=(10, 10))
+    f.create_dataset('random_lzf', data=noise, chunks=(10, 10), compression='lzf')
+
+# Zstandard filter (ID 32015), written by the plugin hdf5plugin bundles
+try:
+    import hdf5plugin
+except ImportError:
+    hdf5plugin = None
+    print("hdf5plugin not installed, skipping zstd.h5. Install with: pip install hdf5plugin")
+if hdf5plugin is not None:
+    with create_file('zstd.h5') as f:
+        data = (np.arange(10000).reshape(100, 100) / 7).astype(np.float64)
+        f.create_dataset('zstd', data=data, chunks=(10, 10), **hdf5plugin.Zstd(clevel=9))
+        f.create_dataset('shuffle_zstd', data=data, chunks=(10, 10), shuffle=True, **hdf5plugin.Zstd())
+
+# Virtual dataset stacking four source datasets in two files along a new
+# first dimension, leaving its last row unmapped
+for i in range(2):
+    with create_file(f'vds_source_{i}.h5') as f:
+        f.create_dataset('data', data=np.arange(10, dtype=np.int32) + 20 * i)
+        f.create_dataset('more', data=np.arange(10, dtype=np.int32) + 20 * i + 10)
+with create_file('vds_stack.h5') as f:
+    vlayout = h5py.VirtualLayout(shape=(5, 10), dtype=np.int32)
+    for i in range(2):
+        vlayout[2 * i] = h5py.VirtualSource(f'vds_source_{i}.h5', 'data', shape=(10,))
+        vlayout[2 * i + 1] = h5py.VirtualSource(f'vds_source_{i}.h5', 'more', shape=(10,))
+    f.create_virtual_dataset('stack', vlayout, fillvalue=-1)
+
+# Virtual dataset of little-endian int32 rows over sources that libhdf5
+# converts: unsigned, big-endian and, last, of the same type
+with create_file('vds_source_types.h5') as f:
+    f.create_dataset('unsigned', data=np.array([1, 2, 3], dtype='<u4'))
+    f.create_dataset('big', data=np.array([4, 5, 6], dtype='>i4'))
+    f.create_dataset('same', data=np.array([7, 8, 9], dtype='<i4'))
+with create_file('vds_types.h5') as f:
+    vlayout = h5py.VirtualLayout(shape=(3, 3), dtype='<i4')
+    for i, name in enumerate(['unsigned', 'big', 'same']):
+        vlayout[i] = h5py.VirtualSource('vds_source_types.h5', name, shape=(3,))
+    f.create_virtual_dataset('stack', vlayout, fillvalue=-1)
+
+shutil.rmtree(scratch)
+if only:
+    print("Generated test files:", ", ".join(sorted(only)))
+    sys.exit(0)
+
 print("Generated test files:")
 print("  - minimal.h5")
 print("  - integers.h5")
@@ -329,6 +473,8 @@ print("  - v0_nested_attrs.h5 (v0 superblock with nested groups/datasets/attribu
 print("  - v0_deep_nested.h5 (v0 superblock with 5 levels of nesting)")
 print("  - compound_attrs.h5 (compound type attributes)")
 print("  - array_attrs.h5 (array type attributes)")
+print("  - nd_attrs.h5, v0_nd_attrs.h5 (2-D and 3-D attributes)")
+print("  - utf8_attrs.h5 (attributes with UTF-8 names)")
 print("  - softlink.h5 (soft links)")
 print("  - external_target.h5 (external link target)")
 print("  - external_source.h5 (external links)")
@@ -345,3 +491,12 @@ print("  - v1_softlinks.h5 (v1 format soft links)")
 print("  - mixed_chain.h5 (soft + external chain)")
 print("  - btree_v2.h5 (B-tree v2 chunked dataset)")
 print("  - btree_v2_compressed.h5 (B-tree v2 with compression)")
+print("  - szip.h5 (SZIP filter, needs libhdf5 with libaec)")
+print("  - scaleoffset.h5 (scale-offset filter)")
+print("  - nbit.h5 (N-bit filter)")
+print("  - lzf.h5 (LZF filter)")
+print("  - zstd.h5 (Zstandard filter, needs hdf5plugin)")
+print("  - vds_stack.h5 (virtual dataset over vds_source_0.h5 and vds_source_1.h5)")
+print("  - vds_types.h5 (virtual dataset over sources of other types in vds_source_types.h5)")
+print()
+print("Run 'python3 make_empty_groups.py' to derive the empty v1 group fixtures.")
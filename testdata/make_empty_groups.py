@@ -0,0 +1,54 @@
+#!/usr/bin/env python3
+"""
+Derive empty v1 group fixtures from v0_minimal.h5 by patching bytes.
+
+The HDF5 library never writes these shapes itself, but other writers do, so
+they are produced by editing the root group's symbol table structures:
+
+  - v0_empty_undefined_btree.h5: root B-tree address is undefined
+  - v0_empty_btree_node.h5:      root B-tree node has zero entries
+  - v0_empty_snod.h5:            root symbol table node has zero symbols
+
+Run from the testdata directory after generate.py.
+"""
+import struct
+
+UNDEFINED = b'\xff' * 8
+
+with open('v0_minimal.h5', 'rb') as f:
+    base = f.read()
+
+tree_addr = base.find(b'TREE')
+snod_addr = base.find(b'SNOD')
+assert tree_addr > 0 and snod_addr > 0
+
+# Undefined B-tree address in both the superblock scratch pad and the
+# root group's symbol table message (both precede the B-tree node).
+data = bytearray(base)
+packed = struct.pack('<Q', tree_addr)
+pos = data.find(packed)
+count = 0
+while 0 <= pos < tree_addr:
+    data[pos:pos + 8] = UNDEFINED
+    count += 1
+    pos = data.find(packed, pos + 8)
+assert count == 2, count
+with open('v0_empty_undefined_btree.h5', 'wb') as f:
+    f.write(data)
+
+# Zero "entries used" in the B-tree node (signature, type, level, entries).
+data = bytearray(base)
+data[tree_addr + 6:tree_addr + 8] = struct.pack('<H', 0)
+with open('v0_empty_btree_node.h5', 'wb') as f:
+    f.write(data)
+
+# Zero "number of symbols" in the SNOD (signature, version, reserved, count).
+data = bytearray(base)
+data[snod_addr + 6:snod_addr + 8] = struct.pack('<H', 0)
+with open('v0_empty_snod.h5', 'wb') as f:
+    f.write(data)
+
+print("Generated empty group fixtures:")
+print("  - v0_empty_undefined_btree.h5")
+print("  - v0_empty_btree_node.h5")
+print("  - v0_empty_snod.h5")